@@ -0,0 +1,73 @@
+// Command graphlint lints graph definitions in JSON or YAML, see
+// graph.GraphDefinition, and prints the problems found:
+//
+//	graphlint [-step-limit n] [-implicit-end] file...
+//
+// The format of a file is told by its extension, ".yaml" or ".yml" for YAML
+// and JSON otherwise. It exits with status 1 if a definition cannot be read
+// or has errors, warnings alone being printed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func main() {
+	stepLimit := flag.Int("step-limit", 0, "lint the graphs as compiled with this step limit")
+	implicitEnd := flag.Bool("implicit-end", false, "lint the graphs as compiled with implicit END for leaf nodes")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: graphlint [-step-limit n] [-implicit-end] file...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var opts []graph.CompileOption
+	if *stepLimit != 0 {
+		opts = append(opts, graph.WithStepLimit(*stepLimit))
+	}
+	if *implicitEnd {
+		opts = append(opts, graph.WithImplicitEnd())
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		findings, err := lintFile(path, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		for _, finding := range findings {
+			fmt.Printf("%s: %s\n", path, finding)
+			failed = failed || finding.Severity == graph.SeverityError
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// lintFile lints the graph definition in the file at path.
+func lintFile(path string, opts []graph.CompileOption) ([]graph.Finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lint := graph.LintJSON
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		lint = graph.LintYAML
+	}
+	return lint(f, opts...)
+}
@@ -0,0 +1,477 @@
+// Command langgraphgo runs, serves, draws and inspects graphs defined in JSON
+// or YAML, see graph.GraphDefinition, and generates new projects:
+//
+//	langgraphgo run [-plugin file.so] [-input json] [-debug] [-break node,...] definition
+//	langgraphgo serve [-plugin file.so] [-addr addr] [-graph-id id] [name[@version]=]definition...
+//	langgraphgo viz [-format mermaid|dot|ascii] definition
+//	langgraphgo threads [-url url] [-limit n] [thread_id]
+//	langgraphgo tracediff [-json] before.json after.json
+//	langgraphgo new [-module path] [-replace dir] dir
+//
+// The format of a definition is told by its extension, ".yaml" or ".yml" for
+// YAML and JSON otherwise. The state of the graphs is a JSON object, a
+// map[string]any, and their functions and routers are loaded from a Go
+// plugin exporting Registry, a *graph.NodeRegistry[map[string]any]:
+//
+//	var Registry = graph.NewNodeRegistry[map[string]any]()
+//
+//	func init() {
+//		Registry.RegisterFunction("agent", agent)
+//	}
+//
+// run -break pauses before the given nodes, reading commands from standard
+// input to print the state (p), set a key of it to a JSON value (set key
+// value), run the next node (s), continue (c) or abort the run (a).
+//
+// serve serves several graphs as the assistants of one server, their graph
+// IDs being the names of their definition files, or the names given before
+// the files, with the versions given after them:
+//
+//	langgraphgo serve support.yaml research@2=research-v2.yaml
+//
+// threads lists the threads of a graph served by serve, or shows the
+// checkpoints of one, latest first.
+//
+// tracediff compares two recorded runs, such as the golden files of
+// graphtest.Harness.Golden before and after a change: the nodes they ran,
+// their routing decisions and the states of their nodes. It exits with
+// status 1 if the runs differ.
+//
+// new generates a ReAct agent project in a new directory, see
+// scaffold.Generate: its state, graph, tools, a command chatting with it on
+// a checkpointed thread, and tests running it with a fake model.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"plugin"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+	"github.com/alberrttt/langgraphgo/graph/registry"
+	"github.com/alberrttt/langgraphgo/graph/scaffold"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+// State is the state of the graphs run by the command.
+type State = map[string]any
+
+const usage = `usage:
+	langgraphgo run [-plugin file.so] [-input json] [-debug] [-break node,...] definition
+	langgraphgo serve [-plugin file.so] [-addr addr] [-graph-id id] [name[@version]=]definition...
+	langgraphgo viz [-format mermaid|dot|ascii] definition
+	langgraphgo threads [-url url] [-limit n] [thread_id]
+	langgraphgo tracediff [-json] before.json after.json
+	langgraphgo new [-module path] [-replace dir] dir`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	commands := map[string]func(args []string) error{
+		"run":       run,
+		"serve":     serve,
+		"viz":       viz,
+		"threads":   threads,
+		"tracediff": tracediff,
+		"new":       newProject,
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := command(os.Args[2:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "langgraphgo %s: %v\n", os.Args[1], err)
+		}
+		os.Exit(1)
+	}
+}
+
+// parse parses the flags of a command taking nargs arguments, or at most
+// -nargs if nargs is negative.
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if nargs >= 0 && fs.NArg() != nargs || nargs < 0 && fs.NArg() > -nargs {
+		fs.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	pluginPath := fs.String("plugin", "", "load the functions and routers of the graph from this Go plugin")
+	input := fs.String("input", "{}", "the initial state, as a JSON object, or @file to read it from a file")
+	debug := fs.Bool("debug", false, "log the nodes to standard error")
+	breakpoints := fs.String("break", "", "pause before these comma-separated nodes to inspect and edit the state")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	data := []byte(*input)
+	if path, ok := strings.CutPrefix(*input, "@"); ok {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return err
+		}
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("decode input: %w", err)
+	}
+
+	var opts []graph.CompileOption
+	if *debug {
+		opts = append(opts, graph.WithDebug(os.Stderr))
+	}
+	runnable, err := load(fs.Arg(0), *pluginPath, opts...)
+	if err != nil {
+		return err
+	}
+	invoke := runnable.Invoke
+	if *breakpoints != "" {
+		invoke = graph.NewDebugger(runnable, prompt(bufio.NewScanner(os.Stdin)), strings.Split(*breakpoints, ",")...).Run
+	}
+	if err := invoke(context.Background(), &state); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(state)
+}
+
+// prompt returns a graph.Debugger break handler reading commands from in, see
+// run -break.
+func prompt(in *bufio.Scanner) func(context.Context, *graph.Breakpoint[State]) (graph.DebugAction, error) {
+	return func(_ context.Context, bp *graph.Breakpoint[State]) (graph.DebugAction, error) {
+		fmt.Fprintf(os.Stderr, "paused before %s at step %d\n", bp.Node, bp.Step)
+		for {
+			fmt.Fprint(os.Stderr, "(debug) ")
+			if !in.Scan() {
+				if err := in.Err(); err != nil {
+					return 0, err
+				}
+				return graph.DebugAbort, nil
+			}
+			command, arg, _ := strings.Cut(strings.TrimSpace(in.Text()), " ")
+			switch command {
+			case "c", "continue":
+				return graph.DebugContinue, nil
+			case "s", "step":
+				return graph.DebugStep, nil
+			case "a", "abort":
+				return graph.DebugAbort, nil
+			case "p", "print":
+				data, err := json.MarshalIndent(*bp.State, "", "  ")
+				if err != nil {
+					return 0, err
+				}
+				fmt.Fprintf(os.Stderr, "%s\nnext: %s\n", data, strings.Join(bp.Next, ", "))
+			case "set":
+				key, value, _ := strings.Cut(strings.TrimSpace(arg), " ")
+				var v any
+				if err := json.Unmarshal([]byte(value), &v); key == "" || err != nil {
+					fmt.Fprintln(os.Stderr, "usage: set key json")
+					continue
+				}
+				if *bp.State == nil {
+					*bp.State = State{}
+				}
+				(*bp.State)[key] = v
+			default:
+				fmt.Fprintln(os.Stderr, "commands: p(rint), set key json, s(tep), c(ontinue), a(bort)")
+			}
+		}
+	}
+}
+
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	pluginPath := fs.String("plugin", "", "load the functions and routers of the graphs from this Go plugin")
+	addr := fs.String("addr", "localhost:2024", "listen on this address")
+	graphID := fs.String("graph-id", "", "serve the graph under this ID, the name of the definition file by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || *graphID != "" && fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	reg := registry.New()
+	for _, arg := range fs.Args() {
+		name, version, path, err := parseServed(arg)
+		if err != nil {
+			return err
+		}
+		if *graphID != "" {
+			name = *graphID
+		}
+		runnable, err := load(path, *pluginPath, graph.WithCheckpointer[State](graph.NewMemorySaver[State]()))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := registry.Register(reg, name, version, runnable); err != nil {
+			return err
+		}
+	}
+	mux, err := reg.Mux()
+	if err != nil {
+		return err
+	}
+	for _, a := range mux.Assistants() {
+		fmt.Fprintf(os.Stderr, "serving graph %s version %d as assistant %s\n", a.GraphID, a.Version, a.AssistantID)
+	}
+	fmt.Fprintf(os.Stderr, "listening on http://%s\n", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+// parseServed parses a definition served by serve, [name[@version]=]path,
+// the name being the name of the file and the version 1 by default.
+func parseServed(arg string) (name string, version int, path string, err error) {
+	version = 1
+	spec, path, ok := strings.Cut(arg, "=")
+	if !ok {
+		path = arg
+		return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), version, path, nil
+	}
+	name, v, ok := strings.Cut(spec, "@")
+	if ok {
+		if version, err = strconv.Atoi(v); err != nil || version < 1 {
+			return "", 0, "", fmt.Errorf("invalid version %q of graph %s", v, name)
+		}
+	}
+	return name, version, path, nil
+}
+
+func viz(args []string) error {
+	fs := flag.NewFlagSet("viz", flag.ContinueOnError)
+	format := fs.String("format", "mermaid", "draw the graph in this format: mermaid, dot or ascii")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	def, err := readDefinition(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	// Drawings need no functions, so any definition is drawn with stubs.
+	registry := graph.NewNodeRegistry[State]()
+	for _, node := range def.Nodes {
+		name := node.Function
+		if name == "" {
+			name = node.Name
+		}
+		registry.RegisterFunction(name, func(context.Context, *State) error { return nil })
+	}
+	for _, edge := range def.ConditionalEdges {
+		registry.RegisterRouter(edge.Router, func(context.Context, *State) ([]string, error) { return nil, nil })
+	}
+	g, err := graph.BuildGraph(def, registry)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "mermaid":
+		fmt.Print(g.DrawMermaid())
+	case "dot":
+		fmt.Print(g.DrawDOT())
+	case "ascii":
+		fmt.Print(g.DrawASCII())
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return nil
+}
+
+func threads(args []string) error {
+	fs := flag.NewFlagSet("threads", flag.ContinueOnError)
+	url := fs.String("url", "http://localhost:2024", "the URL of the server")
+	limit := fs.Int("limit", 10, "show at most this many checkpoints of a thread")
+	if err := parse(fs, args, -1); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		var list []server.Thread
+		if err := post(*url+"/threads/search", map[string]any{}, &list); err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "THREAD\tSTATUS\tUPDATED")
+		for _, thread := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", thread.ThreadID, thread.Status, thread.UpdatedAt.Local().Format(time.DateTime))
+		}
+		return w.Flush()
+	}
+
+	var history []server.ThreadState[json.RawMessage]
+	if err := post(*url+"/threads/"+fs.Arg(0)+"/history", map[string]any{"limit": *limit}, &history); err != nil {
+		return err
+	}
+	for _, state := range history {
+		fmt.Printf("checkpoint %s\n", state.Checkpoint.CheckpointID)
+		fmt.Printf("step:    %v\n", state.Metadata["step"])
+		fmt.Printf("next:    %s\n", strings.Join(state.Next, ", "))
+		for _, task := range state.Tasks {
+			for _, in := range task.Interrupts {
+				fmt.Printf("interrupted %s %s: %v\n", in.When, task.Name, in.Value)
+			}
+		}
+		var values bytes.Buffer
+		if err := json.Indent(&values, state.Values, "", "  "); err != nil {
+			return err
+		}
+		fmt.Printf("values:  %s\n\n", values.String())
+	}
+	return nil
+}
+
+func tracediff(args []string) error {
+	fs := flag.NewFlagSet("tracediff", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "write the difference as JSON")
+	if err := parse(fs, args, 2); err != nil {
+		return err
+	}
+
+	d, err := graphtest.DiffRecordingFiles(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(d)
+	}
+	if d.Changed() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func newProject(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	module := fs.String("module", "", "the module path of the project, the name of the directory by default")
+	replace := fs.String("replace", "", "build the project against the langgraphgo checkout in this directory")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	dir := fs.Arg(0)
+	if err := scaffold.Generate(dir, scaffold.Config{Module: *module, Replace: *replace}); err != nil {
+		return err
+	}
+	fmt.Printf("created %s, now run:\n\tcd %s && go mod tidy && go test ./...\n", dir, dir)
+	return nil
+}
+
+// post posts body as JSON to url and decodes the response into out.
+func post(url string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var detail struct {
+			Detail string `json:"detail"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&detail)
+		return fmt.Errorf("%s: %s", resp.Status, detail.Detail)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// readDefinition reads the graph definition in the file at path.
+func readDefinition(path string) (graph.GraphDefinition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return graph.GraphDefinition{}, err
+	}
+	defer f.Close()
+	if isYAML(path) {
+		return graph.DecodeYAML(f)
+	}
+	return graph.DecodeJSON(f)
+}
+
+// load compiles the graph defined in the file at path with the functions and
+// routers of the plugin at pluginPath, if any, and the CompileOptions of the
+// definition and opts.
+func load(path, pluginPath string, opts ...graph.CompileOption) (*graph.Runnable[State], error) {
+	registry := graph.NewNodeRegistry[State]()
+	if pluginPath != "" {
+		var err error
+		if registry, err = loadRegistry(pluginPath); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	loadGraph := graph.LoadJSON[State]
+	if isYAML(path) {
+		loadGraph = graph.LoadYAML[State]
+	}
+	g, def, err := loadGraph(f, registry)
+	if err != nil {
+		return nil, err
+	}
+	return g.Compile(append(def.CompileOptions(), opts...)...)
+}
+
+// isYAML reports whether the file at path is in YAML.
+func isYAML(path string) bool {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// loadRegistry returns the Registry exported by the Go plugin at path.
+func loadRegistry(path string) (*graph.NodeRegistry[State], error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Registry")
+	if err != nil {
+		return nil, err
+	}
+	switch registry := sym.(type) {
+	case **graph.NodeRegistry[State]:
+		return *registry, nil
+	case *graph.NodeRegistry[State]:
+		return registry, nil
+	}
+	return nil, fmt.Errorf("plugin %s: Registry is a %T, not a *graph.NodeRegistry[map[string]any]", path, sym)
+}
@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSelfEdge is returned when an edge goes from a node to itself.
+var ErrSelfEdge = errors.New("edge from a node to itself")
+
+// Builder assembles a StateGraph with chained calls, recording the mistakes
+// of each call and reporting them all from Build. Unlike with StateGraph,
+// edges and the entry point may refer to nodes added later:
+//
+//	g, err := graph.NewBuilder[graph.MessageState]().
+//		AddNode("agent", callModel).
+//		AddNode("tools", callTools).
+//		AddConditionalEdges("agent", shouldContinue).
+//		AddEdge("tools", "agent").
+//		SetEntryPoint("agent").
+//		Build()
+type Builder[T any] struct {
+	graph *StateGraph[T]
+	errs  []error
+}
+
+// NewBuilder creates a Builder of an empty graph.
+func NewBuilder[T any]() *Builder[T] {
+	return &Builder[T]{graph: NewStateGraph[T]()}
+}
+
+// AddNode adds a node to the graph, see StateGraph.AddNode. It records
+// ErrEmptyName and ErrDuplicateNode, keeping the first node of a name.
+func (b *Builder[T]) AddNode(name string, fn func(ctx context.Context, state *T) error, opts ...NodeOption) *Builder[T] {
+	if _, err := b.graph.AddNode(name, fn, opts...); err != nil {
+		b.errs = append(b.errs, err)
+	}
+	return b
+}
+
+// AddEdge adds an edge to the graph, see StateGraph.AddEdge. It records
+// ErrEmptyName and ErrSelfEdge.
+func (b *Builder[T]) AddEdge(from, to string, opts ...EdgeOption) *Builder[T] {
+	switch {
+	case from == "" || to == "":
+		b.errs = append(b.errs, fmt.Errorf("%w: edge from %q to %q", ErrEmptyName, from, to))
+	case from == to:
+		b.errs = append(b.errs, fmt.Errorf("%w: %s", ErrSelfEdge, from))
+	default:
+		b.graph.addEdge(from, to, opts...)
+	}
+	return b
+}
+
+// AddConditionalEdges adds a conditional edge to the graph, see
+// StateGraph.AddConditionalEdges. It records ErrEmptyName.
+func (b *Builder[T]) AddConditionalEdges(
+	source string,
+	path func(ctx context.Context, state *T) ([]string, error),
+	options ...ConditionalEdgeOptions[T],
+) *Builder[T] {
+	if source == "" {
+		b.errs = append(b.errs, fmt.Errorf("%w: conditional edge source", ErrEmptyName))
+		return b
+	}
+	b.graph.AddConditionalEdges(source, path, options...)
+	return b
+}
+
+// SetEntryPoint sets the entry point of the graph, see
+// StateGraph.SetEntryPoint.
+func (b *Builder[T]) SetEntryPoint(name string) *Builder[T] {
+	b.graph.entryPoint = name
+	return b
+}
+
+// SetNamedEntryPoint sets a named entry point of the graph, see
+// StateGraph.SetNamedEntryPoint.
+func (b *Builder[T]) SetNamedEntryPoint(name, node string) *Builder[T] {
+	if name == "" {
+		b.errs = append(b.errs, fmt.Errorf("%w: entry point at %s", ErrEmptyName, node))
+		return b
+	}
+	b.graph.setNamedEntryPoint(name, node)
+	return b
+}
+
+// Build returns the assembled graph. It returns the mistakes of all the
+// calls to the builder and the problems found by StateGraph.Validate, joined
+// with errors.Join.
+func (b *Builder[T]) Build() (*StateGraph[T], error) {
+	if err := errors.Join(append(b.errs, b.graph.Validate())...); err != nil {
+		return nil, err
+	}
+	return b.graph, nil
+}
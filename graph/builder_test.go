@@ -0,0 +1,98 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	increment := func(_ context.Context, n *int) error {
+		*n++
+		return nil
+	}
+	g, err := graph.NewBuilder[int]().
+		AddNode("first", increment).
+		AddNode("second", increment).
+		AddEdge("first", "second").
+		AddEdge("second", graph.END).
+		SetEntryPoint("first").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2, but got %d", n)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]string, error) { return []string{graph.END}, nil }
+
+	testCases := []struct {
+		name     string
+		build    func(b *graph.Builder[int]) *graph.Builder[int]
+		wantErrs []error
+	}{
+		{
+			name: "Duplicate node",
+			build: func(b *graph.Builder[int]) *graph.Builder[int] {
+				return b.AddNode("agent", noop).AddNode("agent", noop).AddEdge("agent", graph.END)
+			},
+			wantErrs: []error{graph.ErrDuplicateNode},
+		},
+		{
+			name: "Empty names",
+			build: func(b *graph.Builder[int]) *graph.Builder[int] {
+				return b.AddNode("", noop).AddNode("agent", noop).
+					AddEdge("agent", "").AddConditionalEdges("", route).AddEdge("agent", graph.END)
+			},
+			wantErrs: []error{graph.ErrEmptyName},
+		},
+		{
+			name: "Self edge",
+			build: func(b *graph.Builder[int]) *graph.Builder[int] {
+				return b.AddNode("agent", noop).AddEdge("agent", "agent").AddEdge("agent", graph.END)
+			},
+			wantErrs: []error{graph.ErrSelfEdge},
+		},
+		{
+			name: "All mistakes reported",
+			build: func(b *graph.Builder[int]) *graph.Builder[int] {
+				return b.AddNode("agent", noop).AddNode("agent", noop).
+					AddNode("", noop).AddEdge("agent", "agent").AddEdge("ghost", graph.END)
+			},
+			wantErrs: []error{graph.ErrDuplicateNode, graph.ErrEmptyName, graph.ErrSelfEdge, graph.ErrNodeNotFound, graph.ErrNoPathToEnd},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g, err := tc.build(graph.NewBuilder[int]().SetEntryPoint("agent")).Build()
+			if g != nil {
+				t.Error("expected no graph")
+			}
+			for _, want := range tc.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("expected error %v, but got %v", want, err)
+				}
+			}
+		})
+	}
+}
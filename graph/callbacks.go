@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// CallbackHandler is notified of the runs of the nodes of a compiled graph,
+// such as to log or trace them, see WithCallbacks. Handlers must not modify
+// the state.
+type CallbackHandler[T any] interface {
+	// NodeStart is called before a node runs on state.
+	NodeStart(ctx context.Context, node string, state *T)
+
+	// NodeEnd is called after a node ran on state, with the error it
+	// returned, if any.
+	NodeEnd(ctx context.Context, node string, state *T, err error)
+}
+
+// EndHandler is implemented by CallbackHandlers to be notified when a branch
+// of a run ends, after a node routed to END or, with WithImplicitEnd, a node
+// without outgoing edges ran.
+type EndHandler[T any] interface {
+	// End is called with the node ending the branch and the state.
+	End(ctx context.Context, node string, state *T)
+}
+
+// notifyEnd notifies the EndHandlers among callbacks that the branch of node
+// ended.
+func notifyEnd[T any](ctx context.Context, callbacks []CallbackHandler[T], node string, state *T) {
+	for _, h := range callbacks {
+		if h, ok := h.(EndHandler[T]); ok {
+			h.End(ctx, node, state)
+		}
+	}
+}
+
+// debugHandler writes a line per node run to w, see WithDebug.
+type debugHandler[T any] struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (h *debugHandler[T]) NodeStart(_ context.Context, node string, _ *T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(h.w, "node %s: started\n", node)
+}
+
+func (h *debugHandler[T]) NodeEnd(_ context.Context, node string, state *T, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		fmt.Fprintf(h.w, "node %s: failed: %v\n", node, err)
+		return
+	}
+	fmt.Fprintf(h.w, "node %s: done, state: %+v\n", node, *state)
+}
+
+func (h *debugHandler[T]) End(_ context.Context, node string, _ *T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(h.w, "node %s: END\n", node)
+}
@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrChainKey is returned by the nodes of FromChain when the inputs of a
+// chain lack one of its input keys, or its outputs one of its output keys.
+var ErrChainKey = errors.New("missing chain key")
+
+// Chain is a chain of langchaingo, such as an LLMChain or a RetrievalQA. It
+// has the methods FromChain uses of chains.Chain, whose options are of type
+// O, chains.ChainCallOption, so that the chains of that package are used as
+// is.
+type Chain[O any] interface {
+	Call(ctx context.Context, inputs map[string]any, options ...O) (map[string]any, error)
+	GetInputKeys() []string
+	GetOutputKeys() []string
+}
+
+// FromChain returns a node function running chain on the state, so that
+// existing chains are steps of a graph without rewriting them. in maps the
+// state to the inputs of the chain and out stores its outputs in the state:
+//
+//	qa := chains.NewRetrievalQAFromLLM(llm, retriever)
+//	g.AddNode("answer", graph.FromChain[State, chains.ChainCallOption](qa,
+//		func(s *State) map[string]any {
+//			return map[string]any{"query": s.Question}
+//		},
+//		func(s *State, outputs map[string]any) error {
+//			s.Answer, _ = outputs["text"].(string)
+//			return nil
+//		}))
+//
+// The node calls chain directly rather than with chains.Call, so the memory
+// of the chain is not used, the state keeping the conversation instead.
+func FromChain[T, O any](chain Chain[O], in func(state *T) map[string]any, out func(state *T, outputs map[string]any) error) func(ctx context.Context, state *T) error {
+	return func(ctx context.Context, state *T) error {
+		inputs := in(state)
+		if err := hasKeys(inputs, chain.GetInputKeys(), "input"); err != nil {
+			return err
+		}
+		outputs, err := chain.Call(ctx, inputs)
+		if err != nil {
+			return err
+		}
+		if err := hasKeys(outputs, chain.GetOutputKeys(), "output"); err != nil {
+			return err
+		}
+		return out(state, outputs)
+	}
+}
+
+// hasKeys returns ErrChainKey if values lack one of keys.
+func hasKeys(values map[string]any, keys []string, kind string) error {
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			return fmt.Errorf("%w: %s %s", ErrChainKey, kind, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// callOption stands in for chains.ChainCallOption.
+type callOption func()
+
+// qaChain answers the query of its inputs, like a RetrievalQA.
+type qaChain struct {
+	outputKey string
+}
+
+func (c qaChain) Call(_ context.Context, inputs map[string]any, _ ...callOption) (map[string]any, error) {
+	if inputs["query"] == "fail" {
+		return nil, errors.New("chain failed")
+	}
+	return map[string]any{c.outputKey: fmt.Sprintf("answer to %v", inputs["query"])}, nil
+}
+
+func (qaChain) GetInputKeys() []string  { return []string{"query"} }
+func (qaChain) GetOutputKeys() []string { return []string{"text"} }
+
+type qaState struct {
+	Question string
+	Answer   string
+}
+
+func TestFromChain(t *testing.T) {
+	t.Parallel()
+
+	in := func(s *qaState) map[string]any {
+		if s.Question == "" {
+			return map[string]any{}
+		}
+		return map[string]any{"query": s.Question}
+	}
+	out := func(s *qaState, outputs map[string]any) error {
+		s.Answer, _ = outputs["text"].(string)
+		return nil
+	}
+	tests := []struct {
+		name     string
+		chain    qaChain
+		question string
+		want     string
+		wantErr  error
+	}{
+		{name: "Answer", chain: qaChain{outputKey: "text"}, question: "why", want: "answer to why"},
+		{name: "Missing input", chain: qaChain{outputKey: "text"}, wantErr: graph.ErrChainKey},
+		{name: "Missing output", chain: qaChain{outputKey: "result"}, question: "why", wantErr: graph.ErrChainKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := graph.NewStateGraph[qaState]()
+			g.AddNode("answer", graph.FromChain[qaState, callOption](tt.chain, in, out))
+			g.AddEdge("answer", graph.END)
+			g.SetEntryPoint("answer")
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			state := qaState{Question: tt.question}
+			if err := runnable.Invoke(context.Background(), &state); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tt.wantErr, err)
+			}
+			if state.Answer != tt.want {
+				t.Errorf("expected answer %q, but got %q", tt.want, state.Answer)
+			}
+		})
+	}
+
+	node := graph.FromChain[qaState, callOption](qaChain{outputKey: "text"}, in, out)
+	if err := node(context.Background(), &qaState{Question: "fail"}); err == nil || err.Error() != "chain failed" {
+		t.Errorf("expected the error of the chain, but got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault is the error of the faults injected by WithChaos.
+var ErrInjectedFault = errors.New("injected fault")
+
+// ChaosConfig configures the faults injected by WithChaos. Rates are
+// probabilities between 0 and 1.
+type ChaosConfig struct {
+	// NodeErrorRate is the rate of the attempts of nodes failing with
+	// ErrInjectedFault instead of running.
+	NodeErrorRate float64
+
+	// DelayRate is the rate of the attempts of nodes delayed before running,
+	// by a random duration up to MaxDelay. Delays count towards the timeout
+	// of the node, see WithTimeout.
+	DelayRate float64
+	MaxDelay  time.Duration
+
+	// CheckpointErrorRate is the rate of the checkpoints failing to be saved
+	// with ErrInjectedFault.
+	CheckpointErrorRate float64
+
+	// Nodes are the nodes faults are injected into, all by default.
+	Nodes []string
+
+	// Seed seeds the faults, so that runs of a graph fail the same way. When
+	// zero, faults are seeded by the time.
+	Seed int64
+}
+
+// WithChaos injects faults into the runs of the compiled graph: failing and
+// delaying the attempts of nodes and failing checkpoint writes, such as to
+// check that retry policies, fallbacks and recovery work before failures do
+// it in production. Compile returns ErrInvalidOption for rates out of
+// [0, 1] or a negative delay.
+func WithChaos(cfg ChaosConfig) CompileOption {
+	return func(c *compileConfig) {
+		c.chaos = &cfg
+	}
+}
+
+// chaos injects the faults of a ChaosConfig.
+type chaos struct {
+	cfg ChaosConfig
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newChaos(cfg ChaosConfig) (*chaos, error) {
+	for _, rate := range []float64{cfg.NodeErrorRate, cfg.DelayRate, cfg.CheckpointErrorRate} {
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("%w: chaos rate %v out of [0, 1]", ErrInvalidOption, rate)
+		}
+	}
+	if cfg.MaxDelay < 0 {
+		return nil, fmt.Errorf("%w: negative chaos delay %s", ErrInvalidOption, cfg.MaxDelay)
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &chaos{cfg: cfg, rand: rand.New(rand.NewSource(seed))}, nil
+}
+
+// roll reports whether a fault of the given rate happens.
+func (c *chaos) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64() < rate
+}
+
+// nodeFault delays the attempt of node on clock, if it is to be delayed, and
+// returns the error it fails with instead of running, if any.
+func (c *chaos) nodeFault(ctx context.Context, clock Clock, node string) error {
+	if len(c.cfg.Nodes) > 0 && !slices.Contains(c.cfg.Nodes, node) {
+		return nil
+	}
+	if c.cfg.MaxDelay > 0 && c.roll(c.cfg.DelayRate) {
+		c.mu.Lock()
+		delay := time.Duration(c.rand.Int63n(int64(c.cfg.MaxDelay) + 1))
+		c.mu.Unlock()
+		if err := sleep(ctx, clock, delay); err != nil {
+			return err
+		}
+	}
+	if c.roll(c.cfg.NodeErrorRate) {
+		return fmt.Errorf("%w: node %s", ErrInjectedFault, node)
+	}
+	return nil
+}
+
+// chaosCheckpointer fails the checkpoint writes of a checkpointer.
+type chaosCheckpointer[T any] struct {
+	Checkpointer[T]
+	chaos *chaos
+}
+
+func (c chaosCheckpointer[T]) Put(ctx context.Context, cp Checkpoint[T]) error {
+	if c.chaos.roll(c.chaos.cfg.CheckpointErrorRate) {
+		return fmt.Errorf("%w: checkpoint of thread %s", ErrInjectedFault, cp.ThreadID)
+	}
+	return c.Checkpointer.Put(ctx, cp)
+}
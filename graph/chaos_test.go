@@ -0,0 +1,99 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestChaos(t *testing.T) {
+	t.Parallel()
+
+	// build returns a graph of a flaky node, retried up to 10 times, then a
+	// stable node, counting their runs.
+	build := func(runs map[string]int, opts ...graph.NodeOption) *graph.StateGraph[int] {
+		g := graph.NewStateGraph[int]()
+		g.AddNode("flaky", func(_ context.Context, n *int) error {
+			runs["flaky"]++
+			*n++
+			return nil
+		}, append([]graph.NodeOption{graph.WithRetry(graph.RetryPolicy{MaxAttempts: 10, InitialInterval: time.Millisecond})}, opts...)...)
+		g.AddNode("stable", func(_ context.Context, n *int) error {
+			runs["stable"]++
+			*n++
+			return nil
+		})
+		g.AddEdge("flaky", "stable")
+		g.AddEdge("stable", graph.END)
+		g.SetEntryPoint("flaky")
+		return g
+	}
+
+	testCases := []struct {
+		name     string
+		chaos    graph.ChaosConfig
+		opts     []graph.NodeOption
+		thread   bool
+		wantErr  error
+		wantRuns map[string]int
+	}{
+		{
+			name:     "Retried node errors",
+			chaos:    graph.ChaosConfig{NodeErrorRate: 0.5, Nodes: []string{"flaky"}, Seed: 1},
+			wantRuns: map[string]int{"flaky": 1, "stable": 1},
+		},
+		{
+			name:     "Node errors",
+			chaos:    graph.ChaosConfig{NodeErrorRate: 1, Nodes: []string{"stable"}, Seed: 1},
+			wantErr:  graph.ErrInjectedFault,
+			wantRuns: map[string]int{"flaky": 1},
+		},
+		{
+			name:     "Delays past timeouts",
+			chaos:    graph.ChaosConfig{DelayRate: 1, MaxDelay: time.Hour, Nodes: []string{"flaky"}, Seed: 1},
+			opts:     []graph.NodeOption{graph.WithTimeout(time.Millisecond)},
+			wantErr:  context.DeadlineExceeded,
+			wantRuns: map[string]int{},
+		},
+		{
+			name:     "Checkpoint errors",
+			chaos:    graph.ChaosConfig{CheckpointErrorRate: 1, Seed: 1},
+			thread:   true,
+			wantErr:  graph.ErrInjectedFault,
+			wantRuns: map[string]int{"flaky": 1},
+		},
+		{
+			name:    "Invalid rate",
+			chaos:   graph.ChaosConfig{NodeErrorRate: 1.5},
+			wantErr: graph.ErrInvalidOption,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			runs := map[string]int{}
+			runnable, err := build(runs, tc.opts...).Compile(graph.WithChaos(tc.chaos), graph.WithCheckpointer[int](graph.NewMemorySaver[int]()))
+			if err != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+				}
+				return
+			}
+			var opts []graph.InvokeOption
+			if tc.thread {
+				opts = append(opts, graph.WithThreadID("t1"))
+			}
+			n := 0
+			if err := runnable.Invoke(context.Background(), &n, opts...); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if len(runs) != len(tc.wantRuns) || runs["flaky"] != tc.wantRuns["flaky"] || runs["stable"] != tc.wantRuns["stable"] {
+				t.Errorf("expected runs %v, but got %v", tc.wantRuns, runs)
+			}
+		})
+	}
+}
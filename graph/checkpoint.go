@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCheckpointNotFound is returned when a thread has no checkpoint.
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+	// ErrNoCheckpointer is returned when thread state is requested from a graph compiled without a checkpointer.
+	ErrNoCheckpointer = errors.New("no checkpointer configured")
+
+	// ErrCheckpointerType is returned when a checkpointer does not match the state type of the graph.
+	ErrCheckpointerType = errors.New("checkpointer does not match the graph state type")
+)
+
+// Checkpoint is a snapshot of the state of a thread, taken after a node ran.
+type Checkpoint[T any] struct {
+	// ID uniquely identifies the checkpoint.
+	ID string
+
+	// ThreadID is the thread the checkpoint belongs to.
+	ThreadID string
+
+	// Step is the number of nodes that ran in the invocation before the checkpoint was taken.
+	Step int
+
+	// Node is the name of the node that ran last.
+	Node string
+
+	// State is the state of the graph after Node ran.
+	State T
+
+	// Next lists the nodes that were scheduled to run after Node.
+	Next []string
+
+	// Interrupt is set when the run was interrupted by Node, which is then
+	// the first node of Next, or after Node ran (see WithInterruptAfter).
+	Interrupt *GraphInterrupt
+
+	// Error is the error the run failed with in the first node of Next, as
+	// recorded by executors running the nodes elsewhere, such as distributed
+	// workers. Invoke returns errors instead of checkpointing them.
+	Error string
+
+	// CreatedAt is the time the checkpoint was taken.
+	CreatedAt time.Time
+}
+
+// Checkpointer persists the checkpoints of threads.
+type Checkpointer[T any] interface {
+	// Put saves a checkpoint.
+	Put(ctx context.Context, cp Checkpoint[T]) error
+
+	// Get returns the latest checkpoint of a thread, or ErrCheckpointNotFound.
+	Get(ctx context.Context, threadID string) (Checkpoint[T], error)
+
+	// List returns all the checkpoints of a thread, oldest first.
+	List(ctx context.Context, threadID string) ([]Checkpoint[T], error)
+}
+
+// ThreadDeleter is implemented by checkpointers that can delete the
+// checkpoints of a thread, such as when a conversation is deleted.
+type ThreadDeleter interface {
+	// Delete deletes all the checkpoints of a thread. Deleting a thread
+	// without checkpoints is not an error.
+	Delete(ctx context.Context, threadID string) error
+}
+
+// Cloner is implemented by states that hold references, such as slices, and
+// must be copied deeply when a checkpoint is taken.
+type Cloner[T any] interface {
+	Clone() T
+}
+
+// cloneState returns a copy of state, using its Clone method if it has one.
+func cloneState[T any](state *T) T {
+	if c, ok := any(state).(Cloner[T]); ok {
+		return c.Clone()
+	}
+	return *state
+}
+
+// Forker is implemented by states that can be copied without copying what
+// they reference, the copies sharing it until they write to it through their
+// methods. The nodes of a fan-out run on forks of the state, see
+// WithMaxParallelism.
+type Forker[T any] interface {
+	Fork() T
+}
+
+// forkState returns a copy of state, using its Fork method if it has one, or
+// else as cloneState does.
+func forkState[T any](state *T) T {
+	if f, ok := any(state).(Forker[T]); ok {
+		return f.Fork()
+	}
+	return cloneState(state)
+}
+
+// MemorySaver is a Checkpointer that keeps checkpoints in memory.
+// It is safe for concurrent use.
+type MemorySaver[T any] struct {
+	mu      sync.RWMutex
+	threads map[string][]Checkpoint[T]
+}
+
+var (
+	_ Checkpointer[struct{}] = (*MemorySaver[struct{}])(nil)
+	_ BatchPutter[struct{}]  = (*MemorySaver[struct{}])(nil)
+	_ ThreadDeleter          = (*MemorySaver[struct{}])(nil)
+)
+
+// NewMemorySaver creates an empty MemorySaver.
+func NewMemorySaver[T any]() *MemorySaver[T] {
+	return &MemorySaver[T]{
+		threads: make(map[string][]Checkpoint[T]),
+	}
+}
+
+// Put implements Checkpointer.
+func (m *MemorySaver[T]) Put(_ context.Context, cp Checkpoint[T]) error {
+	cp = copyCheckpoint(cp)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.threads[cp.ThreadID] = append(m.threads[cp.ThreadID], cp)
+	return nil
+}
+
+// PutBatch implements BatchPutter.
+func (m *MemorySaver[T]) PutBatch(_ context.Context, cps []Checkpoint[T]) error {
+	copies := make([]Checkpoint[T], len(cps))
+	for i, cp := range cps {
+		copies[i] = copyCheckpoint(cp)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cp := range copies {
+		m.threads[cp.ThreadID] = append(m.threads[cp.ThreadID], cp)
+	}
+	return nil
+}
+
+// Get implements Checkpointer.
+func (m *MemorySaver[T]) Get(_ context.Context, threadID string) (Checkpoint[T], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	checkpoints := m.threads[threadID]
+	if len(checkpoints) == 0 {
+		return Checkpoint[T]{}, ErrCheckpointNotFound
+	}
+	return copyCheckpoint(checkpoints[len(checkpoints)-1]), nil
+}
+
+// List implements Checkpointer.
+func (m *MemorySaver[T]) List(_ context.Context, threadID string) ([]Checkpoint[T], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	checkpoints := make([]Checkpoint[T], 0, len(m.threads[threadID]))
+	for _, cp := range m.threads[threadID] {
+		checkpoints = append(checkpoints, copyCheckpoint(cp))
+	}
+	return checkpoints, nil
+}
+
+// Delete implements ThreadDeleter.
+func (m *MemorySaver[T]) Delete(_ context.Context, threadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.threads, threadID)
+	return nil
+}
+
+// copyCheckpoint returns a copy of cp that shares no memory with it.
+func copyCheckpoint[T any](cp Checkpoint[T]) Checkpoint[T] {
+	cp.State = cloneState(&cp.State)
+	cp.Next = append([]string(nil), cp.Next...)
+	if cp.Interrupt != nil {
+		interrupt := *cp.Interrupt
+		interrupt.Resumes = append([]any(nil), interrupt.Resumes...)
+		cp.Interrupt = &interrupt
+	}
+	return cp
+}
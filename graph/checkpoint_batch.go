@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchPutter is implemented by checkpointers saving several checkpoints at
+// once, such as in a single database transaction, for the runs of graphs
+// compiled with WithCheckpointBatching.
+type BatchPutter[T any] interface {
+	// PutBatch saves the checkpoints, in order.
+	PutBatch(ctx context.Context, cps []Checkpoint[T]) error
+}
+
+// batchCheckpointer holds the checkpoints of a run and saves them to the
+// checkpointer it wraps in batches, at most maxDelay after the first of a
+// batch was put and when the run returns, see WithCheckpointBatching.
+type batchCheckpointer[T any] struct {
+	Checkpointer[T]
+	ctx      context.Context
+	clock    Clock
+	maxDelay time.Duration
+
+	mu      sync.Mutex
+	pending []Checkpoint[T]
+	// stop stops the timer of the pending batch.
+	stop chan struct{}
+	// err is the error of a batch saved by the timer, returned by the next
+	// call.
+	err error
+}
+
+// newBatchCheckpointer returns a batchCheckpointer for the run of ctx,
+// whose context saves the batches.
+func newBatchCheckpointer[T any](ctx context.Context, cp Checkpointer[T], clock Clock, maxDelay time.Duration) *batchCheckpointer[T] {
+	return &batchCheckpointer[T]{Checkpointer: cp, ctx: context.WithoutCancel(ctx), clock: clock, maxDelay: maxDelay}
+}
+
+// Put holds cp until its batch is saved.
+func (b *batchCheckpointer[T]) Put(_ context.Context, cp Checkpoint[T]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.err; err != nil {
+		b.err = nil
+		return err
+	}
+	b.pending = append(b.pending, cp)
+	if len(b.pending) == 1 {
+		b.stop = make(chan struct{})
+		go b.wait(b.stop)
+	}
+	return nil
+}
+
+// wait saves the pending batch once it is due, unless stopped first.
+func (b *batchCheckpointer[T]) wait(stop chan struct{}) {
+	select {
+	case <-b.clock.After(b.maxDelay):
+	case <-stop:
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop == stop {
+		b.err = b.save()
+	}
+}
+
+// Get returns the latest pending checkpoint of the thread, if any.
+func (b *batchCheckpointer[T]) Get(ctx context.Context, threadID string) (Checkpoint[T], error) {
+	b.mu.Lock()
+	for i := len(b.pending) - 1; i >= 0; i-- {
+		if b.pending[i].ThreadID == threadID {
+			cp := b.pending[i]
+			b.mu.Unlock()
+			return cp, nil
+		}
+	}
+	b.mu.Unlock()
+	return b.Checkpointer.Get(ctx, threadID)
+}
+
+// List saves the pending batch before listing the checkpoints.
+func (b *batchCheckpointer[T]) List(ctx context.Context, threadID string) ([]Checkpoint[T], error) {
+	if err := b.flush(); err != nil {
+		return nil, err
+	}
+	return b.Checkpointer.List(ctx, threadID)
+}
+
+// flush saves the pending batch, returning the error of the batch saved by
+// the timer if it failed.
+func (b *batchCheckpointer[T]) flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.err; err != nil {
+		b.err = nil
+		return err
+	}
+	return b.save()
+}
+
+// save saves the pending batch and stops its timer. b.mu must be held.
+func (b *batchCheckpointer[T]) save() error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	close(b.stop)
+	b.stop = nil
+	batch := b.pending
+	b.pending = nil
+	if bp, ok := b.Checkpointer.(BatchPutter[T]); ok {
+		return bp.PutBatch(b.ctx, batch)
+	}
+	for _, cp := range batch {
+		if err := b.Checkpointer.Put(b.ctx, cp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
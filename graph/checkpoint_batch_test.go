@@ -0,0 +1,132 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+// batchSaver is a MemorySaver recording the sizes of the batches it saves.
+type batchSaver struct {
+	*graph.MemorySaver[int]
+	mu      sync.Mutex
+	batches []int
+}
+
+func (s *batchSaver) PutBatch(ctx context.Context, cps []graph.Checkpoint[int]) error {
+	s.mu.Lock()
+	s.batches = append(s.batches, len(cps))
+	s.mu.Unlock()
+	return s.MemorySaver.PutBatch(ctx, cps)
+}
+
+func (s *batchSaver) Batches() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.batches)
+}
+
+func TestCheckpointBatching(t *testing.T) {
+	t.Parallel()
+
+	// build returns a chain of the nodes a, b and c, b waiting for wait.
+	build := func(wait <-chan struct{}) *graph.StateGraph[int] {
+		g := graph.NewStateGraph[int]()
+		for _, name := range []string{"a", "b", "c"} {
+			g.AddNode(name, func(context.Context, *int) error {
+				if name == "b" && wait != nil {
+					<-wait
+				}
+				return nil
+			})
+		}
+		g.AddEdge("a", "b")
+		g.AddEdge("b", "c")
+		g.AddEdge("c", graph.END)
+		g.SetEntryPoint("a")
+		return g
+	}
+	ctx := context.Background()
+
+	t.Run("At the end", func(t *testing.T) {
+		t.Parallel()
+		saver := &batchSaver{MemorySaver: graph.NewMemorySaver[int]()}
+		runnable, err := build(nil).Compile(graph.WithCheckpointer[int](saver), graph.WithCheckpointBatching(time.Hour))
+		if err != nil {
+			t.Fatalf("unexpected compile error: %v", err)
+		}
+		n := 0
+		if err := runnable.Invoke(ctx, &n, graph.WithThreadID("thread")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := saver.Batches(); !slices.Equal(got, []int{3}) {
+			t.Errorf("expected a batch of 3 checkpoints, but got %v", got)
+		}
+	})
+
+	t.Run("After the delay", func(t *testing.T) {
+		t.Parallel()
+		saver := &batchSaver{MemorySaver: graph.NewMemorySaver[int]()}
+		clock := graphtest.NewFakeClock(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+		wait := make(chan struct{})
+		runnable, err := build(wait).Compile(graph.WithCheckpointer[int](saver), graph.WithCheckpointBatching(time.Second), graph.WithClock(clock))
+		if err != nil {
+			t.Fatalf("unexpected compile error: %v", err)
+		}
+		errc := make(chan error, 1)
+		go func() {
+			n := 0
+			errc <- runnable.Invoke(ctx, &n, graph.WithThreadID("thread"))
+		}()
+		// The checkpoint of a is held while b runs, until the delay passed.
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		for len(saver.Batches()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		close(wait)
+		if err := <-errc; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := saver.Batches(); !slices.Equal(got, []int{1, 2}) {
+			t.Errorf("expected batches of 1 and 2 checkpoints, but got %v", got)
+		}
+	})
+
+	t.Run("Interrupt", func(t *testing.T) {
+		t.Parallel()
+		saver := &batchSaver{MemorySaver: graph.NewMemorySaver[int]()}
+		runnable, err := build(nil).Compile(graph.WithCheckpointer[int](saver), graph.WithCheckpointBatching(time.Hour), graph.WithInterruptBefore("c"))
+		if err != nil {
+			t.Fatalf("unexpected compile error: %v", err)
+		}
+		n := 0
+		var gi *graph.GraphInterrupt
+		if err := runnable.Invoke(ctx, &n, graph.WithThreadID("thread")); !errors.As(err, &gi) {
+			t.Fatalf("expected an interrupt, but got %v", err)
+		}
+		cp, err := saver.Get(ctx, "thread")
+		if err != nil || cp.Interrupt == nil {
+			t.Fatalf("expected the interrupted checkpoint to be saved, but got %+v, %v", cp, err)
+		}
+		if err := runnable.Invoke(ctx, &n, graph.WithThreadID("thread"), graph.WithResume(nil)); err != nil {
+			t.Fatalf("unexpected resume error: %v", err)
+		}
+		if got := saver.Batches(); !slices.Equal(got, []int{3, 1}) {
+			t.Errorf("expected batches of 3 and 1 checkpoints, but got %v", got)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		if _, err := build(nil).Compile(graph.WithCheckpointBatching(time.Second)); !errors.Is(err, graph.ErrInvalidOption) {
+			t.Errorf("expected error %v without a checkpointer, but got %v", graph.ErrInvalidOption, err)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package graph
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FileSaver is a Checkpointer keeping checkpoints in files, a directory per
+// thread and a JSON file per checkpoint, written with WriteCheckpoint so
+// that long histories are streamed to disk rather than encoded in memory.
+// It is safe for concurrent use by the runs of a process.
+type FileSaver[T any] struct {
+	dir string
+
+	// mu serializes the puts, which number the checkpoints of a thread.
+	// next, guarded by mu, is the number of the next checkpoint of the
+	// threads put since the saver was created, so that puts do not list
+	// their directory.
+	mu   sync.Mutex
+	next map[string]int
+}
+
+var (
+	_ Checkpointer[struct{}] = (*FileSaver[struct{}])(nil)
+	_ ThreadDeleter          = (*FileSaver[struct{}])(nil)
+)
+
+// NewFileSaver returns a FileSaver keeping checkpoints in dir, creating it
+// if needed.
+func NewFileSaver[T any](dir string) (*FileSaver[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSaver[T]{dir: dir, next: make(map[string]int)}, nil
+}
+
+// threadDir returns the directory of the checkpoints of a thread, named
+// after its ID in lowercase hex, so that IDs such as ".." stay within the
+// directory of the saver and IDs differing only by case do not share a
+// directory on case-insensitive file systems.
+func (s *FileSaver[T]) threadDir(threadID string) string {
+	return filepath.Join(s.dir, "thread-"+hex.EncodeToString([]byte(threadID)))
+}
+
+// files returns the checkpoint files of a thread, oldest first.
+func (s *FileSaver[T]) files(threadID string) ([]string, error) {
+	entries, err := os.ReadDir(s.threadDir(threadID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(s.threadDir(threadID), e.Name()))
+		}
+	}
+	// The files are numbered with a fixed width, so their names sort in
+	// the order they were put.
+	slices.Sort(files)
+	return files, nil
+}
+
+// Put implements Checkpointer. The checkpoint is written to a temporary
+// file synced to disk and renamed once complete, and the directory is
+// synced after the rename, so that a crash neither leaves a partial
+// checkpoint behind nor loses one that was put.
+func (s *FileSaver[T]) Put(_ context.Context, cp Checkpoint[T]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.threadDir(cp.ThreadID)
+	n, ok := s.next[cp.ThreadID]
+	if !ok {
+		// The first put of the thread by this saver numbers its
+		// checkpoints after those of previous savers.
+		files, err := s.files(cp.ThreadID)
+		if err != nil {
+			return err
+		}
+		n = len(files)
+		if n == 0 {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			if err := syncDir(s.dir); err != nil {
+				return err
+			}
+		}
+	}
+	f, err := os.CreateTemp(dir, "put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	w := bufio.NewWriter(f)
+	if err := WriteCheckpoint(w, cp); err != nil {
+		f.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), filepath.Join(dir, fmt.Sprintf("%09d.json", n))); err != nil {
+		return err
+	}
+	s.next[cp.ThreadID] = n + 1
+	return syncDir(dir)
+}
+
+// syncDir syncs the entries of the named directory to disk.
+func syncDir(name string) error {
+	d, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Get implements Checkpointer.
+func (s *FileSaver[T]) Get(_ context.Context, threadID string) (Checkpoint[T], error) {
+	files, err := s.files(threadID)
+	if err != nil {
+		return Checkpoint[T]{}, err
+	}
+	if len(files) == 0 {
+		return Checkpoint[T]{}, ErrCheckpointNotFound
+	}
+	return readCheckpointFile[T](files[len(files)-1])
+}
+
+// List implements Checkpointer.
+func (s *FileSaver[T]) List(_ context.Context, threadID string) ([]Checkpoint[T], error) {
+	files, err := s.files(threadID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]Checkpoint[T], 0, len(files))
+	for _, name := range files {
+		cp, err := readCheckpointFile[T](name)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, nil
+}
+
+// Delete implements ThreadDeleter.
+func (s *FileSaver[T]) Delete(_ context.Context, threadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.next, threadID)
+	return os.RemoveAll(s.threadDir(threadID))
+}
+
+// readCheckpointFile reads the checkpoint of the named file.
+func readCheckpointFile[T any](name string) (Checkpoint[T], error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Checkpoint[T]{}, err
+	}
+	defer f.Close()
+	cp, err := ReadCheckpoint[T](bufio.NewReader(f))
+	if err != nil {
+		return Checkpoint[T]{}, fmt.Errorf("read checkpoint %s: %w", name, err)
+	}
+	return cp, nil
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// checkpointEnvelope is a checkpoint without its state, encoded as the
+// fields of Checkpoint are by encoding/json.
+type checkpointEnvelope struct {
+	ID        string
+	ThreadID  string
+	Step      int
+	Node      string
+	Next      []string
+	Interrupt *GraphInterrupt
+	Error     string
+	CreatedAt time.Time
+}
+
+// stateStreamer is implemented by states written to checkpoints piece by
+// piece rather than encoded whole, see WriteCheckpoint.
+type stateStreamer interface {
+	// writeJSON writes the state to w encoded as JSON, as json.Marshal does.
+	writeJSON(w io.Writer) error
+}
+
+// WriteCheckpoint writes cp to w encoded as JSON, as json.Marshal encodes
+// it, writing the encoding as it goes rather than building it whole: the
+// messages of a MessageState state are written one by one, other states
+// with a json.Encoder, so that saving threads with long histories does not
+// allocate the size of the history on every step. Buffer w, such as with a
+// bufio.Writer, if it is costly to write to.
+func WriteCheckpoint[T any](w io.Writer, cp Checkpoint[T]) error {
+	envelope, err := json.Marshal(checkpointEnvelope{
+		ID:        cp.ID,
+		ThreadID:  cp.ThreadID,
+		Step:      cp.Step,
+		Node:      cp.Node,
+		Next:      cp.Next,
+		Interrupt: cp.Interrupt,
+		Error:     cp.Error,
+		CreatedAt: cp.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	// The state is written last, as a field of the envelope.
+	envelope = append(envelope[:len(envelope)-1], `,"State":`...)
+	if _, err := w.Write(envelope); err != nil {
+		return err
+	}
+	if s, ok := any(&cp.State).(stateStreamer); ok {
+		err = s.writeJSON(w)
+	} else {
+		err = json.NewEncoder(w).Encode(cp.State)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "}")
+	return err
+}
+
+// ReadCheckpoint reads a checkpoint written by WriteCheckpoint, or encoded
+// by json.Marshal, from r.
+func ReadCheckpoint[T any](r io.Reader) (Checkpoint[T], error) {
+	var cp Checkpoint[T]
+	if err := json.NewDecoder(r).Decode(&cp); err != nil {
+		return Checkpoint[T]{}, err
+	}
+	return cp, nil
+}
+
+// writeJSON implements stateStreamer, writing the messages one by one.
+func (s *MessageState) writeJSON(w io.Writer) error {
+	if _, err := io.WriteString(w, `{"messages":[`); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for i, msg := range s.Messages {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := msg.encode(enc); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]}")
+	return err
+}
@@ -0,0 +1,198 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// maxWriter records the size of the largest write.
+type maxWriter struct {
+	bytes.Buffer
+	max int
+}
+
+func (w *maxWriter) Write(p []byte) (int, error) {
+	w.max = max(w.max, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestWriteCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	for i := range 100 {
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, strings.Repeat(fmt.Sprint(i), 100)))
+	}
+	cp := graph.Checkpoint[graph.MessageState]{
+		ID:        "cp",
+		ThreadID:  "thread",
+		Step:      3,
+		Node:      "agent",
+		State:     state,
+		Next:      []string{"tools"},
+		Interrupt: &graph.GraphInterrupt{Node: "tools", Before: true},
+		CreatedAt: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+	}
+
+	var w maxWriter
+	if err := graph.WriteCheckpoint(&w, cp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !json.Valid(w.Bytes()) {
+		t.Fatalf("expected JSON, but got %s", w.Bytes())
+	}
+	if w.max >= w.Len()/10 {
+		t.Errorf("expected the messages to be written one by one, but wrote %d of %d bytes at once", w.max, w.Len())
+	}
+	got, err := graph.ReadCheckpoint[graph.MessageState](&w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want graph.Checkpoint[graph.MessageState]
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected checkpoint %+v, but got %+v", want, got)
+	}
+
+	// States other than MessageState are encoded whole.
+	var b bytes.Buffer
+	if err := graph.WriteCheckpoint(&b, graph.Checkpoint[map[string]int]{ID: "cp", State: map[string]int{"n": 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counter, err := graph.ReadCheckpoint[map[string]int](&b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counter.ID != "cp" || counter.State["n"] != 1 {
+		t.Errorf("expected the checkpoint back, but got %+v", counter)
+	}
+}
+
+func TestFileSaver(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	for _, name := range []string{"first", "second"} {
+		g.AddNode(name, func(_ context.Context, state *graph.MessageState) error {
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, name))
+			return nil
+		})
+	}
+	g.SetEntryPoint("first")
+	g.AddEdge("first", "second")
+	g.AddEdge("second", graph.END)
+
+	dir := t.TempDir()
+	saver, err := graph.NewFileSaver[graph.MessageState](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runnable, err := g.Compile(graph.WithCheckpointer(saver))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := saver.Get(ctx, "../thread"); !errors.Is(err, graph.ErrCheckpointNotFound) {
+		t.Fatalf("expected error %v, but got %v", graph.ErrCheckpointNotFound, err)
+	}
+	state := graph.NewMessageState()
+	if err := runnable.Invoke(ctx, &state, graph.WithThreadID("../thread")); err != nil {
+		t.Fatalf("unexpected invoke error: %v", err)
+	}
+	cps, err := saver.List(ctx, "../thread")
+	if err != nil {
+		t.Fatalf("unexpected list error: %v", err)
+	}
+	if len(cps) != 2 || cps[0].Node != "first" || cps[1].Node != "second" {
+		t.Fatalf("expected the checkpoints of first and second, but got %+v", cps)
+	}
+	latest, err := runnable.GetState(ctx, "../thread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := latest.State.Contents(); !reflect.DeepEqual(got, state.Contents()) {
+		t.Errorf("expected state %v, but got %v", state.Contents(), got)
+	}
+
+	// A saver of the same directory, such as after a restart, puts its
+	// checkpoints after the others.
+	next, err := graph.NewFileSaver[graph.MessageState](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range []string{"third", "fourth"} {
+		if err := next.Put(ctx, graph.Checkpoint[graph.MessageState]{ThreadID: "../thread", Node: node}); err != nil {
+			t.Fatalf("unexpected put error: %v", err)
+		}
+	}
+	if cps, err := next.List(ctx, "../thread"); err != nil || len(cps) != 4 || cps[2].Node != "third" || cps[3].Node != "fourth" {
+		t.Errorf("expected the checkpoints of the next saver last, but got %+v, %v", cps, err)
+	}
+
+	if err := next.Delete(ctx, "../thread"); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if _, err := next.Get(ctx, "../thread"); !errors.Is(err, graph.ErrCheckpointNotFound) {
+		t.Errorf("expected error %v after delete, but got %v", graph.ErrCheckpointNotFound, err)
+	}
+	if err := next.Put(ctx, graph.Checkpoint[graph.MessageState]{ThreadID: "../thread", Node: "again"}); err != nil {
+		t.Fatalf("unexpected put error: %v", err)
+	}
+	if cps, err := next.List(ctx, "../thread"); err != nil || len(cps) != 1 || cps[0].Node != "again" {
+		t.Errorf("expected a new history after delete, but got %+v, %v", cps, err)
+	}
+}
+
+func TestFileSaverCase(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	saver, err := graph.NewFileSaver[graph.MessageState](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	threads := []string{"Thread", "thread"}
+	for _, id := range threads {
+		if err := saver.Put(ctx, graph.Checkpoint[graph.MessageState]{ThreadID: id, Node: id}); err != nil {
+			t.Fatalf("unexpected put error: %v", err)
+		}
+	}
+	for _, id := range threads {
+		if cps, err := saver.List(ctx, id); err != nil || len(cps) != 1 || cps[0].Node != id {
+			t.Errorf("expected the checkpoint of thread %s, but got %+v, %v", id, cps, err)
+		}
+	}
+
+	// The directories of the threads differ on case-insensitive file
+	// systems too.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, e := range entries {
+		names[strings.ToLower(e.Name())] = true
+	}
+	if len(names) != len(threads) {
+		t.Errorf("expected %d thread directories regardless of case, but got %v", len(threads), entries)
+	}
+}
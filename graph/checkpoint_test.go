@@ -0,0 +1,108 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestCheckpointing(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	for _, name := range []string{"first", "second"} {
+		g.AddNode(name, func(_ context.Context, state *graph.MessageState) error {
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, name))
+			return nil
+		})
+	}
+	g.SetEntryPoint("first")
+	g.AddEdge("first", "second")
+	g.AddEdge("second", graph.END)
+
+	saver := graph.NewMemorySaver[graph.MessageState]()
+	runnable, err := g.Compile(graph.WithCheckpointer(saver))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := runnable.GetState(ctx, "thread"); !errors.Is(err, graph.ErrCheckpointNotFound) {
+		t.Fatalf("expected error %v, but got %v", graph.ErrCheckpointNotFound, err)
+	}
+
+	// Invocations without a thread are not checkpointed.
+	state := graph.NewMessageState()
+	if err := runnable.Invoke(ctx, &state); err != nil {
+		t.Fatalf("unexpected invoke error: %v", err)
+	}
+	if cps, _ := saver.List(ctx, ""); len(cps) != 0 {
+		t.Fatalf("expected no checkpoint, but got %d", len(cps))
+	}
+
+	state = graph.NewMessageState()
+	if err := runnable.Invoke(ctx, &state, graph.WithThreadID("thread")); err != nil {
+		t.Fatalf("unexpected invoke error: %v", err)
+	}
+	cps, err := saver.List(ctx, "thread")
+	if err != nil {
+		t.Fatalf("unexpected list error: %v", err)
+	}
+	if len(cps) != 2 {
+		t.Fatalf("expected 2 checkpoints, but got %d", len(cps))
+	}
+	wantNext := [][]string{{"second"}, nil}
+	for i, cp := range cps {
+		if cp.Step != i+1 || cp.Node != []string{"first", "second"}[i] || cp.ThreadID != "thread" || cp.ID == "" {
+			t.Errorf("unexpected checkpoint %d: %+v", i, cp)
+		}
+		if !slices.Equal(cp.Next, wantNext[i]) {
+			t.Errorf("expected checkpoint %d to have next %v, but got %v", i, wantNext[i], cp.Next)
+		}
+		if len(cp.State.Messages) != i+1 {
+			t.Errorf("expected checkpoint %d to hold %d messages, but got %d", i, i+1, len(cp.State.Messages))
+		}
+	}
+
+	// Checkpoints are snapshots: later changes to the state do not leak in.
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "later"))
+	latest, err := runnable.GetState(ctx, "thread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latest.ID != cps[1].ID || len(latest.State.Messages) != 2 {
+		t.Errorf("unexpected latest checkpoint: %+v", latest)
+	}
+
+	if err := saver.Delete(ctx, "thread"); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if _, err := runnable.GetState(ctx, "thread"); !errors.Is(err, graph.ErrCheckpointNotFound) {
+		t.Errorf("expected error %v after delete, but got %v", graph.ErrCheckpointNotFound, err)
+	}
+}
+
+func TestCheckpointerErrors(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("node", func(context.Context, *graph.MessageState) error { return nil })
+	g.SetEntryPoint("node")
+	g.AddEdge("node", graph.END)
+
+	if _, err := g.Compile(graph.WithCheckpointer(graph.NewMemorySaver[int]())); !errors.Is(err, graph.ErrCheckpointerType) {
+		t.Errorf("expected error %v, but got %v", graph.ErrCheckpointerType, err)
+	}
+
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	if _, err := runnable.GetState(context.Background(), "thread"); !errors.Is(err, graph.ErrNoCheckpointer) {
+		t.Errorf("expected error %v, but got %v", graph.ErrNoCheckpointer, err)
+	}
+}
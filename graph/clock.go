@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time to a compiled graph: the delays between retries, the
+// timeouts of nodes, the expiry of cached results and the times of
+// checkpoints, see WithClock. Tests use fake clocks, such as
+// graphtest.FakeClock, to advance the time without waiting.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel receiving the time once d elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock returns the clock of the system, the default of WithClock.
+func SystemClock() Clock {
+	return realClock{}
+}
+
+// WithClock makes the compiled graph tell the time with clock rather than
+// the system clock.
+func WithClock(clock Clock) CompileOption {
+	return func(c *compileConfig) {
+		c.clock = clock
+	}
+}
+
+// sleep waits for d to elapse on clock, returning the error of ctx if it is
+// done first.
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	if _, ok := clock.(realClock); ok {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	select {
+	case <-clock.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withTimeout returns a copy of ctx canceled once timeout elapsed on clock,
+// its error then being context.DeadlineExceeded, as with context.WithTimeout.
+func withTimeout(ctx context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	deadline := clock.Now().Add(timeout)
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-clock.After(timeout):
+			cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+		}
+	}()
+	return &clockContext{Context: ctx, deadline: deadline}, func() { cancel(context.Canceled) }
+}
+
+// clockContext is a context with a deadline on a Clock, see withTimeout.
+type clockContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *clockContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *clockContext) Err() error {
+	if err := c.Context.Err(); err != nil && context.Cause(c.Context) == context.DeadlineExceeded {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// SummaryPrefix starts the text of the summary message maintained by Compact.
+const SummaryPrefix = "Summary of the earlier conversation:\n"
+
+// ErrNoSummary is returned when the model returns no summary.
+var ErrNoSummary = errors.New("model returned no summary")
+
+const compactPrompt = `Summarize the conversation below in a few sentences, keeping the facts, names and decisions that may matter later.
+If a previous summary is given, extend it with the new messages.`
+
+// Compact summarizes the messages older than the threshold most recent ones
+// into a single system message, placed after the leading system prompt if
+// any, and returns the summary. An existing summary message is extended
+// rather than duplicated. It returns "" and leaves the state untouched if
+// there is nothing to compact.
+//
+// The kept messages start on a human message, so that they never open with a
+// reply or a tool result; the messages skipped to get there are summarized.
+func (s *MessageState) Compact(ctx context.Context, model llms.Model, threshold int) (string, error) {
+	msgs := s.Messages
+	var head []Message
+	if len(msgs) > 0 && msgs[0].Role == llms.ChatMessageTypeSystem && !isSummary(msgs[0]) {
+		head, msgs = msgs[:1], msgs[1:]
+	}
+	previous := Message{}
+	if len(msgs) > 0 && isSummary(msgs[0]) {
+		previous, msgs = msgs[0], msgs[1:]
+	}
+	if len(msgs) <= threshold {
+		return "", nil
+	}
+
+	cut := len(msgs) - max(threshold, 0)
+	for cut < len(msgs) && msgs[cut].Role != llms.ChatMessageTypeHuman {
+		cut++
+	}
+	older, kept := msgs[:cut], msgs[cut:]
+
+	summary, err := summarize(ctx, model, strings.TrimPrefix(textOf(previous.MessageContent), SummaryPrefix), older)
+	if err != nil {
+		return "", err
+	}
+	summaryMessage := NewMessage(llms.TextParts(llms.ChatMessageTypeSystem, SummaryPrefix+summary))
+	if previous.ID != "" {
+		summaryMessage.ID = previous.ID
+	}
+
+	compacted := make([]Message, 0, len(head)+1+len(kept))
+	compacted = append(compacted, head...)
+	compacted = append(compacted, s.redact(summaryMessage))
+	s.Messages = append(compacted, kept...)
+	return summary, nil
+}
+
+// Summary returns the summary maintained by Compact, or "" if there is none.
+func (s *MessageState) Summary() string {
+	for _, msg := range s.Messages[:min(2, len(s.Messages))] {
+		if isSummary(msg) {
+			return strings.TrimPrefix(textOf(msg.MessageContent), SummaryPrefix)
+		}
+	}
+	return ""
+}
+
+func summarize(ctx context.Context, model llms.Model, previous string, msgs []Message) (string, error) {
+	var sb strings.Builder
+	if previous != "" {
+		fmt.Fprintf(&sb, "Previous summary: %s\n\n", previous)
+	}
+	for _, msg := range msgs {
+		fmt.Fprintf(&sb, "%s: %s\n", msg.Role, textOf(msg.MessageContent))
+	}
+
+	resp, err := model.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, compactPrompt),
+		llms.TextParts(llms.ChatMessageTypeHuman, sb.String()),
+	}, llms.WithTemperature(0.0))
+	if err != nil {
+		return "", fmt.Errorf("summarize messages: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrNoSummary
+	}
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+func isSummary(msg Message) bool {
+	return msg.Role == llms.ChatMessageTypeSystem && strings.HasPrefix(textOf(msg.MessageContent), SummaryPrefix)
+}
+
+// textOf concatenates the text parts of a message.
+func textOf(msg llms.MessageContent) string {
+	var sb strings.Builder
+	for _, part := range msg.Parts {
+		if text, ok := part.(llms.TextContent); ok {
+			sb.WriteString(text.Text)
+		}
+	}
+	return sb.String()
+}
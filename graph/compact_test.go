@@ -0,0 +1,81 @@
+package graph_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// summarizingModel answers with a "summary" echoing the words of the last
+// message, and records the messages it was given.
+type summarizingModel struct {
+	prompts []string
+}
+
+func (m *summarizingModel) GenerateContent(_ context.Context, messages []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	prompt := messages[len(messages)-1].Parts[0].(llms.TextContent).Text
+	m.prompts = append(m.prompts, prompt)
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: " summary of " + strings.Join(strings.Fields(prompt), " ") + " "}}}, nil
+}
+
+func (m *summarizingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeSystem, "prompt"))
+	for _, text := range []string{"h1", "a1", "h2", "a2", "h3"} {
+		role := llms.ChatMessageTypeHuman
+		if text[0] == 'a' {
+			role = llms.ChatMessageTypeAI
+		}
+		state.AddMessage(llms.TextParts(role, text))
+	}
+
+	model := &summarizingModel{}
+	ctx := context.Background()
+	if summary, err := state.Compact(ctx, model, 5); err != nil || summary != "" {
+		t.Fatalf("expected nothing to compact, but got %q, %v", summary, err)
+	}
+
+	summary, err := state.Compact(ctx, model, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Keeping 2 messages would start on a2, so it is summarized too.
+	if want := "summary of human: h1 ai: a1 human: h2 ai: a2"; summary != want {
+		t.Errorf("expected summary %q, but got %q", want, summary)
+	}
+	want := []string{"prompt", graph.SummaryPrefix + summary, "h3"}
+	if got := texts(state); !slices.Equal(got, want) {
+		t.Errorf("expected messages %q, but got %q", want, got)
+	}
+	if state.Summary() != summary {
+		t.Errorf("expected Summary to return %q, but got %q", summary, state.Summary())
+	}
+	summaryID := state.Messages[1].ID
+
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "a3"))
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "h4"))
+	summary, err = state.Compact(ctx, model, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(model.prompts[1], "Previous summary: summary of human: h1") {
+		t.Errorf("expected the previous summary to be extended, but got prompt %q", model.prompts[1])
+	}
+	want = []string{"prompt", graph.SummaryPrefix + summary, "h4"}
+	if got := texts(state); !slices.Equal(got, want) {
+		t.Errorf("expected messages %q, but got %q", want, got)
+	}
+	if state.Messages[1].ID != summaryID {
+		t.Errorf("expected the summary message to keep ID %q, but got %q", summaryID, state.Messages[1].ID)
+	}
+}
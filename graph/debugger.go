@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrDebugAborted is returned by Debugger.Run when the run is aborted at a
+// breakpoint.
+var ErrDebugAborted = errors.New("run aborted by debugger")
+
+// DebugAction tells a Debugger how to go on from a breakpoint.
+type DebugAction int
+
+const (
+	// DebugContinue runs the graph up to the next breakpoint.
+	DebugContinue DebugAction = iota
+
+	// DebugStep runs the next node and pauses again before the node after
+	// it, whether it is a breakpoint or not.
+	DebugStep
+
+	// DebugAbort stops the run, Run returning ErrDebugAborted.
+	DebugAbort
+)
+
+// Breakpoint is a pause of a Debugger before a node runs.
+type Breakpoint[T any] struct {
+	// Node is the node about to run.
+	Node string
+
+	// Step is the number of nodes run so far.
+	Step int
+
+	// State is the state Node is about to run with. It can be edited, the
+	// run going on with the edited state.
+	State *T
+
+	// Next are the nodes scheduled after Node.
+	Next []string
+}
+
+// Debugger runs a compiled graph one node at a time, pausing before the
+// nodes set as breakpoints to let OnBreak inspect and edit the state, then
+// continue, step or abort the run, such as from a test or a terminal.
+type Debugger[T any] struct {
+	// Runnable is the graph debugged.
+	Runnable *Runnable[T]
+
+	// Breakpoints are the nodes to pause before.
+	Breakpoints []string
+
+	// OnBreak is called at every pause. It returns how to go on, or an
+	// error stopping the run.
+	OnBreak func(ctx context.Context, bp *Breakpoint[T]) (DebugAction, error)
+}
+
+// NewDebugger returns a Debugger of r pausing before the given nodes and
+// calling onBreak at every pause.
+func NewDebugger[T any](r *Runnable[T], onBreak func(ctx context.Context, bp *Breakpoint[T]) (DebugAction, error), breakpoints ...string) *Debugger[T] {
+	return &Debugger[T]{Runnable: r, Breakpoints: breakpoints, OnBreak: onBreak}
+}
+
+// Run runs the graph on state as Invoke does, updating state in place, but
+// pausing at breakpoints. The nodes are run with Runnable.Step, so the run
+// is not checkpointed and options other than WithEntryPoint,
+// WithRunCallbacks and WithMessageStream are ignored. It returns
+// ErrNodeNotFound if a breakpoint is not a node of the graph, and
+// ErrDebugAborted, state being left as of the pause, if the run is aborted.
+func (d *Debugger[T]) Run(ctx context.Context, state *T, opts ...InvokeOption) error {
+	for _, name := range d.Breakpoints {
+		if _, ok := d.Runnable.Graph.nodes[name]; !ok {
+			return fmt.Errorf("%w: breakpoint at %s", ErrNodeNotFound, name)
+		}
+	}
+	var cfg invokeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	// The debugger starts new runs, which are not resumed.
+	opts = append(slices.Clone(opts), func(c *invokeConfig) { c.resume = false })
+
+	cp := Checkpoint[T]{State: *state, Next: []string{d.entryNode(cfg.entryPoint)}}
+	stepping := false
+	for len(cp.Next) > 0 {
+		node := cp.Next[0]
+		if stepping || slices.Contains(d.Breakpoints, node) {
+			bp := &Breakpoint[T]{Node: node, Step: cp.Step, State: &cp.State, Next: slices.Clone(cp.Next[1:])}
+			action, err := d.OnBreak(ctx, bp)
+			if err != nil {
+				*state = cp.State
+				return err
+			}
+			switch action {
+			case DebugContinue:
+				stepping = false
+			case DebugStep:
+				stepping = true
+			case DebugAbort:
+				*state = cp.State
+				return ErrDebugAborted
+			default:
+				return fmt.Errorf("%w: debug action %d", ErrInvalidOption, action)
+			}
+		}
+		next, err := d.Runnable.Step(ctx, cp, opts...)
+		if err != nil {
+			*state = cp.State
+			return err
+		}
+		cp = next
+	}
+	*state = cp.State
+	return nil
+}
+
+// entryNode returns the node a new run starts from, given the name of its
+// entry point, if any.
+func (d *Debugger[T]) entryNode(entryPoint string) string {
+	if entryPoint != "" {
+		return d.Runnable.Graph.entryPoints[entryPoint]
+	}
+	return d.Runnable.Graph.entryPoint
+}
@@ -0,0 +1,101 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestDebugger(t *testing.T) {
+	t.Parallel()
+
+	// a, b and c append their names to the state.
+	g := graph.NewStateGraph[[]string]()
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNode(name, func(_ context.Context, s *[]string) error {
+			*s = append(*s, name)
+			return nil
+		})
+	}
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", graph.END)
+	g.SetEntryPoint("a")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		breakpoints []string
+		actions     []graph.DebugAction
+		edit        func(s *[]string)
+		wantPauses  []string
+		wantState   []string
+		wantErr     error
+	}{
+		{
+			name:        "Continue",
+			breakpoints: []string{"b"},
+			actions:     []graph.DebugAction{graph.DebugContinue},
+			wantPauses:  []string{"b"},
+			wantState:   []string{"a", "b", "c"},
+		},
+		{
+			name:        "Step",
+			breakpoints: []string{"a"},
+			actions:     []graph.DebugAction{graph.DebugStep, graph.DebugStep, graph.DebugContinue},
+			wantPauses:  []string{"a", "b", "c"},
+			wantState:   []string{"a", "b", "c"},
+		},
+		{
+			name:        "Edit",
+			breakpoints: []string{"c"},
+			actions:     []graph.DebugAction{graph.DebugContinue},
+			edit:        func(s *[]string) { *s = append(*s, "edited") },
+			wantPauses:  []string{"c"},
+			wantState:   []string{"a", "b", "edited", "c"},
+		},
+		{
+			name:        "Abort",
+			breakpoints: []string{"b"},
+			actions:     []graph.DebugAction{graph.DebugAbort},
+			wantPauses:  []string{"b"},
+			wantState:   []string{"a"},
+			wantErr:     graph.ErrDebugAborted,
+		},
+		{
+			name:        "Unknown breakpoint",
+			breakpoints: []string{"d"},
+			wantErr:     graph.ErrNodeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var pauses []string
+			d := graph.NewDebugger(runnable, func(_ context.Context, bp *graph.Breakpoint[[]string]) (graph.DebugAction, error) {
+				pauses = append(pauses, bp.Node)
+				if tc.edit != nil {
+					tc.edit(bp.State)
+				}
+				return tc.actions[len(pauses)-1], nil
+			}, tc.breakpoints...)
+			var state []string
+			if err := d.Run(context.Background(), &state); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if !slices.Equal(pauses, tc.wantPauses) {
+				t.Errorf("expected pauses %v, but got %v", tc.wantPauses, pauses)
+			}
+			if !slices.Equal(state, tc.wantState) {
+				t.Errorf("expected state %v, but got %v", tc.wantState, state)
+			}
+		})
+	}
+}
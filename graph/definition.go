@@ -0,0 +1,220 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrFunctionNotRegistered is returned when a graph definition refers to a
+	// node function missing from the NodeRegistry.
+	ErrFunctionNotRegistered = errors.New("node function not registered")
+
+	// ErrRouterNotRegistered is returned when a graph definition refers to a
+	// router missing from the NodeRegistry.
+	ErrRouterNotRegistered = errors.New("router not registered")
+)
+
+// NodeRegistry holds the node functions and routers that graph definitions
+// refer to by name.
+type NodeRegistry[T any] struct {
+	functions map[string]func(ctx context.Context, state *T) error
+	routers   map[string]func(ctx context.Context, state *T) ([]string, error)
+}
+
+// NewNodeRegistry creates an empty NodeRegistry.
+func NewNodeRegistry[T any]() *NodeRegistry[T] {
+	return &NodeRegistry[T]{
+		functions: make(map[string]func(ctx context.Context, state *T) error),
+		routers:   make(map[string]func(ctx context.Context, state *T) ([]string, error)),
+	}
+}
+
+// RegisterFunction registers fn as the node function with the given name.
+func (r *NodeRegistry[T]) RegisterFunction(name string, fn func(ctx context.Context, state *T) error) {
+	r.functions[name] = fn
+}
+
+// RegisterRouter registers fn, which picks the routes of a conditional edge,
+// as the router with the given name.
+func (r *NodeRegistry[T]) RegisterRouter(name string, fn func(ctx context.Context, state *T) ([]string, error)) {
+	r.routers[name] = fn
+}
+
+// GraphDefinition describes a graph as data, its nodes and routers referring
+// to functions of a NodeRegistry:
+//
+//	{
+//	  "entry_point": "agent",
+//	  "nodes": [{"name": "agent", "function": "call_model"}, {"name": "tools"}],
+//	  "edges": [{"from": "tools", "to": "agent"}],
+//	  "conditional_edges": [
+//	    {"from": "agent", "router": "should_continue", "routes": {"continue": "tools", "end": "END"}}
+//	  ],
+//	  "allowed_cycles": [["agent", "tools"]]
+//	}
+type GraphDefinition struct {
+	EntryPoint       string                      `json:"entry_point" yaml:"entry_point"`
+	Nodes            []NodeDefinition            `json:"nodes" yaml:"nodes"`
+	Edges            []EdgeDefinition            `json:"edges,omitempty" yaml:"edges,omitempty"`
+	ConditionalEdges []ConditionalEdgeDefinition `json:"conditional_edges,omitempty" yaml:"conditional_edges,omitempty"`
+
+	// EntryPoints maps the names of named entry points to their nodes, see
+	// StateGraph.SetNamedEntryPoint.
+	EntryPoints map[string]string `json:"entry_points,omitempty" yaml:"entry_points,omitempty"`
+
+	// AllowedCycles are the cycles allowed by CompileOptions.
+	AllowedCycles [][]string `json:"allowed_cycles,omitempty" yaml:"allowed_cycles,omitempty"`
+}
+
+// NodeDefinition describes a node of a GraphDefinition.
+type NodeDefinition struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Function is the name of the registered node function. Defaults to Name.
+	Function string `json:"function,omitempty" yaml:"function,omitempty"`
+
+	// Description, Owner and Tags are the metadata of the node, see
+	// NodeMetadata.
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Owner       string   `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// EdgeDefinition describes an edge of a GraphDefinition.
+type EdgeDefinition struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+
+	// Label names the edge, see WithLabel.
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+
+	// Priority orders the edges from the same node, see WithPriority.
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+}
+
+// ConditionalEdgeDefinition describes a conditional edge of a GraphDefinition,
+// see StateGraph.AddConditionalEdges.
+type ConditionalEdgeDefinition struct {
+	From string `json:"from" yaml:"from"`
+
+	// Router is the name of the registered router picking the routes.
+	Router string `json:"router" yaml:"router"`
+
+	// Routes maps the routes to node names. When empty, routes are node names.
+	Routes map[string]string `json:"routes,omitempty" yaml:"routes,omitempty"`
+
+	// Labels lists the routes the router may return, see WithLabels.
+	Labels []string `json:"labels,omitempty" yaml:"labels,omitempty"`
+
+	// Then is a node to run after the routed nodes.
+	Then string `json:"then,omitempty" yaml:"then,omitempty"`
+
+	// Default is the node to route to when no route matches, see
+	// StateGraph.AddDefaultEdge.
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Priority orders the edges from the same node, see WithPriority.
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+}
+
+// CompileOptions returns the options to compile the graph of the definition
+// with, allowing its cycles.
+func (d GraphDefinition) CompileOptions() []CompileOption {
+	opts := make([]CompileOption, 0, len(d.AllowedCycles))
+	for _, cycle := range d.AllowedCycles {
+		opts = append(opts, WithAllowedCycle(cycle...))
+	}
+	return opts
+}
+
+// LoadJSON reads a GraphDefinition in JSON from r and builds its graph, see
+// BuildGraph. Unknown fields are rejected.
+func LoadJSON[T any](r io.Reader, registry *NodeRegistry[T]) (*StateGraph[T], GraphDefinition, error) {
+	def, err := DecodeJSON(r)
+	if err != nil {
+		return nil, GraphDefinition{}, err
+	}
+	g, err := BuildGraph(def, registry)
+	if err != nil {
+		return nil, GraphDefinition{}, err
+	}
+	return g, def, nil
+}
+
+// DecodeJSON reads a GraphDefinition in JSON from r, rejecting unknown fields,
+// without building its graph.
+func DecodeJSON(r io.Reader) (GraphDefinition, error) {
+	var def GraphDefinition
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&def); err != nil {
+		return GraphDefinition{}, fmt.Errorf("decode graph definition: %w", err)
+	}
+	return def, nil
+}
+
+// BuildGraph builds the graph described by def with the functions of
+// registry. It returns all the references to unregistered functions and
+// routers and the invalid nodes, joined with errors.Join; the structure of
+// the graph, such as the nodes edges refer to, is checked by Compile.
+func BuildGraph[T any](def GraphDefinition, registry *NodeRegistry[T]) (*StateGraph[T], error) {
+	var errs []error
+	g := NewStateGraph[T]()
+	for _, node := range def.Nodes {
+		name := node.Function
+		if name == "" {
+			name = node.Name
+		}
+		fn, ok := registry.functions[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s for node %s", ErrFunctionNotRegistered, name, node.Name))
+			continue
+		}
+		if _, err := g.AddNode(node.Name, fn, WithDescription(node.Description), WithOwner(node.Owner), WithTags(node.Tags...)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, edge := range def.Edges {
+		g.addEdge(edge.From, edge.To, WithLabel(edge.Label), WithPriority(edge.Priority))
+	}
+	for _, edge := range def.ConditionalEdges {
+		router, ok := registry.routers[edge.Router]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s for edge from %s", ErrRouterNotRegistered, edge.Router, edge.From))
+			continue
+		}
+		var opts []ConditionalEdgeOptions[T]
+		if len(edge.Routes) > 0 {
+			opts = append(opts, WithMap[T](edge.Routes))
+		}
+		if edge.Then != "" {
+			opts = append(opts, WithThen[T](edge.Then))
+		}
+		if edge.Default != "" {
+			opts = append(opts, WithDefault[T](edge.Default))
+		}
+		if len(edge.Labels) > 0 {
+			opts = append(opts, WithLabels[T](edge.Labels...))
+		}
+		if edge.Priority != 0 {
+			opts = append(opts, WithBranchPriority[T](edge.Priority))
+		}
+		g.AddConditionalEdges(edge.From, router, opts...)
+	}
+	g.entryPoint = def.EntryPoint
+	for name, node := range def.EntryPoints {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("%w: entry point at %s", ErrEmptyName, node))
+			continue
+		}
+		g.setNamedEntryPoint(name, node)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
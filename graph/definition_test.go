@@ -0,0 +1,114 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// newCounterRegistry registers an agent incrementing a counter, a tool
+// doubling it, and a router calling the tool until the counter reaches 10.
+func newCounterRegistry() *graph.NodeRegistry[int] {
+	registry := graph.NewNodeRegistry[int]()
+	registry.RegisterFunction("increment", func(_ context.Context, n *int) error {
+		*n++
+		return nil
+	})
+	registry.RegisterFunction("tools", func(_ context.Context, n *int) error {
+		*n *= 2
+		return nil
+	})
+	registry.RegisterRouter("should_continue", func(_ context.Context, n *int) ([]string, error) {
+		if *n >= 10 {
+			return []string{"end"}, nil
+		}
+		return []string{"continue"}, nil
+	})
+	return registry
+}
+
+func TestLoadJSON(t *testing.T) {
+	t.Parallel()
+
+	const definition = `{
+		"entry_point": "agent",
+		"nodes": [{"name": "agent", "function": "increment", "tags": ["llm"]}, {"name": "tools", "owner": "platform"}],
+		"edges": [{"from": "tools", "to": "agent", "label": "observe"}],
+		"conditional_edges": [
+			{"from": "agent", "router": "should_continue", "routes": {"continue": "tools"}, "default": "END"}
+		],
+		"allowed_cycles": [["agent", "tools"]]
+	}`
+	g, def, err := graph.LoadJSON(strings.NewReader(definition), newCounterRegistry())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if agent, _ := g.Node("agent"); !agent.Metadata.HasTag("llm") {
+		t.Errorf("expected agent to be tagged llm, but got %q", agent.Metadata.Tags)
+	}
+	if tools, _ := g.Node("tools"); tools.Metadata.Owner != "platform" {
+		t.Errorf("expected tools to be owned by platform, but got %q", tools.Metadata.Owner)
+	}
+	if drawing := g.DrawASCII(); !strings.Contains(drawing, "+--> agent (observe)") || !strings.Contains(drawing, "+..> tools (continue)") {
+		t.Errorf("expected labeled edges, but got\n%s", drawing)
+	}
+	runnable, err := g.Compile(def.CompileOptions()...)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	// agent and tools alternate: 0 -> 1 -> 2 -> 3 -> 6 -> 7 -> 14 -> 15.
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 15 {
+		t.Errorf("expected 15, but got %d", n)
+	}
+}
+
+func TestLoadJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		definition string
+		wantErrs   []error
+	}{
+		{
+			name:       "Invalid JSON",
+			definition: `{"entry_point": "agent",`,
+		},
+		{
+			name:       "Unknown field",
+			definition: `{"entry_point": "agent", "nodes": [{"name": "agent", "fn": "increment"}]}`,
+		},
+		{
+			name: "Unregistered references",
+			definition: `{
+				"entry_point": "agent",
+				"nodes": [{"name": "agent", "function": "call_model"}, {"name": "search"}],
+				"conditional_edges": [{"from": "agent", "router": "route"}]
+			}`,
+			wantErrs: []error{graph.ErrFunctionNotRegistered, graph.ErrRouterNotRegistered},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := graph.LoadJSON(strings.NewReader(tc.definition), newCounterRegistry())
+			if err == nil {
+				t.Fatal("expected an error, but got none")
+			}
+			for _, want := range tc.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("expected error %v, but got %v", want, err)
+				}
+			}
+		})
+	}
+}
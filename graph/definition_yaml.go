@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsetVariable is returned when a graph definition refers to an unset
+// environment variable without a default value.
+var ErrUnsetVariable = errors.New("environment variable not set")
+
+// variablePattern matches the interpolated "${VAR}" and "${VAR:-default}",
+// and "$$" escaping a dollar sign.
+var variablePattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// LoadYAML reads a GraphDefinition in YAML from r and builds its graph, see
+// BuildGraph. Unknown fields are rejected.
+//
+// Values may refer to environment variables as "${VAR}", or "${VAR:-default}"
+// to use default when VAR is unset or empty; "$$" stands for a dollar sign.
+// Variables are interpolated in the parsed values, so their contents cannot
+// change the structure of the document:
+//
+//	entry_point: agent
+//	nodes:
+//	  - name: agent
+//	    function: ${AGENT_FUNCTION:-call_model}
+//	  - name: tools
+//	conditional_edges:
+//	  - from: agent
+//	    router: should_continue
+//	    routes: {continue: tools, end: END}
+//	edges:
+//	  - {from: tools, to: agent}
+//	allowed_cycles:
+//	  - [agent, tools]
+func LoadYAML[T any](r io.Reader, registry *NodeRegistry[T]) (*StateGraph[T], GraphDefinition, error) {
+	def, err := DecodeYAML(r)
+	if err != nil {
+		return nil, GraphDefinition{}, err
+	}
+	g, err := BuildGraph(def, registry)
+	if err != nil {
+		return nil, GraphDefinition{}, err
+	}
+	return g, def, nil
+}
+
+// DecodeYAML reads a GraphDefinition in YAML from r, interpolating the
+// environment variables and rejecting unknown fields.
+func DecodeYAML(r io.Reader) (GraphDefinition, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return GraphDefinition{}, fmt.Errorf("decode graph definition: %w", err)
+	}
+	if err := interpolate(&doc); err != nil {
+		return GraphDefinition{}, err
+	}
+
+	// Node.Decode cannot reject unknown fields, so the interpolated document
+	// is decoded again.
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return GraphDefinition{}, fmt.Errorf("decode graph definition: %w", err)
+	}
+	var def GraphDefinition
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&def); err != nil {
+		return GraphDefinition{}, fmt.Errorf("decode graph definition: %w", err)
+	}
+	return def, nil
+}
+
+// interpolate replaces the environment variables in the scalar values of
+// node and its children. It returns all the unset variables, joined with
+// errors.Join.
+func interpolate(node *yaml.Node) error {
+	var errs []error
+	if node.Kind == yaml.ScalarNode {
+		node.Value = variablePattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+			if match == "$$" {
+				return "$"
+			}
+			groups := variablePattern.FindStringSubmatchIndex(match)
+			name := match[groups[2]:groups[3]]
+			hasDefault := groups[4] >= 0
+			if value, ok := os.LookupEnv(name); ok && (value != "" || !hasDefault) {
+				return value
+			}
+			if hasDefault {
+				return match[groups[4]:groups[5]]
+			}
+			errs = append(errs, fmt.Errorf("%w: %s (line %d)", ErrUnsetVariable, name, node.Line))
+			return match
+		})
+	}
+	for _, child := range node.Content {
+		errs = append(errs, interpolate(child))
+	}
+	return errors.Join(errs...)
+}
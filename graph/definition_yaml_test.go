@@ -0,0 +1,129 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+const counterYAML = `
+entry_point: agent
+nodes:
+  - name: agent
+    function: ${AGENT_FUNCTION:-increment}
+  - name: tools
+edges:
+  - {from: tools, to: agent}
+conditional_edges:
+  - from: agent
+    router: ${AGENT_ROUTER}
+    routes: {continue: tools, end: END}
+allowed_cycles:
+  - [agent, tools]
+`
+
+func TestLoadYAML(t *testing.T) {
+	t.Setenv("AGENT_ROUTER", "should_continue")
+
+	g, def, err := graph.LoadYAML(strings.NewReader(counterYAML), newCounterRegistry())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if def.Nodes[0].Function != "increment" {
+		t.Errorf("expected function increment, but got %q", def.Nodes[0].Function)
+	}
+	runnable, err := g.Compile(def.CompileOptions()...)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 15 {
+		t.Errorf("expected 15, but got %d", n)
+	}
+}
+
+func TestLoadYAMLInterpolation(t *testing.T) {
+	t.Setenv("AGENT_FUNCTION", "tools")
+	t.Setenv("AGENT_ROUTER", "should_continue")
+	t.Setenv("EMPTY", "")
+	t.Setenv("COLON", "a: b")
+
+	testCases := []struct {
+		name     string
+		function string
+		want     string
+	}{
+		{name: "Set variable", function: "${AGENT_FUNCTION}", want: "tools"},
+		{name: "Default ignored", function: "${AGENT_FUNCTION:-increment}", want: "tools"},
+		{name: "Default of unset variable", function: "${UNSET_VARIABLE:-increment}", want: "increment"},
+		{name: "Default of empty variable", function: "${EMPTY:-increment}", want: "increment"},
+		{name: "Escaped dollar", function: "$${AGENT_FUNCTION}", want: "${AGENT_FUNCTION}"},
+		{name: "Value not parsed", function: "${COLON}", want: "a: b"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			definition := "entry_point: agent\nnodes:\n  - name: agent\n    function: " + tc.function + "\n"
+			registry := graph.NewNodeRegistry[int]()
+			registry.RegisterFunction(tc.want, func(context.Context, *int) error { return nil })
+			_, def, err := graph.LoadYAML(strings.NewReader(definition), registry)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if def.Nodes[0].Function != tc.want {
+				t.Errorf("expected function %q, but got %q", tc.want, def.Nodes[0].Function)
+			}
+		})
+	}
+}
+
+func TestLoadYAMLErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		definition string
+		wantErrs   []error
+	}{
+		{
+			name:       "Invalid YAML",
+			definition: "entry_point: [agent",
+		},
+		{
+			name:       "Unknown field",
+			definition: "entry_point: agent\nnodes:\n  - {name: agent, fn: increment}\n",
+		},
+		{
+			name:       "Unset variables",
+			definition: "entry_point: ${UNSET_ENTRY_POINT}\nnodes:\n  - {name: agent, function: '${UNSET_FUNCTION}'}\n",
+			wantErrs:   []error{graph.ErrUnsetVariable},
+		},
+		{
+			name:       "Unregistered references",
+			definition: "entry_point: agent\nnodes:\n  - {name: agent, function: call_model}\nconditional_edges:\n  - {from: agent, router: route}\n",
+			wantErrs:   []error{graph.ErrFunctionNotRegistered, graph.ErrRouterNotRegistered},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := graph.LoadYAML(strings.NewReader(tc.definition), newCounterRegistry())
+			if err == nil {
+				t.Fatal("expected an error, but got none")
+			}
+			for _, want := range tc.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("expected error %v, but got %v", want, err)
+				}
+			}
+		})
+	}
+}
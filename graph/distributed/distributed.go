@@ -0,0 +1,335 @@
+// Package distributed runs compiled graphs on worker processes: every node
+// of a run is a task published to a Broker, such as Redis Streams, and
+// consumed by any worker, so that long runs scale horizontally and survive
+// the failure of a worker.
+//
+// The checkpointer of the graph is the source of truth: a worker runs the
+// next node of a thread from its latest checkpoint, checkpoints the result
+// and publishes a task for the following node. Workers and the processes
+// starting runs compile the same graph with a checkpointer shared between
+// them:
+//
+//	exec, err := distributed.New(runnable, distributed.NewRedisBroker(client, "graph", "workers"))
+//	...
+//	go exec.Work(ctx) // on every worker
+//	...
+//	run, err := exec.Start(ctx, "thread", state)
+//	...
+//	cp, err := run.Wait(ctx)
+//
+// Tasks are delivered at least once. A task is acknowledged once its node
+// was checkpointed and the next task published, so a node may run again when
+// its worker fails in between; tasks that are stale by then, their
+// checkpoint not being the latest of the thread, are dropped.
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrThreadBusy is returned when starting a run on a thread whose run
+	// has nodes left to run.
+	ErrThreadBusy = errors.New("thread is busy")
+
+	// ErrRunFailed is returned by Run.Wait when a node of the run failed.
+	ErrRunFailed = errors.New("run failed")
+)
+
+// TaskKind tells how a worker runs a task.
+type TaskKind string
+
+const (
+	// TaskStart starts a run with the input of the task.
+	TaskStart TaskKind = "start"
+
+	// TaskContinue runs the next node of a thread, see graph.WithContinue.
+	TaskContinue TaskKind = "continue"
+
+	// TaskResume resumes an interrupted thread, see graph.WithResume.
+	TaskResume TaskKind = "resume"
+)
+
+// Task is the run of the next node of a thread.
+type Task struct {
+	// ID identifies the task. The ID of a TaskContinue is the ID of its
+	// checkpoint, so that brokers can drop tasks published twice.
+	ID string `json:"id"`
+
+	Kind     TaskKind `json:"kind"`
+	ThreadID string   `json:"thread_id"`
+
+	// Graph is the name of the graph of the task, see WithGraph.
+	Graph string `json:"graph,omitempty"`
+
+	// After is the ID of the latest checkpoint of the thread when the task
+	// was published, empty if the thread had none. Workers drop the task if
+	// the thread was checkpointed since.
+	After string `json:"after,omitempty"`
+
+	// Input is the JSON state a TaskStart starts the run with.
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// Resume is the JSON value a TaskResume resumes the thread with.
+	Resume json.RawMessage `json:"resume,omitempty"`
+}
+
+// Broker queues tasks for workers.
+type Broker interface {
+	// Publish queues a task. Brokers may drop a task whose ID is already
+	// queued.
+	Publish(ctx context.Context, task Task) error
+
+	// Consume calls handle with the queued tasks until ctx is done,
+	// returning its error, or the broker fails. Tasks for which handle
+	// returns an error are delivered again, as are the tasks of consumers
+	// that failed before handle returned.
+	Consume(ctx context.Context, handle func(context.Context, Task) error) error
+}
+
+// pollInterval is how often Run.Wait reads the latest checkpoint of a thread.
+const pollInterval = 20 * time.Millisecond
+
+// Option configures an Executor.
+type Option func(*options)
+
+type options struct {
+	graph string
+}
+
+// WithGraph names the graph of the executor, so that workers running
+// several graphs with one broker run its tasks with it, see Work.
+func WithGraph(name string) Option {
+	return func(o *options) {
+		o.graph = name
+	}
+}
+
+// Executor runs a graph on the workers consuming the tasks of a broker.
+type Executor[T any] struct {
+	runnable     *graph.Runnable[T]
+	checkpointer graph.Checkpointer[T]
+	broker       Broker
+	graph        string
+}
+
+// New returns an Executor running runnable, which must be compiled with a
+// checkpointer, with the tasks of broker.
+func New[T any](runnable *graph.Runnable[T], broker Broker, opts ...Option) (*Executor[T], error) {
+	checkpointer := runnable.Checkpointer()
+	if checkpointer == nil {
+		return nil, graph.ErrNoCheckpointer
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Executor[T]{runnable: runnable, checkpointer: checkpointer, broker: broker, graph: o.graph}, nil
+}
+
+// Graph returns the name of the graph of the executor, see WithGraph.
+func (e *Executor[T]) Graph() string {
+	return e.graph
+}
+
+// Run is a run started or resumed by an Executor.
+type Run[T any] struct {
+	exec     *Executor[T]
+	threadID string
+	after    string
+}
+
+// Start publishes a task starting a run of the graph on a thread with the
+// given state. It returns ErrThreadBusy if the thread has nodes left to run.
+func (e *Executor[T]) Start(ctx context.Context, threadID string, state T) (*Run[T], error) {
+	if threadID == "" {
+		return nil, graph.ErrThreadRequired
+	}
+	cp, err := e.checkpointer.Get(ctx, threadID)
+	if err != nil && !errors.Is(err, graph.ErrCheckpointNotFound) {
+		return nil, err
+	}
+	if pending(cp) {
+		return nil, fmt.Errorf("%w: %s", ErrThreadBusy, threadID)
+	}
+	input, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("encode state: %w", err)
+	}
+	task := Task{ID: uuid.NewString(), Kind: TaskStart, ThreadID: threadID, After: cp.ID, Input: input}
+	return e.publish(ctx, task)
+}
+
+// Resume publishes a task resuming the interrupted run of a thread with
+// value, see graph.WithResume. It returns graph.ErrNotInterrupted if the
+// thread is not interrupted.
+func (e *Executor[T]) Resume(ctx context.Context, threadID string, value any) (*Run[T], error) {
+	if threadID == "" {
+		return nil, graph.ErrThreadRequired
+	}
+	cp, err := e.checkpointer.Get(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Interrupt == nil {
+		return nil, fmt.Errorf("%w: %s", graph.ErrNotInterrupted, threadID)
+	}
+	resume, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encode resume value: %w", err)
+	}
+	task := Task{ID: uuid.NewString(), Kind: TaskResume, ThreadID: threadID, After: cp.ID, Resume: resume}
+	return e.publish(ctx, task)
+}
+
+func (e *Executor[T]) publish(ctx context.Context, task Task) (*Run[T], error) {
+	task.Graph = e.graph
+	if err := e.broker.Publish(ctx, task); err != nil {
+		return nil, fmt.Errorf("publish task: %w", err)
+	}
+	return &Run[T]{exec: e, threadID: task.ThreadID, after: task.After}, nil
+}
+
+// Wait waits for the run to end and returns the latest checkpoint of its
+// thread. It returns a *graph.GraphInterrupt if the run was interrupted, to
+// be resumed with Executor.Resume, and ErrRunFailed if a node failed.
+func (r *Run[T]) Wait(ctx context.Context) (graph.Checkpoint[T], error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		cp, err := r.exec.checkpointer.Get(ctx, r.threadID)
+		if err != nil && !errors.Is(err, graph.ErrCheckpointNotFound) {
+			return graph.Checkpoint[T]{}, err
+		}
+		if err == nil && cp.ID != r.after && !pending(cp) {
+			switch {
+			case cp.Error != "":
+				return cp, fmt.Errorf("%w: %s", ErrRunFailed, cp.Error)
+			case cp.Interrupt != nil:
+				interrupt := *cp.Interrupt
+				return cp, &interrupt
+			}
+			return cp, nil
+		}
+		select {
+		case <-ctx.Done():
+			return graph.Checkpoint[T]{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Work runs the tasks of the broker until ctx is done, returning its error,
+// or the broker fails.
+func (e *Executor[T]) Work(ctx context.Context) error {
+	return e.broker.Consume(ctx, e.handle)
+}
+
+// Worker is an Executor, whatever the type of the state of its graph.
+type Worker interface {
+	Graph() string
+	handle(ctx context.Context, task Task) error
+}
+
+// Work runs the tasks of broker until ctx is done, returning its error, or
+// the broker fails, with the worker of their graph, see WithGraph, so that
+// one worker process runs several graphs, such as the graphs of a
+// registry.Registry. Tasks of other graphs are delivered again, to the
+// workers running them.
+func Work(ctx context.Context, broker Broker, workers ...Worker) error {
+	byGraph := make(map[string]Worker, len(workers))
+	for _, w := range workers {
+		if _, ok := byGraph[w.Graph()]; ok {
+			return fmt.Errorf("duplicate worker of graph %q", w.Graph())
+		}
+		byGraph[w.Graph()] = w
+	}
+	return broker.Consume(ctx, func(ctx context.Context, task Task) error {
+		w, ok := byGraph[task.Graph]
+		if !ok {
+			return fmt.Errorf("no worker of graph %q", task.Graph)
+		}
+		return w.handle(ctx, task)
+	})
+}
+
+// handle runs the node of task and publishes the task of the next node.
+func (e *Executor[T]) handle(ctx context.Context, task Task) error {
+	cp, err := e.checkpointer.Get(ctx, task.ThreadID)
+	if err != nil && !errors.Is(err, graph.ErrCheckpointNotFound) {
+		return err
+	}
+	if cp.ID != task.After {
+		// The task ran already, and its worker may have failed before
+		// publishing the next task.
+		return e.next(ctx, cp)
+	}
+
+	var state T
+	opts := []graph.InvokeOption{graph.WithThreadID(task.ThreadID), graph.WithMaxSteps(1)}
+	switch task.Kind {
+	case TaskStart:
+		cp = graph.Checkpoint[T]{ThreadID: task.ThreadID}
+		if err := json.Unmarshal(task.Input, &state); err != nil {
+			return e.fail(ctx, cp, fmt.Errorf("decode input: %w", err))
+		}
+	case TaskContinue:
+		opts = append(opts, graph.WithContinue())
+	case TaskResume:
+		var value any
+		if err := json.Unmarshal(task.Resume, &value); err != nil {
+			return e.fail(ctx, cp, fmt.Errorf("decode resume value: %w", err))
+		}
+		opts = append(opts, graph.WithResume(value))
+	default:
+		return e.fail(ctx, cp, fmt.Errorf("unknown task kind %q", task.Kind))
+	}
+
+	err = e.runnable.Invoke(ctx, &state, opts...)
+	var gi *graph.GraphInterrupt
+	switch {
+	case err == nil, errors.As(err, &gi):
+		return nil
+	case errors.Is(err, graph.ErrPaused):
+		latest, err := e.checkpointer.Get(ctx, task.ThreadID)
+		if err != nil {
+			return err
+		}
+		return e.next(ctx, latest)
+	case ctx.Err() != nil:
+		// The worker is stopping: the task is delivered again.
+		return err
+	}
+	return e.fail(ctx, cp, err)
+}
+
+// next publishes the task of the next node of a thread whose latest
+// checkpoint is cp, if any.
+func (e *Executor[T]) next(ctx context.Context, cp graph.Checkpoint[T]) error {
+	if !pending(cp) {
+		return nil
+	}
+	return e.broker.Publish(ctx, Task{ID: cp.ID, Kind: TaskContinue, ThreadID: cp.ThreadID, Graph: e.graph, After: cp.ID})
+}
+
+// fail checkpoints the failure of the run of a thread whose latest
+// checkpoint is cp.
+func (e *Executor[T]) fail(ctx context.Context, cp graph.Checkpoint[T], err error) error {
+	cp.ID = uuid.NewString()
+	cp.Interrupt = nil
+	cp.Error = err.Error()
+	cp.CreatedAt = time.Now()
+	return e.checkpointer.Put(ctx, cp)
+}
+
+// pending reports whether the run checkpointed by cp has nodes left to run.
+func pending[T any](cp graph.Checkpoint[T]) bool {
+	return len(cp.Next) > 0 && cp.Interrupt == nil && cp.Error == ""
+}
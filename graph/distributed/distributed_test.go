@@ -0,0 +1,332 @@
+package distributed_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/distributed"
+)
+
+type stepsState struct {
+	Steps []string `json:"steps"`
+}
+
+// counter counts the runs of nodes.
+type counter struct {
+	mu   sync.Mutex
+	runs map[string]int
+}
+
+func (c *counter) add(node string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.runs == nil {
+		c.runs = make(map[string]int)
+	}
+	c.runs[node]++
+}
+
+// newExecutor compiles a graph running "plan", "ask", which asks for a name
+// with Interrupt unless the state says to skip it, and "act", which fails on
+// "fail". Workers consume broker until the test ends.
+func newExecutor(t *testing.T, broker distributed.Broker, workers int, runs *counter) *distributed.Executor[stepsState] {
+	t.Helper()
+
+	g := graph.NewStateGraph[stepsState]()
+	g.AddNode("plan", func(_ context.Context, s *stepsState) error {
+		runs.add("plan")
+		s.Steps = append(s.Steps, "plan")
+		return nil
+	})
+	g.AddNode("ask", func(ctx context.Context, s *stepsState) error {
+		if slices.Contains(s.Steps, "skip") {
+			return nil
+		}
+		name, err := graph.Interrupt(ctx, "name?")
+		if err != nil {
+			return err
+		}
+		runs.add("ask")
+		s.Steps = append(s.Steps, "name: "+name.(string))
+		return nil
+	})
+	g.AddNode("act", func(_ context.Context, s *stepsState) error {
+		runs.add("act")
+		if slices.Contains(s.Steps, "fail") {
+			return errors.New("boom")
+		}
+		s.Steps = append(s.Steps, "act")
+		return nil
+	})
+	g.AddEdge("plan", "ask")
+	g.AddEdge("ask", "act")
+	g.AddEdge("act", graph.END)
+	g.SetEntryPoint("plan")
+	runnable, err := g.Compile(graph.WithCheckpointer[stepsState](graph.NewMemorySaver[stepsState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	exec, err := distributed.New(runnable, broker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exec.Work(ctx)
+		}()
+	}
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	return exec
+}
+
+// wait waits for run for a few seconds at most.
+func wait(t *testing.T, run *distributed.Run[stepsState]) (graph.Checkpoint[stepsState], error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return run.Wait(ctx)
+}
+
+func TestExecutor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var runs counter
+	exec := newExecutor(t, distributed.NewMemoryBroker(), 3, &runs)
+
+	run, err := exec.Start(ctx, "t1", stepsState{Steps: []string{"hi"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err := wait(t, run)
+	var gi *graph.GraphInterrupt
+	if !errors.As(err, &gi) || gi.Node != "ask" || gi.Value != "name?" {
+		t.Fatalf("expected an interrupt in ask, but got %v", err)
+	}
+	if want := []string{"hi", "plan"}; !slices.Equal(cp.State.Steps, want) {
+		t.Errorf("expected steps %v, but got %v", want, cp.State.Steps)
+	}
+
+	if _, err := exec.Start(ctx, "t1", stepsState{}); err != nil {
+		t.Errorf("expected an interrupted thread to start a new run, but got %v", err)
+	}
+	if _, err := exec.Resume(ctx, "missing", "ada"); !errors.Is(err, graph.ErrCheckpointNotFound) {
+		t.Errorf("expected error %v, but got %v", graph.ErrCheckpointNotFound, err)
+	}
+}
+
+func TestExecutorResume(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var runs counter
+	exec := newExecutor(t, distributed.NewMemoryBroker(), 2, &runs)
+
+	run, err := exec.Start(ctx, "t1", stepsState{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := wait(t, run); err == nil {
+		t.Fatal("expected an interrupt, but got none")
+	}
+	if run, err = exec.Resume(ctx, "t1", "ada"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err := wait(t, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"plan", "name: ada", "act"}; !slices.Equal(cp.State.Steps, want) {
+		t.Errorf("expected steps %v, but got %v", want, cp.State.Steps)
+	}
+	if cp.Node != "act" || cp.Step != 3 {
+		t.Errorf("expected the run to end after act at step 3, but got %s at step %d", cp.Node, cp.Step)
+	}
+	if _, err := exec.Resume(ctx, "t1", "ada"); !errors.Is(err, graph.ErrNotInterrupted) {
+		t.Errorf("expected error %v, but got %v", graph.ErrNotInterrupted, err)
+	}
+}
+
+func TestExecutorNodeError(t *testing.T) {
+	t.Parallel()
+
+	var runs counter
+	exec := newExecutor(t, distributed.NewMemoryBroker(), 1, &runs)
+
+	run, err := exec.Start(context.Background(), "t1", stepsState{Steps: []string{"skip", "fail"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err := wait(t, run)
+	if !errors.Is(err, distributed.ErrRunFailed) || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected error %v with boom, but got %v", distributed.ErrRunFailed, err)
+	}
+	if !slices.Equal(cp.Next, []string{"act"}) || !strings.Contains(cp.Error, "boom") {
+		t.Errorf("expected a failed checkpoint before act, but got %+v", cp)
+	}
+	if runs.runs["act"] != 1 {
+		t.Errorf("expected act to run once, but ran %d times", runs.runs["act"])
+	}
+}
+
+// flakyBroker fails to publish the first continued task, as if its worker
+// failed after checkpointing the node.
+type flakyBroker struct {
+	*distributed.MemoryBroker
+	failed atomic.Bool
+}
+
+func (b *flakyBroker) Publish(ctx context.Context, task distributed.Task) error {
+	if task.Kind == distributed.TaskContinue && b.failed.CompareAndSwap(false, true) {
+		return errors.New("connection reset")
+	}
+	return b.MemoryBroker.Publish(ctx, task)
+}
+
+func TestExecutorRecovery(t *testing.T) {
+	t.Parallel()
+
+	broker := &flakyBroker{MemoryBroker: distributed.NewMemoryBroker()}
+	var runs counter
+	exec := newExecutor(t, broker, 2, &runs)
+
+	run, err := exec.Start(context.Background(), "t1", stepsState{Steps: []string{"skip"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err := wait(t, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !broker.failed.Load() {
+		t.Fatal("expected a failed publish, but got none")
+	}
+	if want := []string{"skip", "plan", "act"}; !slices.Equal(cp.State.Steps, want) {
+		t.Errorf("expected steps %v, but got %v", want, cp.State.Steps)
+	}
+	// The redelivered start task is stale and only publishes the next task.
+	if runs.runs["plan"] != 1 {
+		t.Errorf("expected plan to run once, but ran %d times", runs.runs["plan"])
+	}
+}
+
+// stuckBroker drops the continued tasks, as if the workers were busy.
+type stuckBroker struct {
+	*distributed.MemoryBroker
+	dropped chan struct{}
+}
+
+func (b *stuckBroker) Publish(ctx context.Context, task distributed.Task) error {
+	if task.Kind == distributed.TaskContinue {
+		close(b.dropped)
+		return nil
+	}
+	return b.MemoryBroker.Publish(ctx, task)
+}
+
+func TestExecutorThreadBusy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := &stuckBroker{MemoryBroker: distributed.NewMemoryBroker(), dropped: make(chan struct{})}
+	var runs counter
+	exec := newExecutor(t, broker, 1, &runs)
+
+	if _, err := exec.Start(ctx, "", stepsState{}); !errors.Is(err, graph.ErrThreadRequired) {
+		t.Errorf("expected error %v, but got %v", graph.ErrThreadRequired, err)
+	}
+	run, err := exec.Start(ctx, "t1", stepsState{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-broker.dropped
+	if _, err := exec.Start(ctx, "t1", stepsState{}); !errors.Is(err, distributed.ErrThreadBusy) {
+		t.Errorf("expected error %v, but got %v", distributed.ErrThreadBusy, err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := run.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error %v, but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+type countState struct {
+	Count int `json:"count"`
+}
+
+func TestWork(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	broker := distributed.NewMemoryBroker()
+	compile := func(add int) *graph.Runnable[countState] {
+		g := graph.NewStateGraph[countState]()
+		g.AddNode("add", func(_ context.Context, s *countState) error {
+			s.Count += add
+			return nil
+		})
+		g.AddNode("double", func(_ context.Context, s *countState) error {
+			s.Count *= 2
+			return nil
+		})
+		g.AddEdge("add", "double")
+		g.AddEdge("double", graph.END)
+		g.SetEntryPoint("add")
+		runnable, err := g.Compile(graph.WithCheckpointer[countState](graph.NewMemorySaver[countState]()))
+		if err != nil {
+			t.Fatalf("unexpected compile error: %v", err)
+		}
+		return runnable
+	}
+	one, err := distributed.New(compile(1), broker, distributed.WithGraph("one"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ten, err := distributed.New(compile(10), broker, distributed.WithGraph("ten"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := distributed.Work(ctx, broker, one, one); err == nil {
+		t.Error("expected an error working for graph one twice, but got none")
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- distributed.Work(workCtx, broker, one, ten) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	for _, tc := range []struct {
+		exec *distributed.Executor[countState]
+		want int
+	}{{one, 2}, {ten, 20}} {
+		run, err := tc.exec.Start(ctx, "t1", countState{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		cp, err := run.Wait(waitCtx)
+		cancel()
+		if err != nil || cp.State.Count != tc.want {
+			t.Errorf("graph %s: expected count %d, but got %d and %v", tc.exec.Graph(), tc.want, cp.State.Count, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package distributed
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// redeliveryDelay is how long MemoryBroker waits before delivering a failed
+// task again.
+const redeliveryDelay = 10 * time.Millisecond
+
+// MemoryBroker is a Broker that queues tasks in memory, for workers running
+// in a single process and for tests.
+type MemoryBroker struct {
+	mu    sync.Mutex
+	queue []Task
+	// queued holds the IDs of the tasks queued or being handled.
+	queued map[string]bool
+	// ready is signaled when tasks are queued.
+	ready chan struct{}
+}
+
+// NewMemoryBroker returns an empty MemoryBroker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{queued: make(map[string]bool), ready: make(chan struct{}, 1)}
+}
+
+// Publish queues task, unless a task with the same ID is queued or being
+// handled.
+func (b *MemoryBroker) Publish(_ context.Context, task Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.queued[task.ID] {
+		return nil
+	}
+	b.queued[task.ID] = true
+	b.push(task)
+	return nil
+}
+
+// push queues task, b.mu being held.
+func (b *MemoryBroker) push(task Task) {
+	b.queue = append(b.queue, task)
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Consume calls handle with the queued tasks until ctx is done. Consume may
+// be called concurrently, every call getting different tasks.
+func (b *MemoryBroker) Consume(ctx context.Context, handle func(context.Context, Task) error) error {
+	for {
+		task, err := b.pop(ctx)
+		if err != nil {
+			return err
+		}
+		if err := handle(ctx, task); err != nil {
+			time.AfterFunc(redeliveryDelay, func() {
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				b.push(task)
+			})
+			continue
+		}
+		b.mu.Lock()
+		delete(b.queued, task.ID)
+		b.mu.Unlock()
+	}
+}
+
+// pop waits for a task and dequeues it.
+func (b *MemoryBroker) pop(ctx context.Context) (Task, error) {
+	for {
+		b.mu.Lock()
+		if len(b.queue) > 0 {
+			task := b.queue[0]
+			b.queue = b.queue[1:]
+			if len(b.queue) > 0 {
+				// Wake another consumer for the rest.
+				select {
+				case b.ready <- struct{}{}:
+				default:
+				}
+			}
+			b.mu.Unlock()
+			return task, nil
+		}
+		b.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return Task{}, ctx.Err()
+		case <-b.ready:
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// publishScript adds a task to a stream unless its ID was published before,
+// as told by a key expiring after a while.
+var publishScript = redis.NewScript(`
+if redis.call('SET', KEYS[2], '1', 'NX', 'EX', ARGV[2]) then
+	return redis.call('XADD', KEYS[1], '*', 'task', ARGV[1])
+end
+return false
+`)
+
+// RedisOption configures a RedisBroker.
+type RedisOption func(*RedisBroker)
+
+// WithConsumer names the consumer of the broker in its consumer group, a
+// random name by default. Workers restarting under the same name get the
+// tasks they were handling when they stopped.
+func WithConsumer(name string) RedisOption {
+	return func(b *RedisBroker) {
+		b.consumer = name
+	}
+}
+
+// WithClaimAfter sets how long a task is left to a consumer before other
+// consumers claim it, such as when the consumer failed, one minute by
+// default. It should be longer than the nodes of the graph run.
+func WithClaimAfter(d time.Duration) RedisOption {
+	return func(b *RedisBroker) {
+		b.claimAfter = d
+	}
+}
+
+// WithDedupWindow sets how long the IDs of published tasks are kept to drop
+// tasks published twice, one day by default.
+func WithDedupWindow(d time.Duration) RedisOption {
+	return func(b *RedisBroker) {
+		b.dedupWindow = d
+	}
+}
+
+// RedisBroker is a Broker queuing tasks in a Redis stream, consumed by the
+// workers of a consumer group.
+type RedisBroker struct {
+	client      redis.UniversalClient
+	stream      string
+	group       string
+	consumer    string
+	claimAfter  time.Duration
+	dedupWindow time.Duration
+}
+
+// NewRedisBroker returns a RedisBroker queuing tasks in stream for the
+// consumer group named group, which Consume creates if needed.
+func NewRedisBroker(client redis.UniversalClient, stream, group string, opts ...RedisOption) *RedisBroker {
+	b := &RedisBroker{
+		client:      client,
+		stream:      stream,
+		group:       group,
+		consumer:    uuid.NewString(),
+		claimAfter:  time.Minute,
+		dedupWindow: 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Publish adds task to the stream, unless a task with the same ID was
+// published within the dedup window, see WithDedupWindow.
+func (b *RedisBroker) Publish(ctx context.Context, task Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	keys := []string{b.stream, b.stream + ":task:" + task.ID}
+	err = publishScript.Run(ctx, b.client, keys, data, int(b.dedupWindow/time.Second)).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
+
+// Consume calls handle with the tasks of the stream until ctx is done,
+// acknowledging the tasks it handled. Tasks left unacknowledged, because
+// handle failed or the consumer stopped, are claimed again after the claim
+// delay, see WithClaimAfter.
+func (b *RedisBroker) Consume(ctx context.Context, handle func(context.Context, Task) error) error {
+	err := b.client.XGroupCreateMkStream(ctx, b.stream, b.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create consumer group: %w", err)
+	}
+	for ctx.Err() == nil {
+		msgs, err := b.read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		for _, msg := range msgs {
+			var task Task
+			data, _ := msg.Values["task"].(string)
+			if err := json.Unmarshal([]byte(data), &task); err != nil {
+				// Tasks that cannot be decoded are never handled.
+				if err := b.client.XAck(ctx, b.stream, b.group, msg.ID).Err(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := handle(ctx, task); err != nil {
+				continue
+			}
+			// Handled tasks are acknowledged even when the consumer stops.
+			if err := b.client.XAck(context.WithoutCancel(ctx), b.stream, b.group, msg.ID).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// read returns the next task of the consumer: a task left to another
+// consumer for longer than the claim delay, or a new one, waiting for one a
+// second at most.
+func (b *RedisBroker) read(ctx context.Context) ([]redis.XMessage, error) {
+	msgs, _, err := b.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   b.stream,
+		Group:    b.group,
+		Consumer: b.consumer,
+		MinIdle:  b.claimAfter,
+		Start:    "0-0",
+		Count:    1,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) > 0 {
+		return msgs, nil
+	}
+	streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    b.group,
+		Consumer: b.consumer,
+		Streams:  []string{b.stream, ">"},
+		Count:    1,
+		Block:    time.Second,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return streams[0].Messages, nil
+}
@@ -0,0 +1,94 @@
+package distributed_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph/distributed"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisBroker(t *testing.T) {
+	t.Parallel()
+
+	client := newRedisClient(t)
+	var runs counter
+	exec := newExecutor(t, distributed.NewRedisBroker(client, "graph", "workers"), 2, &runs)
+
+	run, err := exec.Start(context.Background(), "t1", stepsState{Steps: []string{"skip"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err := wait(t, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"skip", "plan", "act"}; !slices.Equal(cp.State.Steps, want) {
+		t.Errorf("expected steps %v, but got %v", want, cp.State.Steps)
+	}
+}
+
+func TestRedisBrokerDedup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newRedisClient(t)
+	broker := distributed.NewRedisBroker(client, "graph", "workers")
+	for _, id := range []string{"a", "a", "b"} {
+		if err := broker.Publish(ctx, distributed.Task{ID: id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := client.XLen(ctx, "graph").Val(); n != 2 {
+		t.Errorf("expected 2 tasks, but got %d", n)
+	}
+}
+
+func TestRedisBrokerClaim(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newRedisClient(t)
+	failing := distributed.NewRedisBroker(client, "graph", "workers", distributed.WithConsumer("failing"), distributed.WithClaimAfter(50*time.Millisecond))
+	if err := failing.Publish(ctx, distributed.Task{ID: "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The failing consumer stops while handling the task.
+	failCtx, stop := context.WithCancel(ctx)
+	err := failing.Consume(failCtx, func(context.Context, distributed.Task) error {
+		stop()
+		return errors.New("killed")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error %v, but got %v", context.Canceled, err)
+	}
+
+	other := distributed.NewRedisBroker(client, "graph", "workers", distributed.WithConsumer("other"), distributed.WithClaimAfter(50*time.Millisecond))
+	claimCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var got distributed.Task
+	err = other.Consume(claimCtx, func(_ context.Context, task distributed.Task) error {
+		got = task
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || got.ID != "a" {
+		t.Fatalf("expected task a to be claimed, but got %+v and %v", got, err)
+	}
+	if pending := client.XPending(ctx, "graph", "workers").Val(); pending.Count != 0 {
+		t.Errorf("expected no pending task, but got %d", pending.Count)
+	}
+}
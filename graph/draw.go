@@ -0,0 +1,289 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// START is the name under which the start of the graph, leading to its entry
+// point, is drawn.
+const START = "START"
+
+// anyNode is the target drawn for conditional edges whose targets are not
+// known before running the graph.
+const anyNode = "*"
+
+// drawEdge is an edge of the drawn graph.
+type drawEdge struct {
+	from, to    string
+	conditional bool
+	label       string
+}
+
+// topology returns the nodes of the graph to draw, in order of discovery from
+// the entry point and starting with START and ending with END, and its edges
+// in the same order.
+func (g *StateGraph[T]) topology() ([]string, []drawEdge) {
+	byNode := make(map[string][]drawEdge)
+	for _, edge := range g.edges {
+		targets, known := edgeTargets(edge)
+		_, conditional := edge.(*Branch[T])
+		from := edge.From()
+		for _, to := range targets {
+			byNode[from] = append(byNode[from], drawEdge{from: from, to: to, conditional: conditional, label: edgeLabel(edge, to)})
+		}
+		if !known {
+			byNode[from] = append(byNode[from], drawEdge{from: from, to: anyNode, conditional: true})
+		}
+	}
+	if g.entryPoint != "" {
+		byNode[START] = []drawEdge{{from: START, to: g.entryPoint}}
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.entryPoints)) {
+		byNode[START] = append(byNode[START], drawEdge{from: START, to: g.entryPoints[name], label: name})
+	}
+
+	nodes := []string{START}
+	seen := map[string]bool{START: true, END: true, anyNode: true}
+	// discover appends the nodes reached from nodes[start:].
+	discover := func(start int) {
+		for i := start; i < len(nodes); i++ {
+			for _, e := range byNode[nodes[i]] {
+				if !seen[e.to] {
+					seen[e.to] = true
+					nodes = append(nodes, e.to)
+				}
+			}
+		}
+	}
+	discover(0)
+	// Nodes unreachable from the entry point come next, by name.
+	names := make([]string, 0, len(g.nodes))
+	for name := range g.nodes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			nodes = append(nodes, name)
+			discover(len(nodes) - 1)
+		}
+	}
+	nodes = append(nodes, END)
+
+	var edges []drawEdge
+	for _, name := range nodes {
+		edges = append(edges, byNode[name]...)
+	}
+	return nodes, edges
+}
+
+// edgeLabel returns the label of the edge from the node of edge to the node
+// to: the label of simple edges, or the routes of branches leading to the
+// node.
+func edgeLabel[T any](edge Edge[T], to string) string {
+	switch e := edge.(type) {
+	case *SimpleEdge[T]:
+		return e.label
+	case *Branch[T]:
+		var routes []string
+		for route, target := range e.Routes {
+			if target == to {
+				routes = append(routes, route)
+			}
+		}
+		slices.Sort(routes)
+		if e.Default == to {
+			routes = append(routes, "default")
+		}
+		return strings.Join(routes, ", ")
+	}
+	return ""
+}
+
+// DrawASCII renders the graph as text, for terminals and test output. Every
+// node is drawn as a box followed by its outgoing edges: "-->" for edges and
+// "..>" for conditional edges, "*" standing for any node when the targets of
+// a conditional edge are not known, and their labels in parentheses.
+//
+//	+-------+
+//	| START |
+//	+-------+
+//	    +--> agent
+//	+-------+
+//	| agent |
+//	+-------+
+//	    +..> tools (needs_tools)
+//	    +..> END (final_answer)
+func (g *StateGraph[T]) DrawASCII() string {
+	nodes, edges := g.topology()
+	var b strings.Builder
+	for _, name := range nodes {
+		border := "+" + strings.Repeat("-", len(name)+2) + "+"
+		fmt.Fprintf(&b, "%s\n| %s |\n%s\n", border, name, border)
+		for _, e := range edges {
+			if e.from != name {
+				continue
+			}
+			arrow := "-->"
+			if e.conditional {
+				arrow = "..>"
+			}
+			fmt.Fprintf(&b, "    +%s %s", arrow, e.to)
+			if e.label != "" {
+				fmt.Fprintf(&b, " (%s)", e.label)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// DrawOption configures drawings of a graph.
+type DrawOption func(*drawConfig)
+
+type drawConfig struct {
+	xray bool
+}
+
+// WithXray expands the nodes added with AddSubgraph, drawing the nodes and
+// edges of their graphs as clusters named after the nodes, at any depth.
+func WithXray() DrawOption {
+	return func(c *drawConfig) {
+		c.xray = true
+	}
+}
+
+// DrawMermaid renders the graph as a Mermaid flowchart, which Markdown
+// viewers such as GitHub's display as a diagram. Conditional edges are
+// dotted and labeled edges show their labels, and tagged nodes are assigned the classes of their tags, with
+// characters other than letters, digits, "-" and "_" replaced by "_", to be
+// styled with classDef statements. With WithXray, subgraphs are drawn as
+// Mermaid subgraphs, which the edges of the node lead to and from.
+func (g *StateGraph[T]) DrawMermaid(opts ...DrawOption) string {
+	var cfg drawConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var b strings.Builder
+	b.WriteString("graph TD;\n")
+	ids := 0
+	g.drawMermaid(&b, cfg, &ids, "\t")
+	return b.String()
+}
+
+// drawMermaid writes the statements of the flowchart of the graph to b,
+// indented with indent. ids counts the node IDs used, for subgraphs to use
+// other IDs.
+func (g *StateGraph[T]) drawMermaid(b *strings.Builder, cfg drawConfig, ids *int, indent string) {
+	nodes, edges := g.topology()
+	nodeIDs := make(map[string]string, len(nodes)+1)
+	declare := func(name string) {
+		id := fmt.Sprintf("n%d", *ids)
+		*ids++
+		nodeIDs[name] = id
+		label := mermaidText(name)
+		switch name {
+		case START, END:
+			fmt.Fprintf(b, "%s%s([\"%s\"]);\n", indent, id, label)
+		case anyNode:
+			fmt.Fprintf(b, "%s%s((\"any node\"));\n", indent, id)
+		default:
+			if sub := g.nodes[name].Subgraph; cfg.xray && sub != nil {
+				fmt.Fprintf(b, "%ssubgraph %s[\"%s\"]\n", indent, id, label)
+				sub.drawMermaid(b, cfg, ids, indent+"\t")
+				fmt.Fprintf(b, "%send\n", indent)
+				return
+			}
+			fmt.Fprintf(b, "%s%s[\"%s\"];\n", indent, id, label)
+		}
+	}
+	for _, name := range nodes {
+		declare(name)
+	}
+	for _, e := range edges {
+		if _, ok := nodeIDs[e.to]; !ok {
+			declare(e.to)
+		}
+		arrow := "-->"
+		if e.conditional {
+			arrow = "-.->"
+		}
+		if e.label != "" {
+			arrow += fmt.Sprintf(`|"%s"|`, mermaidText(e.label))
+		}
+		fmt.Fprintf(b, "%s%s %s %s;\n", indent, nodeIDs[e.from], arrow, nodeIDs[e.to])
+	}
+	for _, name := range nodes {
+		for _, tag := range g.nodes[name].Metadata.Tags {
+			fmt.Fprintf(b, "%sclass %s %s;\n", indent, nodeIDs[name], mermaidClass(tag))
+		}
+	}
+}
+
+// mermaidClass returns tag as a Mermaid class name.
+func mermaidClass(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, tag)
+}
+
+// mermaidText escapes text for Mermaid quoted strings.
+func mermaidText(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
+
+// DrawDOT renders the graph in the DOT language of Graphviz, such as for
+// "dot -Tpng". Conditional edges are dashed and labeled edges show their
+// labels.
+func (g *StateGraph[T]) DrawDOT() string {
+	nodes, edges := g.topology()
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	declared := make(map[string]bool, len(nodes)+1)
+	declare := func(name string) {
+		declared[name] = true
+		switch name {
+		case START, END:
+			fmt.Fprintf(&b, "\t%s [shape=oval];\n", dotID(name))
+		case anyNode:
+			fmt.Fprintf(&b, "\t%s [label=\"any node\", shape=circle];\n", dotID(name))
+		default:
+			fmt.Fprintf(&b, "\t%s [shape=box];\n", dotID(name))
+		}
+	}
+	for _, name := range nodes {
+		declare(name)
+	}
+	for _, e := range edges {
+		if !declared[e.to] {
+			declare(e.to)
+		}
+		var attrs []string
+		if e.conditional {
+			attrs = append(attrs, "style=dashed")
+		}
+		if e.label != "" {
+			attrs = append(attrs, "label="+dotID(e.label))
+		}
+		fmt.Fprintf(&b, "\t%s -> %s", dotID(e.from), dotID(e.to))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotID quotes s as a DOT identifier.
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
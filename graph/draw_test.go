@@ -0,0 +1,215 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// newDrawGraph builds an agent loop whose reviewer routes dynamically.
+func newDrawGraph() *graph.StateGraph[int] {
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]string, error) { return nil, nil }
+
+	g := graph.NewStateGraph[int]()
+	for _, name := range []string{"agent", "tools", "review"} {
+		g.AddNode(name, noop)
+	}
+	g.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"call": "tools", "done": "review"}))
+	g.AddEdge("tools", "agent")
+	g.AddConditionalEdges("review", route)
+	g.SetEntryPoint("agent")
+	return g
+}
+
+func TestDrawASCII(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]string, error) { return nil, nil }
+
+	g := graph.NewStateGraph[int]()
+	for _, name := range []string{"agent", "tools", "review", "orphan"} {
+		g.AddNode(name, noop)
+	}
+	g.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{
+		"call":  "tools",
+		"done":  "review",
+		"retry": "review",
+	}))
+	g.AddEdge("tools", "agent")
+	g.AddConditionalEdges("review", route)
+	g.AddEdge("orphan", graph.END)
+	g.SetEntryPoint("agent")
+
+	want := `+-------+
+| START |
++-------+
+    +--> agent
++-------+
+| agent |
++-------+
+    +..> review (done, retry)
+    +..> tools (call)
++--------+
+| review |
++--------+
+    +..> *
++-------+
+| tools |
++-------+
+    +--> agent
++--------+
+| orphan |
++--------+
+    +--> END
++-----+
+| END |
++-----+
+`
+	if got := g.DrawASCII(); got != want {
+		t.Errorf("expected drawing\n%s\nbut got\n%s", want, got)
+	}
+}
+
+func TestDrawMermaid(t *testing.T) {
+	t.Parallel()
+
+	want := `graph TD;
+	n0(["START"]);
+	n1["agent"];
+	n2["review"];
+	n3["tools"];
+	n4(["END"]);
+	n0 --> n1;
+	n1 -.->|"done"| n2;
+	n1 -.->|"call"| n3;
+	n5(("any node"));
+	n2 -.-> n5;
+	n3 --> n1;
+`
+	if got := newDrawGraph().DrawMermaid(); got != want {
+		t.Errorf("expected diagram\n%s\nbut got\n%s", want, got)
+	}
+}
+
+func TestDrawDOT(t *testing.T) {
+	t.Parallel()
+
+	g := newDrawGraph()
+	g.AddNode("publish", func(context.Context, *int) error { return nil })
+	g.AddEdge("publish", graph.END, graph.WithLabel(`say "done"`))
+
+	want := `digraph {
+	"START" [shape=oval];
+	"agent" [shape=box];
+	"review" [shape=box];
+	"tools" [shape=box];
+	"publish" [shape=box];
+	"END" [shape=oval];
+	"START" -> "agent";
+	"agent" -> "review" [style=dashed, label="done"];
+	"agent" -> "tools" [style=dashed, label="call"];
+	"*" [label="any node", shape=circle];
+	"review" -> "*" [style=dashed];
+	"tools" -> "agent";
+	"publish" -> "END" [label="say \"done\""];
+}
+`
+	if got := g.DrawDOT(); got != want {
+		t.Errorf("expected diagram\n%s\nbut got\n%s", want, got)
+	}
+}
+
+func TestDrawSVG(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := newDrawGraph().DrawSVG(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var svg struct {
+		Rects []struct{} `xml:"rect"`
+		Texts []struct {
+			Class string `xml:"class,attr"`
+			Text  string `xml:",chardata"`
+		} `xml:"text"`
+		Paths []struct {
+			Dash string `xml:"stroke-dasharray,attr"`
+		} `xml:"path"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &svg); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, buf.String())
+	}
+	var names, labels []string
+	for _, text := range svg.Texts {
+		if text.Class == "label" {
+			labels = append(labels, text.Text)
+		} else {
+			names = append(names, text.Text)
+		}
+	}
+	wantNames := []string{"START", "agent", "review", "tools", "any node", "END"}
+	if !slices.Equal(names, wantNames) || len(svg.Rects) != len(wantNames) {
+		t.Errorf("expected nodes %q, but got %q and %d boxes", wantNames, names, len(svg.Rects))
+	}
+	if wantLabels := []string{"done", "call"}; !slices.Equal(labels, wantLabels) {
+		t.Errorf("expected labels %q, but got %q", wantLabels, labels)
+	}
+	dashed := 0
+	for _, p := range svg.Paths {
+		if p.Dash != "" {
+			dashed++
+		}
+	}
+	if len(svg.Paths) != 5 || dashed != 3 {
+		t.Errorf("expected 5 edges of which 3 conditional, but got %d of which %d", len(svg.Paths), dashed)
+	}
+}
+
+func TestDrawPNG(t *testing.T) {
+	t.Parallel()
+
+	g := newDrawGraph()
+	want := g.DrawMermaid()
+	png := []byte("\x89PNG fake image")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		diagram, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(r.URL.Path, "/img/"))
+		if err != nil || string(diagram) != want || r.URL.Query().Get("type") != "png" {
+			http.Error(w, "bad diagram", http.StatusBadRequest)
+			return
+		}
+		w.Write(png)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "graph.png")
+	if err := g.DrawPNG(context.Background(), path, graph.WithMermaidServer(server.URL+"/")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, png) {
+		t.Errorf("expected image %q, but got %q", png, got)
+	}
+
+	g.AddNode("broken", func(context.Context, *int) error { return nil })
+	err = g.DrawPNG(context.Background(), path, graph.WithMermaidServer(server.URL), graph.WithHTTPClient(server.Client()))
+	if !errors.Is(err, graph.ErrRender) || !strings.Contains(err.Error(), "bad diagram") {
+		t.Errorf("expected error %v, but got %v", graph.ErrRender, err)
+	}
+}
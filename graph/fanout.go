@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// fanOut is the nodes a branch routed to, at nextNodes[base:top+1] in route
+// order, which runs with WithMaxParallelism run at once when they are on top
+// of the stack.
+type fanOut struct {
+	base, top int
+}
+
+// isReducer reports whether the states of type T implement Reducer, which
+// the nodes of a fan-out need to run at once.
+func isReducer[T any]() bool {
+	_, ok := any(new(T)).(Reducer[T])
+	return ok
+}
+
+// forkMerger is implemented by states that merge the forks of a fan-out
+// faster all at once than reducing them one at a time, such as MessageState.
+type forkMerger[T any] interface {
+	mergeForks(branches []T) error
+}
+
+// canRunAtOnce reports whether the named nodes, the routes of a fan-out, may
+// run at once: there are several, each once, and none is interrupted before
+// or after.
+func (r *Runnable[T]) canRunAtOnce(names []string) bool {
+	if len(names) < 2 {
+		return false
+	}
+	for i, name := range names {
+		if slices.Contains(names[:i], name) || slices.Contains(r.interruptBefore, name) || slices.Contains(r.interruptAfter, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// runFanOut runs nodes at once, at most n at a time and each on its own
+// fork of state, then merges the forks into state in order, with its Reduce
+// method unless it implements forkMerger. The callbacks are notified of the nodes from the calling
+// goroutine, NodeStart before any runs and NodeEnd once all ended. If a node
+// fails or interrupts the run, the others are canceled, state is left as it
+// was, and runFanOut returns the node and its error.
+//
+// The nodes are run by up to n workers taking the index of the next node
+// from a shared counter, each writing only the copy and error of the nodes
+// it took, so that wide fan-outs share no lock; the results are read once
+// the workers are joined. With a pool, n defaults to its size and each node
+// runs holding a slot of it, unless ctx holds one already: the nodes then
+// run one at a time in that slot.
+func (r *Runnable[T]) runFanOut(ctx context.Context, nodes []Node[T], state *T, n int, pool *WorkerPool, callbacks []CallbackHandler[T]) (Node[T], error) {
+	switch {
+	case pool != nil && pool.Held(ctx):
+		n, pool = 1, nil
+	case pool != nil && n <= 0:
+		n = pool.Size()
+	}
+
+	branches := make([]T, len(nodes))
+	for i, node := range nodes {
+		branches[i] = forkState(state)
+		for _, h := range callbacks {
+			h.NodeStart(ctx, node.Name, &branches[i])
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, len(nodes))
+	// next is the index of the next node to run, and failed that of the
+	// first node to fail, the errors of the nodes canceled then being of no
+	// interest.
+	var next atomic.Int64
+	var failed atomic.Int64
+	failed.Store(-1)
+	var wg sync.WaitGroup
+	for range min(n, len(nodes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rv resumeValues
+			for i := int(next.Add(1) - 1); i < len(nodes); i = int(next.Add(1) - 1) {
+				if err := runCtx.Err(); err != nil {
+					// The nodes not started are canceled with the others.
+					errs[i] = err
+					continue
+				}
+				rv = resumeValues{}
+				if errs[i] = r.runTask(runCtx, nodes[i], &branches[i], &rv, pool); errs[i] != nil {
+					failed.CompareAndSwap(-1, int64(i))
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, node := range nodes {
+		for _, h := range callbacks {
+			h.NodeEnd(ctx, node.Name, &branches[i], errs[i])
+		}
+	}
+	i := int(failed.Load())
+	if i < 0 {
+		// No node failed, but ctx may have been done before all started.
+		i = slices.IndexFunc(errs, func(err error) bool { return err != nil })
+	}
+	if i >= 0 {
+		return nodes[i], errs[i]
+	}
+	if m, ok := any(state).(forkMerger[T]); ok && m.mergeForks(branches) == nil {
+		return Node[T]{}, nil
+	}
+	// One at a time, to tell the node whose changes fail to merge.
+	for i, node := range nodes {
+		if err := reduceState(state, branches[i]); err != nil {
+			return node, err
+		}
+	}
+	return Node[T]{}, nil
+}
+
+// runTask runs node as a task of a fan-out, holding a slot of pool while it
+// runs if pool is not nil.
+func (r *Runnable[T]) runTask(ctx context.Context, node Node[T], state *T, rv *resumeValues, pool *WorkerPool) error {
+	if pool == nil {
+		return r.runNode(ctx, node, state, rv)
+	}
+	ctx, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Release()
+	return r.runNode(ctx, node, state, rv)
+}
+
+// nextFanOut returns the nodes of the fan-out on top of nextNodes, in route
+// order, and the height of the stack below them, if they may run at once.
+// The fan-outs no longer on the stack are dropped from fanOuts.
+func (r *Runnable[T]) nextFanOut(fanOuts *[]fanOut, nextNodes []string) ([]Node[T], int) {
+	for len(*fanOuts) > 0 {
+		f := (*fanOuts)[len(*fanOuts)-1]
+		if f.top < len(nextNodes)-1 {
+			// Nodes run before the fan-out.
+			return nil, 0
+		}
+		*fanOuts = (*fanOuts)[:len(*fanOuts)-1]
+		if f.top > len(nextNodes)-1 {
+			// Some of its nodes ran one at a time.
+			continue
+		}
+
+		var names []string
+		for _, name := range nextNodes[f.base : f.top+1] {
+			if name != END && name != "" {
+				names = append(names, name)
+			}
+		}
+		if !r.canRunAtOnce(names) {
+			return nil, 0
+		}
+		nodes := make([]Node[T], len(names))
+		for i, name := range names {
+			node, ok := r.Graph.nodes[name]
+			if !ok {
+				// Run one at a time, the run fails on the node.
+				return nil, 0
+			}
+			nodes[i] = node
+		}
+		return nodes, f.base
+	}
+	return nil, 0
+}
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// GraphEdge is an edge of the topology of a graph, see StateGraph.Edges.
+type GraphEdge struct {
+	From string
+	To   string
+
+	// Conditional reports whether the edge is a route of a conditional edge.
+	Conditional bool
+}
+
+// String returns the edge as drawn by DrawASCII, such as "agent ..> tools".
+func (e GraphEdge) String() string {
+	arrow := "-->"
+	if e.Conditional {
+		arrow = "..>"
+	}
+	return e.From + " " + arrow + " " + e.To
+}
+
+// Edges returns the edges of the graph, sorted, starting with the edge from
+// START to the entry point. Conditional edges whose targets are not known
+// lead to "*".
+func (g *StateGraph[T]) Edges() []GraphEdge {
+	_, drawn := g.topology()
+	edges := make([]GraphEdge, 0, len(drawn))
+	for _, e := range drawn {
+		edge := GraphEdge{From: e.from, To: e.to, Conditional: e.conditional}
+		if !slices.Contains(edges, edge) {
+			edges = append(edges, edge)
+		}
+	}
+	slices.SortFunc(edges, compareEdges)
+	return edges
+}
+
+func compareEdges(a, b GraphEdge) int {
+	if (a.From == START) != (b.From == START) {
+		if a.From == START {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.String(), b.String())
+}
+
+// nodeNames returns the names of the nodes of the graph, sorted.
+func (g *StateGraph[T]) nodeNames() []string {
+	names := make([]string, 0, len(g.nodes))
+	for name := range g.nodes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Fingerprint returns a hash of the topology of the graph: its nodes, entry
+// point and edges. It is the same for graphs built in any order and changes
+// with their topology only, not with the functions, metadata or options of
+// nodes nor with the labels of edges, so that deployments can tell whether
+// the topology of a graph changed between versions, see Diff.
+func (g *StateGraph[T]) Fingerprint() string {
+	h := sha256.New()
+	for _, name := range g.nodeNames() {
+		fmt.Fprintf(h, "node %q\n", name)
+	}
+	for _, edge := range g.Edges() {
+		fmt.Fprintf(h, "edge %q %q %t\n", edge.From, edge.To, edge.Conditional)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GraphDiff is the difference between the topologies of two graphs, see Diff.
+type GraphDiff struct {
+	AddedNodes   []string
+	RemovedNodes []string
+	AddedEdges   []GraphEdge
+	RemovedEdges []GraphEdge
+}
+
+// IsEmpty reports whether the topologies are the same.
+func (d GraphDiff) IsEmpty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0
+}
+
+// String returns the difference as lines starting with "+" for additions and
+// "-" for removals, such as "+ node review" or "- edge agent --> END".
+func (d GraphDiff) String() string {
+	var b strings.Builder
+	for _, name := range d.RemovedNodes {
+		fmt.Fprintf(&b, "- node %s\n", name)
+	}
+	for _, name := range d.AddedNodes {
+		fmt.Fprintf(&b, "+ node %s\n", name)
+	}
+	for _, edge := range d.RemovedEdges {
+		fmt.Fprintf(&b, "- edge %s\n", edge)
+	}
+	for _, edge := range d.AddedEdges {
+		fmt.Fprintf(&b, "+ edge %s\n", edge)
+	}
+	return b.String()
+}
+
+// Diff returns the nodes and edges of b that are not in a, as added, and
+// those of a that are not in b, as removed. A change of entry point shows as
+// the edge from START changing.
+func Diff[T any](a, b *StateGraph[T]) GraphDiff {
+	return GraphDiff{
+		AddedNodes:   missing(b.nodeNames(), a.nodeNames()),
+		RemovedNodes: missing(a.nodeNames(), b.nodeNames()),
+		AddedEdges:   missing(b.Edges(), a.Edges()),
+		RemovedEdges: missing(a.Edges(), b.Edges()),
+	}
+}
+
+// missing returns the items of from that are not in in, in order.
+func missing[E comparable](from, in []E) []E {
+	var items []E
+	for _, item := range from {
+		if !slices.Contains(in, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,65 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]string, error) { return nil, nil }
+
+	a := graph.NewStateGraph[int]()
+	a.AddNode("agent", noop)
+	a.AddNode("tools", noop)
+	a.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"call": "tools", "done": graph.END}))
+	a.AddEdge("tools", "agent")
+	a.SetEntryPoint("agent")
+
+	// The same topology built in another order, with other functions,
+	// metadata and labels.
+	b := graph.NewStateGraph[int]()
+	b.AddNode("tools", func(context.Context, *int) error { return nil }, graph.WithTags("io"))
+	b.AddNode("agent", noop, graph.WithRetry(graph.RetryPolicy{}))
+	b.SetEntryPoint("agent")
+	b.AddEdge("tools", "agent", graph.WithLabel("observe"))
+	b.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"needs_tools": "tools", "final_answer": graph.END}))
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("expected equal fingerprints, but got %s and %s", a.Fingerprint(), b.Fingerprint())
+	}
+	if diff := graph.Diff(a, b); !diff.IsEmpty() {
+		t.Errorf("expected no difference, but got\n%s", diff)
+	}
+
+	b.AddNode("review", noop)
+	b.RemoveConditionalEdges("agent")
+	b.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"call": "tools", "done": "review"}))
+	b.AddEdge("review", graph.END)
+
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("expected different fingerprints")
+	}
+	want := `+ node review
+- edge agent ..> END
++ edge agent ..> review
++ edge review --> END
+`
+	if got := graph.Diff(a, b).String(); got != want {
+		t.Errorf("expected difference\n%s\nbut got\n%s", want, got)
+	}
+
+	// Edges from START come first.
+	b.SetEntryPoint("tools")
+	diff := graph.Diff(a, b)
+	if got, want := diff.RemovedEdges[0], (graph.GraphEdge{From: graph.START, To: "agent"}); got != want {
+		t.Errorf("expected removed edge %s, but got %s", want, got)
+	}
+	if got, want := diff.AddedEdges[0], (graph.GraphEdge{From: graph.START, To: "tools"}); got != want {
+		t.Errorf("expected added edge %s, but got %s", want, got)
+	}
+}
@@ -4,6 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // END is a special constant used to represent the end node in the graph.
@@ -18,6 +25,24 @@ var (
 
 	// ErrNoOutgoingEdge is returned when no outgoing edge is found for a node.
 	ErrNoOutgoingEdge = errors.New("no outgoing edge found for node")
+
+	// ErrDuplicateNode is returned when a node is added under the name of an
+	// existing node.
+	ErrDuplicateNode = errors.New("duplicate node")
+
+	// ErrEmptyName is returned when a node or an edge endpoint has no name.
+	ErrEmptyName = errors.New("empty node name")
+
+	// ErrEdgeNotFound is returned when removing an edge that is not in the graph.
+	ErrEdgeNotFound = errors.New("edge not found")
+
+	// ErrInvalidOption is returned by Compile and Invoke for invalid or
+	// incompatible options.
+	ErrInvalidOption = errors.New("invalid compile option")
+
+	// ErrStepLimit is returned when an invocation reaches the step limit, see
+	// WithStepLimit.
+	ErrStepLimit = errors.New("step limit reached")
 )
 
 // Node represents a node in the message graph.
@@ -28,6 +53,25 @@ type Node[T any] struct {
 	// Function is the function associated with the node.
 	// It takes a context and a slice of MessageContent as input and returns a slice of MessageContent and an error.
 	Function func(ctx context.Context, state *T) error
+
+	// Metadata describes the node, see NodeMetadata.
+	Metadata NodeMetadata
+
+	// Retry, when set, retries the node when it fails, see WithRetry.
+	Retry *RetryPolicy
+
+	// Timeout, when positive, bounds every run of the node, see WithTimeout.
+	Timeout time.Duration
+
+	// Cache, when set, caches the results of the node, see WithCache.
+	Cache *CachePolicy
+
+	// MaxConcurrency, when positive, limits how many runs of the node the
+	// compiled graph executes at once, see WithMaxConcurrency.
+	MaxConcurrency int
+
+	// Subgraph is the graph the node runs, if added with AddSubgraph.
+	Subgraph *StateGraph[T]
 }
 
 // Edge represents an edge in the message graph.
@@ -41,6 +85,12 @@ type Edge[T any] interface {
 type SimpleEdge[state any] struct {
 	from string
 	to   string
+
+	// label names the edge in drawings, see WithLabel.
+	label string
+
+	// priority orders the edges from the same node, see WithPriority.
+	priority int
 }
 
 func (e *SimpleEdge[state]) From() string {
@@ -55,6 +105,24 @@ type Branch[state any] struct {
 	Mapping func(x string) string
 	Then    string
 	Source  string
+
+	// Targets lists the nodes Mapping can route to, as given to WithMap. When
+	// nil, the branch is assumed to route to any node.
+	Targets []string
+
+	// Routes maps the routes to nodes, as given to WithMap. Drawings label
+	// the edges of the branch with their routes.
+	Routes map[string]string
+
+	// Default is the node to route to when no route matches, see
+	// AddDefaultEdge.
+	Default string
+
+	// Priority orders the edges from the same node, see WithBranchPriority.
+	Priority int
+
+	// Labels lists the routes Path may return, as given to WithLabels.
+	Labels []string
 }
 
 func (b *Branch[s]) From() string {
@@ -68,7 +136,12 @@ func (b *Branch[s]) To(ctx context.Context, state *s) []string {
 	}
 	n := []string{}
 	for _, path := range paths {
-		n = append(n, b.Mapping(path))
+		if to := b.Mapping(path); to != "" {
+			n = append(n, to)
+		}
+	}
+	if len(n) == 0 && b.Default != "" {
+		n = append(n, b.Default)
 	}
 	return append(n, b.Then)
 }
@@ -76,13 +149,30 @@ func (b *Branch[s]) To(ctx context.Context, state *s) []string {
 type ConditionalEdgeOptions[T any] struct {
 	Mapping func(x string) string
 	Then    string
+	Targets []string
+	Routes  map[string]string
+	Default string
+
+	// Priority orders the edges from the same node, see WithBranchPriority.
+	Priority *int
+
+	Labels []string
 }
 
 func WithMap[T any](pathMap map[string]string) ConditionalEdgeOptions[T] {
+	targets := make([]string, 0, len(pathMap))
+	for _, target := range pathMap {
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	slices.Sort(targets)
 	return ConditionalEdgeOptions[T]{
 		Mapping: func(x string) string {
 			return pathMap[x]
 		},
+		Targets: targets,
+		Routes:  pathMap,
 	}
 }
 
@@ -92,12 +182,38 @@ func WithThen[T any](then string) ConditionalEdgeOptions[T] {
 	}
 }
 
+// WithBranchPriority sets the priority of the conditional edge among the
+// edges from its node, see WithPriority.
+func WithBranchPriority[T any](priority int) ConditionalEdgeOptions[T] {
+	return ConditionalEdgeOptions[T]{
+		Priority: &priority,
+	}
+}
+
+// WithLabels lists the routes the path function of the conditional edge may
+// return, for Validate to report those missing from the map given to WithMap
+// with ErrMissingRoute, unless the edge has a default edge, see
+// AddDefaultEdge.
+func WithLabels[T any](labels ...string) ConditionalEdgeOptions[T] {
+	return ConditionalEdgeOptions[T]{
+		Labels: labels,
+	}
+}
+
+// WithDefault routes the conditional edge to the node def when none of the
+// routes returned by its path function matches, see AddDefaultEdge.
+func WithDefault[T any](def string) ConditionalEdgeOptions[T] {
+	return ConditionalEdgeOptions[T]{
+		Default: def,
+	}
+}
+
 // AddConditionalEdges adds a conditional edge from the starting node to any number of destination nodes.
 // It allows for dynamic determination of the next nodes based on the provided path function.
 //
 // Parameters:
 // - source (string): The starting node. This conditional edge will run when exiting this node.
-// - path (Callable[T, []string]): The callable that determines the next node or nodes. If not specifying pathMap, it should return one or more nodes. If it returns "END", the graph will stop execution.
+// - path (Callable[T, []string]): The callable that determines the next node or nodes. If not specifying pathMap, it should return one or more nodes. If it returns "END", or a path mapped to "END", the branch ends: the other nodes it returns and the then node still run.
 // - pathMap (map[string]string, optional): Optional mapping of paths to node names. If omitted, the paths returned by path should be node names.
 // - then (string, optional): The name of a node to execute after the nodes selected by path.
 //
@@ -121,10 +237,21 @@ func (g *StateGraph[T]) AddConditionalEdges(
 	for _, option := range options {
 		if option.Mapping != nil {
 			branch.Mapping = option.Mapping
+			branch.Targets = option.Targets
+			branch.Routes = option.Routes
 		}
 		if option.Then != "" {
 			branch.Then = option.Then
 		}
+		if option.Default != "" {
+			branch.Default = option.Default
+		}
+		if option.Priority != nil {
+			branch.Priority = *option.Priority
+		}
+		if option.Labels != nil {
+			branch.Labels = option.Labels
+		}
 	}
 
 	// Add the Branch edge to the graph's edges
@@ -143,6 +270,10 @@ type StateGraph[T any] struct {
 
 	// entryPoint is the name of the entry point node in the graph.
 	entryPoint string
+
+	// entryPoints maps the names of the named entry points to their nodes,
+	// see SetNamedEntryPoint.
+	entryPoints map[string]string
 }
 
 // NewStateGraph creates a new instance of StateGraph.
@@ -153,55 +284,609 @@ func NewStateGraph[T any]() *StateGraph[T] {
 }
 
 // AddNode adds a new node to the message graph with the given name and function.
-func (g *StateGraph[T]) AddNode(name string, fn func(ctx context.Context, state *T) error) {
+// Options describe the node and configure how it runs, such as WithTags,
+// WithRetry, WithTimeout, WithCache and WithMaxConcurrency.
+//
+// It returns a reference to the node, see NodeRef, or ErrEmptyName or
+// ErrDuplicateNode, leaving the graph unchanged.
+func (g *StateGraph[T]) AddNode(name string, fn func(ctx context.Context, state *T) error, opts ...NodeOption) (NodeRef[T], error) {
+	if name == "" {
+		return NodeRef[T]{}, fmt.Errorf("%w: node", ErrEmptyName)
+	}
+	if _, ok := g.nodes[name]; ok {
+		return NodeRef[T]{}, fmt.Errorf("%w: %s", ErrDuplicateNode, name)
+	}
+	var cfg nodeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	g.nodes[name] = Node[T]{
-		Name:     name,
-		Function: fn,
+		Name:           name,
+		Function:       fn,
+		Metadata:       cfg.metadata,
+		Retry:          cfg.retry,
+		Timeout:        cfg.timeout,
+		Cache:          cfg.cache,
+		MaxConcurrency: cfg.maxConcurrency,
 	}
+	return NodeRef[T]{name: name}, nil
 }
 
 // AddEdge adds a new edge to the message graph between the "from" and "to" nodes.
-func (g *StateGraph[T]) AddEdge(from, to string) {
+// Both nodes must have been added, except for END. Options such as WithLabel
+// describe the edge; WithPriority orders it among the edges from the node.
+//
+// It returns ErrEmptyName or ErrNodeNotFound, leaving the graph unchanged.
+func (g *StateGraph[T]) AddEdge(from, to string, opts ...EdgeOption) error {
+	if from == "" || to == "" {
+		return fmt.Errorf("%w: edge from %q to %q", ErrEmptyName, from, to)
+	}
+	if _, ok := g.nodes[from]; !ok {
+		return fmt.Errorf("%w: edge from %s", ErrNodeNotFound, from)
+	}
+	if _, ok := g.nodes[to]; !ok && to != END {
+		return fmt.Errorf("%w: edge from %s to %s", ErrNodeNotFound, from, to)
+	}
+	g.addEdge(from, to, opts...)
+	return nil
+}
+
+// addEdge adds an edge without checking its nodes, which Compile validates.
+func (g *StateGraph[T]) addEdge(from, to string, opts ...EdgeOption) {
+	var cfg edgeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	g.edges = append(g.edges, &SimpleEdge[T]{
-		from: from,
-		to:   to,
+		from:     from,
+		to:       to,
+		label:    cfg.label,
+		priority: cfg.priority,
 	})
 }
 
-// SetEntryPoint sets the entry point node name for the message graph.
-func (g *StateGraph[T]) SetEntryPoint(name string) {
+// AddDefaultEdge makes the conditional edges from the node from route to the
+// node to when none of the routes returned by their path function matches,
+// such as a route missing from the map given to WithMap. Without a default
+// edge, unmatched routes are skipped. The node to must have been added,
+// except for END.
+//
+// It returns ErrEmptyName, ErrNodeNotFound, or ErrEdgeNotFound if there is no
+// conditional edge from the node, leaving the graph unchanged.
+func (g *StateGraph[T]) AddDefaultEdge(from, to string) error {
+	if from == "" || to == "" {
+		return fmt.Errorf("%w: default edge from %q to %q", ErrEmptyName, from, to)
+	}
+	if _, ok := g.nodes[to]; !ok && to != END {
+		return fmt.Errorf("%w: default edge from %s to %s", ErrNodeNotFound, from, to)
+	}
+	found := false
+	for _, edge := range g.edges {
+		if branch, ok := edge.(*Branch[T]); ok && branch.Source == from {
+			branch.Default = to
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: conditional from %s", ErrEdgeNotFound, from)
+	}
+	return nil
+}
+
+// SetEntryPoint sets the entry point node name for the message graph. The
+// node must have been added.
+//
+// It returns ErrEmptyName or ErrNodeNotFound, leaving the graph unchanged.
+func (g *StateGraph[T]) SetEntryPoint(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: entry point", ErrEmptyName)
+	}
+	if _, ok := g.nodes[name]; !ok {
+		return fmt.Errorf("%w: entry point %s", ErrNodeNotFound, name)
+	}
 	g.entryPoint = name
+	return nil
+}
+
+// SetNamedEntryPoint sets the node as the entry point with the given name, for
+// invocations to start from with WithEntryPoint, so that a graph serves
+// related flows such as replaying a conversation:
+//
+//	g.SetEntryPoint("agent")
+//	g.SetNamedEntryPoint("replay", "load_history")
+//	...
+//	runnable.Invoke(ctx, &state, graph.WithEntryPoint("replay"))
+//
+// Invocations without WithEntryPoint start from the entry point set with
+// SetEntryPoint, which is still required.
+//
+// It returns ErrEmptyName or ErrNodeNotFound, leaving the graph unchanged.
+func (g *StateGraph[T]) SetNamedEntryPoint(name, node string) error {
+	if name == "" || node == "" {
+		return fmt.Errorf("%w: entry point %q at %q", ErrEmptyName, name, node)
+	}
+	if _, ok := g.nodes[node]; !ok {
+		return fmt.Errorf("%w: entry point %s at %s", ErrNodeNotFound, name, node)
+	}
+	g.setNamedEntryPoint(name, node)
+	return nil
+}
+
+// setNamedEntryPoint sets a named entry point without checking its node,
+// which Compile validates.
+func (g *StateGraph[T]) setNamedEntryPoint(name, node string) {
+	if g.entryPoints == nil {
+		g.entryPoints = make(map[string]string)
+	}
+	g.entryPoints[name] = node
+}
+
+// RemoveNode removes the node with the given name, its outgoing edges and the
+// edges leading to it, and unsets the entry points at the node.
+// Conditional edges routing to the node are kept, since their routes cannot
+// be changed, and are reported by Compile if still present.
+//
+// It returns ErrNodeNotFound if there is no such node.
+func (g *StateGraph[T]) RemoveNode(name string) error {
+	if _, ok := g.nodes[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	delete(g.nodes, name)
+	g.edges = slices.DeleteFunc(g.edges, func(edge Edge[T]) bool {
+		simple, ok := edge.(*SimpleEdge[T])
+		return edge.From() == name || ok && simple.to == name
+	})
+	if g.entryPoint == name {
+		g.entryPoint = ""
+	}
+	maps.DeleteFunc(g.entryPoints, func(_, node string) bool {
+		return node == name
+	})
+	return nil
+}
+
+// RemoveEdge removes the edges from the "from" node to the "to" node added
+// with AddEdge.
+//
+// It returns ErrEdgeNotFound if there is no such edge.
+func (g *StateGraph[T]) RemoveEdge(from, to string) error {
+	n := len(g.edges)
+	g.edges = slices.DeleteFunc(g.edges, func(edge Edge[T]) bool {
+		simple, ok := edge.(*SimpleEdge[T])
+		return ok && simple.from == from && simple.to == to
+	})
+	if len(g.edges) == n {
+		return fmt.Errorf("%w: from %s to %s", ErrEdgeNotFound, from, to)
+	}
+	return nil
+}
+
+// RemoveConditionalEdges removes the conditional edges from the source node
+// added with AddConditionalEdges.
+//
+// It returns ErrEdgeNotFound if there is no such edge.
+func (g *StateGraph[T]) RemoveConditionalEdges(source string) error {
+	n := len(g.edges)
+	g.edges = slices.DeleteFunc(g.edges, func(edge Edge[T]) bool {
+		_, ok := edge.(*Branch[T])
+		return ok && edge.From() == source
+	})
+	if len(g.edges) == n {
+		return fmt.Errorf("%w: conditional from %s", ErrEdgeNotFound, source)
+	}
+	return nil
+}
+
+// ReplaceNode replaces the function of the node with the given name by fn,
+// keeping its edges, metadata and policies, such as to stub a node in tests.
+// A subgraph node becomes a plain node. Runnables compiled from g run fn
+// from the next time they reach the node.
+//
+// It returns ErrNodeNotFound if there is no such node.
+func (g *StateGraph[T]) ReplaceNode(name string, fn func(ctx context.Context, state *T) error) error {
+	node, ok := g.nodes[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	node.Function = fn
+	node.Subgraph = nil
+	g.nodes[name] = node
+	return nil
+}
+
+// Clone returns a copy of the graph, whose nodes and edges can be added,
+// removed or replaced without changing the graph.
+func (g *StateGraph[T]) Clone() *StateGraph[T] {
+	return &StateGraph[T]{
+		nodes:       maps.Clone(g.nodes),
+		edges:       slices.Clone(g.edges),
+		entryPoint:  g.entryPoint,
+		entryPoints: maps.Clone(g.entryPoints),
+	}
 }
 
 // Runnable represents a compiled message graph that can be invoked.
 type Runnable[T any] struct {
 	// Graph is the underlying StateGraph object.
 	Graph *StateGraph[T]
+
+	// checkpointer saves the state of threads, if set.
+	checkpointer Checkpointer[T]
+
+	// edges are the outgoing edges of the nodes, by source node, in the
+	// order they are followed, see indexEdges.
+	edges map[string][]Edge[T]
+
+	// plan is the static execution plan of the graph, if its edges are all
+	// simple, see compilePlan.
+	plan *plan[T]
+
+	// semaphores limit the concurrent runs of the nodes with MaxConcurrency.
+	semaphores map[string]chan struct{}
+
+	// cache holds the results of the nodes with a Cache policy.
+	cache nodeCache[T]
+
+	// interruptBefore and interruptAfter are the nodes to interrupt the run
+	// before or after.
+	interruptBefore []string
+	interruptAfter  []string
+
+	// stepLimit is the maximum number of nodes an invocation runs, if
+	// positive.
+	stepLimit int
+
+	// implicitEnd makes nodes without outgoing edges end their branch.
+	implicitEnd bool
+
+	// callbacks are notified of the runs of nodes.
+	callbacks []CallbackHandler[T]
+
+	// chaos injects faults into runs, if set, see WithChaos.
+	chaos *chaos
+
+	// clock tells the time, see WithClock.
+	clock Clock
+
+	// checkpointBatch is the longest checkpoints are held before being
+	// saved, if positive, see WithCheckpointBatching.
+	checkpointBatch time.Duration
+
+	// scheduler schedules the nodes of fan-outs, see WithScheduler.
+	scheduler Scheduler
 }
 
 // Compile compiles the message graph and returns a Runnable instance.
-// It returns an error if the entry point is not set.
-func (g *StateGraph[T]) Compile() (*Runnable[T], error) {
-	if g.entryPoint == "" {
-		return nil, ErrEntryPointNotSet
+// It returns an error if the graph is invalid (see Validate), has cycles that
+// are not allowed (see WithAllowedCycle), or options are invalid or
+// incompatible (see ErrInvalidOption).
+//
+// The edges are indexed by source node, so that a run finds the edge to
+// follow after a node without scanning the graph, and graphs whose edges are
+// all simple are compiled to a plan linking each node to the next: edges
+// added to g after Compile are not followed by the Runnable, compile the
+// graph again.
+func (g *StateGraph[T]) Compile(opts ...CompileOption) (*Runnable[T], error) {
+	var cfg compileConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := g.validate(cfg.implicitEnd); err != nil {
+		return nil, err
+	}
+	if err := g.checkCycles(cfg.allowedCycles); err != nil {
+		return nil, err
+	}
+
+	r := &Runnable[T]{
+		Graph:           g,
+		edges:           g.indexEdges(),
+		semaphores:      make(map[string]chan struct{}),
+		interruptBefore: cfg.interruptBefore,
+		interruptAfter:  cfg.interruptAfter,
+		stepLimit:       cfg.stepLimit,
+		implicitEnd:     cfg.implicitEnd,
+		clock:           cfg.clock,
+		checkpointBatch: cfg.checkpointBatch,
+		scheduler:       cfg.scheduler,
+	}
+	if r.clock == nil {
+		r.clock = realClock{}
+	}
+	r.plan = g.compilePlan(r.edges)
+	for name, node := range g.nodes {
+		if node.MaxConcurrency > 0 {
+			r.semaphores[name] = make(chan struct{}, node.MaxConcurrency)
+		}
+	}
+
+	var errs []error
+	if cfg.checkpointer != nil {
+		cp, ok := cfg.checkpointer.(Checkpointer[T])
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %T", ErrCheckpointerType, cfg.checkpointer))
+		}
+		r.checkpointer = cp
+	}
+	for _, name := range append(slices.Clone(cfg.interruptBefore), cfg.interruptAfter...) {
+		if _, ok := g.nodes[name]; !ok {
+			errs = append(errs, fmt.Errorf("%w: interrupt at %s", ErrNodeNotFound, name))
+		}
+	}
+	if len(cfg.interruptBefore)+len(cfg.interruptAfter) > 0 && cfg.checkpointer == nil {
+		errs = append(errs, fmt.Errorf("%w: interrupts require a checkpointer", ErrInvalidOption))
+	}
+	if cfg.checkpointBatch < 0 {
+		errs = append(errs, fmt.Errorf("%w: negative checkpoint batching delay %v", ErrInvalidOption, cfg.checkpointBatch))
+	}
+	if cfg.checkpointBatch > 0 && cfg.checkpointer == nil {
+		errs = append(errs, fmt.Errorf("%w: checkpoint batching requires a checkpointer", ErrInvalidOption))
+	}
+	if cfg.stepLimit < 0 {
+		errs = append(errs, fmt.Errorf("%w: negative step limit %d", ErrInvalidOption, cfg.stepLimit))
 	}
+	switch cfg.scheduler {
+	case SchedulerSequential:
+	case SchedulerParallel:
+		if !isReducer[T]() {
+			errs = append(errs, fmt.Errorf("%w: parallel scheduler requires a state implementing Reducer", ErrInvalidOption))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%w: unknown scheduler %d", ErrInvalidOption, cfg.scheduler))
+	}
+	for _, h := range cfg.callbacks {
+		handler, ok := h.(CallbackHandler[T])
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: callback handler %T does not match the graph state type", ErrInvalidOption, h))
+			continue
+		}
+		r.callbacks = append(r.callbacks, handler)
+	}
+	if cfg.debug != nil {
+		r.callbacks = append(r.callbacks, &debugHandler[T]{w: cfg.debug})
+	}
+	if cfg.chaos != nil {
+		var err error
+		if r.chaos, err = newChaos(*cfg.chaos); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
 
-	return &Runnable[T]{
-		Graph: g,
-	}, nil
+// Checkpointer returns the checkpointer the graph was compiled with, see
+// WithCheckpointer, or nil.
+func (r *Runnable[T]) Checkpointer() Checkpointer[T] {
+	return r.checkpointer
 }
 
-// Invoke executes the compiled message graph with the given input messages.
-// It returns the resulting messages and an error if any occurs during the execution.
-// Invoke executes the compiled message graph with the given input messages.
-// It returns the resulting messages and an error if any occurs during the execution.
-func (r *Runnable[T]) Invoke(ctx context.Context, state *T) error {
-	nextNodes := []string{r.Graph.entryPoint}
+// GetState returns the latest checkpoint of a thread.
+// It returns ErrNoCheckpointer if the graph was compiled without a checkpointer.
+func (r *Runnable[T]) GetState(ctx context.Context, threadID string) (Checkpoint[T], error) {
+	if r.checkpointer == nil {
+		return Checkpoint[T]{}, ErrNoCheckpointer
+	}
+	return r.checkpointer.Get(ctx, threadID)
+}
 
-	pop := func() string {
-		if len(nextNodes) == 0 {
-			return END
+// UpdateState merges update into the latest state of a thread as if it was
+// returned by the node that ran last, and saves the result as a new
+// checkpoint. States implementing Reducer merge the update with their Reduce
+// method, as MessageState does with AddMessages; other states are replaced.
+//
+// The nodes scheduled to run are kept, so an interrupted run can still be
+// resumed with the updated state.
+func (r *Runnable[T]) UpdateState(ctx context.Context, threadID string, update T) error {
+	if r.checkpointer == nil {
+		return ErrNoCheckpointer
+	}
+	if threadID == "" {
+		return ErrThreadRequired
+	}
+	cp, err := r.checkpointer.Get(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	state := cloneState(&cp.State)
+	if err := reduceState(&state, update); err != nil {
+		return fmt.Errorf("update state of thread %s: %w", threadID, err)
+	}
+	cp.ID = uuid.NewString()
+	cp.State = state
+	cp.CreatedAt = r.clock.Now()
+	return r.checkpointer.Put(ctx, cp)
+}
+
+// Invoke executes the compiled message graph, updating state in place.
+// It returns an error if any occurs during the execution.
+//
+// When the invocation runs on a thread (see WithThreadID) and the graph was
+// compiled with a checkpointer, a checkpoint is saved after every node, or
+// in batches, see WithCheckpointBatching.
+//
+// If a node calls Interrupt, Invoke returns a *GraphInterrupt. On a
+// checkpointed thread, state is then rolled back to the last checkpoint and
+// the run can be continued with WithResume.
+func (r *Runnable[T]) Invoke(ctx context.Context, state *T, opts ...InvokeOption) error {
+	var cfg invokeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	checkpointer := r.checkpointer
+	if r.chaos != nil && checkpointer != nil {
+		checkpointer = chaosCheckpointer[T]{Checkpointer: checkpointer, chaos: r.chaos}
+	}
+	if r.checkpointBatch <= 0 || checkpointer == nil {
+		return r.invoke(ctx, state, cfg, checkpointer)
+	}
+	batch := newBatchCheckpointer(ctx, checkpointer, r.clock, r.checkpointBatch)
+	err := r.invoke(ctx, state, cfg, batch)
+	if flushErr := batch.flush(); flushErr != nil {
+		return errors.Join(err, flushErr)
+	}
+	return err
+}
+
+// Step runs the next node scheduled by cp, a checkpoint of the graph, and
+// returns the checkpoint taken after it. Step saves no checkpoint, leaving
+// the state of runs to the caller, such as a workflow engine. A zero cp.ID
+// starts a new run with cp.State, see WithEntryPoint; interrupted
+// checkpoints are resumed with WithResume, Step otherwise returning their
+// interrupt. The node is run as by Invoke, options other than WithEntryPoint,
+// WithResume, WithRunCallbacks and WithMessageStream being ignored.
+//
+// Step returns a *GraphInterrupt with the interrupted checkpoint if the node
+// interrupted the run. The run ended if the returned checkpoint schedules no
+// nodes.
+func (r *Runnable[T]) Step(ctx context.Context, cp Checkpoint[T], opts ...InvokeOption) (Checkpoint[T], error) {
+	var cfg invokeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cfg.threadID = cp.ThreadID
+	if cfg.threadID == "" {
+		cfg.threadID = "step"
+	}
+	cfg.cont = !cfg.resume && cp.ID != ""
+	cfg.maxSteps = 1
+
+	saver := NewMemorySaver[T]()
+	state := cp.State
+	if cp.ID != "" {
+		cp.ThreadID = cfg.threadID
+		if err := saver.Put(ctx, cp); err != nil {
+			return Checkpoint[T]{}, err
+		}
+	}
+	err := r.invoke(ctx, &state, cfg, saver)
+	var gi *GraphInterrupt
+	if err != nil && !errors.Is(err, ErrPaused) && !errors.As(err, &gi) {
+		return Checkpoint[T]{}, err
+	}
+	next, getErr := saver.Get(ctx, cfg.threadID)
+	if getErr != nil {
+		return Checkpoint[T]{}, getErr
+	}
+	next.ThreadID = cp.ThreadID
+	if gi != nil {
+		return next, gi
+	}
+	return next, nil
+}
+
+// invoke runs the graph as configured by cfg, checkpointing threads with
+// checkpointer if it is not nil.
+func (r *Runnable[T]) invoke(ctx context.Context, state *T, cfg invokeConfig, checkpointer Checkpointer[T]) error {
+	// parallel runs the nodes of fan-outs at once, see WithScheduler,
+	// WithMaxParallelism and WithWorkerPool, at most width at a time.
+	parallel := r.scheduler == SchedulerParallel
+	width := cfg.maxParallelism
+	switch {
+	case cfg.maxParallelism < 0:
+		return fmt.Errorf("%w: negative max parallelism %d", ErrInvalidOption, cfg.maxParallelism)
+	case cfg.maxParallelism > 0:
+		ctx = context.WithValue(ctx, maxParallelismKey{}, cfg.maxParallelism)
+		parallel = isReducer[T]()
+	}
+	// The graphs run by nodes use the pool of their run unless given one.
+	switch inherited, _ := ctx.Value(workerPoolKey{}).(*WorkerPool); {
+	case cfg.pool == nil:
+		cfg.pool = inherited
+	case cfg.pool != inherited:
+		ctx = context.WithValue(ctx, workerPoolKey{}, cfg.pool)
+	}
+	if cfg.pool != nil {
+		parallel = isReducer[T]()
+	}
+	if width == 0 && cfg.pool == nil {
+		// Unlimited, with SchedulerParallel.
+		width = math.MaxInt
+	}
+	if send := cfg.messageStream; send != nil {
+		if parallel {
+			// The nodes of fan-outs emit deltas at once.
+			var mu sync.Mutex
+			send = func(ctx context.Context, d MessageDelta) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return cfg.messageStream(ctx, d)
+			}
+		}
+		ctx = context.WithValue(ctx, messageStreamKey{}, send)
+	}
+	callbacks := r.callbacks
+	if len(cfg.callbacks) > 0 {
+		callbacks = slices.Clone(r.callbacks)
+		for _, h := range cfg.callbacks {
+			handler, ok := h.(CallbackHandler[T])
+			if !ok {
+				return fmt.Errorf("%w: callback handler %T does not match the graph state type", ErrInvalidOption, h)
+			}
+			callbacks = append(callbacks, handler)
+		}
+	}
+
+	entryPoint := r.Graph.entryPoint
+	if cfg.entryPoint != "" {
+		var ok bool
+		if entryPoint, ok = r.Graph.entryPoints[cfg.entryPoint]; !ok {
+			return fmt.Errorf("%w: %s", ErrEntryPointNotSet, cfg.entryPoint)
+		}
+	}
+	stack := stacks.Get().(*[]string)
+	nextNodes := append((*stack)[:0], entryPoint)
+	defer func() {
+		clear(nextNodes[:cap(nextNodes)])
+		*stack = nextNodes[:0]
+		stacks.Put(stack)
+	}()
+	step := 0
+	var resume []any
+	resumedBefore := false
+	if cfg.resume || cfg.cont {
+		var cp Checkpoint[T]
+		var err error
+		if cfg.resume {
+			cp, err = resumeCheckpoint(ctx, checkpointer, cfg.threadID)
+		} else {
+			cp, err = continueCheckpoint(ctx, checkpointer, cfg.threadID)
+		}
+		if err != nil {
+			return err
+		}
+		*state = cp.State
+		nextNodes = nextNodes[:0]
+		for i := len(cp.Next) - 1; i >= 0; i-- {
+			nextNodes = append(nextNodes, cp.Next[i])
+		}
+		step = cp.Step
+		switch {
+		case cp.Interrupt == nil:
+		case cp.Interrupt.Before || cp.Interrupt.After:
+			// The interrupted node is not interrupted before again.
+			resumedBefore = cp.Interrupt.Before
+		default:
+			resume = append(cp.Interrupt.Resumes, cfg.resumeValue)
+		}
+	}
+
+	// last is the state as of the last checkpoint, restored on interrupts.
+	var last T
+	checkpointing := checkpointer != nil && cfg.threadID != ""
+	if (cfg.maxSteps > 0 || cfg.pause != nil) && !checkpointing {
+		if checkpointer == nil {
+			return ErrNoCheckpointer
 		}
+		return ErrThreadRequired
+	}
+	if checkpointing {
+		last = cloneState(state)
+	}
+
+	pop := func() string {
 		item := nextNodes[len(nextNodes)-1]
 		nextNodes = nextNodes[:len(nextNodes)-1]
 		return item
@@ -213,22 +898,109 @@ func (r *Runnable[T]) Invoke(ctx context.Context, state *T) error {
 		return nextNodes[len(nextNodes)-1]
 	}
 
-	for {
+	// steps counts the nodes run by this invocation, for the step limit.
+	steps := 0
+	// rv and gi are reused by the steps of the invocation.
+	var rv resumeValues
+	var gi *GraphInterrupt
+	// fanOuts are the fan-outs of the stack whose nodes may run at once,
+	// the last being the highest.
+	var fanOuts []fanOut
+	// at is the index in the plan of the node on top of nextNodes, if the
+	// graph has a static plan and the node was pushed by following it, and
+	// -1 otherwise.
+	at := -1
+	for len(nextNodes) > 0 {
+		var currentNode string
+		// index is the index in the plan of the node run, if any.
+		index := -1
+		// ran is the number of nodes run by this step, several when the
+		// nodes of a fan-out run at once.
+		ran := 1
+		nodes, base := r.nextFanOut(&fanOuts, nextNodes)
+		switch {
+		case nodes != nil && resume == nil && !resumedBefore &&
+			(r.stepLimit <= 0 || steps+len(nodes) <= r.stepLimit) &&
+			(cfg.maxSteps <= 0 || steps+len(nodes) <= cfg.maxSteps):
+			nextNodes = nextNodes[:base]
+			failed, err := r.runFanOut(ctx, nodes, state, width, cfg.pool, callbacks)
+			if err != nil && errors.As(err, &gi) {
+				interrupt := &GraphInterrupt{Node: failed.Name, Value: gi.Value}
+				if checkpointing {
+					// The nodes of the fan-out run again, the interrupted
+					// one first.
+					next := slices.Clone(nextNodes)
+					for _, node := range nodes {
+						if node.Name != failed.Name {
+							next = append(next, node.Name)
+						}
+					}
+					if err := saveCheckpoint(ctx, checkpointer, cfg, r.clock.Now(), step, failed.Name, last, append(next, failed.Name), interrupt); err != nil {
+						return err
+					}
+				}
+				return interrupt
+			}
+			if err != nil {
+				return fmt.Errorf("error in node %s: %w", failed.Name, err)
+			}
+			// The edges of the first route are followed, as when its
+			// nodes run one at a time, last.
+			currentNode = nodes[0].Name
+			ran = len(nodes)
+		default:
+			currentNode = pop()
+			// END ends the branch that routed to it, other routed nodes
+			// still running; "" stands for no node, such as when a branch
+			// has no Then.
+			if currentNode == END || currentNode == "" {
+				at = -1
+				continue
+			}
+			node, ok := r.Graph.nodes[currentNode]
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrNodeNotFound, currentNode)
+			}
+			index, at = at, -1
+			if i, ok := r.plan.lookup(currentNode); index < 0 && ok {
+				index = i
+			}
+			if r.stepLimit > 0 && steps >= r.stepLimit {
+				return fmt.Errorf("%w: %d", ErrStepLimit, r.stepLimit)
+			}
+			if slices.Contains(r.interruptBefore, currentNode) && !resumedBefore {
+				interrupt := &GraphInterrupt{Node: currentNode, Before: true}
+				if checkpointing {
+					if err := saveCheckpoint(ctx, checkpointer, cfg, r.clock.Now(), step, currentNode, last, append(nextNodes, currentNode), interrupt); err != nil {
+						return err
+					}
+				}
+				return interrupt
+			}
+			resumedBefore = false
 
-		currentNode := pop()
-		if currentNode == END {
-			break
-		}
-		if currentNode == "" {
-			continue
-		}
-		node, ok := r.Graph.nodes[currentNode]
-		if !ok {
-			return fmt.Errorf("node '%s' not found: %w", currentNode, ErrNodeNotFound)
-		}
-		err := node.Function(ctx, state)
-		if err != nil {
-			return fmt.Errorf("error in node '%s': %w", currentNode, err)
+			rv = resumeValues{values: resume}
+			resume = nil
+			for _, h := range callbacks {
+				h.NodeStart(ctx, currentNode, state)
+			}
+			err := r.runNode(ctx, node, state, &rv)
+			for _, h := range callbacks {
+				h.NodeEnd(ctx, currentNode, state, err)
+			}
+			if err != nil && errors.As(err, &gi) {
+				interrupt := &GraphInterrupt{Node: currentNode, Value: gi.Value, Resumes: rv.values[:rv.next]}
+				if checkpointing {
+					*state = cloneState(&last)
+					if err := saveCheckpoint(ctx, checkpointer, cfg, r.clock.Now(), step, currentNode, last, append(nextNodes, currentNode), interrupt); err != nil {
+						return err
+					}
+				}
+				return interrupt
+			}
+			if err != nil {
+				return fmt.Errorf("error in node %s: %w", currentNode, err)
+			}
 		}
 
 		foundNext := false
@@ -236,19 +1008,176 @@ func (r *Runnable[T]) Invoke(ctx context.Context, state *T) error {
 		if peek() != END {
 			foundNext = true
 		}
-		for _, edge := range r.Graph.edges {
-			if foundNext {
-				break
+		if !foundNext && index >= 0 {
+			switch next := r.plan.next[index]; next {
+			case planNone:
+			case planEnd:
+				nextNodes = append(nextNodes, END)
+				notifyEnd(ctx, callbacks, currentNode, state)
+				foundNext = true
+			default:
+				nextNodes = append(nextNodes, r.plan.names[next])
+				at = next
+				foundNext = true
 			}
-			if edge.From() == currentNode {
-				nextNodes = append(nextNodes, edge.To(ctx, state)...)
+		} else if !foundNext {
+			if edges := r.edges[currentNode]; len(edges) > 0 {
+				var next []string
+				if e, ok := edges[0].(*SimpleEdge[T]); ok {
+					// Simple edges are followed without allocating their
+					// targets.
+					nextNodes = append(nextNodes, e.to)
+					next = nextNodes[len(nextNodes)-1:]
+				} else {
+					next = edges[0].To(ctx, state)
+					nextNodes = append(nextNodes, next...)
+					if _, ok := edges[0].(*Branch[T]); ok && parallel && len(next) > 2 {
+						// The routes of the branch, before its Then.
+						fanOuts = append(fanOuts, fanOut{base: len(nextNodes) - len(next), top: len(nextNodes) - 2})
+					}
+				}
+				if slices.Contains(next, END) {
+					notifyEnd(ctx, callbacks, currentNode, state)
+				}
 				foundNext = true
 			}
 		}
 
 		if !foundNext {
-			return fmt.Errorf("no outgoing edge found for node '%s': %w", currentNode, ErrNoOutgoingEdge)
+			if !r.implicitEnd {
+				return fmt.Errorf("%w: %s", ErrNoOutgoingEdge, currentNode)
+			}
+			notifyEnd(ctx, callbacks, currentNode, state)
+		}
+
+		step += ran
+		steps += ran
+		var interrupt *GraphInterrupt
+		if slices.Contains(r.interruptAfter, currentNode) {
+			interrupt = &GraphInterrupt{Node: currentNode, After: true}
+		}
+		if checkpointing {
+			last = cloneState(state)
+			if err := saveCheckpoint(ctx, checkpointer, cfg, r.clock.Now(), step, currentNode, last, nextNodes, interrupt); err != nil {
+				return err
+			}
+		}
+		if interrupt != nil {
+			return interrupt
+		}
+		paused := cfg.maxSteps > 0 && steps >= cfg.maxSteps || isClosed(cfg.pause)
+		if paused && slices.ContainsFunc(nextNodes, func(n string) bool { return n != END && n != "" }) {
+			return ErrPaused
+		}
+	}
+	return nil
+}
+
+// stacks are the stacks of nodes to run of the invocations, reused across
+// invocations so that short runs do not allocate one each.
+var stacks = sync.Pool{
+	New: func() any {
+		stack := make([]string, 0, 8)
+		return &stack
+	},
+}
+
+// isClosed reports whether ch is closed, without blocking.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// indexEdges returns the outgoing edges of the nodes by source node, each
+// ordered by descending priority, the first added first among edges of equal
+// priority: a run follows the first edge of a node.
+func (g *StateGraph[T]) indexEdges() map[string][]Edge[T] {
+	index := make(map[string][]Edge[T], len(g.nodes))
+	for _, edge := range g.edges {
+		index[edge.From()] = append(index[edge.From()], edge)
+	}
+	for _, edges := range index {
+		slices.SortStableFunc(edges, func(a, b Edge[T]) int {
+			return edgePriority(b) - edgePriority(a)
+		})
+	}
+	return index
+}
+
+// edgePriority returns the priority of edge, see WithPriority.
+func edgePriority[T any](edge Edge[T]) int {
+	switch e := edge.(type) {
+	case *SimpleEdge[T]:
+		return e.priority
+	case *Branch[T]:
+		return e.Priority
+	}
+	return 0
+}
+
+// resumeCheckpoint returns the checkpoint an interrupted thread is resumed from.
+func resumeCheckpoint[T any](ctx context.Context, checkpointer Checkpointer[T], threadID string) (Checkpoint[T], error) {
+	if checkpointer == nil {
+		return Checkpoint[T]{}, ErrNoCheckpointer
+	}
+	if threadID == "" {
+		return Checkpoint[T]{}, ErrThreadRequired
+	}
+	cp, err := checkpointer.Get(ctx, threadID)
+	if err != nil {
+		return Checkpoint[T]{}, err
+	}
+	if cp.Interrupt == nil {
+		return Checkpoint[T]{}, fmt.Errorf("%w: %s", ErrNotInterrupted, threadID)
+	}
+	return cp, nil
+}
+
+// continueCheckpoint returns the checkpoint a paused thread is continued from.
+func continueCheckpoint[T any](ctx context.Context, checkpointer Checkpointer[T], threadID string) (Checkpoint[T], error) {
+	if checkpointer == nil {
+		return Checkpoint[T]{}, ErrNoCheckpointer
+	}
+	if threadID == "" {
+		return Checkpoint[T]{}, ErrThreadRequired
+	}
+	cp, err := checkpointer.Get(ctx, threadID)
+	if err != nil {
+		return Checkpoint[T]{}, err
+	}
+	if cp.Interrupt != nil {
+		interrupt := *cp.Interrupt
+		return Checkpoint[T]{}, &interrupt
+	}
+	return cp, nil
+}
+
+// saveCheckpoint saves state, which must not be modified afterwards, as the
+// state of the thread of the invocation at time now. next is the stack of
+// nodes to run, the top being last.
+func saveCheckpoint[T any](ctx context.Context, checkpointer Checkpointer[T], cfg invokeConfig, now time.Time, step int, node string, state T, next []string, interrupt *GraphInterrupt) error {
+	pending := make([]string, 0, len(next))
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i] != "" && next[i] != END {
+			pending = append(pending, next[i])
 		}
 	}
+	err := checkpointer.Put(ctx, Checkpoint[T]{
+		ID:        uuid.NewString(),
+		ThreadID:  cfg.threadID,
+		Step:      step,
+		Node:      node,
+		State:     state,
+		Next:      pending,
+		Interrupt: interrupt,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("save checkpoint after node %s: %w", node, err)
+	}
 	return nil
 }
@@ -4,15 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 )
 
-func ExampleMessageGraph() {
-	model, err := openai.New()
+func Example_messageGraph() {
+	// The requests to OpenAI are replayed from testdata, or recorded with
+	// -graphtest.update and OPENAI_API_KEY set.
+	rr, err := graphtest.NewHTTPRecorder("testdata/message_graph.httprr", http.DefaultTransport)
+	if err != nil {
+		panic(err)
+	}
+	defer rr.Close()
+	opts := []openai.Option{openai.WithHTTPClient(rr.Client()), openai.WithModel("gpt-4o-mini")}
+	if !rr.Recording() {
+		opts = append(opts, openai.WithToken("replay"))
+	}
+	model, err := openai.New(opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -20,13 +35,11 @@ func ExampleMessageGraph() {
 	g := graph.NewStateGraph[graph.MessageState]()
 
 	g.AddNode("oracle", func(ctx context.Context, state *graph.MessageState) error {
-		r, err := model.GenerateContent(ctx, state.Messages, llms.WithTemperature(0.0))
+		r, err := model.GenerateContent(ctx, state.Contents(), llms.WithTemperature(0.0))
 		if err != nil {
 			return err
 		}
-		state.Messages = append(state.Messages,
-			llms.TextParts(llms.ChatMessageTypeAI, r.Choices[0].Content),
-		)
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, r.Choices[0].Content))
 		return nil
 	})
 	g.AddNode(graph.END, func(_ context.Context, state *graph.MessageState) error {
@@ -44,15 +57,15 @@ func ExampleMessageGraph() {
 	ctx := context.Background()
 	// Let's run it!
 	msgs := graph.NewMessageState()
+	msgs.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "What is 1 + 1?"))
 	err = runnable.Invoke(ctx, &msgs)
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Println(msgs)
-
+	fmt.Println(msgs.LastMessage().Parts[0])
 	// Output:
-	// [{human [{What is 1 + 1?}]} {ai [{1 + 1 equals 2.}]}]
+	// 1 + 1 equals 2.
 }
 
 func TestMessageGraph(t *testing.T) {
@@ -69,11 +82,11 @@ func TestMessageGraph(t *testing.T) {
 			buildGraph: func() *graph.StateGraph[graph.MessageState] {
 				g := graph.NewStateGraph[graph.MessageState]()
 				g.AddNode("node1", func(_ context.Context, state *graph.MessageState) error {
-					state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Node 1"))
+					state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "Node 1"))
 					return nil
 				})
 				g.AddNode("node2", func(_ context.Context, state *graph.MessageState) error {
-					state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Node 2"))
+					state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "Node 2"))
 					return nil
 				})
 				g.AddEdge("node1", "node2")
@@ -100,19 +113,6 @@ func TestMessageGraph(t *testing.T) {
 			},
 			expectedError: graph.ErrEntryPointNotSet,
 		},
-		{
-			name: "Node not found",
-			buildGraph: func() *graph.StateGraph[graph.MessageState] {
-				g := graph.NewStateGraph[graph.MessageState]()
-				g.AddNode("node1", func(_ context.Context, state *graph.MessageState) error {
-					return nil
-				})
-				g.AddEdge("node1", "node2")
-				g.SetEntryPoint("node1")
-				return g
-			},
-			expectedError: fmt.Errorf("%w: node2", graph.ErrNodeNotFound),
-		},
 		{
 			name: "No outgoing edge",
 			buildGraph: func() *graph.StateGraph[graph.MessageState] {
@@ -123,7 +123,7 @@ func TestMessageGraph(t *testing.T) {
 				g.SetEntryPoint("node1")
 				return g
 			},
-			expectedError: fmt.Errorf("%w: node1", graph.ErrNoOutgoingEdge),
+			expectedError: graph.ErrNoPathToEnd,
 		},
 		{
 			name: "Error in node function",
@@ -152,8 +152,11 @@ func TestMessageGraph(t *testing.T) {
 				return
 			}
 
-			output := &graph.MessageState{Messages: tc.inputMessages}
-			err = runnable.Invoke(context.Background(), output)
+			output := graph.NewMessageState()
+			for _, msg := range tc.inputMessages {
+				output.AddMessage(msg)
+			}
+			err = runnable.Invoke(context.Background(), &output)
 			if err != nil {
 				if tc.expectedError == nil || err.Error() != tc.expectedError.Error() {
 					t.Fatalf("unexpected invoke error: '%v', expected '%v'", err, tc.expectedError)
@@ -170,7 +173,7 @@ func TestMessageGraph(t *testing.T) {
 			}
 
 			for i, msg := range output.Messages {
-				got := fmt.Sprint(msg)
+				got := fmt.Sprint(msg.MessageContent)
 				expected := fmt.Sprint(tc.expectedOutput[i])
 				if got != expected {
 					t.Errorf("expected output[%d] content %q, but got %q", i, expected, got)
@@ -179,3 +182,589 @@ func TestMessageGraph(t *testing.T) {
 		})
 	}
 }
+
+func TestStateGraphErrors(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+
+	testCases := []struct {
+		name    string
+		mutate  func(g *graph.StateGraph[int]) error
+		wantErr error
+	}{
+		{
+			name: "Duplicate node",
+			mutate: func(g *graph.StateGraph[int]) error {
+				_, err := g.AddNode("agent", noop)
+				return err
+			},
+			wantErr: graph.ErrDuplicateNode,
+		},
+		{
+			name: "Empty node name",
+			mutate: func(g *graph.StateGraph[int]) error {
+				_, err := g.AddNode("", noop)
+				return err
+			},
+			wantErr: graph.ErrEmptyName,
+		},
+		{
+			name:    "Edge from unknown node",
+			mutate:  func(g *graph.StateGraph[int]) error { return g.AddEdge("ghost", "agent") },
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Edge to unknown node",
+			mutate:  func(g *graph.StateGraph[int]) error { return g.AddEdge("agent", "ghost") },
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Edge with empty name",
+			mutate:  func(g *graph.StateGraph[int]) error { return g.AddEdge("agent", "") },
+			wantErr: graph.ErrEmptyName,
+		},
+		{
+			name:    "Unknown entry point",
+			mutate:  func(g *graph.StateGraph[int]) error { return g.SetEntryPoint("ghost") },
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Empty entry point",
+			mutate:  func(g *graph.StateGraph[int]) error { return g.SetEntryPoint("") },
+			wantErr: graph.ErrEmptyName,
+		},
+		{
+			name:    "Default edge without conditional edge",
+			mutate:  func(g *graph.StateGraph[int]) error { return g.AddDefaultEdge("agent", graph.END) },
+			wantErr: graph.ErrEdgeNotFound,
+		},
+		{
+			name:    "Default edge to unknown node",
+			mutate:  func(g *graph.StateGraph[int]) error { return g.AddDefaultEdge("agent", "ghost") },
+			wantErr: graph.ErrNodeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := graph.NewStateGraph[int]()
+			if _, err := g.AddNode("agent", noop); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := g.AddEdge("agent", graph.END); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := g.SetEntryPoint("agent"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			before := g.DrawASCII()
+
+			if err := tc.mutate(g); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if after := g.DrawASCII(); after != before {
+				t.Errorf("expected graph to be unchanged\n%s\nbut got\n%s", before, after)
+			}
+			if _, err := g.Compile(); err != nil {
+				t.Errorf("unexpected compile error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddDefaultEdge(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		route     string
+		def       bool
+		wantState int
+	}{
+		{name: "Matching route", route: "double", def: true, wantState: 2},
+		{name: "Unmatched route", route: "triple", def: true, wantState: 10},
+		{name: "Unmatched route without default", route: "triple", wantState: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := graph.NewStateGraph[int]()
+			g.AddNode("start", func(_ context.Context, n *int) error {
+				*n = 1
+				return nil
+			})
+			g.AddNode("double", func(_ context.Context, n *int) error {
+				*n *= 2
+				return nil
+			})
+			g.AddNode("fallback", func(_ context.Context, n *int) error {
+				*n *= 10
+				return nil
+			})
+			g.AddConditionalEdges("start", func(context.Context, *int) ([]string, error) {
+				return []string{tc.route}, nil
+			}, graph.WithMap[int](map[string]string{"double": "double", "done": graph.END}))
+			g.AddEdge("double", graph.END)
+			g.AddEdge("fallback", graph.END)
+			g.SetEntryPoint("start")
+			if tc.def {
+				if err := g.AddDefaultEdge("start", "fallback"); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if want := "start ..> fallback (default)"; !strings.Contains(edgeList(g), want) {
+					t.Errorf("expected edges to contain %q, but got %s", want, edgeList(g))
+				}
+			} else {
+				g.RemoveNode("fallback")
+			}
+
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			n := 0
+			if err := runnable.Invoke(context.Background(), &n); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != tc.wantState {
+				t.Errorf("expected %d, but got %d", tc.wantState, n)
+			}
+		})
+	}
+}
+
+func TestRouterEnd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		routes     []string
+		opts       []graph.ConditionalEdgeOptions[int]
+		wantEvents []string
+	}{
+		{
+			name:       "END",
+			routes:     []string{graph.END},
+			wantEvents: []string{"start router", "end router", "END after router"},
+		},
+		{
+			name:   "END and a node",
+			routes: []string{"a", graph.END},
+			wantEvents: []string{
+				"start router", "end router", "END after router",
+				"start a", "end a", "END after a",
+			},
+		},
+		{
+			name:   "Route mapped to END with Then",
+			routes: []string{"done"},
+			opts: []graph.ConditionalEdgeOptions[int]{
+				graph.WithMap[int](map[string]string{"done": graph.END, "a": "a"}),
+				graph.WithThen[int]("summary"),
+			},
+			wantEvents: []string{
+				"start router", "end router", "END after router",
+				"start summary", "end summary", "END after summary",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			noop := func(context.Context, *int) error { return nil }
+			g := graph.NewStateGraph[int]()
+			g.AddNode("router", noop)
+			g.AddNode("a", noop)
+			g.AddNode("summary", noop)
+			g.AddConditionalEdges("router", func(context.Context, *int) ([]string, error) {
+				return tc.routes, nil
+			}, tc.opts...)
+			g.AddEdge("a", graph.END)
+			g.AddEdge("summary", graph.END)
+			g.SetEntryPoint("router")
+
+			rec := &recorder{}
+			runnable, err := g.Compile(graph.WithCallbacks[int](rec))
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			n := 0
+			if err := runnable.Invoke(context.Background(), &n); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(rec.events, tc.wantEvents) {
+				t.Errorf("expected events %q, but got %q", tc.wantEvents, rec.events)
+			}
+		})
+	}
+}
+
+func TestNamedEntryPoints(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[int]()
+	g.AddNode("load", func(_ context.Context, n *int) error {
+		*n = 10
+		return nil
+	})
+	g.AddNode("agent", func(_ context.Context, n *int) error {
+		*n++
+		return nil
+	})
+	g.AddEdge("load", "agent")
+	g.AddEdge("agent", graph.END)
+	g.SetEntryPoint("agent")
+	if _, err := g.Compile(); !errors.Is(err, graph.ErrUnreachableNode) {
+		t.Fatalf("expected error %v, but got %v", graph.ErrUnreachableNode, err)
+	}
+
+	if err := g.SetNamedEntryPoint("replay", "ghost"); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("expected error %v, but got %v", graph.ErrNodeNotFound, err)
+	}
+	if err := g.SetNamedEntryPoint("", "load"); !errors.Is(err, graph.ErrEmptyName) {
+		t.Errorf("expected error %v, but got %v", graph.ErrEmptyName, err)
+	}
+	if err := g.SetNamedEntryPoint("replay", "load"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "START --> agent, START --> load (replay)"; !strings.HasPrefix(edgeList(g), want) {
+		t.Errorf("expected edges to start with %q, but got %s", want, edgeList(g))
+	}
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		opts      []graph.InvokeOption
+		wantState int
+		wantErr   error
+	}{
+		{name: "Default entry point", wantState: 1},
+		{name: "Named entry point", opts: []graph.InvokeOption{graph.WithEntryPoint("replay")}, wantState: 11},
+		{name: "Unknown entry point", opts: []graph.InvokeOption{graph.WithEntryPoint("ghost")}, wantErr: graph.ErrEntryPointNotSet},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := 0
+			if err := runnable.Invoke(context.Background(), &n, tc.opts...); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if n != tc.wantState {
+				t.Errorf("expected %d, but got %d", tc.wantState, n)
+			}
+		})
+	}
+
+	// Removing the node removes its entry point.
+	g.RemoveNode("load")
+	if _, err := g.Compile(); err != nil {
+		t.Errorf("unexpected compile error: %v", err)
+	}
+}
+
+func TestEdgePriority(t *testing.T) {
+	t.Parallel()
+
+	route := func(context.Context, *string) ([]string, error) { return []string{"c"}, nil }
+
+	testCases := []struct {
+		name  string
+		edges func(g *graph.StateGraph[string])
+		want  string
+	}{
+		{
+			name: "Insertion order",
+			edges: func(g *graph.StateGraph[string]) {
+				g.AddEdge("a", "b")
+				g.AddEdge("a", "c")
+			},
+			want: "ab",
+		},
+		{
+			name: "Higher priority",
+			edges: func(g *graph.StateGraph[string]) {
+				g.AddEdge("a", "b")
+				g.AddEdge("a", "c", graph.WithPriority(1))
+			},
+			want: "ac",
+		},
+		{
+			name: "Lower priority",
+			edges: func(g *graph.StateGraph[string]) {
+				g.AddEdge("a", "b", graph.WithPriority(-1))
+				g.AddEdge("a", "c")
+			},
+			want: "ac",
+		},
+		{
+			name: "Conditional edge priority",
+			edges: func(g *graph.StateGraph[string]) {
+				g.AddEdge("a", "b", graph.WithPriority(1))
+				g.AddConditionalEdges("a", route, graph.WithBranchPriority[string](2))
+			},
+			want: "ac",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := graph.NewStateGraph[string]()
+			for _, name := range []string{"a", "b", "c"} {
+				g.AddNode(name, func(_ context.Context, s *string) error {
+					*s += name
+					return nil
+				})
+			}
+			tc.edges(g)
+			g.AddEdge("b", graph.END)
+			g.AddEdge("c", graph.END)
+			g.SetEntryPoint("a")
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			var s string
+			if err := runnable.Invoke(context.Background(), &s); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s != tc.want {
+				t.Errorf("expected %q, but got %q", tc.want, s)
+			}
+		})
+	}
+}
+
+// BenchmarkInvokeChain runs a chain of 500 nodes, followed with the static
+// plan of the graph, or interpreted when the last edge is conditional.
+func BenchmarkInvokeChain(b *testing.B) {
+	const n = 500
+	chain := func(conditional bool) *graph.Runnable[int] {
+		g := graph.NewStateGraph[int]()
+		for i := range n {
+			g.AddNode(fmt.Sprint(i), func(_ context.Context, s *int) error {
+				*s++
+				return nil
+			})
+			if i > 0 {
+				g.AddEdge(fmt.Sprint(i-1), fmt.Sprint(i))
+			}
+		}
+		if conditional {
+			g.AddConditionalEdges(fmt.Sprint(n-1), func(context.Context, *int) ([]string, error) {
+				return []string{graph.END}, nil
+			})
+		} else {
+			g.AddEdge(fmt.Sprint(n-1), graph.END)
+		}
+		g.SetEntryPoint("0")
+		runnable, err := g.Compile()
+		if err != nil {
+			b.Fatal(err)
+		}
+		return runnable
+	}
+
+	for _, bc := range []struct {
+		name        string
+		conditional bool
+	}{
+		{"Plan", false},
+		{"Interpreted", true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			runnable := chain(bc.conditional)
+			b.ReportAllocs()
+			for range b.N {
+				var s int
+				if err := runnable.Invoke(context.Background(), &s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]string, error) { return []string{"done"}, nil }
+
+	// build returns a pipeline with an optional review, which may send the
+	// draft back.
+	build := func() *graph.StateGraph[int] {
+		g := graph.NewStateGraph[int]()
+		for _, name := range []string{"draft", "review", "publish"} {
+			if _, err := g.AddNode(name, noop); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		g.AddEdge("draft", "review")
+		g.AddConditionalEdges("review", route, graph.WithMap[int](map[string]string{"again": "draft", "done": "publish"}))
+		g.AddEdge("publish", graph.END)
+		g.SetEntryPoint("draft")
+		return g
+	}
+
+	testCases := []struct {
+		name    string
+		remove  func(g *graph.StateGraph[int]) error
+		want    string
+		wantErr error
+
+		// wantCompileErr is the error of Compile for the pruned graph.
+		wantCompileErr error
+	}{
+		{
+			name: "Node with its edges",
+			remove: func(g *graph.StateGraph[int]) error {
+				if err := g.RemoveNode("review"); err != nil {
+					return err
+				}
+				return g.AddEdge("draft", "publish")
+			},
+			want: "START --> draft, draft --> publish, publish --> END",
+		},
+		{
+			name: "Entry point",
+			remove: func(g *graph.StateGraph[int]) error {
+				if err := g.RemoveNode("draft"); err != nil {
+					return err
+				}
+				return g.SetEntryPoint("review")
+			},
+			// The conditional edge routing to draft is kept.
+			want:           "START --> review, review ..> draft (again), review ..> publish (done), publish --> END",
+			wantCompileErr: graph.ErrNodeNotFound,
+		},
+		{
+			name: "Edge",
+			remove: func(g *graph.StateGraph[int]) error {
+				if err := g.RemoveEdge("draft", "review"); err != nil {
+					return err
+				}
+				return g.AddEdge("draft", "publish")
+			},
+			want:           "START --> draft, draft --> publish, publish --> END, review ..> draft (again), review ..> publish (done)",
+			wantCompileErr: graph.ErrUnreachableNode,
+		},
+		{
+			name: "Conditional edges",
+			remove: func(g *graph.StateGraph[int]) error {
+				if err := g.RemoveConditionalEdges("review"); err != nil {
+					return err
+				}
+				return g.AddEdge("review", "publish")
+			},
+			want: "START --> draft, draft --> review, review --> publish, publish --> END",
+		},
+		{
+			name:   "Replace node",
+			remove: func(g *graph.StateGraph[int]) error { return g.ReplaceNode("review", noop) },
+			want:   "START --> draft, draft --> review, review ..> draft (again), review ..> publish (done), publish --> END",
+		},
+		{
+			name:    "Unknown node",
+			remove:  func(g *graph.StateGraph[int]) error { return g.RemoveNode("ghost") },
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Replace unknown node",
+			remove:  func(g *graph.StateGraph[int]) error { return g.ReplaceNode("ghost", noop) },
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Unknown edge",
+			remove:  func(g *graph.StateGraph[int]) error { return g.RemoveEdge("review", "publish") },
+			wantErr: graph.ErrEdgeNotFound,
+		},
+		{
+			name:    "Unknown conditional edges",
+			remove:  func(g *graph.StateGraph[int]) error { return g.RemoveConditionalEdges("draft") },
+			wantErr: graph.ErrEdgeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			original := build()
+			g := original.Clone()
+			if err := tc.remove(g); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if got := edgeList(g); got != tc.want {
+				t.Errorf("expected edges %q, but got %q", tc.want, got)
+			}
+			if got, want := edgeList(original), edgeList(build()); got != want {
+				t.Errorf("expected the cloned graph unchanged with edges %q, but got %q", want, got)
+			}
+			if _, err := g.Compile(graph.WithAllowedCycle("draft", "review")); !errors.Is(err, tc.wantCompileErr) {
+				t.Errorf("expected compile error %v, but got %v", tc.wantCompileErr, err)
+			}
+		})
+	}
+}
+
+func TestReplaceNodeAfterCompile(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[int]()
+	g.AddNode("first", func(_ context.Context, s *int) error {
+		*s += 1
+		return nil
+	})
+	g.AddNode("second", func(_ context.Context, s *int) error {
+		*s += 10
+		return nil
+	})
+	g.AddEdge("first", "second")
+	g.AddEdge("second", graph.END)
+	g.SetEntryPoint("first")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	// Both nodes are looked up when they run, the entry point as the others.
+	for _, name := range []string{"first", "second"} {
+		if err := g.ReplaceNode(name, func(_ context.Context, s *int) error {
+			*s += 100
+			return nil
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var s int
+	if err := runnable.Invoke(context.Background(), &s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s != 200 {
+		t.Errorf("expected 200, but got %d", s)
+	}
+}
+
+// edgeList summarizes the edges drawn by DrawASCII on one line.
+func edgeList(g *graph.StateGraph[int]) string {
+	var edges []string
+	var from string
+	for _, line := range strings.Split(g.DrawASCII(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "| "):
+			from = strings.Trim(line, "| ")
+		case strings.HasPrefix(line, "    +"):
+			edges = append(edges, from+" "+strings.TrimPrefix(line, "    +"))
+		}
+	}
+	return strings.Join(edges, ", ")
+}
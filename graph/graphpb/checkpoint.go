@@ -0,0 +1,76 @@
+package graphpb
+
+import (
+	"fmt"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MarshalCheckpoint returns cp encoded as a Checkpoint message.
+func MarshalCheckpoint[T any](cp graph.Checkpoint[T]) ([]byte, error) {
+	m := dynamicpb.NewMessage(checkpointDesc)
+	set(m, "id", protoreflect.ValueOfString(cp.ID))
+	set(m, "thread_id", protoreflect.ValueOfString(cp.ThreadID))
+	set(m, "step", protoreflect.ValueOfInt64(int64(cp.Step)))
+	set(m, "node", protoreflect.ValueOfString(cp.Node))
+	if err := setState(m, &cp.State); err != nil {
+		return nil, err
+	}
+	next := mutable(m, "next").List()
+	for _, name := range cp.Next {
+		next.Append(protoreflect.ValueOfString(name))
+	}
+	if cp.Interrupt != nil {
+		im, err := interruptMessage(cp.Interrupt)
+		if err != nil {
+			return nil, err
+		}
+		set(m, "interrupt", protoreflect.ValueOfMessage(im))
+	}
+	set(m, "error", protoreflect.ValueOfString(cp.Error))
+	if !cp.CreatedAt.IsZero() {
+		set(m, "created_at", protoreflect.ValueOfMessage(timestamppb.New(cp.CreatedAt).ProtoReflect()))
+	}
+	return proto.Marshal(m)
+}
+
+// UnmarshalCheckpoint decodes a Checkpoint message.
+func UnmarshalCheckpoint[T any](data []byte) (graph.Checkpoint[T], error) {
+	m := dynamicpb.NewMessage(checkpointDesc)
+	if err := proto.Unmarshal(data, m); err != nil {
+		return graph.Checkpoint[T]{}, fmt.Errorf("graphpb: decode checkpoint: %w", err)
+	}
+	cp := graph.Checkpoint[T]{
+		ID:       get(m, "id").String(),
+		ThreadID: get(m, "thread_id").String(),
+		Step:     int(get(m, "step").Int()),
+		Node:     get(m, "node").String(),
+		Error:    get(m, "error").String(),
+	}
+	if err := stateOf(m, &cp.State); err != nil {
+		return graph.Checkpoint[T]{}, err
+	}
+	next := get(m, "next").List()
+	for i := range next.Len() {
+		cp.Next = append(cp.Next, next.Get(i).String())
+	}
+	if has(m, "interrupt") {
+		gi, err := interruptOf(get(m, "interrupt").Message())
+		if err != nil {
+			return graph.Checkpoint[T]{}, err
+		}
+		cp.Interrupt = gi
+	}
+	if has(m, "created_at") {
+		var createdAt timestamppb.Timestamp
+		if err := convert(get(m, "created_at").Message(), &createdAt); err != nil {
+			return graph.Checkpoint[T]{}, fmt.Errorf("graphpb: decode created_at: %w", err)
+		}
+		cp.CreatedAt = createdAt.AsTime()
+	}
+	return cp, nil
+}
@@ -0,0 +1,152 @@
+package graphpb
+
+import (
+	"github.com/alberrttt/langgraphgo/graph/internal/protoschema"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The messages of langgraph.proto. Their descriptors are built from
+// fileProto and the messages are dynamic, so the package needs no generated
+// code.
+var (
+	partDesc         = File.Messages().ByName("Part")
+	messageDesc      = File.Messages().ByName("Message")
+	messageStateDesc = File.Messages().ByName("MessageState")
+	messageDeltaDesc = File.Messages().ByName("MessageDelta")
+	interruptDesc    = File.Messages().ByName("Interrupt")
+	checkpointDesc   = File.Messages().ByName("Checkpoint")
+	eventDesc        = File.Messages().ByName("Event")
+)
+
+// File is the descriptor of langgraph.proto, such as to register it with
+// protoregistry.GlobalFiles or to export the schema to other languages.
+var File = func() protoreflect.FileDescriptor {
+	// Resolved from the global files, where structpb and timestamppb
+	// register them.
+	_ = structpb.Struct{}
+	_ = timestamppb.Timestamp{}
+
+	fd, err := protodesc.NewFile(fileProto(), protoregistry.GlobalFiles)
+	if err != nil {
+		panic("graphpb: invalid langgraph.proto descriptor: " + err.Error())
+	}
+	return fd
+}()
+
+// fileProto returns the descriptor of langgraph.proto, which
+// TestFileMatchesProto checks it against.
+func fileProto() *descriptorpb.FileDescriptorProto {
+	var (
+		str      = protoschema.String
+		bytes    = protoschema.Bytes
+		boolean  = protoschema.Bool
+		repeated = protoschema.Repeated
+		oneof    = protoschema.Oneof
+		msg      = protoschema.Msg
+		oneofs   = protoschema.Oneofs
+	)
+	message := func(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+		if typeName[0] != '.' {
+			typeName = ".langgraphgo.v1." + typeName
+		}
+		return protoschema.Message(name, number, typeName)
+	}
+	eventType := protoschema.Enum("type", 1, ".langgraphgo.v1.EventType")
+	timestamp := ".google.protobuf.Timestamp"
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("langgraphgo/v1/langgraph.proto"),
+		Package:    proto.String("langgraphgo.v1"),
+		Dependency: []string{"google/protobuf/struct.proto", "google/protobuf/timestamp.proto"},
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("github.com/alberrttt/langgraphgo/graph/graphpb")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			oneofs(msg("Part",
+				oneof(0, str("text", 1)),
+				oneof(0, message("image_url", 2, "ImageURL")),
+				oneof(0, message("binary", 3, "Binary")),
+				oneof(0, message("tool_call", 4, "ToolCall")),
+				oneof(0, message("tool_response", 5, "ToolResponse")),
+			), "kind"),
+			msg("ImageURL", str("url", 1), str("detail", 2)),
+			msg("Binary", str("mime_type", 1), bytes("data", 2)),
+			msg("ToolCall", str("id", 1), str("type", 2), message("function", 3, "FunctionCall")),
+			msg("FunctionCall", str("name", 1), str("arguments", 2)),
+			msg("ToolResponse", str("tool_call_id", 1), str("name", 2), str("content", 3)),
+			msg("Message",
+				str("id", 1),
+				str("role", 2),
+				repeated(message("parts", 3, "Part")),
+				message("metadata", 4, ".google.protobuf.Struct"),
+			),
+			msg("MessageState", repeated(message("messages", 1, "Message"))),
+			msg("MessageDelta",
+				str("id", 1),
+				str("role", 2),
+				str("text", 3),
+				repeated(message("parts", 4, "Part")),
+				boolean("replace", 5),
+				boolean("remove", 6),
+			),
+			msg("Interrupt",
+				str("node", 1),
+				bytes("value", 2),
+				repeated(bytes("resumes", 3)),
+				boolean("before", 4),
+				boolean("after", 5),
+			),
+			oneofs(msg("Checkpoint",
+				str("id", 1),
+				str("thread_id", 2),
+				protoschema.Int64("step", 3),
+				str("node", 4),
+				oneof(0, bytes("state_json", 5)),
+				oneof(0, message("message_state", 10, "MessageState")),
+				repeated(str("next", 6)),
+				message("interrupt", 7, "Interrupt"),
+				str("error", 8),
+				message("created_at", 9, timestamp),
+			), "state"),
+			oneofs(msg("Event",
+				eventType,
+				str("run_id", 10),
+				str("thread_id", 11),
+				str("node", 2),
+				message("time", 3, timestamp),
+				oneof(0, bytes("state_json", 4)),
+				oneof(0, message("message_state", 9, "MessageState")),
+				bytes("update", 5),
+				message("delta", 6, "MessageDelta"),
+				message("interrupt", 7, "Interrupt"),
+				str("error", 8),
+			), "state"),
+		},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("EventType"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("EVENT_TYPE_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("EVENT_TYPE_NODE_START"), Number: proto.Int32(1)},
+				{Name: proto.String("EVENT_TYPE_NODE_END"), Number: proto.Int32(2)},
+				{Name: proto.String("EVENT_TYPE_VALUES"), Number: proto.Int32(3)},
+				{Name: proto.String("EVENT_TYPE_UPDATES"), Number: proto.Int32(4)},
+				{Name: proto.String("EVENT_TYPE_MESSAGES"), Number: proto.Int32(5)},
+				{Name: proto.String("EVENT_TYPE_INTERRUPT"), Number: proto.Int32(6)},
+				{Name: proto.String("EVENT_TYPE_ERROR"), Number: proto.Int32(7)},
+			},
+		}},
+	}
+}
+
+// The accessors of the fields of dynamic messages.
+var (
+	get     = protoschema.Get
+	has     = protoschema.Has
+	set     = protoschema.Set
+	mutable = protoschema.Mutable
+)
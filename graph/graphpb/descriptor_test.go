@@ -0,0 +1,21 @@
+package graphpb_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/graphpb"
+	"github.com/alberrttt/langgraphgo/graph/internal/protoschema"
+)
+
+func TestFileMatchesProto(t *testing.T) {
+	t.Parallel()
+
+	src, err := os.ReadFile("langgraph.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := protoschema.Compare(graphpb.File, string(src)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,226 @@
+package graphpb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EventType is the type of an Event.
+type EventType int32
+
+// The event types, numbered as the EventType enum.
+const (
+	EventNodeStart EventType = iota + 1
+	EventNodeEnd
+	EventValues
+	EventUpdates
+	EventMessages
+	EventInterrupt
+	EventError
+)
+
+// Event is an event of a run of a graph with state T.
+type Event[T any] struct {
+	Type     EventType
+	RunID    string
+	ThreadID string
+	Node     string
+	Time     time.Time
+
+	// State is the state of node start, node end and values events.
+	State *T
+
+	// Update holds the fields of the state the node changed, encoded as
+	// JSON, for updates events.
+	Update json.RawMessage
+
+	// Delta is the change of the node to the messages of the state, for
+	// messages events.
+	Delta *graph.MessageDelta
+
+	// Interrupt is the interrupt of interrupt events.
+	Interrupt *graph.GraphInterrupt
+
+	// Error is the error of node end events of failed nodes and of error
+	// events.
+	Error string
+}
+
+// MarshalEvent returns e encoded as an Event message.
+func MarshalEvent[T any](e Event[T]) ([]byte, error) {
+	m, err := eventMessage(e)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+func eventMessage[T any](e Event[T]) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(eventDesc)
+	set(m, "type", protoreflect.ValueOfEnum(protoreflect.EnumNumber(e.Type)))
+	set(m, "run_id", protoreflect.ValueOfString(e.RunID))
+	set(m, "thread_id", protoreflect.ValueOfString(e.ThreadID))
+	set(m, "node", protoreflect.ValueOfString(e.Node))
+	if !e.Time.IsZero() {
+		set(m, "time", protoreflect.ValueOfMessage(timestamppb.New(e.Time).ProtoReflect()))
+	}
+	if e.State != nil {
+		if err := setState(m, e.State); err != nil {
+			return nil, err
+		}
+	}
+	set(m, "update", protoreflect.ValueOfBytes(e.Update))
+	if e.Delta != nil {
+		dm, err := messageDeltaMessage(*e.Delta)
+		if err != nil {
+			return nil, err
+		}
+		set(m, "delta", protoreflect.ValueOfMessage(dm))
+	}
+	if e.Interrupt != nil {
+		im, err := interruptMessage(e.Interrupt)
+		if err != nil {
+			return nil, err
+		}
+		set(m, "interrupt", protoreflect.ValueOfMessage(im))
+	}
+	set(m, "error", protoreflect.ValueOfString(e.Error))
+	return m, nil
+}
+
+// UnmarshalEvent decodes an Event message.
+func UnmarshalEvent[T any](data []byte) (Event[T], error) {
+	m := dynamicpb.NewMessage(eventDesc)
+	if err := proto.Unmarshal(data, m); err != nil {
+		return Event[T]{}, fmt.Errorf("graphpb: decode event: %w", err)
+	}
+	return eventOf[T](m)
+}
+
+func eventOf[T any](m protoreflect.Message) (Event[T], error) {
+	e := Event[T]{
+		Type:     EventType(get(m, "type").Enum()),
+		RunID:    get(m, "run_id").String(),
+		ThreadID: get(m, "thread_id").String(),
+		Node:     get(m, "node").String(),
+		Error:    get(m, "error").String(),
+	}
+	if has(m, "time") {
+		var t timestamppb.Timestamp
+		if err := convert(get(m, "time").Message(), &t); err != nil {
+			return Event[T]{}, fmt.Errorf("graphpb: decode time: %w", err)
+		}
+		e.Time = t.AsTime()
+	}
+	if has(m, "state_json") || has(m, "message_state") {
+		e.State = new(T)
+		if err := stateOf(m, e.State); err != nil {
+			return Event[T]{}, err
+		}
+	}
+	if update := get(m, "update").Bytes(); len(update) > 0 {
+		e.Update = json.RawMessage(update)
+	}
+	if has(m, "delta") {
+		d, err := messageDeltaOf(get(m, "delta").Message())
+		if err != nil {
+			return Event[T]{}, err
+		}
+		e.Delta = &d
+	}
+	if has(m, "interrupt") {
+		gi, err := interruptOf(get(m, "interrupt").Message())
+		if err != nil {
+			return Event[T]{}, err
+		}
+		e.Interrupt = gi
+	}
+	return e, nil
+}
+
+// EventWriter records the node start and node end events of runs to a
+// writer as size-delimited Event messages, see ReadEvent. Nodes that fail
+// with an interrupt record an interrupt event instead of their node end. It
+// is a graph.CallbackHandler:
+//
+//	w := graphpb.NewEventWriter[State](f)
+//	err := runnable.Invoke(ctx, &state, graph.WithRunCallbacks[State](w))
+//	...
+//	err = w.Err()
+type EventWriter[T any] struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewEventWriter returns an EventWriter writing to w.
+func NewEventWriter[T any](w io.Writer) *EventWriter[T] {
+	return &EventWriter[T]{w: w}
+}
+
+// Write writes e. After a failed write, events are dropped and Err returns
+// the error.
+func (w *EventWriter[T]) Write(e Event[T]) error {
+	m, err := eventMessage(e)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if err == nil {
+		_, err = protodelim.MarshalTo(w.w, m)
+	}
+	w.err = err
+	return err
+}
+
+// Err returns the error of the first failed write, if any.
+func (w *EventWriter[T]) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// NodeStart implements graph.CallbackHandler.
+func (w *EventWriter[T]) NodeStart(_ context.Context, node string, state *T) {
+	_ = w.Write(Event[T]{Type: EventNodeStart, Node: node, Time: time.Now().UTC(), State: state})
+}
+
+// NodeEnd implements graph.CallbackHandler.
+func (w *EventWriter[T]) NodeEnd(_ context.Context, node string, state *T, err error) {
+	e := Event[T]{Type: EventNodeEnd, Node: node, Time: time.Now().UTC(), State: state}
+	var gi *graph.GraphInterrupt
+	switch {
+	case errors.As(err, &gi):
+		e.Type, e.Interrupt = EventInterrupt, gi
+	case err != nil:
+		e.Error = err.Error()
+	}
+	_ = w.Write(e)
+}
+
+// ReadEvent reads the next event written by an EventWriter, returning io.EOF
+// at the end of r.
+func ReadEvent[T any](r *bufio.Reader) (Event[T], error) {
+	m := dynamicpb.NewMessage(eventDesc)
+	if err := (protodelim.UnmarshalOptions{MaxSize: -1}).UnmarshalFrom(r, m); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Event[T]{}, io.EOF
+		}
+		return Event[T]{}, fmt.Errorf("graphpb: read event: %w", err)
+	}
+	return eventOf[T](m)
+}
@@ -0,0 +1,323 @@
+// Package graphpb encodes the artifacts of graphs — messages, checkpoints and
+// run events — as the protocol buffers of langgraph.proto, the canonical
+// schema other languages and storage systems read them with:
+//
+//	data, err := graphpb.MarshalCheckpoint(cp)
+//	...
+//	cp, err := graphpb.UnmarshalCheckpoint[State](data)
+//
+// A graph.MessageState state is encoded as a MessageState message; states of
+// other types are encoded as JSON, as are interrupt and resume values.
+package graphpb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MarshalMessageState returns s encoded as a MessageState message.
+func MarshalMessageState(s graph.MessageState) ([]byte, error) {
+	m, err := messageStateMessage(s)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+// UnmarshalMessageState decodes a MessageState message.
+func UnmarshalMessageState(data []byte) (graph.MessageState, error) {
+	m := dynamicpb.NewMessage(messageStateDesc)
+	if err := proto.Unmarshal(data, m); err != nil {
+		return graph.MessageState{}, fmt.Errorf("graphpb: decode message state: %w", err)
+	}
+	return messageStateOf(m)
+}
+
+func messageStateMessage(s graph.MessageState) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(messageStateDesc)
+	messages := mutable(m, "messages").List()
+	for _, msg := range s.Messages {
+		mm, err := messageMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		messages.Append(protoreflect.ValueOfMessage(mm))
+	}
+	return m, nil
+}
+
+func messageStateOf(m protoreflect.Message) (graph.MessageState, error) {
+	s := graph.MessageState{Messages: []graph.Message{}}
+	messages := get(m, "messages").List()
+	for i := range messages.Len() {
+		msg, err := messageOf(messages.Get(i).Message())
+		if err != nil {
+			return graph.MessageState{}, err
+		}
+		s.Messages = append(s.Messages, msg)
+	}
+	return s, nil
+}
+
+func messageMessage(msg graph.Message) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(messageDesc)
+	set(m, "id", protoreflect.ValueOfString(msg.ID))
+	set(m, "role", protoreflect.ValueOfString(string(msg.Role)))
+	if err := appendParts(mutable(m, "parts").List(), msg.Parts); err != nil {
+		return nil, err
+	}
+	if len(msg.Metadata) > 0 {
+		// Encoded as JSON first, for times to become strings.
+		data, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("graphpb: encode metadata: %w", err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("graphpb: encode metadata: %w", err)
+		}
+		metadata, err := structpb.NewStruct(fields)
+		if err != nil {
+			return nil, fmt.Errorf("graphpb: encode metadata: %w", err)
+		}
+		set(m, "metadata", protoreflect.ValueOfMessage(metadata.ProtoReflect()))
+	}
+	return m, nil
+}
+
+func messageOf(m protoreflect.Message) (graph.Message, error) {
+	parts, err := partsOf(get(m, "parts").List())
+	if err != nil {
+		return graph.Message{}, err
+	}
+	msg := graph.Message{
+		MessageContent: llms.MessageContent{Role: llms.ChatMessageType(get(m, "role").String()), Parts: parts},
+		ID:             get(m, "id").String(),
+	}
+	if has(m, "metadata") {
+		var metadata structpb.Struct
+		if err := convert(get(m, "metadata").Message(), &metadata); err != nil {
+			return graph.Message{}, err
+		}
+		msg.Metadata = metadata.AsMap()
+		if s, ok := msg.Metadata[graph.MetadataCreatedAt].(string); ok {
+			createdAt, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return graph.Message{}, fmt.Errorf("graphpb: parse %s metadata: %w", graph.MetadataCreatedAt, err)
+			}
+			msg.Metadata[graph.MetadataCreatedAt] = createdAt
+		}
+	}
+	return msg, nil
+}
+
+// convert copies the dynamic message m into the generated message dst of
+// the same type.
+func convert(m protoreflect.Message, dst proto.Message) error {
+	data, err := proto.Marshal(m.Interface())
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, dst)
+}
+
+func appendParts(list protoreflect.List, parts []llms.ContentPart) error {
+	for _, part := range parts {
+		m := dynamicpb.NewMessage(partDesc)
+		switch p := part.(type) {
+		case llms.TextContent:
+			set(m, "text", protoreflect.ValueOfString(p.Text))
+		case llms.ImageURLContent:
+			image := mutable(m, "image_url").Message()
+			set(image, "url", protoreflect.ValueOfString(p.URL))
+			set(image, "detail", protoreflect.ValueOfString(p.Detail))
+		case llms.BinaryContent:
+			binary := mutable(m, "binary").Message()
+			set(binary, "mime_type", protoreflect.ValueOfString(p.MIMEType))
+			set(binary, "data", protoreflect.ValueOfBytes(p.Data))
+		case llms.ToolCall:
+			call := mutable(m, "tool_call").Message()
+			set(call, "id", protoreflect.ValueOfString(p.ID))
+			set(call, "type", protoreflect.ValueOfString(p.Type))
+			if p.FunctionCall != nil {
+				function := mutable(call, "function").Message()
+				set(function, "name", protoreflect.ValueOfString(p.FunctionCall.Name))
+				set(function, "arguments", protoreflect.ValueOfString(p.FunctionCall.Arguments))
+			}
+		case llms.ToolCallResponse:
+			resp := mutable(m, "tool_response").Message()
+			set(resp, "tool_call_id", protoreflect.ValueOfString(p.ToolCallID))
+			set(resp, "name", protoreflect.ValueOfString(p.Name))
+			set(resp, "content", protoreflect.ValueOfString(p.Content))
+		default:
+			return fmt.Errorf("graphpb: %w: %T", graph.ErrUnknownPartType, part)
+		}
+		list.Append(protoreflect.ValueOfMessage(m))
+	}
+	return nil
+}
+
+// partsOf returns nil for no parts, as graph.Message decodes from JSON.
+func partsOf(list protoreflect.List) ([]llms.ContentPart, error) {
+	var parts []llms.ContentPart
+	for i := range list.Len() {
+		m := list.Get(i).Message()
+		switch {
+		case has(m, "text"):
+			parts = append(parts, llms.TextContent{Text: get(m, "text").String()})
+		case has(m, "image_url"):
+			image := get(m, "image_url").Message()
+			parts = append(parts, llms.ImageURLContent{URL: get(image, "url").String(), Detail: get(image, "detail").String()})
+		case has(m, "binary"):
+			binary := get(m, "binary").Message()
+			parts = append(parts, llms.BinaryContent{MIMEType: get(binary, "mime_type").String(), Data: get(binary, "data").Bytes()})
+		case has(m, "tool_call"):
+			call := get(m, "tool_call").Message()
+			tc := llms.ToolCall{ID: get(call, "id").String(), Type: get(call, "type").String()}
+			if has(call, "function") {
+				function := get(call, "function").Message()
+				tc.FunctionCall = &llms.FunctionCall{Name: get(function, "name").String(), Arguments: get(function, "arguments").String()}
+			}
+			parts = append(parts, tc)
+		case has(m, "tool_response"):
+			resp := get(m, "tool_response").Message()
+			parts = append(parts, llms.ToolCallResponse{
+				ToolCallID: get(resp, "tool_call_id").String(),
+				Name:       get(resp, "name").String(),
+				Content:    get(resp, "content").String(),
+			})
+		default:
+			return nil, fmt.Errorf("graphpb: %w: empty part", graph.ErrUnknownPartType)
+		}
+	}
+	return parts, nil
+}
+
+func messageDeltaMessage(d graph.MessageDelta) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(messageDeltaDesc)
+	set(m, "id", protoreflect.ValueOfString(d.ID))
+	set(m, "role", protoreflect.ValueOfString(string(d.Role)))
+	set(m, "text", protoreflect.ValueOfString(d.Text))
+	set(m, "replace", protoreflect.ValueOfBool(d.Replace))
+	set(m, "remove", protoreflect.ValueOfBool(d.Remove))
+	if err := appendParts(mutable(m, "parts").List(), d.Parts); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func messageDeltaOf(m protoreflect.Message) (graph.MessageDelta, error) {
+	parts, err := partsOf(get(m, "parts").List())
+	if err != nil {
+		return graph.MessageDelta{}, err
+	}
+	return graph.MessageDelta{
+		ID:      get(m, "id").String(),
+		Role:    llms.ChatMessageType(get(m, "role").String()),
+		Text:    get(m, "text").String(),
+		Parts:   parts,
+		Replace: get(m, "replace").Bool(),
+		Remove:  get(m, "remove").Bool(),
+	}, nil
+}
+
+func interruptMessage(gi *graph.GraphInterrupt) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(interruptDesc)
+	set(m, "node", protoreflect.ValueOfString(gi.Node))
+	set(m, "before", protoreflect.ValueOfBool(gi.Before))
+	set(m, "after", protoreflect.ValueOfBool(gi.After))
+	if gi.Value != nil {
+		value, err := json.Marshal(gi.Value)
+		if err != nil {
+			return nil, fmt.Errorf("graphpb: encode interrupt value: %w", err)
+		}
+		set(m, "value", protoreflect.ValueOfBytes(value))
+	}
+	resumes := mutable(m, "resumes").List()
+	for _, resume := range gi.Resumes {
+		value, err := json.Marshal(resume)
+		if err != nil {
+			return nil, fmt.Errorf("graphpb: encode resume value: %w", err)
+		}
+		resumes.Append(protoreflect.ValueOfBytes(value))
+	}
+	return m, nil
+}
+
+func interruptOf(m protoreflect.Message) (*graph.GraphInterrupt, error) {
+	gi := &graph.GraphInterrupt{
+		Node:   get(m, "node").String(),
+		Before: get(m, "before").Bool(),
+		After:  get(m, "after").Bool(),
+	}
+	if value := get(m, "value").Bytes(); len(value) > 0 {
+		if err := json.Unmarshal(value, &gi.Value); err != nil {
+			return nil, fmt.Errorf("graphpb: decode interrupt value: %w", err)
+		}
+	}
+	resumes := get(m, "resumes").List()
+	for i := range resumes.Len() {
+		var resume any
+		if err := json.Unmarshal(resumes.Get(i).Bytes(), &resume); err != nil {
+			return nil, fmt.Errorf("graphpb: decode resume value: %w", err)
+		}
+		gi.Resumes = append(gi.Resumes, resume)
+	}
+	return gi, nil
+}
+
+// setState sets the state oneof of m to state, a MessageState message for
+// graph.MessageState states and JSON otherwise.
+func setState[T any](m protoreflect.Message, state *T) error {
+	if ms, ok := any(state).(*graph.MessageState); ok {
+		sm, err := messageStateMessage(*ms)
+		if err != nil {
+			return err
+		}
+		set(m, "message_state", protoreflect.ValueOfMessage(sm))
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("graphpb: encode state: %w", err)
+	}
+	set(m, "state_json", protoreflect.ValueOfBytes(data))
+	return nil
+}
+
+// stateOf decodes the state oneof of m into state. MessageState messages
+// decode into other types through their JSON encoding, and JSON into
+// graph.MessageState states.
+func stateOf[T any](m protoreflect.Message, state *T) error {
+	switch {
+	case has(m, "message_state"):
+		s, err := messageStateOf(get(m, "message_state").Message())
+		if err != nil {
+			return err
+		}
+		if ms, ok := any(state).(*graph.MessageState); ok {
+			*ms = s
+			return nil
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			return fmt.Errorf("graphpb: decode state: %w", err)
+		}
+		if err := json.Unmarshal(data, state); err != nil {
+			return fmt.Errorf("graphpb: decode state: %w", err)
+		}
+	case has(m, "state_json"):
+		if err := json.Unmarshal(get(m, "state_json").Bytes(), state); err != nil {
+			return fmt.Errorf("graphpb: decode state: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package graphpb_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphpb"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestMessageState(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := graph.MessageState{Messages: []graph.Message{
+		{
+			ID:             "1",
+			MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+			Metadata:       graph.Metadata{graph.MetadataCreatedAt: createdAt, graph.MetadataName: "ada"},
+		},
+		{ID: "2", MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "let me look"},
+			llms.ToolCall{ID: "call", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: `{"q":"go"}`}},
+		}}},
+		{ID: "3", MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+			llms.ToolCallResponse{ToolCallID: "call", Name: "search", Content: "results"},
+		}}},
+		{ID: "4", MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+			llms.ImageURLContent{URL: "https://example.com/cat.png", Detail: "low"},
+			llms.BinaryContent{MIMEType: "image/png", Data: []byte{1, 2, 3}},
+		}}},
+	}}
+
+	data, err := graphpb.MarshalMessageState(want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := graphpb.UnmarshalMessageState(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, but got %+v", want, got)
+	}
+
+	bad := graph.MessageState{Messages: []graph.Message{{MessageContent: llms.MessageContent{Parts: []llms.ContentPart{nil}}}}}
+	if _, err := graphpb.MarshalMessageState(bad); !errors.Is(err, graph.ErrUnknownPartType) {
+		t.Errorf("expected error %v, but got %v", graph.ErrUnknownPartType, err)
+	}
+}
+
+type counter struct {
+	Count int      `json:"count"`
+	Steps []string `json:"steps"`
+}
+
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := graph.Checkpoint[counter]{
+		ID:        "cp",
+		ThreadID:  "thread",
+		Step:      2,
+		Node:      "b",
+		State:     counter{Count: 2, Steps: []string{"a", "b"}},
+		Next:      []string{"c"},
+		Interrupt: &graph.GraphInterrupt{Node: "c", Value: "continue?", Resumes: []any{"yes"}},
+		Error:     "failed",
+		CreatedAt: createdAt,
+	}
+	data, err := graphpb.MarshalCheckpoint(want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := graphpb.UnmarshalCheckpoint[counter](data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, but got %+v", want, got)
+	}
+
+	// MessageState states are MessageState messages, which decode into
+	// other types through JSON.
+	ms := graph.Checkpoint[graph.MessageState]{ID: "cp", State: graph.MessageState{Messages: []graph.Message{
+		{ID: "1", MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "hi")},
+	}}}
+	data, err = graphpb.MarshalCheckpoint(ms)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gotMS, err := graphpb.UnmarshalCheckpoint[graph.MessageState](data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotMS.State, ms.State) {
+		t.Errorf("expected state %+v, but got %+v", ms.State, gotMS.State)
+	}
+	type chat struct {
+		Messages []struct {
+			Role string `json:"role"`
+		} `json:"messages"`
+	}
+	gotChat, err := graphpb.UnmarshalCheckpoint[chat](data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gotChat.State.Messages) != 1 || gotChat.State.Messages[0].Role != "human" {
+		t.Errorf("expected one human message, but got %+v", gotChat.State)
+	}
+
+	if _, err := graphpb.UnmarshalCheckpoint[counter]([]byte{0xff}); err == nil {
+		t.Error("expected an error for invalid data, but got nil")
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[counter]()
+	g.AddNode("count", func(_ context.Context, s *counter) error {
+		s.Count++
+		return nil
+	})
+	g.AddNode("ask", func(ctx context.Context, _ *counter) error {
+		_, err := graph.Interrupt(ctx, "continue?")
+		return err
+	})
+	g.AddEdge("count", "ask")
+	g.AddEdge("ask", graph.END)
+	g.SetEntryPoint("count")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w := graphpb.NewEventWriter[counter](&buf)
+	var state counter
+	if err := runnable.Invoke(context.Background(), &state, graph.WithRunCallbacks[counter](w)); err == nil {
+		t.Fatal("expected an interrupt, but got nil")
+	}
+	if err := w.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var types []graphpb.EventType
+	var last graphpb.Event[counter]
+	r := bufio.NewReader(&buf)
+	for {
+		e, err := graphpb.ReadEvent[counter](r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e.Time.IsZero() || e.State == nil {
+			t.Errorf("expected a time and a state, but got %+v", e)
+		}
+		types = append(types, e.Type)
+		last = e
+	}
+	want := []graphpb.EventType{graphpb.EventNodeStart, graphpb.EventNodeEnd, graphpb.EventNodeStart, graphpb.EventInterrupt}
+	if !slices.Equal(types, want) {
+		t.Errorf("expected events %v, but got %v", want, types)
+	}
+	if last.Node != "ask" || last.Interrupt == nil || last.Interrupt.Value != "continue?" || last.State.Count != 1 {
+		t.Errorf("expected the interrupt of ask, but got %+v", last)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	want := graphpb.Event[graph.MessageState]{
+		Type:     graphpb.EventMessages,
+		RunID:    "run",
+		ThreadID: "thread",
+		Node:     "agent",
+		Time:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Delta:    &graph.MessageDelta{ID: "1", Role: llms.ChatMessageTypeAI, Text: "hel", Parts: []llms.ContentPart{llms.TextContent{Text: "lo"}}},
+	}
+	data, err := graphpb.MarshalEvent(want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := graphpb.UnmarshalEvent[graph.MessageState](data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, but got %+v", want, got)
+	}
+	if name := graphpb.File.Messages().ByName("Event").FullName(); name != "langgraphgo.v1.Event" {
+		t.Errorf("expected message langgraphgo.v1.Event, but got %s", name)
+	}
+}
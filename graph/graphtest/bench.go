@@ -0,0 +1,199 @@
+package graphtest
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// BenchConfig configures Harness.Benchmark.
+type BenchConfig[T any] struct {
+	// Input returns the state a run starts with, the zero value by default.
+	Input func() T
+
+	// Warmup is the number of runs before measuring, 10 by default.
+	Warmup int
+
+	// Runs is the number of runs measured when the harness does not run a
+	// benchmark, 100 by default. Benchmarks run b.N times.
+	Runs int
+
+	// Opts are the options of the runs.
+	Opts []graph.InvokeOption
+}
+
+// BenchResult is the result of Harness.Benchmark.
+type BenchResult struct {
+	// Runs is the number of runs measured.
+	Runs int
+
+	// Total is the time of the runs measured.
+	Total time.Duration
+
+	// P50 and P95 are the median and the 95th percentile of the time of a
+	// run.
+	P50, P95 time.Duration
+
+	// AllocsPerRun and BytesPerRun are the mean heap allocations of a run.
+	AllocsPerRun, BytesPerRun float64
+
+	// Nodes are the results of the nodes, by name.
+	Nodes map[string]NodeBenchResult
+}
+
+// OpsPerSec returns the number of runs per second.
+func (r BenchResult) OpsPerSec() float64 {
+	if r.Total <= 0 {
+		return 0
+	}
+	return float64(r.Runs) / r.Total.Seconds()
+}
+
+// NodeBenchResult is the result of a node in Harness.Benchmark.
+type NodeBenchResult struct {
+	// Calls is the number of runs of the node.
+	Calls int
+
+	// Mean and P95 are the mean and the 95th percentile of the time of a run
+	// of the node.
+	Mean, P95 time.Duration
+}
+
+// Benchmark measures the runs of the graph, with its mocks, end to end and
+// node by node, after warming it up. Nodes calling models are replaced by
+// mocks beforehand, such as the Node of a cycling FakeModel, so that the
+// graph itself is measured:
+//
+//	func BenchmarkAgent(b *testing.B) {
+//		h := graphtest.New(b, newAgentGraph())
+//		h.Mock("call_model", graphtest.NewFakeModel(responses...).Cycle().Node())
+//		h.Benchmark(context.Background(), graphtest.BenchConfig[State]{})
+//	}
+//
+// When the harness runs a benchmark, Benchmark runs the graph b.N times and
+// reports the allocations, the runs per second, the 95th percentile of the
+// time of a run and the mean time of every node as metrics. Otherwise it runs
+// the graph cfg.Runs times. It fails the test if a run fails, and returns the
+// result.
+func (h *Harness[T]) Benchmark(ctx context.Context, cfg BenchConfig[T]) BenchResult {
+	h.t.Helper()
+	if cfg.Warmup <= 0 {
+		cfg.Warmup = 10
+	}
+	if cfg.Runs <= 0 {
+		cfg.Runs = 100
+	}
+	input := cfg.Input
+	if input == nil {
+		input = func() T {
+			var zero T
+			return zero
+		}
+	}
+	runnable, err := h.graph.Compile(h.opts...)
+	if err != nil {
+		h.t.Fatalf("graphtest: compile: %v", err)
+	}
+	timer := &nodeTimer[T]{durations: map[string][]time.Duration{}}
+	opts := append([]graph.InvokeOption{graph.WithRunCallbacks[T](timer)}, cfg.Opts...)
+	run := func() time.Duration {
+		state := input()
+		start := time.Now()
+		if err := runnable.Invoke(ctx, &state, opts...); err != nil {
+			h.t.Fatalf("graphtest: benchmark: %v", err)
+		}
+		return time.Since(start)
+	}
+
+	for range cfg.Warmup {
+		run()
+	}
+	timer.reset()
+	b, isBenchmark := h.t.(*testing.B)
+	runs := cfg.Runs
+	if isBenchmark {
+		runs = b.N
+		b.ReportAllocs()
+		b.ResetTimer()
+	}
+	durations := make([]time.Duration, runs)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := range durations {
+		durations[i] = run()
+	}
+	total := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if isBenchmark {
+		b.StopTimer()
+	}
+
+	result := BenchResult{
+		Runs:         runs,
+		Total:        total,
+		P50:          percentile(durations, 0.5),
+		P95:          percentile(durations, 0.95),
+		AllocsPerRun: float64(after.Mallocs-before.Mallocs) / float64(runs),
+		BytesPerRun:  float64(after.TotalAlloc-before.TotalAlloc) / float64(runs),
+		Nodes:        map[string]NodeBenchResult{},
+	}
+	for node, d := range timer.durations {
+		var sum time.Duration
+		for _, v := range d {
+			sum += v
+		}
+		result.Nodes[node] = NodeBenchResult{Calls: len(d), Mean: sum / time.Duration(len(d)), P95: percentile(d, 0.95)}
+	}
+	if isBenchmark {
+		b.ReportMetric(result.OpsPerSec(), "ops/s")
+		b.ReportMetric(float64(result.P95.Nanoseconds()), "p95-ns/op")
+		for node, r := range result.Nodes {
+			b.ReportMetric(float64(r.Mean.Nanoseconds()), node+"-ns/call")
+		}
+	}
+	return result
+}
+
+// percentile returns the pth percentile of durations, which it sorts.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	slices.Sort(durations)
+	return durations[int(p*float64(len(durations)-1))]
+}
+
+// nodeTimer records the times of the runs of nodes.
+type nodeTimer[T any] struct {
+	mu        sync.Mutex
+	starts    map[string]time.Time
+	durations map[string][]time.Duration
+}
+
+func (n *nodeTimer[T]) NodeStart(_ context.Context, node string, _ *T) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.starts == nil {
+		n.starts = map[string]time.Time{}
+	}
+	n.starts[node] = time.Now()
+}
+
+func (n *nodeTimer[T]) NodeEnd(_ context.Context, node string, _ *T, _ error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.durations[node] = append(n.durations[node], time.Since(n.starts[node]))
+}
+
+// reset forgets the runs recorded so far.
+func (n *nodeTimer[T]) reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.durations = map[string][]time.Duration{}
+}
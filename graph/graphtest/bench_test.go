@@ -0,0 +1,46 @@
+package graphtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+// newChatGraph builds a graph replying to the messages with a model node.
+func newChatGraph() *graph.StateGraph[graph.MessageState] {
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("agent", func(context.Context, *graph.MessageState) error {
+		panic("the model is mocked")
+	})
+	g.AddEdge("agent", graph.END)
+	g.SetEntryPoint("agent")
+	return g
+}
+
+func TestBenchmark(t *testing.T) {
+	t.Parallel()
+
+	h := graphtest.New(t, newChatGraph())
+	h.Mock("agent", graphtest.NewFakeModel(graphtest.Response{Text: "Hello!"}).Cycle().Node())
+	result := h.Benchmark(context.Background(), graphtest.BenchConfig[graph.MessageState]{
+		Input: graph.NewMessageState,
+		Runs:  20,
+	})
+	if result.Runs != 20 {
+		t.Errorf("expected 20 runs, but got %d", result.Runs)
+	}
+	if result.P95 < result.P50 || result.OpsPerSec() <= 0 {
+		t.Errorf("unexpected latencies: p50 %s, p95 %s, %f ops/s", result.P50, result.P95, result.OpsPerSec())
+	}
+	if calls := result.Nodes["agent"].Calls; calls != 20 {
+		t.Errorf("expected 20 calls of agent, but got %d", calls)
+	}
+}
+
+func BenchmarkChatGraph(b *testing.B) {
+	h := graphtest.New(b, newChatGraph())
+	h.Mock("agent", graphtest.NewFakeModel(graphtest.Response{Text: "Hello!"}).Cycle().Node())
+	h.Benchmark(context.Background(), graphtest.BenchConfig[graph.MessageState]{Input: graph.NewMessageState})
+}
@@ -0,0 +1,154 @@
+package graphtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// CheckpointerCall is a call of a MockCheckpointer.
+type CheckpointerCall struct {
+	// Method is "Put", "Get", "List" or "Delete".
+	Method string
+
+	ThreadID string
+
+	// Step is the step of the checkpoint put, or returned by Get.
+	Step int
+
+	// Err is the error the call returned, if any.
+	Err error
+}
+
+// MockCheckpointer is a graph.Checkpointer keeping checkpoints in memory
+// that records its calls and fails or delays the writes and reads it is told
+// to, such as to test how a graph or a server handles a database failing on
+// the third checkpoint of a run:
+//
+//	cp := graphtest.NewMockCheckpointer[State]()
+//	cp.FailPut(3, errors.New("connection reset"))
+//	h := graphtest.New(t, g, graph.WithCheckpointer[State](cp))
+//
+// It is safe for concurrent use.
+type MockCheckpointer[T any] struct {
+	saver *graph.MemorySaver[T]
+
+	mu        sync.Mutex
+	calls     []CheckpointerCall
+	puts      int
+	gets      int
+	putErrs   map[int]error
+	getErrs   map[int]error
+	putDelays map[int]time.Duration
+}
+
+var (
+	_ graph.Checkpointer[struct{}] = (*MockCheckpointer[struct{}])(nil)
+	_ graph.ThreadDeleter          = (*MockCheckpointer[struct{}])(nil)
+)
+
+// NewMockCheckpointer returns a MockCheckpointer without checkpoints.
+func NewMockCheckpointer[T any]() *MockCheckpointer[T] {
+	return &MockCheckpointer[T]{
+		saver:     graph.NewMemorySaver[T](),
+		putErrs:   map[int]error{},
+		getErrs:   map[int]error{},
+		putDelays: map[int]time.Duration{},
+	}
+}
+
+// FailPut makes the nth call of Put, counting from 1, return err without
+// saving the checkpoint.
+func (m *MockCheckpointer[T]) FailPut(n int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.putErrs[n] = err
+}
+
+// DelayPut makes the nth call of Put, counting from 1, wait for d before
+// saving the checkpoint, or return the error of its context if it is done
+// first.
+func (m *MockCheckpointer[T]) DelayPut(n int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.putDelays[n] = d
+}
+
+// FailGet makes the nth call of Get, counting from 1, return err.
+func (m *MockCheckpointer[T]) FailGet(n int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getErrs[n] = err
+}
+
+// Put implements graph.Checkpointer.
+func (m *MockCheckpointer[T]) Put(ctx context.Context, cp graph.Checkpoint[T]) error {
+	m.mu.Lock()
+	m.puts++
+	err, delay := m.putErrs[m.puts], m.putDelays[m.puts]
+	m.mu.Unlock()
+	if err == nil && delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		}
+	}
+	if err == nil {
+		err = m.saver.Put(ctx, cp)
+	}
+	m.record(CheckpointerCall{Method: "Put", ThreadID: cp.ThreadID, Step: cp.Step, Err: err})
+	return err
+}
+
+// Get implements graph.Checkpointer.
+func (m *MockCheckpointer[T]) Get(ctx context.Context, threadID string) (graph.Checkpoint[T], error) {
+	m.mu.Lock()
+	m.gets++
+	err := m.getErrs[m.gets]
+	m.mu.Unlock()
+	var cp graph.Checkpoint[T]
+	if err == nil {
+		cp, err = m.saver.Get(ctx, threadID)
+	}
+	m.record(CheckpointerCall{Method: "Get", ThreadID: threadID, Step: cp.Step, Err: err})
+	return cp, err
+}
+
+// List implements graph.Checkpointer.
+func (m *MockCheckpointer[T]) List(ctx context.Context, threadID string) ([]graph.Checkpoint[T], error) {
+	cps, err := m.saver.List(ctx, threadID)
+	m.record(CheckpointerCall{Method: "List", ThreadID: threadID, Err: err})
+	return cps, err
+}
+
+// Delete implements graph.ThreadDeleter.
+func (m *MockCheckpointer[T]) Delete(ctx context.Context, threadID string) error {
+	err := m.saver.Delete(ctx, threadID)
+	m.record(CheckpointerCall{Method: "Delete", ThreadID: threadID, Err: err})
+	return err
+}
+
+func (m *MockCheckpointer[T]) record(call CheckpointerCall) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+// Calls returns the calls of the checkpointer, in the order they returned.
+func (m *MockCheckpointer[T]) Calls() []CheckpointerCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CheckpointerCall(nil), m.calls...)
+}
+
+// Puts returns the number of calls of Put, including the failed ones.
+func (m *MockCheckpointer[T]) Puts() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.puts
+}
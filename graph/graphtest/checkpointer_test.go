@@ -0,0 +1,62 @@
+package graphtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+func TestMockCheckpointer(t *testing.T) {
+	t.Parallel()
+
+	cp := graphtest.NewMockCheckpointer[answer]()
+	reset := errors.New("connection reset")
+	cp.FailPut(2, reset)
+	h := graphtest.New(t, newAnswerGraph(), graph.WithAllowedCycle("search"), graph.WithCheckpointer[answer](cp))
+	h.Stub("search", func(s *answer) { s.Sources = append(s.Sources, "wiki") })
+	if err := h.Run(context.Background(), &answer{}, graph.WithThreadID("t1")); !errors.Is(err, reset) {
+		t.Fatalf("expected error %v, but got %v", reset, err)
+	}
+	// The run failed after the second search, whose checkpoint was lost.
+	saved, err := cp.Get(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Step != 1 {
+		t.Errorf("expected the checkpoint of step 1, but got step %d", saved.Step)
+	}
+	want := []graphtest.CheckpointerCall{
+		{Method: "Put", ThreadID: "t1", Step: 1},
+		{Method: "Put", ThreadID: "t1", Step: 2, Err: reset},
+		{Method: "Get", ThreadID: "t1", Step: 1},
+	}
+	calls := cp.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("expected calls %v, but got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("expected call %d to be %v, but got %v", i+1, want[i], calls[i])
+		}
+	}
+
+	// A delayed write fails when its context is done first.
+	cp.DelayPut(3, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := cp.Put(ctx, graph.Checkpoint[answer]{ThreadID: "t2"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error %v, but got %v", context.DeadlineExceeded, err)
+	}
+
+	cp.FailGet(2, reset)
+	if _, err := cp.Get(context.Background(), "t1"); !errors.Is(err, reset) {
+		t.Errorf("expected error %v, but got %v", reset, err)
+	}
+	if got := cp.Puts(); got != 3 {
+		t.Errorf("expected 3 puts, but got %d", got)
+	}
+}
@@ -0,0 +1,97 @@
+package graphtest
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// FakeClock is a graph.Clock whose time only moves when advanced, so that
+// retries, timeouts and expiries are tested without waiting:
+//
+//	clock := graphtest.NewFakeClock(time.Now())
+//	h := graphtest.New(t, g, graph.WithClock(clock))
+//	go func() {
+//		clock.BlockUntil(1) // the node waits to be retried
+//		clock.Advance(time.Second)
+//	}()
+//
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+var _ graph.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now implements graph.Clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After implements graph.Clock. The channel receives the time once the
+// clock is advanced by d, or at once if d is not positive.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// Advance moves the time forward by d, firing the timers due by then in
+// the order they are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	slices.SortStableFunc(c.timers, func(a, b fakeTimer) int { return a.at.Compare(b.at) })
+	i := 0
+	for ; i < len(c.timers) && !c.timers[i].at.After(c.now); i++ {
+		c.timers[i].ch <- c.timers[i].at
+	}
+	c.timers = slices.Delete(c.timers, 0, i)
+	c.cond.Broadcast()
+}
+
+// Timers returns the number of timers waiting for the clock to advance.
+// Timers whose receivers gave up, such as on a canceled context, still
+// count until they fire.
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil waits for n timers to wait for the clock to advance, such as
+// for a node to wait for its retry before advancing the clock past it.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
@@ -0,0 +1,91 @@
+package graphtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+
+	// build returns a graph of a single node, run with opts.
+	build := func(fn func(ctx context.Context, n *int) error, opts ...graph.NodeOption) *graph.StateGraph[int] {
+		g := graph.NewStateGraph[int]()
+		g.AddNode("node", fn, opts...)
+		g.AddEdge("node", graph.END)
+		g.SetEntryPoint("node")
+		return g
+	}
+	// run runs h in the background, advancing clock by d once the run waits
+	// for it.
+	run := func(h *graphtest.Harness[int], clock *graphtest.FakeClock, d time.Duration) error {
+		errc := make(chan error, 1)
+		go func() {
+			n := 0
+			errc <- h.Run(context.Background(), &n)
+		}()
+		clock.BlockUntil(1)
+		clock.Advance(d)
+		return <-errc
+	}
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+		clock := graphtest.NewFakeClock(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+		attempts := 0
+		g := build(func(context.Context, *int) error {
+			attempts++
+			if attempts == 1 {
+				return errors.New("flaky")
+			}
+			return nil
+		}, graph.WithRetry(graph.RetryPolicy{InitialInterval: time.Hour}))
+		if err := run(graphtest.New(t, g, graph.WithClock(clock)), clock, time.Hour); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, but got %d", attempts)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		t.Parallel()
+		clock := graphtest.NewFakeClock(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+		g := build(func(ctx context.Context, _ *int) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, graph.WithTimeout(time.Minute))
+		if err := run(graphtest.New(t, g, graph.WithClock(clock)), clock, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected error %v, but got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	t.Run("Cache TTL", func(t *testing.T) {
+		t.Parallel()
+		clock := graphtest.NewFakeClock(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+		calls := 0
+		g := build(func(context.Context, *int) error {
+			calls++
+			return nil
+		}, graph.WithCache(graph.CachePolicy{TTL: time.Minute}))
+		runnable, err := g.Compile(graph.WithClock(clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, advance := range []time.Duration{0, 30 * time.Second, 31 * time.Second} {
+			clock.Advance(advance)
+			n := 0
+			if err := runnable.Invoke(context.Background(), &n); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if calls != 2 {
+			t.Errorf("expected 2 calls, the cache expiring after a minute, but got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package graphtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// Coverage collects the nodes and edges of a graph exercised by runs, such
+// as across the tests of a package, to report the routes no test takes:
+//
+//	var coverage = graphtest.NewCoverage(newAgentGraph())
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		coverage.Report(os.Stdout)
+//		os.Exit(code)
+//	}
+//
+// Runs report to a Coverage through its Handler, or through a Harness, see
+// Harness.Cover. It is safe for concurrent use.
+type Coverage[T any] struct {
+	nodes []string
+	edges []graph.GraphEdge
+
+	mu        sync.Mutex
+	nodeCalls map[string]int
+	edgeCalls map[graph.GraphEdge]int
+}
+
+// NewCoverage returns a Coverage of the nodes and edges of g, see
+// graph.StateGraph.Edges.
+func NewCoverage[T any](g *graph.StateGraph[T]) *Coverage[T] {
+	c := &Coverage[T]{edges: g.Edges(), nodeCalls: map[string]int{}, edgeCalls: map[graph.GraphEdge]int{}}
+	for _, node := range g.Nodes() {
+		c.nodes = append(c.nodes, node.Name)
+	}
+	return c
+}
+
+// Handler returns a handler recording the nodes and edges of a run, to be
+// given to a single run with graph.WithRunCallbacks. The edge to the first
+// node is the edge from START, and routes to unknown nodes count as the edges
+// to "*" of their conditional edges.
+func (c *Coverage[T]) Handler() graph.CallbackHandler[T] {
+	return &coverageHandler[T]{c: c, last: graph.START}
+}
+
+// record records the run of the edge from from to to.
+func (c *Coverage[T]) record(from, to string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if to != graph.END {
+		c.nodeCalls[to]++
+	}
+	var wildcard *graph.GraphEdge
+	for i, e := range c.edges {
+		if e.From != from {
+			continue
+		}
+		if e.To == to {
+			c.edgeCalls[e]++
+			return
+		}
+		if e.To == "*" {
+			wildcard = &c.edges[i]
+		}
+	}
+	if wildcard != nil {
+		c.edgeCalls[*wildcard]++
+	}
+}
+
+// NodeCalls returns how many times the runs ran the node with the given
+// name.
+func (c *Coverage[T]) NodeCalls(node string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nodeCalls[node]
+}
+
+// EdgeCalls returns how many times the runs followed edge.
+func (c *Coverage[T]) EdgeCalls(edge graph.GraphEdge) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.edgeCalls[edge]
+}
+
+// UnvisitedNodes returns the nodes no run ran, sorted.
+func (c *Coverage[T]) UnvisitedNodes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var nodes []string
+	for _, node := range c.nodes {
+		if c.nodeCalls[node] == 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// UnvisitedEdges returns the edges no run followed, in the order of
+// graph.StateGraph.Edges.
+func (c *Coverage[T]) UnvisitedEdges() []graph.GraphEdge {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var edges []graph.GraphEdge
+	for _, e := range c.edges {
+		if c.edgeCalls[e] == 0 {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Report writes the share of the nodes and edges the runs exercised, and the
+// ones they did not, to w:
+//
+//	graph coverage: 3/4 nodes (75.0%), 4/6 edges (66.7%)
+//	unvisited node: escalate
+//	unvisited edge: agent ..> escalate
+//	unvisited edge: escalate --> END
+func (c *Coverage[T]) Report(w io.Writer) error {
+	nodes, edges := c.UnvisitedNodes(), c.UnvisitedEdges()
+	var b strings.Builder
+	fmt.Fprintf(&b, "graph coverage: %d/%d nodes (%s), %d/%d edges (%s)\n",
+		len(c.nodes)-len(nodes), len(c.nodes), percent(len(c.nodes)-len(nodes), len(c.nodes)),
+		len(c.edges)-len(edges), len(c.edges), percent(len(c.edges)-len(edges), len(c.edges)))
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "unvisited node: %s\n", node)
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&b, "unvisited edge: %s\n", e)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// percent formats n out of total as a percentage.
+func percent(n, total int) string {
+	if total == 0 {
+		return "100.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
+
+// coverageHandler records a run to a Coverage.
+type coverageHandler[T any] struct {
+	c *Coverage[T]
+
+	mu   sync.Mutex
+	last string
+}
+
+func (h *coverageHandler[T]) NodeStart(_ context.Context, node string, _ *T) {
+	h.mu.Lock()
+	from := h.last
+	h.last = node
+	h.mu.Unlock()
+	h.c.record(from, node)
+}
+
+func (h *coverageHandler[T]) NodeEnd(context.Context, string, *T, error) {}
+
+func (h *coverageHandler[T]) End(_ context.Context, node string, _ *T) {
+	h.c.record(node, graph.END)
+}
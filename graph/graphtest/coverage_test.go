@@ -0,0 +1,64 @@
+package graphtest_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+func TestCoverage(t *testing.T) {
+	t.Parallel()
+
+	// The graph answers questions it has sources for and escalates others.
+	g := graph.NewStateGraph[answer]()
+	g.AddNode("search", func(context.Context, *answer) error { return nil })
+	g.AddNode("respond", func(_ context.Context, s *answer) error {
+		s.Answer = s.Sources[0]
+		return nil
+	})
+	g.AddNode("escalate", func(context.Context, *answer) error { return nil })
+	g.AddConditionalEdges("search", func(_ context.Context, s *answer) ([]string, error) {
+		if len(s.Sources) == 0 {
+			return []string{"escalate"}, nil
+		}
+		return []string{"respond"}, nil
+	}, graph.WithMap[answer](map[string]string{"respond": "respond", "escalate": "escalate"}))
+	g.AddEdge("respond", graph.END)
+	g.AddEdge("escalate", graph.END)
+	g.SetEntryPoint("search")
+
+	coverage := graphtest.NewCoverage(g)
+	// Two tests run the graph with sources, none without.
+	for range 2 {
+		h := graphtest.New(t, g)
+		h.Cover(coverage)
+		if err := h.Run(context.Background(), &answer{Sources: []string{"wiki"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, want := coverage.UnvisitedNodes(), []string{"escalate"}; !slices.Equal(got, want) {
+		t.Errorf("expected unvisited nodes %v, but got %v", want, got)
+	}
+	if got := coverage.NodeCalls("search"); got != 2 {
+		t.Errorf("expected 2 calls of search, but got %d", got)
+	}
+	if got := coverage.EdgeCalls(graph.GraphEdge{From: "search", To: "respond", Conditional: true}); got != 2 {
+		t.Errorf("expected 2 runs of search ..> respond, but got %d", got)
+	}
+	var b strings.Builder
+	if err := coverage.Report(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "graph coverage: 2/3 nodes (66.7%), 3/5 edges (60.0%)\n" +
+		"unvisited node: escalate\n" +
+		"unvisited edge: escalate --> END\n" +
+		"unvisited edge: search ..> escalate\n"
+	if b.String() != want {
+		t.Errorf("expected report\n%s\nbut got\n%s", want, b.String())
+	}
+}
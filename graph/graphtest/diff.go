@@ -0,0 +1,64 @@
+package graphtest
+
+import "strings"
+
+// diffLines returns the lines of want and got as a diff, the lines of want
+// missing from got prefixed with "-", the lines added in got with "+".
+func diffLines(want, got []string) string {
+	var b strings.Builder
+	for _, p := range align(want, got) {
+		switch {
+		case p.want >= 0 && p.got >= 0:
+			b.WriteString("  " + want[p.want] + "\n")
+		case p.want >= 0:
+			b.WriteString("- " + want[p.want] + "\n")
+		default:
+			b.WriteString("+ " + got[p.got] + "\n")
+		}
+	}
+	return b.String()
+}
+
+// pair is an element of an alignment, the index of an element of want, of
+// got or of both, the missing index being -1.
+type pair struct {
+	want, got int
+}
+
+// align aligns want and got along their longest common subsequence, the
+// elements of want missing from got coming before the elements added in got.
+func align(want, got []string) []pair {
+	// lcs[i][j] is the length of the longest common subsequence of want[i:]
+	// and got[j:].
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var pairs []pair
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			pairs = append(pairs, pair{i, j})
+			i++
+			j++
+		case i < len(want) && (j == len(got) || lcs[i+1][j] >= lcs[i][j+1]):
+			pairs = append(pairs, pair{i, -1})
+			i++
+		default:
+			pairs = append(pairs, pair{-1, j})
+			j++
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,189 @@
+package graphtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+var update = flag.Bool("graphtest.update", false, "record the golden files of Harness.Golden and the files of HTTPRecorder again")
+
+// Recording is a recorded run, as stored in golden files.
+type Recording struct {
+	// Steps are the nodes the run ran, in the order they started.
+	Steps []Step `json:"steps"`
+}
+
+// Step is a node run of a Recording.
+type Step struct {
+	Node string `json:"node"`
+
+	// State is the state the node returned, as JSON.
+	State json.RawMessage `json:"state"`
+
+	// Error is the error the node returned, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Golden runs the graph on state against the recording in the golden file
+// at path. The nodes named in replay, such as those calling models, are not
+// run: they return the states they returned when recorded, in the order
+// they ran. The test fails when the path of the run or the states the other
+// nodes return differ from the recording. Golden returns the error of the
+// run.
+//
+// When the file does not exist or the test runs with -graphtest.update, the
+// run is recorded to the file instead, with all the nodes run.
+func (h *Harness[T]) Golden(ctx context.Context, path string, state *T, replay ...string) error {
+	h.t.Helper()
+	rec := &stepRecorder[T]{}
+	data, err := os.ReadFile(path)
+	if *update || errors.Is(err, os.ErrNotExist) {
+		runErr := h.run(ctx, h.graph, state, graph.WithRunCallbacks[T](rec))
+		b, err := json.MarshalIndent(Recording{Steps: rec.steps}, "", "  ")
+		if err != nil {
+			h.t.Fatalf("graphtest: golden: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			h.t.Fatalf("graphtest: golden: %v", err)
+		}
+		if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+			h.t.Fatalf("graphtest: golden: %v", err)
+		}
+		h.t.Logf("graphtest: recorded %s", path)
+		return runErr
+	}
+	if err != nil {
+		h.t.Fatalf("graphtest: golden: %v", err)
+	}
+	var golden Recording
+	if err := json.Unmarshal(data, &golden); err != nil {
+		h.t.Fatalf("graphtest: golden %s: %v", path, err)
+	}
+
+	g := h.graph.Clone()
+	for _, node := range replay {
+		steps := slices.DeleteFunc(slices.Clone(golden.Steps), func(s Step) bool { return s.Node != node })
+		var mu sync.Mutex
+		replayed := func(_ context.Context, state *T) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(steps) == 0 {
+				return fmt.Errorf("graphtest: node %s ran more often than recorded", node)
+			}
+			step := steps[0]
+			steps = steps[1:]
+			if step.Error != "" {
+				return errors.New(step.Error)
+			}
+			var s T
+			if err := json.Unmarshal(step.State, &s); err != nil {
+				return fmt.Errorf("graphtest: recorded state of node %s: %w", node, err)
+			}
+			*state = s
+			return nil
+		}
+		if err := g.ReplaceNode(node, replayed); err != nil {
+			h.t.Fatalf("graphtest: replay: %v", err)
+		}
+	}
+	runErr := h.run(ctx, g, state, graph.WithRunCallbacks[T](rec))
+
+	want := make([]string, len(golden.Steps))
+	for i, s := range golden.Steps {
+		want[i] = s.Node
+	}
+	if got := h.Path(); !slices.Equal(got, want) {
+		h.t.Errorf("graphtest: path drifted from %s (-recorded +got):\n%s", path, diffLines(want, got))
+		return runErr
+	}
+	// The states of the nodes run are compared call by call.
+	calls := map[string]int{}
+	for _, recorded := range golden.Steps {
+		n := calls[recorded.Node]
+		calls[recorded.Node]++
+		if slices.Contains(replay, recorded.Node) {
+			continue
+		}
+		got, ok := rec.nth(recorded.Node, n)
+		if !ok {
+			continue
+		}
+		if got.Error != recorded.Error {
+			h.t.Errorf("graphtest: error of node %s (call %d) drifted from %s: expected %q, but got %q", recorded.Node, n+1, path, recorded.Error, got.Error)
+		}
+		if want, got := indent(recorded.State), indent(got.State); want != got {
+			h.t.Errorf("graphtest: state of node %s (call %d) drifted from %s (-recorded +got):\n%s",
+				recorded.Node, n+1, path, diffLines(strings.Split(want, "\n"), strings.Split(got, "\n")))
+		}
+	}
+	return runErr
+}
+
+// indent returns the JSON data indented, for comparisons and diffs.
+func indent(data json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return b.String()
+}
+
+// stepRecorder records the states returned by the nodes of a run.
+type stepRecorder[T any] struct {
+	mu    sync.Mutex
+	steps []Step
+}
+
+// NodeStart reserves the step of the node, so that steps are in the order
+// nodes started.
+func (r *stepRecorder[T]) NodeStart(_ context.Context, node string, _ *T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, Step{Node: node})
+}
+
+func (r *stepRecorder[T]) NodeEnd(_ context.Context, node string, state *T, err error) {
+	data, merr := json.Marshal(state)
+	if merr != nil {
+		data, _ = json.Marshal(merr.Error())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// The step is the first of the node that has not ended.
+	for i := range r.steps {
+		if s := &r.steps[i]; s.Node == node && s.State == nil {
+			s.State = data
+			if err != nil {
+				s.Error = err.Error()
+			}
+			return
+		}
+	}
+}
+
+// nth returns the step of the nth call of node.
+func (r *stepRecorder[T]) nth(node string, n int) (Step, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.steps {
+		if s.Node != node {
+			continue
+		}
+		if n == 0 {
+			return s, true
+		}
+		n--
+	}
+	return Step{}, false
+}
@@ -0,0 +1,94 @@
+package graphtest_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+// newModelGraph builds a graph asking model, then formatting its answer with
+// format.
+func newModelGraph(model, format func(s *answer)) *graph.StateGraph[answer] {
+	g := graph.NewStateGraph[answer]()
+	g.AddNode("model", func(_ context.Context, s *answer) error {
+		model(s)
+		return nil
+	}, graph.WithTags("llm"))
+	g.AddNode("format", func(_ context.Context, s *answer) error {
+		format(s)
+		return nil
+	})
+	g.AddEdge("model", "format")
+	g.AddEdge("format", graph.END)
+	g.SetEntryPoint("model")
+	return g
+}
+
+func TestGolden(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "testdata", "answer.json")
+	calls := 0
+	model := func(s *answer) {
+		calls++
+		s.Answer = fmt.Sprintf("answer %d", calls)
+	}
+	upper := func(s *answer) { s.Answer = strings.ToUpper(s.Answer) }
+
+	// The first run records the answer of the model.
+	state := answer{Question: "why?"}
+	if err := graphtest.New(t, newModelGraph(model, upper)).Golden(context.Background(), path, &state, "model"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Answer != "ANSWER 1" {
+		t.Fatalf("expected a recorded run, but got %+v", state)
+	}
+
+	// The model is replayed, not asked again.
+	state = answer{Question: "why?"}
+	if err := graphtest.New(t, newModelGraph(model, upper)).Golden(context.Background(), path, &state, "model"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Answer != "ANSWER 1" || calls != 1 {
+		t.Errorf("expected the recorded answer without calling the model, but got %+v after %d calls", state, calls)
+	}
+
+	tests := []struct {
+		name string
+		g    *graph.StateGraph[answer]
+		want string
+	}{
+		{
+			name: "Output drift",
+			g:    newModelGraph(model, func(s *answer) { s.Answer = strings.ToLower(s.Answer) }),
+			want: "graphtest: state of node format (call 1) drifted from " + path + " (-recorded +got):\n" +
+				"  {\n    \"question\": \"why?\",\n    \"sources\": null,\n-   \"answer\": \"ANSWER 1\"\n+   \"answer\": \"answer 1\"\n  }\n",
+		},
+		{
+			name: "Topology drift",
+			g: func() *graph.StateGraph[answer] {
+				g := newModelGraph(model, upper)
+				g.RemoveNode("format")
+				g.AddEdge("model", graph.END)
+				return g
+			}(),
+			want: "graphtest: path drifted from " + path + " (-recorded +got):\n  model\n- format\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &recordingT{TB: t}
+			if err := graphtest.New(rt, tt.g).Golden(context.Background(), path, &answer{Question: "why?"}, "model"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rt.errors) != 1 || rt.errors[0] != tt.want {
+				t.Errorf("expected failure\n%s\nbut got %q", tt.want, rt.errors)
+			}
+		})
+	}
+}
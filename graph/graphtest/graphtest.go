@@ -0,0 +1,213 @@
+// Package graphtest tests graphs: it runs a copy of a graph with some nodes
+// replaced by mocks, records the nodes each run ran, and compares the path,
+// the calls of nodes and the final state to the expected ones, reporting
+// differences as diffs:
+//
+//	func TestAgent(t *testing.T) {
+//		h := graphtest.New(t, newAgentGraph())
+//		h.Stub("call_model", func(s *State) { s.Answer = "42" })
+//		state := State{Question: "meaning of life?"}
+//		if err := h.Run(context.Background(), &state); err != nil {
+//			t.Fatal(err)
+//		}
+//		h.AssertPath("plan", "call_model", "respond")
+//		h.AssertCalls("search", 0)
+//		graphtest.AssertState(t, state, State{Question: "meaning of life?", Answer: "42"})
+//	}
+//
+// The nodes of parallel branches are recorded in the order they start, which
+// varies between runs; AssertCalls checks them regardless of their order.
+//
+// Golden records runs to golden files and replays them, the nodes calling
+// models returning their recorded states, so that tests fail when the graph
+// or its deterministic nodes change:
+//
+//	err := h.Golden(ctx, "testdata/agent.json", &state, "call_model")
+//
+// DiffRecordings compares two recorded runs, such as golden files before and
+// after a change, node by node.
+//
+// A FakeModel replaces models with scripted responses, its Node replying to
+// the messages of a graph.MessageState.
+//
+// AssertSnapshots checks the state after every step of a run, leaving out
+// the fields that vary between runs, such as IDs:
+//
+//	graphtest.AssertSnapshots(t, h.Recording(),
+//		graphtest.Snapshot{Node: "plan", State: State{Question: "why?", Plan: "search"}},
+//		graphtest.Snapshot{Node: "search", State: State{Question: "why?", Plan: "search"}, Ignore: []string{"sources.*.id"}},
+//	)
+//
+// Check runs a graph on random inputs, checking that it terminates and that
+// invariants hold after every node, and Benchmark measures its runs end to
+// end and node by node. A Coverage collects the nodes and edges runs
+// exercised across tests, reporting the routes no test takes. A
+// MockCheckpointer records checkpoint writes and fails or delays them, and an
+// HTTPRecorder records the requests of a test to models and replays them,
+// so that the test runs offline. A FakeClock, given to graph.WithClock, moves
+// the time of retries, timeouts and expiries only when advanced.
+package graphtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// Harness runs a graph in a test, see New.
+type Harness[T any] struct {
+	t     testing.TB
+	graph *graph.StateGraph[T]
+	opts  []graph.CompileOption
+
+	// coverages record the runs of the harness, see Cover.
+	coverages []*Coverage[T]
+
+	mu    sync.Mutex
+	path  []string
+	calls map[string]int
+	steps *stepRecorder[T]
+}
+
+// New returns a Harness running a copy of g, compiled with opts, so that
+// mocking nodes leaves g unchanged.
+func New[T any](t testing.TB, g *graph.StateGraph[T], opts ...graph.CompileOption) *Harness[T] {
+	return &Harness[T]{t: t, graph: g.Clone(), opts: opts, calls: map[string]int{}}
+}
+
+// Mock replaces the function of the node with the given name by fn, keeping
+// its edges. It fails the test if there is no such node.
+func (h *Harness[T]) Mock(node string, fn func(ctx context.Context, state *T) error) {
+	h.t.Helper()
+	if err := h.graph.ReplaceNode(node, fn); err != nil {
+		h.t.Fatalf("graphtest: mock: %v", err)
+	}
+}
+
+// Stub replaces the node with the given name by a node updating the state
+// with update, see Mock.
+func (h *Harness[T]) Stub(node string, update func(state *T)) {
+	h.t.Helper()
+	h.Mock(node, func(_ context.Context, state *T) error {
+		update(state)
+		return nil
+	})
+}
+
+// Fail replaces the node with the given name by a node returning err, see
+// Mock.
+func (h *Harness[T]) Fail(node string, err error) {
+	h.t.Helper()
+	h.Mock(node, func(context.Context, *T) error {
+		return err
+	})
+}
+
+// Run compiles the graph with its mocks and runs it on state, recording the
+// nodes it runs. It fails the test if the graph does not compile, and
+// returns the error of the run.
+func (h *Harness[T]) Run(ctx context.Context, state *T, opts ...graph.InvokeOption) error {
+	h.t.Helper()
+	return h.run(ctx, h.graph, state, opts...)
+}
+
+// run runs g, the graph of the harness or a copy, see Run.
+func (h *Harness[T]) run(ctx context.Context, g *graph.StateGraph[T], state *T, opts ...graph.InvokeOption) error {
+	h.t.Helper()
+	runnable, err := g.Compile(h.opts...)
+	if err != nil {
+		h.t.Fatalf("graphtest: compile: %v", err)
+	}
+	steps := &stepRecorder[T]{}
+	h.mu.Lock()
+	h.path, h.calls, h.steps = nil, map[string]int{}, steps
+	h.mu.Unlock()
+	handlers := []graph.CallbackHandler[T]{recorder[T]{h}, steps}
+	for _, c := range h.coverages {
+		handlers = append(handlers, c.Handler())
+	}
+	return runnable.Invoke(ctx, state, append([]graph.InvokeOption{graph.WithRunCallbacks(handlers...)}, opts...)...)
+}
+
+// Cover records the nodes and edges of the following runs of the harness to
+// c, which may be shared by the harnesses of several tests.
+func (h *Harness[T]) Cover(c *Coverage[T]) {
+	h.coverages = append(h.coverages, c)
+}
+
+// Path returns the nodes the last run ran, in the order they started.
+func (h *Harness[T]) Path() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.path)
+}
+
+// Calls returns how many times the last run ran the node with the given
+// name.
+func (h *Harness[T]) Calls(node string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls[node]
+}
+
+// AssertPath checks that the last run ran the nodes want, in order.
+func (h *Harness[T]) AssertPath(want ...string) {
+	h.t.Helper()
+	if got := h.Path(); !slices.Equal(got, want) {
+		h.t.Errorf("graphtest: unexpected path (-want +got):\n%s", diffLines(want, got))
+	}
+}
+
+// AssertCalls checks that the last run ran the node with the given name want
+// times.
+func (h *Harness[T]) AssertCalls(node string, want int) {
+	h.t.Helper()
+	if got := h.Calls(node); got != want {
+		h.t.Errorf("graphtest: expected %d calls of node %s, but got %d (path %s)", want, node, got, strings.Join(h.Path(), " -> "))
+	}
+}
+
+// recorder records the nodes run by a Harness.
+type recorder[T any] struct {
+	h *Harness[T]
+}
+
+func (r recorder[T]) NodeStart(_ context.Context, node string, _ *T) {
+	r.h.mu.Lock()
+	defer r.h.mu.Unlock()
+	r.h.path = append(r.h.path, node)
+	r.h.calls[node]++
+}
+
+func (r recorder[T]) NodeEnd(context.Context, string, *T, error) {}
+
+// AssertState checks that got equals want, as reflect.DeepEqual does,
+// reporting their differences as a diff of their JSON.
+func AssertState[T any](t testing.TB, got, want T) {
+	t.Helper()
+	if reflect.DeepEqual(got, want) {
+		return
+	}
+	g, w := format(got), format(want)
+	if g == w {
+		// The states differ in fields missing from their JSON.
+		g, w = fmt.Sprintf("%#v", got), fmt.Sprintf("%#v", want)
+	}
+	t.Errorf("graphtest: unexpected state (-want +got):\n%s", diffLines(strings.Split(w, "\n"), strings.Split(g, "\n")))
+}
+
+// format formats v as indented JSON, or as Go syntax if it is not JSON.
+func format(v any) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,120 @@
+package graphtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+type answer struct {
+	Question string   `json:"question"`
+	Sources  []string `json:"sources"`
+	Answer   string   `json:"answer"`
+}
+
+// newAnswerGraph builds a graph searching for sources until it has two, then
+// answering.
+func newAnswerGraph() *graph.StateGraph[answer] {
+	g := graph.NewStateGraph[answer]()
+	g.AddNode("search", func(_ context.Context, s *answer) error {
+		return errors.New("search is offline")
+	})
+	g.AddNode("respond", func(_ context.Context, s *answer) error {
+		s.Answer = fmt.Sprintf("%d sources", len(s.Sources))
+		return nil
+	})
+	g.AddConditionalEdges("search", func(_ context.Context, s *answer) ([]string, error) {
+		if len(s.Sources) < 2 {
+			return []string{"search"}, nil
+		}
+		return []string{"respond"}, nil
+	})
+	g.AddEdge("respond", graph.END)
+	g.SetEntryPoint("search")
+	return g
+}
+
+// recordingT records the failures of assertions.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+}
+
+func TestHarness(t *testing.T) {
+	t.Parallel()
+
+	g := newAnswerGraph()
+	h := graphtest.New(t, g, graph.WithAllowedCycle("search"))
+	h.Stub("search", func(s *answer) { s.Sources = append(s.Sources, "wiki") })
+	state := answer{Question: "why?"}
+	if err := h.Run(context.Background(), &state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.AssertPath("search", "search", "respond")
+	h.AssertCalls("search", 2)
+	graphtest.AssertState(t, state, answer{Question: "why?", Sources: []string{"wiki", "wiki"}, Answer: "2 sources"})
+
+	// The graph keeps its nodes.
+	runnable, err := g.Compile(graph.WithAllowedCycle("search"))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	if err := runnable.Invoke(context.Background(), &answer{}); err == nil || !strings.Contains(err.Error(), "search is offline") {
+		t.Errorf("expected the error of the search node, but got %v", err)
+	}
+
+	offline := errors.New("offline")
+	h.Fail("search", offline)
+	if err := h.Run(context.Background(), &answer{}); !errors.Is(err, offline) {
+		t.Errorf("expected error %v, but got %v", offline, err)
+	}
+	h.AssertPath("search")
+	h.AssertCalls("respond", 0)
+}
+
+func TestHarnessFailures(t *testing.T) {
+	t.Parallel()
+
+	rt := &recordingT{TB: t}
+	h := graphtest.New(rt, newAnswerGraph(), graph.WithAllowedCycle("search"))
+	h.Mock("ghost", func(context.Context, *answer) error { return nil })
+	h.Stub("search", func(s *answer) { s.Sources = []string{"wiki", "news"} })
+	state := answer{}
+	if err := h.Run(context.Background(), &state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.AssertPath("search", "search", "respond")
+	h.AssertCalls("search", 2)
+	graphtest.AssertState(rt, state, answer{Sources: []string{"wiki", "blog"}, Answer: "2 sources"})
+
+	want := []string{
+		"graphtest: mock: node not found: ghost",
+		"graphtest: unexpected path (-want +got):\n  search\n- search\n  respond\n",
+		"graphtest: expected 2 calls of node search, but got 1 (path search -> respond)",
+		"graphtest: unexpected state (-want +got):\n" +
+			"  {\n    \"question\": \"\",\n    \"sources\": [\n      \"wiki\",\n-     \"blog\"\n+     \"news\"\n    ],\n    \"answer\": \"2 sources\"\n  }\n",
+	}
+	if len(rt.errors) != len(want) {
+		t.Fatalf("expected %d failures, but got %q", len(want), rt.errors)
+	}
+	for i := range want {
+		if rt.errors[i] != want[i] {
+			t.Errorf("expected failure\n%s\nbut got\n%s", want[i], rt.errors[i])
+		}
+	}
+}
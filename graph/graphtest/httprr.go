@@ -0,0 +1,209 @@
+package graphtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// ErrUnexpectedRequest is returned by an HTTPRecorder replaying a recording
+// for a request that does not match the next recorded one.
+var ErrUnexpectedRequest = errors.New("graphtest: request not recorded")
+
+// Interaction is a request and its response, as recorded by an
+// HTTPRecorder. Headers of requests are not recorded, so that recordings do
+// not hold credentials.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request of an Interaction.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response of an Interaction.
+type RecordedResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// HTTPRecorder is an http.RoundTripper recording the requests of a test and
+// their responses to a file, then replaying them from the file, so that
+// tests and examples calling models run offline and deterministically:
+//
+//	rr, err := graphtest.NewHTTPRecorder("testdata/agent.httprr", http.DefaultTransport)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rr.Close()
+//	model, err := openai.New(openai.WithHTTPClient(rr.Client()))
+//
+// It records when the file does not exist or the test runs with
+// -graphtest.update, and replays otherwise, requests having to match the
+// recorded ones in order. JSON bodies match regardless of their formatting.
+// It is safe for concurrent use.
+type HTTPRecorder struct {
+	path      string
+	transport http.RoundTripper
+	recording bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	next         int
+}
+
+// NewHTTPRecorder returns an HTTPRecorder recording the requests sent with
+// transport to the file at path, or replaying them from it.
+func NewHTTPRecorder(path string, transport http.RoundTripper) (*HTTPRecorder, error) {
+	r := &HTTPRecorder{path: path, transport: transport}
+	data, err := os.ReadFile(path)
+	if *update || errors.Is(err, os.ErrNotExist) {
+		r.recording = true
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("graphtest: recording %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// HTTPClient returns a client recording or replaying the requests of the
+// test to the file at path, see HTTPRecorder. The recording is saved when
+// the test ends. It fails the test if the file cannot be read or written.
+func HTTPClient(t testing.TB, path string) *http.Client {
+	t.Helper()
+	r, err := NewHTTPRecorder(path, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r.Client()
+}
+
+// Recording reports whether the recorder records requests rather than
+// replaying them, such as to use real credentials only when recording.
+func (r *HTTPRecorder) Recording() bool {
+	return r.recording
+}
+
+// Client returns a client sending its requests through the recorder.
+func (r *HTTPRecorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *HTTPRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{Method: req.Method, URL: req.URL.String(), Body: string(body)}
+	if r.recording {
+		return r.record(req, recorded)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= len(r.interactions) {
+		return nil, fmt.Errorf("%w: %s %s after the %d recorded in %s", ErrUnexpectedRequest, req.Method, recorded.URL, len(r.interactions), r.path)
+	}
+	in := r.interactions[r.next]
+	if !matches(in.Request, recorded) {
+		return nil, fmt.Errorf("%w: %s %s instead of request %d, %s %s, in %s", ErrUnexpectedRequest, req.Method, recorded.URL, r.next+1, in.Request.Method, in.Request.URL, r.path)
+	}
+	r.next++
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+		StatusCode:    in.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader([]byte(in.Response.Body))),
+		ContentLength: int64(len(in.Response.Body)),
+		Request:       req,
+	}
+	if in.Response.ContentType != "" {
+		resp.Header.Set("Content-Type", in.Response.ContentType)
+	}
+	return resp, nil
+}
+
+// record sends req and records it with its response.
+func (r *HTTPRecorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Request:  recorded,
+		Response: RecordedResponse{StatusCode: resp.StatusCode, ContentType: resp.Header.Get("Content-Type"), Body: string(body)},
+	})
+	return resp, nil
+}
+
+// matches reports whether got matches the recorded request want.
+func matches(want, got RecordedRequest) bool {
+	if want.Method != got.Method || want.URL != got.URL {
+		return false
+	}
+	if want.Body == got.Body {
+		return true
+	}
+	var w, g any
+	if json.Unmarshal([]byte(want.Body), &w) != nil || json.Unmarshal([]byte(got.Body), &g) != nil {
+		return false
+	}
+	return reflect.DeepEqual(w, g)
+}
+
+// Close saves the recording, if the recorder records. Replaying recorders
+// have nothing to save.
+func (r *HTTPRecorder) Close() error {
+	if !r.recording {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
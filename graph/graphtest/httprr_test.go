@@ -0,0 +1,67 @@
+package graphtest_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+func TestHTTPRecorder(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"echo":`+string(body)+`}`)
+	}))
+	path := filepath.Join(t.TempDir(), "echo.httprr")
+	// post posts body to the server and returns the response.
+	post := func(t *testing.T, client *http.Client, body string) (string, error) {
+		resp, err := client.Post(srv.URL+"/echo", "application/json", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b), nil
+	}
+
+	rec, err := graphtest.NewHTTPRecorder(path, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Recording() {
+		t.Fatal("expected the recorder to record without a file")
+	}
+	if got, err := post(t, rec.Client(), `{"n": 1}`); err != nil || got != `{"echo":{"n": 1}}` {
+		t.Fatalf("unexpected response %q, error %v", got, err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	// The server is gone, so responses are replayed.
+	replay, err := graphtest.NewHTTPRecorder(path, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Recording() {
+		t.Fatal("expected the recorder to replay its file")
+	}
+	if got, err := post(t, replay.Client(), `{"n":1}`); err != nil || got != `{"echo":{"n": 1}}` {
+		t.Errorf("unexpected replayed response %q, error %v", got, err)
+	}
+	if _, err := post(t, replay.Client(), `{"n":1}`); !errors.Is(err, graphtest.ErrUnexpectedRequest) {
+		t.Errorf("expected error %v, but got %v", graphtest.ErrUnexpectedRequest, err)
+	}
+}
@@ -0,0 +1,172 @@
+package graphtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrNoResponse is returned by a FakeModel asked for more responses than
+// scripted.
+var ErrNoResponse = errors.New("graphtest: no scripted response left")
+
+// Response is a scripted response of a FakeModel.
+type Response struct {
+	// Text is the reply of the model.
+	Text string
+
+	// ToolCalls are the tools the model calls, see ToolCall.
+	ToolCalls []llms.ToolCall
+
+	// Err, if set, is returned instead of the response.
+	Err error
+}
+
+// ToolCall returns a call of the tool with the given name, args being encoded
+// as the JSON of its arguments. The FakeModel numbers the calls without an
+// ID.
+func ToolCall(name string, args any) llms.ToolCall {
+	b, err := json.Marshal(args)
+	if err != nil {
+		panic(fmt.Sprintf("graphtest: arguments of tool call %s: %v", name, err))
+	}
+	return llms.ToolCall{Type: "function", FunctionCall: &llms.FunctionCall{Name: name, Arguments: string(b)}}
+}
+
+// FakeModel is an llms.Model returning scripted responses in order, so that
+// graphs calling models are tested without network access. Responses are
+// streamed word by word to the streaming function of the call, if any. It is
+// safe for concurrent use.
+type FakeModel struct {
+	mu        sync.Mutex
+	responses []Response
+	requests  [][]llms.MessageContent
+	calls     int
+	cycle     bool
+}
+
+var _ llms.Model = (*FakeModel)(nil)
+
+// NewFakeModel returns a FakeModel returning responses in order.
+func NewFakeModel(responses ...Response) *FakeModel {
+	return &FakeModel{responses: responses}
+}
+
+// Cycle makes the model return its responses again once it returned them
+// all, rather than ErrNoResponse, such as to benchmark a graph, see
+// Harness.Benchmark. A cycling model does not record its requests. It
+// returns m.
+func (m *FakeModel) Cycle() *FakeModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cycle = true
+	return m
+}
+
+// GenerateContent implements llms.Model, returning the next response.
+func (m *FakeModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	resp, n, err := m.next(messages)
+	if err != nil {
+		return nil, err
+	}
+	return respond(ctx, resp, n, opts.StreamingFunc)
+}
+
+// next returns the next response, to the nth call of the model.
+func (m *FakeModel) next(messages []llms.MessageContent) (Response, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.cycle {
+		if len(m.responses) == 0 {
+			return Response{}, 0, ErrNoResponse
+		}
+		resp := m.responses[(m.calls-1)%len(m.responses)]
+		return resp, m.calls, resp.Err
+	}
+	m.requests = append(m.requests, messages)
+	if len(m.responses) == 0 {
+		return Response{}, 0, ErrNoResponse
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp, m.calls, resp.Err
+}
+
+// respond streams resp, the response to the nth call, to stream, if not
+// nil, and returns it.
+func respond(ctx context.Context, resp Response, n int, stream func(ctx context.Context, chunk []byte) error) (*llms.ContentResponse, error) {
+	if stream != nil {
+		for _, token := range strings.SplitAfter(resp.Text, " ") {
+			if token == "" {
+				continue
+			}
+			if err := stream(ctx, []byte(token)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	calls := make([]llms.ToolCall, len(resp.ToolCalls))
+	for i, call := range resp.ToolCalls {
+		if call.ID == "" {
+			call.ID = fmt.Sprintf("call-%d-%d", n, i+1)
+		}
+		calls[i] = call
+	}
+	choice := &llms.ContentChoice{Content: resp.Text, StopReason: "stop", ToolCalls: calls}
+	if len(calls) > 0 {
+		choice.StopReason = "tool_calls"
+		choice.FuncCall = calls[0].FunctionCall
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}, nil
+}
+
+// Call implements llms.Model.
+func (m *FakeModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Requests returns the messages of the calls of the model, in order.
+func (m *FakeModel) Requests() [][]llms.MessageContent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([][]llms.MessageContent(nil), m.requests...)
+}
+
+// Node returns a node asking the model to reply to the messages of the
+// state and adding its reply, with its tool calls, as an AI message. The
+// reply is streamed as message deltas, see graph.StreamDeltas. Replies are
+// numbered "fake-1", "fake-2" and so on, in the order of the calls of the
+// model.
+func (m *FakeModel) Node() func(ctx context.Context, state *graph.MessageState) error {
+	return func(ctx context.Context, state *graph.MessageState) error {
+		r, n, err := m.next(state.Contents())
+		if err != nil {
+			return err
+		}
+		id := fmt.Sprintf("fake-%d", n)
+		resp, err := respond(ctx, r, n, graph.StreamDeltas(id, graph.EmitMessageDelta))
+		if err != nil {
+			return err
+		}
+		choice := resp.Choices[0]
+		var parts []llms.ContentPart
+		if choice.Content != "" {
+			parts = append(parts, llms.TextContent{Text: choice.Content})
+		}
+		for _, call := range choice.ToolCalls {
+			parts = append(parts, call)
+		}
+		return state.AddMessages(graph.Message{MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: parts}, ID: id})
+	}
+}
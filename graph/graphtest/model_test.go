@@ -0,0 +1,69 @@
+package graphtest_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestFakeModel(t *testing.T) {
+	t.Parallel()
+
+	model := graphtest.NewFakeModel(
+		graphtest.Response{ToolCalls: []llms.ToolCall{graphtest.ToolCall("weather", map[string]string{"city": "Paris"})}},
+		graphtest.Response{Text: "It is sunny in Paris."},
+	)
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("agent", model.Node())
+	g.AddNode("tools", func(_ context.Context, s *graph.MessageState) error {
+		call := s.LastMessage().Parts[0].(llms.ToolCall)
+		s.AddMessage(llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+			llms.ToolCallResponse{ToolCallID: call.ID, Name: call.FunctionCall.Name, Content: "sunny"},
+		}})
+		return nil
+	})
+	g.AddConditionalEdges("agent", func(_ context.Context, s *graph.MessageState) ([]string, error) {
+		if _, ok := s.LastMessage().Parts[0].(llms.ToolCall); ok {
+			return []string{"tools"}, nil
+		}
+		return []string{graph.END}, nil
+	})
+	g.AddEdge("tools", "agent")
+	g.SetEntryPoint("agent")
+
+	h := graphtest.New(t, g, graph.WithAllowedCycle("agent", "tools"))
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "weather in Paris?"))
+	var deltas []string
+	stream := graph.WithMessageStream(func(_ context.Context, d graph.MessageDelta) error {
+		deltas = append(deltas, d.ID+":"+d.Text)
+		return nil
+	})
+	if err := h.Run(context.Background(), &state, stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.AssertPath("agent", "tools", "agent")
+
+	reply := state.LastMessage()
+	if reply.ID != "fake-2" || reply.Parts[0] != (llms.TextContent{Text: "It is sunny in Paris."}) {
+		t.Errorf("expected reply fake-2, but got %+v", reply)
+	}
+	if call := state.Messages[1].Parts[0].(llms.ToolCall); call.ID != "call-1-1" || call.FunctionCall.Arguments != `{"city":"Paris"}` {
+		t.Errorf("expected tool call call-1-1, but got %+v", call)
+	}
+	if want := []string{"fake-2:It ", "fake-2:is ", "fake-2:sunny ", "fake-2:in ", "fake-2:Paris."}; !slices.Equal(deltas, want) {
+		t.Errorf("expected deltas %q, but got %q", want, deltas)
+	}
+	if requests := model.Requests(); len(requests) != 2 || len(requests[1]) != 3 {
+		t.Errorf("expected the model to see 1 then 3 messages, but got %v", requests)
+	}
+
+	if _, err := model.Call(context.Background(), "again?"); !errors.Is(err, graphtest.ErrNoResponse) {
+		t.Errorf("expected error %v, but got %v", graphtest.ErrNoResponse, err)
+	}
+}
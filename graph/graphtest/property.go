@@ -0,0 +1,123 @@
+package graphtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sync"
+	"testing/quick"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// Invariant returns an error if state does not hold a property of the graph.
+type Invariant[T any] func(state *T) error
+
+// CheckConfig configures Harness.Check.
+type CheckConfig[T any] struct {
+	// Runs is the number of runs, 100 by default.
+	Runs int
+
+	// Seed is the seed of the first run, the following runs using the next
+	// seeds. By default it is random, and reported with the failures to
+	// reproduce them.
+	Seed int64
+
+	// Generate returns the input of a run from a source seeded by its seed.
+	// By default inputs are generated by testing/quick.
+	Generate func(r *rand.Rand) T
+
+	// StepLimit is the maximum number of nodes of a run, 100 by default, so
+	// that the graph must terminate within it, see graph.WithStepLimit.
+	StepLimit int
+
+	// Invariants must hold after every node of a run.
+	Invariants []Invariant[T]
+
+	// AllowError reports whether the error of a run is expected, such as the
+	// rejection of an invalid input. Errors fail the check by default, and
+	// graph.ErrStepLimit always does.
+	AllowError func(err error) bool
+}
+
+// Check runs the graph on random inputs, failing the test at the first run
+// that does not terminate within the step limit, returns an error, or breaks
+// an invariant after a node. The failure reports the seed and the input of
+// the run.
+func (h *Harness[T]) Check(ctx context.Context, cfg CheckConfig[T]) {
+	h.t.Helper()
+	if cfg.Runs <= 0 {
+		cfg.Runs = 100
+	}
+	if cfg.StepLimit <= 0 {
+		cfg.StepLimit = 100
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	generate := cfg.Generate
+	if generate == nil {
+		generate = func(r *rand.Rand) T {
+			v, ok := quick.Value(reflect.TypeFor[T](), r)
+			if !ok {
+				h.t.Fatalf("graphtest: check: cannot generate %s, see CheckConfig.Generate", reflect.TypeFor[T]())
+			}
+			return v.Interface().(T)
+		}
+	}
+	runnable, err := h.graph.Compile(append(slices.Clone(h.opts), graph.WithStepLimit(cfg.StepLimit))...)
+	if err != nil {
+		h.t.Fatalf("graphtest: compile: %v", err)
+	}
+
+	for i := range cfg.Runs {
+		seed := cfg.Seed + int64(i)
+		input := generate(rand.New(rand.NewSource(seed)))
+		state := input
+		checker := &invariantChecker[T]{invariants: cfg.Invariants}
+		err := runnable.Invoke(ctx, &state, graph.WithRunCallbacks[T](checker))
+		switch {
+		case checker.err != nil:
+			err = checker.err
+		case err == nil:
+			continue
+		case errors.Is(err, graph.ErrStepLimit):
+			err = fmt.Errorf("no termination within %d steps: %w", cfg.StepLimit, err)
+		case cfg.AllowError != nil && cfg.AllowError(err):
+			continue
+		}
+		h.t.Errorf("graphtest: check failed on run %d with seed %d: %v\ninput: %s", i+1, seed, err, format(input))
+		return
+	}
+}
+
+// invariantChecker checks invariants after every node, keeping the first
+// error.
+type invariantChecker[T any] struct {
+	invariants []Invariant[T]
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *invariantChecker[T]) NodeStart(context.Context, string, *T) {}
+
+func (c *invariantChecker[T]) NodeEnd(_ context.Context, node string, state *T, err error) {
+	if err != nil {
+		return
+	}
+	for _, invariant := range c.invariants {
+		if err := invariant(state); err != nil {
+			c.mu.Lock()
+			if c.err == nil {
+				c.err = fmt.Errorf("invariant broken after node %s: %w", node, err)
+			}
+			c.mu.Unlock()
+			return
+		}
+	}
+}
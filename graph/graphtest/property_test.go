@@ -0,0 +1,125 @@
+package graphtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+type countdown struct {
+	N      int
+	Target int
+}
+
+// newCountGraph builds a graph adding step to N until it reaches the target.
+func newCountGraph(step int) *graph.StateGraph[countdown] {
+	g := graph.NewStateGraph[countdown]()
+	g.AddNode("count", func(_ context.Context, s *countdown) error {
+		if s.Target < 0 {
+			return errors.New("negative target")
+		}
+		if s.N < s.Target {
+			s.N += step
+		}
+		return nil
+	})
+	g.AddConditionalEdges("count", func(_ context.Context, s *countdown) ([]string, error) {
+		if s.N == s.Target {
+			return []string{graph.END}, nil
+		}
+		return []string{"count"}, nil
+	})
+	g.SetEntryPoint("count")
+	return g
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	generate := func(r *rand.Rand) countdown {
+		return countdown{Target: r.Intn(20)}
+	}
+	notPast := func(s *countdown) error {
+		if s.N > s.Target {
+			return fmt.Errorf("counted past %d to %d", s.Target, s.N)
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name string
+		step int
+		cfg  graphtest.CheckConfig[countdown]
+		want string
+	}{
+		{name: "Holds", step: 1, cfg: graphtest.CheckConfig[countdown]{Generate: generate, Invariants: []graphtest.Invariant[countdown]{notPast}}},
+		{
+			name: "Broken invariant",
+			step: 2,
+			cfg:  graphtest.CheckConfig[countdown]{Generate: generate, Invariants: []graphtest.Invariant[countdown]{notPast}},
+			want: "invariant broken after node count: counted past",
+		},
+		{
+			name: "No termination",
+			step: 2,
+			cfg:  graphtest.CheckConfig[countdown]{Generate: generate, StepLimit: 50},
+			want: "no termination within 50 steps",
+		},
+		{
+			name: "Error",
+			step: 1,
+			cfg:  graphtest.CheckConfig[countdown]{Generate: func(r *rand.Rand) countdown { return countdown{Target: -1 - r.Intn(5)} }},
+			want: "negative target",
+		},
+		{
+			name: "Allowed error",
+			step: 1,
+			cfg: graphtest.CheckConfig[countdown]{
+				Generate:   func(r *rand.Rand) countdown { return countdown{Target: r.Intn(20) - 10} },
+				AllowError: func(err error) bool { return strings.Contains(err.Error(), "negative target") },
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rt := &recordingT{TB: t}
+			graphtest.New(rt, newCountGraph(tt.step), graph.WithAllowedCycle("count")).Check(context.Background(), tt.cfg)
+			switch {
+			case tt.want == "" && len(rt.errors) > 0:
+				t.Errorf("expected no failure, but got %q", rt.errors)
+			case tt.want != "" && (len(rt.errors) != 1 || !strings.Contains(rt.errors[0], tt.want) || !strings.Contains(rt.errors[0], "with seed")):
+				t.Errorf("expected a failure with its seed and %q, but got %q", tt.want, rt.errors)
+			}
+		})
+	}
+}
+
+func TestCheckQuick(t *testing.T) {
+	t.Parallel()
+
+	// Inputs are generated by testing/quick, the node making them valid.
+	g := graph.NewStateGraph[countdown]()
+	g.AddNode("clamp", func(_ context.Context, s *countdown) error {
+		s.N, s.Target = 0, s.Target&0xf
+		return nil
+	})
+	g.AddEdge("clamp", graph.END)
+	g.SetEntryPoint("clamp")
+	graphtest.New(t, g).Check(context.Background(), graphtest.CheckConfig[countdown]{
+		Runs: 20,
+		Seed: 42,
+		Invariants: []graphtest.Invariant[countdown]{func(s *countdown) error {
+			if s.Target < 0 || s.Target > 15 {
+				return fmt.Errorf("target %d out of range", s.Target)
+			}
+			return nil
+		}},
+	})
+}
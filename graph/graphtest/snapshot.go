@@ -0,0 +1,120 @@
+package graphtest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Snapshot is the expected state after a step of a run, see AssertSnapshots.
+type Snapshot struct {
+	// Node is the node of the step, any node if empty.
+	Node string
+
+	// State is the expected state, compared to the state of the step as
+	// JSON.
+	State any
+
+	// Ignore are the fields left out of the comparison, as paths of JSON
+	// keys and array indexes separated by dots, "*" matching any key or
+	// index, such as "messages.*.id".
+	Ignore []string
+}
+
+// AssertSnapshots checks that run, such as the Recording of the last run of
+// a Harness, has a step for every snapshot in want, in order, whose node and
+// state match it. States are compared as JSON without their ignored fields,
+// differences being reported as diffs of the step.
+func AssertSnapshots(t testing.TB, run Recording, want ...Snapshot) {
+	t.Helper()
+	if len(run.Steps) != len(want) {
+		got := make([]string, len(run.Steps))
+		for i, s := range run.Steps {
+			got[i] = s.Node
+		}
+		nodes := make([]string, len(want))
+		for i, s := range want {
+			nodes[i] = s.Node
+		}
+		t.Errorf("graphtest: expected %d steps, but got %d (-want +got):\n%s", len(want), len(run.Steps), diffLines(nodes, got))
+		return
+	}
+	for i, snapshot := range want {
+		step := run.Steps[i]
+		if snapshot.Node != "" && snapshot.Node != step.Node {
+			t.Errorf("graphtest: expected step %d to be node %s, but got %s", i+1, snapshot.Node, step.Node)
+			continue
+		}
+		wantData, err := json.Marshal(snapshot.State)
+		if err != nil {
+			t.Fatalf("graphtest: snapshot %d: %v", i+1, err)
+		}
+		var w, g any
+		if err := json.Unmarshal(wantData, &w); err != nil {
+			t.Fatalf("graphtest: snapshot %d: %v", i+1, err)
+		}
+		if err := json.Unmarshal(step.State, &g); err != nil {
+			t.Fatalf("graphtest: state of step %d: %v", i+1, err)
+		}
+		for _, path := range snapshot.Ignore {
+			fields := strings.Split(path, ".")
+			w, g = without(w, fields), without(g, fields)
+		}
+		if reflect.DeepEqual(w, g) {
+			continue
+		}
+		t.Errorf("graphtest: unexpected state after step %d, node %s (-want +got):\n%s",
+			i+1, step.Node, diffLines(strings.Split(format(w), "\n"), strings.Split(format(g), "\n")))
+	}
+}
+
+// without returns v, a decoded JSON value, without the field at path.
+func without(v any, path []string) any {
+	if len(path) == 0 {
+		return v
+	}
+	key, rest := path[0], path[1:]
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if key != "*" && key != k {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(v, k)
+			} else {
+				v[k] = without(field, rest)
+			}
+		}
+	case []any:
+		for i, elem := range v {
+			if key != "*" && key != strconv.Itoa(i) {
+				continue
+			}
+			if len(rest) == 0 {
+				// Array elements are blanked rather than removed, to keep
+				// the indexes of the others.
+				v[i] = nil
+			} else {
+				v[i] = without(elem, rest)
+			}
+		}
+	}
+	return v
+}
+
+// Recording returns the recording of the last run of the harness, such as
+// to check its steps with AssertSnapshots.
+func (h *Harness[T]) Recording() Recording {
+	h.mu.Lock()
+	rec := h.steps
+	h.mu.Unlock()
+	if rec == nil {
+		return Recording{}
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return Recording{Steps: append([]Step(nil), rec.steps...)}
+}
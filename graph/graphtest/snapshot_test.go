@@ -0,0 +1,53 @@
+package graphtest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+func TestAssertSnapshots(t *testing.T) {
+	t.Parallel()
+
+	h := graphtest.New(t, newAnswerGraph(), graph.WithAllowedCycle("search"))
+	searches := 0
+	h.Stub("search", func(s *answer) {
+		searches++
+		s.Sources = append(s.Sources, fmt.Sprintf("source-%d", searches))
+	})
+	if err := h.Run(context.Background(), &answer{Question: "why?"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	graphtest.AssertSnapshots(t, h.Recording(),
+		graphtest.Snapshot{Node: "search", State: answer{Question: "why?", Sources: []string{"source-1"}}},
+		graphtest.Snapshot{Node: "search", State: answer{Question: "why?", Sources: []string{"source-1", "source-2"}}},
+		graphtest.Snapshot{State: answer{Question: "why?", Answer: "2 sources"}, Ignore: []string{"sources"}},
+	)
+
+	rt := &recordingT{TB: t}
+	graphtest.AssertSnapshots(rt, h.Recording(),
+		graphtest.Snapshot{Node: "search", State: answer{Question: "why?", Sources: []string{"wiki"}}},
+		graphtest.Snapshot{Node: "respond"},
+		graphtest.Snapshot{State: answer{Question: "why?", Sources: []string{"", ""}, Answer: "2 sources"}, Ignore: []string{"sources.*"}},
+	)
+	graphtest.AssertSnapshots(rt, h.Recording(), graphtest.Snapshot{Node: "search"})
+
+	want := []string{
+		"graphtest: unexpected state after step 1, node search (-want +got):\n" +
+			"  {\n    \"answer\": \"\",\n    \"question\": \"why?\",\n    \"sources\": [\n-     \"wiki\"\n+     \"source-1\"\n    ]\n  }\n",
+		"graphtest: expected step 2 to be node respond, but got search",
+		"graphtest: expected 1 steps, but got 3 (-want +got):\n  search\n+ search\n+ respond\n",
+	}
+	if len(rt.errors) != len(want) {
+		t.Fatalf("expected %d failures, but got %q", len(want), rt.errors)
+	}
+	for i := range want {
+		if rt.errors[i] != want[i] {
+			t.Errorf("expected failure\n%s\nbut got\n%s", want[i], rt.errors[i])
+		}
+	}
+}
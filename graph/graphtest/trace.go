@@ -0,0 +1,241 @@
+package graphtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// TraceDiff is the difference between two recorded runs of a graph, such as
+// before and after a change of a prompt or of the topology, see
+// DiffRecordings. It encodes to JSON, to be kept or reviewed as an artifact.
+type TraceDiff struct {
+	// Steps are the nodes of both runs aligned: nodes run by both, only
+	// before or only after.
+	Steps []StepChange `json:"steps"`
+
+	// Routes are the routing decisions that changed: the node following a
+	// node run by both.
+	Routes []RouteChange `json:"routes,omitempty"`
+
+	// States are the changes of the states nodes run by both returned.
+	States []StateChange `json:"states,omitempty"`
+}
+
+// StepOp tells whether a node of a TraceDiff ran in both runs or only one.
+type StepOp string
+
+const (
+	StepKept    StepOp = "="
+	StepRemoved StepOp = "-"
+	StepAdded   StepOp = "+"
+)
+
+// StepChange is a node of a TraceDiff.
+type StepChange struct {
+	Op   StepOp `json:"op"`
+	Node string `json:"node"`
+}
+
+// RouteChange is a routing decision of a TraceDiff: the node that followed
+// the Call-th run of Node, counted from 1, graph.END if none did.
+type RouteChange struct {
+	Node   string `json:"node"`
+	Call   int    `json:"call"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// StateChange is the change of the state the Call-th run of Node returned,
+// counted from 1, in a TraceDiff.
+type StateChange struct {
+	Node   string        `json:"node"`
+	Call   int           `json:"call"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// FieldChange is a changed field of a state, at Path, the JSON keys and
+// array indexes of the field separated by dots, as in Snapshot.Ignore.
+// Before or After is missing if the field is new or gone.
+type FieldChange struct {
+	Path   string          `json:"path"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// DiffRecordings returns the difference between the runs before and after,
+// such as two golden files of Harness.Golden: their nodes, aligned along
+// their longest common sequence, the routing decisions and the states of the
+// nodes run by both that changed.
+func DiffRecordings(before, after Recording) TraceDiff {
+	names := func(r Recording) []string {
+		nodes := make([]string, len(r.Steps))
+		for i, s := range r.Steps {
+			nodes[i] = s.Node
+		}
+		return nodes
+	}
+	next := func(r Recording, i int) string {
+		if i+1 < len(r.Steps) {
+			return r.Steps[i+1].Node
+		}
+		return graph.END
+	}
+
+	var d TraceDiff
+	calls := map[string]int{}
+	for _, p := range align(names(before), names(after)) {
+		switch {
+		case p.got < 0:
+			d.Steps = append(d.Steps, StepChange{Op: StepRemoved, Node: before.Steps[p.want].Node})
+			continue
+		case p.want < 0:
+			d.Steps = append(d.Steps, StepChange{Op: StepAdded, Node: after.Steps[p.got].Node})
+			continue
+		}
+		b, a := before.Steps[p.want], after.Steps[p.got]
+		d.Steps = append(d.Steps, StepChange{Op: StepKept, Node: b.Node})
+		calls[b.Node]++
+		if nb, na := next(before, p.want), next(after, p.got); nb != na {
+			d.Routes = append(d.Routes, RouteChange{Node: b.Node, Call: calls[b.Node], Before: nb, After: na})
+		}
+		if fields := diffJSON(b.State, a.State); len(fields) > 0 {
+			d.States = append(d.States, StateChange{Node: b.Node, Call: calls[b.Node], Fields: fields})
+		}
+	}
+	return d
+}
+
+// DiffRecordingFiles returns the difference between the runs recorded in
+// the files before and after, see DiffRecordings.
+func DiffRecordingFiles(before, after string) (TraceDiff, error) {
+	var recordings [2]Recording
+	for i, path := range []string{before, after} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return TraceDiff{}, err
+		}
+		if err := json.Unmarshal(data, &recordings[i]); err != nil {
+			return TraceDiff{}, fmt.Errorf("graphtest: recording %s: %w", path, err)
+		}
+	}
+	return DiffRecordings(recordings[0], recordings[1]), nil
+}
+
+// Changed reports whether the runs differ.
+func (d TraceDiff) Changed() bool {
+	return len(d.Routes) > 0 || len(d.States) > 0 || slices.ContainsFunc(d.Steps, func(s StepChange) bool { return s.Op != StepKept })
+}
+
+// String formats the difference for review:
+//
+//	  plan
+//	- search
+//	+ lookup
+//	  respond
+//	route after plan (call 1): search -> lookup
+//	state of respond (call 1):
+//	  answer: "2 sources" -> "1 source"
+func (d TraceDiff) String() string {
+	var b strings.Builder
+	for _, s := range d.Steps {
+		op := string(s.Op)
+		if s.Op == StepKept {
+			op = " "
+		}
+		fmt.Fprintf(&b, "%s %s\n", op, s.Node)
+	}
+	for _, r := range d.Routes {
+		fmt.Fprintf(&b, "route after %s (call %d): %s -> %s\n", r.Node, r.Call, r.Before, r.After)
+	}
+	for _, s := range d.States {
+		fmt.Fprintf(&b, "state of %s (call %d):\n", s.Node, s.Call)
+		for _, f := range s.Fields {
+			fmt.Fprintf(&b, "  %s: %s -> %s\n", f.Path, orNone(f.Before), orNone(f.After))
+		}
+	}
+	return b.String()
+}
+
+// orNone formats a missing JSON value as "(none)".
+func orNone(v json.RawMessage) string {
+	if v == nil {
+		return "(none)"
+	}
+	return string(v)
+}
+
+// diffJSON returns the changed fields between the JSON values before and
+// after.
+func diffJSON(before, after json.RawMessage) []FieldChange {
+	var b, a any
+	if json.Unmarshal(before, &b) != nil || json.Unmarshal(after, &a) != nil {
+		if string(before) == string(after) {
+			return nil
+		}
+		return []FieldChange{{Path: "", Before: before, After: after}}
+	}
+	var changes []FieldChange
+	diffValues(&changes, nil, b, a, true, true)
+	return changes
+}
+
+// diffValues appends the changes between the decoded JSON values before and
+// after at path to changes, hasBefore and hasAfter telling whether they
+// exist.
+func diffValues(changes *[]FieldChange, path []string, before, after any, hasBefore, hasAfter bool) {
+	if hasBefore && hasAfter {
+		switch b := before.(type) {
+		case map[string]any:
+			if a, ok := after.(map[string]any); ok {
+				keys := make([]string, 0, len(b)+len(a))
+				for k := range b {
+					keys = append(keys, k)
+				}
+				for k := range a {
+					if _, ok := b[k]; !ok {
+						keys = append(keys, k)
+					}
+				}
+				slices.Sort(keys)
+				for _, k := range keys {
+					bv, bok := b[k]
+					av, aok := a[k]
+					diffValues(changes, append(path, k), bv, av, bok, aok)
+				}
+				return
+			}
+		case []any:
+			if a, ok := after.([]any); ok {
+				for i := range max(len(b), len(a)) {
+					var bv, av any
+					if i < len(b) {
+						bv = b[i]
+					}
+					if i < len(a) {
+						av = a[i]
+					}
+					diffValues(changes, append(path, strconv.Itoa(i)), bv, av, i < len(b), i < len(a))
+				}
+				return
+			}
+		}
+		if reflect.DeepEqual(before, after) {
+			return
+		}
+	}
+	change := FieldChange{Path: strings.Join(path, ".")}
+	if hasBefore {
+		change.Before, _ = json.Marshal(before)
+	}
+	if hasAfter {
+		change.After, _ = json.Marshal(after)
+	}
+	*changes = append(*changes, change)
+}
@@ -0,0 +1,47 @@
+package graphtest_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/graphtest"
+)
+
+func TestDiffRecordings(t *testing.T) {
+	t.Parallel()
+
+	recording := func(steps ...string) graphtest.Recording {
+		var r graphtest.Recording
+		for i := 0; i < len(steps); i += 2 {
+			r.Steps = append(r.Steps, graphtest.Step{Node: steps[i], State: json.RawMessage(steps[i+1])})
+		}
+		return r
+	}
+	before := recording(
+		"plan", `{"question":"why?"}`,
+		"search", `{"question":"why?","sources":["wiki","news"]}`,
+		"respond", `{"question":"why?","sources":["wiki","news"],"answer":"2 sources"}`,
+	)
+	after := recording(
+		"plan", `{"question":"why?"}`,
+		"lookup", `{"question":"why?","sources":["wiki"]}`,
+		"respond", `{"question":"why?","sources":["wiki"],"answer":"1 source"}`,
+	)
+
+	d := graphtest.DiffRecordings(before, after)
+	if !d.Changed() {
+		t.Fatal("expected the runs to differ")
+	}
+	want := "  plan\n- search\n+ lookup\n  respond\n" +
+		"route after plan (call 1): search -> lookup\n" +
+		"state of respond (call 1):\n" +
+		"  answer: \"2 sources\" -> \"1 source\"\n" +
+		"  sources.1: \"news\" -> (none)\n"
+	if got := d.String(); got != want {
+		t.Errorf("expected diff\n%s\nbut got\n%s", want, got)
+	}
+
+	if d := graphtest.DiffRecordings(before, before); d.Changed() {
+		t.Errorf("expected no difference, but got\n%s", d)
+	}
+}
@@ -0,0 +1,413 @@
+package protoschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// scalars are the types of the scalar fields by name.
+var scalars = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"double":   descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"float":    descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"int64":    descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"uint64":   descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"int32":    descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"fixed64":  descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+	"fixed32":  descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+	"bool":     descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"string":   descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bytes":    descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+	"uint32":   descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"sfixed32": descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+	"sfixed64": descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+	"sint32":   descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+	"sint64":   descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+}
+
+// Parse returns the descriptor of the .proto file src, at path name, as
+// protoc builds it without source info. It supports the subset of the
+// language the files of this module use: proto3 files with messages of
+// scalar, message and enum fields, repeated, optional or in oneofs, top-level
+// enums, services, imports and the go_package option.
+func Parse(name string, src string) (*descriptorpb.FileDescriptorProto, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	p := &parser{toks: toks, file: &descriptorpb.FileDescriptorProto{Name: proto.String(name)}, enums: map[string]bool{}}
+	if err := p.parseFile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return p.file, nil
+}
+
+// Compare returns an error showing both descriptors if fd is not the
+// descriptor of the .proto file src. The dependencies of src are resolved
+// from protoregistry.GlobalFiles.
+func Compare(fd protoreflect.FileDescriptor, src string) error {
+	parsed, err := Parse(fd.Path(), src)
+	if err != nil {
+		return err
+	}
+	// Building the parsed descriptor fills in what protoc would, such as
+	// the JSON names of the fields.
+	want, err := protodesc.NewFile(parsed, protoregistry.GlobalFiles)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fd.Path(), err)
+	}
+	got, wantProto := protodesc.ToFileDescriptorProto(fd), protodesc.ToFileDescriptorProto(want)
+	if !proto.Equal(got, wantProto) {
+		opts := prototext.MarshalOptions{Multiline: true}
+		return fmt.Errorf("%s: descriptor\n%s\ndoes not match the file\n%s", fd.Path(), opts.Format(got), opts.Format(wantProto))
+	}
+	return nil
+}
+
+// tokenize splits src into identifiers, numbers, string literals with their
+// quotes and punctuation, dropping comments.
+func tokenize(src string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			toks = append(toks, src[i:i+end+2])
+			i += end + 2
+		case isWord(c):
+			j := i
+			for j < len(src) && isWord(src[j]) {
+				j++
+			}
+			toks = append(toks, src[i:j])
+			i = j
+		default:
+			toks = append(toks, src[i:i+1])
+			i++
+		}
+	}
+	return toks, nil
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type parser struct {
+	toks []string
+	file *descriptorpb.FileDescriptorProto
+
+	// enums are the names of the enums of the file, and types the fields
+	// whose types are resolved once the file is parsed, with their types.
+	enums map[string]bool
+	types []fieldType
+}
+
+type fieldType struct {
+	field *descriptorpb.FieldDescriptorProto
+	name  string
+}
+
+// next returns the next token, or "" at the end of the file.
+func (p *parser) next() string {
+	if len(p.toks) == 0 {
+		return ""
+	}
+	tok := p.toks[0]
+	p.toks = p.toks[1:]
+	return tok
+}
+
+// peek returns the next token without consuming it.
+func (p *parser) peek() string {
+	if len(p.toks) == 0 {
+		return ""
+	}
+	return p.toks[0]
+}
+
+// expect consumes the next token, which must be want.
+func (p *parser) expect(want string) error {
+	if tok := p.next(); tok != want {
+		return fmt.Errorf("expected %q, got %q", want, tok)
+	}
+	return nil
+}
+
+// str consumes a string literal and returns its value.
+func (p *parser) str() (string, error) {
+	tok := p.next()
+	if len(tok) < 2 || tok[0] != '"' {
+		return "", fmt.Errorf("expected a string, got %q", tok)
+	}
+	return tok[1 : len(tok)-1], nil
+}
+
+// number consumes a field or enum value number.
+func (p *parser) number() (int32, error) {
+	tok := p.next()
+	n, err := strconv.ParseInt(tok, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, got %q", tok)
+	}
+	return int32(n), nil
+}
+
+func (p *parser) parseFile() error {
+	for len(p.toks) > 0 {
+		var err error
+		switch tok := p.next(); tok {
+		case "syntax":
+			err = p.parseSyntax()
+		case "package":
+			p.file.Package = proto.String(p.next())
+			err = p.expect(";")
+		case "import":
+			var path string
+			if path, err = p.str(); err == nil {
+				p.file.Dependency = append(p.file.Dependency, path)
+				err = p.expect(";")
+			}
+		case "option":
+			err = p.parseOption()
+		case "message":
+			var m *descriptorpb.DescriptorProto
+			if m, err = p.parseMessage(); err == nil {
+				p.file.MessageType = append(p.file.MessageType, m)
+			}
+		case "enum":
+			err = p.parseEnum()
+		case "service":
+			err = p.parseService()
+		default:
+			err = fmt.Errorf("unexpected %q", tok)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range p.types {
+		switch typ, ok := scalars[t.name]; {
+		case ok:
+			t.field.Type = typ.Enum()
+		case strings.Contains(t.name, "."):
+			t.field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			t.field.TypeName = proto.String("." + t.name)
+		case p.enums[t.name]:
+			t.field.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+			t.field.TypeName = proto.String("." + p.file.GetPackage() + "." + t.name)
+		default:
+			t.field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			t.field.TypeName = proto.String("." + p.file.GetPackage() + "." + t.name)
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseSyntax() error {
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	syntax, err := p.str()
+	if err != nil {
+		return err
+	}
+	if syntax != "proto3" {
+		return fmt.Errorf("unsupported syntax %q", syntax)
+	}
+	p.file.Syntax = proto.String(syntax)
+	return p.expect(";")
+}
+
+func (p *parser) parseOption() error {
+	if name := p.next(); name != "go_package" {
+		return fmt.Errorf("unsupported option %q", name)
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	value, err := p.str()
+	if err != nil {
+		return err
+	}
+	p.file.Options = &descriptorpb.FileOptions{GoPackage: proto.String(value)}
+	return p.expect(";")
+}
+
+func (p *parser) parseMessage() (*descriptorpb.DescriptorProto, error) {
+	m := &descriptorpb.DescriptorProto{Name: proto.String(p.next())}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	// optional are the proto3 optional fields, whose synthetic oneofs are
+	// declared after the others.
+	var optional []*descriptorpb.FieldDescriptorProto
+	for p.peek() != "}" {
+		if p.peek() != "oneof" {
+			f, err := p.parseField()
+			if err != nil {
+				return nil, err
+			}
+			if f.GetProto3Optional() {
+				optional = append(optional, f)
+			}
+			m.Field = append(m.Field, f)
+			continue
+		}
+		p.next()
+		index := int32(len(m.OneofDecl))
+		m.OneofDecl = append(m.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String(p.next())})
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		for p.peek() != "}" {
+			f, err := p.parseField()
+			if err != nil {
+				return nil, err
+			}
+			m.Field = append(m.Field, Oneof(index, f))
+		}
+		p.next()
+	}
+	p.next()
+	for _, f := range optional {
+		Oneof(int32(len(m.OneofDecl)), f)
+		m.OneofDecl = append(m.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + f.GetName())})
+	}
+	return m, nil
+}
+
+func (p *parser) parseField() (*descriptorpb.FieldDescriptorProto, error) {
+	f := &descriptorpb.FieldDescriptorProto{Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()}
+	switch p.peek() {
+	case "repeated":
+		p.next()
+		Repeated(f)
+	case "optional":
+		p.next()
+		f.Proto3Optional = proto.Bool(true)
+	}
+	typ := p.next()
+	f.Name = proto.String(p.next())
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	number, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	f.Number = proto.Int32(number)
+	p.types = append(p.types, fieldType{field: f, name: typ})
+	return f, p.expect(";")
+}
+
+func (p *parser) parseEnum() error {
+	e := &descriptorpb.EnumDescriptorProto{Name: proto.String(p.next())}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		v := &descriptorpb.EnumValueDescriptorProto{Name: proto.String(p.next())}
+		if err := p.expect("="); err != nil {
+			return err
+		}
+		number, err := p.number()
+		if err != nil {
+			return err
+		}
+		v.Number = proto.Int32(number)
+		e.Value = append(e.Value, v)
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+	}
+	p.next()
+	p.enums[e.GetName()] = true
+	p.file.EnumType = append(p.file.EnumType, e)
+	return nil
+}
+
+func (p *parser) parseService() error {
+	s := &descriptorpb.ServiceDescriptorProto{Name: proto.String(p.next())}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		if err := p.expect("rpc"); err != nil {
+			return err
+		}
+		name := p.next()
+		input, clientStreaming, err := p.parseMethodType()
+		if err != nil {
+			return err
+		}
+		if err := p.expect("returns"); err != nil {
+			return err
+		}
+		output, serverStreaming, err := p.parseMethodType()
+		if err != nil {
+			return err
+		}
+		s.Method = append(s.Method, Method(name, p.qualify(input), p.qualify(output), clientStreaming, serverStreaming))
+		if p.peek() == "{" {
+			p.next()
+			err = p.expect("}")
+		} else {
+			err = p.expect(";")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.next()
+	p.file.Service = append(p.file.Service, s)
+	return nil
+}
+
+// parseMethodType parses the parenthesized input or output type of a
+// method, reporting whether it is streamed.
+func (p *parser) parseMethodType() (string, bool, error) {
+	if err := p.expect("("); err != nil {
+		return "", false, err
+	}
+	stream := p.peek() == "stream"
+	if stream {
+		p.next()
+	}
+	typ := p.next()
+	return typ, stream, p.expect(")")
+}
+
+// qualify returns the fully qualified name of a message type.
+func (p *parser) qualify(name string) string {
+	if strings.Contains(name, ".") {
+		return "." + name
+	}
+	return "." + p.file.GetPackage() + "." + name
+}
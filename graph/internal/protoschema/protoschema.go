@@ -0,0 +1,127 @@
+// Package protoschema builds the descriptors of the .proto files of the
+// packages serving graphs over protocol buffers with dynamic messages, so
+// that they need no generated code, and parses those files for their tests
+// to check the descriptors against them.
+package protoschema
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Field returns the descriptor of a singular field.
+func Field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+}
+
+// String returns the descriptor of a string field.
+func String(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return Field(name, number, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+}
+
+// Bytes returns the descriptor of a bytes field.
+func Bytes(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return Field(name, number, descriptorpb.FieldDescriptorProto_TYPE_BYTES)
+}
+
+// Bool returns the descriptor of a bool field.
+func Bool(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return Field(name, number, descriptorpb.FieldDescriptorProto_TYPE_BOOL)
+}
+
+// Int64 returns the descriptor of an int64 field.
+func Int64(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return Field(name, number, descriptorpb.FieldDescriptorProto_TYPE_INT64)
+}
+
+// Message returns the descriptor of a field of the message type with the
+// given fully qualified name, such as ".google.protobuf.Timestamp".
+func Message(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := Field(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+	f.TypeName = proto.String(typeName)
+	return f
+}
+
+// Enum returns the descriptor of a field of the enum type with the given
+// fully qualified name.
+func Enum(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := Field(name, number, descriptorpb.FieldDescriptorProto_TYPE_ENUM)
+	f.TypeName = proto.String(typeName)
+	return f
+}
+
+// Repeated makes f a repeated field.
+func Repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+// Oneof makes f a member of the oneof of the given index in its message.
+func Oneof(index int32, f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.OneofIndex = proto.Int32(index)
+	return f
+}
+
+// Optional makes f a proto3 optional field, member of the synthetic oneof of
+// the given index in its message, see Oneofs.
+func Optional(index int32, f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Proto3Optional = proto.Bool(true)
+	return Oneof(index, f)
+}
+
+// Msg returns the descriptor of a message with fields.
+func Msg(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+// Oneofs declares the oneofs of m, in order. The synthetic oneof of a proto3
+// optional field is named after the field, prefixed with an underscore.
+func Oneofs(m *descriptorpb.DescriptorProto, names ...string) *descriptorpb.DescriptorProto {
+	for _, name := range names {
+		m.OneofDecl = append(m.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String(name)})
+	}
+	return m
+}
+
+// Method returns the descriptor of a method whose input and output types
+// are fully qualified names.
+func Method(name, input, output string, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+	m := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(input),
+		OutputType: proto.String(output),
+	}
+	if clientStreaming {
+		m.ClientStreaming = proto.Bool(true)
+	}
+	if serverStreaming {
+		m.ServerStreaming = proto.Bool(true)
+	}
+	return m
+}
+
+// Get returns the value of the named field of m.
+func Get(m protoreflect.Message, name string) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+// Has reports whether the named field of m is set.
+func Has(m protoreflect.Message, name string) bool {
+	return m.Has(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+// Set sets the named field of m.
+func Set(m protoreflect.Message, name string, v protoreflect.Value) {
+	m.Set(m.Descriptor().Fields().ByName(protoreflect.Name(name)), v)
+}
+
+// Mutable returns the named composite field of m, allocating it if unset.
+func Mutable(m protoreflect.Message, name string) protoreflect.Value {
+	return m.Mutable(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
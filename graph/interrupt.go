@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrThreadRequired is returned when an operation that needs a thread is
+	// invoked without one.
+	ErrThreadRequired = errors.New("thread ID is required")
+
+	// ErrNotInterrupted is returned when resuming a thread that is not interrupted.
+	ErrNotInterrupted = errors.New("thread is not interrupted")
+
+	// ErrPaused is returned by Invoke when the run paused with nodes left to
+	// run, see WithMaxSteps and WithPause.
+	ErrPaused = errors.New("run paused")
+)
+
+// GraphInterrupt is returned by Invoke when a node paused the run by calling
+// Interrupt. The run can be resumed with WithResume on the same thread.
+type GraphInterrupt struct {
+	// Node is the name of the node that interrupted the run.
+	Node string
+
+	// Value is the value the node passed to Interrupt, typically a question
+	// for the user.
+	Value any
+
+	// Resumes are the values the node was already resumed with, in the order
+	// of its Interrupt calls. They are replayed when the node runs again.
+	Resumes []any
+
+	// Before is set when the run was interrupted before Node ran, see
+	// WithInterruptBefore.
+	Before bool
+
+	// After is set when the run was interrupted after Node ran, see
+	// WithInterruptAfter.
+	After bool
+}
+
+// Error implements error.
+func (e *GraphInterrupt) Error() string {
+	switch {
+	case e.Before:
+		return fmt.Sprintf("graph interrupted before node %s", e.Node)
+	case e.After:
+		return fmt.Sprintf("graph interrupted after node %s", e.Node)
+	}
+	return fmt.Sprintf("graph interrupted in node %s: %v", e.Node, e.Value)
+}
+
+// resumeValues are the values a node run was resumed with; next is the index
+// of the value the next Interrupt call returns.
+type resumeValues struct {
+	values []any
+	next   int
+}
+
+// Interrupt pauses the run of the graph from within a node, surfacing value
+// to the caller of Invoke as a *GraphInterrupt.
+//
+// When the run is resumed with WithResume, the interrupted node runs again
+// from the start and this call returns the resume value instead. A node may
+// call Interrupt several times; each call is answered by its own resume, in
+// order. Since the node runs again, any work done before the call is repeated.
+//
+// The returned error must be returned by the node.
+func Interrupt(ctx context.Context, value any) (any, error) {
+	if node, ok := ctx.Value(currentNodeKey{}).(*runningNode); ok && node.resume != nil && node.resume.next < len(node.resume.values) {
+		rv := node.resume
+		v := rv.values[rv.next]
+		rv.next++
+		return v, nil
+	}
+	return nil, &GraphInterrupt{Value: value}
+}
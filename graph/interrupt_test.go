@@ -0,0 +1,276 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// newFormGraph builds a graph whose "ask" node asks for a name and an age
+// before "done" runs.
+func newFormGraph(t *testing.T, cp graph.Checkpointer[graph.MessageState]) *graph.Runnable[graph.MessageState] {
+	t.Helper()
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("start", func(_ context.Context, state *graph.MessageState) error {
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "start"))
+		return nil
+	})
+	g.AddNode("ask", func(ctx context.Context, state *graph.MessageState) error {
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "asking"))
+		for _, question := range []string{"name?", "age?"} {
+			answer, err := graph.Interrupt(ctx, question)
+			if err != nil {
+				return err
+			}
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprint(answer)))
+		}
+		return nil
+	})
+	g.AddNode("done", func(_ context.Context, state *graph.MessageState) error {
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "done"))
+		return nil
+	})
+	g.SetEntryPoint("start")
+	g.AddEdge("start", "ask")
+	g.AddEdge("ask", "done")
+	g.AddEdge("done", graph.END)
+
+	var opts []graph.CompileOption
+	if cp != nil {
+		opts = append(opts, graph.WithCheckpointer(cp))
+	}
+	runnable, err := g.Compile(opts...)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return runnable
+}
+
+func texts(state graph.MessageState) []string {
+	var texts []string
+	for _, msg := range state.Messages {
+		texts = append(texts, fmt.Sprint(msg.Parts[0]))
+	}
+	return texts
+}
+
+func TestInterrupt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	runnable := newFormGraph(t, graph.NewMemorySaver[graph.MessageState]())
+	thread := graph.WithThreadID("thread")
+
+	state := graph.NewMessageState()
+	steps := []struct {
+		opts     []graph.InvokeOption
+		wantAsk  string
+		wantMsgs []string
+	}{
+		{opts: nil, wantAsk: "name?", wantMsgs: []string{"start"}},
+		{opts: []graph.InvokeOption{graph.WithResume("Ada")}, wantAsk: "age?", wantMsgs: []string{"start"}},
+		{opts: []graph.InvokeOption{graph.WithResume(36)}, wantMsgs: []string{"start", "asking", "Ada", "36", "done"}},
+	}
+	for i, step := range steps {
+		err := runnable.Invoke(ctx, &state, append(step.opts, thread)...)
+		var gi *graph.GraphInterrupt
+		switch {
+		case step.wantAsk == "" && err != nil:
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		case step.wantAsk != "" && !errors.As(err, &gi):
+			t.Fatalf("step %d: expected an interrupt, but got %v", i, err)
+		case step.wantAsk != "" && (gi.Node != "ask" || gi.Value != step.wantAsk):
+			t.Errorf("step %d: expected node ask to ask %q, but got %+v", i, step.wantAsk, gi)
+		}
+		if got := texts(state); !slices.Equal(got, step.wantMsgs) {
+			t.Errorf("step %d: expected messages %q, but got %q", i, step.wantMsgs, got)
+		}
+	}
+
+	cp, err := runnable.GetState(ctx, "thread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.Interrupt != nil || cp.Node != "done" || cp.Step != 3 {
+		t.Errorf("unexpected final checkpoint: %+v", cp)
+	}
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithResume("again")); !errors.Is(err, graph.ErrNotInterrupted) {
+		t.Errorf("expected error %v, but got %v", graph.ErrNotInterrupted, err)
+	}
+}
+
+func TestInterruptErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		checkpointer bool
+		opts         []graph.InvokeOption
+		wantErr      error
+	}{
+		{name: "Resume without checkpointer", opts: []graph.InvokeOption{graph.WithThreadID("thread"), graph.WithResume(1)}, wantErr: graph.ErrNoCheckpointer},
+		{name: "Resume without thread", checkpointer: true, opts: []graph.InvokeOption{graph.WithResume(1)}, wantErr: graph.ErrThreadRequired},
+		{name: "Resume unknown thread", checkpointer: true, opts: []graph.InvokeOption{graph.WithThreadID("thread"), graph.WithResume(1)}, wantErr: graph.ErrCheckpointNotFound},
+		{name: "Continue unknown thread", checkpointer: true, opts: []graph.InvokeOption{graph.WithThreadID("thread"), graph.WithContinue()}, wantErr: graph.ErrCheckpointNotFound},
+		{name: "Max steps without checkpointer", opts: []graph.InvokeOption{graph.WithThreadID("thread"), graph.WithMaxSteps(1)}, wantErr: graph.ErrNoCheckpointer},
+		{name: "Max steps without thread", checkpointer: true, opts: []graph.InvokeOption{graph.WithMaxSteps(1)}, wantErr: graph.ErrThreadRequired},
+		{name: "Pause without checkpointer", opts: []graph.InvokeOption{graph.WithThreadID("thread"), graph.WithPause(make(chan struct{}))}, wantErr: graph.ErrNoCheckpointer},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var cp graph.Checkpointer[graph.MessageState]
+			if tc.checkpointer {
+				cp = graph.NewMemorySaver[graph.MessageState]()
+			}
+			state := graph.NewMessageState()
+			err := newFormGraph(t, cp).Invoke(context.Background(), &state, tc.opts...)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, but got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestInvokeMaxSteps(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	runnable := newFormGraph(t, graph.NewMemorySaver[graph.MessageState]())
+	thread := graph.WithThreadID("thread")
+
+	state := graph.NewMessageState()
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithMaxSteps(1)); !errors.Is(err, graph.ErrPaused) {
+		t.Fatalf("expected error %v, but got %v", graph.ErrPaused, err)
+	}
+	cp, err := runnable.GetState(ctx, "thread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.Node != "start" || !slices.Equal(cp.Next, []string{"ask"}) || cp.Interrupt != nil {
+		t.Errorf("expected a checkpoint paused after start, but got %+v", cp)
+	}
+
+	// The continued run is interrupted in ask, and stays so when continued again.
+	state = graph.NewMessageState()
+	for i := 0; i < 2; i++ {
+		var gi *graph.GraphInterrupt
+		if err := runnable.Invoke(ctx, &state, thread, graph.WithContinue()); !errors.As(err, &gi) || gi.Value != "name?" {
+			t.Fatalf("expected an interrupt asking name?, but got %v", err)
+		}
+	}
+	if want := []string{"start"}; !slices.Equal(texts(state), want) {
+		t.Errorf("expected messages %q, but got %q", want, texts(state))
+	}
+
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithResume("Ada"), graph.WithMaxSteps(1)); err == nil || errors.Is(err, graph.ErrPaused) {
+		t.Fatalf("expected an interrupt asking age?, but got %v", err)
+	}
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithResume(36), graph.WithMaxSteps(1)); !errors.Is(err, graph.ErrPaused) {
+		t.Fatalf("expected error %v, but got %v", graph.ErrPaused, err)
+	}
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithContinue(), graph.WithMaxSteps(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"start", "asking", "Ada", "36", "done"}; !slices.Equal(texts(state), want) {
+		t.Errorf("expected messages %q, but got %q", want, texts(state))
+	}
+
+	// A run that ended has nothing left to run.
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithContinue()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := 5; len(state.Messages) != want {
+		t.Errorf("expected %d messages, but got %d", want, len(state.Messages))
+	}
+}
+
+func TestInvokePause(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pause := make(chan struct{})
+	g := graph.NewStateGraph[[]string]()
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNode(name, func(_ context.Context, s *[]string) error {
+			*s = append(*s, name)
+			if name == "a" {
+				close(pause)
+			}
+			return nil
+		})
+	}
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", graph.END)
+	g.SetEntryPoint("a")
+	runnable, err := g.Compile(graph.WithCheckpointer[[]string](graph.NewMemorySaver[[]string]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	thread := graph.WithThreadID("thread")
+
+	// The node running when the pause is closed ends before the run pauses.
+	var state []string
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithPause(pause)); !errors.Is(err, graph.ErrPaused) {
+		t.Fatalf("expected error %v, but got %v", graph.ErrPaused, err)
+	}
+	cp, err := runnable.GetState(ctx, "thread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.Node != "a" || !slices.Equal(cp.Next, []string{"b"}) {
+		t.Errorf("expected a checkpoint paused after a, but got %+v", cp)
+	}
+
+	state = nil
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithContinue()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(state, want) {
+		t.Errorf("expected %q, but got %q", want, state)
+	}
+}
+
+func TestStep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	runnable := newFormGraph(t, nil)
+
+	cp, err := runnable.Step(ctx, graph.Checkpoint[graph.MessageState]{ThreadID: "thread", State: graph.NewMessageState()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.ID == "" || cp.ThreadID != "thread" || cp.Node != "start" || !slices.Equal(cp.Next, []string{"ask"}) {
+		t.Errorf("expected a checkpoint after start, but got %+v", cp)
+	}
+
+	// Interrupted checkpoints stay so until resumed.
+	for _, opts := range [][]graph.InvokeOption{nil, nil, {graph.WithResume("Ada")}} {
+		var gi *graph.GraphInterrupt
+		if cp, err = runnable.Step(ctx, cp, opts...); !errors.As(err, &gi) {
+			t.Fatalf("expected an interrupt, but got %v", err)
+		}
+	}
+	if cp.Interrupt == nil || cp.Interrupt.Value != "age?" {
+		t.Errorf("expected an interrupt asking age?, but got %+v", cp.Interrupt)
+	}
+	for _, opts := range [][]graph.InvokeOption{{graph.WithResume(36)}, nil, nil} {
+		if cp, err = runnable.Step(ctx, cp, opts...); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(cp.Next) != 0 || cp.Step != 3 {
+		t.Errorf("expected the run to end at step 3, but got %+v", cp)
+	}
+	if want := []string{"start", "asking", "Ada", "36", "done"}; !slices.Equal(texts(cp.State), want) {
+		t.Errorf("expected messages %q, but got %q", want, texts(cp.State))
+	}
+}
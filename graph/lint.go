@@ -0,0 +1,230 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrDeadEnd is reported by Lint for nodes from which END cannot be
+	// reached.
+	ErrDeadEnd = errors.New("node cannot reach END")
+
+	// ErrUnmappedRoutes is reported by Lint for conditional edges whose
+	// routes are not given with WithMap, so they cannot be checked.
+	ErrUnmappedRoutes = errors.New("conditional edge without a map of its routes")
+
+	// ErrUnboundedCycle is reported by Lint for cycles run without a step
+	// limit.
+	ErrUnboundedCycle = errors.New("cycle without a step limit")
+)
+
+// Severity tells whether a Finding makes the graph fail to compile or run.
+type Severity string
+
+const (
+	// SeverityError is the severity of problems that make Compile or Invoke
+	// fail.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is the severity of likely mistakes.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem of a graph found by Lint.
+type Finding struct {
+	Severity Severity
+
+	// Err is the problem, matching one of the errors of the package such as
+	// ErrUnreachableNode or ErrDeadEnd with errors.Is.
+	Err error
+
+	// Hint tells how to fix the problem, if known.
+	Hint string
+}
+
+func (f Finding) String() string {
+	if f.Hint == "" {
+		return fmt.Sprintf("%s: %v", f.Severity, f.Err)
+	}
+	return fmt.Sprintf("%s: %v (%s)", f.Severity, f.Err, f.Hint)
+}
+
+// lintHints are the hints of the errors of Validate and Compile.
+var lintHints = []struct {
+	err  error
+	hint string
+}{
+	{ErrEntryPointNotSet, "call SetEntryPoint"},
+	{ErrNodeNotFound, "add the node or fix the name"},
+	{ErrUnreachableNode, "add an edge to the node or remove it"},
+	{ErrNoPathToEnd, "add an edge to END"},
+	{ErrCycle, "allow the cycle with WithAllowedCycle or break it"},
+}
+
+// Lint checks the graph as compiled with opts and returns the problems
+// found, errors first. Beyond the errors of Compile, such as unreachable
+// nodes, missing paths to END and cycles that are not allowed, it reports
+// nodes that cannot reach END, conditional edges without a map of their
+// routes and cycles run without a step limit. It is meant for test suites:
+//
+//	for _, finding := range g.Lint(graph.WithAllowedCycle("agent", "tools")) {
+//		t.Error(finding)
+//	}
+func (g *StateGraph[T]) Lint(opts ...CompileOption) []Finding {
+	var cfg compileConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var findings []Finding
+	report := func(severity Severity, err error) {
+		if err == nil {
+			return
+		}
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			finding := Finding{Severity: severity, Err: err}
+			for _, h := range lintHints {
+				if errors.Is(err, h.err) {
+					finding.Hint = h.hint
+					break
+				}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	report(SeverityError, g.validate(cfg.implicitEnd))
+	report(SeverityError, g.checkCycles(cfg.allowedCycles))
+
+	// successors maps nodes to the nodes their edges lead to, END included;
+	// dynamic holds the nodes with edges that may lead anywhere.
+	successors := make(map[string][]string)
+	dynamic := make(map[string]bool)
+	for _, edge := range g.edges {
+		targets, ok := edgeTargets(edge)
+		if !ok {
+			dynamic[edge.From()] = true
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Err:      fmt.Errorf("%w: from %s", ErrUnmappedRoutes, edge.From()),
+				Hint:     "give its routes with WithMap",
+			})
+		}
+		successors[edge.From()] = append(successors[edge.From()], targets...)
+	}
+
+	// Nodes reaching END, walking the edges backwards from it. Nodes with
+	// dynamic edges are assumed to reach it.
+	predecessors := make(map[string][]string)
+	for from, targets := range successors {
+		for _, to := range targets {
+			predecessors[to] = append(predecessors[to], from)
+		}
+	}
+	queue := []string{END}
+	reaching := map[string]bool{END: true}
+	for name := range g.nodes {
+		if dynamic[name] || (cfg.implicitEnd && len(successors[name]) == 0) {
+			queue = append(queue, name)
+			reaching[name] = true
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, from := range predecessors[name] {
+			if !reaching[from] {
+				reaching[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+	for _, name := range g.nodeNames() {
+		if reaching[name] {
+			continue
+		}
+		hint := "add a path from it to END"
+		if len(successors[name]) == 0 {
+			hint = "add an edge from it to END or compile WithImplicitEnd"
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Err:      fmt.Errorf("%w: %s", ErrDeadEnd, name),
+			Hint:     hint,
+		})
+	}
+
+	if cfg.stepLimit == 0 {
+		for _, component := range stronglyConnected(g.nodes, successors) {
+			slices.Sort(component)
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Err:      fmt.Errorf("%w: %s", ErrUnboundedCycle, strings.Join(component, ", ")),
+				Hint:     "compile WithStepLimit",
+			})
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		if a.Severity == b.Severity {
+			return 0
+		}
+		if a.Severity == SeverityError {
+			return -1
+		}
+		return 1
+	})
+	return findings
+}
+
+// LintJSON lints the graph of a GraphDefinition in JSON read from r, see
+// LintDefinition.
+func LintJSON(r io.Reader, opts ...CompileOption) ([]Finding, error) {
+	def, err := DecodeJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	return LintDefinition(def, opts...)
+}
+
+// LintYAML lints the graph of a GraphDefinition in YAML read from r, see
+// LintDefinition.
+func LintYAML(r io.Reader, opts ...CompileOption) ([]Finding, error) {
+	def, err := DecodeYAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return LintDefinition(def, opts...)
+}
+
+// LintDefinition lints the graph of def compiled with its CompileOptions and
+// opts, see StateGraph.Lint. The graph is built with placeholders for the
+// functions and routers def refers to, so that no NodeRegistry is needed. It
+// returns an error if the graph cannot be built, such as for duplicate
+// nodes.
+func LintDefinition(def GraphDefinition, opts ...CompileOption) ([]Finding, error) {
+	registry := NewNodeRegistry[struct{}]()
+	for _, node := range def.Nodes {
+		name := node.Function
+		if name == "" {
+			name = node.Name
+		}
+		registry.RegisterFunction(name, func(context.Context, *struct{}) error { return nil })
+	}
+	for _, edge := range def.ConditionalEdges {
+		registry.RegisterRouter(edge.Router, func(context.Context, *struct{}) ([]string, error) { return nil, nil })
+	}
+	g, err := BuildGraph(def, registry)
+	if err != nil {
+		return nil, err
+	}
+	return g.Lint(append(def.CompileOptions(), opts...)...), nil
+}
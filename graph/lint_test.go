@@ -0,0 +1,153 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]string, error) { return nil, nil }
+
+	testCases := []struct {
+		name  string
+		build func(g *graph.StateGraph[int])
+		opts  []graph.CompileOption
+		want  []graph.Finding
+	}{
+		{
+			name: "Clean graph",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddEdge("agent", graph.END)
+			},
+		},
+		{
+			name: "Leaf node",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddNode("tools", noop)
+				g.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"call": "tools", "done": graph.END}))
+			},
+			want: []graph.Finding{{Severity: graph.SeverityError, Err: graph.ErrDeadEnd}},
+		},
+		{
+			name: "Leaf node with implicit END",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddNode("tools", noop)
+				g.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"call": "tools", "done": graph.END}))
+			},
+			opts: []graph.CompileOption{graph.WithImplicitEnd()},
+		},
+		{
+			name: "Unmapped routes",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddConditionalEdges("agent", route)
+			},
+			want: []graph.Finding{{Severity: graph.SeverityWarning, Err: graph.ErrUnmappedRoutes}},
+		},
+		{
+			name: "Unreachable node",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddNode("orphan", noop)
+				g.AddEdge("agent", graph.END)
+				g.AddEdge("orphan", graph.END)
+			},
+			want: []graph.Finding{{Severity: graph.SeverityError, Err: graph.ErrUnreachableNode}},
+		},
+		{
+			name: "Cycle without exit",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddNode("tools", noop)
+				g.AddEdge("agent", "tools")
+				g.AddEdge("tools", "agent")
+			},
+			want: []graph.Finding{
+				{Severity: graph.SeverityError, Err: graph.ErrNoPathToEnd},
+				{Severity: graph.SeverityError, Err: graph.ErrCycle},
+				{Severity: graph.SeverityError, Err: graph.ErrDeadEnd},
+				{Severity: graph.SeverityError, Err: graph.ErrDeadEnd},
+				{Severity: graph.SeverityWarning, Err: graph.ErrUnboundedCycle},
+			},
+		},
+		{
+			name: "Allowed cycle without step limit",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddNode("tools", noop)
+				g.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"call": "tools", "done": graph.END}))
+				g.AddEdge("tools", "agent")
+			},
+			opts: []graph.CompileOption{graph.WithAllowedCycle("agent", "tools")},
+			want: []graph.Finding{{Severity: graph.SeverityWarning, Err: graph.ErrUnboundedCycle}},
+		},
+		{
+			name: "Allowed cycle with step limit",
+			build: func(g *graph.StateGraph[int]) {
+				g.AddNode("tools", noop)
+				g.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"call": "tools", "done": graph.END}))
+				g.AddEdge("tools", "agent")
+			},
+			opts: []graph.CompileOption{graph.WithAllowedCycle("agent", "tools"), graph.WithStepLimit(10)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := graph.NewStateGraph[int]()
+			g.AddNode("agent", noop)
+			g.SetEntryPoint("agent")
+			tc.build(g)
+
+			findings := g.Lint(tc.opts...)
+			if len(findings) != len(tc.want) {
+				t.Fatalf("expected %d findings, but got %q", len(tc.want), findings)
+			}
+			for i, want := range tc.want {
+				if findings[i].Severity != want.Severity || !errors.Is(findings[i].Err, want.Err) {
+					t.Errorf("expected %s: %v, but got %s", want.Severity, want.Err, findings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLintYAML(t *testing.T) {
+	t.Parallel()
+
+	const definition = `
+entry_point: agent
+nodes:
+  - name: agent
+    function: call_model
+  - name: tools
+conditional_edges:
+  - from: agent
+    router: should_continue
+    routes: {continue: tools, end: END}
+edges:
+  - {from: tools, to: agent}
+allowed_cycles:
+  - [agent, tools]
+`
+	findings, err := graph.LintYAML(strings.NewReader(definition), graph.WithStepLimit(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected no findings, but got %q", findings)
+	}
+
+	findings, err = graph.LintYAML(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "warning: cycle without a step limit: agent, tools (compile WithStepLimit)"
+	if len(findings) != 1 || findings[0].String() != want {
+		t.Errorf("expected finding %q, but got %q", want, findings)
+	}
+}
@@ -0,0 +1,180 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+)
+
+// NamespaceSeparator separates the prefix of the nodes merged from another
+// graph from their names, see StateGraph.Merge.
+const NamespaceSeparator = "/"
+
+// Fragment is the handle of a graph merged into another, to wire it to the
+// other nodes, see StateGraph.Merge.
+type Fragment[T any] struct {
+	graph  *StateGraph[T]
+	prefix string
+
+	// Entry is the name of the entry point of the merged graph, to add edges
+	// to.
+	Entry string
+
+	// Exits are the names of the nodes of the merged graph with edges to END,
+	// sorted.
+	Exits []string
+}
+
+// Node returns the name under which the node of the merged graph with the
+// given name was added.
+func (f *Fragment[T]) Node(name string) string {
+	if name == END || name == "" {
+		return name
+	}
+	return f.prefix + NamespaceSeparator + name
+}
+
+// Connect makes the merged graph continue to the node to instead of ending:
+// its edges to END, including the routes of its conditional edges, lead to
+// the node instead.
+func (f *Fragment[T]) Connect(to string) error {
+	if _, ok := f.graph.nodes[to]; !ok && to != END {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, to)
+	}
+	for i, edge := range f.graph.edges {
+		if !slices.Contains(f.Exits, edge.From()) {
+			continue
+		}
+		switch edge := edge.(type) {
+		case *SimpleEdge[T]:
+			if edge.to == END {
+				simple := *edge
+				simple.to = to
+				f.graph.edges[i] = &simple
+			}
+		case *Branch[T]:
+			branch := *edge
+			branch.Mapping = func(x string) string {
+				if target := edge.Mapping(x); target != END {
+					return target
+				}
+				return to
+			}
+			if branch.Targets != nil {
+				branch.Targets = replaceTarget(edge.Targets, END, to)
+			}
+			if branch.Default == END {
+				branch.Default = to
+			}
+			branch.Routes = mapRoutes(edge.Routes, func(target string) string {
+				if target == END {
+					return to
+				}
+				return target
+			})
+			f.graph.edges[i] = &branch
+		}
+	}
+	return nil
+}
+
+// Merge adds the nodes and edges of other to the graph, their names prefixed
+// with prefix and NamespaceSeparator, such as "research/search" for the node
+// "search" merged with the prefix "research". This allows reusing graphs as
+// fragments of larger graphs, possibly several times under different
+// prefixes. Edges to END are kept; the returned Fragment connects the merged
+// graph to the other nodes:
+//
+//	research, err := g.Merge(newResearchGraph(), "research")
+//	...
+//	g.AddEdge("plan", research.Entry)
+//	research.Connect("write")
+//
+// It returns ErrEmptyName if prefix is empty and ErrDuplicateNode if a
+// prefixed name is taken, leaving the graph unchanged.
+func (g *StateGraph[T]) Merge(other *StateGraph[T], prefix string) (*Fragment[T], error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("%w: prefix", ErrEmptyName)
+	}
+	f := &Fragment[T]{graph: g, prefix: prefix}
+	for name := range other.nodes {
+		if _, ok := g.nodes[f.Node(name)]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateNode, f.Node(name))
+		}
+	}
+
+	nodes := make([]Node[T], 0, len(other.nodes))
+	for _, node := range other.nodes {
+		node.Name = f.Node(node.Name)
+		nodes = append(nodes, node)
+	}
+	edges := make([]Edge[T], 0, len(other.edges))
+	for _, edge := range other.edges {
+		switch edge := edge.(type) {
+		case *SimpleEdge[T]:
+			simple := *edge
+			simple.from, simple.to = f.Node(edge.from), f.Node(edge.to)
+			edges = append(edges, &simple)
+			if edge.to == END && !slices.Contains(f.Exits, f.Node(edge.from)) {
+				f.Exits = append(f.Exits, f.Node(edge.from))
+			}
+		case *Branch[T]:
+			branch := *edge
+			branch.Source = f.Node(edge.Source)
+			branch.Then = f.Node(edge.Then)
+			branch.Default = f.Node(edge.Default)
+			branch.Mapping = func(x string) string { return f.Node(edge.Mapping(x)) }
+			if edge.Targets != nil {
+				branch.Targets = make([]string, len(edge.Targets))
+				for i, target := range edge.Targets {
+					branch.Targets[i] = f.Node(target)
+				}
+				slices.Sort(branch.Targets)
+			}
+			branch.Routes = mapRoutes(edge.Routes, f.Node)
+			edges = append(edges, &branch)
+			// Unmapped branches may route to END.
+			if (edge.Targets == nil || slices.Contains(edge.Targets, END) || edge.Default == END) && !slices.Contains(f.Exits, branch.Source) {
+				f.Exits = append(f.Exits, branch.Source)
+			}
+		default:
+			return nil, fmt.Errorf("merge edge from %s: unsupported edge type %T", edge.From(), edge)
+		}
+	}
+
+	for _, node := range nodes {
+		g.nodes[node.Name] = node
+	}
+	g.edges = append(g.edges, edges...)
+	f.Entry = f.Node(other.entryPoint)
+	slices.Sort(f.Exits)
+	return f, nil
+}
+
+// replaceTarget returns targets with old replaced by new, sorted and without
+// duplicates.
+func replaceTarget(targets []string, old, new string) []string {
+	replaced := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if target == old {
+			target = new
+		}
+		if !slices.Contains(replaced, target) {
+			replaced = append(replaced, target)
+		}
+	}
+	slices.Sort(replaced)
+	return replaced
+}
+
+// mapRoutes returns routes with their targets mapped by fn, or nil if routes
+// is nil.
+func mapRoutes(routes map[string]string, fn func(target string) string) map[string]string {
+	if routes == nil {
+		return nil
+	}
+	mapped := make(map[string]string, len(routes))
+	for route, target := range routes {
+		mapped[route] = fn(target)
+	}
+	return mapped
+}
@@ -0,0 +1,100 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// visit is a node recording its name in the state.
+func visit(ctx context.Context, visited *[]string) error {
+	name, _, _ := graph.CurrentNode(ctx)
+	*visited = append(*visited, name)
+	return nil
+}
+
+// newSearchFragment builds a graph searching and ranking until five nodes were
+// visited.
+func newSearchFragment() *graph.StateGraph[[]string] {
+	g := graph.NewStateGraph[[]string]()
+	g.AddNode("search", visit)
+	g.AddNode("rank", visit)
+	g.AddEdge("search", "rank")
+	g.AddConditionalEdges("rank", func(_ context.Context, visited *[]string) ([]string, error) {
+		if len(*visited) < 5 {
+			return []string{"more"}, nil
+		}
+		return []string{"done"}, nil
+	}, graph.WithMap[[]string](map[string]string{"more": "search", "done": graph.END}))
+	g.SetEntryPoint("search")
+	return g
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[[]string]()
+	g.AddNode("plan", visit)
+	g.AddNode("write", visit)
+	g.AddEdge("write", graph.END)
+	g.SetEntryPoint("plan")
+
+	research, err := g.Merge(newSearchFragment(), "research")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if research.Entry != "research/search" || !slices.Equal(research.Exits, []string{"research/rank"}) {
+		t.Errorf("expected entry research/search and exits [research/rank], but got %s and %q", research.Entry, research.Exits)
+	}
+	if got := research.Node("rank"); got != "research/rank" {
+		t.Errorf("expected research/rank, but got %s", got)
+	}
+	if err := g.AddEdge("plan", research.Entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := research.Connect("write"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runnable, err := g.Compile(graph.WithAllowedCycle(research.Node("search"), research.Node("rank")))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	var visited []string
+	if err := runnable.Invoke(context.Background(), &visited); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"plan", "research/search", "research/rank", "research/search", "research/rank", "write"}
+	if !slices.Equal(visited, want) {
+		t.Errorf("expected %q, but got %q", want, visited)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[[]string]()
+	if _, err := g.Merge(newSearchFragment(), "research"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The same fragment can be merged again under another prefix only.
+	if _, err := g.Merge(newSearchFragment(), "research"); !errors.Is(err, graph.ErrDuplicateNode) {
+		t.Errorf("expected error %v, but got %v", graph.ErrDuplicateNode, err)
+	}
+	if _, err := g.Merge(newSearchFragment(), ""); !errors.Is(err, graph.ErrEmptyName) {
+		t.Errorf("expected error %v, but got %v", graph.ErrEmptyName, err)
+	}
+	review, err := g.Merge(newSearchFragment(), "review")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := review.Connect("ghost"); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("expected error %v, but got %v", graph.ErrNodeNotFound, err)
+	}
+	if got := len(g.Nodes()); got != 4 {
+		t.Errorf("expected 4 nodes, but got %d", got)
+	}
+}
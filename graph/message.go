@@ -0,0 +1,249 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// RemoveAllMessages is the ID of a RemoveMessage that removes every message.
+const RemoveAllMessages = "__remove_all__"
+
+// ErrMessageNotFound is returned when a message referenced by ID does not exist.
+var ErrMessageNotFound = errors.New("message not found")
+
+// Message is a message of a conversation with a stable ID, so that it can be
+// replaced or removed by later updates.
+type Message struct {
+	llms.MessageContent
+
+	// ID identifies the message within its conversation.
+	ID string
+
+	// Metadata describes the message, see the Metadata* keys.
+	Metadata Metadata
+
+	// remove marks a RemoveMessage.
+	remove bool
+}
+
+// NewMessage wraps content in a Message with a new ID, recording its
+// creation time.
+func NewMessage(content llms.MessageContent) Message {
+	return Message{
+		MessageContent: content,
+		ID:             uuid.NewString(),
+		Metadata:       Metadata{MetadataCreatedAt: time.Now().UTC().Round(0)},
+	}
+}
+
+// Well-known metadata keys.
+const (
+	// MetadataCreatedAt is the time.Time the message was created.
+	MetadataCreatedAt = "created_at"
+
+	// MetadataName is the name of the author of the message.
+	MetadataName = "name"
+
+	// MetadataToolCallID is the ID of the tool call a tool message answers.
+	MetadataToolCallID = "tool_call_id"
+)
+
+// Metadata holds metadata about a message. Values must be serializable to
+// JSON; besides the well-known keys, custom values come back from JSON as
+// the types encoding/json decodes into an interface.
+type Metadata map[string]any
+
+// CreatedAt returns the creation time of the message, if known.
+func (m Message) CreatedAt() (time.Time, bool) {
+	t, ok := m.Metadata[MetadataCreatedAt].(time.Time)
+	return t, ok
+}
+
+// Name returns the name of the author of the message, if any.
+func (m Message) Name() string {
+	name, _ := m.Metadata[MetadataName].(string)
+	return name
+}
+
+// ToolCallID returns the ID of the tool call a tool message answers, if any.
+func (m Message) ToolCallID() string {
+	id, _ := m.Metadata[MetadataToolCallID].(string)
+	return id
+}
+
+// WithMetadata returns a copy of m with the metadata key set to value.
+func (m Message) WithMetadata(key string, value any) Message {
+	metadata := maps.Clone(m.Metadata)
+	if metadata == nil {
+		metadata = make(Metadata, 1)
+	}
+	metadata[key] = value
+	m.Metadata = metadata
+	return m
+}
+
+// RemoveMessage returns a message that, when added with AddMessages, removes
+// the message with the given ID, or all messages for RemoveAllMessages.
+func RemoveMessage(id string) Message {
+	return Message{ID: id, remove: true}
+}
+
+// IsRemove reports whether m is a RemoveMessage.
+func (m Message) IsRemove() bool {
+	return m.remove
+}
+
+// AddMessagesOption configures AddMessages.
+type AddMessagesOption func(*addMessagesConfig)
+
+type addMessagesConfig struct {
+	dedupContent bool
+}
+
+// DedupContent makes AddMessages drop the messages of the update with the same
+// role and parts as a message it already holds, whatever their IDs. It is
+// meant for fan-in joins, where parallel branches may each add a copy of the
+// same message.
+func DedupContent() AddMessagesOption {
+	return func(cfg *addMessagesConfig) {
+		cfg.dedupContent = true
+	}
+}
+
+// AddMessages merges update into messages and returns the result, leaving
+// messages untouched. It is the reducer of MessageState.
+//
+// Messages of update with the ID of an existing message replace it, and
+// RemoveMessage entries remove it; other messages are appended, with a new ID
+// if they have none, so merging the messages of parallel branches does not
+// double the ones they share. It returns ErrMessageNotFound when removing an
+// unknown message.
+func AddMessages(messages []Message, update []Message, opts ...AddMessagesOption) ([]Message, error) {
+	var cfg addMessagesConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	merged := make([]Message, len(messages), len(messages)+len(update))
+	copy(merged, messages)
+
+	index := messageIndexes.Get().(map[string]int)
+	defer func() {
+		clear(index)
+		messageIndexes.Put(index)
+	}()
+	for i, m := range merged {
+		index[m.ID] = i
+	}
+	removed := make(map[string]bool)
+	pruned := false
+
+	// contents counts the kept messages by content hash when deduplicating
+	// by content.
+	var contents map[[sha256.Size]byte]int
+	if cfg.dedupContent {
+		contents = make(map[[sha256.Size]byte]int, len(merged))
+		for _, m := range merged {
+			if h, ok := contentHash(m); ok {
+				contents[h]++
+			}
+		}
+	}
+	forget := func(m Message) {
+		if contents == nil {
+			return
+		}
+		if h, ok := contentHash(m); ok {
+			contents[h]--
+		}
+	}
+
+	for _, m := range update {
+		switch {
+		case m.remove && m.ID == RemoveAllMessages:
+			merged = merged[:0]
+			clear(index)
+			clear(removed)
+			clear(contents)
+			pruned = false
+		case m.remove:
+			i, ok := index[m.ID]
+			if !ok || removed[m.ID] {
+				return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, m.ID)
+			}
+			forget(merged[i])
+			merged[i].remove = true
+			removed[m.ID] = true
+			pruned = true
+		default:
+			if m.ID == "" {
+				m.ID = uuid.NewString()
+			}
+			i, replace := index[m.ID]
+			replace = replace && !removed[m.ID]
+			if contents != nil {
+				h, ok := contentHash(m)
+				if ok && !replace && contents[h] > 0 {
+					continue
+				}
+				if replace {
+					forget(merged[i])
+				}
+				if ok {
+					contents[h]++
+				}
+			}
+			if replace {
+				merged[i] = m
+				continue
+			}
+			index[m.ID] = len(merged)
+			delete(removed, m.ID)
+			merged = append(merged, m)
+		}
+	}
+
+	if !pruned {
+		return merged, nil
+	}
+	kept := merged[:0]
+	for _, m := range merged {
+		if !m.remove {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
+
+// messageIndexes are the maps of AddMessages from message IDs to indexes,
+// reused across calls so that adding to a long history does not allocate an
+// index of its size every time.
+var messageIndexes = sync.Pool{
+	New: func() any { return map[string]int{} },
+}
+
+// contentHash hashes the role and parts of m. It reports false for messages
+// that cannot be serialized.
+func contentHash(m Message) ([sha256.Size]byte, bool) {
+	data, err := Message{MessageContent: m.MessageContent}.MarshalJSON()
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256(data), true
+}
+
+// MessageContents returns the contents of messages, as sent to models.
+func MessageContents(messages []Message) []llms.MessageContent {
+	contents := make([]llms.MessageContent, len(messages))
+	for i, m := range messages {
+		contents[i] = m.MessageContent
+	}
+	return contents
+}
@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// MessageDelta is an incremental update of a message, such as a chunk of a
+// reply being streamed, so that clients can keep their copy of a conversation
+// up to date without receiving the whole history every time.
+type MessageDelta struct {
+	// ID is the ID of the message to update.
+	ID string
+
+	// Role is the role of the message. It is required for the first delta of
+	// a new message, which is then appended to the conversation.
+	Role llms.ChatMessageType
+
+	// Text is appended to the last part of the message if it is text, and
+	// added as a new part otherwise.
+	Text string
+
+	// Parts are appended to the message, after Text.
+	Parts []llms.ContentPart
+
+	// Replace clears the parts of the message before Text and Parts are added.
+	Replace bool
+
+	// Remove removes the message.
+	Remove bool
+}
+
+type jsonMessageDelta struct {
+	ID      string               `json:"id"`
+	Role    llms.ChatMessageType `json:"role,omitempty"`
+	Text    string               `json:"text,omitempty"`
+	Parts   []jsonPart           `json:"parts,omitempty"`
+	Replace bool                 `json:"replace,omitempty"`
+	Remove  bool                 `json:"remove,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding parts as Message does.
+func (d MessageDelta) MarshalJSON() ([]byte, error) {
+	parts, err := marshalParts(d.Parts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonMessageDelta{ID: d.ID, Role: d.Role, Text: d.Text, Parts: parts, Replace: d.Replace, Remove: d.Remove})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *MessageDelta) UnmarshalJSON(data []byte) error {
+	var jd jsonMessageDelta
+	if err := json.Unmarshal(data, &jd); err != nil {
+		return err
+	}
+	parts, err := unmarshalParts(jd.Parts)
+	if err != nil {
+		return err
+	}
+	*d = MessageDelta{ID: jd.ID, Role: jd.Role, Text: jd.Text, Parts: parts, Replace: jd.Replace, Remove: jd.Remove}
+	return nil
+}
+
+// ApplyDelta updates the state with d. It returns ErrMessageNotFound if d
+// refers to an unknown message without starting a new one.
+//
+// Deltas are applied as is, without the window policy or redactor of the
+// state, since they mirror a state that already applied them.
+func (s *MessageState) ApplyDelta(d MessageDelta) error {
+	i := slices.IndexFunc(s.Messages, func(m Message) bool { return m.ID == d.ID })
+	appended := false
+	switch {
+	case d.Remove && i >= 0:
+		s.Messages = slices.Delete(slices.Clone(s.Messages), i, i+1)
+		return nil
+	case i < 0 && (d.Remove || d.Role == ""):
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, d.ID)
+	case i < 0:
+		msg := NewMessage(llms.MessageContent{Role: d.Role})
+		msg.ID = d.ID
+		s.Messages = append(s.Messages, msg)
+		i = len(s.Messages) - 1
+		appended = true
+	}
+
+	msg := s.Messages[i]
+	if d.Role != "" {
+		msg.Role = d.Role
+	}
+	// The parts are copied since they may be shared with clones of the state.
+	var parts []llms.ContentPart
+	if !d.Replace {
+		parts = slices.Clone(msg.Parts)
+	}
+	if d.Text != "" {
+		var last llms.ContentPart
+		if len(parts) > 0 {
+			last = parts[len(parts)-1]
+		}
+		if text, ok := last.(llms.TextContent); ok {
+			parts[len(parts)-1] = llms.TextContent{Text: text.Text + d.Text}
+		} else {
+			parts = append(parts, llms.TextContent{Text: d.Text})
+		}
+	}
+	msg.Parts = append(parts, d.Parts...)
+	if !appended {
+		// The messages are copied since they may be shared with forks of
+		// the state.
+		s.Messages = slices.Clone(s.Messages)
+	}
+	s.Messages[i] = msg
+	return nil
+}
+
+// MessageDeltas returns the deltas that turn the messages before into the
+// messages after, such as the messages of a state before and after a node
+// ran. Messages are told apart by ID.
+//
+// It reports false if after cannot be reached with deltas, which only append
+// new messages, for instance when a message is inserted in front; the whole
+// history must then be sent instead.
+func MessageDeltas(before, after []Message) ([]MessageDelta, bool) {
+	previous := make(map[string]Message, len(before))
+	for _, m := range before {
+		previous[m.ID] = m
+	}
+	current := make(map[string]bool, len(after))
+	for _, m := range after {
+		current[m.ID] = true
+	}
+
+	var deltas []MessageDelta
+	for _, m := range before {
+		if !current[m.ID] {
+			deltas = append(deltas, MessageDelta{ID: m.ID, Remove: true})
+		}
+	}
+	for _, m := range after {
+		prev, ok := previous[m.ID]
+		switch {
+		case !ok:
+			deltas = append(deltas, MessageDelta{ID: m.ID, Role: m.Role, Parts: m.Parts})
+		case reflect.DeepEqual(prev.MessageContent, m.MessageContent):
+		case prev.Role == m.Role:
+			if d, ok := appendDelta(prev, m); ok {
+				deltas = append(deltas, d)
+				break
+			}
+			fallthrough
+		default:
+			deltas = append(deltas, MessageDelta{ID: m.ID, Role: m.Role, Parts: m.Parts, Replace: true})
+		}
+	}
+
+	// Deltas cannot move messages, so check that they rebuild after.
+	state := MessageState{Messages: slices.Clone(before)}
+	for _, d := range deltas {
+		if err := state.ApplyDelta(d); err != nil {
+			return nil, false
+		}
+	}
+	if len(state.Messages) != len(after) {
+		return nil, false
+	}
+	for i, m := range state.Messages {
+		if m.ID != after[i].ID || !reflect.DeepEqual(m.MessageContent, after[i].MessageContent) {
+			return nil, false
+		}
+	}
+	return deltas, true
+}
+
+// appendDelta returns the delta appending to prev to get m, if m only
+// extends prev.
+func appendDelta(prev, m Message) (MessageDelta, bool) {
+	n := len(prev.Parts)
+	if len(m.Parts) < n {
+		return MessageDelta{}, false
+	}
+	for i := range n - 1 {
+		if !reflect.DeepEqual(prev.Parts[i], m.Parts[i]) {
+			return MessageDelta{}, false
+		}
+	}
+	d := MessageDelta{ID: m.ID}
+	if n > 0 && !reflect.DeepEqual(prev.Parts[n-1], m.Parts[n-1]) {
+		before, ok1 := prev.Parts[n-1].(llms.TextContent)
+		after, ok2 := m.Parts[n-1].(llms.TextContent)
+		if !ok1 || !ok2 || len(after.Text) < len(before.Text) || after.Text[:len(before.Text)] != before.Text {
+			return MessageDelta{}, false
+		}
+		d.Text = after.Text[len(before.Text):]
+	}
+	d.Parts = m.Parts[n:]
+	return d, true
+}
+
+// messageStreamKey is the context key of the message stream of a run, see
+// WithMessageStream.
+type messageStreamKey struct{}
+
+// EmitMessageDelta sends d to the message stream of the run of ctx, the
+// context of a node, see WithMessageStream. Runs without a message stream
+// drop d, the messages being added to the state anyway. It is the emit
+// function of StreamDeltas for nodes streaming the replies of models:
+//
+//	llms.WithStreamingFunc(graph.StreamDeltas(id, graph.EmitMessageDelta))
+func EmitMessageDelta(ctx context.Context, d MessageDelta) error {
+	send, ok := ctx.Value(messageStreamKey{}).(func(context.Context, MessageDelta) error)
+	if !ok {
+		return nil
+	}
+	return send(ctx, d)
+}
+
+// StreamDeltas returns a streaming function for llms.WithStreamingFunc that
+// emits the chunks of the reply of a model as deltas of the AI message with
+// the given ID:
+//
+//	id := uuid.NewString()
+//	resp, err := model.GenerateContent(ctx, state.Contents(), llms.WithStreamingFunc(graph.StreamDeltas(id, send)))
+//
+// The first delta starts the message. The reply should then be added to the
+// state with the same ID.
+func StreamDeltas(id string, emit func(ctx context.Context, d MessageDelta) error) func(ctx context.Context, chunk []byte) error {
+	started := false
+	return func(ctx context.Context, chunk []byte) error {
+		d := MessageDelta{ID: id, Text: string(chunk)}
+		if !started {
+			d.Role = llms.ChatMessageTypeAI
+			started = true
+		}
+		return emit(ctx, d)
+	}
+}
@@ -0,0 +1,219 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestApplyDelta(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	human := state.Messages[0].ID
+	snapshot := state.Clone()
+
+	call := llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: "{}"}}
+	for _, d := range []graph.MessageDelta{
+		{ID: "reply", Role: llms.ChatMessageTypeAI, Text: "Hel"},
+		{ID: "reply", Text: "lo"},
+		{ID: "reply", Parts: []llms.ContentPart{call}},
+		{ID: "reply", Text: "!"},
+		{ID: human, Text: " there"},
+	} {
+		if err := state.ApplyDelta(d); err != nil {
+			t.Fatalf("unexpected error applying %+v: %v", d, err)
+		}
+	}
+
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "hi there"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.TextContent{Text: "Hello"}, call, llms.TextContent{Text: "!"}}},
+	}
+	if got := state.Contents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected contents %v, but got %v", want, got)
+	}
+	if got := texts(snapshot); !slices.Equal(got, []string{"hi"}) {
+		t.Errorf("the clone of the state was modified: %q", got)
+	}
+
+	if err := state.ApplyDelta(graph.MessageDelta{ID: "reply", Text: "Bye", Replace: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := state.ApplyDelta(graph.MessageDelta{ID: human, Remove: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := state.Contents(), []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeAI, "Bye")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected contents %v, but got %v", want, got)
+	}
+
+	for _, d := range []graph.MessageDelta{{ID: "unknown", Text: "x"}, {ID: "unknown", Remove: true}} {
+		if err := state.ApplyDelta(d); !errors.Is(err, graph.ErrMessageNotFound) {
+			t.Errorf("expected error %v applying %+v, but got %v", graph.ErrMessageNotFound, d, err)
+		}
+	}
+}
+
+func TestMessageDeltas(t *testing.T) {
+	t.Parallel()
+
+	msg := func(id string, role llms.ChatMessageType, parts ...llms.ContentPart) graph.Message {
+		return graph.Message{MessageContent: llms.MessageContent{Role: role, Parts: parts}, ID: id}
+	}
+	text := func(s string) llms.ContentPart { return llms.TextContent{Text: s} }
+	call := llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: "{}"}}
+
+	before := []graph.Message{
+		msg("1", llms.ChatMessageTypeHuman, text("hi")),
+		msg("2", llms.ChatMessageTypeAI, text("Hel")),
+		msg("3", llms.ChatMessageTypeAI, text("draft")),
+		msg("4", llms.ChatMessageTypeHuman, text("gone")),
+	}
+
+	testCases := []struct {
+		name   string
+		after  []graph.Message
+		want   []graph.MessageDelta
+		wantOK bool
+	}{
+		{
+			name:   "Unchanged",
+			after:  before,
+			wantOK: true,
+		},
+		{
+			name: "Append, extend, replace and remove",
+			after: []graph.Message{
+				before[0],
+				msg("2", llms.ChatMessageTypeAI, text("Hello"), call),
+				msg("3", llms.ChatMessageTypeAI, text("final")),
+				msg("5", llms.ChatMessageTypeTool, text("result")),
+			},
+			want: []graph.MessageDelta{
+				{ID: "4", Remove: true},
+				{ID: "2", Text: "lo", Parts: []llms.ContentPart{call}},
+				{ID: "3", Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{text("final")}, Replace: true},
+				{ID: "5", Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{text("result")}},
+			},
+			wantOK: true,
+		},
+		{
+			name:  "Inserted in front",
+			after: append([]graph.Message{msg("0", llms.ChatMessageTypeSystem, text("system"))}, before...),
+		},
+		{
+			name:  "Reordered",
+			after: []graph.Message{before[1], before[0], before[2], before[3]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			deltas, ok := graph.MessageDeltas(before, tc.after)
+			if ok != tc.wantOK {
+				t.Fatalf("expected ok %v, but got %v", tc.wantOK, ok)
+			}
+			if !reflect.DeepEqual(deltas, tc.want) {
+				t.Errorf("expected deltas %+v, but got %+v", tc.want, deltas)
+			}
+		})
+	}
+}
+
+func TestMessageDeltaJSON(t *testing.T) {
+	t.Parallel()
+
+	d := graph.MessageDelta{
+		ID:    "reply",
+		Role:  llms.ChatMessageTypeAI,
+		Text:  "Hello",
+		Parts: []llms.ContentPart{llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: "{}"}}},
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got graph.MessageDelta
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Errorf("expected delta %+v, but got %+v", d, got)
+	}
+}
+
+func TestStreamDeltas(t *testing.T) {
+	t.Parallel()
+
+	var deltas []graph.MessageDelta
+	stream := graph.StreamDeltas("reply", func(_ context.Context, d graph.MessageDelta) error {
+		deltas = append(deltas, d)
+		return nil
+	})
+	for _, chunk := range []string{"Hel", "lo"} {
+		if err := stream(context.Background(), []byte(chunk)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	state := graph.NewMessageState()
+	for _, d := range deltas {
+		if err := state.ApplyDelta(d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := texts(state); !slices.Equal(got, []string{"Hello"}) || state.Messages[0].ID != "reply" || state.Messages[0].Role != llms.ChatMessageTypeAI {
+		t.Errorf("expected AI message reply saying Hello, but got %+v", state.Messages)
+	}
+}
+
+func TestEmitMessageDelta(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("reply", func(ctx context.Context, s *graph.MessageState) error {
+		stream := graph.StreamDeltas("reply", graph.EmitMessageDelta)
+		for _, chunk := range []string{"Hel", "lo"} {
+			if err := stream(ctx, []byte(chunk)); err != nil {
+				return err
+			}
+		}
+		return s.AddMessages(graph.Message{ID: "reply", MessageContent: llms.TextParts(llms.ChatMessageTypeAI, "Hello")})
+	})
+	g.AddEdge("reply", graph.END)
+	g.SetEntryPoint("reply")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	var deltas []graph.MessageDelta
+	state := graph.NewMessageState()
+	err = runnable.Invoke(context.Background(), &state, graph.WithMessageStream(func(_ context.Context, d graph.MessageDelta) error {
+		deltas = append(deltas, d)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deltas) != 2 || deltas[0].Text != "Hel" || deltas[1].Text != "lo" {
+		t.Errorf("expected the deltas Hel and lo, but got %+v", deltas)
+	}
+
+	// Runs without a message stream drop the deltas.
+	state = graph.NewMessageState()
+	if err := runnable.Invoke(context.Background(), &state); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := texts(state); !slices.Equal(got, []string{"Hello"}) {
+		t.Errorf("expected the reply Hello, but got %v", got)
+	}
+}
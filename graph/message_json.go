@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrUnknownPartType is returned when (de)serializing a message part of an
+// unsupported type.
+var ErrUnknownPartType = errors.New("unknown message part type")
+
+// Types of the serialized message parts.
+const (
+	partTypeText         = "text"
+	partTypeImageURL     = "image_url"
+	partTypeBinary       = "binary"
+	partTypeToolCall     = "tool_call"
+	partTypeToolResponse = "tool_response"
+)
+
+type jsonMessage struct {
+	ID       string               `json:"id,omitempty"`
+	Role     llms.ChatMessageType `json:"role"`
+	Parts    []jsonPart           `json:"parts"`
+	Metadata Metadata             `json:"metadata,omitempty"`
+	Remove   bool                 `json:"remove,omitempty"`
+}
+
+// jsonPart is the union of the fields of all the part types, told apart by
+// Type.
+type jsonPart struct {
+	Type string `json:"type"`
+
+	// TextContent.
+	Text string `json:"text,omitempty"`
+
+	// ImageURLContent.
+	URL    string `json:"url,omitempty"`
+	Detail string `json:"detail,omitempty"`
+
+	// BinaryContent.
+	MIMEType string `json:"mime_type,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+
+	// ToolCall.
+	ID       string             `json:"id,omitempty"`
+	ToolType string             `json:"tool_type,omitempty"`
+	Function *llms.FunctionCall `json:"function,omitempty"`
+
+	// ToolCallResponse.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Content    string `json:"content,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Every part type of llms.MessageContent
+// is supported, with binary data encoded in base64.
+func (m Message) MarshalJSON() ([]byte, error) {
+	parts, err := marshalParts(m.Parts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonMessage{ID: m.ID, Role: m.Role, Parts: parts, Metadata: m.Metadata, Remove: m.remove})
+}
+
+// encode encodes m with enc, as MarshalJSON does, without allocating the
+// encoding.
+func (m Message) encode(enc *json.Encoder) error {
+	parts, err := marshalParts(m.Parts)
+	if err != nil {
+		return err
+	}
+	return enc.Encode(jsonMessage{ID: m.ID, Role: m.Role, Parts: parts, Metadata: m.Metadata, Remove: m.remove})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var jm jsonMessage
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+	parts, err := unmarshalParts(jm.Parts)
+	if err != nil {
+		return err
+	}
+	if s, ok := jm.Metadata[MetadataCreatedAt].(string); ok {
+		createdAt, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("parse %s metadata: %w", MetadataCreatedAt, err)
+		}
+		jm.Metadata[MetadataCreatedAt] = createdAt
+	}
+	*m = Message{
+		MessageContent: llms.MessageContent{Role: jm.Role, Parts: parts},
+		ID:             jm.ID,
+		Metadata:       jm.Metadata,
+		remove:         jm.Remove,
+	}
+	return nil
+}
+
+func marshalParts(parts []llms.ContentPart) ([]jsonPart, error) {
+	jparts := make([]jsonPart, len(parts))
+	for i, part := range parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			jparts[i] = jsonPart{Type: partTypeText, Text: p.Text}
+		case llms.ImageURLContent:
+			jparts[i] = jsonPart{Type: partTypeImageURL, URL: p.URL, Detail: p.Detail}
+		case llms.BinaryContent:
+			jparts[i] = jsonPart{Type: partTypeBinary, MIMEType: p.MIMEType, Data: p.Data}
+		case llms.ToolCall:
+			jparts[i] = jsonPart{Type: partTypeToolCall, ID: p.ID, ToolType: p.Type, Function: p.FunctionCall}
+		case llms.ToolCallResponse:
+			jparts[i] = jsonPart{Type: partTypeToolResponse, ToolCallID: p.ToolCallID, Name: p.Name, Content: p.Content}
+		default:
+			return nil, fmt.Errorf("%w: %T", ErrUnknownPartType, part)
+		}
+	}
+	return jparts, nil
+}
+
+// unmarshalParts returns nil for no parts.
+func unmarshalParts(jparts []jsonPart) ([]llms.ContentPart, error) {
+	if len(jparts) == 0 {
+		return nil, nil
+	}
+	parts := make([]llms.ContentPart, len(jparts))
+	for i, p := range jparts {
+		switch p.Type {
+		case partTypeText:
+			parts[i] = llms.TextContent{Text: p.Text}
+		case partTypeImageURL:
+			parts[i] = llms.ImageURLContent{URL: p.URL, Detail: p.Detail}
+		case partTypeBinary:
+			parts[i] = llms.BinaryContent{MIMEType: p.MIMEType, Data: p.Data}
+		case partTypeToolCall:
+			parts[i] = llms.ToolCall{ID: p.ID, Type: p.ToolType, FunctionCall: p.Function}
+		case partTypeToolResponse:
+			parts[i] = llms.ToolCallResponse{ToolCallID: p.ToolCallID, Name: p.Name, Content: p.Content}
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownPartType, p.Type)
+		}
+	}
+	return parts, nil
+}
+
+type jsonMessageState struct {
+	Messages []Message `json:"messages"`
+}
+
+// MarshalJSON implements json.Marshaler. The messages are encoded one by
+// one into a buffer reused across calls, see WriteCheckpoint.
+func (s MessageState) MarshalJSON() ([]byte, error) {
+	b := jsonBuffers.Get().(*bytes.Buffer)
+	defer putJSONBuffer(b)
+	if err := s.writeJSON(b); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(b.Bytes()), nil
+}
+
+// jsonBuffers are the buffers message states are encoded in.
+var jsonBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxJSONBuffer is the capacity above which a buffer is dropped rather than
+// reused, so that an outsized history does not pin memory.
+const maxJSONBuffer = 1 << 20
+
+func putJSONBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxJSONBuffer {
+		return
+	}
+	b.Reset()
+	jsonBuffers.Put(b)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *MessageState) UnmarshalJSON(data []byte) error {
+	var js jsonMessageState
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+	if js.Messages == nil {
+		js.Messages = []Message{}
+	}
+	s.Messages = js.Messages
+	return nil
+}
@@ -0,0 +1,66 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestMessageStateJSON(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeSystem, "prompt"))
+	state.AddMessage(llms.MessageContent{
+		Role: llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{
+			llms.TextContent{Text: "What is in these?"},
+			llms.ImageURLContent{URL: "https://example.com/cat.png", Detail: "low"},
+			llms.BinaryContent{MIMEType: "image/png", Data: []byte{0x89, 'P', 'N', 'G', 0}},
+		},
+	})
+	state.AddMessage(llms.MessageContent{
+		Role: llms.ChatMessageTypeAI,
+		Parts: []llms.ContentPart{llms.ToolCall{
+			ID:           "call-1",
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: "describe", Arguments: `{"n":2}`},
+		}},
+	})
+	state.AddMessage(llms.MessageContent{
+		Role:  llms.ChatMessageTypeTool,
+		Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call-1", Name: "describe", Content: "a cat"}},
+	})
+	state.Messages = append(state.Messages, graph.RemoveMessage("old"))
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	var decoded graph.MessageState
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, state) {
+		t.Errorf("expected %+v, but got %+v", state, decoded)
+	}
+	if !decoded.LastMessage().IsRemove() {
+		t.Errorf("expected the RemoveMessage to survive the round trip")
+	}
+}
+
+func TestMessageJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := json.Marshal(graph.Message{MessageContent: llms.MessageContent{Parts: []llms.ContentPart{nil}}}); !errors.Is(err, graph.ErrUnknownPartType) {
+		t.Errorf("expected error %v, but got %v", graph.ErrUnknownPartType, err)
+	}
+	var msg graph.Message
+	if err := json.Unmarshal([]byte(`{"role":"human","parts":[{"type":"video"}]}`), &msg); !errors.Is(err, graph.ErrUnknownPartType) {
+		t.Errorf("expected error %v, but got %v", graph.ErrUnknownPartType, err)
+	}
+}
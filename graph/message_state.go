@@ -1,31 +1,269 @@
 package graph
 
 import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
 	"github.com/tmc/langchaingo/llms"
 )
 
 type MessageState struct {
-	Messages []llms.MessageContent
+	Messages []Message
+
+	// Window, if set, is applied to Messages every time messages are added
+	// through the methods of the state, to bound the history.
+	Window WindowPolicy
+
+	// Redactor, if set, is applied to every message added through the
+	// methods of the state, before it is stored.
+	Redactor Redactor
+}
+
+// WindowPolicy bounds the history of a MessageState, see
+// messagestate.Window for a configurable implementation.
+type WindowPolicy interface {
+	// Apply returns the messages to keep. It must not modify msgs.
+	Apply(msgs []Message) []Message
+}
+
+// Redactor scrubs sensitive data, such as email addresses, from messages
+// before they are stored in a MessageState, and so before they are
+// checkpointed or sent to models. See messagestate.Redactor for pattern based
+// implementations.
+type Redactor interface {
+	// Redact returns msg with its sensitive data removed. It must not modify
+	// msg.
+	Redact(msg Message) Message
 }
 
 func NewMessageState() MessageState {
 	return MessageState{
-		Messages: []llms.MessageContent{},
+		Messages: []Message{},
+	}
+}
+
+// Clone returns a copy of the state that does not share its message slice
+// or the metadata of its messages.
+func (s *MessageState) Clone() MessageState {
+	messages := append([]Message(nil), s.Messages...)
+	for i := range messages {
+		messages[i].Metadata = maps.Clone(messages[i].Metadata)
 	}
+	return MessageState{Messages: messages, Window: s.Window, Redactor: s.Redactor}
+}
+
+// Fork returns a copy of the state sharing its messages, as the state of a
+// branch, without copying them as Clone does. The methods of the state copy
+// the messages before changing them in place, so the copies do not see each
+// other's changes through them, but writing to the messages or to their
+// metadata directly shows through every copy.
+func (s *MessageState) Fork() MessageState {
+	return MessageState{Messages: s.Messages[:len(s.Messages):len(s.Messages)], Window: s.Window, Redactor: s.Redactor}
 }
+
+// AddMessage appends message to the state with a new ID.
 func (s *MessageState) AddMessage(message llms.MessageContent) {
-	s.Messages = append(s.Messages, message)
+	s.setMessages(append(s.Messages, s.redact(NewMessage(message))))
+}
+
+// AddMessages merges messages into the state with the AddMessages reducer,
+// replacing and removing messages by ID.
+func (s *MessageState) AddMessages(messages ...Message) error {
+	merged, err := AddMessages(s.Messages, s.redactAll(messages))
+	if err != nil {
+		return err
+	}
+	s.setMessages(merged)
+	return nil
+}
+
+// Merge merges the messages of branch, a state forked from s, into s. The
+// messages they share are matched by ID, and with DedupContent by content,
+// so that joining parallel branches does not double them.
+func (s *MessageState) Merge(branch MessageState, opts ...AddMessagesOption) error {
+	merged, err := AddMessages(s.Messages, s.redactAll(branch.Messages), opts...)
+	if err != nil {
+		return err
+	}
+	s.setMessages(merged)
+	return nil
+}
+
+// Reduce implements Reducer: the messages of update are merged into the
+// state with AddMessages, appending new messages and replacing or removing
+// existing ones by ID.
+func (s *MessageState) Reduce(update MessageState) error {
+	return s.AddMessages(update.Messages...)
+}
+
+// mergeForks merges the messages branches, forked from s, added, changed or
+// removed into s, in order, as if they ran one after the other. The messages
+// a branch left as they were are not merged, so that it does not undo the
+// changes of the branches before it, and the messages it dropped, such as
+// with RemoveMessage or its window policy, are removed. The messages of s
+// are indexed only once.
+func (s *MessageState) mergeForks(branches []MessageState) error {
+	index := make(map[string]int, len(s.Messages))
+	for i, m := range s.Messages {
+		index[m.ID] = i
+	}
+	var update []Message
+	removed := make(map[string]bool)
+	kept := make([]bool, len(s.Messages))
+	for _, b := range branches {
+		clear(kept)
+		var changed []Message
+		for _, m := range b.Messages {
+			i, ok := index[m.ID]
+			if ok {
+				kept[i] = true
+			}
+			// DeepEqual returns early for the parts and metadata the
+			// branch shares with s.
+			if ok && reflect.DeepEqual(m, s.Messages[i]) {
+				continue
+			}
+			changed = append(changed, m)
+		}
+		for i, m := range s.Messages {
+			if !kept[i] && !removed[m.ID] {
+				removed[m.ID] = true
+				update = append(update, RemoveMessage(m.ID))
+			}
+		}
+		update = append(update, changed...)
+	}
+	return s.AddMessages(update...)
+}
+
+// redact returns msg as scrubbed by the redactor of the state.
+func (s *MessageState) redact(msg Message) Message {
+	if s.Redactor == nil || msg.remove {
+		return msg
+	}
+	return s.Redactor.Redact(msg)
+}
+
+// redactAll returns messages as scrubbed by the redactor of the state,
+// leaving messages untouched.
+func (s *MessageState) redactAll(messages []Message) []Message {
+	if s.Redactor == nil {
+		return messages
+	}
+	redacted := make([]Message, len(messages))
+	for i, msg := range messages {
+		redacted[i] = s.redact(msg)
+	}
+	return redacted
+}
+
+// setMessages sets the messages of the state, applying its window policy.
+func (s *MessageState) setMessages(messages []Message) {
+	if s.Window != nil {
+		messages = s.Window.Apply(messages)
+	}
+	s.Messages = messages
+}
+
+// Contents returns the contents of the messages, as sent to models.
+func (s *MessageState) Contents() []llms.MessageContent {
+	return MessageContents(s.Messages)
 }
 
-func (s *MessageState) LastMessage() llms.MessageContent {
+// LastMessage returns the last message. It panics if there are no messages.
+func (s *MessageState) LastMessage() Message {
 	return s.Messages[len(s.Messages)-1]
 }
 
-func (s *MessageState) LastMessageOfRole(role llms.ChatMessageType) llms.MessageContent {
+// LastMessageOfRole returns the last message of role, if there is one.
+func (s *MessageState) LastMessageOfRole(role llms.ChatMessageType) (Message, bool) {
 	for i := len(s.Messages) - 1; i >= 0; i-- {
 		if s.Messages[i].Role == role {
-			return s.Messages[i]
+			return s.Messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// FirstMessageOfRole returns the first message of role, if there is one.
+func (s *MessageState) FirstMessageOfRole(role llms.ChatMessageType) (Message, bool) {
+	for _, msg := range s.Messages {
+		if msg.Role == role {
+			return msg, true
+		}
+	}
+	return Message{}, false
+}
+
+// MessagesSince returns the messages after the message with the given ID.
+// It returns ErrMessageNotFound if there is no such message.
+func (s *MessageState) MessagesSince(id string) ([]Message, error) {
+	for i, msg := range s.Messages {
+		if msg.ID == id {
+			return append([]Message(nil), s.Messages[i+1:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+}
+
+// LastHumanAndAIMessages returns the last exchange of the conversation: the
+// last AI message and the human message it answers. It reports false if
+// there is no AI message preceded by a human message.
+func (s *MessageState) LastHumanAndAIMessages() (human, ai Message, ok bool) {
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		if s.Messages[i].Role != llms.ChatMessageTypeAI {
+			continue
 		}
+		for j := i - 1; j >= 0; j-- {
+			if s.Messages[j].Role == llms.ChatMessageTypeHuman {
+				return s.Messages[j], s.Messages[i], true
+			}
+		}
+		break
+	}
+	return Message{}, Message{}, false
+}
+
+// SystemMessage returns the leading system message, which holds the system
+// prompt, if there is one. The summary message maintained by Compact is not
+// a system prompt.
+func (s *MessageState) SystemMessage() (Message, bool) {
+	if len(s.Messages) == 0 || s.Messages[0].Role != llms.ChatMessageTypeSystem || isSummary(s.Messages[0]) {
+		return Message{}, false
 	}
-	panic("no message of role " + role)
+	return s.Messages[0], true
+}
+
+// EnsureSystemMessage inserts a leading system message with prompt unless the
+// state already has one, in which case it is kept as is.
+func (s *MessageState) EnsureSystemMessage(prompt string) {
+	if _, ok := s.SystemMessage(); ok {
+		return
+	}
+	s.insertSystemMessage(prompt)
+}
+
+// ReplaceSystemMessage sets the text of the leading system message to prompt,
+// keeping its ID, or inserts one if there is none.
+func (s *MessageState) ReplaceSystemMessage(prompt string) {
+	if _, ok := s.SystemMessage(); ok {
+		// The messages are copied since they may be shared with forks of
+		// the state.
+		s.Messages = slices.Clone(s.Messages)
+		s.Messages[0] = s.redact(Message{
+			MessageContent: llms.TextParts(llms.ChatMessageTypeSystem, prompt),
+			ID:             s.Messages[0].ID,
+			Metadata:       s.Messages[0].Metadata,
+		})
+		return
+	}
+	s.insertSystemMessage(prompt)
+}
+
+func (s *MessageState) insertSystemMessage(prompt string) {
+	messages := make([]Message, 0, len(s.Messages)+1)
+	messages = append(messages, s.redact(NewMessage(llms.TextParts(llms.ChatMessageTypeSystem, prompt))))
+	s.setMessages(append(messages, s.Messages...))
 }
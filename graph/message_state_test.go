@@ -0,0 +1,246 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestSystemMessage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		messages []llms.MessageContent
+		apply    func(*graph.MessageState)
+		want     []string
+	}{
+		{
+			name:     "Ensure inserts",
+			messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")},
+			apply:    func(s *graph.MessageState) { s.EnsureSystemMessage("prompt") },
+			want:     []string{"prompt", "hi"},
+		},
+		{
+			name: "Ensure is idempotent",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, "prompt"),
+				llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+			},
+			apply: func(s *graph.MessageState) {
+				s.EnsureSystemMessage("other")
+				s.EnsureSystemMessage("other")
+			},
+			want: []string{"prompt", "hi"},
+		},
+		{
+			name: "Replace updates",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, "prompt"),
+				llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+			},
+			apply: func(s *graph.MessageState) {
+				s.ReplaceSystemMessage("new")
+				s.ReplaceSystemMessage("newer")
+			},
+			want: []string{"newer", "hi"},
+		},
+		{
+			name:  "Replace inserts",
+			apply: func(s *graph.MessageState) { s.ReplaceSystemMessage("prompt") },
+			want:  []string{"prompt"},
+		},
+		{
+			name: "Summary is not a system prompt",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, graph.SummaryPrefix+"earlier"),
+				llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+			},
+			apply: func(s *graph.MessageState) { s.EnsureSystemMessage("prompt") },
+			want:  []string{"prompt", graph.SummaryPrefix + "earlier", "hi"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			state := graph.NewMessageState()
+			for _, msg := range tc.messages {
+				state.AddMessage(msg)
+			}
+			var id string
+			if msg, ok := state.SystemMessage(); ok {
+				id = msg.ID
+			}
+
+			tc.apply(&state)
+			if got := texts(state); !slices.Equal(got, tc.want) {
+				t.Errorf("expected messages %q, but got %q", tc.want, got)
+			}
+			if msg, ok := state.SystemMessage(); !ok || (id != "" && msg.ID != id) {
+				t.Errorf("expected system message with ID %q, but got %+v", id, msg)
+			}
+		})
+	}
+}
+
+func TestMessageStateQueries(t *testing.T) {
+	t.Parallel()
+
+	empty := graph.NewMessageState()
+	if _, ok := empty.LastMessageOfRole(llms.ChatMessageTypeHuman); ok {
+		t.Errorf("expected no human message in an empty state")
+	}
+	if _, ok := empty.FirstMessageOfRole(llms.ChatMessageTypeHuman); ok {
+		t.Errorf("expected no human message in an empty state")
+	}
+	if _, _, ok := empty.LastHumanAndAIMessages(); ok {
+		t.Errorf("expected no exchange in an empty state")
+	}
+
+	state := graph.NewMessageState()
+	for _, m := range []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "prompt"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "h1"),
+		llms.TextParts(llms.ChatMessageTypeAI, "a1"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "h2"),
+		llms.TextParts(llms.ChatMessageTypeTool, "t1"),
+		llms.TextParts(llms.ChatMessageTypeAI, "a2"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "h3"),
+	} {
+		state.AddMessage(m)
+	}
+	text := func(m graph.Message) string { return m.Parts[0].(llms.TextContent).Text }
+
+	if m, ok := state.FirstMessageOfRole(llms.ChatMessageTypeHuman); !ok || text(m) != "h1" {
+		t.Errorf("expected first human message h1, but got %v", m)
+	}
+	if m, ok := state.LastMessageOfRole(llms.ChatMessageTypeAI); !ok || text(m) != "a2" {
+		t.Errorf("expected last AI message a2, but got %v", m)
+	}
+	if human, ai, ok := state.LastHumanAndAIMessages(); !ok || text(human) != "h2" || text(ai) != "a2" {
+		t.Errorf("expected last exchange h2/a2, but got %v/%v", human, ai)
+	}
+
+	since, err := state.MessagesSince(state.Messages[3].ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := texts(graph.MessageState{Messages: since}); !slices.Equal(got, []string{"t1", "a2", "h3"}) {
+		t.Errorf("expected messages since h2, but got %q", got)
+	}
+	if _, err := state.MessagesSince("unknown"); !errors.Is(err, graph.ErrMessageNotFound) {
+		t.Errorf("expected error %v, but got %v", graph.ErrMessageNotFound, err)
+	}
+}
+
+func TestMessageStateFork(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		apply func(*graph.MessageState)
+		want  []string
+	}{
+		{
+			name:  "AddMessage",
+			apply: func(s *graph.MessageState) { s.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "fork")) },
+			want:  []string{"prompt", "hi", "hello", "fork"},
+		},
+		{
+			name:  "ReplaceSystemMessage",
+			apply: func(s *graph.MessageState) { s.ReplaceSystemMessage("fork") },
+			want:  []string{"fork", "hi", "hello"},
+		},
+		{
+			name: "ApplyDelta",
+			apply: func(s *graph.MessageState) {
+				if err := s.ApplyDelta(graph.MessageDelta{ID: s.LastMessage().ID, Text: " fork"}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			want: []string{"prompt", "hi", "hello fork"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// Three messages leave room to append without reallocating.
+			state := graph.NewMessageState()
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeSystem, "prompt"))
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "hello"))
+
+			fork := state.Fork()
+			tc.apply(&fork)
+			if got := texts(fork); !slices.Equal(got, tc.want) {
+				t.Errorf("expected fork messages %q, but got %q", tc.want, got)
+			}
+			if got, want := texts(state), []string{"prompt", "hi", "hello"}; !slices.Equal(got, want) {
+				t.Errorf("expected state messages %q, but got %q", want, got)
+			}
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "state"))
+			if got := texts(fork); !slices.Equal(got, tc.want) {
+				t.Errorf("expected fork messages %q after adding to the state, but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// conversation returns a MessageState of n messages alternating between a
+// question and an answer calling a tool.
+func conversation(n int) graph.MessageState {
+	s := graph.NewMessageState()
+	for i := range n {
+		if i%2 == 0 {
+			s.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "What is the weather in Paris today?"))
+			continue
+		}
+		s.AddMessage(llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "Let me check."},
+			llms.ToolCall{ID: "call", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+		}})
+	}
+	return s
+}
+
+func BenchmarkMessageState(b *testing.B) {
+	for _, n := range []int{10, 1000} {
+		b.Run(fmt.Sprintf("AddMessage/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				b.StopTimer()
+				s := conversation(n)
+				b.StartTimer()
+				s.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "It is sunny."))
+			}
+		})
+		b.Run(fmt.Sprintf("AddMessages/%d", n), func(b *testing.B) {
+			s := conversation(n)
+			reply := graph.NewMessage(llms.TextParts(llms.ChatMessageTypeAI, "It is sunny."))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				merged, err := graph.AddMessages(s.Messages, []graph.Message{reply})
+				if err != nil || len(merged) != n+1 {
+					b.Fatalf("unexpected merge: %d messages, %v", len(merged), err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("MarshalJSON/%d", n), func(b *testing.B) {
+			s := conversation(n)
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := json.Marshal(s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func message(id, text string) graph.Message {
+	return graph.Message{MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, text), ID: id}
+}
+
+func TestAddMessages(t *testing.T) {
+	t.Parallel()
+
+	existing := []graph.Message{message("1", "one"), message("2", "two"), message("3", "three")}
+
+	testCases := []struct {
+		name    string
+		update  []graph.Message
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "Append",
+			update: []graph.Message{message("4", "four")},
+			want:   []string{"1:one", "2:two", "3:three", "4:four"},
+		},
+		{
+			name:   "Replace by ID",
+			update: []graph.Message{message("2", "deux")},
+			want:   []string{"1:one", "2:deux", "3:three"},
+		},
+		{
+			name:   "Remove by ID",
+			update: []graph.Message{graph.RemoveMessage("1"), graph.RemoveMessage("3")},
+			want:   []string{"2:two"},
+		},
+		{
+			name:   "Remove then add back",
+			update: []graph.Message{graph.RemoveMessage("1"), message("1", "uno")},
+			want:   []string{"2:two", "3:three", "1:uno"},
+		},
+		{
+			name:   "Remove all",
+			update: []graph.Message{message("4", "four"), graph.RemoveMessage(graph.RemoveAllMessages), message("5", "five")},
+			want:   []string{"5:five"},
+		},
+		{
+			name:    "Remove unknown message",
+			update:  []graph.Message{graph.RemoveMessage("9")},
+			wantErr: graph.ErrMessageNotFound,
+		},
+		{
+			name:    "Remove twice",
+			update:  []graph.Message{graph.RemoveMessage("1"), graph.RemoveMessage("1")},
+			wantErr: graph.ErrMessageNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			merged, err := graph.AddMessages(existing, tc.update)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			var got []string
+			for _, m := range merged {
+				got = append(got, m.ID+":"+m.Parts[0].(llms.TextContent).Text)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected messages %q, but got %q", tc.want, got)
+			}
+			if existing[0].ID != "1" || len(existing) != 3 {
+				t.Errorf("existing messages were modified: %v", existing)
+			}
+		})
+	}
+}
+
+func TestMessageStateAssignsIDs(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	if err := state.AddMessages(graph.Message{MessageContent: llms.TextParts(llms.ChatMessageTypeAI, "hello")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, last := state.Messages[0].ID, state.LastMessage().ID
+	if first == "" || last == "" || first == last {
+		t.Errorf("expected distinct message IDs, but got %q and %q", first, last)
+	}
+	if err := state.AddMessages(graph.RemoveMessage(first)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(state.Messages) != 1 || state.Messages[0].ID != last {
+		t.Errorf("expected only message %q to remain, but got %v", last, state.Messages)
+	}
+}
+
+func TestMessageMetadata(t *testing.T) {
+	t.Parallel()
+
+	before := time.Now()
+	msg := graph.NewMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	createdAt, ok := msg.CreatedAt()
+	if !ok || createdAt.Before(before.Add(-time.Second)) || createdAt.After(time.Now()) {
+		t.Errorf("unexpected creation time %v", createdAt)
+	}
+
+	named := msg.WithMetadata(graph.MetadataName, "ada").WithMetadata("channel", "web")
+	if named.Name() != "ada" || named.Metadata["channel"] != "web" {
+		t.Errorf("unexpected metadata %v", named.Metadata)
+	}
+	if msg.Name() != "" || len(msg.Metadata) != 1 {
+		t.Errorf("WithMetadata modified the original message: %v", msg.Metadata)
+	}
+
+	state := graph.MessageState{Messages: []graph.Message{named}}
+	clone := state.Clone()
+	clone.Messages[0].Metadata["channel"] = "cli"
+	if state.Messages[0].Metadata["channel"] != "web" {
+		t.Errorf("Clone shares metadata with the original state")
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	var decoded graph.MessageState
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, state) {
+		t.Errorf("expected %+v, but got %+v", state, decoded)
+	}
+}
+
+func TestAddMessagesDedupContent(t *testing.T) {
+	t.Parallel()
+
+	existing := []graph.Message{message("1", "one"), message("2", "two")}
+
+	testCases := []struct {
+		name   string
+		update []graph.Message
+		opts   []graph.AddMessagesOption
+		want   []string
+	}{
+		{
+			name:   "Same content kept without option",
+			update: []graph.Message{message("3", "two")},
+			want:   []string{"1:one", "2:two", "3:two"},
+		},
+		{
+			name:   "Same content dropped",
+			update: []graph.Message{message("3", "two"), message("4", "four"), message("5", "four")},
+			opts:   []graph.AddMessagesOption{graph.DedupContent()},
+			want:   []string{"1:one", "2:two", "4:four"},
+		},
+		{
+			name:   "Other role kept",
+			update: []graph.Message{{MessageContent: llms.TextParts(llms.ChatMessageTypeAI, "two"), ID: "3"}},
+			opts:   []graph.AddMessagesOption{graph.DedupContent()},
+			want:   []string{"1:one", "2:two", "3:two"},
+		},
+		{
+			name:   "Replace by ID",
+			update: []graph.Message{message("2", "one"), message("3", "two")},
+			opts:   []graph.AddMessagesOption{graph.DedupContent()},
+			want:   []string{"1:one", "2:one", "3:two"},
+		},
+		{
+			name:   "Removed content added back",
+			update: []graph.Message{graph.RemoveMessage("1"), message("3", "one")},
+			opts:   []graph.AddMessagesOption{graph.DedupContent()},
+			want:   []string{"2:two", "3:one"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			merged, err := graph.AddMessages(existing, tc.update, tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, m := range merged {
+				got = append(got, m.ID+":"+m.Parts[0].(llms.TextContent).Text)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected messages %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMessageStateMerge(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "question"))
+
+	left, right := state.Clone(), state.Clone()
+	left.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "left"))
+	left.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "done"))
+	right.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "right"))
+	right.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "done"))
+
+	for _, branch := range []graph.MessageState{left, right} {
+		if err := state.Merge(branch, graph.DedupContent()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := []string{"question", "left", "done", "right"}
+	if got := texts(state); !slices.Equal(got, want) {
+		t.Errorf("expected messages %q, but got %q", want, got)
+	}
+}
@@ -0,0 +1,271 @@
+package messagestate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// AnthropicMessage is a message of the Anthropic messages API.
+type AnthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []AnthropicContentBlock `json:"content"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting content given as a
+// plain string.
+func (m *AnthropicMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	content, err := anthropicBlocks(raw.Content)
+	if err != nil {
+		return err
+	}
+	*m = AnthropicMessage{Role: raw.Role, Content: content}
+	return nil
+}
+
+// AnthropicContentBlock is a content block of an AnthropicMessage.
+type AnthropicContentBlock struct {
+	Type string `json:"type"`
+
+	// Text is set for "text" blocks.
+	Text string `json:"text,omitempty"`
+
+	// Source is set for "image" blocks.
+	Source *AnthropicImageSource `json:"source,omitempty"`
+
+	// ID, Name and Input are set for "tool_use" blocks.
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// ToolUseID, Content and IsError are set for "tool_result" blocks.
+	ToolUseID string                  `json:"tool_use_id,omitempty"`
+	Content   []AnthropicContentBlock `json:"content,omitempty"`
+	IsError   bool                    `json:"is_error,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting tool result content
+// given as a plain string.
+func (b *AnthropicContentBlock) UnmarshalJSON(data []byte) error {
+	type block AnthropicContentBlock
+	var raw struct {
+		block
+		Content json.RawMessage `json:"content,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	content, err := anthropicBlocks(raw.Content)
+	if err != nil {
+		return err
+	}
+	*b = AnthropicContentBlock(raw.block)
+	b.Content = content
+	return nil
+}
+
+// anthropicBlocks decodes content given either as blocks or as a string.
+func anthropicBlocks(data json.RawMessage) ([]AnthropicContentBlock, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	if data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		return []AnthropicContentBlock{{Type: "text", Text: text}}, nil
+	}
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// AnthropicImageSource is the source of an "image" block.
+type AnthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// ToAnthropic converts messages to the Anthropic messages format, returning
+// the system prompt separately as the API expects.
+//
+// System messages are joined into the system prompt, tool results are sent
+// in user messages, and consecutive messages of the same role are merged
+// since the API requires alternating roles.
+func ToAnthropic(msgs []graph.Message) (string, []AnthropicMessage, error) {
+	var system []string
+	out := make([]AnthropicMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		var role string
+		switch msg.Role {
+		case llms.ChatMessageTypeSystem:
+			for _, part := range msg.Parts {
+				p, ok := part.(llms.TextContent)
+				if !ok {
+					return "", nil, fmt.Errorf("%w: %T in %s message", ErrUnsupportedPart, part, msg.Role)
+				}
+				system = append(system, p.Text)
+			}
+			continue
+		case llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric, llms.ChatMessageTypeTool:
+			role = "user"
+		case llms.ChatMessageTypeAI:
+			role = "assistant"
+		default:
+			return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedRole, msg.Role)
+		}
+
+		blocks := make([]AnthropicContentBlock, 0, len(msg.Parts))
+		for _, part := range msg.Parts {
+			block, err := anthropicBlock(part)
+			if err != nil {
+				return "", nil, fmt.Errorf("%w in %s message", err, msg.Role)
+			}
+			blocks = append(blocks, block)
+		}
+
+		if last := len(out) - 1; last >= 0 && out[last].Role == role {
+			out[last].Content = append(out[last].Content, blocks...)
+			continue
+		}
+		out = append(out, AnthropicMessage{Role: role, Content: blocks})
+	}
+	return strings.Join(system, "\n\n"), out, nil
+}
+
+func anthropicBlock(part llms.ContentPart) (AnthropicContentBlock, error) {
+	switch p := part.(type) {
+	case llms.TextContent:
+		return AnthropicContentBlock{Type: "text", Text: p.Text}, nil
+	case llms.ImageURLContent:
+		return AnthropicContentBlock{Type: "image", Source: &AnthropicImageSource{Type: "url", URL: p.URL}}, nil
+	case llms.BinaryContent:
+		return AnthropicContentBlock{Type: "image", Source: &AnthropicImageSource{
+			Type:      "base64",
+			MediaType: p.MIMEType,
+			Data:      base64.StdEncoding.EncodeToString(p.Data),
+		}}, nil
+	case llms.ToolCall:
+		block := AnthropicContentBlock{Type: "tool_use", ID: p.ID, Input: json.RawMessage("{}")}
+		if p.FunctionCall != nil {
+			block.Name = p.FunctionCall.Name
+			if args := strings.TrimSpace(p.FunctionCall.Arguments); args != "" {
+				if !json.Valid([]byte(args)) {
+					return AnthropicContentBlock{}, fmt.Errorf("invalid arguments for tool call %s", p.ID)
+				}
+				block.Input = json.RawMessage(args)
+			}
+		}
+		return block, nil
+	case llms.ToolCallResponse:
+		return AnthropicContentBlock{
+			Type:      "tool_result",
+			ToolUseID: p.ToolCallID,
+			Content:   []AnthropicContentBlock{{Type: "text", Text: p.Content}},
+		}, nil
+	default:
+		return AnthropicContentBlock{}, fmt.Errorf("%w: %T", ErrUnsupportedPart, part)
+	}
+}
+
+// FromAnthropic converts a system prompt and messages of the Anthropic
+// messages format. Tool results become tool messages, named after the tool
+// use they answer. Every message gets a new ID.
+func FromAnthropic(system string, msgs []AnthropicMessage) ([]graph.Message, error) {
+	out := make([]graph.Message, 0, len(msgs)+1)
+	if system != "" {
+		out = append(out, graph.NewMessage(llms.TextParts(llms.ChatMessageTypeSystem, system)))
+	}
+
+	toolNames := make(map[string]string)
+	for _, am := range msgs {
+		var role llms.ChatMessageType
+		switch am.Role {
+		case "user":
+			role = llms.ChatMessageTypeHuman
+		case "assistant":
+			role = llms.ChatMessageTypeAI
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRole, am.Role)
+		}
+
+		// A user message is split into runs of tool results and other blocks.
+		var current *llms.MessageContent
+		flush := func() {
+			if current != nil {
+				out = append(out, graph.NewMessage(*current))
+				current = nil
+			}
+		}
+		for _, block := range am.Content {
+			part, err := fromAnthropicBlock(block, toolNames)
+			if err != nil {
+				return nil, err
+			}
+			partRole := role
+			if block.Type == "tool_result" {
+				partRole = llms.ChatMessageTypeTool
+			}
+			if current == nil || current.Role != partRole {
+				flush()
+				current = &llms.MessageContent{Role: partRole}
+			}
+			current.Parts = append(current.Parts, part)
+		}
+		flush()
+	}
+	return out, nil
+}
+
+func fromAnthropicBlock(block AnthropicContentBlock, toolNames map[string]string) (llms.ContentPart, error) {
+	switch block.Type {
+	case "text":
+		return llms.TextContent{Text: block.Text}, nil
+	case "image":
+		if block.Source == nil {
+			break
+		}
+		switch block.Source.Type {
+		case "base64":
+			data, err := base64.StdEncoding.DecodeString(block.Source.Data)
+			if err != nil {
+				return nil, fmt.Errorf("decode image: %w", err)
+			}
+			return llms.BinaryContent{MIMEType: block.Source.MediaType, Data: data}, nil
+		case "url":
+			return llms.ImageURLContent{URL: block.Source.URL}, nil
+		}
+	case "tool_use":
+		toolNames[block.ID] = block.Name
+		args := string(block.Input)
+		if args == "" {
+			args = "{}"
+		}
+		return llms.ToolCall{ID: block.ID, Type: "function", FunctionCall: &llms.FunctionCall{Name: block.Name, Arguments: args}}, nil
+	case "tool_result":
+		var text strings.Builder
+		for _, b := range block.Content {
+			if b.Type == "text" {
+				text.WriteString(b.Text)
+			}
+		}
+		return llms.ToolCallResponse{ToolCallID: block.ToolUseID, Name: toolNames[block.ToolUseID], Content: text.String()}, nil
+	}
+	return nil, fmt.Errorf("%w: %s block", ErrUnsupportedPart, block.Type)
+}
@@ -0,0 +1,107 @@
+package messagestate
+
+import (
+	"slices"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Predicate reports whether a message matches.
+type Predicate func(msg graph.Message) bool
+
+// Filter returns the messages that match keep.
+func Filter(msgs []graph.Message, keep Predicate) []graph.Message {
+	filtered := make([]graph.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if keep(msg) {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
+
+// Exclude returns the messages that do not match drop.
+func Exclude(msgs []graph.Message, drop Predicate) []graph.Message {
+	return Filter(msgs, Not(drop))
+}
+
+// SinceLast returns the messages after the last message of role, or all the
+// messages if there is none.
+func SinceLast(msgs []graph.Message, role llms.ChatMessageType) []graph.Message {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == role {
+			return slices.Clone(msgs[i+1:])
+		}
+	}
+	return slices.Clone(msgs)
+}
+
+// ByRole matches messages with one of the given roles.
+func ByRole(roles ...llms.ChatMessageType) Predicate {
+	return func(msg graph.Message) bool {
+		return slices.Contains(roles, msg.Role)
+	}
+}
+
+// ByName matches tool messages answering, and AI messages calling, one of the
+// given tools.
+func ByName(names ...string) Predicate {
+	return func(msg graph.Message) bool {
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case llms.ToolCall:
+				if p.FunctionCall != nil && slices.Contains(names, p.FunctionCall.Name) {
+					return true
+				}
+			case llms.ToolCallResponse:
+				if slices.Contains(names, p.Name) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// ByID matches messages with one of the given IDs.
+func ByID(ids ...string) Predicate {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return func(msg graph.Message) bool {
+		return set[msg.ID]
+	}
+}
+
+// Not matches the messages p does not match.
+func Not(p Predicate) Predicate {
+	return func(msg graph.Message) bool {
+		return !p(msg)
+	}
+}
+
+// All matches the messages matched by all of ps.
+func All(ps ...Predicate) Predicate {
+	return func(msg graph.Message) bool {
+		for _, p := range ps {
+			if !p(msg) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches the messages matched by any of ps.
+func Any(ps ...Predicate) Predicate {
+	return func(msg graph.Message) bool {
+		for _, p := range ps {
+			if p(msg) {
+				return true
+			}
+		}
+		return false
+	}
+}
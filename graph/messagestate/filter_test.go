@@ -0,0 +1,108 @@
+package messagestate_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func toolCall(name string) graph.Message {
+	return graph.Message{ID: "call-" + name, MessageContent: llms.MessageContent{
+		Role:  llms.ChatMessageTypeAI,
+		Parts: []llms.ContentPart{llms.ToolCall{ID: name, FunctionCall: &llms.FunctionCall{Name: name}}},
+	}}
+}
+
+func toolResult(name string) graph.Message {
+	return graph.Message{ID: "result-" + name, MessageContent: llms.MessageContent{
+		Role:  llms.ChatMessageTypeTool,
+		Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: name, Name: name}},
+	}}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	conversation := []graph.Message{
+		{ID: "h1", MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "h1")},
+		toolCall("search"),
+		toolResult("search"),
+		{ID: "h2", MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "h2")},
+		toolCall("calc"),
+		toolResult("calc"),
+		toolResult("search"),
+	}
+
+	testCases := []struct {
+		name string
+		msgs []graph.Message
+		keep messagestate.Predicate
+		want []string
+	}{
+		{
+			name: "By role",
+			msgs: conversation,
+			keep: messagestate.ByRole(llms.ChatMessageTypeHuman),
+			want: []string{"h1", "h2"},
+		},
+		{
+			name: "By name",
+			msgs: conversation,
+			keep: messagestate.ByName("calc"),
+			want: []string{"call-calc", "result-calc"},
+		},
+		{
+			name: "By ID",
+			msgs: conversation,
+			keep: messagestate.ByID("h2", "call-search"),
+			want: []string{"call-search", "h2"},
+		},
+		{
+			name: "Tool results since the last human turn",
+			msgs: messagestate.SinceLast(conversation, llms.ChatMessageTypeHuman),
+			keep: messagestate.ByRole(llms.ChatMessageTypeTool),
+			want: []string{"result-calc", "result-search"},
+		},
+		{
+			name: "Combined",
+			msgs: conversation,
+			keep: messagestate.All(messagestate.ByName("search"), messagestate.Not(messagestate.ByRole(llms.ChatMessageTypeAI))),
+			want: []string{"result-search", "result-search"},
+		},
+		{
+			name: "Custom predicate",
+			msgs: conversation,
+			keep: messagestate.Any(messagestate.ByID("h1"), func(m graph.Message) bool { return len(m.ID) > 11 }),
+			want: []string{"h1", "result-search", "result-search"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var got []string
+			for _, m := range messagestate.Filter(tc.msgs, tc.keep) {
+				got = append(got, m.ID)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestExclude(t *testing.T) {
+	t.Parallel()
+
+	msgs := []graph.Message{toolCall("a"), toolResult("a"), toolCall("b")}
+	got := messagestate.Exclude(msgs, messagestate.ByName("a"))
+	if len(got) != 1 || got[0].ID != "call-b" {
+		t.Errorf("expected only call-b, but got %v", got)
+	}
+	if len(msgs) != 3 || msgs[0].ID != "call-a" {
+		t.Errorf("input was modified: %v", msgs)
+	}
+}
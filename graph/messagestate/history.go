@@ -0,0 +1,141 @@
+package messagestate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// ToChatMessages converts messages to langchaingo chat messages, as stored by
+// a schema.ChatMessageHistory. Messages may only hold text, tool calls and
+// tool results; tool messages holding several results are split into one
+// message per result.
+func ToChatMessages(msgs []graph.Message) ([]llms.ChatMessage, error) {
+	out := make([]llms.ChatMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Role == llms.ChatMessageTypeTool {
+			for _, part := range msg.Parts {
+				p, ok := part.(llms.ToolCallResponse)
+				if !ok {
+					return nil, fmt.Errorf("%w: %T in %s message", ErrUnsupportedPart, part, msg.Role)
+				}
+				out = append(out, llms.ToolChatMessage{ID: p.ToolCallID, Content: p.Content})
+			}
+			continue
+		}
+
+		var text strings.Builder
+		var calls []llms.ToolCall
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				text.WriteString(p.Text)
+			case llms.ToolCall:
+				if msg.Role == llms.ChatMessageTypeAI {
+					calls = append(calls, p)
+					continue
+				}
+				return nil, fmt.Errorf("%w: %T in %s message", ErrUnsupportedPart, part, msg.Role)
+			default:
+				return nil, fmt.Errorf("%w: %T in %s message", ErrUnsupportedPart, part, msg.Role)
+			}
+		}
+
+		switch msg.Role {
+		case llms.ChatMessageTypeSystem:
+			out = append(out, llms.SystemChatMessage{Content: text.String()})
+		case llms.ChatMessageTypeHuman:
+			out = append(out, llms.HumanChatMessage{Content: text.String()})
+		case llms.ChatMessageTypeAI:
+			out = append(out, llms.AIChatMessage{Content: text.String(), ToolCalls: calls})
+		case llms.ChatMessageTypeGeneric:
+			out = append(out, llms.GenericChatMessage{Content: text.String(), Name: msg.Name()})
+		case llms.ChatMessageTypeFunction:
+			out = append(out, llms.FunctionChatMessage{Content: text.String(), Name: msg.Name()})
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRole, msg.Role)
+		}
+	}
+	return out, nil
+}
+
+// FromChatMessages converts langchaingo chat messages, as stored by a
+// schema.ChatMessageHistory. Tool results are named after the tool call they
+// answer, and author names are kept in the metadata. Every message gets a
+// new ID.
+func FromChatMessages(msgs []llms.ChatMessage) ([]graph.Message, error) {
+	out := make([]graph.Message, 0, len(msgs))
+	toolNames := make(map[string]string)
+	for _, cm := range msgs {
+		content := llms.MessageContent{Role: cm.GetType()}
+		if text := cm.GetContent(); text != "" {
+			content.Parts = append(content.Parts, llms.TextContent{Text: text})
+		}
+
+		var name string
+		switch m := cm.(type) {
+		case llms.AIChatMessage:
+			for _, call := range m.ToolCalls {
+				if call.FunctionCall != nil {
+					toolNames[call.ID] = call.FunctionCall.Name
+				}
+				content.Parts = append(content.Parts, call)
+			}
+		case llms.ToolChatMessage:
+			content.Parts = []llms.ContentPart{llms.ToolCallResponse{
+				ToolCallID: m.ID,
+				Name:       toolNames[m.ID],
+				Content:    m.Content,
+			}}
+			out = append(out, graph.NewMessage(content).WithMetadata(graph.MetadataToolCallID, m.ID))
+			continue
+		case llms.Named:
+			name = m.GetName()
+		}
+
+		switch content.Role {
+		case llms.ChatMessageTypeSystem, llms.ChatMessageTypeHuman, llms.ChatMessageTypeAI,
+			llms.ChatMessageTypeGeneric, llms.ChatMessageTypeFunction:
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRole, content.Role)
+		}
+		msg := graph.NewMessage(content)
+		if name != "" {
+			msg = msg.WithMetadata(graph.MetadataName, name)
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+// LoadHistory adds the messages of a langchaingo chat history, such as the
+// ChatHistory of a memory.ConversationBuffer, to state, so that an existing
+// conversation can continue in a graph thread.
+func LoadHistory(ctx context.Context, history schema.ChatMessageHistory, state *graph.MessageState) error {
+	chatMessages, err := history.Messages(ctx)
+	if err != nil {
+		return fmt.Errorf("load chat history: %w", err)
+	}
+	msgs, err := FromChatMessages(chatMessages)
+	if err != nil {
+		return err
+	}
+	return state.AddMessages(msgs...)
+}
+
+// SaveHistory replaces the messages of a langchaingo chat history with msgs,
+// typically the messages of a MessageState after a run.
+func SaveHistory(ctx context.Context, history schema.ChatMessageHistory, msgs []graph.Message) error {
+	chatMessages, err := ToChatMessages(msgs)
+	if err != nil {
+		return err
+	}
+	if err := history.SetMessages(ctx, chatMessages); err != nil {
+		return fmt.Errorf("save chat history: %w", err)
+	}
+	return nil
+}
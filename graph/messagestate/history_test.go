@@ -0,0 +1,103 @@
+package messagestate_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+)
+
+func TestChatHistoryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	call := llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}}
+	history := memory.NewChatMessageHistory(memory.WithPreviousMessages([]llms.ChatMessage{
+		llms.SystemChatMessage{Content: "Be helpful."},
+		llms.HumanChatMessage{Content: "Weather in Paris?"},
+		llms.AIChatMessage{ToolCalls: []llms.ToolCall{call}},
+		llms.ToolChatMessage{ID: "call-1", Content: "sunny"},
+		llms.GenericChatMessage{Content: "Noted.", Name: "observer"},
+		llms.AIChatMessage{Content: "It is sunny."},
+	}))
+
+	state := graph.NewMessageState()
+	if err := messagestate.LoadHistory(ctx, history, &state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Be helpful."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris?"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call-1", Name: "weather", Content: "sunny"}}},
+		llms.TextParts(llms.ChatMessageTypeGeneric, "Noted."),
+		llms.TextParts(llms.ChatMessageTypeAI, "It is sunny."),
+	}
+	if got := state.Contents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected contents %v, but got %v", want, got)
+	}
+	if got := state.Messages[3].ToolCallID(); got != "call-1" {
+		t.Errorf("expected tool call ID %q, but got %q", "call-1", got)
+	}
+	if got := state.Messages[4].Name(); got != "observer" {
+		t.Errorf("expected name %q, but got %q", "observer", got)
+	}
+
+	original, err := history.Messages(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	saved := memory.NewChatMessageHistory()
+	if err := messagestate.SaveHistory(ctx, saved, state.Messages); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := saved.Messages(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, original) {
+		t.Errorf("expected saved messages %v, but got %v", original, got)
+	}
+}
+
+func TestToChatMessagesErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		msg     llms.MessageContent
+		wantErr error
+	}{
+		{
+			name:    "Image",
+			msg:     llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{llms.ImageURLContent{URL: "https://example.com/cat.png"}}},
+			wantErr: messagestate.ErrUnsupportedPart,
+		},
+		{
+			name:    "Human tool call",
+			msg:     llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{llms.ToolCall{ID: "call-1"}}},
+			wantErr: messagestate.ErrUnsupportedPart,
+		},
+		{
+			name:    "Unknown role",
+			msg:     llms.TextParts("narrator", "Once upon a time"),
+			wantErr: messagestate.ErrUnsupportedRole,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := messagestate.ToChatMessages([]graph.Message{graph.NewMessage(tc.msg)})
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, but got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package messagestate
+
+import (
+	"slices"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// MergeConsecutive merges adjacent messages of the same role into one,
+// concatenating their parts, for providers that reject back-to-back messages
+// of a role. A merged message keeps the ID of the first message of its run.
+//
+// Tool messages are never merged, since each of them answers a single tool
+// call.
+func MergeConsecutive(msgs []graph.Message) []graph.Message {
+	merged := make([]graph.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		last := len(merged) - 1
+		if last < 0 || merged[last].Role != msg.Role || msg.Role == llms.ChatMessageTypeTool {
+			merged = append(merged, msg)
+			continue
+		}
+		// Clip the parts so that appending never writes to the input.
+		parts := slices.Clip(merged[last].Parts)
+		merged[last].Parts = append(parts, msg.Parts...)
+	}
+	return merged
+}
@@ -0,0 +1,38 @@
+package messagestate_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestMergeConsecutive(t *testing.T) {
+	t.Parallel()
+
+	msgs := []graph.Message{
+		{ID: "1", MessageContent: llms.TextParts(llms.ChatMessageTypeSystem, "s1")},
+		{ID: "2", MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "h1")},
+		{ID: "3", MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "h2", "h3")},
+		{ID: "4", MessageContent: llms.TextParts(llms.ChatMessageTypeAI, "a1")},
+		toolResult("x"),
+		toolResult("y"),
+		{ID: "5", MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "h4")},
+		{ID: "6", MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "h5")},
+	}
+
+	var got []string
+	for _, m := range messagestate.MergeConsecutive(msgs) {
+		got = append(got, fmt.Sprintf("%s %s %d", m.ID, m.Role, len(m.Parts)))
+	}
+	want := []string{"1 system 1", "2 human 3", "4 ai 1", "result-x tool 1", "result-y tool 1", "5 human 2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %q, but got %q", want, got)
+	}
+	if len(msgs[1].Parts) != 1 || len(msgs[6].Parts) != 1 {
+		t.Errorf("input messages were modified: %v", msgs)
+	}
+}
@@ -0,0 +1,118 @@
+package messagestate
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// MaxBinarySize is the maximum size, in bytes, of the data of binary parts.
+const MaxBinarySize = 20 << 20
+
+var (
+	// ErrInvalidURL is returned when building an image part from a URL that is
+	// neither an http(s) nor a data URL.
+	ErrInvalidURL = errors.New("invalid URL")
+
+	// ErrInvalidMIMEType is returned when building a binary part whose MIME
+	// type is invalid or not of the expected kind.
+	ErrInvalidMIMEType = errors.New("invalid MIME type")
+
+	// ErrEmptyContent is returned when building a binary part without data.
+	ErrEmptyContent = errors.New("empty content")
+
+	// ErrContentTooLarge is returned when building a binary part with more than
+	// MaxBinarySize bytes of data.
+	ErrContentTooLarge = errors.New("content too large")
+)
+
+// imageDetails are the detail levels accepted by ImageURL.
+var imageDetails = []string{"", "auto", "low", "high"}
+
+// ImageURL returns an image part referencing url, an http(s) or data URL.
+// detail is the resolution at which the model sees the image: "auto", "low",
+// "high", or empty for the model default.
+func ImageURL(rawURL, detail string) (llms.ContentPart, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("%w: %s has no host", ErrInvalidURL, rawURL)
+		}
+	case "data":
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidURL, u.Scheme)
+	}
+	valid := false
+	for _, d := range imageDetails {
+		valid = valid || d == detail
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid image detail %q", detail)
+	}
+	return llms.ImageURLContent{URL: rawURL, Detail: detail}, nil
+}
+
+// Binary returns a binary part holding data. An empty mimeType is detected
+// from the data.
+func Binary(mimeType string, data []byte) (llms.ContentPart, error) {
+	return binary("", mimeType, data)
+}
+
+// Image returns a binary part holding image data, such as a PNG or JPEG file.
+// An empty mimeType is detected from the data.
+func Image(mimeType string, data []byte) (llms.ContentPart, error) {
+	return binary("image", mimeType, data)
+}
+
+// Audio returns a binary part holding audio data, such as a WAV or MP3 file.
+// An empty mimeType is detected from the data.
+func Audio(mimeType string, data []byte) (llms.ContentPart, error) {
+	return binary("audio", mimeType, data)
+}
+
+// binary returns a binary part of the given kind of media, or of any kind if
+// kind is empty.
+func binary(kind, mimeType string, data []byte) (llms.ContentPart, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyContent
+	}
+	if len(data) > MaxBinarySize {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d", ErrContentTooLarge, len(data), MaxBinarySize)
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMIMEType, mimeType)
+	}
+	if kind != "" && !strings.HasPrefix(mediaType, kind+"/") {
+		return nil, fmt.Errorf("%w: %q is not %s", ErrInvalidMIMEType, mimeType, kind)
+	}
+	return llms.BinaryContent{MIMEType: mediaType, Data: data}, nil
+}
+
+// Multimodal returns a new message of role with text followed by parts, as
+// built by ImageURL, Image, Audio or Binary. Empty text is left out.
+//
+//	img, err := messagestate.Image("", data)
+//	...
+//	err = state.AddMessages(messagestate.Multimodal(llms.ChatMessageTypeHuman, "What is this?", img))
+func Multimodal(role llms.ChatMessageType, text string, parts ...llms.ContentPart) graph.Message {
+	content := llms.MessageContent{Role: role, Parts: make([]llms.ContentPart, 0, len(parts)+1)}
+	if text != "" {
+		content.Parts = append(content.Parts, llms.TextContent{Text: text})
+	}
+	content.Parts = append(content.Parts, parts...)
+	return graph.NewMessage(content)
+}
@@ -0,0 +1,107 @@
+package messagestate_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestImageURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		url     string
+		detail  string
+		wantErr bool
+	}{
+		{name: "HTTPS", url: "https://example.com/cat.png", detail: "high"},
+		{name: "Data URL", url: "data:image/png;base64,cG5n"},
+		{name: "Relative URL", url: "cat.png", wantErr: true},
+		{name: "Missing host", url: "https:///cat.png", wantErr: true},
+		{name: "File URL", url: "file:///tmp/cat.png", wantErr: true},
+		{name: "Invalid detail", url: "https://example.com/cat.png", detail: "max", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			part, err := messagestate.ImageURL(tc.url, tc.detail)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, but got part %v", part)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := llms.ImageURLContent{URL: tc.url, Detail: tc.detail}
+			if part != want {
+				t.Errorf("expected part %v, but got %v", want, part)
+			}
+		})
+	}
+}
+
+func TestBinaryParts(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\n rest of the image")
+	wav := []byte("RIFF\x00\x00\x00\x00WAVEfmt rest of the audio")
+
+	testCases := []struct {
+		name     string
+		build    func(string, []byte) (llms.ContentPart, error)
+		mimeType string
+		data     []byte
+		want     string
+		wantErr  error
+	}{
+		{name: "Image", build: messagestate.Image, mimeType: "image/png", data: png, want: "image/png"},
+		{name: "Detected image", build: messagestate.Image, data: png, want: "image/png"},
+		{name: "Detected audio", build: messagestate.Audio, data: wav, want: "audio/wave"},
+		{name: "Parameters dropped", build: messagestate.Binary, mimeType: "text/plain; charset=utf-8", data: []byte("hi"), want: "text/plain"},
+		{name: "Wrong kind", build: messagestate.Audio, mimeType: "image/png", data: png, wantErr: messagestate.ErrInvalidMIMEType},
+		{name: "Wrong detected kind", build: messagestate.Image, data: wav, wantErr: messagestate.ErrInvalidMIMEType},
+		{name: "Invalid MIME type", build: messagestate.Binary, mimeType: "image/", data: png, wantErr: messagestate.ErrInvalidMIMEType},
+		{name: "Empty", build: messagestate.Image, mimeType: "image/png", wantErr: messagestate.ErrEmptyContent},
+		{name: "Too large", build: messagestate.Binary, mimeType: "application/pdf", data: make([]byte, messagestate.MaxBinarySize+1), wantErr: messagestate.ErrContentTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			part, err := tc.build(tc.mimeType, tc.data)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			want := llms.BinaryContent{MIMEType: tc.want, Data: tc.data}
+			if !reflect.DeepEqual(part, want) {
+				t.Errorf("expected part of type %q, but got %v", tc.want, part)
+			}
+		})
+	}
+}
+
+func TestMultimodal(t *testing.T) {
+	t.Parallel()
+
+	img := llms.ImageURLContent{URL: "https://example.com/cat.png"}
+	msg := messagestate.Multimodal(llms.ChatMessageTypeHuman, "What is this?", img)
+	want := []llms.ContentPart{llms.TextContent{Text: "What is this?"}, img}
+	if msg.ID == "" || msg.Role != llms.ChatMessageTypeHuman || !reflect.DeepEqual(msg.Parts, want) {
+		t.Errorf("expected a human message with parts %v, but got %+v", want, msg)
+	}
+
+	msg = messagestate.Multimodal(llms.ChatMessageTypeHuman, "", img)
+	if !reflect.DeepEqual(msg.Parts, want[1:]) {
+		t.Errorf("expected parts %v, but got %v", want[1:], msg.Parts)
+	}
+}
@@ -0,0 +1,240 @@
+package messagestate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+var (
+	// ErrUnsupportedRole is returned when converting a message whose role has
+	// no equivalent in the target format.
+	ErrUnsupportedRole = errors.New("unsupported message role")
+
+	// ErrUnsupportedPart is returned when converting a message part that has
+	// no equivalent in the target format.
+	ErrUnsupportedPart = errors.New("unsupported message part")
+)
+
+// OpenAIMessage is a message of the OpenAI chat completions API.
+type OpenAIMessage struct {
+	Role       string           `json:"role"`
+	Content    OpenAIContent    `json:"content"`
+	Name       string           `json:"name,omitempty"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIContent is the content of an OpenAIMessage: either a plain string or
+// a list of parts. It is null for assistant messages with only tool calls.
+type OpenAIContent struct {
+	Text  string
+	Parts []OpenAIContentPart
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c OpenAIContent) MarshalJSON() ([]byte, error) {
+	switch {
+	case c.Parts != nil:
+		return json.Marshal(c.Parts)
+	case c.Text != "":
+		return json.Marshal(c.Text)
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *OpenAIContent) UnmarshalJSON(data []byte) error {
+	*c = OpenAIContent{}
+	switch {
+	case string(data) == "null":
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		return json.Unmarshal(data, &c.Parts)
+	default:
+		return json.Unmarshal(data, &c.Text)
+	}
+}
+
+// OpenAIContentPart is a part of the content of an OpenAIMessage.
+type OpenAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *OpenAIImageURL `json:"image_url,omitempty"`
+}
+
+// OpenAIImageURL is the image of an "image_url" content part.
+type OpenAIImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// OpenAIToolCall is a tool call of an assistant message.
+type OpenAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function OpenAIFunctionCall `json:"function"`
+}
+
+// OpenAIFunctionCall is the function called by an OpenAIToolCall.
+type OpenAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToOpenAI converts messages to the OpenAI chat completions format.
+//
+// Binary parts are sent as base64 data URLs, and tool messages holding
+// several results are split into one message per result.
+func ToOpenAI(msgs []graph.Message) ([]OpenAIMessage, error) {
+	out := make([]OpenAIMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		switch msg.Role {
+		case llms.ChatMessageTypeSystem, llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric:
+			content, err := openAIContent(msg.Parts)
+			if err != nil {
+				return nil, err
+			}
+			role := "user"
+			if msg.Role == llms.ChatMessageTypeSystem {
+				role = "system"
+			}
+			out = append(out, OpenAIMessage{Role: role, Content: content, Name: msg.Name()})
+
+		case llms.ChatMessageTypeAI:
+			om := OpenAIMessage{Role: "assistant", Name: msg.Name()}
+			var text strings.Builder
+			for _, part := range msg.Parts {
+				switch p := part.(type) {
+				case llms.TextContent:
+					text.WriteString(p.Text)
+				case llms.ToolCall:
+					call := OpenAIToolCall{ID: p.ID, Type: p.Type}
+					if call.Type == "" {
+						call.Type = "function"
+					}
+					if p.FunctionCall != nil {
+						call.Function = OpenAIFunctionCall(*p.FunctionCall)
+					}
+					om.ToolCalls = append(om.ToolCalls, call)
+				default:
+					return nil, fmt.Errorf("%w: %T in %s message", ErrUnsupportedPart, part, msg.Role)
+				}
+			}
+			om.Content.Text = text.String()
+			out = append(out, om)
+
+		case llms.ChatMessageTypeTool:
+			for _, part := range msg.Parts {
+				p, ok := part.(llms.ToolCallResponse)
+				if !ok {
+					return nil, fmt.Errorf("%w: %T in %s message", ErrUnsupportedPart, part, msg.Role)
+				}
+				out = append(out, OpenAIMessage{
+					Role:       "tool",
+					Content:    OpenAIContent{Text: p.Content},
+					Name:       p.Name,
+					ToolCallID: p.ToolCallID,
+				})
+			}
+
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRole, msg.Role)
+		}
+	}
+	return out, nil
+}
+
+func openAIContent(parts []llms.ContentPart) (OpenAIContent, error) {
+	if len(parts) == 1 {
+		if p, ok := parts[0].(llms.TextContent); ok {
+			return OpenAIContent{Text: p.Text}, nil
+		}
+	}
+	content := OpenAIContent{Parts: make([]OpenAIContentPart, 0, len(parts))}
+	for _, part := range parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			content.Parts = append(content.Parts, OpenAIContentPart{Type: "text", Text: p.Text})
+		case llms.ImageURLContent:
+			content.Parts = append(content.Parts, OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: p.URL, Detail: p.Detail}})
+		case llms.BinaryContent:
+			content.Parts = append(content.Parts, OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: p.String()}})
+		default:
+			return OpenAIContent{}, fmt.Errorf("%w: %T", ErrUnsupportedPart, part)
+		}
+	}
+	return content, nil
+}
+
+// FromOpenAI converts messages of the OpenAI chat completions format. Images
+// sent as base64 data URLs become binary parts, and author names are kept in
+// the metadata. Every message gets a new ID.
+func FromOpenAI(msgs []OpenAIMessage) ([]graph.Message, error) {
+	out := make([]graph.Message, 0, len(msgs))
+	for _, om := range msgs {
+		var content llms.MessageContent
+		switch om.Role {
+		case "system", "developer":
+			content.Role = llms.ChatMessageTypeSystem
+		case "user":
+			content.Role = llms.ChatMessageTypeHuman
+		case "assistant":
+			content.Role = llms.ChatMessageTypeAI
+		case "tool":
+			content.Role = llms.ChatMessageTypeTool
+			content.Parts = []llms.ContentPart{llms.ToolCallResponse{
+				ToolCallID: om.ToolCallID,
+				Name:       om.Name,
+				Content:    om.Content.Text,
+			}}
+			out = append(out, graph.NewMessage(content).WithMetadata(graph.MetadataToolCallID, om.ToolCallID))
+			continue
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRole, om.Role)
+		}
+
+		if om.Content.Text != "" {
+			content.Parts = append(content.Parts, llms.TextContent{Text: om.Content.Text})
+		}
+		for _, p := range om.Content.Parts {
+			switch {
+			case p.Type == "text":
+				content.Parts = append(content.Parts, llms.TextContent{Text: p.Text})
+			case p.Type == "image_url" && p.ImageURL != nil:
+				content.Parts = append(content.Parts, imagePart(p.ImageURL.URL, p.ImageURL.Detail))
+			default:
+				return nil, fmt.Errorf("%w: %s", ErrUnsupportedPart, p.Type)
+			}
+		}
+		for _, call := range om.ToolCalls {
+			fn := llms.FunctionCall(call.Function)
+			content.Parts = append(content.Parts, llms.ToolCall{ID: call.ID, Type: call.Type, FunctionCall: &fn})
+		}
+		msg := graph.NewMessage(content)
+		if om.Name != "" {
+			msg = msg.WithMetadata(graph.MetadataName, om.Name)
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+// imagePart returns a binary part for base64 data URLs and an image URL part
+// for other URLs.
+func imagePart(url, detail string) llms.ContentPart {
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		if mimeType, data, ok := strings.Cut(rest, ";base64,"); ok {
+			if decoded, err := base64.StdEncoding.DecodeString(data); err == nil {
+				return llms.BinaryContent{MIMEType: mimeType, Data: decoded}
+			}
+		}
+	}
+	return llms.ImageURLContent{URL: url, Detail: detail}
+}
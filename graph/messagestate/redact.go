@@ -0,0 +1,119 @@
+package messagestate
+
+import (
+	"regexp"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Replacements of the data scrubbed by RedactEmails and RedactCardNumbers.
+const (
+	RedactedEmail      = "[EMAIL]"
+	RedactedCardNumber = "[CARD NUMBER]"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+	// cardPattern matches 13 to 19 digits, optionally grouped by spaces or
+	// dashes; matches are then checked with the Luhn algorithm.
+	cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// Redactor is a graph.Redactor that scrubs the text of messages: text parts,
+// tool call arguments and tool results. It returns the text to store.
+//
+//	state.Redactor = messagestate.Redactors(
+//		messagestate.RedactEmails,
+//		messagestate.RedactCardNumbers,
+//		messagestate.RedactPattern(regexp.MustCompile(`\bACME-\d{6}\b`), "[ACCOUNT]"),
+//	)
+//
+// Any function can be used as a Redactor, such as one backed by a named
+// entity recognition model.
+type Redactor func(text string) string
+
+var _ graph.Redactor = Redactor(nil)
+
+// Redact implements graph.Redactor.
+func (r Redactor) Redact(msg graph.Message) graph.Message {
+	parts := make([]llms.ContentPart, len(msg.Parts))
+	for i, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			p.Text = r(p.Text)
+			parts[i] = p
+		case llms.ToolCall:
+			if p.FunctionCall != nil {
+				fn := *p.FunctionCall
+				fn.Arguments = r(fn.Arguments)
+				p.FunctionCall = &fn
+			}
+			parts[i] = p
+		case llms.ToolCallResponse:
+			p.Content = r(p.Content)
+			parts[i] = p
+		default:
+			parts[i] = part
+		}
+	}
+	msg.Parts = parts
+	return msg
+}
+
+// Redactors returns a Redactor applying redactors in order.
+func Redactors(redactors ...Redactor) Redactor {
+	return func(text string) string {
+		for _, r := range redactors {
+			text = r(text)
+		}
+		return text
+	}
+}
+
+// RedactPattern returns a Redactor replacing the matches of re with
+// replacement, which may refer to submatches as in regexp.Regexp.ReplaceAllString.
+func RedactPattern(re *regexp.Regexp, replacement string) Redactor {
+	return func(text string) string {
+		return re.ReplaceAllString(text, replacement)
+	}
+}
+
+// RedactEmails replaces email addresses with RedactedEmail.
+func RedactEmails(text string) string {
+	return emailPattern.ReplaceAllLiteralString(text, RedactedEmail)
+}
+
+// RedactCardNumbers replaces payment card numbers with RedactedCardNumber.
+// Only numbers passing the Luhn check are replaced, to spare other long
+// numbers such as order references.
+func RedactCardNumbers(text string) string {
+	return cardPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if luhn(match) {
+			return RedactedCardNumber
+		}
+		return match
+	})
+}
+
+// luhn reports whether the digits of s pass the Luhn check, ignoring other
+// characters.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
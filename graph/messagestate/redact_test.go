@@ -0,0 +1,98 @@
+package messagestate_test
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestRedactors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		redactor messagestate.Redactor
+		text     string
+		want     string
+	}{
+		{
+			name:     "Emails",
+			redactor: messagestate.RedactEmails,
+			text:     "Write to ada.lovelace+math@example.co.uk or bob@mail.example.com.",
+			want:     "Write to [EMAIL] or [EMAIL].",
+		},
+		{
+			name:     "Card numbers",
+			redactor: messagestate.RedactCardNumbers,
+			text:     "Pay with 4111 1111 1111 1111 or 5500-0000-0000-0004.",
+			want:     "Pay with [CARD NUMBER] or [CARD NUMBER].",
+		},
+		{
+			name:     "Other numbers",
+			redactor: messagestate.RedactCardNumbers,
+			text:     "Order 4111111111111112 shipped, call 555 0100.",
+			want:     "Order 4111111111111112 shipped, call 555 0100.",
+		},
+		{
+			name:     "Pattern",
+			redactor: messagestate.RedactPattern(regexp.MustCompile(`ACME-(\d{2})\d{4}`), "ACME-${1}****"),
+			text:     "Account ACME-123456.",
+			want:     "Account ACME-12****.",
+		},
+		{
+			name:     "Chain",
+			redactor: messagestate.Redactors(messagestate.RedactEmails, messagestate.RedactCardNumbers),
+			text:     "ada@example.com paid with 4111111111111111",
+			want:     "[EMAIL] paid with [CARD NUMBER]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tc.redactor(tc.text); got != tc.want {
+				t.Errorf("expected %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRedactorOnMessageState(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.Redactor = messagestate.Redactor(messagestate.RedactEmails)
+
+	state.EnsureSystemMessage("Escalate to support@example.com.")
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "I am ada@example.com"))
+	call := llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "lookup", Arguments: `{"email":"ada@example.com"}`}}
+	update := graph.NewMessage(llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}})
+	if err := state.AddMessages(update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := state.AddToolResult("call-1", "Found ada@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Escalate to [EMAIL]."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "I am [EMAIL]"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "lookup", Arguments: `{"email":"[EMAIL]"}`}}}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call-1", Name: "lookup", Content: "Found [EMAIL]"}}},
+	}
+	if got := state.Contents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected contents %v, but got %v", want, got)
+	}
+	if call.FunctionCall.Arguments != `{"email":"ada@example.com"}` || update.Parts[0].(llms.ToolCall).FunctionCall.Arguments != call.FunctionCall.Arguments {
+		t.Errorf("the added message was modified: %v", update)
+	}
+
+	state.ReplaceSystemMessage("Escalate to help@example.com.")
+	if got := state.Messages[0].Parts; !reflect.DeepEqual(got, want[0].Parts[:1]) {
+		t.Errorf("expected system prompt %v, but got %v", want[0].Parts, got)
+	}
+}
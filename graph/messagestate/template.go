@@ -0,0 +1,106 @@
+package messagestate
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Template renders a Go text/template against a value, typically the state of
+// a graph, into a message of its role:
+//
+//	prompt := messagestate.MustTemplate(llms.ChatMessageTypeHuman,
+//		"Answer {{.Question}} using this conversation:\n{{transcript .Messages}}")
+//	msg, err := prompt.Message(state)
+//
+// Besides the builtin functions of text/template, templates can use:
+//
+//	text        the text of a graph.Message, or the content of a tool result
+//	transcript  the messages of a []graph.Message, one "role: text" line each
+//	join        strings.Join
+//
+// Rendering fails on missing map keys rather than printing "<no value>".
+type Template struct {
+	role llms.ChatMessageType
+	tmpl *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"text":       plainText,
+	"transcript": transcript,
+	"join":       strings.Join,
+}
+
+// NewTemplate parses text into a Template for messages of role.
+func NewTemplate(role llms.ChatMessageType, text string) (*Template, error) {
+	tmpl, err := template.New(string(role)).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template: %w", role, err)
+	}
+	return &Template{role: role, tmpl: tmpl}, nil
+}
+
+// MustTemplate is like NewTemplate but panics if text cannot be parsed. It is
+// meant for templates known at compile time.
+func MustTemplate(role llms.ChatMessageType, text string) *Template {
+	t, err := NewTemplate(role, text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Message renders the template against data into a new message.
+func (t *Template) Message(data any) (graph.Message, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return graph.Message{}, fmt.Errorf("render %s template: %w", t.role, err)
+	}
+	return graph.NewMessage(llms.TextParts(t.role, b.String())), nil
+}
+
+// Prompt is a sequence of templates rendered together, such as a system
+// prompt followed by a human message.
+type Prompt []*Template
+
+// Messages renders the templates of p against data into new messages.
+func (p Prompt) Messages(data any) ([]graph.Message, error) {
+	msgs := make([]graph.Message, len(p))
+	for i, t := range p {
+		msg, err := t.Message(data)
+		if err != nil {
+			return nil, err
+		}
+		msgs[i] = msg
+	}
+	return msgs, nil
+}
+
+// transcript renders msgs as one "role: text" line per message.
+func transcript(msgs []graph.Message) string {
+	var b strings.Builder
+	for i, msg := range msgs {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s: %s", msg.Role, plainText(msg))
+	}
+	return b.String()
+}
+
+// plainText returns the text parts and tool results of msg.
+func plainText(msg graph.Message) string {
+	var b strings.Builder
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			b.WriteString(p.Text)
+		case llms.ToolCallResponse:
+			b.WriteString(p.Content)
+		}
+	}
+	return b.String()
+}
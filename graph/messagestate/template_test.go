@@ -0,0 +1,61 @@
+package messagestate_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type ticketState struct {
+	graph.MessageState
+	Customer string
+	Tags     []string
+}
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	state := ticketState{MessageState: graph.NewMessageState(), Customer: "Ada", Tags: []string{"billing", "urgent"}}
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "I was charged twice."))
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "Let me check."))
+
+	prompt := messagestate.Prompt{
+		messagestate.MustTemplate(llms.ChatMessageTypeSystem, "You help {{.Customer}} with {{join .Tags \", \"}} issues."),
+		messagestate.MustTemplate(llms.ChatMessageTypeHuman, "Conversation:\n{{transcript .Messages}}\nLast: {{text .LastMessage}}"),
+	}
+	msgs, err := prompt.Messages(&state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "You help Ada with billing, urgent issues."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Conversation:\nhuman: I was charged twice.\nai: Let me check.\nLast: Let me check."),
+	}
+	if got := graph.MessageContents(msgs); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected messages %v, but got %v", want, got)
+	}
+	if msgs[0].ID == "" || msgs[0].ID == msgs[1].ID {
+		t.Errorf("expected distinct message IDs, but got %q and %q", msgs[0].ID, msgs[1].ID)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := messagestate.NewTemplate(llms.ChatMessageTypeHuman, "{{.Question"); err == nil {
+		t.Error("expected a parse error, but got none")
+	}
+
+	tmpl := messagestate.MustTemplate(llms.ChatMessageTypeHuman, "{{.Question}}")
+	if _, err := tmpl.Message(map[string]any{}); err == nil || !strings.Contains(err.Error(), "Question") {
+		t.Errorf("expected a missing key error, but got %v", err)
+	}
+	if _, err := tmpl.Message(struct{}{}); err == nil {
+		t.Error("expected a missing field error, but got none")
+	}
+}
@@ -0,0 +1,135 @@
+package messagestate
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// TokenCounter counts the tokens of texts and messages for a model.
+type TokenCounter interface {
+	// CountText returns the number of tokens of text.
+	CountText(text string) int
+
+	// CountMessages returns the number of tokens msgs take in a prompt,
+	// including the overhead of each message. Counts of consecutive slices
+	// add up.
+	CountMessages(msgs []graph.Message) int
+}
+
+// messageOverhead is the number of tokens OpenAI chat models spend on the
+// role and delimiters of each message.
+const messageOverhead = 3
+
+// countMessages counts the tokens of msgs as the tokens of their text plus
+// messageOverhead each.
+func countMessages(countText func(string) int, msgs []graph.Message) int {
+	n := 0
+	for _, msg := range msgs {
+		n += countText(messageText(msg)) + messageOverhead
+	}
+	return n
+}
+
+// messageText returns the text of all the parts of a message, including tool
+// calls and results. The text of a message of a single text part is returned
+// as is, the others are joined in a buffer reused across calls.
+func messageText(msg graph.Message) string {
+	if len(msg.Parts) == 1 {
+		if p, ok := msg.Parts[0].(llms.TextContent); ok {
+			return p.Text
+		}
+	}
+	b := textBuffers.Get().(*bytes.Buffer)
+	defer func() {
+		b.Reset()
+		textBuffers.Put(b)
+	}()
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			b.WriteString(p.Text)
+		case llms.ToolCall:
+			if p.FunctionCall != nil {
+				b.WriteString(p.FunctionCall.Name)
+				b.WriteString(p.FunctionCall.Arguments)
+			}
+		case llms.ToolCallResponse:
+			b.WriteString(p.Name)
+			b.WriteString(p.Content)
+		}
+	}
+	return b.String()
+}
+
+// textBuffers are the buffers messageText joins the parts of messages in.
+var textBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// ApproximateCounter is a TokenCounter that estimates about four characters
+// per token. It is the fallback for models without a known tokenizer.
+type ApproximateCounter struct{}
+
+// CountText implements TokenCounter.
+func (ApproximateCounter) CountText(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// CountMessages implements TokenCounter.
+func (c ApproximateCounter) CountMessages(msgs []graph.Message) int {
+	return countMessages(c.CountText, msgs)
+}
+
+// TokenCounterFunc is a TokenCounter counting the tokens of texts with a
+// function.
+type TokenCounterFunc func(text string) int
+
+// CountText implements TokenCounter.
+func (f TokenCounterFunc) CountText(text string) int {
+	return f(text)
+}
+
+// CountMessages implements TokenCounter.
+func (f TokenCounterFunc) CountMessages(msgs []graph.Message) int {
+	return countMessages(f, msgs)
+}
+
+// TiktokenCounter is a TokenCounter using the tiktoken encoding of OpenAI
+// models.
+type TiktokenCounter struct {
+	encoding *tiktoken.Tiktoken
+}
+
+// NewTiktokenCounter creates a TiktokenCounter for the encoding of model.
+// Encodings are downloaded on first use, see the tiktoken-go package for
+// caching and offline loading.
+func NewTiktokenCounter(model string) (*TiktokenCounter, error) {
+	encoding, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return &TiktokenCounter{encoding: encoding}, nil
+}
+
+// CountText implements TokenCounter.
+func (c *TiktokenCounter) CountText(text string) int {
+	return len(c.encoding.EncodeOrdinary(text))
+}
+
+// CountMessages implements TokenCounter.
+func (c *TiktokenCounter) CountMessages(msgs []graph.Message) int {
+	return countMessages(c.CountText, msgs)
+}
+
+// CounterForModel returns a TiktokenCounter for model, or an
+// ApproximateCounter if its encoding is unknown or cannot be loaded.
+func CounterForModel(model string) TokenCounter {
+	if c, err := NewTiktokenCounter(model); err == nil {
+		return c
+	}
+	return ApproximateCounter{}
+}
@@ -0,0 +1,77 @@
+package messagestate_test
+
+import (
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// byteLoader is a tiktoken BPE loader whose vocabulary has one token per
+// byte and no merges, so that tests run offline and count bytes.
+type byteLoader struct{}
+
+func (byteLoader) LoadTiktokenBpe(string) (map[string]int, error) {
+	ranks := make(map[string]int, 256)
+	for i := range 256 {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	return ranks, nil
+}
+
+// TestTokenCounters does not run in parallel since it replaces the global
+// tiktoken loader.
+func TestTokenCounters(t *testing.T) {
+	tiktoken.SetBpeLoader(byteLoader{})
+	tiktokenCounter, err := messagestate.NewTiktokenCounter("gpt-4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msgs := []graph.Message{
+		msg(llms.ChatMessageTypeHuman, "hello world"),
+		toolResult("search"),
+	}
+
+	testCases := []struct {
+		name         string
+		counter      messagestate.TokenCounter
+		wantText     int
+		wantMessages int
+	}{
+		{name: "Tiktoken", counter: tiktokenCounter, wantText: 11, wantMessages: 11 + 3 + 6 + 3},
+		{name: "Approximate", counter: messagestate.ApproximateCounter{}, wantText: 3, wantMessages: 3 + 3 + 2 + 3},
+		{name: "Unknown model falls back", counter: messagestate.CounterForModel("no-such-model"), wantText: 3, wantMessages: 11},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.counter.CountText("hello world"); got != tc.wantText {
+				t.Errorf("expected %d text tokens, but got %d", tc.wantText, got)
+			}
+			if got := tc.counter.CountMessages(msgs); got != tc.wantMessages {
+				t.Errorf("expected %d message tokens, but got %d", tc.wantMessages, got)
+			}
+			if sum := tc.counter.CountMessages(msgs[:1]) + tc.counter.CountMessages(msgs[1:]); sum != tc.wantMessages {
+				t.Errorf("expected counts of consecutive slices to add up to %d, but got %d", tc.wantMessages, sum)
+			}
+		})
+	}
+}
+
+func BenchmarkCountMessages(b *testing.B) {
+	msgs := make([]graph.Message, 1000)
+	for i := range msgs {
+		msgs[i] = graph.NewMessage(llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "Let me check."},
+			llms.ToolCall{ID: "call", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+		}})
+	}
+	counter := messagestate.TokenCounterFunc(func(text string) int { return len(text) })
+	b.ReportAllocs()
+	for range b.N {
+		counter.CountMessages(msgs)
+	}
+}
@@ -0,0 +1,103 @@
+// Package messagestate provides helpers to inspect and reshape the messages of
+// a graph.MessageState, such as trimming a conversation to fit the context
+// window of a model.
+//
+// The helpers never modify the slices they are given; they return new ones.
+package messagestate
+
+import (
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Strategy selects which end of a conversation Trim keeps.
+type Strategy int
+
+const (
+	// KeepLast keeps the most recent messages.
+	KeepLast Strategy = iota
+
+	// KeepFirst keeps the oldest messages.
+	KeepFirst
+)
+
+// TrimOptions configures Trim. Zero limits are not enforced.
+type TrimOptions struct {
+	// MaxTokens is the maximum number of tokens of the trimmed messages.
+	MaxTokens int
+
+	// MaxMessages is the maximum number of trimmed messages.
+	MaxMessages int
+
+	// TokenCounter counts the tokens of messages. Defaults to ApproximateCounter.
+	TokenCounter TokenCounter
+
+	// Strategy selects the messages to keep. Defaults to KeepLast.
+	Strategy Strategy
+
+	// KeepSystem always keeps a leading system message, counting it against
+	// the limits.
+	KeepSystem bool
+
+	// StartOnHuman drops messages from the start of the kept history until it
+	// starts on a human message, so that it never opens with a reply or a tool
+	// result. A system message kept by KeepSystem stays in front. It only
+	// applies to KeepLast.
+	StartOnHuman bool
+}
+
+// Trim returns the messages that fit in the limits of opts.
+func Trim(msgs []graph.Message, opts TrimOptions) []graph.Message {
+	counter := opts.TokenCounter
+	if counter == nil {
+		counter = ApproximateCounter{}
+	}
+	tokens, messages := 0, 0
+	// one holds the message counted, reused for every message.
+	one := make([]graph.Message, 1)
+	fits := func(msg graph.Message) bool {
+		one[0] = msg
+		n := counter.CountMessages(one)
+		if opts.MaxTokens > 0 && tokens+n > opts.MaxTokens {
+			return false
+		}
+		if opts.MaxMessages > 0 && messages+1 > opts.MaxMessages {
+			return false
+		}
+		tokens += n
+		messages++
+		return true
+	}
+
+	var system []graph.Message
+	if opts.KeepSystem && len(msgs) > 0 && msgs[0].Role == llms.ChatMessageTypeSystem {
+		if !fits(msgs[0]) {
+			return []graph.Message{}
+		}
+		system, msgs = msgs[:1], msgs[1:]
+	}
+
+	trimmed := make([]graph.Message, 0, len(system)+len(msgs))
+	trimmed = append(trimmed, system...)
+
+	if opts.Strategy == KeepFirst {
+		for _, msg := range msgs {
+			if !fits(msg) {
+				break
+			}
+			trimmed = append(trimmed, msg)
+		}
+		return trimmed
+	}
+
+	start := len(msgs)
+	for start > 0 && fits(msgs[start-1]) {
+		start--
+	}
+	if opts.StartOnHuman {
+		for start < len(msgs) && msgs[start].Role != llms.ChatMessageTypeHuman {
+			start++
+		}
+	}
+	return append(trimmed, msgs[start:]...)
+}
@@ -0,0 +1,109 @@
+package messagestate_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func msg(role llms.ChatMessageType, text string) graph.Message {
+	return graph.NewMessage(llms.TextParts(role, text))
+}
+
+func texts(msgs []graph.Message) []string {
+	texts := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		texts = append(texts, m.Parts[0].(llms.TextContent).Text)
+	}
+	return texts
+}
+
+// fiveCharTokens counts a token per five characters, plus the overhead of 3
+// tokens per message, for predictable tests.
+var fiveCharTokens = messagestate.TokenCounterFunc(func(text string) int {
+	return len(text) / 5
+})
+
+func TestTrim(t *testing.T) {
+	t.Parallel()
+
+	conversation := []graph.Message{
+		msg(llms.ChatMessageTypeSystem, "sys.."),
+		msg(llms.ChatMessageTypeHuman, "h1..."),
+		msg(llms.ChatMessageTypeAI, "a1..."),
+		msg(llms.ChatMessageTypeHuman, "h2..."),
+		msg(llms.ChatMessageTypeAI, "a2..."),
+		msg(llms.ChatMessageTypeHuman, "h3...h3..."),
+	}
+
+	testCases := []struct {
+		name string
+		opts messagestate.TrimOptions
+		want []string
+	}{
+		{
+			name: "No limits",
+			opts: messagestate.TrimOptions{},
+			want: texts(conversation),
+		},
+		{
+			name: "Max messages",
+			opts: messagestate.TrimOptions{MaxMessages: 2},
+			want: []string{"a2...", "h3...h3..."},
+		},
+		{
+			name: "Max tokens",
+			opts: messagestate.TrimOptions{MaxTokens: 13, TokenCounter: fiveCharTokens},
+			want: []string{"h2...", "a2...", "h3...h3..."},
+		},
+		{
+			name: "Keep system",
+			opts: messagestate.TrimOptions{MaxMessages: 3, KeepSystem: true},
+			want: []string{"sys..", "a2...", "h3...h3..."},
+		},
+		{
+			name: "Start on human",
+			opts: messagestate.TrimOptions{MaxMessages: 3, KeepSystem: true, StartOnHuman: true},
+			want: []string{"sys..", "h3...h3..."},
+		},
+		{
+			name: "Start on human without system",
+			opts: messagestate.TrimOptions{MaxMessages: 4, StartOnHuman: true},
+			want: []string{"h2...", "a2...", "h3...h3..."},
+		},
+		{
+			name: "Keep first",
+			opts: messagestate.TrimOptions{MaxTokens: 12, TokenCounter: fiveCharTokens, Strategy: messagestate.KeepFirst},
+			want: []string{"sys..", "h1...", "a1..."},
+		},
+		{
+			name: "System does not fit",
+			opts: messagestate.TrimOptions{MaxTokens: 1, TokenCounter: fiveCharTokens, KeepSystem: true},
+			want: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := texts(messagestate.Trim(conversation, tc.opts))
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func BenchmarkTrim(b *testing.B) {
+	msgs := make([]graph.Message, 1000)
+	for i := range msgs {
+		msgs[i] = msg(llms.ChatMessageTypeHuman, "What is the weather in Paris today?")
+	}
+	b.ReportAllocs()
+	for range b.N {
+		messagestate.Trim(msgs, messagestate.TrimOptions{MaxTokens: 4000})
+	}
+}
@@ -0,0 +1,58 @@
+package messagestate
+
+import (
+	"slices"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// Window is a graph.WindowPolicy that trims the history of a MessageState
+// whenever messages are added to it:
+//
+//	state.Window = messagestate.Window{MaxMessages: 50, KeepSystem: true, StartOnHuman: true}
+type Window struct {
+	// TrimOptions sets the limits of the window and how it is trimmed.
+	TrimOptions
+
+	// PinnedIDs are the IDs of messages that are always kept, in place. They
+	// count against the limits.
+	PinnedIDs []string
+}
+
+var _ graph.WindowPolicy = Window{}
+
+// Apply implements graph.WindowPolicy.
+func (w Window) Apply(msgs []graph.Message) []graph.Message {
+	if len(w.PinnedIDs) == 0 {
+		return Trim(msgs, w.TrimOptions)
+	}
+
+	isPinned := ByID(w.PinnedIDs...)
+	pinned := Filter(msgs, isPinned)
+	opts := w.TrimOptions
+	if opts.MaxMessages > 0 {
+		opts.MaxMessages = max(opts.MaxMessages-len(pinned), 0)
+		if opts.MaxMessages == 0 {
+			return pinned
+		}
+	}
+	if opts.MaxTokens > 0 {
+		counter := opts.TokenCounter
+		if counter == nil {
+			counter = ApproximateCounter{}
+		}
+		opts.MaxTokens = max(opts.MaxTokens-counter.CountMessages(pinned), 0)
+		if opts.MaxTokens == 0 {
+			return pinned
+		}
+	}
+
+	kept := Trim(Exclude(msgs, isPinned), opts)
+	keptIDs := make(map[string]bool, len(kept))
+	for _, msg := range kept {
+		keptIDs[msg.ID] = true
+	}
+	return slices.DeleteFunc(slices.Clone(msgs), func(msg graph.Message) bool {
+		return !isPinned(msg) && !keptIDs[msg.ID]
+	})
+}
@@ -0,0 +1,80 @@
+package messagestate_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		window messagestate.Window
+		pin    string
+		want   []string
+	}{
+		{
+			name:   "Max messages",
+			window: messagestate.Window{TrimOptions: messagestate.TrimOptions{MaxMessages: 3}},
+			want:   []string{"m4", "m5", "m6"},
+		},
+		{
+			name:   "Keep system",
+			window: messagestate.Window{TrimOptions: messagestate.TrimOptions{MaxMessages: 3, KeepSystem: true}},
+			want:   []string{"system", "m5", "m6"},
+		},
+		{
+			name:   "Pinned message stays in place",
+			window: messagestate.Window{TrimOptions: messagestate.TrimOptions{MaxMessages: 3}},
+			pin:    "m2",
+			want:   []string{"m2", "m5", "m6"},
+		},
+		{
+			name:   "Pinned message counts against max tokens",
+			window: messagestate.Window{TrimOptions: messagestate.TrimOptions{MaxTokens: 9, TokenCounter: fiveCharTokens}},
+			pin:    "m2",
+			want:   []string{"m2", "m5", "m6"},
+		},
+		{
+			name:   "Only pinned messages fit",
+			window: messagestate.Window{TrimOptions: messagestate.TrimOptions{MaxMessages: 1}},
+			pin:    "m2",
+			want:   []string{"m2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			state := graph.NewMessageState()
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeSystem, "system"))
+			for _, text := range []string{"m1", "m2", "m3"} {
+				state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, text))
+			}
+			if tc.pin != "" {
+				for _, msg := range state.Messages {
+					if texts([]graph.Message{msg})[0] == tc.pin {
+						tc.window.PinnedIDs = []string{msg.ID}
+					}
+				}
+			}
+
+			// The window applies to every append from now on.
+			state.Window = tc.window
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "m4"))
+			if err := state.AddMessages(graph.NewMessage(llms.TextParts(llms.ChatMessageTypeHuman, "m5"))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "m6"))
+
+			if got := texts(state.Messages); !slices.Equal(got, tc.want) {
+				t.Errorf("expected %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package messagestate_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func wireConversation() []graph.Message {
+	return []graph.Message{
+		graph.NewMessage(llms.TextParts(llms.ChatMessageTypeSystem, "Be helpful.")),
+		graph.NewMessage(llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "What is this?"},
+			llms.BinaryContent{MIMEType: "image/png", Data: []byte("png")},
+		}}),
+		graph.NewMessage(llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "Let me check."},
+			llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "describe", Arguments: `{"detail":"high"}`}},
+		}}),
+		graph.NewMessage(llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+			llms.ToolCallResponse{ToolCallID: "call-1", Name: "describe", Content: "a cat"},
+		}}),
+		graph.NewMessage(llms.TextParts(llms.ChatMessageTypeAI, "It is a cat.")),
+	}
+}
+
+func TestOpenAIRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	msgs := wireConversation()
+	wire, err := messagestate.ToOpenAI(msgs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.Marshal(wire)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	want := `[{"role":"system","content":"Be helpful."},` +
+		`{"role":"user","content":[{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]},` +
+		`{"role":"assistant","content":"Let me check.","tool_calls":[{"id":"call-1","type":"function","function":{"name":"describe","arguments":"{\"detail\":\"high\"}"}}]},` +
+		`{"role":"tool","content":"a cat","name":"describe","tool_call_id":"call-1"},` +
+		`{"role":"assistant","content":"It is a cat."}]`
+	if string(data) != want {
+		t.Errorf("expected\n%s\nbut got\n%s", want, data)
+	}
+
+	var decoded []messagestate.OpenAIMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	back, err := messagestate.FromOpenAI(decoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Converters do not preserve message IDs.
+	if !reflect.DeepEqual(graph.MessageContents(back), graph.MessageContents(msgs)) {
+		t.Errorf("expected %v, but got %v", graph.MessageContents(msgs), graph.MessageContents(back))
+	}
+}
+
+func TestAnthropicRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	msgs := wireConversation()
+	system, wire, err := messagestate.ToAnthropic(msgs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if system != "Be helpful." {
+		t.Errorf("unexpected system prompt %q", system)
+	}
+	data, err := json.Marshal(wire)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	want := `[{"role":"user","content":[{"type":"text","text":"What is this?"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"cG5n"}}]},` +
+		`{"role":"assistant","content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"call-1","name":"describe","input":{"detail":"high"}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"call-1","content":[{"type":"text","text":"a cat"}]}]},` +
+		`{"role":"assistant","content":[{"type":"text","text":"It is a cat."}]}]`
+	if string(data) != want {
+		t.Errorf("expected\n%s\nbut got\n%s", want, data)
+	}
+
+	var decoded []messagestate.AnthropicMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	back, err := messagestate.FromAnthropic(system, decoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(graph.MessageContents(back), graph.MessageContents(msgs)) {
+		t.Errorf("expected %v, but got %v", graph.MessageContents(msgs), graph.MessageContents(back))
+	}
+}
+
+func TestFromAnthropicTranscript(t *testing.T) {
+	t.Parallel()
+
+	// Exported transcripts may use string content and mix tool results with
+	// text in a user message.
+	transcript := `[
+		{"role": "user", "content": "Weather in Paris?"},
+		{"role": "assistant", "content": [{"type": "tool_use", "id": "t1", "name": "weather", "input": {"city": "Paris"}}]},
+		{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "t1", "content": "sunny"},
+			{"type": "text", "text": "And tomorrow?"}
+		]}
+	]`
+	var wire []messagestate.AnthropicMessage
+	if err := json.Unmarshal([]byte(transcript), &wire); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	msgs, err := messagestate.FromAnthropic("", wire)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris?"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{ID: "t1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city": "Paris"}`}}}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "t1", Name: "weather", Content: "sunny"}}},
+		llms.TextParts(llms.ChatMessageTypeHuman, "And tomorrow?"),
+	}
+	if !reflect.DeepEqual(graph.MessageContents(msgs), want) {
+		t.Errorf("expected %v, but got %v", want, graph.MessageContents(msgs))
+	}
+}
+
+func TestConverterErrors(t *testing.T) {
+	t.Parallel()
+
+	unsupported := []graph.Message{graph.NewMessage(llms.TextParts(llms.ChatMessageTypeFunction, "f"))}
+	if _, err := messagestate.ToOpenAI(unsupported); !errors.Is(err, messagestate.ErrUnsupportedRole) {
+		t.Errorf("expected error %v, but got %v", messagestate.ErrUnsupportedRole, err)
+	}
+	if _, _, err := messagestate.ToAnthropic(unsupported); !errors.Is(err, messagestate.ErrUnsupportedRole) {
+		t.Errorf("expected error %v, but got %v", messagestate.ErrUnsupportedRole, err)
+	}
+	if _, err := messagestate.FromOpenAI([]messagestate.OpenAIMessage{{Role: "critic"}}); !errors.Is(err, messagestate.ErrUnsupportedRole) {
+		t.Errorf("expected error %v, but got %v", messagestate.ErrUnsupportedRole, err)
+	}
+	if _, err := messagestate.FromAnthropic("", []messagestate.AnthropicMessage{{Role: "user", Content: []messagestate.AnthropicContentBlock{{Type: "document"}}}}); !errors.Is(err, messagestate.ErrUnsupportedPart) {
+		t.Errorf("expected error %v, but got %v", messagestate.ErrUnsupportedPart, err)
+	}
+}
+
+func TestOpenAINames(t *testing.T) {
+	t.Parallel()
+
+	msgs := []graph.Message{graph.NewMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi")).WithMetadata(graph.MetadataName, "ada")}
+	wire, err := messagestate.ToOpenAI(msgs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wire[0].Name != "ada" {
+		t.Errorf("expected name ada, but got %q", wire[0].Name)
+	}
+	back, err := messagestate.FromOpenAI(wire)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if back[0].Name() != "ada" {
+		t.Errorf("expected name ada, but got %q", back[0].Name())
+	}
+}
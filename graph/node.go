@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// NodeMetadata describes a node for people and tools, without affecting how
+// the graph runs. It is set with NodeOptions when adding the node, and shows
+// in drawings of the graph.
+type NodeMetadata struct {
+	// Description tells what the node does.
+	Description string
+
+	// Owner is the team or person responsible for the node.
+	Owner string
+
+	// Tags classify the node, such as "llm" for nodes calling a model or "io"
+	// for nodes calling external services.
+	Tags []string
+}
+
+// HasTag reports whether the node is tagged with tag.
+func (m NodeMetadata) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// IsZero reports whether no metadata is set.
+func (m NodeMetadata) IsZero() bool {
+	return m.Description == "" && m.Owner == "" && len(m.Tags) == 0
+}
+
+// String summarizes the metadata on one line per field, as shown in tooltips.
+func (m NodeMetadata) String() string {
+	var lines []string
+	if m.Description != "" {
+		lines = append(lines, m.Description)
+	}
+	if m.Owner != "" {
+		lines = append(lines, "owner: "+m.Owner)
+	}
+	if len(m.Tags) > 0 {
+		lines = append(lines, "tags: "+strings.Join(m.Tags, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Node returns the node with the given name.
+func (g *StateGraph[T]) Node(name string) (Node[T], bool) {
+	node, ok := g.nodes[name]
+	return node, ok
+}
+
+// Nodes returns the nodes of the graph, sorted by name.
+func (g *StateGraph[T]) Nodes() []Node[T] {
+	nodes := make([]Node[T], 0, len(g.nodes))
+	for _, node := range g.nodes {
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, func(a, b Node[T]) int { return strings.Compare(a.Name, b.Name) })
+	return nodes
+}
+
+// NodesWithTag returns the names of the nodes tagged with tag, sorted.
+func (g *StateGraph[T]) NodesWithTag(tag string) []string {
+	var names []string
+	for _, node := range g.Nodes() {
+		if node.Metadata.HasTag(tag) {
+			names = append(names, node.Name)
+		}
+	}
+	return names
+}
+
+// currentNodeKey is the context key of the node being run.
+type currentNodeKey struct{}
+
+// runningNode is the node being run and the values it was resumed with,
+// held by a single context value.
+type runningNode struct {
+	name     string
+	metadata NodeMetadata
+	resume   *resumeValues
+}
+
+// nodeContext is the context of a running node. It holds the node as
+// context.WithValue would, in one allocation rather than two for the
+// context and the boxed node, since runs make one per node.
+type nodeContext struct {
+	context.Context
+	node runningNode
+}
+
+// Value returns a pointer to the running node for currentNodeKey.
+func (c *nodeContext) Value(key any) any {
+	if key == (currentNodeKey{}) {
+		return &c.node
+	}
+	return c.Context.Value(key)
+}
+
+// CurrentNode returns the name and metadata of the node running with ctx,
+// such as to label the traces and metrics recorded by the node or by the
+// models and tools it calls. ok is false outside of nodes.
+func CurrentNode(ctx context.Context) (name string, metadata NodeMetadata, ok bool) {
+	node, ok := ctx.Value(currentNodeKey{}).(*runningNode)
+	if !ok {
+		return "", NodeMetadata{}, false
+	}
+	return node.name, node.metadata, true
+}
@@ -0,0 +1,280 @@
+package graph
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults of RetryPolicy.
+const (
+	DefaultMaxAttempts     = 3
+	DefaultInitialInterval = 500 * time.Millisecond
+	DefaultBackoffFactor   = 2
+)
+
+// DefaultMaxCacheEntries is the default of CachePolicy.MaxEntries.
+const DefaultMaxCacheEntries = 1024
+
+// RetryPolicy configures how a failing node is retried, see WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times the node runs before its error is
+	// returned, including the first. Defaults to DefaultMaxAttempts.
+	MaxAttempts int
+
+	// InitialInterval is the delay before the first retry. Defaults to
+	// DefaultInitialInterval.
+	InitialInterval time.Duration
+
+	// BackoffFactor multiplies the delay after every retry. Defaults to
+	// DefaultBackoffFactor.
+	BackoffFactor float64
+
+	// MaxInterval caps the delay between retries. Zero means no cap.
+	MaxInterval time.Duration
+
+	// RetryOn reports whether an error is worth retrying. When nil, all
+	// errors are retried.
+	RetryOn func(err error) bool
+}
+
+// retries reports whether err, returned by the given attempt counted from 1,
+// is retried.
+func (p RetryPolicy) retries(attempt int, err error) bool {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return attempt < maxAttempts && (p.RetryOn == nil || p.RetryOn(err))
+}
+
+// delay returns the delay before the given retry, counted from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	interval, factor := p.InitialInterval, p.BackoffFactor
+	if interval <= 0 {
+		interval = DefaultInitialInterval
+	}
+	if factor <= 0 {
+		factor = DefaultBackoffFactor
+	}
+	d := float64(interval)
+	for i := 1; i < retry; i++ {
+		d *= factor
+		if p.MaxInterval > 0 && d >= float64(p.MaxInterval) {
+			break
+		}
+	}
+	if p.MaxInterval > 0 && d > float64(p.MaxInterval) {
+		return p.MaxInterval
+	}
+	return time.Duration(d)
+}
+
+// CachePolicy configures how the results of a node are cached, see
+// WithCache.
+type CachePolicy struct {
+	// TTL is how long results are kept. Zero means until evicted.
+	TTL time.Duration
+
+	// MaxEntries caps the results kept for the node: beyond it, expired
+	// results are dropped, then the least recently used. Defaults to
+	// DefaultMaxCacheEntries.
+	MaxEntries int
+
+	// Key returns the cache key of the state the node runs with, a pointer to
+	// the state of the graph. When nil, the key is a hash of the state
+	// encoded in JSON.
+	Key func(state any) (string, error)
+}
+
+// key returns the cache key of state.
+func (p CachePolicy) key(state any) (string, error) {
+	if p.Key != nil {
+		return p.Key(state)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// maxEntries returns the number of results kept.
+func (p CachePolicy) maxEntries() int {
+	if p.MaxEntries <= 0 {
+		return DefaultMaxCacheEntries
+	}
+	return p.MaxEntries
+}
+
+// nodeCache holds the states resulting from cached nodes, by node and key,
+// each node keeping its entries in least recently used order.
+type nodeCache[T any] struct {
+	mu    sync.Mutex
+	nodes map[string]*cacheEntries[T]
+}
+
+// cacheEntries are the entries of a node, the most recently used first.
+type cacheEntries[T any] struct {
+	lru   list.List
+	byKey map[string]*list.Element
+}
+
+type cacheEntry[T any] struct {
+	key     string
+	state   T
+	expires time.Time
+}
+
+// expired reports whether the entry expired as of now.
+func (e *cacheEntry[T]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// get returns the state cached for node and key as of now.
+func (c *nodeCache[T]) get(node, key string, now time.Time) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero T
+	entries := c.nodes[node]
+	if entries == nil {
+		return zero, false
+	}
+	elem, ok := entries.byKey[key]
+	if !ok {
+		return zero, false
+	}
+	entry := elem.Value.(*cacheEntry[T])
+	if entry.expired(now) {
+		entries.remove(elem)
+		return zero, false
+	}
+	entries.lru.MoveToFront(elem)
+	return entry.state, true
+}
+
+// put caches state for node and key from now as configured by policy,
+// evicting entries beyond its maximum.
+func (c *nodeCache[T]) put(node, key string, state T, now time.Time, policy CachePolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nodes == nil {
+		c.nodes = make(map[string]*cacheEntries[T])
+	}
+	entries := c.nodes[node]
+	if entries == nil {
+		entries = &cacheEntries[T]{byKey: make(map[string]*list.Element)}
+		c.nodes[node] = entries
+	}
+	entry := &cacheEntry[T]{key: key, state: state}
+	if policy.TTL > 0 {
+		entry.expires = now.Add(policy.TTL)
+	}
+	if elem, ok := entries.byKey[key]; ok {
+		elem.Value = entry
+		entries.lru.MoveToFront(elem)
+		return
+	}
+	if limit := policy.maxEntries(); entries.lru.Len() >= limit {
+		entries.sweep(now)
+		for entries.lru.Len() >= limit {
+			entries.remove(entries.lru.Back())
+		}
+	}
+	entries.byKey[key] = entries.lru.PushFront(entry)
+}
+
+// remove removes the entry of elem.
+func (e *cacheEntries[T]) remove(elem *list.Element) {
+	delete(e.byKey, elem.Value.(*cacheEntry[T]).key)
+	e.lru.Remove(elem)
+}
+
+// sweep removes the entries expired as of now.
+func (e *cacheEntries[T]) sweep(now time.Time) {
+	for elem := e.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*cacheEntry[T]).expired(now) {
+			e.remove(elem)
+		}
+		elem = next
+	}
+}
+
+// runNode runs node on state according to its policies: waiting for a slot
+// when its concurrency is limited, returning a cached result, and retrying
+// failed attempts from the state the node started with, each attempt bounded
+// by the timeout of the node.
+func (r *Runnable[T]) runNode(ctx context.Context, node Node[T], state *T, rv *resumeValues) error {
+	if sem := r.semaphores[node.Name]; sem != nil {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var key string
+	if node.Cache != nil {
+		var err error
+		if key, err = node.Cache.key(state); err != nil {
+			return fmt.Errorf("cache key: %w", err)
+		}
+		if cached, ok := r.cache.get(node.Name, key, r.clock.Now()); ok {
+			*state = cloneState(&cached)
+			return nil
+		}
+	}
+
+	ctx = &nodeContext{Context: ctx, node: runningNode{name: node.Name, metadata: node.Metadata, resume: rv}}
+	// initial is the state the attempts are retried from, kept only for
+	// nodes with a retry policy.
+	var initial *T
+	policy := node.Retry
+	if policy != nil {
+		c := cloneState(state)
+		initial = &c
+	}
+	for attempt := 1; ; attempt++ {
+		rv.next = 0
+		err := r.attempt(ctx, node, state)
+		if err == nil {
+			if node.Cache != nil {
+				r.cache.put(node.Name, key, cloneState(state), r.clock.Now(), *node.Cache)
+			}
+			return nil
+		}
+		var gi *GraphInterrupt
+		if policy == nil || errors.As(err, &gi) || ctx.Err() != nil || !policy.retries(attempt, err) {
+			return err
+		}
+		if sleep(ctx, r.clock, policy.delay(attempt)) != nil {
+			return err
+		}
+		*state = cloneState(initial)
+	}
+}
+
+// attempt runs the function of node once, within the timeout of the node,
+// unless a fault is injected instead, see WithChaos.
+func (r *Runnable[T]) attempt(ctx context.Context, node Node[T], state *T) error {
+	if node.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, r.clock, node.Timeout)
+		defer cancel()
+	}
+	if r.chaos != nil {
+		if err := r.chaos.nodeFault(ctx, r.clock, node.Name); err != nil {
+			return err
+		}
+	}
+	return node.Function(ctx, state)
+}
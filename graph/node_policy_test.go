@@ -0,0 +1,201 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// runSingleNode compiles a graph made of a single node running fn.
+func runSingleNode(t *testing.T, fn func(ctx context.Context, n *int) error, opts ...graph.NodeOption) (*graph.Runnable[int], error) {
+	t.Helper()
+	g := graph.NewStateGraph[int]()
+	g.AddNode("node", fn, opts...)
+	g.AddEdge("node", graph.END)
+	g.SetEntryPoint("node")
+	return g.Compile()
+}
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	errTransient := errors.New("transient")
+	errFatal := errors.New("fatal")
+
+	testCases := []struct {
+		name      string
+		policy    graph.RetryPolicy
+		failures  []error
+		wantCalls int
+		wantErr   error
+		wantState int
+	}{
+		{
+			name:      "Succeeds after retries",
+			policy:    graph.RetryPolicy{InitialInterval: time.Millisecond},
+			failures:  []error{errTransient, errTransient},
+			wantCalls: 3,
+			wantState: 1,
+		},
+		{
+			name:      "Gives up after max attempts",
+			policy:    graph.RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond},
+			failures:  []error{errTransient, errTransient, errTransient},
+			wantCalls: 2,
+			wantErr:   errTransient,
+		},
+		{
+			name: "Stops on errors not retried",
+			policy: graph.RetryPolicy{
+				InitialInterval: time.Millisecond,
+				RetryOn:         func(err error) bool { return !errors.Is(err, errFatal) },
+			},
+			failures:  []error{errTransient, errFatal},
+			wantCalls: 2,
+			wantErr:   errFatal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			runnable, err := runSingleNode(t, func(_ context.Context, n *int) error {
+				// Attempts start from the initial state despite this change.
+				*n++
+				calls++
+				if calls <= len(tc.failures) {
+					return tc.failures[calls-1]
+				}
+				return nil
+			}, graph.WithRetry(tc.policy))
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			n := 0
+			err = runnable.Invoke(context.Background(), &n)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("expected %d calls, but got %d", tc.wantCalls, calls)
+			}
+			if tc.wantErr == nil && n != tc.wantState {
+				t.Errorf("expected state %d, but got %d", tc.wantState, n)
+			}
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	runnable, err := runSingleNode(t, func(ctx context.Context, _ *int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, graph.WithTimeout(time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	var n int
+	if err := runnable.Invoke(context.Background(), &n); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error %v, but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		policy    graph.CachePolicy
+		inputs    []int
+		wantCalls int
+	}{
+		{name: "Hits for the same state", inputs: []int{3, 3, 4, 3}, wantCalls: 2},
+		{
+			name:      "Custom key",
+			policy:    graph.CachePolicy{Key: func(state any) (string, error) { return "constant", nil }},
+			inputs:    []int{3, 4},
+			wantCalls: 1,
+		},
+		{name: "Expires", policy: graph.CachePolicy{TTL: time.Nanosecond}, inputs: []int{3, 3}, wantCalls: 2},
+		{
+			name:      "Evicts the least recently used",
+			policy:    graph.CachePolicy{MaxEntries: 2},
+			inputs:    []int{3, 4, 3, 5, 3, 4},
+			wantCalls: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			runnable, err := runSingleNode(t, func(_ context.Context, n *int) error {
+				calls++
+				*n *= *n
+				return nil
+			}, graph.WithCache(tc.policy))
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			for _, input := range tc.inputs {
+				n := input
+				if err := runnable.Invoke(context.Background(), &n); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if tc.policy.Key == nil && n != input*input {
+					t.Errorf("expected %d, but got %d", input*input, n)
+				}
+				time.Sleep(time.Microsecond)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("expected %d calls, but got %d", tc.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestWithMaxConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var running, peak atomic.Int32
+	runnable, err := runSingleNode(t, func(context.Context, *int) error {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if now <= p || peak.CompareAndSwap(p, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}, graph.WithMaxConcurrency(2))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var n int
+			if err := runnable.Invoke(context.Background(), &n); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got != 2 {
+		t.Errorf("expected at most 2 concurrent runs, but got %d", got)
+	}
+}
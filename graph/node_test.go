@@ -0,0 +1,113 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// newTaggedGraph builds a graph calling a model then a search service.
+func newTaggedGraph(fn func(ctx context.Context, n *int) error) *graph.StateGraph[int] {
+	g := graph.NewStateGraph[int]()
+	g.AddNode("agent", fn, graph.WithDescription("Calls the model"), graph.WithOwner("ml-team"), graph.WithTags("llm"))
+	g.AddNode("search", fn, graph.WithTags("io", "web search"), graph.WithTags("io"))
+	g.AddNode("format", fn)
+	g.AddEdge("agent", "search")
+	g.AddEdge("search", "format")
+	g.AddEdge("format", graph.END)
+	g.SetEntryPoint("agent")
+	return g
+}
+
+func TestNodeMetadata(t *testing.T) {
+	t.Parallel()
+
+	g := newTaggedGraph(func(context.Context, *int) error { return nil })
+
+	node, ok := g.Node("agent")
+	if !ok {
+		t.Fatal("expected node agent")
+	}
+	want := graph.NodeMetadata{Description: "Calls the model", Owner: "ml-team", Tags: []string{"llm"}}
+	if node.Metadata.Description != want.Description || node.Metadata.Owner != want.Owner || !slices.Equal(node.Metadata.Tags, want.Tags) {
+		t.Errorf("expected metadata %+v, but got %+v", want, node.Metadata)
+	}
+	if search, _ := g.Node("search"); !slices.Equal(search.Metadata.Tags, []string{"io", "web search"}) {
+		t.Errorf("expected tags [io web search], but got %q", search.Metadata.Tags)
+	}
+	if format, _ := g.Node("format"); !format.Metadata.IsZero() {
+		t.Errorf("expected no metadata, but got %+v", format.Metadata)
+	}
+	if _, ok := g.Node("missing"); ok {
+		t.Error("expected no node missing")
+	}
+
+	var names []string
+	for _, node := range g.Nodes() {
+		names = append(names, node.Name)
+	}
+	if want := []string{"agent", "format", "search"}; !slices.Equal(names, want) {
+		t.Errorf("expected nodes %q, but got %q", want, names)
+	}
+	if got := g.NodesWithTag("io"); !slices.Equal(got, []string{"search"}) {
+		t.Errorf("expected nodes [search], but got %q", got)
+	}
+}
+
+func TestCurrentNode(t *testing.T) {
+	t.Parallel()
+
+	if _, _, ok := graph.CurrentNode(context.Background()); ok {
+		t.Error("expected no current node outside of nodes")
+	}
+
+	var visited []string
+	g := newTaggedGraph(func(ctx context.Context, _ *int) error {
+		name, metadata, ok := graph.CurrentNode(ctx)
+		if !ok {
+			t.Error("expected a current node")
+		}
+		visited = append(visited, name+"["+strings.Join(metadata.Tags, ",")+"]")
+		return nil
+	})
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	var n int
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"agent[llm]", "search[io,web search]", "format[]"}; !slices.Equal(visited, want) {
+		t.Errorf("expected %q, but got %q", want, visited)
+	}
+}
+
+func TestDrawNodeMetadata(t *testing.T) {
+	t.Parallel()
+
+	g := newTaggedGraph(func(context.Context, *int) error { return nil })
+
+	mermaid := g.DrawMermaid()
+	for _, want := range []string{"\tclass n1 llm;\n", "\tclass n2 io;\n", "\tclass n2 web_search;\n"} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("expected diagram to contain %q, but got\n%s", want, mermaid)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.DrawSVG(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "<title>Calls the model\nowner: ml-team\ntags: llm</title>"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected SVG to contain %q, but got\n%s", want, buf.String())
+	}
+	if got := strings.Count(buf.String(), "<title>"); got != 2 {
+		t.Errorf("expected 2 tooltips, but got %d", got)
+	}
+}
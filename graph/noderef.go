@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// NodeRef refers to a node of a graph with state type T. It is returned by
+// StateGraph.AddNode, so that edges added with AddEdgeRef,
+// AddConditionalEdgesRef and SetEntryPointRef can only refer to nodes that
+// were added, or to END (see End):
+//
+//	agent, _ := g.AddNode("agent", callModel)
+//	tools, _ := g.AddNode("tools", callTools)
+//	g.AddEdgeRef(tools, agent)
+//	g.SetEntryPointRef(agent)
+type NodeRef[T any] struct {
+	name string
+}
+
+// Name returns the name of the node.
+func (r NodeRef[T]) Name() string {
+	return r.name
+}
+
+// End returns a NodeRef to END.
+func End[T any]() NodeRef[T] {
+	return NodeRef[T]{name: END}
+}
+
+// checkRef returns ErrNodeNotFound if ref does not refer to a node of the
+// graph or to END, such as the zero NodeRef.
+func (g *StateGraph[T]) checkRef(ref NodeRef[T]) error {
+	if _, ok := g.nodes[ref.name]; !ok && ref.name != END {
+		return fmt.Errorf("%w: %q", ErrNodeNotFound, ref.name)
+	}
+	return nil
+}
+
+// AddEdgeRef adds an edge between the referenced nodes, see AddEdge.
+//
+// It returns ErrNodeNotFound if a reference does not refer to a node of the
+// graph, leaving the graph unchanged.
+func (g *StateGraph[T]) AddEdgeRef(from, to NodeRef[T], opts ...EdgeOption) error {
+	for _, ref := range []NodeRef[T]{from, to} {
+		if err := g.checkRef(ref); err != nil {
+			return err
+		}
+	}
+	return g.AddEdge(from.name, to.name, opts...)
+}
+
+// SetEntryPointRef sets the referenced node as the entry point, see
+// SetEntryPoint.
+//
+// It returns ErrNodeNotFound if ref does not refer to a node of the graph,
+// leaving the graph unchanged.
+func (g *StateGraph[T]) SetEntryPointRef(ref NodeRef[T]) error {
+	if err := g.checkRef(ref); err != nil {
+		return err
+	}
+	return g.SetEntryPoint(ref.name)
+}
+
+// AddConditionalEdgesRef adds a conditional edge from the node from to the
+// nodes returned by path, see AddConditionalEdges. targets lists the nodes
+// path may return, for Compile to validate the graph and for drawings; when
+// empty, path may return any node.
+//
+// It returns ErrNodeNotFound if a reference does not refer to a node of the
+// graph, leaving the graph unchanged.
+func (g *StateGraph[T]) AddConditionalEdgesRef(
+	from NodeRef[T],
+	path func(ctx context.Context, state *T) ([]NodeRef[T], error),
+	targets ...NodeRef[T],
+) error {
+	if from.name == END {
+		return fmt.Errorf("%w: conditional edge from %s", ErrNodeNotFound, END)
+	}
+	for _, ref := range append([]NodeRef[T]{from}, targets...) {
+		if err := g.checkRef(ref); err != nil {
+			return err
+		}
+	}
+
+	branch := &Branch[T]{
+		Source: from.name,
+		Path: func(ctx context.Context, state *T) ([]string, error) {
+			refs, err := path(ctx, state)
+			names := make([]string, len(refs))
+			for i, ref := range refs {
+				names[i] = ref.name
+			}
+			return names, err
+		},
+		Mapping: func(x string) string {
+			return x
+		},
+	}
+	for _, ref := range targets {
+		if !slices.Contains(branch.Targets, ref.name) {
+			branch.Targets = append(branch.Targets, ref.name)
+		}
+	}
+	slices.Sort(branch.Targets)
+	g.edges = append(g.edges, branch)
+	return nil
+}
@@ -0,0 +1,93 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestNodeRef(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[int]()
+	agent, err := g.AddNode("agent", func(_ context.Context, n *int) error {
+		*n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tools, err := g.AddNode("tools", func(_ context.Context, n *int) error {
+		*n *= 2
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if agent.Name() != "agent" {
+		t.Errorf("expected name agent, but got %s", agent.Name())
+	}
+
+	if err := g.AddConditionalEdgesRef(agent, func(_ context.Context, n *int) ([]graph.NodeRef[int], error) {
+		if *n >= 10 {
+			return []graph.NodeRef[int]{graph.End[int]()}, nil
+		}
+		return []graph.NodeRef[int]{tools}, nil
+	}, tools, graph.End[int]()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.AddEdgeRef(tools, agent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.SetEntryPointRef(agent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runnable, err := g.Compile(graph.WithAllowedCycle("agent", "tools"))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 15 {
+		t.Errorf("expected 15, but got %d", n)
+	}
+}
+
+func TestNodeRefErrors(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]graph.NodeRef[int], error) { return nil, nil }
+
+	g := graph.NewStateGraph[int]()
+	agent, _ := g.AddNode("agent", noop)
+	// A reference to a node of another graph with the same state type.
+	other, _ := graph.NewStateGraph[int]().AddNode("other", noop)
+
+	testCases := []struct {
+		name string
+		add  func() error
+	}{
+		{name: "Zero reference", add: func() error { return g.AddEdgeRef(agent, graph.NodeRef[int]{}) }},
+		{name: "Edge from END", add: func() error { return g.AddEdgeRef(graph.End[int](), agent) }},
+		{name: "Node of another graph", add: func() error { return g.SetEntryPointRef(other) }},
+		{name: "Conditional edge from END", add: func() error { return g.AddConditionalEdgesRef(graph.End[int](), route) }},
+		{name: "Conditional edge target", add: func() error { return g.AddConditionalEdgesRef(agent, route, other) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.add(); !errors.Is(err, graph.ErrNodeNotFound) {
+				t.Errorf("expected error %v, but got %v", graph.ErrNodeNotFound, err)
+			}
+		})
+	}
+	if edges := g.Edges(); len(edges) != 0 {
+		t.Errorf("expected no edges, but got %v", edges)
+	}
+}
@@ -0,0 +1,424 @@
+package graph
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+)
+
+// CompileOption configures how a StateGraph is compiled.
+type CompileOption func(*compileConfig)
+
+type compileConfig struct {
+	// checkpointer is a Checkpointer[T] for the state type of the graph; it is
+	// checked by Compile since options are not parameterized by the state type.
+	checkpointer any
+
+	// allowedCycles are the sets of nodes allowed to form cycles.
+	allowedCycles [][]string
+
+	// interruptBefore and interruptAfter are the nodes to interrupt the run
+	// before or after.
+	interruptBefore []string
+	interruptAfter  []string
+
+	// stepLimit is the maximum number of nodes an invocation runs, if
+	// positive.
+	stepLimit int
+
+	// callbacks are CallbackHandler[T]s for the state type of the graph,
+	// checked by Compile like checkpointer.
+	callbacks []any
+
+	// debug receives the debug output, if set.
+	debug io.Writer
+
+	// implicitEnd makes nodes without outgoing edges end their branch.
+	implicitEnd bool
+
+	// chaos configures the faults injected into runs, if set.
+	chaos *ChaosConfig
+
+	// clock tells the time, the system clock if nil.
+	clock Clock
+
+	// checkpointBatch is the longest the checkpoints of a run are held
+	// before being saved, if positive, see WithCheckpointBatching.
+	checkpointBatch time.Duration
+
+	// scheduler schedules the nodes of fan-outs, see WithScheduler.
+	scheduler Scheduler
+}
+
+// WithCheckpointer makes the compiled graph save a checkpoint to cp after
+// every node of invocations that run on a thread (see WithThreadID).
+func WithCheckpointer[T any](cp Checkpointer[T]) CompileOption {
+	return func(c *compileConfig) {
+		c.checkpointer = cp
+	}
+}
+
+// WithAllowedCycle allows the given nodes to form a cycle, such as an agent
+// calling tools until it is done:
+//
+//	g.Compile(graph.WithAllowedCycle("agent", "tools"))
+//
+// Compile rejects the graph if its edges form cycles that are not allowed, to
+// catch accidental infinite loops. The option can be given once per cycle.
+func WithAllowedCycle(nodes ...string) CompileOption {
+	return func(c *compileConfig) {
+		c.allowedCycles = append(c.allowedCycles, nodes)
+	}
+}
+
+// WithInterruptBefore interrupts the run before any of the given nodes runs,
+// such as to let a human review the state. Invoke returns a *GraphInterrupt
+// whose Before field is set and the run is resumed with WithResume, the
+// resume value being ignored. It requires a checkpointer, see
+// WithCheckpointer.
+func WithInterruptBefore(nodes ...string) CompileOption {
+	return func(c *compileConfig) {
+		c.interruptBefore = append(c.interruptBefore, nodes...)
+	}
+}
+
+// WithInterruptAfter interrupts the run after any of the given nodes ran,
+// such as to let a human review its output. Invoke returns a *GraphInterrupt
+// whose After field is set and the run is resumed with WithResume, the
+// resume value being ignored. It requires a checkpointer, see
+// WithCheckpointer.
+func WithInterruptAfter(nodes ...string) CompileOption {
+	return func(c *compileConfig) {
+		c.interruptAfter = append(c.interruptAfter, nodes...)
+	}
+}
+
+// WithCheckpointBatching makes invocations save their checkpoints in
+// batches rather than after every node, cutting the round trips to the
+// checkpointer of graphs running many short nodes: a checkpoint is held at
+// most maxDelay before its batch is saved, and the held checkpoints are
+// saved when the invocation returns, whether it completed, was interrupted
+// or failed. Checkpointers implementing BatchPutter save a batch at once.
+//
+// A crash loses the checkpoints held, at most maxDelay of the run. The
+// errors of the batches saved while nodes run are returned by the
+// invocation.
+func WithCheckpointBatching(maxDelay time.Duration) CompileOption {
+	return func(c *compileConfig) {
+		c.checkpointBatch = maxDelay
+	}
+}
+
+// WithStepLimit makes invocations fail with ErrStepLimit rather than run more
+// than n nodes, to stop runaway loops.
+func WithStepLimit(n int) CompileOption {
+	return func(c *compileConfig) {
+		c.stepLimit = n
+	}
+}
+
+// WithCallbacks notifies handlers of every node run, in order.
+func WithCallbacks[T any](handlers ...CallbackHandler[T]) CompileOption {
+	return func(c *compileConfig) {
+		for _, h := range handlers {
+			c.callbacks = append(c.callbacks, h)
+		}
+	}
+}
+
+// WithDebug writes a line to w when a node starts and when it ends, with the
+// resulting state or the error of the node.
+func WithDebug(w io.Writer) CompileOption {
+	return func(c *compileConfig) {
+		c.debug = w
+	}
+}
+
+// WithImplicitEnd makes nodes without outgoing edges end their branch, as if
+// they had an edge to END, rather than fail the run with ErrNoOutgoingEdge.
+// Validate then also counts such nodes as leading to END.
+func WithImplicitEnd() CompileOption {
+	return func(c *compileConfig) {
+		c.implicitEnd = true
+	}
+}
+
+// Scheduler selects how the nodes a conditional edge routes to, the nodes of
+// a fan-out, are run, see WithScheduler.
+type Scheduler int
+
+const (
+	// SchedulerSequential runs the nodes of fan-outs one at a time, depth
+	// first, unless an invocation runs them at once with WithMaxParallelism
+	// or WithWorkerPool. It is the default.
+	SchedulerSequential Scheduler = iota
+
+	// SchedulerParallel runs the nodes of fan-outs at once in every
+	// invocation, all of them unless it limits them with WithMaxParallelism
+	// or WithWorkerPool. It requires a state implementing Reducer.
+	SchedulerParallel
+)
+
+// WithScheduler selects how the compiled graph runs the nodes of fan-outs,
+// SchedulerSequential by default. The nodes run at once as described by
+// WithMaxParallelism. Compile returns ErrInvalidOption for unknown
+// schedulers and for SchedulerParallel if the state does not implement
+// Reducer.
+func WithScheduler(s Scheduler) CompileOption {
+	return func(c *compileConfig) {
+		c.scheduler = s
+	}
+}
+
+// NodeOption configures a node added with StateGraph.AddNode.
+type NodeOption func(*nodeConfig)
+
+type nodeConfig struct {
+	metadata       NodeMetadata
+	retry          *RetryPolicy
+	timeout        time.Duration
+	cache          *CachePolicy
+	maxConcurrency int
+}
+
+// WithDescription describes what the node does.
+func WithDescription(description string) NodeOption {
+	return func(c *nodeConfig) {
+		c.metadata.Description = description
+	}
+}
+
+// WithOwner names the team or person responsible for the node.
+func WithOwner(owner string) NodeOption {
+	return func(c *nodeConfig) {
+		c.metadata.Owner = owner
+	}
+}
+
+// WithTags tags the node, such as with "llm" or "io". The option can be given
+// several times to add more tags.
+func WithTags(tags ...string) NodeOption {
+	return func(c *nodeConfig) {
+		for _, tag := range tags {
+			if !slices.Contains(c.metadata.Tags, tag) {
+				c.metadata.Tags = append(c.metadata.Tags, tag)
+			}
+		}
+	}
+}
+
+// WithRetry retries the node when it fails, as configured by policy. Every
+// attempt starts from the state the node was first run with, restored by
+// cloning it (see Cloner). Interrupts are not retried.
+func WithRetry(policy RetryPolicy) NodeOption {
+	return func(c *nodeConfig) {
+		c.retry = &policy
+	}
+}
+
+// WithTimeout cancels the context of the node when a run lasts longer than
+// timeout. With WithRetry, every attempt gets its own timeout.
+func WithTimeout(timeout time.Duration) NodeOption {
+	return func(c *nodeConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithCache caches the state resulting from the node by the state it runs
+// with, as configured by policy, so that the node does not run again for the
+// same state while the result is cached. The cache belongs to the compiled
+// graph.
+func WithCache(policy CachePolicy) NodeOption {
+	return func(c *nodeConfig) {
+		c.cache = &policy
+	}
+}
+
+// WithMaxConcurrency limits how many runs of the node the compiled graph
+// executes at once, such as across concurrent invocations, to n. Further
+// runs wait for a slot or for their context to be done.
+func WithMaxConcurrency(n int) NodeOption {
+	return func(c *nodeConfig) {
+		c.maxConcurrency = n
+	}
+}
+
+// EdgeOption configures an edge added with StateGraph.AddEdge.
+type EdgeOption func(*edgeConfig)
+
+type edgeConfig struct {
+	label    string
+	priority int
+}
+
+// WithLabel names the edge, such as "needs_review", to tell in drawings why
+// the run goes that way. The edges of conditional edges are labeled with
+// their routes, see WithMap.
+func WithLabel(label string) EdgeOption {
+	return func(c *edgeConfig) {
+		c.label = label
+	}
+}
+
+// WithPriority sets the priority of the edge among the edges from its node,
+// zero by default. A run follows a single edge from every node: the one with
+// the highest priority, the first added among edges of equal priority. Other
+// edges from the node are not followed, although Validate counts them. The
+// priority of conditional edges is set with WithBranchPriority.
+func WithPriority(priority int) EdgeOption {
+	return func(c *edgeConfig) {
+		c.priority = priority
+	}
+}
+
+// InvokeOption configures a single invocation of a Runnable.
+type InvokeOption func(*invokeConfig)
+
+type invokeConfig struct {
+	threadID    string
+	resume      bool
+	resumeValue any
+	cont        bool
+	maxSteps    int
+	pause       <-chan struct{}
+	entryPoint  string
+
+	// maxParallelism is the number of tasks nodes execute at once, see
+	// WithMaxParallelism.
+	maxParallelism int
+
+	// pool bounds the nodes of fan-outs running at once, see
+	// WithWorkerPool.
+	pool *WorkerPool
+
+	// messageStream receives the deltas emitted by nodes, see
+	// WithMessageStream.
+	messageStream func(ctx context.Context, d MessageDelta) error
+
+	// callbacks are CallbackHandler[T]s for the state type of the graph,
+	// checked by Invoke.
+	callbacks []any
+}
+
+// WithRunCallbacks notifies handlers of the node runs of this invocation
+// only, after the handlers given to WithCallbacks, such as to stream its
+// progress to a client. Invoke returns ErrInvalidOption if the handlers do
+// not match the state type of the graph.
+func WithRunCallbacks[T any](handlers ...CallbackHandler[T]) InvokeOption {
+	return func(c *invokeConfig) {
+		for _, h := range handlers {
+			c.callbacks = append(c.callbacks, h)
+		}
+	}
+}
+
+// WithEntryPoint starts the invocation from the named entry point, see
+// StateGraph.SetNamedEntryPoint. Invoke returns ErrEntryPointNotSet if the
+// graph has no such entry point. Resumed runs continue where they were
+// interrupted.
+func WithEntryPoint(name string) InvokeOption {
+	return func(c *invokeConfig) {
+		c.entryPoint = name
+	}
+}
+
+// WithThreadID runs the invocation on the given thread. When the graph was
+// compiled with a checkpointer, the state is checkpointed on that thread.
+func WithThreadID(threadID string) InvokeOption {
+	return func(c *invokeConfig) {
+		c.threadID = threadID
+	}
+}
+
+// WithResume resumes the interrupted run of the thread (see WithThreadID):
+// the state is restored from the latest checkpoint of the thread and the
+// interrupted node runs again, its call to Interrupt returning value.
+func WithResume(value any) InvokeOption {
+	return func(c *invokeConfig) {
+		c.resume = true
+		c.resumeValue = value
+	}
+}
+
+// WithContinue continues the paused run of the thread (see WithMaxSteps)
+// from its latest checkpoint, running the nodes the checkpoint scheduled. A
+// thread whose run ended has nothing left to run. Invoke returns the
+// interrupt of an interrupted thread, which is continued with WithResume.
+func WithContinue() InvokeOption {
+	return func(c *invokeConfig) {
+		c.cont = true
+	}
+}
+
+// WithMaxSteps pauses the run after n nodes ran, returning ErrPaused if
+// nodes are left to run. The run is checkpointed and continued with
+// WithContinue, such as by another process, so it needs a thread on a graph
+// compiled with a checkpointer.
+func WithMaxSteps(n int) InvokeOption {
+	return func(c *invokeConfig) {
+		c.maxSteps = n
+	}
+}
+
+// WithPause pauses the run once pause is closed, after the node running
+// then, returning ErrPaused if nodes are left to run. As with WithMaxSteps,
+// the run is checkpointed and continued with WithContinue, such as when a
+// server shutting down parks its runs instead of losing their progress.
+func WithPause(pause <-chan struct{}) InvokeOption {
+	return func(c *invokeConfig) {
+		c.pause = pause
+	}
+}
+
+// WithMaxParallelism limits the tasks the nodes of the run execute at once,
+// such as the tool calls of prebuilt.ToolNodeWithPool, to n, so that the
+// concurrency of a run can be tuned to the capacity of the services it
+// calls. Further tasks are queued and start in order as running ones end.
+// Nodes read the limit with MaxParallelism.
+//
+// The nodes a conditional edge routes to run at once too, at most n at a
+// time, if the state implements Reducer: each runs on its own copy of the
+// state, forked if the state implements Forker, the copies being merged into
+// the state with Reduce in route order once all ended, and the edges of the
+// first route are followed, as when they run one at a time. A MessageState
+// merges the messages each node added, changed or removed, such as with
+// RemoveMessage or its window policy, telling them apart by ID and content
+// from those it left as they were, which are not merged so as not to undo
+// the changes of the other nodes. They run one at a time if some are
+// interrupted before or after, see WithInterruptBefore, or would exceed the
+// step limits of the run. If one fails or interrupts the
+// run, the others are canceled and their changes dropped; a resumed run runs
+// them one at a time, the interrupted one first. The checkpoint of the
+// fan-out counts a step per node.
+//
+// Invoke returns ErrInvalidOption if n is negative.
+func WithMaxParallelism(n int) InvokeOption {
+	return func(c *invokeConfig) {
+		c.maxParallelism = n
+	}
+}
+
+// WithWorkerPool runs the nodes of fan-outs in pool, each holding a slot of
+// it while it runs, so that runs sharing pool, and the tool nodes sharing it
+// such as prebuilt.ToolNodeWithPool, are bounded together. Fan-outs run at
+// once as with WithMaxParallelism, at most the size of pool nodes at a time
+// unless the run sets a lower limit. Nodes holding a slot run the work they
+// fan out in their slot, one at a time, see WorkerPool.
+//
+// Graphs run by the nodes of the run, such as subgraphs, use pool too unless
+// invoked with a pool of their own.
+func WithWorkerPool(pool *WorkerPool) InvokeOption {
+	return func(c *invokeConfig) {
+		c.pool = pool
+	}
+}
+
+// WithMessageStream sends the message deltas nodes emit with
+// EmitMessageDelta while they run, such as the tokens of a reply being
+// generated, to send, so that clients see replies before their node ends.
+func WithMessageStream(send func(ctx context.Context, d MessageDelta) error) InvokeOption {
+	return func(c *invokeConfig) {
+		c.messageStream = send
+	}
+}
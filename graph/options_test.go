@@ -0,0 +1,256 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// newPipeline builds a graph running the nodes a, b and c in order, each
+// adding one to the state.
+func newPipeline() *graph.StateGraph[int] {
+	g := graph.NewStateGraph[int]()
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNode(name, func(_ context.Context, n *int) error {
+			*n++
+			return nil
+		})
+	}
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", graph.END)
+	g.SetEntryPoint("a")
+	return g
+}
+
+func TestStaticInterrupts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		option     graph.CompileOption
+		wantState  int
+		wantBefore bool
+	}{
+		{name: "Before", option: graph.WithInterruptBefore("b"), wantState: 1, wantBefore: true},
+		{name: "After", option: graph.WithInterruptAfter("b"), wantState: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cp := graph.NewMemorySaver[int]()
+			runnable, err := newPipeline().Compile(graph.WithCheckpointer[int](cp), tc.option)
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			ctx := context.Background()
+			n := 0
+			err = runnable.Invoke(ctx, &n, graph.WithThreadID("thread"))
+			var gi *graph.GraphInterrupt
+			if !errors.As(err, &gi) {
+				t.Fatalf("expected an interrupt, but got %v", err)
+			}
+			if gi.Node != "b" || gi.Before != tc.wantBefore || gi.After == tc.wantBefore {
+				t.Errorf("expected interrupt at b with Before %t, but got %+v", tc.wantBefore, gi)
+			}
+			if n != tc.wantState {
+				t.Errorf("expected state %d, but got %d", tc.wantState, n)
+			}
+
+			// The resumed run is not interrupted again by the same node.
+			var resumed int
+			if err := runnable.Invoke(ctx, &resumed, graph.WithThreadID("thread"), graph.WithResume(nil)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resumed != 3 {
+				t.Errorf("expected state 3, but got %d", resumed)
+			}
+		})
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[int]()
+	g.AddNode("loop", func(_ context.Context, n *int) error {
+		*n++
+		return nil
+	})
+	g.AddConditionalEdges("loop", func(context.Context, *int) ([]string, error) {
+		return []string{"loop"}, nil
+	}, graph.WithMap[int](map[string]string{"loop": "loop", "done": graph.END}))
+	g.SetEntryPoint("loop")
+	runnable, err := g.Compile(graph.WithAllowedCycle("loop"), graph.WithStepLimit(5))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); !errors.Is(err, graph.ErrStepLimit) {
+		t.Errorf("expected error %v, but got %v", graph.ErrStepLimit, err)
+	}
+	if n != 5 {
+		t.Errorf("expected 5 steps, but got %d", n)
+	}
+}
+
+func TestImplicitEnd(t *testing.T) {
+	t.Parallel()
+
+	g := newPipeline()
+	g.RemoveEdge("c", graph.END)
+	if _, err := g.Compile(); !errors.Is(err, graph.ErrNoPathToEnd) {
+		t.Fatalf("expected error %v, but got %v", graph.ErrNoPathToEnd, err)
+	}
+
+	runnable, err := g.Compile(graph.WithImplicitEnd())
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3, but got %d", n)
+	}
+}
+
+// recorder is a CallbackHandler recording the node runs.
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) NodeStart(_ context.Context, node string, n *int) {
+	r.events = append(r.events, "start "+node)
+}
+
+func (r *recorder) NodeEnd(_ context.Context, node string, n *int, err error) {
+	r.events = append(r.events, "end "+node)
+}
+
+func (r *recorder) End(_ context.Context, node string, n *int) {
+	r.events = append(r.events, "END after "+node)
+}
+
+// noopHandler is a CallbackHandler ignoring the node runs.
+type noopHandler[T any] struct{}
+
+func (noopHandler[T]) NodeStart(context.Context, string, *T)      {}
+func (noopHandler[T]) NodeEnd(context.Context, string, *T, error) {}
+
+func TestCallbacks(t *testing.T) {
+	t.Parallel()
+
+	rec := &recorder{}
+	var debug bytes.Buffer
+	runnable, err := newPipeline().Compile(graph.WithCallbacks[int](rec), graph.WithDebug(&debug))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"start a", "end a", "start b", "end b", "start c", "end c", "END after c"}
+	if !slices.Equal(rec.events, want) {
+		t.Errorf("expected events %q, but got %q", want, rec.events)
+	}
+	wantDebug := "node a: started\nnode a: done, state: 1\nnode b: started\n"
+	if !strings.HasPrefix(debug.String(), wantDebug) {
+		t.Errorf("expected debug output to start with %q, but got %q", wantDebug, debug.String())
+	}
+}
+
+func TestRunCallbacks(t *testing.T) {
+	t.Parallel()
+
+	runnable, err := newPipeline().Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	rec := &recorder{}
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n, graph.WithRunCallbacks[int](rec)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rec.events) != 7 {
+		t.Errorf("expected 7 events, but got %q", rec.events)
+	}
+
+	// Handlers are notified of their invocation only.
+	n = 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rec.events) != 7 {
+		t.Errorf("expected 7 events, but got %q", rec.events)
+	}
+
+	err = runnable.Invoke(context.Background(), &n, graph.WithRunCallbacks[string](noopHandler[string]{}))
+	if !errors.Is(err, graph.ErrInvalidOption) {
+		t.Errorf("expected error %v, but got %v", graph.ErrInvalidOption, err)
+	}
+}
+
+func TestCompileOptionErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		opts    []graph.CompileOption
+		wantErr error
+	}{
+		{
+			name:    "Interrupts without checkpointer",
+			opts:    []graph.CompileOption{graph.WithInterruptBefore("b")},
+			wantErr: graph.ErrInvalidOption,
+		},
+		{
+			name: "Interrupt at unknown node",
+			opts: []graph.CompileOption{
+				graph.WithCheckpointer[int](graph.NewMemorySaver[int]()),
+				graph.WithInterruptAfter("ghost"),
+			},
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Negative step limit",
+			opts:    []graph.CompileOption{graph.WithStepLimit(-1)},
+			wantErr: graph.ErrInvalidOption,
+		},
+		{
+			name:    "Callbacks of another state type",
+			opts:    []graph.CompileOption{graph.WithCallbacks[string](noopHandler[string]{})},
+			wantErr: graph.ErrInvalidOption,
+		},
+		{
+			name:    "Parallel scheduler without reducer",
+			opts:    []graph.CompileOption{graph.WithScheduler(graph.SchedulerParallel)},
+			wantErr: graph.ErrInvalidOption,
+		},
+		{
+			name:    "Unknown scheduler",
+			opts:    []graph.CompileOption{graph.WithScheduler(graph.Scheduler(-1))},
+			wantErr: graph.ErrInvalidOption,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := newPipeline().Compile(tc.opts...); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, but got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package graph
+
+import "context"
+
+// maxParallelismKey is the context key of the parallelism limit of a run,
+// see WithMaxParallelism.
+type maxParallelismKey struct{}
+
+// MaxParallelism returns the number of tasks the nodes of the run of ctx may
+// execute at once, such as the tool calls of prebuilt.ToolNodeWithPool, or 0
+// if the run sets no limit, see WithMaxParallelism. Nodes fanning out work
+// should run at most that many tasks at once, queueing the others in order.
+func MaxParallelism(ctx context.Context) int {
+	n, _ := ctx.Value(maxParallelismKey{}).(int)
+	return n
+}
+
+// WorkerPool bounds the tasks running at once across the runs and nodes
+// sharing it, such as the nodes of fan-outs, see WithWorkerPool, and the tool
+// calls of prebuilt.ToolNodeWithPool, so that bursts of work do not open
+// thousands of connections at once. Tasks beyond the size of the pool wait
+// for a running task to end. It is safe for concurrent use.
+//
+// Tasks started by a task holding a slot, such as the tool calls of a node
+// of a fan-out, run in the slot of their caller, one at a time, rather than
+// wait for another slot, which could never free up once all slots are held
+// by callers; see Held.
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// NewWorkerPool returns a WorkerPool running up to size tasks at once, at
+// least one.
+func NewWorkerPool(size int) *WorkerPool {
+	return &WorkerPool{slots: make(chan struct{}, max(size, 1))}
+}
+
+// Size returns the number of tasks the pool runs at once.
+func (p *WorkerPool) Size() int {
+	return cap(p.slots)
+}
+
+// heldKey is the context key of the tasks holding a slot of pool.
+type heldKey struct {
+	pool *WorkerPool
+}
+
+// Held reports whether ctx is the context of a task holding a slot of p, as
+// returned by Acquire. Such a task runs the tasks it starts itself, one at a
+// time, rather than acquire slots for them.
+func (p *WorkerPool) Held(ctx context.Context) bool {
+	return ctx.Value(heldKey{p}) != nil
+}
+
+// Acquire waits for a slot of p, or for ctx to be done, and returns the
+// context of the task holding it, which Held reports. The slot is freed with
+// Release.
+func (p *WorkerPool) Acquire(ctx context.Context) (context.Context, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return context.WithValue(ctx, heldKey{p}, true), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Release frees a slot acquired with Acquire.
+func (p *WorkerPool) Release() {
+	<-p.slots
+}
+
+// workerPoolKey is the context key of the worker pool of a run, see
+// WithWorkerPool.
+type workerPoolKey struct{}
@@ -0,0 +1,477 @@
+package graph_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestWithMaxParallelism(t *testing.T) {
+	t.Parallel()
+
+	var got []int
+	g := graph.NewStateGraph[int]()
+	g.AddNode("node", func(ctx context.Context, _ *int) error {
+		got = append(got, graph.MaxParallelism(ctx))
+		return nil
+	})
+	g.AddEdge("node", graph.END)
+	g.SetEntryPoint("node")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	var state int
+	if err := runnable.Invoke(context.Background(), &state); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if err := runnable.Invoke(context.Background(), &state, graph.WithMaxParallelism(4)); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if len(got) != 2 || got[0] != 0 || got[1] != 4 {
+		t.Errorf("MaxParallelism = %v, want [0 4]", got)
+	}
+
+	err = runnable.Invoke(context.Background(), &state, graph.WithMaxParallelism(-1))
+	if !errors.Is(err, graph.ErrInvalidOption) {
+		t.Errorf("Invoke with negative parallelism = %v, want %v", err, graph.ErrInvalidOption)
+	}
+}
+
+// fanOutGraph returns a graph whose router fans out to the nodes a, b and
+// c, each adding a message, and whose first route continues to join. fail
+// is the error node b returns, if any. peak records the most nodes running
+// at once.
+func fanOutGraph(t *testing.T, peak *atomic.Int32, fail func(ctx context.Context) error, opts ...graph.CompileOption) *graph.Runnable[graph.MessageState] {
+	t.Helper()
+
+	var running atomic.Int32
+	add := func(name string) func(context.Context, *graph.MessageState) error {
+		return func(ctx context.Context, state *graph.MessageState) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			if name == "b" && fail != nil {
+				if err := fail(ctx); err != nil {
+					return err
+				}
+			}
+			select {
+			case <-time.After(20 * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, name))
+			return nil
+		}
+	}
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("router", func(_ context.Context, state *graph.MessageState) error {
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "router"))
+		return nil
+	})
+	for _, name := range []string{"a", "b", "c", "join"} {
+		g.AddNode(name, add(name))
+	}
+	g.AddConditionalEdges("router", func(context.Context, *graph.MessageState) ([]string, error) {
+		return []string{"a", "b", "c"}, nil
+	})
+	g.AddEdge("a", "join")
+	g.AddEdge("b", graph.END)
+	g.AddEdge("c", graph.END)
+	g.AddEdge("join", graph.END)
+	g.SetEntryPoint("router")
+	runnable, err := g.Compile(opts...)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return runnable
+}
+
+func TestFanOut(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		opts     []graph.InvokeOption
+		wantPeak int32
+		want     []string
+	}{
+		{
+			name:     "One at a time",
+			wantPeak: 1,
+			want:     []string{"router", "c", "b", "a", "join"},
+		},
+		{
+			name:     "At once",
+			opts:     []graph.InvokeOption{graph.WithMaxParallelism(2)},
+			wantPeak: 2,
+			want:     []string{"router", "a", "b", "c", "join"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var peak atomic.Int32
+			runnable := fanOutGraph(t, &peak, nil)
+			state := graph.NewMessageState()
+			if err := runnable.Invoke(context.Background(), &state, tc.opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := peak.Load(); got != tc.wantPeak {
+				t.Errorf("expected %d nodes at once, but got %d", tc.wantPeak, got)
+			}
+			if got := texts(state); !slices.Equal(got, tc.want) {
+				t.Errorf("expected messages %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		scheduler graph.Scheduler
+		opts      []graph.InvokeOption
+		wantPeak  int32
+		want      []string
+	}{
+		{
+			name:      "Sequential",
+			scheduler: graph.SchedulerSequential,
+			wantPeak:  1,
+			want:      []string{"router", "c", "b", "a", "join"},
+		},
+		{
+			name:      "Parallel",
+			scheduler: graph.SchedulerParallel,
+			wantPeak:  3,
+			want:      []string{"router", "a", "b", "c", "join"},
+		},
+		{
+			name:      "Parallel with max parallelism",
+			scheduler: graph.SchedulerParallel,
+			opts:      []graph.InvokeOption{graph.WithMaxParallelism(2)},
+			wantPeak:  2,
+			want:      []string{"router", "a", "b", "c", "join"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var peak atomic.Int32
+			runnable := fanOutGraph(t, &peak, nil, graph.WithScheduler(tc.scheduler))
+			state := graph.NewMessageState()
+			if err := runnable.Invoke(context.Background(), &state, tc.opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := peak.Load(); got != tc.wantPeak {
+				t.Errorf("expected %d nodes at once, but got %d", tc.wantPeak, got)
+			}
+			if got := texts(state); !slices.Equal(got, tc.want) {
+				t.Errorf("expected messages %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFanOutError(t *testing.T) {
+	t.Parallel()
+
+	var peak atomic.Int32
+	errBoom := errors.New("boom")
+	runnable := fanOutGraph(t, &peak, func(context.Context) error { return errBoom })
+	state := graph.NewMessageState()
+	err := runnable.Invoke(context.Background(), &state, graph.WithMaxParallelism(3))
+	if !errors.Is(err, errBoom) || err.Error() != "error in node b: boom" {
+		t.Fatalf("expected the error of b, but got %v", err)
+	}
+	if got := texts(state); !slices.Equal(got, []string{"router"}) {
+		t.Errorf("expected the changes of the fan-out dropped, but got %q", got)
+	}
+}
+
+func TestFanOutInterrupt(t *testing.T) {
+	t.Parallel()
+
+	var peak atomic.Int32
+	runnable := fanOutGraph(t, &peak, func(ctx context.Context) error {
+		_, err := graph.Interrupt(ctx, "approve?")
+		return err
+	}, graph.WithCheckpointer[graph.MessageState](graph.NewMemorySaver[graph.MessageState]()))
+
+	ctx := context.Background()
+	state := graph.NewMessageState()
+	var gi *graph.GraphInterrupt
+	err := runnable.Invoke(ctx, &state, graph.WithThreadID("t1"), graph.WithMaxParallelism(3))
+	if !errors.As(err, &gi) || gi.Node != "b" || gi.Value != "approve?" {
+		t.Fatalf("expected an interrupt of b, but got %v", err)
+	}
+	cp, err := runnable.GetState(ctx, "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"b", "c", "a"}; !slices.Equal(cp.Next, want) {
+		t.Errorf("expected next nodes %q, but got %q", want, cp.Next)
+	}
+
+	// The fan-out runs again one node at a time, the interrupted one first.
+	if err := runnable.Invoke(ctx, &state, graph.WithThreadID("t1"), graph.WithResume("yes"), graph.WithMaxParallelism(3)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"router", "b", "c", "a", "join"}; !slices.Equal(texts(state), want) {
+		t.Errorf("expected messages %q, but got %q", want, texts(state))
+	}
+}
+
+func TestFanOutFork(t *testing.T) {
+	t.Parallel()
+
+	replaced := make(chan struct{})
+	var seen string
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("router", func(context.Context, *graph.MessageState) error { return nil })
+	g.AddNode("prompt", func(_ context.Context, state *graph.MessageState) error {
+		state.ReplaceSystemMessage("replaced")
+		close(replaced)
+		return nil
+	})
+	g.AddNode("reader", func(ctx context.Context, state *graph.MessageState) error {
+		select {
+		case <-replaced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		msg, _ := state.SystemMessage()
+		seen = fmt.Sprint(msg.Parts[0])
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "read"))
+		return nil
+	})
+	g.AddConditionalEdges("router", func(context.Context, *graph.MessageState) ([]string, error) {
+		return []string{"prompt", "reader"}, nil
+	})
+	g.AddEdge("prompt", graph.END)
+	g.AddEdge("reader", graph.END)
+	g.SetEntryPoint("router")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeSystem, "prompt"))
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	if err := runnable.Invoke(context.Background(), &state, graph.WithMaxParallelism(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen != "prompt" {
+		t.Errorf("expected reader to see system message %q, but got %q", "prompt", seen)
+	}
+	// The copy of the system message reader left as it was does not undo
+	// the change of prompt.
+	if got, want := texts(state), []string{"replaced", "hi", "read"}; !slices.Equal(got, want) {
+		t.Errorf("expected messages %q, but got %q", want, got)
+	}
+}
+
+// keepLast is a window policy keeping the last messages.
+type keepLast int
+
+func (n keepLast) Apply(msgs []graph.Message) []graph.Message {
+	return msgs[max(len(msgs)-int(n), 0):]
+}
+
+func TestFanOutMerge(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		window graph.WindowPolicy
+		// apply and applyB are the nodes a and b, b adding a message if
+		// applyB is nil.
+		apply  func(*graph.MessageState) error
+		applyB func(*graph.MessageState) error
+		want   []string
+	}{
+		{
+			name:  "RemoveMessage",
+			apply: func(s *graph.MessageState) error { return s.AddMessages(graph.RemoveMessage(s.Messages[0].ID)) },
+			want:  []string{"y", "b"},
+		},
+		{
+			name: "RemoveAllMessages",
+			apply: func(s *graph.MessageState) error {
+				return s.AddMessages(graph.RemoveMessage(graph.RemoveAllMessages), graph.NewMessage(llms.TextParts(llms.ChatMessageTypeAI, "a")))
+			},
+			want: []string{"b", "a"},
+		},
+		{
+			name:   "Window",
+			window: keepLast(2),
+			apply: func(s *graph.MessageState) error {
+				s.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "a"))
+				return nil
+			},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "Rebuilt",
+			apply: func(s *graph.MessageState) error {
+				m := s.Messages[0]
+				m.Parts = slices.Clone(m.Parts)
+				return s.AddMessages(m)
+			},
+			applyB: func(s *graph.MessageState) error {
+				m := graph.NewMessage(llms.TextParts(llms.ChatMessageTypeHuman, "changed"))
+				m.ID = s.Messages[0].ID
+				return s.AddMessages(m)
+			},
+			want: []string{"changed", "y"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := graph.NewStateGraph[graph.MessageState]()
+			g.AddNode("router", func(context.Context, *graph.MessageState) error { return nil })
+			g.AddNode("a", func(_ context.Context, state *graph.MessageState) error { return tc.apply(state) })
+			g.AddNode("b", func(_ context.Context, state *graph.MessageState) error {
+				if tc.applyB != nil {
+					return tc.applyB(state)
+				}
+				state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "b"))
+				return nil
+			})
+			g.AddConditionalEdges("router", func(context.Context, *graph.MessageState) ([]string, error) {
+				return []string{"b", "a"}, nil
+			})
+			g.AddEdge("a", graph.END)
+			g.AddEdge("b", graph.END)
+			g.SetEntryPoint("router")
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			// One at a time, the routes run in reverse order, a before b,
+			// while at once they merge in route order, so the messages are
+			// compared regardless of their order.
+			var got [2][]string
+			for i, opts := range [][]graph.InvokeOption{nil, {graph.WithMaxParallelism(2)}} {
+				state := graph.NewMessageState()
+				state.Window = tc.window
+				state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "x"))
+				state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "y"))
+				if err := runnable.Invoke(context.Background(), &state, opts...); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got[i] = texts(state)
+			}
+			sorted := [2][]string{slices.Sorted(slices.Values(got[0])), slices.Sorted(slices.Values(got[1]))}
+			if !slices.Equal(got[1], tc.want) {
+				t.Errorf("expected messages %q at once, but got %q", tc.want, got[1])
+			}
+			if !slices.Equal(sorted[0], sorted[1]) {
+				t.Errorf("expected the same messages one at a time and at once, but got %q and %q", got[0], got[1])
+			}
+		})
+	}
+}
+
+func TestFanOutWithoutReducer(t *testing.T) {
+	t.Parallel()
+
+	var running, peak atomic.Int32
+	g := graph.NewStateGraph[int]()
+	g.AddNode("router", func(context.Context, *int) error { return nil })
+	for _, name := range []string{"a", "b"} {
+		g.AddNode(name, func(_ context.Context, n *int) error {
+			if r := running.Add(1); r > peak.Load() {
+				peak.Store(r)
+			}
+			defer running.Add(-1)
+			time.Sleep(10 * time.Millisecond)
+			*n++
+			return nil
+		})
+		g.AddEdge(name, graph.END)
+	}
+	g.AddConditionalEdges("router", func(context.Context, *int) ([]string, error) {
+		return []string{"a", "b"}, nil
+	})
+	g.SetEntryPoint("router")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	// States that cannot be merged are not copied, the nodes run one at a
+	// time.
+	var n int
+	if err := runnable.Invoke(context.Background(), &n, graph.WithMaxParallelism(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 || peak.Load() != 1 {
+		t.Errorf("expected 2 nodes run one at a time, but got %d with %d at once", n, peak.Load())
+	}
+}
+
+// BenchmarkFanOut runs a fan-out to 256 nodes hashing 64 KiB each, one at a
+// time or on all cores.
+func BenchmarkFanOut(b *testing.B) {
+	const width = 256
+	work := make([]byte, 64<<10)
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("router", func(context.Context, *graph.MessageState) error { return nil })
+	routes := make([]string, width)
+	for i := range routes {
+		routes[i] = fmt.Sprint("branch-", i)
+		g.AddNode(routes[i], func(_ context.Context, state *graph.MessageState) error {
+			sum := sha256.Sum256(work)
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, hex.EncodeToString(sum[:4])))
+			return nil
+		})
+		g.AddEdge(routes[i], graph.END)
+	}
+	g.AddConditionalEdges("router", func(context.Context, *graph.MessageState) ([]string, error) {
+		return routes, nil
+	})
+	g.SetEntryPoint("router")
+	runnable, err := g.Compile()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name string
+		opts []graph.InvokeOption
+	}{
+		{"OneAtATime", nil},
+		{"AtOnce", []graph.InvokeOption{graph.WithMaxParallelism(runtime.GOMAXPROCS(0))}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				state := graph.NewMessageState()
+				if err := runnable.Invoke(context.Background(), &state, bc.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package graph
+
+// Successors of the nodes of a plan that are not nodes.
+const (
+	// planEnd is the successor of the nodes with an edge to END.
+	planEnd = -1
+
+	// planNone is the successor of the nodes without outgoing edges.
+	planNone = -2
+)
+
+// plan is the static execution plan of a graph whose edges are all simple:
+// its nodes by index, with the index of the node each continues to, so that
+// a run follows the graph without looking up edges by name, see
+// compilePlan. The nodes are still looked up by name, so that a run calls
+// the functions set with ReplaceNode after Compile.
+type plan[T any] struct {
+	// index is the index of the nodes by name, looked up only where a run
+	// starts.
+	index map[string]int
+
+	names []string
+
+	// next is the successor of each node: the index of the node it
+	// continues to, planEnd or planNone.
+	next []int
+}
+
+// compilePlan returns the static execution plan of the graph, or nil if
+// some of its edges are conditional. edges are the outgoing edges of the
+// nodes, see indexEdges.
+func (g *StateGraph[T]) compilePlan(edges map[string][]Edge[T]) *plan[T] {
+	for _, edge := range g.edges {
+		if _, ok := edge.(*SimpleEdge[T]); !ok {
+			return nil
+		}
+	}
+
+	p := &plan[T]{index: make(map[string]int, len(g.nodes))}
+	for name := range g.nodes {
+		p.index[name] = len(p.names)
+		p.names = append(p.names, name)
+	}
+	p.next = make([]int, len(p.names))
+	for i, name := range p.names {
+		out := edges[name]
+		switch {
+		case len(out) == 0:
+			p.next[i] = planNone
+		case out[0].(*SimpleEdge[T]).to == END:
+			p.next[i] = planEnd
+		default:
+			next, ok := p.index[out[0].(*SimpleEdge[T]).to]
+			if !ok {
+				// Validate reports edges to unknown nodes; runs fail on them
+				// as interpreted.
+				return nil
+			}
+			p.next[i] = next
+		}
+	}
+	return p
+}
+
+// lookup returns the index of the named node in p, if p is not nil and has
+// it, as nodes added to the graph after Compile are not in the plan.
+func (p *plan[T]) lookup(name string) (int, bool) {
+	if p == nil {
+		return 0, false
+	}
+	i, ok := p.index[name]
+	return i, ok
+}
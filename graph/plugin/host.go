@@ -0,0 +1,204 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// closeTimeout is how long Close waits for a plugin to stop before killing
+// it.
+const closeTimeout = 5 * time.Second
+
+// ErrNodeFailed is returned by the nodes of a plugin that failed, with the
+// error of the plugin.
+var ErrNodeFailed = errors.New("plugin: node failed")
+
+// Plugin is a running plugin, whose nodes have state type T. It is safe for
+// concurrent use.
+type Plugin[T any] struct {
+	cmd    *exec.Cmd
+	stdin  io.Closer
+	url    string
+	token  string
+	client *http.Client
+	nodes  []string
+
+	// done is closed when the process exited, with err.
+	done chan struct{}
+	err  error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Open starts cmd as a plugin and returns it once it serves its nodes. The
+// standard output and error of cmd, if set, receive the output of the plugin
+// after its handshake. ctx bounds the start of the plugin, not its lifetime:
+// the plugin runs until Close.
+func Open[T any](ctx context.Context, cmd *exec.Cmd) (*Plugin[T], error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, tokenEnv+"="+token)
+
+	// The output of the plugin is read from a pipe of its own rather than
+	// from StdoutPipe, which must be read before Wait.
+	stdout := cmd.Stdout
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = w
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	w.Close()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	p := &Plugin[T]{cmd: cmd, stdin: stdin, token: token, client: &http.Client{}, done: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+
+	out := bufio.NewReader(r)
+	type handshake struct {
+		addr string
+		err  error
+	}
+	ready := make(chan handshake, 1)
+	go func() {
+		addr, err := readHandshake(out)
+		ready <- handshake{addr, err}
+	}()
+	var h handshake
+	select {
+	case h = <-ready:
+	case <-ctx.Done():
+		h.err = ctx.Err()
+	}
+	if h.err != nil {
+		p.kill()
+		r.Close()
+		return nil, h.err
+	}
+	go func() {
+		defer r.Close()
+		io.Copy(stdout, out)
+	}()
+
+	p.url = "http://" + h.addr
+	var nodes nodesResponse
+	if err := p.call(ctx, http.MethodGet, "/nodes", nil, &nodes); err != nil {
+		p.kill()
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	p.nodes = nodes.Nodes
+	return p, nil
+}
+
+// Nodes returns the names of the nodes of the plugin.
+func (p *Plugin[T]) Nodes() []string {
+	return p.nodes
+}
+
+// Node returns the node of the plugin with the given name. The state is sent
+// to the plugin and replaced by the state the plugin returns.
+func (p *Plugin[T]) Node(name string) func(ctx context.Context, state *T) error {
+	return func(ctx context.Context, state *T) error {
+		var result T
+		if err := p.call(ctx, http.MethodPost, "/nodes/"+url.PathEscape(name), state, &result); err != nil {
+			return fmt.Errorf("node %s: %w", name, err)
+		}
+		*state = result
+		return nil
+	}
+}
+
+// Register registers the nodes of the plugin in registry under their names,
+// so that graph definitions refer to them.
+func (p *Plugin[T]) Register(registry *graph.NodeRegistry[T]) {
+	for _, name := range p.nodes {
+		registry.RegisterFunction(name, p.Node(name))
+	}
+}
+
+// Close stops the plugin, killing it if it does not stop in time, and
+// returns the error of its exit.
+func (p *Plugin[T]) Close() error {
+	p.closeOnce.Do(func() {
+		p.stdin.Close()
+		select {
+		case <-p.done:
+			p.closeErr = p.err
+		case <-time.After(closeTimeout):
+			p.kill()
+			p.closeErr = fmt.Errorf("plugin: killed after %s", closeTimeout)
+		}
+	})
+	return p.closeErr
+}
+
+// kill kills the plugin and waits for it to exit.
+func (p *Plugin[T]) kill() {
+	p.cmd.Process.Kill()
+	<-p.done
+}
+
+// call sends a request with the JSON of in, if not nil, to the plugin and
+// decodes its response into out.
+func (p *Plugin[T]) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.url+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		return fmt.Errorf("%w: %s", ErrNodeFailed, e.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,152 @@
+// Package plugin runs node functions provided by other programs, so that
+// graph definitions refer to nodes built and deployed apart from the graph.
+//
+// A plugin is a program serving its nodes with Serve:
+//
+//	func main() {
+//		err := plugin.Serve(map[string]func(context.Context, *State) error{
+//			"summarize": summarize,
+//		})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// and the host starts it with Open, registering its nodes by name for the
+// definitions of graphs, see graph.BuildGraph:
+//
+//	p, err := plugin.Open[State](ctx, exec.Command("./summarizer"))
+//	...
+//	defer p.Close()
+//	p.Register(registry)
+//
+// The plugin runs as a child process of the host, serving its nodes over
+// HTTP on a local port it announces on its standard output, states being
+// encoded as JSON. Requests carry a token the host passes to the plugin, so
+// that other local processes cannot run its nodes. The plugin stops when the
+// host closes its standard input. Nodes of plugins cannot interrupt graphs.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// tokenEnv is the environment variable passing the token of the plugin.
+const tokenEnv = "LANGGRAPHGO_PLUGIN_TOKEN"
+
+// protocol is the version of the protocol between plugins and hosts. The
+// handshake is the line "langgraphgo-plugin|<protocol>|<address>".
+const (
+	protocol        = "1"
+	handshakePrefix = "langgraphgo-plugin|" + protocol + "|"
+)
+
+var (
+	// ErrNotPlugin is returned by Serve when the program was not started by
+	// Open.
+	ErrNotPlugin = errors.New("plugin: not started by a host")
+
+	// ErrHandshake is returned by Open when the program does not announce
+	// its nodes as a plugin of this version does.
+	ErrHandshake = errors.New("plugin: invalid handshake")
+)
+
+// nodesResponse is the response listing the nodes of a plugin.
+type nodesResponse struct {
+	Nodes []string `json:"nodes"`
+}
+
+// errorResponse is the response of a failed node.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Serve serves nodes, by name, to the host that started the program with
+// Open, until the host closes the standard input of the program. It returns
+// ErrNotPlugin when the program was not started by Open.
+func Serve[T any](nodes map[string]func(ctx context.Context, state *T) error) error {
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return ErrNotPlugin
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: handler(token, nodes)}
+	go func() {
+		io.Copy(io.Discard, os.Stdin)
+		srv.Shutdown(context.Background())
+	}()
+	if _, err := fmt.Fprintln(os.Stdout, handshakePrefix+lis.Addr().String()); err != nil {
+		lis.Close()
+		return err
+	}
+	if err := srv.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handler serves nodes to the requests carrying token.
+func handler[T any](token string, nodes map[string]func(ctx context.Context, state *T) error) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /nodes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, nodesResponse{Nodes: slices.Sorted(maps.Keys(nodes))})
+	})
+	mux.HandleFunc("POST /nodes/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		fn, ok := nodes[name]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: "unknown node " + name})
+			return
+		}
+		var state T
+		if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "decode state: " + err.Error()})
+			return
+		}
+		if err := fn(r.Context(), &state); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid token"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// readHandshake returns the address announced by the first line of r.
+func readHandshake(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	addr, ok := strings.CutPrefix(strings.TrimSpace(line), handshakePrefix)
+	if !ok || addr == "" {
+		return "", fmt.Errorf("%w: %q", ErrHandshake, strings.TrimSpace(line))
+	}
+	return addr, nil
+}
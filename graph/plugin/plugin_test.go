@@ -0,0 +1,132 @@
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/plugin"
+)
+
+// helperEnv selects what the test binary runs as when started by the tests.
+const helperEnv = "PLUGIN_TEST_HELPER"
+
+type counter struct {
+	N   int      `json:"n"`
+	Log []string `json:"log"`
+}
+
+// TestMain runs the test binary as a plugin when started by the tests.
+func TestMain(m *testing.M) {
+	switch os.Getenv(helperEnv) {
+	case "plugin":
+		err := plugin.Serve(map[string]func(context.Context, *counter) error{
+			"double": func(_ context.Context, c *counter) error {
+				c.N *= 2
+				c.Log = append(c.Log, "double")
+				return nil
+			},
+			"fail": func(_ context.Context, c *counter) error {
+				return fmt.Errorf("cannot count past %d", c.N)
+			},
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "noise":
+		fmt.Println("not a plugin")
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// helper returns the command running the test binary as helper.
+func helper(helper string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), helperEnv+"="+helper)
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+func TestPlugin(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	p, err := plugin.Open[counter](ctx, helper("plugin"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(p.Nodes(), ","); got != "double,fail" {
+		t.Errorf("expected nodes double,fail, but got %s", got)
+	}
+
+	registry := graph.NewNodeRegistry[counter]()
+	registry.RegisterFunction("increment", func(_ context.Context, c *counter) error {
+		c.N++
+		c.Log = append(c.Log, "increment")
+		return nil
+	})
+	p.Register(registry)
+	const definition = `{
+		"entry_point": "increment",
+		"nodes": [{"name": "increment"}, {"name": "twice", "function": "double"}],
+		"edges": [{"from": "increment", "to": "twice"}, {"from": "twice", "to": "END"}]
+	}`
+	g, def, err := graph.LoadJSON(strings.NewReader(definition), registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runnable, err := g.Compile(def.CompileOptions()...)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	got := &counter{N: 1}
+	if err := runnable.Invoke(ctx, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.N != 4 || strings.Join(got.Log, ",") != "increment,double" {
+		t.Errorf("expected 4 after increment,double, but got %d after %v", got.N, got.Log)
+	}
+
+	state := counter{N: 3}
+	if err := p.Node("fail")(ctx, &state); !errors.Is(err, plugin.ErrNodeFailed) || !strings.Contains(err.Error(), "cannot count past 3") {
+		t.Errorf("expected the error of the plugin, but got %v", err)
+	}
+	if err := p.Node("missing")(ctx, &state); !errors.Is(err, plugin.ErrNodeFailed) {
+		t.Errorf("expected %v for an unknown node, but got %v", plugin.ErrNodeFailed, err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+	if err := p.Node("double")(ctx, &state); err == nil {
+		t.Error("expected an error after close, but got none")
+	}
+}
+
+func TestOpenHandshake(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := plugin.Open[counter](ctx, helper("noise")); !errors.Is(err, plugin.ErrHandshake) {
+		t.Errorf("expected %v, but got %v", plugin.ErrHandshake, err)
+	}
+}
+
+func TestServeNotPlugin(t *testing.T) {
+	t.Parallel()
+
+	if err := plugin.Serve(map[string]func(context.Context, *counter) error{}); !errors.Is(err, plugin.ErrNotPlugin) {
+		t.Errorf("expected %v, but got %v", plugin.ErrNotPlugin, err)
+	}
+}
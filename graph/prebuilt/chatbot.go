@@ -0,0 +1,156 @@
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/messagestate"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Node names used by the chatbot graph.
+const (
+	ChatbotNodeManageHistory = "manage_history"
+	ChatbotNodeChat          = "chat"
+)
+
+// ErrMissingThreadID is returned when a chatbot is called without a thread.
+var ErrMissingThreadID = errors.New("thread ID is required")
+
+// ChatbotConfig configures a persistent chatbot.
+type ChatbotConfig struct {
+	// Model generates the replies and the summaries.
+	Model llms.Model
+
+	// SystemPrompt is sent as the first message of every model call.
+	SystemPrompt string
+
+	// Checkpointer stores the conversations. Defaults to a MemorySaver.
+	Checkpointer graph.Checkpointer[graph.MessageState]
+
+	// MaxMessages bounds how many messages of the conversation are kept.
+	// Older messages are dropped, or summarized when Summarize is set.
+	// Defaults to 20.
+	MaxMessages int
+
+	// Summarize replaces the dropped messages with a summary produced by the
+	// model instead of forgetting them.
+	Summarize bool
+}
+
+// Chatbot is a multi-turn chatbot whose conversations are persisted by a
+// checkpointer, one thread per conversation.
+type Chatbot struct {
+	// Runnable is the compiled chatbot graph.
+	Runnable *graph.Runnable[graph.MessageState]
+
+	cfg ChatbotConfig
+}
+
+// NewChatbot assembles and compiles a chatbot graph: a node that trims (and
+// optionally summarizes) the history, followed by a node that calls the model.
+func NewChatbot(cfg ChatbotConfig) (*Chatbot, error) {
+	if cfg.Model == nil {
+		return nil, ErrMissingModel
+	}
+	if cfg.Checkpointer == nil {
+		cfg.Checkpointer = graph.NewMemorySaver[graph.MessageState]()
+	}
+	if cfg.MaxMessages <= 0 {
+		cfg.MaxMessages = 20
+	}
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	if _, err := g.AddNode(ChatbotNodeManageHistory, cfg.manageHistory); err != nil {
+		return nil, err
+	}
+	if _, err := g.AddNode(ChatbotNodeChat, cfg.chat); err != nil {
+		return nil, err
+	}
+	if err := g.SetEntryPoint(ChatbotNodeManageHistory); err != nil {
+		return nil, err
+	}
+	if err := g.AddEdge(ChatbotNodeManageHistory, ChatbotNodeChat); err != nil {
+		return nil, err
+	}
+	if err := g.AddEdge(ChatbotNodeChat, graph.END); err != nil {
+		return nil, err
+	}
+
+	runnable, err := g.Compile(graph.WithCheckpointer(cfg.Checkpointer))
+	if err != nil {
+		return nil, err
+	}
+	return &Chatbot{Runnable: runnable, cfg: cfg}, nil
+}
+
+// Chat adds a human message to the conversation of the thread and returns the
+// reply of the model.
+func (c *Chatbot) Chat(ctx context.Context, threadID, message string) (string, error) {
+	if threadID == "" {
+		return "", ErrMissingThreadID
+	}
+	state := graph.NewMessageState()
+	cp, err := c.Runnable.GetState(ctx, threadID)
+	switch {
+	case err == nil:
+		state = cp.State
+	case !errors.Is(err, graph.ErrCheckpointNotFound):
+		return "", err
+	}
+
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, message))
+	if err := c.Runnable.Invoke(ctx, &state, graph.WithThreadID(threadID)); err != nil {
+		return "", err
+	}
+	return messageText(state.LastMessage().MessageContent), nil
+}
+
+func (cfg ChatbotConfig) manageHistory(ctx context.Context, state *graph.MessageState) error {
+	if cfg.Summarize {
+		_, err := state.Compact(ctx, cfg.Model, cfg.MaxMessages)
+		return err
+	}
+	state.Messages = messagestate.Trim(state.Messages, messagestate.TrimOptions{
+		MaxMessages:  cfg.MaxMessages,
+		StartOnHuman: true,
+	})
+	return nil
+}
+
+func (cfg ChatbotConfig) chat(ctx context.Context, state *graph.MessageState) error {
+	system, history := cfg.SystemPrompt, state.Messages
+	if summary := state.Summary(); summary != "" {
+		system = strings.TrimSpace(system + "\n\n" + graph.SummaryPrefix + summary)
+		history = history[1:]
+	}
+
+	messages := make([]llms.MessageContent, 0, len(history)+1)
+	if system != "" {
+		messages = append(messages, llms.TextParts(llms.ChatMessageTypeSystem, system))
+	}
+	messages = append(messages, graph.MessageContents(history)...)
+
+	resp, err := cfg.Model.GenerateContent(ctx, messages)
+	if err != nil {
+		return err
+	}
+	if len(resp.Choices) == 0 {
+		return ErrNoChoices
+	}
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, resp.Choices[0].Content))
+	return nil
+}
+
+// messageText concatenates the text parts of a message.
+func messageText(msg llms.MessageContent) string {
+	var sb strings.Builder
+	for _, part := range msg.Parts {
+		if text, ok := part.(llms.TextContent); ok {
+			sb.WriteString(text.Text)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/prebuilt"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestChatbot(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		maxMessages int
+		summarize   bool
+		wantHistory []string
+		wantSystem  string
+	}{
+		{
+			name:        "Conversation is remembered",
+			maxMessages: 10,
+			wantHistory: []string{"one", "reply 1", "two", "reply 2", "three"},
+			wantSystem:  "Be brief.",
+		},
+		{
+			name:        "Old messages are trimmed",
+			maxMessages: 2,
+			wantHistory: []string{"three"},
+			wantSystem:  "Be brief.",
+		},
+		{
+			name:        "Old messages are summarized",
+			maxMessages: 2,
+			summarize:   true,
+			wantHistory: []string{"three"},
+			wantSystem:  "Be brief.\n\nSummary of the earlier conversation:\nsummary 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var summaries int
+			var system string
+			var history []string
+			model := &recordingModel{respond: func(messages []llms.MessageContent) string {
+				if strings.HasPrefix(textOf(messages[0]), "Summarize the conversation") {
+					summaries++
+					return fmt.Sprintf("summary %d", summaries)
+				}
+				system, history = textOf(messages[0]), nil
+				for _, msg := range messages[1:] {
+					history = append(history, textOf(msg))
+				}
+				return fmt.Sprintf("reply %d", len(history)/2+1)
+			}}
+			bot, err := prebuilt.NewChatbot(prebuilt.ChatbotConfig{
+				Model:        model,
+				SystemPrompt: "Be brief.",
+				MaxMessages:  tc.maxMessages,
+				Summarize:    tc.summarize,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ctx := context.Background()
+			for _, message := range []string{"one", "two", "three"} {
+				if _, err := bot.Chat(ctx, "thread", message); err != nil {
+					t.Fatalf("unexpected chat error: %v", err)
+				}
+			}
+			if system != tc.wantSystem {
+				t.Errorf("expected system prompt %q, but got %q", tc.wantSystem, system)
+			}
+			if strings.Join(history, "|") != strings.Join(tc.wantHistory, "|") {
+				t.Errorf("expected history %q, but got %q", tc.wantHistory, history)
+			}
+
+			// Threads are independent conversations.
+			if _, err := bot.Chat(ctx, "other", "hello"); err != nil {
+				t.Fatalf("unexpected chat error: %v", err)
+			}
+			if len(history) != 1 || history[0] != "hello" {
+				t.Errorf("expected a fresh conversation, but got %q", history)
+			}
+		})
+	}
+}
+
+func TestChatbotErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := prebuilt.NewChatbot(prebuilt.ChatbotConfig{}); !errors.Is(err, prebuilt.ErrMissingModel) {
+		t.Errorf("expected error %v, but got %v", prebuilt.ErrMissingModel, err)
+	}
+
+	bot, err := prebuilt.NewChatbot(prebuilt.ChatbotConfig{
+		Model:        &scriptedModel{respond: func(string, string) string { return "hi" }},
+		Checkpointer: graph.NewMemorySaver[graph.MessageState](),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := bot.Chat(context.Background(), "", "hello"); !errors.Is(err, prebuilt.ErrMissingThreadID) {
+		t.Errorf("expected error %v, but got %v", prebuilt.ErrMissingThreadID, err)
+	}
+}
+
+// recordingModel answers every call with the result of respond, which
+// receives the whole conversation.
+type recordingModel struct {
+	respond func(messages []llms.MessageContent) string
+}
+
+func (m *recordingModel) GenerateContent(_ context.Context, messages []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: m.respond(messages)}},
+	}, nil
+}
+
+func (m *recordingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
@@ -0,0 +1,96 @@
+package prebuilt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ClarificationNodeClarify is the name of the node added by AddClarification.
+const ClarificationNodeClarify = "clarify"
+
+// ClarificationConfig configures the clarification step.
+type ClarificationConfig struct {
+	// Model decides whether the request is ambiguous and phrases the questions.
+	Model llms.Model
+
+	// MaxQuestions is the maximum number of clarifying questions asked before
+	// handing the request over as is. Defaults to 2.
+	MaxQuestions int
+}
+
+const clarificationPrompt = `You decide whether the latest request of the user is clear enough to act on.
+If it is, answer exactly CLEAR.
+Otherwise answer with a single short question to the user that would resolve the ambiguity, and nothing else.`
+
+// AddClarification adds a clarification step to g and makes it the entry
+// point, in front of the node next, which must already be added.
+//
+// The step asks the model whether the latest user request is ambiguous. If it
+// is, the run is interrupted (see graph.Interrupt) with the clarifying
+// question as value, a string. When the run is resumed with the answer, the
+// question and the answer are added to the messages, and the step checks the
+// request again, up to MaxQuestions times, before continuing to next.
+//
+// Interrupted runs can only be resumed if the graph is compiled with a
+// checkpointer and invoked on a thread.
+func AddClarification(g *graph.StateGraph[graph.MessageState], next string, cfg ClarificationConfig) error {
+	if cfg.Model == nil {
+		return ErrMissingModel
+	}
+	if cfg.MaxQuestions <= 0 {
+		cfg.MaxQuestions = 2
+	}
+
+	if _, err := g.AddNode(ClarificationNodeClarify, cfg.clarify); err != nil {
+		return err
+	}
+	if err := g.AddEdge(ClarificationNodeClarify, next); err != nil {
+		return err
+	}
+	return g.SetEntryPoint(ClarificationNodeClarify)
+}
+
+func (cfg ClarificationConfig) clarify(ctx context.Context, state *graph.MessageState) error {
+	for asked := 0; asked < cfg.MaxQuestions; asked++ {
+		question, err := cfg.clarifyingQuestion(ctx, state.Contents())
+		if err != nil {
+			return err
+		}
+		if question == "" {
+			return nil
+		}
+
+		answer, err := graph.Interrupt(ctx, question)
+		if err != nil {
+			return err
+		}
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, question))
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprint(answer)))
+	}
+	return nil
+}
+
+// clarifyingQuestion returns the question to ask about the conversation, or
+// "" if the latest request is clear.
+func (cfg ClarificationConfig) clarifyingQuestion(ctx context.Context, messages []llms.MessageContent) (string, error) {
+	prompt := make([]llms.MessageContent, 0, len(messages)+1)
+	prompt = append(prompt, llms.TextParts(llms.ChatMessageTypeSystem, clarificationPrompt))
+	prompt = append(prompt, messages...)
+
+	resp, err := cfg.Model.GenerateContent(ctx, prompt, llms.WithTemperature(0.0))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrNoChoices
+	}
+	question := strings.TrimSpace(resp.Choices[0].Content)
+	if strings.EqualFold(strings.Trim(question, "."), "clear") {
+		return "", nil
+	}
+	return question, nil
+}
@@ -0,0 +1,110 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/prebuilt"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestClarification(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		request      string
+		maxQuestions int
+		answers      []string
+		wantMessages []string
+	}{
+		{
+			name:         "Clear request",
+			request:      "Book a table for 2 at 8pm",
+			wantMessages: []string{"Book a table for 2 at 8pm"},
+		},
+		{
+			name:         "Ambiguous request",
+			request:      "Book a table",
+			answers:      []string{"2 people", "8pm"},
+			wantMessages: []string{"Book a table", "For how many people?", "2 people", "At what time?", "8pm"},
+		},
+		{
+			name:         "Questions are capped",
+			request:      "Book a table",
+			maxQuestions: 1,
+			answers:      []string{"2 people"},
+			wantMessages: []string{"Book a table", "For how many people?", "2 people"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			model := &scriptedModel{respond: func(_, last string) string {
+				switch last {
+				case "Book a table":
+					return "For how many people?"
+				case "2 people":
+					return "At what time?"
+				default:
+					return "CLEAR"
+				}
+			}}
+
+			var agentMessages []string
+			g := graph.NewStateGraph[graph.MessageState]()
+			g.AddNode("agent", func(_ context.Context, state *graph.MessageState) error {
+				for _, msg := range state.Messages {
+					agentMessages = append(agentMessages, textOf(msg.MessageContent))
+				}
+				return nil
+			})
+			g.AddEdge("agent", graph.END)
+			err := prebuilt.AddClarification(g, "agent", prebuilt.ClarificationConfig{
+				Model:        model,
+				MaxQuestions: tc.maxQuestions,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			runnable, err := g.Compile(graph.WithCheckpointer(graph.NewMemorySaver[graph.MessageState]()))
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			ctx := context.Background()
+			state := graph.NewMessageState()
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, tc.request))
+			opts := []graph.InvokeOption{graph.WithThreadID("thread")}
+			for _, answer := range tc.answers {
+				var gi *graph.GraphInterrupt
+				if err := runnable.Invoke(ctx, &state, opts...); !errors.As(err, &gi) {
+					t.Fatalf("expected an interrupt, but got %v", err)
+				}
+				if agentMessages != nil {
+					t.Fatalf("agent ran before the request was clarified")
+				}
+				opts = []graph.InvokeOption{graph.WithThreadID("thread"), graph.WithResume(answer)}
+			}
+			if err := runnable.Invoke(ctx, &state, opts...); err != nil {
+				t.Fatalf("unexpected invoke error: %v", err)
+			}
+			if !slices.Equal(agentMessages, tc.wantMessages) {
+				t.Errorf("expected the agent to get %q, but got %q", tc.wantMessages, agentMessages)
+			}
+		})
+	}
+}
+
+func TestClarificationConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	if err := prebuilt.AddClarification(g, "agent", prebuilt.ClarificationConfig{}); !errors.Is(err, prebuilt.ErrMissingModel) {
+		t.Errorf("expected error %v, but got %v", prebuilt.ErrMissingModel, err)
+	}
+}
@@ -0,0 +1,275 @@
+package prebuilt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+var (
+	// ErrUnsupportedLanguage is returned when code is submitted in a language without a configured interpreter.
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+
+	// ErrEmptyCode is returned when a code execution request contains no code.
+	ErrEmptyCode = errors.New("no code to execute")
+
+	// ErrSandboxSetup is returned when the resource limits of an execution
+	// could not be applied, in which case the code is not run.
+	ErrSandboxSetup = errors.New("cannot set up code execution sandbox")
+
+	// ErrSandboxUnsupported is returned when executing code on a platform
+	// where the sandbox is not available.
+	ErrSandboxUnsupported = errors.New("code execution sandbox is not supported on this platform")
+)
+
+// CodeExecutorConfig configures a CodeExecutor.
+type CodeExecutorConfig struct {
+	// Interpreters maps a language name to the command that runs a source file
+	// in that language; the path of the file is appended to the command.
+	// Defaults to DefaultInterpreters.
+	Interpreters map[string][]string
+
+	// Timeout bounds the wall-clock duration of one execution. Defaults to 10 seconds.
+	Timeout time.Duration
+
+	// MaxOutputBytes caps the size of stdout and stderr, each. Output beyond the
+	// cap is discarded and reported as truncated. Defaults to 64 KiB.
+	MaxOutputBytes int
+
+	// CPUSeconds is the CPU time limit of the process. Defaults to the timeout.
+	CPUSeconds int
+
+	// MemoryBytes is the virtual memory limit of the process. Defaults to
+	// 512 MiB. A negative value disables the limit, for platforms such as
+	// macOS that reject address space limits.
+	MemoryBytes int64
+
+	// MaxFileBytes caps the size of files written by the process. Defaults to 16 MiB.
+	MaxFileBytes int64
+
+	// Env is the environment of the process. It is empty apart from PATH by default,
+	// so secrets of the host process are not leaked to generated code.
+	Env []string
+
+	// SandboxHelper is the executable applying the resource limits, which
+	// calls SandboxMain first thing in main. Defaults to the current
+	// executable.
+	SandboxHelper string
+}
+
+// DefaultInterpreters are the interpreters used when none are configured.
+var DefaultInterpreters = map[string][]string{
+	"python":     {"python3"},
+	"javascript": {"node"},
+	"bash":       {"bash"},
+	"sh":         {"sh"},
+}
+
+// CodeExecutor is a tool that runs model-generated code in a subprocess.
+//
+// Every execution happens in a fresh temporary directory, with a wall-clock
+// timeout that kills the whole process group and caps on the amount of output
+// returned to the conversation. Resource limits (CPU time, memory, file size)
+// are set with setrlimit by a helper: the current executable, or the one set
+// by CodeExecutorConfig.SandboxHelper, is executed and its call to SandboxMain
+// applies the limits before replacing the process with the interpreter, so
+// programs running a CodeExecutor call SandboxMain first thing in main. If a
+// limit cannot be applied, or the helper exits without calling SandboxMain,
+// Execute fails with ErrSandboxSetup and the code is not run. The sandbox is
+// only available on Linux and macOS; elsewhere Execute returns
+// ErrSandboxUnsupported.
+//
+// Subprocess limits are a mitigation, not an isolation boundary: run the graph
+// inside a container or VM when executing untrusted code.
+type CodeExecutor struct {
+	cfg CodeExecutorConfig
+}
+
+var _ Tool = (*CodeExecutor)(nil)
+
+// NewCodeExecutor creates a CodeExecutor, filling unset fields of cfg with defaults.
+func NewCodeExecutor(cfg CodeExecutorConfig) *CodeExecutor {
+	if cfg.Interpreters == nil {
+		cfg.Interpreters = DefaultInterpreters
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = 64 << 10
+	}
+	if cfg.CPUSeconds <= 0 {
+		cfg.CPUSeconds = int((cfg.Timeout + time.Second - 1) / time.Second)
+	}
+	if cfg.MemoryBytes == 0 {
+		cfg.MemoryBytes = 512 << 20
+	}
+	if cfg.MaxFileBytes <= 0 {
+		cfg.MaxFileBytes = 16 << 20
+	}
+	if cfg.Env == nil {
+		cfg.Env = []string{"PATH=" + os.Getenv("PATH")}
+	}
+	return &CodeExecutor{cfg: cfg}
+}
+
+// NewCodeExecutionNode returns a tool node that executes the code execution
+// tool calls of the last message with a CodeExecutor configured by cfg.
+func NewCodeExecutionNode(cfg CodeExecutorConfig) func(ctx context.Context, state *graph.MessageState) error {
+	return ToolNode(NewCodeExecutor(cfg))
+}
+
+// Name implements Tool.
+func (e *CodeExecutor) Name() string {
+	return "execute_code"
+}
+
+// Description implements Tool.
+func (e *CodeExecutor) Description() string {
+	return fmt.Sprintf("Executes code and returns its exit code, stdout and stderr. Supported languages: %s. "+
+		"Executions are limited to %s.", strings.Join(e.languages(), ", "), e.cfg.Timeout)
+}
+
+// Parameters implements ParametersSchema.
+func (e *CodeExecutor) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"language": map[string]any{"type": "string", "enum": e.languages()},
+			"code":     map[string]any{"type": "string"},
+		},
+		"required": []string{"language", "code"},
+	}
+}
+
+// CodeExecutionRequest is the input of the code execution tool.
+type CodeExecutionRequest struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// CodeExecutionResult is the outcome of running a CodeExecutionRequest.
+type CodeExecutionResult struct {
+	ExitCode        int
+	Stdout          string
+	Stderr          string
+	TimedOut        bool
+	OutputTruncated bool
+}
+
+// String renders the result in the form returned to the conversation.
+func (r CodeExecutionResult) String() string {
+	var sb strings.Builder
+	if r.TimedOut {
+		sb.WriteString("execution timed out\n")
+	}
+	fmt.Fprintf(&sb, "exit code: %d\n", r.ExitCode)
+	fmt.Fprintf(&sb, "stdout:\n%s\n", r.Stdout)
+	fmt.Fprintf(&sb, "stderr:\n%s", r.Stderr)
+	if r.OutputTruncated {
+		sb.WriteString("\n[output truncated]")
+	}
+	return sb.String()
+}
+
+// Call implements Tool. The input is a JSON encoded CodeExecutionRequest.
+func (e *CodeExecutor) Call(ctx context.Context, input string) (string, error) {
+	var req CodeExecutionRequest
+	if err := json.Unmarshal([]byte(input), &req); err != nil {
+		return "", fmt.Errorf("decode code execution request: %w", err)
+	}
+	res, err := e.Execute(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
+
+// Execute runs the code of req and returns its result. Errors are only
+// returned when the code could not be run at all; a failing program is
+// reported through the exit code and stderr of the result.
+func (e *CodeExecutor) Execute(ctx context.Context, req CodeExecutionRequest) (CodeExecutionResult, error) {
+	interpreter, ok := e.cfg.Interpreters[req.Language]
+	if !ok || len(interpreter) == 0 {
+		return CodeExecutionResult{}, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, req.Language)
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return CodeExecutionResult{}, ErrEmptyCode
+	}
+
+	dir, err := os.MkdirTemp("", "langgraphgo-exec-")
+	if err != nil {
+		return CodeExecutionResult{}, err
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "main")
+	if err := os.WriteFile(source, []byte(req.Code), 0o600); err != nil {
+		return CodeExecutionResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{max: e.cfg.MaxOutputBytes}
+	stderr := &cappedBuffer{max: e.cfg.MaxOutputBytes}
+	runErr := e.run(ctx, append(append([]string{}, interpreter...), source), dir, stdout, stderr)
+	res := CodeExecutionResult{
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		TimedOut:        errors.Is(ctx.Err(), context.DeadlineExceeded),
+		OutputTruncated: stdout.truncated || stderr.truncated,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case runErr == nil:
+	case errors.As(runErr, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case res.TimedOut:
+		res.ExitCode = -1
+	default:
+		return CodeExecutionResult{}, runErr
+	}
+	return res, nil
+}
+
+func (e *CodeExecutor) languages() []string {
+	languages := make([]string, 0, len(e.cfg.Interpreters))
+	for language := range e.cfg.Interpreters {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// cappedBuffer is an io.Writer that keeps at most max bytes and silently
+// discards the rest, so a chatty program cannot exhaust memory.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,140 @@
+//go:build linux || darwin
+
+package prebuilt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sandboxHelperArg is the first argument of the helper, followed by the
+// resource limits and the program to execute, see SandboxMain.
+const sandboxHelperArg = "-langgraphgo-sandbox-helper"
+
+// sandboxStatusOK is written by the helper to its status pipe once the
+// limits are applied, right before it replaces itself with the interpreter.
+const sandboxStatusOK = "ok"
+
+// SandboxMain runs the process as the sandbox helper of a CodeExecutor if it
+// was started as one, and returns otherwise. The helper applies the resource
+// limits of an execution and replaces itself with the interpreter, so the
+// executable started as the helper, see CodeExecutorConfig.SandboxHelper,
+// calls SandboxMain first thing in main:
+//
+//	func main() {
+//		prebuilt.SandboxMain()
+//		...
+//	}
+func SandboxMain() {
+	if len(os.Args) > 1 && os.Args[1] == sandboxHelperArg {
+		runSandboxHelper(os.Args[2:])
+	}
+}
+
+// run executes args through the sandbox helper and waits for it to exit.
+func (e *CodeExecutor) run(ctx context.Context, args []string, dir string, stdout, stderr io.Writer) error {
+	exe := e.cfg.SandboxHelper
+	if exe == "" {
+		var err error
+		if exe, err = os.Executable(); err != nil {
+			return fmt.Errorf("%w: %w", ErrSandboxSetup, err)
+		}
+	}
+	statusR, statusW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSandboxSetup, err)
+	}
+	defer statusR.Close()
+
+	limits := fmt.Sprintf("%d,%d,%d", e.cfg.CPUSeconds, e.cfg.MemoryBytes, e.cfg.MaxFileBytes)
+	cmd := exec.CommandContext(ctx, exe, append([]string{sandboxHelperArg, limits}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = e.cfg.Env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = []*os.File{statusW}
+	// Run in a dedicated process group and kill the whole group on
+	// cancellation, so children spawned by the code do not outlive the timeout.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Start()
+	statusW.Close()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSandboxSetup, err)
+	}
+	runErr := cmd.Wait()
+
+	status, _ := io.ReadAll(statusR)
+	switch {
+	case string(status) == sandboxStatusOK:
+		return runErr
+	case len(status) == 0 && ctx.Err() != nil:
+		// Killed by the timeout before the limits were even applied.
+		return runErr
+	case len(status) == 0:
+		return fmt.Errorf("%w: helper exited before applying limits: %v", ErrSandboxSetup, runErr)
+	default:
+		return fmt.Errorf("%w: %s", ErrSandboxSetup, strings.TrimPrefix(string(status), sandboxStatusOK))
+	}
+}
+
+// runSandboxHelper applies the resource limits encoded in args[0] to the
+// current process and replaces it with the program args[1:]. Failures are
+// reported on the status pipe (file descriptor 3). It never returns.
+func runSandboxHelper(args []string) {
+	status := os.NewFile(3, "sandbox-status")
+	fail := func(err error) {
+		fmt.Fprint(status, err)
+		os.Exit(126)
+	}
+
+	if len(args) < 2 {
+		fail(fmt.Errorf("no program to execute"))
+	}
+	// Resolve the program before limiting memory: the lookup allocates.
+	path, err := exec.LookPath(args[1])
+	if err != nil {
+		fail(err)
+	}
+
+	values := strings.Split(args[0], ",")
+	if len(values) != 3 {
+		fail(fmt.Errorf("malformed limits %q", args[0]))
+	}
+	resources := []struct {
+		name     string
+		resource int
+	}{
+		{"cpu", syscall.RLIMIT_CPU},
+		{"memory", syscall.RLIMIT_AS},
+		{"file size", syscall.RLIMIT_FSIZE},
+	}
+	for i, r := range resources {
+		value, err := strconv.ParseInt(values[i], 10, 64)
+		if err != nil {
+			fail(fmt.Errorf("malformed %s limit %q", r.name, values[i]))
+		}
+		if value < 0 {
+			continue
+		}
+		limit := &syscall.Rlimit{Cur: uint64(value), Max: uint64(value)}
+		if err := syscall.Setrlimit(r.resource, limit); err != nil {
+			fail(fmt.Errorf("set %s limit: %w", r.name, err))
+		}
+	}
+
+	fmt.Fprint(status, sandboxStatusOK)
+	syscall.CloseOnExec(int(status.Fd()))
+	fail(syscall.Exec(path, args[1:], os.Environ()))
+}
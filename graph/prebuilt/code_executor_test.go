@@ -0,0 +1,144 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/prebuilt"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestMain(m *testing.M) {
+	// The test binary is the sandbox helper of the code executors.
+	prebuilt.SandboxMain()
+	os.Exit(m.Run())
+}
+
+func TestCodeExecutor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		cfg     prebuilt.CodeExecutorConfig
+		req     prebuilt.CodeExecutionRequest
+		want    prebuilt.CodeExecutionResult
+		wantErr error
+	}{
+		{
+			name: "Stdout and stderr",
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "echo out; echo err >&2"},
+			want: prebuilt.CodeExecutionResult{Stdout: "out\n", Stderr: "err\n"},
+		},
+		{
+			name: "Exit code",
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "exit 3"},
+			want: prebuilt.CodeExecutionResult{ExitCode: 3},
+		},
+		{
+			name: "Output cap",
+			cfg:  prebuilt.CodeExecutorConfig{MaxOutputBytes: 4},
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "echo 123456789"},
+			want: prebuilt.CodeExecutionResult{Stdout: "1234", OutputTruncated: true},
+		},
+		{
+			name: "Timeout",
+			cfg:  prebuilt.CodeExecutorConfig{Timeout: 100 * time.Millisecond},
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "sleep 5"},
+			want: prebuilt.CodeExecutionResult{ExitCode: -1, TimedOut: true},
+		},
+		{
+			name: "Environment is not inherited",
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: `echo "${HOME:-unset}"`},
+			want: prebuilt.CodeExecutionResult{Stdout: "unset\n"},
+		},
+		{
+			name: "Limits are visible to the code",
+			cfg:  prebuilt.CodeExecutorConfig{CPUSeconds: 7},
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "ulimit -t"},
+			want: prebuilt.CodeExecutionResult{Stdout: "7\n"},
+		},
+		{
+			name: "Memory limit defaults to 512 MiB",
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "ulimit -v"},
+			want: prebuilt.CodeExecutionResult{Stdout: "524288\n"},
+		},
+		{
+			name: "Negative memory limit disables the limit",
+			cfg:  prebuilt.CodeExecutorConfig{MemoryBytes: -1},
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "ulimit -v"},
+			want: prebuilt.CodeExecutionResult{Stdout: "unlimited\n"},
+		},
+		{
+			name: "File size limit is enforced",
+			cfg:  prebuilt.CodeExecutorConfig{MaxFileBytes: 1024},
+			req:  prebuilt.CodeExecutionRequest{Language: "sh", Code: "head -c 4096 /dev/zero > out 2>/dev/null; wc -c < out"},
+			want: prebuilt.CodeExecutionResult{Stdout: "1024\n"},
+		},
+		{
+			name:    "Helper failures are reported",
+			cfg:     prebuilt.CodeExecutorConfig{Interpreters: map[string][]string{"ghost": {"langgraphgo-no-such-interpreter"}}},
+			req:     prebuilt.CodeExecutionRequest{Language: "ghost", Code: "echo unreachable"},
+			wantErr: prebuilt.ErrSandboxSetup,
+		},
+		{
+			name:    "Helper not calling SandboxMain",
+			cfg:     prebuilt.CodeExecutorConfig{SandboxHelper: "true"},
+			req:     prebuilt.CodeExecutionRequest{Language: "sh", Code: "echo unreachable"},
+			wantErr: prebuilt.ErrSandboxSetup,
+		},
+		{
+			name:    "Unsupported language",
+			req:     prebuilt.CodeExecutionRequest{Language: "cobol", Code: "DISPLAY 'HI'."},
+			wantErr: prebuilt.ErrUnsupportedLanguage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := prebuilt.NewCodeExecutor(tc.cfg).Execute(context.Background(), tc.req)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected result %+v, but got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCodeExecutionNode(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.MessageContent{
+		Role: llms.ChatMessageTypeAI,
+		Parts: []llms.ContentPart{llms.ToolCall{
+			ID:           "call-1",
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: "execute_code", Arguments: `{"language":"sh","code":"echo hello"}`},
+		}},
+	})
+
+	node := prebuilt.NewCodeExecutionNode(prebuilt.CodeExecutorConfig{})
+	if err := node(context.Background(), &state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last := state.LastMessage()
+	if last.Role != llms.ChatMessageTypeTool {
+		t.Fatalf("expected a tool message, but got %q", last.Role)
+	}
+	resp, ok := last.Parts[0].(llms.ToolCallResponse)
+	if !ok {
+		t.Fatalf("expected a tool call response, but got %T", last.Parts[0])
+	}
+	if resp.ToolCallID != "call-1" || !strings.Contains(resp.Content, "stdout:\nhello\n") {
+		t.Errorf("unexpected tool response %+v", resp)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package prebuilt
+
+import (
+	"context"
+	"io"
+)
+
+// SandboxMain returns, the sandbox of CodeExecutor not being available on
+// this platform.
+func SandboxMain() {}
+
+// run reports that the sandbox is not available on this platform.
+func (e *CodeExecutor) run(context.Context, []string, string, io.Writer, io.Writer) error {
+	return ErrSandboxUnsupported
+}
@@ -0,0 +1,218 @@
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// Node names used by the corrective RAG graph.
+const (
+	CRAGNodeRetrieve       = "retrieve"
+	CRAGNodeGradeDocuments = "grade_documents"
+	CRAGNodeTransformQuery = "transform_query"
+	CRAGNodeWebSearch      = "web_search"
+	CRAGNodeGenerate       = "generate"
+)
+
+// ErrMissingRetriever is returned when a corrective RAG graph is built without a retriever.
+var ErrMissingRetriever = errors.New("retriever is required")
+
+// CRAGState is the state threaded through the corrective RAG graph.
+type CRAGState struct {
+	// Question is the user question. It is replaced by the rewritten query when
+	// the retrieved documents are not relevant enough.
+	Question string
+
+	// Documents are the documents used as context for generation.
+	Documents []schema.Document
+
+	// Generation is the final answer produced by the model.
+	Generation string
+
+	// NeedsCorrection is set by the grading node when at least one retrieved
+	// document was judged irrelevant to the question. It sends the graph to
+	// the query rewriter, followed by web search when a WebSearcher is
+	// configured or by another retrieval otherwise.
+	NeedsCorrection bool
+
+	// Rewrites counts how many times the question has been rewritten.
+	Rewrites int
+}
+
+// WebSearcher searches the web for documents matching a query.
+type WebSearcher interface {
+	Search(ctx context.Context, query string) ([]schema.Document, error)
+}
+
+// WebSearchFunc adapts an ordinary function to the WebSearcher interface.
+type WebSearchFunc func(ctx context.Context, query string) ([]schema.Document, error)
+
+// Search calls f(ctx, query).
+func (f WebSearchFunc) Search(ctx context.Context, query string) ([]schema.Document, error) {
+	return f(ctx, query)
+}
+
+// CRAGConfig configures a corrective RAG graph.
+type CRAGConfig struct {
+	// Model is used to grade documents, rewrite queries and generate the answer.
+	Model llms.Model
+
+	// Retriever fetches the documents for the question.
+	Retriever schema.Retriever
+
+	// WebSearch is the fallback used when the retrieved documents are not
+	// relevant. If nil, the rewritten question is sent back to the retriever.
+	WebSearch WebSearcher
+
+	// MaxRewrites bounds how many times the question is rewritten before the
+	// graph generates an answer with whatever documents it has. Defaults to 1.
+	MaxRewrites int
+}
+
+const (
+	cragGradePrompt = `You are a grader assessing the relevance of a retrieved document to a user question.
+If the document contains keywords or semantic meaning related to the question, grade it as relevant.
+Answer with a single word: "yes" if the document is relevant, "no" otherwise.`
+
+	cragRewritePrompt = `You are a question re-writer that converts an input question to a better version that is optimized for search.
+Look at the input and reason about the underlying semantic intent. Respond with the improved question only.`
+
+	cragGeneratePrompt = `You are an assistant for question-answering tasks.
+Use the following pieces of retrieved context to answer the question. If you don't know the answer, just say that you don't know.
+Use three sentences maximum and keep the answer concise.`
+)
+
+// NewCorrectiveRAG builds a corrective RAG (CRAG) graph.
+//
+// The graph retrieves documents for the question, grades each of them for
+// relevance and drops the irrelevant ones. If every document is relevant it
+// generates the answer directly; otherwise it rewrites the question and either
+// falls back to web search or retrieves again with the rewritten question.
+func NewCorrectiveRAG(cfg CRAGConfig) (*graph.StateGraph[CRAGState], error) {
+	if cfg.Model == nil {
+		return nil, ErrMissingModel
+	}
+	if cfg.Retriever == nil {
+		return nil, ErrMissingRetriever
+	}
+	if cfg.MaxRewrites <= 0 {
+		cfg.MaxRewrites = 1
+	}
+
+	type node struct {
+		name string
+		fn   func(context.Context, *CRAGState) error
+	}
+	nodes := []node{
+		{CRAGNodeRetrieve, cfg.retrieve},
+		{CRAGNodeGradeDocuments, cfg.gradeDocuments},
+		{CRAGNodeTransformQuery, cfg.transformQuery},
+		{CRAGNodeGenerate, cfg.generate},
+	}
+	edges := [][2]string{
+		{CRAGNodeRetrieve, CRAGNodeGradeDocuments},
+		{CRAGNodeGenerate, graph.END},
+	}
+	if cfg.WebSearch != nil {
+		nodes = append(nodes, node{CRAGNodeWebSearch, cfg.webSearch})
+		edges = append(edges, [2]string{CRAGNodeTransformQuery, CRAGNodeWebSearch}, [2]string{CRAGNodeWebSearch, CRAGNodeGenerate})
+	} else {
+		edges = append(edges, [2]string{CRAGNodeTransformQuery, CRAGNodeRetrieve})
+	}
+
+	g := graph.NewStateGraph[CRAGState]()
+	for _, n := range nodes {
+		if _, err := g.AddNode(n.name, n.fn); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.SetEntryPoint(CRAGNodeRetrieve); err != nil {
+		return nil, err
+	}
+	for _, edge := range edges {
+		if err := g.AddEdge(edge[0], edge[1]); err != nil {
+			return nil, err
+		}
+	}
+	g.AddConditionalEdges(CRAGNodeGradeDocuments, cfg.decideToGenerate)
+
+	return g, nil
+}
+
+func (cfg CRAGConfig) retrieve(ctx context.Context, state *CRAGState) error {
+	docs, err := cfg.Retriever.GetRelevantDocuments(ctx, state.Question)
+	if err != nil {
+		return fmt.Errorf("retrieve documents: %w", err)
+	}
+	state.Documents = docs
+	return nil
+}
+
+func (cfg CRAGConfig) gradeDocuments(ctx context.Context, state *CRAGState) error {
+	relevant := make([]schema.Document, 0, len(state.Documents))
+	state.NeedsCorrection = false
+	for _, doc := range state.Documents {
+		answer, err := generateText(ctx, cfg.Model, cragGradePrompt,
+			fmt.Sprintf("Retrieved document:\n\n%s\n\nUser question: %s", doc.PageContent, state.Question))
+		if err != nil {
+			return fmt.Errorf("grade document: %w", err)
+		}
+		if isAffirmative(answer) {
+			relevant = append(relevant, doc)
+		} else {
+			state.NeedsCorrection = true
+		}
+	}
+	if len(state.Documents) == 0 {
+		state.NeedsCorrection = true
+	}
+	state.Documents = relevant
+	return nil
+}
+
+func (cfg CRAGConfig) decideToGenerate(_ context.Context, state *CRAGState) ([]string, error) {
+	if !state.NeedsCorrection || state.Rewrites >= cfg.MaxRewrites {
+		return []string{CRAGNodeGenerate}, nil
+	}
+	return []string{CRAGNodeTransformQuery}, nil
+}
+
+func (cfg CRAGConfig) transformQuery(ctx context.Context, state *CRAGState) error {
+	question, err := generateText(ctx, cfg.Model, cragRewritePrompt,
+		"Here is the initial question:\n\n"+state.Question+"\n\nFormulate an improved question.")
+	if err != nil {
+		return fmt.Errorf("rewrite question: %w", err)
+	}
+	state.Question = question
+	state.Rewrites++
+	return nil
+}
+
+func (cfg CRAGConfig) webSearch(ctx context.Context, state *CRAGState) error {
+	docs, err := cfg.WebSearch.Search(ctx, state.Question)
+	if err != nil {
+		return fmt.Errorf("web search: %w", err)
+	}
+	state.Documents = append(state.Documents, docs...)
+	return nil
+}
+
+func (cfg CRAGConfig) generate(ctx context.Context, state *CRAGState) error {
+	contents := make([]string, 0, len(state.Documents))
+	for _, doc := range state.Documents {
+		contents = append(contents, doc.PageContent)
+	}
+	answer, err := generateText(ctx, cfg.Model, cragGeneratePrompt,
+		fmt.Sprintf("Question: %s\n\nContext:\n%s", state.Question, strings.Join(contents, "\n\n")))
+	if err != nil {
+		return fmt.Errorf("generate answer: %w", err)
+	}
+	state.Generation = answer
+	return nil
+}
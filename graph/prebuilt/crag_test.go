@@ -0,0 +1,190 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/prebuilt"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// scriptedModel answers every call with the result of respond, which receives
+// the system prompt and the last message of the conversation.
+type scriptedModel struct {
+	respond func(system, last string) string
+	calls   int
+}
+
+func (m *scriptedModel) GenerateContent(_ context.Context, messages []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls++
+	var system, last string
+	for _, msg := range messages {
+		text := textOf(msg)
+		if msg.Role == llms.ChatMessageTypeSystem && system == "" {
+			system = text
+		}
+		last = text
+	}
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: m.respond(system, last)}},
+	}, nil
+}
+
+func (m *scriptedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func textOf(msg llms.MessageContent) string {
+	var sb strings.Builder
+	for _, part := range msg.Parts {
+		if text, ok := part.(llms.TextContent); ok {
+			sb.WriteString(text.Text)
+		}
+	}
+	return sb.String()
+}
+
+type staticRetriever []schema.Document
+
+func (r staticRetriever) GetRelevantDocuments(context.Context, string) ([]schema.Document, error) {
+	return r, nil
+}
+
+func cragModel(system, last string) string {
+	switch {
+	case strings.Contains(system, "grader"):
+		if strings.HasPrefix(last, "Retrieved document:\n\ngolang") {
+			return "yes"
+		}
+		return "no"
+	case strings.Contains(system, "re-writer"):
+		return "What is the Go programming language?"
+	default:
+		return "Go is a programming language."
+	}
+}
+
+func TestCorrectiveRAG(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		docs          staticRetriever
+		webSearch     bool
+		maxRewrites   int
+		wantQuestion  string
+		wantDocuments []string
+		wantRewrites  int
+	}{
+		{
+			name:          "All documents relevant",
+			docs:          staticRetriever{{PageContent: "golang is a language"}},
+			wantQuestion:  "what is golang?",
+			wantDocuments: []string{"golang is a language"},
+		},
+		{
+			name:          "Irrelevant documents trigger web search",
+			docs:          staticRetriever{{PageContent: "golang is a language"}, {PageContent: "bananas"}},
+			webSearch:     true,
+			wantQuestion:  "What is the Go programming language?",
+			wantDocuments: []string{"golang is a language", "web result"},
+			wantRewrites:  1,
+		},
+		{
+			name:          "Irrelevant documents without web search retrieve again",
+			docs:          staticRetriever{{PageContent: "bananas"}},
+			wantQuestion:  "What is the Go programming language?",
+			wantDocuments: []string{},
+			wantRewrites:  1,
+		},
+		{
+			name:          "Retrieval loop stops after MaxRewrites",
+			docs:          staticRetriever{{PageContent: "bananas"}},
+			maxRewrites:   3,
+			wantQuestion:  "What is the Go programming language?",
+			wantDocuments: []string{},
+			wantRewrites:  3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := prebuilt.CRAGConfig{
+				Model:       &scriptedModel{respond: cragModel},
+				Retriever:   tc.docs,
+				MaxRewrites: tc.maxRewrites,
+			}
+			if tc.webSearch {
+				cfg.WebSearch = prebuilt.WebSearchFunc(func(context.Context, string) ([]schema.Document, error) {
+					return []schema.Document{{PageContent: "web result"}}, nil
+				})
+			}
+			g, err := prebuilt.NewCorrectiveRAG(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			state := &prebuilt.CRAGState{Question: "what is golang?"}
+			if err := runnable.Invoke(context.Background(), state); err != nil {
+				t.Fatalf("unexpected invoke error: %v", err)
+			}
+
+			if state.Question != tc.wantQuestion {
+				t.Errorf("expected question %q, but got %q", tc.wantQuestion, state.Question)
+			}
+			if state.Rewrites != tc.wantRewrites {
+				t.Errorf("expected %d rewrites, but got %d", tc.wantRewrites, state.Rewrites)
+			}
+			if state.Generation != "Go is a programming language." {
+				t.Errorf("unexpected generation %q", state.Generation)
+			}
+			if len(state.Documents) != len(tc.wantDocuments) {
+				t.Fatalf("expected %d documents, but got %d", len(tc.wantDocuments), len(state.Documents))
+			}
+			for i, doc := range state.Documents {
+				if doc.PageContent != tc.wantDocuments[i] {
+					t.Errorf("expected document[%d] %q, but got %q", i, tc.wantDocuments[i], doc.PageContent)
+				}
+			}
+		})
+	}
+}
+
+func TestCorrectiveRAGConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		cfg     prebuilt.CRAGConfig
+		wantErr error
+	}{
+		{
+			name:    "Missing model",
+			cfg:     prebuilt.CRAGConfig{Retriever: staticRetriever{}},
+			wantErr: prebuilt.ErrMissingModel,
+		},
+		{
+			name:    "Missing retriever",
+			cfg:     prebuilt.CRAGConfig{Model: &scriptedModel{respond: cragModel}},
+			wantErr: prebuilt.ErrMissingRetriever,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := prebuilt.NewCorrectiveRAG(tc.cfg)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, but got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Package prebuilt provides ready-made graphs for common agent architectures.
+//
+// Each constructor wires the nodes and edges of a well-known topology and
+// returns the resulting graph, so callers can compile it as-is or extend it
+// with their own nodes before compiling.
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+var (
+	// ErrMissingModel is returned when a prebuilt graph is built without a model.
+	ErrMissingModel = errors.New("model is required")
+
+	// ErrNoChoices is returned when a model responds without any content choices.
+	ErrNoChoices = errors.New("model returned no choices")
+)
+
+// generateText sends a system and a human message to the model and returns the
+// content of the first choice.
+func generateText(ctx context.Context, model llms.Model, system, human string) (string, error) {
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, system),
+		llms.TextParts(llms.ChatMessageTypeHuman, human),
+	}
+	resp, err := model.GenerateContent(ctx, messages, llms.WithTemperature(0.0))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrNoChoices
+	}
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+// isAffirmative reports whether a model answer to a yes/no question is "yes".
+func isAffirmative(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return strings.HasPrefix(answer, "yes")
+}
@@ -0,0 +1,388 @@
+package prebuilt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Node names used by the SQL agent graph.
+const (
+	SQLNodeInspectSchema  = "inspect_schema"
+	SQLNodeGenerateQuery  = "generate_query"
+	SQLNodeExecuteQuery   = "execute_query"
+	SQLNodeRecoverQuery   = "recover_query"
+	SQLNodeGenerateAnswer = "generate_answer"
+)
+
+var (
+	// ErrMissingDB is returned when a SQL agent is built without a database.
+	ErrMissingDB = errors.New("database is required")
+
+	// ErrQueryFailed is returned when the generated query still fails after all recovery attempts.
+	ErrQueryFailed = errors.New("query failed after all recovery attempts")
+
+	// ErrWriteQuery is reported to the model when it generates a statement that
+	// modifies the database while writes are not allowed.
+	ErrWriteQuery = errors.New("only read-only queries are allowed")
+
+	// ErrReadOnlyTransaction is returned when the read-only transaction that
+	// guards queries cannot be started, e.g. because the driver does not support it.
+	ErrReadOnlyTransaction = errors.New("cannot start read-only transaction")
+)
+
+// SQLAgentState is the state threaded through the SQL agent graph.
+type SQLAgentState struct {
+	// Question is the natural-language question to answer.
+	Question string
+
+	// Schema is the description of the database schema given to the model.
+	Schema string
+
+	// Query is the last SQL query generated by the model.
+	Query string
+
+	// Result is the textual rendering of the rows returned by Query.
+	Result string
+
+	// Error is the error returned by the last execution of Query, if any.
+	Error string
+
+	// Attempts counts how many times a query has been executed.
+	Attempts int
+
+	// Answer is the final natural-language answer.
+	Answer string
+}
+
+// SchemaInspector describes the schema of a database in a form suitable for a prompt.
+type SchemaInspector func(ctx context.Context, db *sql.DB) (string, error)
+
+// SQLAgentConfig configures a SQL agent graph.
+type SQLAgentConfig struct {
+	// Model generates, repairs and explains the queries.
+	Model llms.Model
+
+	// DB is the database queried by the agent. Any database/sql driver can be used.
+	DB *sql.DB
+
+	// Dialect names the SQL dialect in the prompts, e.g. "PostgreSQL" or "SQLite".
+	Dialect string
+
+	// InspectSchema describes the schema of DB. Defaults to InformationSchemaInspector.
+	InspectSchema SchemaInspector
+
+	// MaxAttempts bounds how many times a query is executed, including
+	// executions of repaired queries. Defaults to 3.
+	MaxAttempts int
+
+	// MaxRows bounds how many rows of a result are shown to the model. Defaults to 50.
+	MaxRows int
+
+	// AllowWrites lets the agent execute statements other than queries. When
+	// false, every query runs in a read-only transaction that is always rolled
+	// back, so the driver must support sql.TxOptions.ReadOnly.
+	AllowWrites bool
+}
+
+const (
+	sqlGeneratePrompt = `You are an expert in %s. Given an input question, create a syntactically correct query to run.
+Only use the tables and columns in the schema below. Respond with the SQL query only, without any explanation.
+
+Schema:
+%s`
+
+	sqlRecoverPrompt = `You are an expert in %s. The query below failed with the given error.
+Fix the query so that it answers the question. Respond with the corrected SQL query only.
+
+Schema:
+%s`
+
+	sqlAnswerPrompt = `You are a helpful data analyst. Answer the question using the result of the SQL query.
+If the result is empty, say that no matching data was found.`
+)
+
+// NewSQLAgent builds a natural-language-to-SQL agent graph.
+//
+// The graph inspects the schema, asks the model for a query and executes it.
+// When the execution fails the error is handed back to the model to repair
+// the query, up to MaxAttempts executions; the result of the first successful
+// query is then turned into an answer.
+func NewSQLAgent(cfg SQLAgentConfig) (*graph.StateGraph[SQLAgentState], error) {
+	if cfg.Model == nil {
+		return nil, ErrMissingModel
+	}
+	if cfg.DB == nil {
+		return nil, ErrMissingDB
+	}
+	if cfg.Dialect == "" {
+		cfg.Dialect = "SQL"
+	}
+	if cfg.InspectSchema == nil {
+		cfg.InspectSchema = InformationSchemaInspector
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 50
+	}
+
+	g := graph.NewStateGraph[SQLAgentState]()
+	for _, node := range []struct {
+		name string
+		fn   func(context.Context, *SQLAgentState) error
+	}{
+		{SQLNodeInspectSchema, cfg.inspectSchema},
+		{SQLNodeGenerateQuery, cfg.generateQuery},
+		{SQLNodeExecuteQuery, cfg.executeQuery},
+		{SQLNodeRecoverQuery, cfg.recoverQuery},
+		{SQLNodeGenerateAnswer, cfg.generateAnswer},
+	} {
+		if _, err := g.AddNode(node.name, node.fn); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := g.SetEntryPoint(SQLNodeInspectSchema); err != nil {
+		return nil, err
+	}
+	for _, edge := range [][2]string{
+		{SQLNodeInspectSchema, SQLNodeGenerateQuery},
+		{SQLNodeGenerateQuery, SQLNodeExecuteQuery},
+		{SQLNodeRecoverQuery, SQLNodeExecuteQuery},
+		{SQLNodeGenerateAnswer, graph.END},
+	} {
+		if err := g.AddEdge(edge[0], edge[1]); err != nil {
+			return nil, err
+		}
+	}
+	g.AddConditionalEdges(SQLNodeExecuteQuery, cfg.routeExecution)
+
+	return g, nil
+}
+
+// InformationSchemaInspector describes the tables and columns listed in
+// information_schema.columns, which most SQL databases provide.
+func InformationSchemaInspector(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT table_name, column_name, data_type
+FROM information_schema.columns
+WHERE table_schema NOT IN ('information_schema', 'pg_catalog', 'mysql', 'performance_schema', 'sys')
+ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	current := ""
+	for rows.Next() {
+		var table, column, dataType string
+		if err := rows.Scan(&table, &column, &dataType); err != nil {
+			return "", err
+		}
+		if table != current {
+			if current != "" {
+				sb.WriteString(")\n")
+			}
+			fmt.Fprintf(&sb, "%s(", table)
+			current = table
+		} else {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s %s", column, dataType)
+	}
+	if current != "" {
+		sb.WriteString(")\n")
+	}
+	return sb.String(), rows.Err()
+}
+
+// SQLiteSchemaInspector describes the tables of a SQLite database using the
+// CREATE statements stored in sqlite_master.
+func SQLiteSchemaInspector(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT sql FROM sqlite_master WHERE type = 'table' AND sql IS NOT NULL ORDER BY name`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var statement string
+		if err := rows.Scan(&statement); err != nil {
+			return "", err
+		}
+		statements = append(statements, statement)
+	}
+	return strings.Join(statements, "\n"), rows.Err()
+}
+
+func (cfg SQLAgentConfig) inspectSchema(ctx context.Context, state *SQLAgentState) error {
+	schema, err := cfg.InspectSchema(ctx, cfg.DB)
+	if err != nil {
+		return fmt.Errorf("inspect schema: %w", err)
+	}
+	state.Schema = schema
+	return nil
+}
+
+func (cfg SQLAgentConfig) generateQuery(ctx context.Context, state *SQLAgentState) error {
+	query, err := generateText(ctx, cfg.Model, fmt.Sprintf(sqlGeneratePrompt, cfg.Dialect, state.Schema), state.Question)
+	if err != nil {
+		return fmt.Errorf("generate query: %w", err)
+	}
+	state.Query = stripCodeFence(query)
+	return nil
+}
+
+func (cfg SQLAgentConfig) recoverQuery(ctx context.Context, state *SQLAgentState) error {
+	query, err := generateText(ctx, cfg.Model, fmt.Sprintf(sqlRecoverPrompt, cfg.Dialect, state.Schema),
+		fmt.Sprintf("Question: %s\n\nQuery:\n%s\n\nError: %s", state.Question, state.Query, state.Error))
+	if err != nil {
+		return fmt.Errorf("recover query: %w", err)
+	}
+	state.Query = stripCodeFence(query)
+	return nil
+}
+
+func (cfg SQLAgentConfig) executeQuery(ctx context.Context, state *SQLAgentState) error {
+	state.Attempts++
+	state.Error = ""
+	state.Result = ""
+
+	result, err := cfg.runQuery(ctx, state.Query)
+	if errors.Is(err, ErrReadOnlyTransaction) {
+		return err
+	}
+	if err != nil {
+		state.Error = err.Error()
+		if state.Attempts >= cfg.MaxAttempts {
+			return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+		}
+		return nil
+	}
+	state.Result = result
+	return nil
+}
+
+func (cfg SQLAgentConfig) runQuery(ctx context.Context, query string) (string, error) {
+	if cfg.AllowWrites {
+		rows, err := cfg.DB.QueryContext(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		defer rows.Close()
+		return formatRows(rows, cfg.MaxRows)
+	}
+
+	// The keyword check only gives the model early feedback on obvious writes;
+	// the read-only transaction is what actually prevents them.
+	if !isReadOnly(query) {
+		return "", ErrWriteQuery
+	}
+	tx, err := cfg.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrReadOnlyTransaction, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Nothing is ever committed.
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	return formatRows(rows, cfg.MaxRows)
+}
+
+func (cfg SQLAgentConfig) routeExecution(_ context.Context, state *SQLAgentState) ([]string, error) {
+	if state.Error != "" {
+		return []string{SQLNodeRecoverQuery}, nil
+	}
+	return []string{SQLNodeGenerateAnswer}, nil
+}
+
+func (cfg SQLAgentConfig) generateAnswer(ctx context.Context, state *SQLAgentState) error {
+	answer, err := generateText(ctx, cfg.Model, sqlAnswerPrompt,
+		fmt.Sprintf("Question: %s\n\nSQL query:\n%s\n\nResult:\n%s", state.Question, state.Query, state.Result))
+	if err != nil {
+		return fmt.Errorf("generate answer: %w", err)
+	}
+	state.Answer = answer
+	return nil
+}
+
+// formatRows renders up to maxRows rows as tab-separated lines preceded by a
+// header with the column names.
+func formatRows(rows *sql.Rows, maxRows int) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Join(columns, "\t"))
+	sb.WriteByte('\n')
+
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	count := 0
+	for rows.Next() {
+		if count == maxRows {
+			fmt.Fprintf(&sb, "... (truncated to %d rows)\n", maxRows)
+			break
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return "", err
+		}
+		for i, value := range values {
+			if i > 0 {
+				sb.WriteByte('\t')
+			}
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			fmt.Fprint(&sb, value)
+		}
+		sb.WriteByte('\n')
+		count++
+	}
+	return sb.String(), rows.Err()
+}
+
+// isReadOnly reports whether the statement starts with a keyword that usually
+// only reads data. It is a heuristic: writes can hide behind these keywords.
+func isReadOnly(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(strings.TrimLeft(fields[0], "(")) {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "VALUES":
+		return !strings.Contains(strings.TrimRight(strings.TrimSpace(query), ";"), ";")
+	default:
+		return false
+	}
+}
+
+// stripCodeFence removes a surrounding markdown code fence from a model answer.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,314 @@
+package prebuilt_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/prebuilt"
+)
+
+// fakeDB is a database/sql connector serving canned results for known queries
+// and failing any other query. It records the queries it receives and the
+// transactions opened on it.
+type fakeDB struct {
+	results map[string][][]driver.Value
+
+	mu      sync.Mutex
+	queries []string
+	txs     []*fakeTx
+}
+
+func newFakeDB(t *testing.T, results map[string][][]driver.Value) (*fakeDB, *sql.DB) {
+	t.Helper()
+	fdb := &fakeDB{results: results}
+	db := sql.OpenDB(fdb)
+	t.Cleanup(func() { db.Close() })
+	return fdb, db
+}
+
+func (d *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d *fakeDB) Driver() driver.Driver                        { return d }
+func (d *fakeDB) Open(string) (driver.Conn, error)             { return fakeConn{d}, nil }
+
+func (d *fakeDB) executed() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.queries)
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (fakeConn) Close() error                                { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                   { return nil, errors.New("not supported") }
+
+func (c fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	tx := &fakeTx{readOnly: opts.ReadOnly}
+	c.db.txs = append(c.db.txs, tx)
+	return tx, nil
+}
+
+type fakeTx struct {
+	readOnly   bool
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) Commit() error   { tx.committed = true; return nil }
+func (tx *fakeTx) Rollback() error { tx.rolledBack = true; return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	s.db.queries = append(s.db.queries, s.query)
+	s.db.mu.Unlock()
+	rows, ok := s.db.results[s.query]
+	if !ok {
+		return nil, fmt.Errorf("no such column in query %q", s.query)
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+// fakeRows uses its first row as the column names.
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	columns := make([]string, len(r.rows[0]))
+	for i, v := range r.rows[0] {
+		columns[i] = fmt.Sprint(v)
+	}
+	return columns
+}
+
+func (*fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	r.next++
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	return nil
+}
+
+const sqliteSchemaQuery = "SELECT sql FROM sqlite_master WHERE type = 'table' AND sql IS NOT NULL ORDER BY name"
+
+func sqlAgentResults() map[string][][]driver.Value {
+	return map[string][][]driver.Value{
+		sqliteSchemaQuery:            {{"sql"}, {"CREATE TABLE users (id INTEGER, name TEXT)"}},
+		"SELECT COUNT(*) FROM users": {{"count"}, {int64(2)}},
+		"DELETE FROM users":          {{"count"}, {int64(2)}},
+	}
+}
+
+func TestSQLAgent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		firstQuery    string
+		wantAttempts  int
+		wantQueries   []string
+		wantRecovered string
+		wantErr       error
+	}{
+		{
+			name:         "Valid query",
+			firstQuery:   "SELECT COUNT(*) FROM users",
+			wantAttempts: 1,
+			wantQueries:  []string{sqliteSchemaQuery, "SELECT COUNT(*) FROM users"},
+		},
+		{
+			name:          "Failing query is recovered",
+			firstQuery:    "SELECT COUNT(user) FROM users",
+			wantAttempts:  2,
+			wantQueries:   []string{sqliteSchemaQuery, "SELECT COUNT(user) FROM users", "SELECT COUNT(*) FROM users"},
+			wantRecovered: "no such column",
+		},
+		{
+			name:          "Write query is rejected before reaching the database",
+			firstQuery:    "DELETE FROM users",
+			wantAttempts:  2,
+			wantQueries:   []string{sqliteSchemaQuery, "SELECT COUNT(*) FROM users"},
+			wantRecovered: prebuilt.ErrWriteQuery.Error(),
+		},
+		{
+			name:          "Recovery gives up",
+			firstQuery:    "SELECT nope FROM users",
+			wantAttempts:  3,
+			wantRecovered: "no such column",
+			wantErr:       prebuilt.ErrQueryFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fdb, db := newFakeDB(t, sqlAgentResults())
+			var recoverPrompts []string
+			model := &scriptedModel{respond: func(system, last string) string {
+				switch {
+				case strings.Contains(system, "create a syntactically correct query"):
+					if !strings.Contains(system, "CREATE TABLE users") {
+						t.Errorf("schema missing from prompt: %q", system)
+					}
+					return "```sql\n" + tc.firstQuery + "\n```"
+				case strings.Contains(system, "failed with the given error"):
+					recoverPrompts = append(recoverPrompts, last)
+					if tc.wantErr != nil {
+						return tc.firstQuery
+					}
+					return "SELECT COUNT(*) FROM users"
+				default:
+					return "There are 2 users."
+				}
+			}}
+			g, err := prebuilt.NewSQLAgent(prebuilt.SQLAgentConfig{
+				Model:         model,
+				DB:            db,
+				Dialect:       "SQLite",
+				InspectSchema: prebuilt.SQLiteSchemaInspector,
+				MaxAttempts:   3,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			state := &prebuilt.SQLAgentState{Question: "How many users are there?"}
+			err = runnable.Invoke(context.Background(), state)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if state.Attempts != tc.wantAttempts {
+				t.Errorf("expected %d attempts, but got %d", tc.wantAttempts, state.Attempts)
+			}
+			if tc.wantRecovered != "" {
+				if len(recoverPrompts) == 0 || !strings.Contains(recoverPrompts[0], "Error: "+tc.wantRecovered) {
+					t.Errorf("expected the recovery prompt to report %q, but got %q", tc.wantRecovered, recoverPrompts)
+				}
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if got := fdb.executed(); !slices.Equal(got, tc.wantQueries) {
+				t.Errorf("expected queries %q, but got %q", tc.wantQueries, got)
+			}
+			if state.Result != "count\n2\n" {
+				t.Errorf("unexpected result %q", state.Result)
+			}
+			if state.Answer != "There are 2 users." {
+				t.Errorf("unexpected answer %q", state.Answer)
+			}
+		})
+	}
+}
+
+func TestSQLAgentQueriesRunInRolledBackReadOnlyTransactions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		allowWrites  bool
+		wantReadOnly bool
+	}{
+		{name: "Writes not allowed", wantReadOnly: true},
+		{name: "Writes allowed", allowWrites: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fdb, db := newFakeDB(t, sqlAgentResults())
+			model := &scriptedModel{respond: func(system, _ string) string {
+				if strings.Contains(system, "create a syntactically correct query") {
+					return "SELECT COUNT(*) FROM users"
+				}
+				return "There are 2 users."
+			}}
+			g, err := prebuilt.NewSQLAgent(prebuilt.SQLAgentConfig{
+				Model:         model,
+				DB:            db,
+				InspectSchema: prebuilt.SQLiteSchemaInspector,
+				AllowWrites:   tc.allowWrites,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			if err := runnable.Invoke(context.Background(), &prebuilt.SQLAgentState{Question: "How many users?"}); err != nil {
+				t.Fatalf("unexpected invoke error: %v", err)
+			}
+
+			if !tc.wantReadOnly {
+				if len(fdb.txs) != 0 {
+					t.Errorf("expected no transaction, but got %d", len(fdb.txs))
+				}
+				return
+			}
+			if len(fdb.txs) != 1 {
+				t.Fatalf("expected 1 transaction, but got %d", len(fdb.txs))
+			}
+			if tx := fdb.txs[0]; !tx.readOnly || !tx.rolledBack || tx.committed {
+				t.Errorf("expected a rolled back read-only transaction, but got %+v", *tx)
+			}
+		})
+	}
+}
+
+func TestInformationSchemaInspector(t *testing.T) {
+	t.Parallel()
+
+	const query = `SELECT table_name, column_name, data_type
+FROM information_schema.columns
+WHERE table_schema NOT IN ('information_schema', 'pg_catalog', 'mysql', 'performance_schema', 'sys')
+ORDER BY table_name, ordinal_position`
+
+	_, db := newFakeDB(t, map[string][][]driver.Value{
+		query: {
+			{"table_name", "column_name", "data_type"},
+			{"orders", "id", "integer"},
+			{"orders", "total", "numeric"},
+			{"users", "id", "integer"},
+			{"users", "name", "text"},
+		},
+	})
+
+	got, err := prebuilt.InformationSchemaInspector(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "orders(id integer, total numeric)\nusers(id integer, name text)\n"
+	if got != want {
+		t.Errorf("expected schema %q, but got %q", want, got)
+	}
+}
@@ -0,0 +1,179 @@
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrNoMessages is returned when a node that reads the conversation runs on an empty MessageState.
+var ErrNoMessages = errors.New("no messages in state")
+
+// Tool is a tool that can be called by a model. It has the same method set as
+// langchaingo's Tool, so the tools of that package can be used as is.
+type Tool interface {
+	Name() string
+	Description() string
+	Call(ctx context.Context, input string) (string, error)
+}
+
+// ToolNode returns a node function that executes the tool calls of the last
+// message in the state and appends one tool message per call with the result.
+// The calls run one at a time, in order; see ToolNodeWithPool to run them
+// concurrently.
+//
+// A call to an unknown tool, or a tool returning an error, is reported back to
+// the model in the tool response rather than failing the graph, so the model
+// gets a chance to correct itself.
+func ToolNode(ts ...Tool) func(ctx context.Context, state *graph.MessageState) error {
+	byName := toolsByName(ts)
+
+	return func(ctx context.Context, state *graph.MessageState) error {
+		if len(state.Messages) == 0 {
+			return ErrNoMessages
+		}
+		last := state.LastMessage()
+		for _, part := range last.Parts {
+			call, ok := part.(llms.ToolCall)
+			if !ok || call.FunctionCall == nil {
+				continue
+			}
+			if err := state.AddToolResult(call.ID, callTool(ctx, byName, call.FunctionCall)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ToolNodeWithPool returns a ToolNode running its calls concurrently in
+// pool, such as a pool sized for the rate limits of the services the tools
+// call, so tools must be safe for concurrent use. The results are appended
+// in the order of the calls. Runs invoked with graph.WithMaxParallelism run
+// at most that many calls at once.
+//
+// Nodes running in pool, such as the nodes of fan-outs of runs invoked with
+// graph.WithWorkerPool, or the tool nodes of agents used as tools sharing
+// the pool, run their calls one at a time in the slot of their caller rather
+// than wait for another slot, which could never free up once all slots are
+// held by callers.
+func ToolNodeWithPool(pool *graph.WorkerPool, ts ...Tool) func(ctx context.Context, state *graph.MessageState) error {
+	byName := toolsByName(ts)
+	sequential := ToolNode(ts...)
+
+	return func(ctx context.Context, state *graph.MessageState) error {
+		if pool.Held(ctx) {
+			return sequential(ctx, state)
+		}
+		if len(state.Messages) == 0 {
+			return ErrNoMessages
+		}
+		var calls []llms.ToolCall
+		for _, part := range state.LastMessage().Parts {
+			if call, ok := part.(llms.ToolCall); ok && call.FunctionCall != nil {
+				calls = append(calls, call)
+			}
+		}
+
+		// run holds the calls running, when the run limits them.
+		var run chan struct{}
+		if n := graph.MaxParallelism(ctx); n > 0 {
+			run = make(chan struct{}, n)
+		}
+		results := make([]string, len(calls))
+		var wg sync.WaitGroup
+		for i, call := range calls {
+			if run != nil {
+				select {
+				case run <- struct{}{}:
+				case <-ctx.Done():
+					wg.Wait()
+					return ctx.Err()
+				}
+			}
+			callCtx, err := pool.Acquire(ctx)
+			if err != nil {
+				if run != nil {
+					<-run
+				}
+				wg.Wait()
+				return err
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer pool.Release()
+				if run != nil {
+					defer func() { <-run }()
+				}
+				results[i] = callTool(callCtx, byName, call.FunctionCall)
+			}()
+		}
+		wg.Wait()
+
+		for i, call := range calls {
+			if err := state.AddToolResult(call.ID, results[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func toolsByName(ts []Tool) map[string]Tool {
+	byName := make(map[string]Tool, len(ts))
+	for _, t := range ts {
+		byName[t.Name()] = t
+	}
+	return byName
+}
+
+func callTool(ctx context.Context, byName map[string]Tool, fn *llms.FunctionCall) string {
+	t, ok := byName[fn.Name]
+	if !ok {
+		return fmt.Sprintf("error: unknown tool %q", fn.Name)
+	}
+	out, err := t.Call(ctx, fn.Arguments)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return out
+}
+
+// ToolDefinitions returns the definitions of the given tools in the form
+// expected by llms.WithTools. Tools implementing ParametersSchema advertise
+// their JSON schema; the others take a single string input.
+func ToolDefinitions(ts ...Tool) []llms.Tool {
+	defs := make([]llms.Tool, 0, len(ts))
+	for _, t := range ts {
+		var params any = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"input": map[string]any{"type": "string"},
+			},
+			"required": []string{"input"},
+		}
+		if p, ok := t.(ParametersSchema); ok {
+			params = p.Parameters()
+		}
+		defs = append(defs, llms.Tool{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:        t.Name(),
+				Description: t.Description(),
+				Parameters:  params,
+			},
+		})
+	}
+	return defs
+}
+
+// ParametersSchema is implemented by tools that take structured JSON arguments.
+type ParametersSchema interface {
+	// Parameters returns the JSON schema of the tool arguments.
+	Parameters() any
+}
@@ -0,0 +1,239 @@
+package prebuilt_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/prebuilt"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// slowTool records the most calls it had running at once.
+type slowTool struct {
+	running, peak atomic.Int32
+}
+
+func (t *slowTool) Name() string        { return "slow" }
+func (t *slowTool) Description() string { return "Echoes its input, slowly." }
+
+func (t *slowTool) Call(_ context.Context, input string) (string, error) {
+	n := t.running.Add(1)
+	defer t.running.Add(-1)
+	for {
+		peak := t.peak.Load()
+		if n <= peak || t.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return input, nil
+}
+
+// agentTool runs a tool node, as an agent used as a tool would.
+type agentTool struct {
+	node func(ctx context.Context, state *graph.MessageState) error
+}
+
+func (t agentTool) Name() string        { return "agent" }
+func (t agentTool) Description() string { return "Calls the slow tool." }
+
+func (t agentTool) Call(ctx context.Context, _ string) (string, error) {
+	state := toolCalls(3)
+	if err := t.node(ctx, state); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(len(state.Messages) - 1), nil
+}
+
+// toolCalls returns a state whose last message calls the slow tool n times.
+func toolCalls(n int) *graph.MessageState {
+	return callsTo("slow", n)
+}
+
+// callsTo returns a state whose last message calls the named tool n times.
+func callsTo(name string, n int) *graph.MessageState {
+	msg := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+	for i := range n {
+		msg.Parts = append(msg.Parts, llms.ToolCall{
+			ID:           fmt.Sprint("call-", i),
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: name, Arguments: fmt.Sprint(i)},
+		})
+	}
+	state := &graph.MessageState{}
+	state.AddMessage(msg)
+	return state
+}
+
+func TestToolNodeWithPool(t *testing.T) {
+	t.Parallel()
+
+	const calls = 10
+	state := toolCalls(calls)
+
+	tool := &slowTool{}
+	pool := graph.NewWorkerPool(3)
+	if err := prebuilt.ToolNodeWithPool(pool, tool)(context.Background(), state); err != nil {
+		t.Fatalf("ToolNode: %v", err)
+	}
+
+	if peak := tool.peak.Load(); peak < 2 || peak > int32(pool.Size()) {
+		t.Errorf("peak concurrent calls = %d, want between 2 and %d", peak, pool.Size())
+	}
+	results := state.Messages[1:]
+	if len(results) != calls {
+		t.Fatalf("got %d tool messages, want %d", len(results), calls)
+	}
+	for i, m := range results {
+		resp, ok := m.Parts[0].(llms.ToolCallResponse)
+		if !ok {
+			t.Fatalf("message %d: part is %T, want llms.ToolCallResponse", i, m.Parts[0])
+		}
+		if want := fmt.Sprint("call-", i); resp.ToolCallID != want || resp.Content != fmt.Sprint(i) {
+			t.Errorf("message %d = %s %q, want %s %q", i, resp.ToolCallID, resp.Content, want, fmt.Sprint(i))
+		}
+	}
+}
+
+func TestToolNodeSequential(t *testing.T) {
+	t.Parallel()
+
+	tool := &slowTool{}
+	state := toolCalls(3)
+	if err := prebuilt.ToolNode(tool)(context.Background(), state); err != nil {
+		t.Fatalf("ToolNode: %v", err)
+	}
+	if peak := tool.peak.Load(); peak != 1 {
+		t.Errorf("peak concurrent calls = %d, want 1", peak)
+	}
+	if got := len(state.Messages) - 1; got != 3 {
+		t.Errorf("got %d tool messages, want 3", got)
+	}
+}
+
+func TestToolNodeWithPoolNested(t *testing.T) {
+	t.Parallel()
+
+	// The agents hold the only slots of the pool while their own tool
+	// nodes run.
+	pool := graph.NewWorkerPool(2)
+	tool := &slowTool{}
+	agent := agentTool{node: prebuilt.ToolNodeWithPool(pool, tool)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	state := callsTo("agent", 2)
+	if err := prebuilt.ToolNodeWithPool(pool, agent)(ctx, state); err != nil {
+		t.Fatalf("ToolNode: %v", err)
+	}
+	for i, m := range state.Messages[1:] {
+		if resp := m.Parts[0].(llms.ToolCallResponse); resp.Content != "3" {
+			t.Errorf("message %d = %q, want the results of 3 calls", i, resp.Content)
+		}
+	}
+	if peak := tool.peak.Load(); peak > 2 {
+		t.Errorf("peak concurrent calls = %d, want at most 2", peak)
+	}
+}
+
+func TestToolNodeMaxParallelism(t *testing.T) {
+	t.Parallel()
+
+	tool := &slowTool{}
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("tools", prebuilt.ToolNodeWithPool(graph.NewWorkerPool(8), tool))
+	g.AddEdge("tools", graph.END)
+	g.SetEntryPoint("tools")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	state := toolCalls(10)
+	if err := runnable.Invoke(context.Background(), state, graph.WithMaxParallelism(2)); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if peak := tool.peak.Load(); peak > 2 {
+		t.Errorf("peak concurrent calls = %d, want at most 2", peak)
+	}
+	if got := len(state.Messages) - 1; got != 10 {
+		t.Errorf("got %d tool messages, want 10", got)
+	}
+}
+
+func TestToolNodeFanOutPool(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int{1, 2} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			t.Parallel()
+
+			// The nodes of the fan-out call the slow tool themselves, then
+			// through their tool node, so that its peak counts both.
+			pool := graph.NewWorkerPool(size)
+			tool := &slowTool{}
+			tools := prebuilt.ToolNodeWithPool(pool, tool)
+			g := graph.NewStateGraph[graph.MessageState]()
+			g.AddNode("router", func(_ context.Context, state *graph.MessageState) error {
+				*state = *callsTo("slow", 2)
+				return nil
+			})
+			routes := []string{"a", "b", "c"}
+			for _, name := range routes {
+				g.AddNode(name, func(ctx context.Context, state *graph.MessageState) error {
+					if _, err := tool.Call(ctx, name); err != nil {
+						return err
+					}
+					return tools(ctx, state)
+				})
+				g.AddEdge(name, graph.END)
+			}
+			g.AddConditionalEdges("router", func(context.Context, *graph.MessageState) ([]string, error) {
+				return routes, nil
+			})
+			g.SetEntryPoint("router")
+			runnable, err := g.Compile()
+			if err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			state := graph.NewMessageState()
+			if err := runnable.Invoke(ctx, &state, graph.WithWorkerPool(pool)); err != nil {
+				t.Fatalf("Invoke: %v", err)
+			}
+			if peak := tool.peak.Load(); peak != int32(size) {
+				t.Errorf("peak concurrent calls = %d, want %d", peak, size)
+			}
+			if got := len(state.Messages) - 1; got != 6 {
+				t.Errorf("got %d tool messages, want 6", got)
+			}
+		})
+	}
+}
+
+func TestToolNodeWithPoolCanceled(t *testing.T) {
+	t.Parallel()
+
+	state := &graph.MessageState{}
+	state.AddMessage(llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+		llms.ToolCall{ID: "a", Type: "function", FunctionCall: &llms.FunctionCall{Name: "slow"}},
+		llms.ToolCall{ID: "b", Type: "function", FunctionCall: &llms.FunctionCall{Name: "slow"}},
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pool := graph.NewWorkerPool(1)
+	err := prebuilt.ToolNodeWithPool(pool, &slowTool{})(ctx, state)
+	if err != context.Canceled {
+		t.Errorf("ToolNode = %v, want %v", err, context.Canceled)
+	}
+	if len(state.Messages) != 1 {
+		t.Errorf("got %d messages, want no tool results", len(state.Messages)-1)
+	}
+}
@@ -0,0 +1,212 @@
+package pycheckpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// pyObject is a Python object serialized by LangGraph, such as a message:
+// its class, found in module, and the arguments of its constructor.
+type pyObject struct {
+	Module string
+	Name   string
+	Args   []any
+	Kwargs map[string]any
+}
+
+// messageClasses are the LangChain message classes of the roles, with their
+// modules.
+var messageClasses = map[llms.ChatMessageType][2]string{
+	llms.ChatMessageTypeHuman:   {"langchain_core.messages.human", "HumanMessage"},
+	llms.ChatMessageTypeAI:      {"langchain_core.messages.ai", "AIMessage"},
+	llms.ChatMessageTypeSystem:  {"langchain_core.messages.system", "SystemMessage"},
+	llms.ChatMessageTypeTool:    {"langchain_core.messages.tool", "ToolMessage"},
+	llms.ChatMessageTypeGeneric: {"langchain_core.messages.chat", "ChatMessage"},
+}
+
+// messageRoles are the roles of the type fields of LangChain messages.
+var messageRoles = map[string]llms.ChatMessageType{
+	"human":  llms.ChatMessageTypeHuman,
+	"ai":     llms.ChatMessageTypeAI,
+	"system": llms.ChatMessageTypeSystem,
+	"tool":   llms.ChatMessageTypeTool,
+	"chat":   llms.ChatMessageTypeGeneric,
+}
+
+// toLangChain returns the JSON constructor of the LangChain message of msg,
+// revived by the serializer of LangGraph as the message.
+func toLangChain(msg graph.Message) (map[string]any, error) {
+	class, ok := messageClasses[msg.Role]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMessage, msg.Role)
+	}
+	kwargs := map[string]any{
+		"type":              strings.TrimSuffix(strings.ToLower(class[1]), "message"),
+		"id":                msg.ID,
+		"name":              nil,
+		"additional_kwargs": map[string]any{},
+		"response_metadata": map[string]any{},
+	}
+	if name := msg.Name(); name != "" {
+		kwargs["name"] = name
+	}
+	var content []any
+	var toolCalls []any
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			content = append(content, map[string]any{"type": "text", "text": p.Text})
+		case llms.ImageURLContent:
+			content = append(content, map[string]any{"type": "image_url", "image_url": map[string]any{"url": p.URL}})
+		case llms.BinaryContent:
+			content = append(content, map[string]any{"type": "image_url", "image_url": map[string]any{"url": p.String()}})
+		case llms.ToolCall:
+			args := map[string]any{}
+			if p.FunctionCall == nil {
+				return nil, fmt.Errorf("%w: tool call %s without a function", ErrUnsupportedMessage, p.ID)
+			}
+			if p.FunctionCall.Arguments != "" {
+				if err := json.Unmarshal([]byte(p.FunctionCall.Arguments), &args); err != nil {
+					return nil, fmt.Errorf("%w: arguments of tool call %s: %v", ErrUnsupportedMessage, p.ID, err)
+				}
+			}
+			toolCalls = append(toolCalls, map[string]any{"type": "tool_call", "id": p.ID, "name": p.FunctionCall.Name, "args": args})
+		case llms.ToolCallResponse:
+			content = append(content, map[string]any{"type": "text", "text": p.Content})
+			kwargs["tool_call_id"] = p.ToolCallID
+			if p.Name != "" {
+				kwargs["name"] = p.Name
+			}
+		default:
+			return nil, fmt.Errorf("%w: part %T", ErrUnsupportedMessage, part)
+		}
+	}
+	// Messages of a single text are plain strings, as LangChain makes them.
+	if len(content) == 1 {
+		if part := content[0].(map[string]any); part["type"] == "text" {
+			kwargs["content"] = part["text"]
+		}
+	}
+	if _, ok := kwargs["content"]; !ok {
+		if content == nil {
+			content = []any{}
+		}
+		kwargs["content"] = content
+	}
+	switch msg.Role {
+	case llms.ChatMessageTypeAI:
+		if toolCalls == nil {
+			toolCalls = []any{}
+		}
+		kwargs["tool_calls"] = toolCalls
+		kwargs["invalid_tool_calls"] = []any{}
+	case llms.ChatMessageTypeTool:
+		kwargs["status"] = "success"
+	case llms.ChatMessageTypeGeneric:
+		kwargs["role"] = "generic"
+	}
+	return map[string]any{
+		"lc":     2,
+		"type":   "constructor",
+		"id":     append(strings.Split(class[0], "."), class[1]),
+		"method": nil,
+		"args":   []any{},
+		"kwargs": kwargs,
+	}, nil
+}
+
+// fromLangChain returns the message of a LangChain message. Messages without
+// an ID get a new one.
+func fromLangChain(v any) (graph.Message, error) {
+	obj, ok := v.(pyObject)
+	if !ok || obj.Kwargs == nil {
+		return graph.Message{}, fmt.Errorf("%w: %T is not a message", ErrUnsupportedMessage, v)
+	}
+	kwargs := obj.Kwargs
+	kind, _ := kwargs["type"].(string)
+	role, ok := messageRoles[strings.TrimSuffix(kind, "Chunk")]
+	if !ok {
+		return graph.Message{}, fmt.Errorf("%w: %s of type %q", ErrUnsupportedMessage, obj.Name, kind)
+	}
+
+	var parts []llms.ContentPart
+	switch content := kwargs["content"].(type) {
+	case string:
+		if content != "" || role != llms.ChatMessageTypeAI {
+			parts = append(parts, llms.TextContent{Text: content})
+		}
+	case []any:
+		for _, c := range content {
+			part, err := contentPart(c)
+			if err != nil {
+				return graph.Message{}, err
+			}
+			parts = append(parts, part)
+		}
+	}
+
+	id, _ := kwargs["id"].(string)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	msg := graph.Message{MessageContent: llms.MessageContent{Role: role}, ID: id, Metadata: graph.Metadata{}}
+	name, _ := kwargs["name"].(string)
+	if name != "" {
+		msg.Metadata[graph.MetadataName] = name
+	}
+	switch role {
+	case llms.ChatMessageTypeAI:
+		calls, _ := kwargs["tool_calls"].([]any)
+		for _, c := range calls {
+			call, _ := c.(map[string]any)
+			id, _ := call["id"].(string)
+			name, _ := call["name"].(string)
+			args, err := json.Marshal(call["args"])
+			if err != nil {
+				return graph.Message{}, fmt.Errorf("%w: arguments of tool call %s: %v", ErrUnsupportedMessage, id, err)
+			}
+			parts = append(parts, llms.ToolCall{ID: id, Type: "function", FunctionCall: &llms.FunctionCall{Name: name, Arguments: string(args)}})
+		}
+	case llms.ChatMessageTypeTool:
+		// Tool messages answer a call with their text.
+		callID, _ := kwargs["tool_call_id"].(string)
+		var text strings.Builder
+		for _, part := range parts {
+			if p, ok := part.(llms.TextContent); ok {
+				text.WriteString(p.Text)
+			}
+		}
+		parts = []llms.ContentPart{llms.ToolCallResponse{ToolCallID: callID, Name: name, Content: text.String()}}
+		msg.Metadata[graph.MetadataToolCallID] = callID
+	}
+	msg.Parts = parts
+	return msg, nil
+}
+
+// contentPart returns the part of a content block of a LangChain message.
+func contentPart(v any) (llms.ContentPart, error) {
+	if text, ok := v.(string); ok {
+		return llms.TextContent{Text: text}, nil
+	}
+	block, _ := v.(map[string]any)
+	switch block["type"] {
+	case "text":
+		text, _ := block["text"].(string)
+		return llms.TextContent{Text: text}, nil
+	case "image_url":
+		var url string
+		switch image := block["image_url"].(type) {
+		case string:
+			url = image
+		case map[string]any:
+			url, _ = image["url"].(string)
+		}
+		return llms.ImageURLContent{URL: url}, nil
+	}
+	return nil, fmt.Errorf("%w: content block %v", ErrUnsupportedMessage, block["type"])
+}
@@ -0,0 +1,228 @@
+package pycheckpoint
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errShortMsgpack is returned when msgpack data ends within a value.
+var errShortMsgpack = errors.New("unexpected end of msgpack data")
+
+// The extension types of the msgpack serializer of LangGraph, holding the
+// Python objects it serializes.
+const (
+	extConstructorSingleArg = 0
+	extConstructorPosArgs   = 1
+	extConstructorKwArgs    = 2
+	extMethodSingleArg      = 3
+	extPydanticV1           = 4
+	extPydanticV2           = 5
+)
+
+// msgpackDecoder decodes the msgpack values written by LangGraph, as the
+// values encoding/json decodes into an interface, with integers as int64,
+// binary data as []byte and Python objects as pyObject.
+type msgpackDecoder struct {
+	data []byte
+}
+
+// decodeMsgpack decodes the value of data.
+func decodeMsgpack(data []byte) (any, error) {
+	d := &msgpackDecoder{data: data}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if len(d.data) > 0 {
+		return nil, fmt.Errorf("%d bytes of msgpack data left", len(d.data))
+	}
+	return v, nil
+}
+
+// next consumes n bytes.
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data) < n {
+		return nil, errShortMsgpack
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+// uint consumes an unsigned integer of n bytes.
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) value() (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	switch c := b[0]; {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.mapOf(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	// sized returns the length of n bytes that follows.
+	sized := func(n int) (int, error) {
+		v, err := d.uint(n)
+		return int(v), err
+	}
+	switch c := b[0]; c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := sized(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		bin, err := d.next(n)
+		return append([]byte(nil), bin...), err
+	case 0xc7, 0xc8, 0xc9:
+		n, err := sized(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		v, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.uint(1 << (c - 0xcc))
+		if v > math.MaxInt64 {
+			return v, err
+		}
+		return int64(v), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		n := 1 << (c - 0xd0)
+		v, err := d.uint(n)
+		// Sign-extend the n bytes.
+		shift := 64 - 8*n
+		return int64(v<<shift) >> shift, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := sized(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := sized(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n)
+	case 0xde, 0xdf:
+		n, err := sized(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n)
+	}
+	return nil, fmt.Errorf("invalid msgpack byte %#x", b[0])
+}
+
+func (d *msgpackDecoder) str(n int) (any, error) {
+	b, err := d.next(n)
+	return string(b), err
+}
+
+func (d *msgpackDecoder) array(n int) (any, error) {
+	if n > len(d.data) {
+		return nil, errShortMsgpack
+	}
+	a := make([]any, n)
+	for i := range a {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func (d *msgpackDecoder) mapOf(n int) (any, error) {
+	if 2*n > len(d.data) {
+		return nil, errShortMsgpack
+	}
+	m := make(map[string]any, n)
+	for range n {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// ext decodes an extension value of n bytes, the Python objects of LangGraph
+// being their class and constructor arguments, as msgpack.
+func (d *msgpackDecoder) ext(n int) (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	code := int8(b[0])
+	v, err := decodeMsgpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("extension %d: %w", code, err)
+	}
+	fields, _ := v.([]any)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("%w: msgpack extension %d", ErrUnsupportedType, code)
+	}
+	obj := pyObject{}
+	obj.Module, _ = fields[0].(string)
+	obj.Name, _ = fields[1].(string)
+	switch code {
+	case extConstructorSingleArg, extMethodSingleArg:
+		obj.Args = []any{fields[2]}
+	case extConstructorPosArgs:
+		obj.Args, _ = fields[2].([]any)
+	case extConstructorKwArgs, extPydanticV1, extPydanticV2:
+		obj.Kwargs, _ = fields[2].(map[string]any)
+	default:
+		return nil, fmt.Errorf("%w: msgpack extension %d", ErrUnsupportedType, code)
+	}
+	return obj, nil
+}
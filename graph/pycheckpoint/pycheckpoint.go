@@ -0,0 +1,391 @@
+// Package pycheckpoint reads and writes the checkpoints of MessageState
+// graphs in the format of the Postgres saver of Python LangGraph, so that
+// threads migrate between Python and Go implementations of an agent:
+//
+//	db, err := sql.Open("pgx", dsn) // any Postgres driver
+//	...
+//	runnable, err := g.Compile(graph.WithCheckpointer[graph.MessageState](pycheckpoint.NewSaver(db)))
+//
+// The saver uses the tables of the Python saver, created by its setup.
+// Encode and Decode map checkpoints to the rows of these tables for other
+// stores, such as to migrate threads in bulk.
+//
+// The messages of a thread are its "messages" channel, the nodes left to
+// run its "branch:to:" channels in their order, as of LangGraph 0.4
+// (checkpoints of version 4). Messages are written as JSON and read as JSON or msgpack, the default
+// serialization of Python; other channels, pending writes, and with them
+// interrupts, are not mapped.
+package pycheckpoint
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+var (
+	// ErrUnsupportedType is returned when decoding a value serialized in a
+	// format other than JSON and msgpack, such as pickle.
+	ErrUnsupportedType = errors.New("unsupported serialization type")
+
+	// ErrUnsupportedMessage is returned when converting a message that has
+	// no equivalent in the other language.
+	ErrUnsupportedMessage = errors.New("unsupported message")
+)
+
+const (
+	// messagesChannel is the channel of the messages of a MessagesState.
+	messagesChannel = "messages"
+
+	// branchPrefix prefixes the channels triggering the nodes of a graph.
+	branchPrefix = "branch:to:"
+
+	// version is the version of the checkpoints written.
+	version = 4
+)
+
+// CheckpointRow is a row of the checkpoints table.
+type CheckpointRow struct {
+	ThreadID     string
+	CheckpointNS string
+	CheckpointID string
+
+	// ParentCheckpointID is the previous checkpoint of the thread, empty
+	// for the first.
+	ParentCheckpointID string
+
+	// Checkpoint is the checkpoint as JSON, its channel values of Python
+	// primitive types inline.
+	Checkpoint json.RawMessage
+
+	// Metadata is the metadata of the checkpoint as JSON.
+	Metadata json.RawMessage
+}
+
+// BlobRow is a row of the checkpoint_blobs table: the value of a channel at
+// a version.
+type BlobRow struct {
+	ThreadID     string
+	CheckpointNS string
+	Channel      string
+	Version      string
+
+	// Type is the serialization of Blob: "json", "msgpack", or "empty" for
+	// channels without a value.
+	Type string
+	Blob []byte
+}
+
+// pyCheckpoint is the checkpoint of a CheckpointRow.
+type pyCheckpoint struct {
+	V               int                                  `json:"v"`
+	ID              string                               `json:"id"`
+	TS              string                               `json:"ts"`
+	ChannelValues   map[string]json.RawMessage           `json:"channel_values"`
+	ChannelVersions channelVersions                      `json:"channel_versions"`
+	VersionsSeen    map[string]map[string]channelVersion `json:"versions_seen"`
+	PendingSends    []any                                `json:"pending_sends"`
+}
+
+// pyMetadata is the metadata of a CheckpointRow.
+type pyMetadata struct {
+	Source  string         `json:"source"`
+	Step    int            `json:"step"`
+	Parents map[string]any `json:"parents"`
+
+	// Next is the nodes left to run, in the order of a Go checkpoint, which
+	// a jsonb column does not keep for the keys of channel_versions.
+	Next []string `json:"next,omitempty"`
+}
+
+// channelVersion is the version of a channel. Python savers number them with
+// strings or integers, both ordered as zero-padded strings.
+type channelVersion string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *channelVersion) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = channelVersion(s)
+		return nil
+	}
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid channel version %s", data)
+	}
+	*v = newVersion(int64(f))
+	return nil
+}
+
+// channelVersions is the versions of the channels of a checkpoint, in the
+// order of their JSON object, which orders the nodes left to run.
+type channelVersions struct {
+	channels []string
+	versions map[string]channelVersion
+}
+
+// get returns the version of channel.
+func (cv channelVersions) get(channel string) (channelVersion, bool) {
+	v, ok := cv.versions[channel]
+	return v, ok
+}
+
+// set sets the version of channel, after the others if it has none.
+func (cv *channelVersions) set(channel string, v channelVersion) {
+	if cv.versions == nil {
+		cv.versions = map[string]channelVersion{}
+	}
+	if _, ok := cv.versions[channel]; !ok {
+		cv.channels = append(cv.channels, channel)
+	}
+	cv.versions[channel] = v
+}
+
+// MarshalJSON implements json.Marshaler.
+func (cv channelVersions) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, channel := range cv.channels {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, err := json.Marshal(channel)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(string(cv.versions[channel]))
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(append(buf, key...), ':'), value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (cv *channelVersions) UnmarshalJSON(data []byte) error {
+	*cv = channelVersions{}
+	if string(data) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("invalid channel versions %s", data)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var v channelVersion
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		cv.set(tok.(string), v)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// newVersion returns the version numbered n, as the Postgres saver formats
+// them.
+func newVersion(n int64) channelVersion {
+	return channelVersion(fmt.Sprintf("%032d.%016d", n, 0))
+}
+
+// Encode returns the rows of cp, whose previous checkpoint is parentID, in
+// the root namespace. The channels are versioned by the time of cp, so that
+// later checkpoints have later versions.
+func Encode(cp graph.Checkpoint[graph.MessageState], parentID string) (CheckpointRow, []BlobRow, error) {
+	messages := make([]any, 0, len(cp.State.Messages))
+	for _, msg := range cp.State.Messages {
+		m, err := toLangChain(msg)
+		if err != nil {
+			return CheckpointRow{}, nil, err
+		}
+		messages = append(messages, m)
+	}
+	blob, err := json.Marshal(messages)
+	if err != nil {
+		return CheckpointRow{}, nil, err
+	}
+
+	createdAt := cp.CreatedAt.UTC()
+	v := newVersion(createdAt.UnixNano())
+	pc := pyCheckpoint{
+		V:               version,
+		ID:              cp.ID,
+		TS:              createdAt.Format("2006-01-02T15:04:05.000000+00:00"),
+		ChannelValues:   map[string]json.RawMessage{},
+		ChannelVersions: channelVersions{},
+		VersionsSeen:    map[string]map[string]channelVersion{},
+		PendingSends:    []any{},
+	}
+	pc.ChannelVersions.set(messagesChannel, v)
+	// The nodes left to run are triggered by their channels, which they
+	// have not seen, in the order of cp.Next.
+	for _, node := range cp.Next {
+		if node == graph.END || node == "" {
+			continue
+		}
+		pc.ChannelValues[branchPrefix+node] = json.RawMessage("null")
+		pc.ChannelVersions.set(branchPrefix+node, v)
+	}
+	checkpoint, err := json.Marshal(pc)
+	if err != nil {
+		return CheckpointRow{}, nil, err
+	}
+	meta := pyMetadata{Source: "loop", Step: cp.Step, Parents: map[string]any{}}
+	for _, channel := range pc.ChannelVersions.channels {
+		if node, ok := strings.CutPrefix(channel, branchPrefix); ok {
+			meta.Next = append(meta.Next, node)
+		}
+	}
+	metadata, err := json.Marshal(meta)
+	if err != nil {
+		return CheckpointRow{}, nil, err
+	}
+
+	row := CheckpointRow{ThreadID: cp.ThreadID, CheckpointID: cp.ID, ParentCheckpointID: parentID, Checkpoint: checkpoint, Metadata: metadata}
+	blobs := []BlobRow{{ThreadID: cp.ThreadID, Channel: messagesChannel, Version: string(v), Type: "json", Blob: blob}}
+	for _, channel := range slices.Sorted(maps.Keys(pc.ChannelValues)) {
+		blobs = append(blobs, BlobRow{ThreadID: cp.ThreadID, Channel: channel, Version: string(v), Type: "empty"})
+	}
+	return row, blobs, nil
+}
+
+// Decode returns the checkpoint of row, with the values of its channels
+// among blobs, the rows of its thread and namespace. The node that ran last
+// is not recorded by Python, so it is empty.
+func Decode(row CheckpointRow, blobs []BlobRow) (graph.Checkpoint[graph.MessageState], error) {
+	var pc pyCheckpoint
+	if err := json.Unmarshal(row.Checkpoint, &pc); err != nil {
+		return graph.Checkpoint[graph.MessageState]{}, fmt.Errorf("checkpoint %s: %w", row.CheckpointID, err)
+	}
+	var metadata pyMetadata
+	if len(row.Metadata) > 0 {
+		if err := json.Unmarshal(row.Metadata, &metadata); err != nil {
+			return graph.Checkpoint[graph.MessageState]{}, fmt.Errorf("metadata of checkpoint %s: %w", row.CheckpointID, err)
+		}
+	}
+	cp := graph.Checkpoint[graph.MessageState]{
+		ID:       row.CheckpointID,
+		ThreadID: row.ThreadID,
+		Step:     metadata.Step,
+		State:    graph.NewMessageState(),
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, pc.TS); err == nil {
+		cp.CreatedAt = ts
+	}
+
+	// The messages are inline or in the blob of their version.
+	var value any
+	if raw, ok := pc.ChannelValues[messagesChannel]; ok {
+		v, err := decodeJSON(raw)
+		if err != nil {
+			return cp, fmt.Errorf("messages of checkpoint %s: %w", row.CheckpointID, err)
+		}
+		value = v
+	} else if version, ok := pc.ChannelVersions.get(messagesChannel); ok {
+		i := slices.IndexFunc(blobs, func(b BlobRow) bool {
+			return b.Channel == messagesChannel && b.Version == string(version)
+		})
+		if i >= 0 {
+			v, err := decodeBlob(blobs[i])
+			if err != nil {
+				return cp, fmt.Errorf("messages of checkpoint %s: %w", row.CheckpointID, err)
+			}
+			value = v
+		}
+	}
+	if value != nil {
+		values, ok := value.([]any)
+		if !ok {
+			return cp, fmt.Errorf("messages of checkpoint %s: %w: %T", row.CheckpointID, ErrUnsupportedType, value)
+		}
+		for _, v := range values {
+			msg, err := fromLangChain(v)
+			if err != nil {
+				return cp, fmt.Errorf("messages of checkpoint %s: %w", row.CheckpointID, err)
+			}
+			cp.State.Messages = append(cp.State.Messages, msg)
+		}
+	}
+
+	// Nodes run when their channel has a version they have not seen, in the
+	// order of the channels.
+	for _, channel := range pc.ChannelVersions.channels {
+		node, ok := strings.CutPrefix(channel, branchPrefix)
+		if !ok {
+			continue
+		}
+		if seen, ok := pc.VersionsSeen[node][channel]; !ok || seen < pc.ChannelVersions.versions[channel] {
+			cp.Next = append(cp.Next, node)
+		}
+	}
+	if len(metadata.Next) > 0 {
+		// Written by Encode: the nodes are in the order of cp.Next, those
+		// triggered since by Python after them.
+		slices.SortStableFunc(cp.Next, func(a, b string) int {
+			return cmp.Compare(uint(slices.Index(metadata.Next, a)), uint(slices.Index(metadata.Next, b)))
+		})
+	}
+	return cp, nil
+}
+
+// decodeBlob decodes the value of a blob.
+func decodeBlob(b BlobRow) (any, error) {
+	switch b.Type {
+	case "empty":
+		return nil, nil
+	case "json":
+		return decodeJSON(b.Blob)
+	case "msgpack":
+		return decodeMsgpack(b.Blob)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, b.Type)
+}
+
+// decodeJSON decodes JSON serialized by LangGraph, reviving the constructors
+// of Python objects as pyObject.
+func decodeJSON(data []byte) (any, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return revive(v), nil
+}
+
+// revive replaces the constructors of v by their objects.
+func revive(v any) any {
+	switch v := v.(type) {
+	case []any:
+		for i := range v {
+			v[i] = revive(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = revive(v[k])
+		}
+		id, _ := v["id"].([]any)
+		if v["type"] != "constructor" || len(id) == 0 {
+			return v
+		}
+		path := make([]string, len(id))
+		for i, p := range id {
+			path[i] = fmt.Sprint(p)
+		}
+		obj := pyObject{Module: strings.Join(path[:len(path)-1], "."), Name: path[len(path)-1]}
+		obj.Args, _ = v["args"].([]any)
+		obj.Kwargs, _ = v["kwargs"].(map[string]any)
+		return obj
+	}
+	return v
+}
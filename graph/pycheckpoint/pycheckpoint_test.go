@@ -0,0 +1,170 @@
+package pycheckpoint_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/pycheckpoint"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	call := llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}}
+	cp := graph.Checkpoint[graph.MessageState]{
+		ID:        "1ef4f797-8335-6428-8001-8a1503f9b875",
+		ThreadID:  "t1",
+		Step:      2,
+		Next:      []string{"tools", "agent"},
+		CreatedAt: time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC),
+		State: graph.MessageState{Messages: []graph.Message{
+			{MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "weather in Paris?"), ID: "m1", Metadata: graph.Metadata{graph.MetadataName: "ada"}},
+			{MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}}, ID: "m2", Metadata: graph.Metadata{}},
+			{MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call-1", Name: "weather", Content: "sunny"}}}, ID: "m3", Metadata: graph.Metadata{graph.MetadataName: "weather", graph.MetadataToolCallID: "call-1"}},
+		}},
+	}
+	row, blobs, err := pycheckpoint.Encode(cp, "parent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row.ParentCheckpointID != "parent" || len(blobs) != 3 {
+		t.Errorf("expected a row with parent and 3 blobs, but got %+v %+v", row, blobs)
+	}
+	got, err := pycheckpoint.Decode(row, blobs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.CreatedAt.Equal(cp.CreatedAt) {
+		t.Errorf("expected time %v, but got %v", cp.CreatedAt, got.CreatedAt)
+	}
+	got.CreatedAt = cp.CreatedAt
+	if !reflect.DeepEqual(got, cp) {
+		t.Errorf("expected checkpoint %+v, but got %+v", cp, got)
+	}
+
+	cp.Next = []string{graph.END}
+	row, blobs, _ = pycheckpoint.Encode(cp, "")
+	if got, _ := pycheckpoint.Decode(row, blobs); len(got.Next) != 0 {
+		t.Errorf("expected no nodes left, but got %v", got.Next)
+	}
+}
+
+// pythonCheckpoint is a checkpoint written by Python after the model asked
+// for a tool, its messages inline or in a blob of version 3.
+func pythonCheckpoint(messages json.RawMessage) pycheckpoint.CheckpointRow {
+	channels := `{"branch:to:tools": null}`
+	if messages != nil {
+		channels = `{"branch:to:tools": null, "messages": ` + string(messages) + `}`
+	}
+	return pycheckpoint.CheckpointRow{
+		ThreadID:     "t1",
+		CheckpointID: "1ef4f797-8335-6428-8001-8a1503f9b875",
+		Checkpoint: json.RawMessage(`{
+			"v": 4, "id": "1ef4f797-8335-6428-8001-8a1503f9b875", "ts": "2026-10-16T09:30:00.000000+00:00",
+			"channel_values": ` + channels + `,
+			"channel_versions": {"__start__": 1, "messages": "00000000000000000000000000000003.0.4", "branch:to:agent": 2, "branch:to:tools": 3},
+			"versions_seen": {"__input__": {}, "agent": {"branch:to:agent": 2}},
+			"pending_sends": []
+		}`),
+		Metadata: json.RawMessage(`{"source": "loop", "step": 1, "parents": {}}`),
+	}
+}
+
+const pythonMessages = `[
+	{"lc": 1, "type": "constructor", "id": ["langchain", "schema", "messages", "HumanMessage"],
+	 "kwargs": {"content": "weather in Paris?", "type": "human", "id": "m1"}},
+	{"lc": 2, "type": "constructor", "id": ["langchain_core", "messages", "ai", "AIMessage"], "method": null, "args": [],
+	 "kwargs": {"content": [{"type": "text", "text": "checking"}], "type": "ai", "id": "m2",
+	            "tool_calls": [{"type": "tool_call", "id": "call-1", "name": "weather", "args": {"city": "Paris"}}]}}
+]`
+
+func TestDecodePython(t *testing.T) {
+	t.Parallel()
+
+	packed := pack(t, []any{
+		ext{code: 5, fields: []any{"langchain_core.messages.human", "HumanMessage",
+			map[string]any{"content": "weather in Paris?", "type": "human", "id": "m1"}, "model_validate_json"}},
+		ext{code: 2, fields: []any{"langchain_core.messages.ai", "AIMessage",
+			map[string]any{"content": []any{map[string]any{"type": "text", "text": "checking"}}, "type": "ai", "id": "m2",
+				"tool_calls": []any{map[string]any{"type": "tool_call", "id": "call-1", "name": "weather", "args": map[string]any{"city": "Paris"}}}}}},
+	})
+	version := "00000000000000000000000000000003.0.4"
+
+	want := []graph.Message{
+		{MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, "weather in Paris?"), ID: "m1", Metadata: graph.Metadata{}},
+		{MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "checking"},
+			llms.ToolCall{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+		}}, ID: "m2", Metadata: graph.Metadata{}},
+	}
+	tests := []struct {
+		name    string
+		row     pycheckpoint.CheckpointRow
+		blobs   []pycheckpoint.BlobRow
+		wantErr error
+	}{
+		{name: "Inline JSON", row: pythonCheckpoint(json.RawMessage(pythonMessages))},
+		{name: "JSON blob", row: pythonCheckpoint(nil), blobs: []pycheckpoint.BlobRow{{Channel: "messages", Version: version, Type: "json", Blob: []byte(pythonMessages)}}},
+		{name: "Msgpack blob", row: pythonCheckpoint(nil), blobs: []pycheckpoint.BlobRow{{Channel: "messages", Version: version, Type: "msgpack", Blob: packed}}},
+		{name: "Pickle blob", row: pythonCheckpoint(nil), blobs: []pycheckpoint.BlobRow{{Channel: "messages", Version: version, Type: "pickle", Blob: []byte{0x80}}}, wantErr: pycheckpoint.ErrUnsupportedType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cp, err := pycheckpoint.Decode(tt.row, tt.blobs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(cp.State.Messages, want) {
+				t.Errorf("expected messages %+v, but got %+v", want, cp.State.Messages)
+			}
+			if !slices.Equal(cp.Next, []string{"tools"}) || cp.Step != 1 {
+				t.Errorf("expected step 1 before tools, but got step %d before %v", cp.Step, cp.Next)
+			}
+		})
+	}
+}
+
+// ext is a msgpack extension value.
+type ext struct {
+	code   int8
+	fields []any
+}
+
+// pack encodes v as msgpack, with the formats of the Python serializer.
+func pack(t *testing.T, v any) []byte {
+	t.Helper()
+	switch v := v.(type) {
+	case nil:
+		return []byte{0xc0}
+	case string:
+		return append([]byte{0xd9, byte(len(v))}, v...)
+	case []any:
+		b := []byte{0x90 | byte(len(v))}
+		for _, e := range v {
+			b = append(b, pack(t, e)...)
+		}
+		return b
+	case map[string]any:
+		b := []byte{0x80 | byte(len(v))}
+		for k, e := range v {
+			b = append(b, pack(t, k)...)
+			b = append(b, pack(t, e)...)
+		}
+		return b
+	case ext:
+		data := pack(t, v.fields)
+		return append([]byte{0xc8, byte(len(data) >> 8), byte(len(data)), byte(v.code)}, data...)
+	}
+	t.Fatalf("cannot pack %T", v)
+	return nil
+}
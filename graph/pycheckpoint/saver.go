@@ -0,0 +1,209 @@
+package pycheckpoint
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+)
+
+// Saver is a Checkpointer of MessageState graphs keeping their checkpoints in
+// the Postgres tables of the Python saver of LangGraph, so that Python and Go
+// agents share threads. It is safe for concurrent use.
+type Saver struct {
+	db *sql.DB
+}
+
+var (
+	_ graph.Checkpointer[graph.MessageState] = (*Saver)(nil)
+	_ graph.BatchPutter[graph.MessageState]  = (*Saver)(nil)
+	_ graph.ThreadDeleter                    = (*Saver)(nil)
+)
+
+// NewSaver returns a Saver keeping checkpoints in db, a Postgres database
+// whose tables were created by the setup of the Python saver.
+func NewSaver(db *sql.DB) *Saver {
+	return &Saver{db: db}
+}
+
+// Put implements graph.Checkpointer. Python orders the checkpoints of a
+// thread by ID, so checkpoints whose ID is not a time-ordered version 6 UUID,
+// as Python makes them, are saved with the ID returned by CheckpointID, under
+// which Get and List return them.
+func (s *Saver) Put(ctx context.Context, cp graph.Checkpoint[graph.MessageState]) error {
+	return s.PutBatch(ctx, []graph.Checkpoint[graph.MessageState]{cp})
+}
+
+// gregorianOffset is the number of 100ns intervals between the start of the
+// Gregorian calendar, the epoch of version 6 UUIDs, and the Unix epoch.
+const gregorianOffset = 0x01b21dd213814000
+
+// CheckpointID returns the ID a Saver saves cp with: its ID if it is a
+// version 6 UUID, else the version 6 UUID of the time cp was created whose
+// other bits are those of the SHA-1 hash of its ID. The same checkpoint is
+// thus always saved with the same ID, and in the order it was created.
+func CheckpointID(cp graph.Checkpoint[graph.MessageState]) string {
+	if id, err := uuid.Parse(cp.ID); err == nil && id.Version() == 6 {
+		return cp.ID
+	}
+	var ticks uint64
+	if !cp.CreatedAt.IsZero() {
+		ticks = uint64(cp.CreatedAt.UnixNano()/100) + gregorianOffset
+	}
+	// Laid out as Python makes them, the bits of the time from the highest.
+	var id uuid.UUID
+	binary.BigEndian.PutUint32(id[0:], uint32(ticks>>28))
+	binary.BigEndian.PutUint16(id[4:], uint16(ticks>>12))
+	binary.BigEndian.PutUint16(id[6:], 0x6000|uint16(ticks&0xfff))
+	hash := sha1.Sum([]byte(cp.ID))
+	copy(id[8:], hash[:])
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+	return id.String()
+}
+
+// PutBatch implements graph.BatchPutter, saving the checkpoints in one
+// transaction, as Put does.
+func (s *Saver) PutBatch(ctx context.Context, cps []graph.Checkpoint[graph.MessageState]) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, cp := range cps {
+		if err := put(ctx, tx, cp); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// put saves cp within tx.
+func put(ctx context.Context, tx *sql.Tx, cp graph.Checkpoint[graph.MessageState]) error {
+	cp.ID = CheckpointID(cp)
+	var parentID sql.NullString
+	err := tx.QueryRowContext(ctx, `SELECT checkpoint_id FROM checkpoints
+		WHERE thread_id = $1 AND checkpoint_ns = '' ORDER BY checkpoint_id DESC LIMIT 1`, cp.ThreadID).Scan(&parentID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	row, blobs, err := Encode(cp, parentID.String)
+	if err != nil {
+		return err
+	}
+	for _, b := range blobs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO checkpoint_blobs (thread_id, checkpoint_ns, channel, version, type, blob)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (thread_id, checkpoint_ns, channel, version) DO NOTHING`,
+			b.ThreadID, b.CheckpointNS, b.Channel, b.Version, b.Type, b.Blob); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO checkpoints (thread_id, checkpoint_ns, checkpoint_id, parent_checkpoint_id, checkpoint, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (thread_id, checkpoint_ns, checkpoint_id) DO UPDATE SET checkpoint = EXCLUDED.checkpoint, metadata = EXCLUDED.metadata`,
+		row.ThreadID, row.CheckpointNS, row.CheckpointID, sql.NullString{String: row.ParentCheckpointID, Valid: row.ParentCheckpointID != ""},
+		string(row.Checkpoint), string(row.Metadata))
+	return err
+}
+
+// Get implements graph.Checkpointer.
+func (s *Saver) Get(ctx context.Context, threadID string) (graph.Checkpoint[graph.MessageState], error) {
+	cps, err := s.list(ctx, threadID, "DESC LIMIT 1")
+	if err != nil {
+		return graph.Checkpoint[graph.MessageState]{}, err
+	}
+	if len(cps) == 0 {
+		return graph.Checkpoint[graph.MessageState]{}, graph.ErrCheckpointNotFound
+	}
+	return cps[0], nil
+}
+
+// List implements graph.Checkpointer.
+func (s *Saver) List(ctx context.Context, threadID string) ([]graph.Checkpoint[graph.MessageState], error) {
+	return s.list(ctx, threadID, "ASC")
+}
+
+// list returns the checkpoints of a thread in the root namespace, in the
+// order of their IDs.
+func (s *Saver) list(ctx context.Context, threadID, order string) ([]graph.Checkpoint[graph.MessageState], error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT checkpoint_id, parent_checkpoint_id, checkpoint, metadata FROM checkpoints
+		WHERE thread_id = $1 AND checkpoint_ns = '' ORDER BY checkpoint_id `+order, threadID)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []CheckpointRow
+	for rows.Next() {
+		row := CheckpointRow{ThreadID: threadID}
+		var parentID sql.NullString
+		var checkpoint, metadata []byte
+		if err := rows.Scan(&row.CheckpointID, &parentID, &checkpoint, &metadata); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		row.ParentCheckpointID, row.Checkpoint, row.Metadata = parentID.String, checkpoint, metadata
+		checkpoints = append(checkpoints, row)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, nil
+	}
+
+	blobs, err := s.blobs(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	cps := make([]graph.Checkpoint[graph.MessageState], 0, len(checkpoints))
+	for _, row := range checkpoints {
+		cp, err := Decode(row, blobs)
+		if err != nil {
+			return nil, err
+		}
+		cps = append(cps, cp)
+	}
+	return cps, nil
+}
+
+// blobs returns the messages of a thread in the root namespace.
+func (s *Saver) blobs(ctx context.Context, threadID string) ([]BlobRow, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT version, type, blob FROM checkpoint_blobs
+		WHERE thread_id = $1 AND checkpoint_ns = '' AND channel = $2`, threadID, messagesChannel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var blobs []BlobRow
+	for rows.Next() {
+		b := BlobRow{ThreadID: threadID, Channel: messagesChannel}
+		if err := rows.Scan(&b.Version, &b.Type, &b.Blob); err != nil {
+			return nil, fmt.Errorf("blob of thread %s: %w", threadID, err)
+		}
+		blobs = append(blobs, b)
+	}
+	return blobs, rows.Err()
+}
+
+// Delete implements graph.ThreadDeleter, deleting the checkpoints of the
+// thread in all namespaces with their blobs and writes.
+func (s *Saver) Delete(ctx context.Context, threadID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"checkpoints", "checkpoint_blobs", "checkpoint_writes"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE thread_id = $1", threadID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,342 @@
+package pycheckpoint_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/pycheckpoint"
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// tables are the Python tables a fakeDB keeps, checkpoints keyed by thread
+// and ID, blobs by thread, channel and version, all in the root namespace.
+type tables struct {
+	checkpoints map[[2]string]pycheckpoint.CheckpointRow
+	blobs       map[[3]string]pycheckpoint.BlobRow
+}
+
+func (t tables) clone() tables {
+	return tables{checkpoints: maps.Clone(t.checkpoints), blobs: maps.Clone(t.blobs)}
+}
+
+// fakeDB is a database/sql driver running the statements of the Saver on
+// tables, storing JSON as a jsonb column does, its object keys reordered.
+type fakeDB struct {
+	mu     sync.Mutex
+	tables tables
+	tx     *tables // the tables as of the transaction begun, to roll back
+}
+
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{tables: tables{checkpoints: map[[2]string]pycheckpoint.CheckpointRow{}, blobs: map[[3]string]pycheckpoint.BlobRow{}}}
+	db := sql.OpenDB(f)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return f, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)             { return f, nil }
+func (f *fakeDB) Close() error                                 { return nil }
+
+func (f *fakeDB) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{db: f, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (f *fakeDB) Begin() (driver.Tx, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	saved := f.tables.clone()
+	f.tx = &saved
+	return f, nil
+}
+
+func (f *fakeDB) Commit() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tx = nil
+	return nil
+}
+
+func (f *fakeDB) Rollback() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tx != nil {
+		f.tables, f.tx = *f.tx, nil
+	}
+	return nil
+}
+
+// jsonb returns data as a jsonb column returns it.
+func jsonb(data string) (json.RawMessage, error) {
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	f := s.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	str := func(i int) string {
+		v, _ := args[i].(string)
+		return v
+	}
+	switch {
+	case strings.HasPrefix(s.query, "INSERT INTO checkpoint_blobs "):
+		b := pycheckpoint.BlobRow{ThreadID: str(0), CheckpointNS: str(1), Channel: str(2), Version: str(3), Type: str(4)}
+		b.Blob, _ = args[5].([]byte)
+		key := [3]string{b.ThreadID, b.Channel, b.Version}
+		if _, ok := f.tables.blobs[key]; !ok {
+			f.tables.blobs[key] = b
+		}
+	case strings.HasPrefix(s.query, "INSERT INTO checkpoints "):
+		checkpoint, err := jsonb(str(4))
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := jsonb(str(5))
+		if err != nil {
+			return nil, err
+		}
+		f.tables.checkpoints[[2]string{str(0), str(2)}] = pycheckpoint.CheckpointRow{
+			ThreadID: str(0), CheckpointNS: str(1), CheckpointID: str(2), ParentCheckpointID: str(3), Checkpoint: checkpoint, Metadata: metadata,
+		}
+	case strings.HasPrefix(s.query, "DELETE FROM checkpoints "):
+		maps.DeleteFunc(f.tables.checkpoints, func(k [2]string, _ pycheckpoint.CheckpointRow) bool { return k[0] == str(0) })
+	case strings.HasPrefix(s.query, "DELETE FROM checkpoint_blobs "):
+		maps.DeleteFunc(f.tables.blobs, func(k [3]string, _ pycheckpoint.BlobRow) bool { return k[0] == str(0) })
+	case strings.HasPrefix(s.query, "DELETE FROM checkpoint_writes "):
+	default:
+		return nil, fmt.Errorf("unexpected statement %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	f := s.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	threadID, _ := args[0].(string)
+	var checkpoints []pycheckpoint.CheckpointRow
+	for k, row := range f.tables.checkpoints {
+		if k[0] == threadID {
+			checkpoints = append(checkpoints, row)
+		}
+	}
+	slices.SortFunc(checkpoints, func(a, b pycheckpoint.CheckpointRow) int { return strings.Compare(a.CheckpointID, b.CheckpointID) })
+	if strings.Contains(s.query, " DESC LIMIT 1") {
+		slices.Reverse(checkpoints)
+		checkpoints = checkpoints[:min(len(checkpoints), 1)]
+	}
+
+	rows := &fakeRows{}
+	switch {
+	case strings.HasPrefix(s.query, "SELECT checkpoint_id FROM checkpoints "):
+		rows.columns = []string{"checkpoint_id"}
+		for _, row := range checkpoints {
+			rows.values = append(rows.values, []driver.Value{row.CheckpointID})
+		}
+	case strings.HasPrefix(s.query, "SELECT checkpoint_id, parent_checkpoint_id, checkpoint, metadata FROM checkpoints "):
+		rows.columns = []string{"checkpoint_id", "parent_checkpoint_id", "checkpoint", "metadata"}
+		for _, row := range checkpoints {
+			var parentID driver.Value
+			if row.ParentCheckpointID != "" {
+				parentID = row.ParentCheckpointID
+			}
+			rows.values = append(rows.values, []driver.Value{row.CheckpointID, parentID, []byte(row.Checkpoint), []byte(row.Metadata)})
+		}
+	case strings.HasPrefix(s.query, "SELECT version, type, blob FROM checkpoint_blobs "):
+		rows.columns = []string{"version", "type", "blob"}
+		for k, b := range f.tables.blobs {
+			if k[0] == threadID && k[1] == args[1] {
+				rows.values = append(rows.values, []driver.Value{b.Version, b.Type, b.Blob})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected query %q", s.query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// v6Time returns the time of a version 6 UUID, as Python lays them out.
+func v6Time(id uuid.UUID) time.Time {
+	b := binary.BigEndian.Uint64(id[:8])
+	ticks := b>>16<<12 | b&0xfff
+	return time.Unix(0, int64(ticks-0x01b21dd213814000)*100)
+}
+
+func TestSaver(t *testing.T) {
+	t.Parallel()
+
+	db, f := newFakeDB(t)
+	saver := pycheckpoint.NewSaver(db)
+	ctx := context.Background()
+	createdAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	checkpoint := func(id string, step int, next []string, texts ...string) graph.Checkpoint[graph.MessageState] {
+		cp := graph.Checkpoint[graph.MessageState]{ID: id, ThreadID: "t1", Step: step, Next: next, CreatedAt: createdAt.Add(time.Duration(step) * time.Second), State: graph.NewMessageState()}
+		for i, text := range texts {
+			cp.State.Messages = append(cp.State.Messages, graph.Message{MessageContent: llms.TextParts(llms.ChatMessageTypeHuman, text), ID: fmt.Sprint("m", i), Metadata: graph.Metadata{}})
+		}
+		return cp
+	}
+
+	// The checkpoints of the engine have version 4 UUIDs.
+	first := checkpoint(uuid.NewString(), 1, []string{"tools", "agent"}, "hi")
+	if err := saver.Put(ctx, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := checkpoint(uuid.NewString(), 2, nil, "hi", "hello")
+	third := checkpoint("", 3, []string{"agent"}, "hi", "hello", "bye")
+	third.ID = pycheckpoint.CheckpointID(graph.Checkpoint[graph.MessageState]{ID: "python", CreatedAt: third.CreatedAt})
+	if err := saver.PutBatch(ctx, []graph.Checkpoint[graph.MessageState]{second, third}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := saver.List(ctx, "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []graph.Checkpoint[graph.MessageState]{first, second, third}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d checkpoints, but got %+v", len(want), got)
+	}
+	for i, cp := range want {
+		// Version 6 IDs are kept, the others converted once and for all.
+		if id := pycheckpoint.CheckpointID(cp); got[i].ID != id || pycheckpoint.CheckpointID(got[i]) != id {
+			t.Errorf("checkpoint %d: expected ID %s, but got %s", i, id, got[i].ID)
+		}
+		if id, err := uuid.Parse(got[i].ID); err != nil || id.Version() != 6 || !v6Time(id).Equal(cp.CreatedAt) {
+			t.Errorf("checkpoint %d: expected a version 6 UUID of %v, but got %s", i, cp.CreatedAt, got[i].ID)
+		}
+		got[i].ID = cp.ID
+		if !got[i].CreatedAt.Equal(cp.CreatedAt) {
+			t.Errorf("checkpoint %d: expected time %v, but got %v", i, cp.CreatedAt, got[i].CreatedAt)
+		}
+		got[i].CreatedAt = cp.CreatedAt
+		if !reflect.DeepEqual(got[i], cp) {
+			t.Errorf("checkpoint %d: expected %+v, but got %+v", i, cp, got[i])
+		}
+	}
+	if last, err := saver.Get(ctx, "t1"); err != nil || last.ID != third.ID {
+		t.Errorf("expected the last checkpoint %s, but got %s, %v", third.ID, last.ID, err)
+	}
+
+	// The rows are linked as Python links them.
+	var parents []string
+	for _, row := range f.tables.checkpoints {
+		parents = append(parents, row.ParentCheckpointID)
+	}
+	slices.Sort(parents)
+	if want := []string{"", pycheckpoint.CheckpointID(first), pycheckpoint.CheckpointID(second)}; !slices.Equal(parents, want) {
+		t.Errorf("expected parents %v, but got %v", want, parents)
+	}
+
+	// A batch failing to encode is rolled back.
+	bad := checkpoint(uuid.NewString(), 4, nil)
+	bad.State.Messages = []graph.Message{{MessageContent: llms.MessageContent{Role: llms.ChatMessageTypeFunction}}}
+	if err := saver.PutBatch(ctx, []graph.Checkpoint[graph.MessageState]{checkpoint(uuid.NewString(), 4, nil), bad}); err == nil {
+		t.Errorf("expected an error for a function message, but got none")
+	}
+	if cps, _ := saver.List(ctx, "t1"); len(cps) != 3 {
+		t.Errorf("expected 3 checkpoints after a failed batch, but got %d", len(cps))
+	}
+
+	if err := saver.Delete(ctx, "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := saver.Get(ctx, "t1"); !errors.Is(err, graph.ErrCheckpointNotFound) {
+		t.Errorf("expected error %v, but got %v", graph.ErrCheckpointNotFound, err)
+	}
+	if len(f.tables.blobs) != 0 {
+		t.Errorf("expected no blobs, but got %v", slices.Collect(maps.Keys(f.tables.blobs)))
+	}
+}
+
+func TestSaverPython(t *testing.T) {
+	t.Parallel()
+
+	// A thread of Python resumes in Go, and the other way round.
+	db, f := newFakeDB(t)
+	row := pythonCheckpoint(nil)
+	f.tables.checkpoints[[2]string{row.ThreadID, row.CheckpointID}] = row
+	version := "00000000000000000000000000000003.0.4"
+	f.tables.blobs[[3]string{row.ThreadID, "messages", version}] = pycheckpoint.BlobRow{ThreadID: row.ThreadID, Channel: "messages", Version: version, Type: "json", Blob: []byte(pythonMessages)}
+
+	saver := pycheckpoint.NewSaver(db)
+	ctx := context.Background()
+	cp, err := saver.Get(ctx, row.ThreadID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.ID != row.CheckpointID || len(cp.State.Messages) != 2 || !slices.Equal(cp.Next, []string{"tools"}) {
+		t.Fatalf("expected the Python checkpoint before tools, but got %+v", cp)
+	}
+
+	cp.ID = uuid.NewString()
+	cp.Step++
+	cp.CreatedAt = cp.CreatedAt.Add(time.Second)
+	cp.Next = []string{"agent"}
+	if err := saver.Put(ctx, cp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	saved := f.tables.checkpoints[[2]string{row.ThreadID, pycheckpoint.CheckpointID(cp)}]
+	var pc struct {
+		V               int               `json:"v"`
+		ID              string            `json:"id"`
+		ChannelVersions map[string]string `json:"channel_versions"`
+	}
+	if err := json.Unmarshal(saved.Checkpoint, &pc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved.ParentCheckpointID != row.CheckpointID || pc.V != 4 || pc.ID != saved.CheckpointID || pc.ChannelVersions["branch:to:agent"] == "" {
+		t.Errorf("expected a checkpoint of version 4 after the Python one, but got %+v %s", saved, saved.Checkpoint)
+	}
+	if last, err := saver.Get(ctx, row.ThreadID); err != nil || len(last.State.Messages) != 2 || last.Step != 2 {
+		t.Errorf("expected the checkpoint saved, but got %+v, %v", last, err)
+	}
+}
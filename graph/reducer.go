@@ -0,0 +1,19 @@
+package graph
+
+// Reducer is implemented by states that merge updates into themselves rather
+// than being overwritten by them. MessageState implements it with the
+// AddMessages reducer.
+type Reducer[T any] interface {
+	// Reduce merges update into the state.
+	Reduce(update T) error
+}
+
+// reduceState merges update into state with its Reduce method if it has one,
+// and replaces it otherwise.
+func reduceState[T any](state *T, update T) error {
+	if r, ok := any(state).(Reducer[T]); ok {
+		return r.Reduce(update)
+	}
+	*state = update
+	return nil
+}
@@ -0,0 +1,117 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestUpdateStateReducesMessages(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	runnable := newFormGraph(t, graph.NewMemorySaver[graph.MessageState]())
+	thread := graph.WithThreadID("thread")
+
+	state := graph.NewMessageState()
+	var gi *graph.GraphInterrupt
+	if err := runnable.Invoke(ctx, &state, thread); !errors.As(err, &gi) {
+		t.Fatalf("expected an interrupt, but got %v", err)
+	}
+	start := state.Messages[0]
+
+	update := graph.NewMessageState()
+	update.Messages = []graph.Message{
+		{MessageContent: llms.TextParts(llms.ChatMessageTypeAI, "restart"), ID: start.ID},
+		graph.NewMessage(llms.TextParts(llms.ChatMessageTypeHuman, "note")),
+	}
+	if err := runnable.UpdateState(ctx, "thread", update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err := runnable.GetState(ctx, "thread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"restart", "note"}; !slices.Equal(texts(cp.State), want) {
+		t.Errorf("expected messages %q, but got %q", want, texts(cp.State))
+	}
+	if cp.Interrupt == nil || !slices.Equal(cp.Next, []string{"ask"}) {
+		t.Errorf("expected the interrupted run to stay pending, but got %+v", cp)
+	}
+
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithResume("Ada")); !errors.As(err, &gi) {
+		t.Fatalf("expected an interrupt, but got %v", err)
+	}
+	if err := runnable.Invoke(ctx, &state, thread, graph.WithResume(36)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"restart", "note", "asking", "Ada", "36", "done"}
+	if got := texts(state); !slices.Equal(got, want) {
+		t.Errorf("expected messages %q, but got %q", want, got)
+	}
+}
+
+func TestUpdateStateReplaces(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := graph.NewStateGraph[int]()
+	g.AddNode("double", func(_ context.Context, n *int) error {
+		*n *= 2
+		return nil
+	})
+	g.SetEntryPoint("double")
+	g.AddEdge("double", graph.END)
+	runnable, err := g.Compile(graph.WithCheckpointer(graph.NewMemorySaver[int]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	n := 2
+	if err := runnable.Invoke(ctx, &n, graph.WithThreadID("thread")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := runnable.UpdateState(ctx, "thread", 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err := runnable.GetState(ctx, "thread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.State != 10 || cp.Node != "double" {
+		t.Errorf("expected state 10 after node double, but got %+v", cp)
+	}
+}
+
+func TestUpdateStateErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		checkpointer bool
+		threadID     string
+		wantErr      error
+	}{
+		{name: "Without checkpointer", threadID: "thread", wantErr: graph.ErrNoCheckpointer},
+		{name: "Without thread", checkpointer: true, wantErr: graph.ErrThreadRequired},
+		{name: "Unknown thread", checkpointer: true, threadID: "thread", wantErr: graph.ErrCheckpointNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var cp graph.Checkpointer[graph.MessageState]
+			if tc.checkpointer {
+				cp = graph.NewMemorySaver[graph.MessageState]()
+			}
+			err := newFormGraph(t, cp).UpdateState(context.Background(), tc.threadID, graph.NewMessageState())
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, but got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
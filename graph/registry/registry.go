@@ -0,0 +1,205 @@
+// Package registry registers compiled graphs by name and version, so that
+// one deployment hosts several agents, each with its own config, and the
+// layers serving them look them up per request:
+//
+//	reg := registry.New()
+//	err := registry.Register(reg, "support", 1, support, registry.WithDescription("Answers customers"))
+//	...
+//	err = registry.Register(reg, "support", 2, supportV2)
+//	...
+//	mux, err := reg.Mux() // serves the latest version by graph ID
+//	...
+//	runnable, err := registry.Get[State](reg, "support", 0) // the latest version
+//
+// Distributed workers run the graphs of a registry with one executor per
+// graph, named with distributed.WithGraph, see distributed.Work.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+var (
+	// ErrNotFound is returned when looking up a graph that is not
+	// registered.
+	ErrNotFound = errors.New("graph not found")
+
+	// ErrDuplicate is returned when registering a version of a graph that
+	// is registered already.
+	ErrDuplicate = errors.New("duplicate graph")
+
+	// ErrStateType is returned by Get when the state of the graph is not of
+	// the requested type.
+	ErrStateType = errors.New("wrong state type")
+)
+
+// Entry is a version of a graph registered in a Registry.
+type Entry struct {
+	Name        string
+	Version     int
+	Description string
+
+	// Config is the config of the graph, reported to the clients of the
+	// server as the config of its assistant.
+	Config map[string]any
+
+	// Runnable is the *graph.Runnable[T] of the graph.
+	Runnable any
+
+	RegisteredAt time.Time
+
+	// invokeOpts are the options of the runs of the graph.
+	invokeOpts []graph.InvokeOption
+
+	// handle serves the graph on a server.Mux, the type of its state being
+	// known when it was registered only.
+	handle func(m *server.Mux) error
+}
+
+// Option configures a registered graph.
+type Option func(*Entry)
+
+// WithDescription describes the graph.
+func WithDescription(description string) Option {
+	return func(e *Entry) {
+		e.Description = description
+	}
+}
+
+// WithConfig sets the config of the graph.
+func WithConfig(config map[string]any) Option {
+	return func(e *Entry) {
+		e.Config = config
+	}
+}
+
+// WithInvokeOptions invokes the runs of the graph served by the registry with
+// opts, such as graph.WithEntryPoint.
+func WithInvokeOptions(opts ...graph.InvokeOption) Option {
+	return func(e *Entry) {
+		e.invokeOpts = append(e.invokeOpts, opts...)
+	}
+}
+
+// Registry is a set of graphs registered by name and version. It is safe for
+// concurrent use.
+type Registry struct {
+	mu sync.RWMutex
+	// entries are the versions of the graphs by name, in increasing order.
+	entries map[string][]*Entry
+}
+
+// New returns an empty Registry.
+func New() *Registry {
+	return &Registry{entries: make(map[string][]*Entry)}
+}
+
+// Register registers runnable as the given version of the graph with the
+// given name. Versions start at 1. It returns ErrDuplicate if the version
+// is registered already.
+func Register[T any](r *Registry, name string, version int, runnable *graph.Runnable[T], opts ...Option) error {
+	switch {
+	case name == "":
+		return errors.New("registry: empty graph name")
+	case version < 1:
+		return fmt.Errorf("registry: invalid version %d of graph %s", version, name)
+	case runnable == nil:
+		return fmt.Errorf("registry: nil runnable of graph %s", name)
+	}
+	e := &Entry{Name: name, Version: version, Config: map[string]any{}, Runnable: runnable, RegisteredAt: time.Now().UTC()}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.handle = func(m *server.Mux) error {
+		_, err := server.Handle(m, e.Name, runnable,
+			server.WithVersion(e.Version),
+			server.WithDescription(e.Description),
+			server.WithConfig(e.Config),
+			server.WithInvokeOptions(e.invokeOpts...))
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	versions := r.entries[name]
+	i, found := slices.BinarySearchFunc(versions, version, func(e *Entry, v int) int { return e.Version - v })
+	if found {
+		return fmt.Errorf("%w: version %d of %s", ErrDuplicate, version, name)
+	}
+	r.entries[name] = slices.Insert(versions, i, e)
+	return nil
+}
+
+// Lookup returns the given version of the graph with the given name, or its
+// latest version if version is 0. It returns ErrNotFound if there is none.
+func (r *Registry) Lookup(name string, version int) (Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.entries[name]
+	if version == 0 && len(versions) > 0 {
+		return *versions[len(versions)-1], nil
+	}
+	for _, e := range versions {
+		if e.Version == version {
+			return *e, nil
+		}
+	}
+	if version == 0 {
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return Entry{}, fmt.Errorf("%w: version %d of %s", ErrNotFound, version, name)
+}
+
+// Get returns the runnable of a graph, see Registry.Lookup. It returns
+// ErrStateType if the state of the graph is not of type T.
+func Get[T any](r *Registry, name string, version int) (*graph.Runnable[T], error) {
+	e, err := r.Lookup(name, version)
+	if err != nil {
+		return nil, err
+	}
+	runnable, ok := e.Runnable.(*graph.Runnable[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: version %d of %s is a %T", ErrStateType, e.Version, name, e.Runnable)
+	}
+	return runnable, nil
+}
+
+// Entries returns the registered graphs, ordered by name and version.
+func (r *Registry) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var entries []Entry
+	for _, versions := range r.entries {
+		for _, e := range versions {
+			entries = append(entries, *e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return a.Version - b.Version
+	})
+	return entries
+}
+
+// Mux returns a server.Mux serving the registered graphs, each version of a
+// graph being an assistant whose graph ID is the name of the graph. The
+// graphs must have been compiled with a checkpointer.
+func (r *Registry) Mux() (*server.Mux, error) {
+	m := server.NewMux()
+	for _, e := range r.Entries() {
+		if err := e.handle(m); err != nil {
+			return nil, fmt.Errorf("registry: serve version %d of %s: %w", e.Version, e.Name, err)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,172 @@
+package registry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/registry"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+type countState struct {
+	Count int `json:"count"`
+}
+
+type textState struct {
+	Text string `json:"text"`
+}
+
+// compile compiles a graph with a node named node, which applies f to the
+// state.
+func compile[T any](t *testing.T, node string, f func(*T), opts ...graph.CompileOption) *graph.Runnable[T] {
+	t.Helper()
+
+	g := graph.NewStateGraph[T]()
+	g.AddNode(node, func(_ context.Context, s *T) error {
+		f(s)
+		return nil
+	})
+	g.AddEdge(node, graph.END)
+	g.SetEntryPoint(node)
+	runnable, err := g.Compile(opts...)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return runnable
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	reg := registry.New()
+	v1 := compile(t, "add", func(s *countState) { s.Count++ })
+	v2 := compile(t, "add", func(s *countState) { s.Count += 2 })
+	echo := compile(t, "echo", func(s *textState) { s.Text += "!" })
+	if err := registry.Register(reg, "counter", 2, v2, registry.WithDescription("Counts by two")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(reg, "counter", 1, v1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(reg, "echo", 1, echo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(reg, "counter", 1, v1); !errors.Is(err, registry.ErrDuplicate) {
+		t.Errorf("expected error %v, but got %v", registry.ErrDuplicate, err)
+	}
+	if err := registry.Register(reg, "counter", 0, v1); err == nil {
+		t.Error("expected an error registering version 0, but got none")
+	}
+
+	testCases := []struct {
+		name    string
+		graph   string
+		version int
+		want    *graph.Runnable[countState]
+		wantErr error
+	}{
+		{name: "Latest", graph: "counter", want: v2},
+		{name: "Version", graph: "counter", version: 1, want: v1},
+		{name: "Unknown version", graph: "counter", version: 3, wantErr: registry.ErrNotFound},
+		{name: "Unknown graph", graph: "missing", wantErr: registry.ErrNotFound},
+		{name: "Other state type", graph: "echo", wantErr: registry.ErrStateType},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := registry.Get[countState](reg, tc.graph, tc.version)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected runnable %p, but got %p", tc.want, got)
+			}
+		})
+	}
+
+	var got []string
+	for _, e := range reg.Entries() {
+		got = append(got, fmt.Sprintf("%s@%d", e.Name, e.Version))
+	}
+	if want := []string{"counter@1", "counter@2", "echo@1"}; !slices.Equal(got, want) {
+		t.Errorf("expected entries %v, but got %v", want, got)
+	}
+	if e, _ := reg.Lookup("counter", 0); e.Description != "Counts by two" {
+		t.Errorf("expected the description of version 2, but got %q", e.Description)
+	}
+}
+
+func TestRegistryMux(t *testing.T) {
+	t.Parallel()
+
+	reg := registry.New()
+	register := func(name string, version int, add int) {
+		runnable := compile(t, "add", func(s *countState) { s.Count += add },
+			graph.WithCheckpointer[countState](graph.NewMemorySaver[countState]()))
+		if err := registry.Register(reg, name, version, runnable, registry.WithConfig(map[string]any{"add": add})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	register("counter", 1, 1)
+	register("counter", 2, 2)
+	register("big", 1, 100)
+	mux, err := reg.Mux()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(mux.Assistants()); n != 3 {
+		t.Errorf("expected 3 assistants, but got %d", n)
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	post := func(path string, body, out any) int {
+		t.Helper()
+		data, _ := json.Marshal(body)
+		resp, err := http.Post(ts.URL+path, "application/json", bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return resp.StatusCode
+	}
+	for i, tc := range []struct {
+		assistant string
+		want      int
+	}{{"counter", 2}, {"big", 100}} {
+		threadID := string(rune('a' + i))
+		post("/threads", map[string]any{"thread_id": threadID}, nil)
+		var state countState
+		if status := post("/threads/"+threadID+"/runs/wait", map[string]any{"assistant_id": tc.assistant}, &state); status != http.StatusOK || state.Count != tc.want {
+			t.Errorf("%s: expected count %d, but got %d %+v", tc.assistant, tc.want, status, state)
+		}
+	}
+	var assistants []server.Assistant
+	post("/assistants/search", map[string]any{"graph_id": "counter"}, &assistants)
+	if len(assistants) != 2 || assistants[1].Config["add"] != float64(2) {
+		t.Errorf("expected the versions of counter with their config, but got %+v", assistants)
+	}
+
+	// Graphs compiled without a checkpointer cannot be served.
+	bad := registry.New()
+	if err := registry.Register(bad, "counter", 1, compile(t, "add", func(s *countState) {})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := bad.Mux(); !errors.Is(err, graph.ErrNoCheckpointer) {
+		t.Errorf("expected error %v, but got %v", graph.ErrNoCheckpointer, err)
+	}
+}
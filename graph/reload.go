@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reloader serves the compiled graph of a definition file, rebuilding it when
+// the file changes, for fast iteration in long-running development servers:
+//
+//	reloader, err := graph.NewReloader("agent.yaml", registry)
+//	...
+//	go reloader.Watch(ctx, time.Second, func(err error) { log.Print(err) })
+//	...
+//	err = reloader.Runnable().Invoke(ctx, &state)
+//
+// Files ending in ".yaml" or ".yml" are read with LoadYAML, others with
+// LoadJSON, and compiled with the CompileOptions of the definition and the
+// options given to NewReloader.
+type Reloader[T any] struct {
+	path     string
+	registry *NodeRegistry[T]
+	opts     []CompileOption
+
+	runnable atomic.Pointer[Runnable[T]]
+
+	// mu serializes reloads; sum is the checksum of the loaded file.
+	mu  sync.Mutex
+	sum [sha256.Size]byte
+}
+
+// NewReloader loads and compiles the graph defined in the file at path. It
+// returns an error if the graph cannot be loaded or compiled.
+func NewReloader[T any](path string, registry *NodeRegistry[T], opts ...CompileOption) (*Reloader[T], error) {
+	r := &Reloader[T]{path: path, registry: registry, opts: opts}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Runnable returns the compiled graph of the last successful load. Runs keep
+// the Runnable they started with when the graph is reloaded.
+func (r *Reloader[T]) Runnable() *Runnable[T] {
+	return r.runnable.Load()
+}
+
+// Reload loads and compiles the graph again if the file changed since the
+// last successful load, and reports whether the graph was swapped. On
+// failure, the previous graph keeps being served.
+func (r *Reloader[T]) Reload() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, fmt.Errorf("reload graph: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if r.runnable.Load() != nil && sum == r.sum {
+		return false, nil
+	}
+
+	load := LoadJSON[T]
+	switch filepath.Ext(r.path) {
+	case ".yaml", ".yml":
+		load = LoadYAML[T]
+	}
+	g, def, err := load(bytes.NewReader(data), r.registry)
+	if err != nil {
+		return false, fmt.Errorf("reload graph %s: %w", r.path, err)
+	}
+	runnable, err := g.Compile(append(def.CompileOptions(), r.opts...)...)
+	if err != nil {
+		return false, fmt.Errorf("reload graph %s: %w", r.path, err)
+	}
+	r.runnable.Store(runnable)
+	r.sum = sum
+	return true, nil
+}
+
+// Watch checks the file for changes every interval until ctx is done, calling
+// Reload, and onError, if not nil, with the errors of failed reloads. An
+// error is reported once until the file is fixed or fails otherwise.
+func (r *Reloader[T]) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := r.Reload()
+			if err == nil {
+				last = ""
+				continue
+			}
+			if err.Error() != last && onError != nil {
+				onError(err)
+			}
+			last = err.Error()
+		}
+	}
+}
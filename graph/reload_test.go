@@ -0,0 +1,93 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestReloader(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "graph.yaml")
+	write := func(definition string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(definition), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	invoke := func(r *graph.Reloader[int]) int {
+		t.Helper()
+		n := 0
+		if err := r.Runnable().Invoke(context.Background(), &n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return n
+	}
+
+	write("entry_point: agent\nnodes:\n  - {name: agent, function: increment}\nedges:\n  - {from: agent, to: END}\n")
+	reloader, err := graph.NewReloader(path, newCounterRegistry())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := invoke(reloader); n != 1 {
+		t.Errorf("expected 1, but got %d", n)
+	}
+	if swapped, err := reloader.Reload(); swapped || err != nil {
+		t.Errorf("expected unchanged file not to be reloaded, but got %t, %v", swapped, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go reloader.Watch(ctx, 5*time.Millisecond, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	// An invalid graph keeps the previous one serving.
+	before := reloader.Runnable()
+	write("entry_point: agent\nnodes:\n  - {name: agent, function: increment}\n")
+	select {
+	case err := <-errs:
+		if !errors.Is(err, graph.ErrNoPathToEnd) {
+			t.Errorf("expected error %v, but got %v", graph.ErrNoPathToEnd, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload error")
+	}
+	if reloader.Runnable() != before {
+		t.Error("expected the previous graph to be served")
+	}
+
+	write("entry_point: agent\nnodes:\n  - {name: agent, function: increment}\n  - {name: tools}\nedges:\n  - {from: agent, to: tools}\n  - {from: tools, to: END}\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for reloader.Runnable() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the graph to be reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := invoke(reloader); n != 2 {
+		t.Errorf("expected 2, but got %d", n)
+	}
+}
+
+func TestNewReloaderError(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "graph.json")
+	if err := os.WriteFile(path, []byte(`{"entry_point": "agent", "nodes": [{"name": "agent", "function": "missing"}]}`), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := graph.NewReloader(path, newCounterRegistry()); !errors.Is(err, graph.ErrFunctionNotRegistered) {
+		t.Errorf("expected error %v, but got %v", graph.ErrFunctionNotRegistered, err)
+	}
+}
@@ -0,0 +1,355 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/streammode"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// InvokeOption configures a remote run.
+type InvokeOption func(*invokeConfig)
+
+type invokeConfig struct {
+	threadID    string
+	entryPoint  string
+	resume      bool
+	resumeValue any
+	streamModes []StreamMode
+}
+
+// StreamMode selects the events of streamed runs, see WithStreamMode. The
+// modes are those of package graph/server.
+type StreamMode = streammode.Mode
+
+const (
+	// StreamValues sends the state after each node.
+	StreamValues = streammode.Values
+
+	// StreamUpdates sends the fields of the state each node changed. States
+	// that are not JSON objects are sent whole.
+	StreamUpdates = streammode.Updates
+
+	// StreamMessages sends the changes each node made to the messages of a
+	// graph.MessageState state as deltas, see graph.MessageDeltas. When the
+	// changes cannot be sent as deltas, the state is sent as a values event
+	// instead.
+	StreamMessages = streammode.Messages
+)
+
+// WithStreamMode selects the events of streamed runs, StreamValues by
+// default.
+func WithStreamMode(modes ...StreamMode) InvokeOption {
+	return func(c *invokeConfig) {
+		c.streamModes = append(c.streamModes, modes...)
+	}
+}
+
+// Event is an event of a streamed run, sent after a node ran.
+type Event struct {
+	Mode StreamMode
+	Node string
+
+	// Update holds the fields of the state the node changed, for
+	// StreamUpdates events.
+	Update json.RawMessage
+
+	// Delta is a change of the node to the messages of the state, for
+	// StreamMessages events.
+	Delta *graph.MessageDelta
+}
+
+// WithThreadID runs the graph on the given thread, see graph.WithThreadID.
+func WithThreadID(threadID string) InvokeOption {
+	return func(c *invokeConfig) {
+		c.threadID = threadID
+	}
+}
+
+// WithEntryPoint starts the run at the named entry point, see
+// graph.WithEntryPoint.
+func WithEntryPoint(name string) InvokeOption {
+	return func(c *invokeConfig) {
+		c.entryPoint = name
+	}
+}
+
+// WithResume resumes the interrupted run of the thread with value, see
+// graph.WithResume. The value must be encodable as JSON.
+func WithResume(value any) InvokeOption {
+	return func(c *invokeConfig) {
+		c.resume = true
+		c.resumeValue = value
+	}
+}
+
+// Client runs a graph served by Register. T must be the state type of the
+// served graph, or one encoding to the same JSON.
+type Client[T any] struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient returns a client of the graph served on cc.
+func NewClient[T any](cc grpc.ClientConnInterface) *Client[T] {
+	return &Client[T]{cc: cc}
+}
+
+// Invoke runs the graph from state and sets state to the final state, as
+// graph.Runnable.Invoke does. Interrupted runs return a
+// *graph.GraphInterrupt, whose Value is decoded from JSON. The deadline of
+// ctx applies to the run on the server.
+func (c *Client[T]) Invoke(ctx context.Context, state *T, opts ...InvokeOption) error {
+	req, err := newRequest(state, opts)
+	if err != nil {
+		return err
+	}
+	resp := dynamicpb.NewMessage(invokeResponse)
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/Invoke", req, resp); err != nil {
+		return fromStatus(err)
+	}
+	return decodeResult(resp, state)
+}
+
+// Stream runs the graph like Invoke, calling onNode, if not nil, with the
+// state after each node that ran.
+func (c *Client[T]) Stream(ctx context.Context, state *T, onNode func(node string, state *T), opts ...InvokeOption) error {
+	return c.StreamEvents(ctx, state, func(e Event) error {
+		if e.Mode == StreamValues && onNode != nil {
+			onNode(e.Node, state)
+		}
+		return nil
+	}, opts...)
+}
+
+// StreamEvents runs the graph like Invoke, calling onEvent with the events of
+// the stream modes of the run, see WithStreamMode. The state is set to the
+// state of values events before onEvent is called. An error of onEvent ends
+// the run.
+func (c *Client[T]) StreamEvents(ctx context.Context, state *T, onEvent func(Event) error, opts ...InvokeOption) error {
+	req, err := newRequest(state, opts)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], "/"+serviceName+"/Stream")
+	if err != nil {
+		return fromStatus(err)
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return fromStatus(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return fromStatus(err)
+	}
+	for {
+		event := dynamicpb.NewMessage(streamEvent)
+		err := stream.RecvMsg(event)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fromStatus(err)
+		}
+		if _, err := handleEvent(event, state, onEvent); err != nil {
+			return err
+		}
+	}
+}
+
+// handleEvent decodes event, calling onEvent with the events of nodes. It
+// reports whether the event is the last of a run, returning the interrupt of
+// interrupted runs as the error.
+func handleEvent[T any](event *dynamicpb.Message, state *T, onEvent func(Event) error) (bool, error) {
+	e := Event{Mode: StreamMode(get(event, "mode").String()), Node: get(event, "node").String()}
+	switch {
+	case get(event, "done").Bool() || has(event, "interrupt"):
+		return true, decodeResult(event, state)
+	case e.Mode == StreamUpdates:
+		e.Update = json.RawMessage(get(event, "update").Bytes())
+	case e.Mode == StreamMessages:
+		e.Delta = new(graph.MessageDelta)
+		if err := json.Unmarshal(get(event, "delta").Bytes(), e.Delta); err != nil {
+			return false, fmt.Errorf("decode delta: %w", err)
+		}
+	default:
+		// Servers without stream modes send values events without mode.
+		e.Mode = StreamValues
+		if err := decodeResult(event, state); err != nil {
+			return false, err
+		}
+	}
+	return false, onEvent(e)
+}
+
+// Session is a bidirectional stream running the graph on a thread, so that
+// the runs following an interrupted run resume it on the same stream.
+type Session[T any] struct {
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+// Session opens a session with the graph. It ends when ctx is canceled or
+// Close is called.
+func (c *Client[T]) Session(ctx context.Context) (*Session[T], error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[1], "/"+serviceName+"/Session")
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("open session: %w", fromStatus(err))
+	}
+	return &Session[T]{stream: stream, cancel: cancel}, nil
+}
+
+// Run runs the graph like Client.StreamEvents. Runs without a thread ID or
+// stream modes have those of the first run of the session, so that an
+// interrupted run is resumed with:
+//
+//	err = session.Run(&state, onEvent, remote.WithResume(answer))
+//
+// Errors other than interrupts end the session.
+func (s *Session[T]) Run(state *T, onEvent func(Event) error, opts ...InvokeOption) error {
+	req, err := newRequest(state, opts)
+	if err != nil {
+		return err
+	}
+	if err := s.stream.SendMsg(req); err != nil {
+		return fromStatus(err)
+	}
+	for {
+		event := dynamicpb.NewMessage(streamEvent)
+		if err := s.stream.RecvMsg(event); err != nil {
+			return fromStatus(err)
+		}
+		done, err := handleEvent(event, state, onEvent)
+		var gi *graph.GraphInterrupt
+		if err != nil && !errors.As(err, &gi) {
+			s.cancel()
+			return err
+		}
+		if done {
+			return err
+		}
+	}
+}
+
+// Close ends the session.
+func (s *Session[T]) Close() error {
+	defer s.cancel()
+	if err := s.stream.CloseSend(); err != nil {
+		return fromStatus(err)
+	}
+	err := s.stream.RecvMsg(dynamicpb.NewMessage(streamEvent))
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return fromStatus(err)
+}
+
+// GetState returns the latest checkpoint of a thread, see
+// graph.Runnable.GetState.
+func (c *Client[T]) GetState(ctx context.Context, threadID string) (graph.Checkpoint[T], error) {
+	req := dynamicpb.NewMessage(getStateRequest)
+	set(req, "thread_id", protoreflect.ValueOfString(threadID))
+	resp := dynamicpb.NewMessage(stateSnapshot)
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/GetState", req, resp); err != nil {
+		return graph.Checkpoint[T]{}, fromStatus(err)
+	}
+	return checkpointOf[T](resp)
+}
+
+// UpdateState merges update into the state of a thread, see
+// graph.Runnable.UpdateState.
+func (c *Client[T]) UpdateState(ctx context.Context, threadID string, update T) error {
+	req := dynamicpb.NewMessage(updateStateRequest)
+	set(req, "thread_id", protoreflect.ValueOfString(threadID))
+	if err := setJSON(req, "values", update); err != nil {
+		return err
+	}
+	resp := dynamicpb.NewMessage(stateSnapshot)
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/UpdateState", req, resp); err != nil {
+		return fromStatus(err)
+	}
+	return nil
+}
+
+// newRequest returns the InvokeRequest of a run from state.
+func newRequest[T any](state *T, opts []InvokeOption) (*dynamicpb.Message, error) {
+	var cfg invokeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	req := dynamicpb.NewMessage(invokeRequest)
+	if err := setJSON(req, "state", state); err != nil {
+		return nil, err
+	}
+	set(req, "thread_id", protoreflect.ValueOfString(cfg.threadID))
+	set(req, "entry_point", protoreflect.ValueOfString(cfg.entryPoint))
+	modes := req.Mutable(req.Descriptor().Fields().ByName("stream_mode")).List()
+	for _, mode := range cfg.streamModes {
+		modes.Append(protoreflect.ValueOfString(string(mode)))
+	}
+	if cfg.resume {
+		value, err := json.Marshal(cfg.resumeValue)
+		if err != nil {
+			return nil, fmt.Errorf("encode resume value: %w", err)
+		}
+		set(req, "resume", protoreflect.ValueOfBytes(value))
+	}
+	return req, nil
+}
+
+// decodeResult decodes the state of m, an InvokeResponse or StreamEvent,
+// into state. It returns the interrupt of m, if any, as the error.
+func decodeResult[T any](m protoreflect.Message, state *T) error {
+	var next T
+	if err := json.Unmarshal(get(m, "state").Bytes(), &next); err != nil {
+		return fmt.Errorf("decode state: %w", err)
+	}
+	*state = next
+	if !has(m, "interrupt") {
+		return nil
+	}
+	gi, err := interruptOf(get(m, "interrupt").Message())
+	if err != nil {
+		return err
+	}
+	return gi
+}
+
+// checkpointOf returns the checkpoint of a StateSnapshot.
+func checkpointOf[T any](m protoreflect.Message) (graph.Checkpoint[T], error) {
+	cp := graph.Checkpoint[T]{
+		ID:       get(m, "checkpoint_id").String(),
+		ThreadID: get(m, "thread_id").String(),
+		Step:     int(get(m, "step").Int()),
+		Node:     get(m, "node").String(),
+	}
+	if err := json.Unmarshal(get(m, "state").Bytes(), &cp.State); err != nil {
+		return cp, fmt.Errorf("decode state: %w", err)
+	}
+	next := get(m, "next").List()
+	for i := range next.Len() {
+		cp.Next = append(cp.Next, next.Get(i).String())
+	}
+	if has(m, "interrupt") {
+		gi, err := interruptOf(get(m, "interrupt").Message())
+		if err != nil {
+			return cp, err
+		}
+		cp.Interrupt = gi
+	}
+	if has(m, "created_at") {
+		createdAt := get(m, "created_at").Message()
+		cp.CreatedAt = time.Unix(get(createdAt, "seconds").Int(), get(createdAt, "nanos").Int())
+	}
+	return cp, nil
+}
@@ -0,0 +1,118 @@
+package remote
+
+import (
+	"github.com/alberrttt/langgraphgo/graph/internal/protoschema"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The messages of graph.proto. Their descriptors are built from fileProto
+// and the messages are dynamic, so the package needs no generated code.
+var (
+	invokeRequest      = messageDescriptor("InvokeRequest")
+	invokeResponse     = messageDescriptor("InvokeResponse")
+	streamEvent        = messageDescriptor("StreamEvent")
+	interrupt          = messageDescriptor("Interrupt")
+	getStateRequest    = messageDescriptor("GetStateRequest")
+	updateStateRequest = messageDescriptor("UpdateStateRequest")
+	stateSnapshot      = messageDescriptor("StateSnapshot")
+)
+
+var file = func() protoreflect.FileDescriptor {
+	// Resolved from the global files, where timestamppb registers it.
+	_ = timestamppb.Timestamp{}
+
+	fd, err := protodesc.NewFile(fileProto(), protoregistry.GlobalFiles)
+	if err != nil {
+		panic("remote: invalid graph.proto descriptor: " + err.Error())
+	}
+	return fd
+}()
+
+// messageDescriptor returns the descriptor of the named message of
+// graph.proto.
+func messageDescriptor(name protoreflect.Name) protoreflect.MessageDescriptor {
+	return file.Messages().ByName(name)
+}
+
+// fileProto returns the descriptor of graph.proto, which
+// TestFileMatchesProto checks it against.
+func fileProto() *descriptorpb.FileDescriptorProto {
+	var (
+		str      = protoschema.String
+		bytes    = protoschema.Bytes
+		boolean  = protoschema.Bool
+		repeated = protoschema.Repeated
+		msg      = protoschema.Msg
+	)
+	message := func(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+		if typeName[0] != '.' {
+			typeName = ".langgraphgo.remote.v1." + typeName
+		}
+		return protoschema.Message(name, number, typeName)
+	}
+	method := func(name, input, output string, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+		return protoschema.Method(name, ".langgraphgo.remote.v1."+input, ".langgraphgo.remote.v1."+output, clientStreaming, serverStreaming)
+	}
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("langgraphgo/remote/v1/graph.proto"),
+		Package:    proto.String("langgraphgo.remote.v1"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("github.com/alberrttt/langgraphgo/graph/remote")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			protoschema.Oneofs(msg("InvokeRequest",
+				bytes("state", 1),
+				str("thread_id", 2),
+				str("entry_point", 3),
+				protoschema.Optional(0, bytes("resume", 4)),
+				repeated(str("stream_mode", 5)),
+			), "_resume"),
+			msg("InvokeResponse", bytes("state", 1), message("interrupt", 2, "Interrupt")),
+			msg("StreamEvent",
+				str("node", 1),
+				bytes("state", 2),
+				message("interrupt", 3, "Interrupt"),
+				str("mode", 4),
+				bytes("update", 5),
+				bytes("delta", 6),
+				boolean("done", 7),
+			),
+			msg("Interrupt", str("node", 1), bytes("value", 2), boolean("before", 3), boolean("after", 4)),
+			msg("GetStateRequest", str("thread_id", 1)),
+			msg("UpdateStateRequest", str("thread_id", 1), bytes("values", 2)),
+			msg("StateSnapshot",
+				str("checkpoint_id", 1),
+				str("thread_id", 2),
+				protoschema.Int64("step", 3),
+				str("node", 4),
+				bytes("state", 5),
+				repeated(str("next", 6)),
+				message("interrupt", 7, "Interrupt"),
+				message("created_at", 8, ".google.protobuf.Timestamp"),
+			),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Graph"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Invoke", "InvokeRequest", "InvokeResponse", false, false),
+				method("Stream", "InvokeRequest", "StreamEvent", false, true),
+				method("Session", "InvokeRequest", "StreamEvent", true, true),
+				method("GetState", "GetStateRequest", "StateSnapshot", false, false),
+				method("UpdateState", "UpdateStateRequest", "StateSnapshot", false, false),
+			},
+		}},
+	}
+}
+
+// The accessors of the fields of dynamic messages.
+var (
+	get = protoschema.Get
+	has = protoschema.Has
+	set = protoschema.Set
+)
@@ -0,0 +1,20 @@
+package remote
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/internal/protoschema"
+)
+
+func TestFileMatchesProto(t *testing.T) {
+	t.Parallel()
+
+	src, err := os.ReadFile("graph.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := protoschema.Compare(file, string(src)); err != nil {
+		t.Error(err)
+	}
+}
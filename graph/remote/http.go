@@ -0,0 +1,271 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	graphserver "github.com/alberrttt/langgraphgo/graph/server"
+)
+
+// HTTPOption configures an HTTPClient.
+type HTTPOption func(*httpConfig)
+
+type httpConfig struct {
+	client *http.Client
+	header http.Header
+}
+
+// WithHTTPClient sends the requests with client instead of
+// http.DefaultClient.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(c *httpConfig) {
+		c.client = client
+	}
+}
+
+// WithHeader adds a header to the requests, such as an API key.
+func WithHeader(key, value string) HTTPOption {
+	return func(c *httpConfig) {
+		c.header.Add(key, value)
+	}
+}
+
+// HTTPClient runs a graph served over HTTP by package graph/server, as the
+// assistant, or graph ID, of the server at baseURL. T must be the state type
+// of the served graph, or one encoding to the same JSON.
+//
+// Runs on a thread merge the state into the state of the thread, see
+// graphserver.RunRequest; runs without a thread run on a new thread. Entry
+// points are not supported.
+type HTTPClient[T any] struct {
+	baseURL     string
+	assistantID string
+	httpConfig
+}
+
+// NewHTTPClient returns a client of the assistant at baseURL.
+func NewHTTPClient[T any](baseURL, assistantID string, opts ...HTTPOption) *HTTPClient[T] {
+	c := &HTTPClient[T]{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		assistantID: assistantID,
+		httpConfig:  httpConfig{client: http.DefaultClient, header: make(http.Header)},
+	}
+	for _, opt := range opts {
+		opt(&c.httpConfig)
+	}
+	return c
+}
+
+// Invoke runs the graph from state and sets state to the final state, as
+// Client.Invoke does.
+func (c *HTTPClient[T]) Invoke(ctx context.Context, state *T, opts ...InvokeOption) error {
+	return c.Stream(ctx, state, nil, opts...)
+}
+
+// Stream runs the graph like Invoke, calling onNode, if not nil, with the
+// state after each node that ran.
+func (c *HTTPClient[T]) Stream(ctx context.Context, state *T, onNode func(node string, state *T), opts ...InvokeOption) error {
+	var cfg invokeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.entryPoint != "" {
+		return errors.New("remote: entry points are not supported over HTTP")
+	}
+	threadID, err := c.thread(ctx, cfg.threadID)
+	if err != nil {
+		return err
+	}
+	req := graphserver.RunRequest{
+		AssistantID: c.assistantID,
+		StreamMode:  graphserver.StreamModes{graphserver.StreamUpdates, graphserver.StreamValues},
+	}
+	if cfg.resume {
+		value, err := json.Marshal(cfg.resumeValue)
+		if err != nil {
+			return fmt.Errorf("encode resume value: %w", err)
+		}
+		req.Command = &graphserver.Command{Resume: value}
+	} else if req.Input, err = json.Marshal(state); err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/threads/"+url.PathEscape(threadID)+"/runs/stream", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := readEvents(resp.Body, state, onNode); err != nil {
+		return err
+	}
+
+	cp, err := c.GetState(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	*state = cp.State
+	if cp.Interrupt != nil {
+		return cp.Interrupt
+	}
+	return nil
+}
+
+// readEvents reads the updates and values events of a run, calling onNode
+// with the state after each node. It returns the error event of the run, if
+// any.
+func readEvents[T any](r io.Reader, state *T, onNode func(node string, state *T)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	var event, node string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			event = name
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		switch event {
+		case "updates":
+			var update map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(data), &update); err != nil {
+				return fmt.Errorf("decode update: %w", err)
+			}
+			// The update of a node is {"node": {...}}.
+			for name := range update {
+				node = name
+			}
+		case "values":
+			var next T
+			if err := json.Unmarshal([]byte(data), &next); err != nil {
+				return fmt.Errorf("decode state: %w", err)
+			}
+			*state = next
+			if onNode != nil {
+				onNode(node, state)
+			}
+		case "error":
+			var e struct {
+				Message string `json:"message"`
+			}
+			_ = json.Unmarshal([]byte(data), &e)
+			return fmt.Errorf("remote: run failed: %s", e.Message)
+		}
+	}
+	return scanner.Err()
+}
+
+// GetState returns the latest checkpoint of a thread, see
+// graph.Runnable.GetState. Threads without checkpoints return
+// graph.ErrCheckpointNotFound.
+func (c *HTTPClient[T]) GetState(ctx context.Context, threadID string) (graph.Checkpoint[T], error) {
+	resp, err := c.do(ctx, http.MethodGet, "/threads/"+url.PathEscape(threadID)+"/state", nil)
+	var se *statusError
+	if errors.As(err, &se) && se.status == http.StatusNotFound {
+		return graph.Checkpoint[T]{}, graph.ErrCheckpointNotFound
+	}
+	if err != nil {
+		return graph.Checkpoint[T]{}, err
+	}
+	defer resp.Body.Close()
+	var ts graphserver.ThreadState[T]
+	if err := json.NewDecoder(resp.Body).Decode(&ts); err != nil {
+		return graph.Checkpoint[T]{}, fmt.Errorf("decode state: %w", err)
+	}
+	if ts.Checkpoint.CheckpointID == "" {
+		return graph.Checkpoint[T]{}, graph.ErrCheckpointNotFound
+	}
+	cp := graph.Checkpoint[T]{
+		ID:       ts.Checkpoint.CheckpointID,
+		ThreadID: threadID,
+		State:    ts.Values,
+		Next:     ts.Next,
+	}
+	if step, ok := ts.Metadata["step"].(float64); ok {
+		cp.Step = int(step)
+	}
+	if ts.CreatedAt != nil {
+		cp.CreatedAt = *ts.CreatedAt
+	}
+	for _, task := range ts.Tasks {
+		for _, in := range task.Interrupts {
+			cp.Interrupt = &graph.GraphInterrupt{Node: task.Name, Value: in.Value, Before: in.When == "before", After: in.When == "after"}
+		}
+	}
+	return cp, nil
+}
+
+// thread returns the thread ID, creating the thread if needed. An empty ID
+// creates a new thread.
+func (c *HTTPClient[T]) thread(ctx context.Context, threadID string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPost, "/threads", map[string]string{"thread_id": threadID})
+	var se *statusError
+	if errors.As(err, &se) && se.status == http.StatusConflict && threadID != "" {
+		return threadID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var thread graphserver.Thread
+	if err := json.NewDecoder(resp.Body).Decode(&thread); err != nil {
+		return "", fmt.Errorf("decode thread: %w", err)
+	}
+	return thread.ThreadID, nil
+}
+
+// statusError is a response of the server with an error status.
+type statusError struct {
+	status int
+	detail string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("remote: %s: %s", http.StatusText(e.status), e.detail)
+}
+
+// do sends a request with body encoded as JSON, if not nil, and returns the
+// response, or a *statusError for error statuses.
+func (c *HTTPClient[T]) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var e struct {
+			Detail string `json:"detail"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return nil, &statusError{status: resp.StatusCode, detail: e.Detail}
+	}
+	return resp, nil
+}
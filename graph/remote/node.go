@@ -0,0 +1,184 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// Graph is a graph run by another service, such as over gRPC by a Client or
+// over HTTP by an HTTPClient.
+type Graph[T any] interface {
+	Stream(ctx context.Context, state *T, onNode func(node string, state *T), opts ...InvokeOption) error
+	GetState(ctx context.Context, threadID string) (graph.Checkpoint[T], error)
+}
+
+var (
+	_ Graph[struct{}] = (*Client[struct{}])(nil)
+	_ Graph[struct{}] = (*HTTPClient[struct{}])(nil)
+)
+
+// NodeOption configures a node delegating to a remote graph, whose state P
+// is the state of the graph of the node and C the state of the remote graph.
+type NodeOption[P, C any] func(*nodeConfig[P, C])
+
+type nodeConfig[P, C any] struct {
+	thread func(ctx context.Context, state *P) string
+	onNode func(ctx context.Context, node string, state *C)
+	opts   []InvokeOption
+}
+
+// WithThread runs the remote graph on the thread thread returns, so that its
+// state persists across the runs of the node and its interrupts are
+// forwarded, see MapNode. An empty thread runs it without one.
+func WithThread[P, C any](thread func(ctx context.Context, state *P) string) NodeOption[P, C] {
+	return func(c *nodeConfig[P, C]) {
+		c.thread = thread
+	}
+}
+
+// WithOnNode calls onNode with the state of the remote graph after each of
+// its nodes.
+func WithOnNode[P, C any](onNode func(ctx context.Context, node string, state *C)) NodeOption[P, C] {
+	return func(c *nodeConfig[P, C]) {
+		c.onNode = onNode
+	}
+}
+
+// WithNodeInvokeOptions runs the remote graph with opts, such as
+// WithEntryPoint.
+func WithNodeInvokeOptions[P, C any](opts ...InvokeOption) NodeOption[P, C] {
+	return func(c *nodeConfig[P, C]) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// Node returns a node running the remote graph g on the state of the node,
+// such as to delegate a step to a graph owned by another service, see
+// MapNode.
+func Node[T any](g Graph[T], opts ...NodeOption[T, T]) func(ctx context.Context, state *T) error {
+	in := func(state *T) (T, error) { return *state, nil }
+	out := func(state *T, result T) error {
+		*state = result
+		return nil
+	}
+	return MapNode(g, in, out, opts...)
+}
+
+// MapNode returns a node running the remote graph g on the state in maps
+// the state of the node to, setting the result of the run into the state of
+// the node with out.
+//
+// The changes of a graph.MessageState remote state to its messages are
+// emitted as message deltas after each remote node, see
+// graph.EmitMessageDelta, so that streams of the graph of the node pass
+// them through.
+//
+// When the remote graph is interrupted on a thread, see WithThread, the node
+// interrupts the graph of the node with the same value. The node resumes the
+// remote run with the resume value when it runs again. Interrupts without a
+// thread cannot be resumed and are returned as errors.
+func MapNode[P, C any](g Graph[C], in func(state *P) (C, error), out func(state *P, result C) error, opts ...NodeOption[P, C]) func(ctx context.Context, state *P) error {
+	var cfg nodeConfig[P, C]
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(ctx context.Context, state *P) error {
+		var threadID string
+		if cfg.thread != nil {
+			threadID = cfg.thread(ctx, state)
+		}
+		invokeOpts := append([]InvokeOption{}, cfg.opts...)
+		if threadID != "" {
+			invokeOpts = append(invokeOpts, WithThreadID(threadID))
+			resume, resumed, err := pendingResume(ctx, g, threadID)
+			if err != nil {
+				return err
+			}
+			if resumed {
+				invokeOpts = append(invokeOpts, WithResume(resume))
+			}
+		}
+
+		child, err := in(state)
+		if err != nil {
+			return err
+		}
+		var before []graph.Message
+		if ms, ok := any(&child).(*graph.MessageState); ok {
+			before = ms.Clone().Messages
+		}
+		var emitErr error
+		onNode := func(node string, s *C) {
+			if ms, ok := any(s).(*graph.MessageState); ok && emitErr == nil {
+				emitErr = emitDeltas(ctx, before, ms.Messages)
+				before = ms.Clone().Messages
+			}
+			if cfg.onNode != nil {
+				cfg.onNode(ctx, node, s)
+			}
+		}
+		err = g.Stream(ctx, &child, onNode, invokeOpts...)
+		var gi *graph.GraphInterrupt
+		switch {
+		case errors.As(err, &gi) && threadID != "":
+			// The resume values were consumed by pendingResume, so this
+			// interrupts the node.
+			_, err := graph.Interrupt(ctx, gi.Value)
+			return err
+		case errors.As(err, &gi):
+			return fmt.Errorf("remote: graph interrupted without a thread in node %s: %v", gi.Node, gi.Value)
+		case err != nil:
+			return err
+		case emitErr != nil:
+			return emitErr
+		}
+		return out(state, child)
+	}
+}
+
+// pendingResume returns the value the remote run interrupted on the thread
+// is resumed with, if the thread is interrupted and the run of the node is
+// resumed. The node is resumed with a value for every remote interrupt, the
+// last of which answers the pending one. An interrupted thread of a node run
+// that is not resumed interrupts the node again.
+func pendingResume[C any](ctx context.Context, g Graph[C], threadID string) (any, bool, error) {
+	cp, err := g.GetState(ctx, threadID)
+	switch {
+	case errors.Is(err, graph.ErrCheckpointNotFound):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, err
+	case cp.Interrupt == nil:
+		return nil, false, nil
+	}
+	var value any
+	resumed := false
+	for {
+		v, err := graph.Interrupt(ctx, cp.Interrupt.Value)
+		if err != nil && !resumed {
+			return nil, false, err
+		}
+		if err != nil {
+			return value, true, nil
+		}
+		value, resumed = v, true
+	}
+}
+
+// emitDeltas emits the deltas that turn the messages before into after.
+// Changes that cannot be expressed as deltas are not emitted.
+func emitDeltas(ctx context.Context, before, after []graph.Message) error {
+	deltas, ok := graph.MessageDeltas(before, after)
+	if !ok {
+		return nil
+	}
+	for _, d := range deltas {
+		if err := graph.EmitMessageDelta(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package remote_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/remote"
+	"github.com/alberrttt/langgraphgo/graph/server"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type parentState struct {
+	Greeting []string `json:"greeting"`
+}
+
+func TestMapNode(t *testing.T) {
+	t.Parallel()
+
+	in := func(*parentState) (greetState, error) { return greetState{}, nil }
+	out := func(s *parentState, result greetState) error {
+		s.Greeting = result.Messages
+		return nil
+	}
+	thread := remote.WithThread[parentState, greetState](func(context.Context, *parentState) string { return "child" })
+	var nodes []string
+	onNode := remote.WithOnNode[parentState](func(_ context.Context, node string, _ *greetState) {
+		nodes = append(nodes, node)
+	})
+	g := graph.NewStateGraph[parentState]()
+	g.AddNode("greeter", remote.MapNode(newClient(t), in, out, thread, onNode))
+	g.AddEdge("greeter", graph.END)
+	g.SetEntryPoint("greeter")
+	runnable, err := g.Compile(graph.WithCheckpointer[parentState](graph.NewMemorySaver[parentState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	// The interrupt of the remote graph interrupts the parent graph.
+	var state parentState
+	err = runnable.Invoke(context.Background(), &state, graph.WithThreadID("parent"))
+	var gi *graph.GraphInterrupt
+	if !errors.As(err, &gi) || gi.Node != "greeter" || gi.Value != "what is your name?" {
+		t.Fatalf("expected an interrupt of greeter, but got %v", err)
+	}
+
+	// Resuming the parent graph resumes the remote run.
+	err = runnable.Invoke(context.Background(), &state, graph.WithThreadID("parent"), graph.WithResume("Ada"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"name: Ada", "hello"}; !slices.Equal(state.Greeting, want) {
+		t.Errorf("expected greeting %q, but got %q", want, state.Greeting)
+	}
+	if want := []string{"ask", "greet"}; !slices.Equal(nodes, want) {
+		t.Errorf("expected remote nodes %q, but got %q", want, nodes)
+	}
+
+	// Interrupts without a thread cannot be resumed.
+	g = graph.NewStateGraph[parentState]()
+	g.AddNode("greeter", remote.MapNode(newClient(t), in, out))
+	g.AddEdge("greeter", graph.END)
+	g.SetEntryPoint("greeter")
+	runnable, err = g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	err = runnable.Invoke(context.Background(), &parentState{})
+	if err == nil || errors.As(err, &gi) {
+		t.Errorf("expected an error that is not an interrupt, but got %v", err)
+	}
+}
+
+func TestHTTPNode(t *testing.T) {
+	t.Parallel()
+
+	echo := graph.NewStateGraph[graph.MessageState]()
+	echo.AddNode("echo", func(_ context.Context, s *graph.MessageState) error {
+		last := s.Messages[len(s.Messages)-1]
+		s.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "You said: "+last.Parts[0].(llms.TextContent).Text))
+		return nil
+	})
+	echo.AddEdge("echo", graph.END)
+	echo.SetEntryPoint("echo")
+	echoRunnable, err := echo.Compile(graph.WithCheckpointer[graph.MessageState](graph.NewMemorySaver[graph.MessageState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	srv, err := server.New("echo", echoRunnable)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("delegate", remote.Node(remote.NewHTTPClient[graph.MessageState](ts.URL, "echo")))
+	g.AddEdge("delegate", graph.END)
+	g.SetEntryPoint("delegate")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	var state graph.MessageState
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	var streamed strings.Builder
+	err = runnable.Invoke(context.Background(), &state, graph.WithMessageStream(func(_ context.Context, d graph.MessageDelta) error {
+		streamed.WriteString(d.Text)
+		for _, p := range d.Parts {
+			streamed.WriteString(p.(llms.TextContent).Text)
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(state.Messages) != 2 || state.Messages[1].Parts[0].(llms.TextContent).Text != "You said: hi" {
+		t.Errorf("expected the reply of the remote graph, but got %+v", state.Messages)
+	}
+	if streamed.String() != "You said: hi" {
+		t.Errorf("expected streamed reply %q, but got %q", "You said: hi", streamed.String())
+	}
+
+	// Unknown assistants fail the node.
+	g = graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("delegate", remote.Node(remote.NewHTTPClient[graph.MessageState](ts.URL, "missing")))
+	g.AddEdge("delegate", graph.END)
+	g.SetEntryPoint("delegate")
+	runnable, err = g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	if err := runnable.Invoke(context.Background(), &state); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected an assistant not found error, but got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Package remote runs compiled graphs over gRPC, so that other services can
+// invoke them with deadlines propagated to the nodes. The service is defined
+// in graph.proto; states are encoded as JSON, so clients in other languages
+// can be generated from it.
+//
+// A service serves a graph with Register:
+//
+//	s := grpc.NewServer()
+//	remote.Register(s, runnable)
+//	s.Serve(lis)
+//
+// and Go services run it with a Client of the same state type:
+//
+//	client := remote.NewClient[State](conn)
+//	err := client.Invoke(ctx, &state, remote.WithThreadID("thread"))
+//
+// StreamEvents streams the values, updates or messages of runs, as the stream
+// modes of package graph/server do, and a Session resumes interrupted runs on
+// the same bidirectional stream.
+//
+// An HTTPClient runs graphs served over HTTP by package graph/server the same
+// way. Node and MapNode delegate a node of a graph to a remote graph run by
+// either client:
+//
+//	g.AddNode("research", remote.Node(remote.NewHTTPClient[State]("https://research.example.com", "research")))
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const serviceName = "langgraphgo.remote.v1.Graph"
+
+// graphService is implemented by the servers of graphs of any state type.
+type graphService interface {
+	invoke(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error)
+	stream(req *dynamicpb.Message, stream grpc.ServerStream) error
+	session(stream grpc.ServerStream) error
+	getState(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error)
+	updateState(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*graphService)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("Invoke", invokeRequest, graphService.invoke),
+		unary("GetState", getStateRequest, graphService.getState),
+		unary("UpdateState", updateStateRequest, graphService.updateState),
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				req := dynamicpb.NewMessage(invokeRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(graphService).stream(req, stream)
+			},
+		},
+		{
+			StreamName:    "Session",
+			ServerStreams: true,
+			ClientStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				return srv.(graphService).session(stream)
+			},
+		},
+	},
+	Metadata: "graph.proto",
+}
+
+// unary returns the description of a unary method of the service.
+func unary(name string, in protoreflect.MessageDescriptor, method func(graphService, context.Context, *dynamicpb.Message) (*dynamicpb.Message, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := dynamicpb.NewMessage(in)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return method(srv.(graphService), ctx, req.(*dynamicpb.Message))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + name}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+// errorCodes are the status codes of the errors of the graph package. The
+// client turns them back into the errors, see fromStatus.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{graph.ErrCheckpointNotFound, codes.NotFound},
+	{graph.ErrNoCheckpointer, codes.FailedPrecondition},
+	{graph.ErrEntryPointNotSet, codes.InvalidArgument},
+	{graph.ErrInvalidOption, codes.InvalidArgument},
+	{graph.ErrStepLimit, codes.ResourceExhausted},
+}
+
+// toStatus returns the status error of err.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		code := status.FromContextError(err).Code()
+		return status.Error(code, err.Error())
+	}
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return status.Error(ec.code, err.Error())
+		}
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// fromStatus returns the error of a status error, wrapping the error of the
+// graph package it was made from, if any.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, ec := range errorCodes {
+		if st.Code() == ec.code && strings.Contains(st.Message(), ec.err.Error()) {
+			return fmt.Errorf("%w (remote: %s)", ec.err, st.Message())
+		}
+	}
+	return err
+}
+
+// interruptMessage returns the message of gi.
+func interruptMessage(gi *graph.GraphInterrupt) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(interrupt)
+	set(m, "node", protoreflect.ValueOfString(gi.Node))
+	set(m, "before", protoreflect.ValueOfBool(gi.Before))
+	set(m, "after", protoreflect.ValueOfBool(gi.After))
+	if gi.Value != nil {
+		value, err := json.Marshal(gi.Value)
+		if err != nil {
+			return nil, fmt.Errorf("encode interrupt value: %w", err)
+		}
+		set(m, "value", protoreflect.ValueOfBytes(value))
+	}
+	return m, nil
+}
+
+// interruptOf returns the interrupt of m.
+func interruptOf(m protoreflect.Message) (*graph.GraphInterrupt, error) {
+	gi := &graph.GraphInterrupt{
+		Node:   get(m, "node").String(),
+		Before: get(m, "before").Bool(),
+		After:  get(m, "after").Bool(),
+	}
+	if value := get(m, "value").Bytes(); len(value) > 0 {
+		if err := json.Unmarshal(value, &gi.Value); err != nil {
+			return nil, fmt.Errorf("decode interrupt value: %w", err)
+		}
+	}
+	return gi, nil
+}
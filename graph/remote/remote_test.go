@@ -0,0 +1,162 @@
+package remote_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/remote"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type greetState struct {
+	Messages []string `json:"messages"`
+}
+
+// newClient serves a graph asking for a name with Interrupt, then greeting
+// it. Its "slow" entry point waits for the run to be canceled.
+func newClient(t *testing.T) *remote.Client[greetState] {
+	t.Helper()
+
+	g := graph.NewStateGraph[greetState]()
+	g.AddNode("ask", func(ctx context.Context, s *greetState) error {
+		name, err := graph.Interrupt(ctx, "what is your name?")
+		if err != nil {
+			return err
+		}
+		s.Messages = append(s.Messages, "name: "+name.(string))
+		return nil
+	})
+	g.AddNode("greet", func(_ context.Context, s *greetState) error {
+		s.Messages = append(s.Messages, "hello")
+		return nil
+	})
+	g.AddNode("slow", func(ctx context.Context, _ *greetState) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.AddEdge("ask", "greet")
+	g.AddEdge("greet", graph.END)
+	g.AddEdge("slow", graph.END)
+	g.SetEntryPoint("ask")
+	if err := g.SetNamedEntryPoint("slow", "slow"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runnable, err := g.Compile(graph.WithCheckpointer[greetState](graph.NewMemorySaver[greetState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return connect(t, runnable)
+}
+
+// connect serves runnable and returns a client of it.
+func connect[T any](t *testing.T, runnable *graph.Runnable[T]) *remote.Client[T] {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	remote.Register(s, runnable)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return remote.NewClient[T](conn)
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newClient(t)
+
+	state := greetState{Messages: []string{"hi"}}
+	err := client.Invoke(ctx, &state, remote.WithThreadID("t1"))
+	var gi *graph.GraphInterrupt
+	if !errors.As(err, &gi) || gi.Node != "ask" || gi.Value != "what is your name?" {
+		t.Fatalf("expected an interrupt in ask, but got %v", err)
+	}
+	if want := []string{"hi"}; !slices.Equal(state.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, state.Messages)
+	}
+	cp, err := client.GetState(ctx, "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cp.Next, []string{"ask"}) || cp.Interrupt == nil || cp.CreatedAt.IsZero() {
+		t.Errorf("expected an interrupted checkpoint, but got %+v", cp)
+	}
+
+	var nodes []string
+	err = client.Stream(ctx, &state, func(node string, _ *greetState) {
+		nodes = append(nodes, node)
+	}, remote.WithThreadID("t1"), remote.WithResume("ada"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"ask", "greet"}; !slices.Equal(nodes, want) {
+		t.Errorf("expected nodes %v, but got %v", want, nodes)
+	}
+	if want := []string{"hi", "name: ada", "hello"}; !slices.Equal(state.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, state.Messages)
+	}
+
+	if err := client.UpdateState(ctx, "t1", greetState{Messages: []string{"edited"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cp, err = client.GetState(ctx, "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"edited"}; !slices.Equal(cp.State.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, cp.State.Messages)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	client := newClient(t)
+
+	t.Run("Unknown thread", func(t *testing.T) {
+		t.Parallel()
+		if _, err := client.GetState(context.Background(), "missing"); !errors.Is(err, graph.ErrCheckpointNotFound) {
+			t.Errorf("expected error %v, but got %v", graph.ErrCheckpointNotFound, err)
+		}
+	})
+
+	t.Run("Unknown entry point", func(t *testing.T) {
+		t.Parallel()
+		var state greetState
+		if err := client.Invoke(context.Background(), &state, remote.WithEntryPoint("missing")); !errors.Is(err, graph.ErrEntryPointNotSet) {
+			t.Errorf("expected error %v, but got %v", graph.ErrEntryPointNotSet, err)
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		var state greetState
+		err := client.Invoke(ctx, &state, remote.WithEntryPoint("slow"))
+		if status.Code(err) != codes.DeadlineExceeded {
+			t.Errorf("expected code %v, but got %v", codes.DeadlineExceeded, err)
+		}
+	})
+}
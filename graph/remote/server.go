@@ -0,0 +1,262 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/streammode"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// server serves a compiled graph.
+type server[T any] struct {
+	runnable *graph.Runnable[T]
+}
+
+// Register registers the Graph service running runnable on s. Runs are
+// canceled when their deadline passes or their client goes away. Threads,
+// GetState and UpdateState require runnable to be compiled with a
+// checkpointer, see graph.WithCheckpointer.
+func Register[T any](s grpc.ServiceRegistrar, runnable *graph.Runnable[T]) {
+	s.RegisterService(&serviceDesc, &server[T]{runnable: runnable})
+}
+
+// run invokes the graph as requested by req.
+func (s *server[T]) run(ctx context.Context, req *dynamicpb.Message, opts ...graph.InvokeOption) (T, error) {
+	var state T
+	if data := get(req, "state").Bytes(); len(data) > 0 {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return state, status.Errorf(codes.InvalidArgument, "decode state: %v", err)
+		}
+	}
+	if threadID := get(req, "thread_id").String(); threadID != "" {
+		opts = append(opts, graph.WithThreadID(threadID))
+	}
+	if entryPoint := get(req, "entry_point").String(); entryPoint != "" {
+		opts = append(opts, graph.WithEntryPoint(entryPoint))
+	}
+	if has(req, "resume") {
+		var value any
+		if data := get(req, "resume").Bytes(); len(data) > 0 {
+			if err := json.Unmarshal(data, &value); err != nil {
+				return state, status.Errorf(codes.InvalidArgument, "decode resume value: %v", err)
+			}
+		}
+		opts = append(opts, graph.WithResume(value))
+	}
+	err := s.runnable.Invoke(ctx, &state, opts...)
+	return state, err
+}
+
+func (s *server[T]) invoke(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error) {
+	state, err := s.run(ctx, req)
+	var gi *graph.GraphInterrupt
+	if err != nil && !errors.As(err, &gi) {
+		return nil, toStatus(err)
+	}
+	resp := dynamicpb.NewMessage(invokeResponse)
+	if err := setJSON(resp, "state", state); err != nil {
+		return nil, toStatus(err)
+	}
+	if gi != nil {
+		if err := setInterrupt(resp, gi); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return resp, nil
+}
+
+func (s *server[T]) stream(req *dynamicpb.Message, stream grpc.ServerStream) error {
+	handler, err := newStreamHandler[T](req, stream.SendMsg)
+	if err != nil {
+		return err
+	}
+	state, err := s.run(stream.Context(), req, graph.WithRunCallbacks[T](handler))
+	var gi *graph.GraphInterrupt
+	if err == nil {
+		return nil
+	}
+	if !errors.As(err, &gi) {
+		return toStatus(err)
+	}
+	event, err := finalEvent(state, gi)
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(event)
+}
+
+// session runs the graph for every request of stream, ending the events of
+// each run with a done event. Requests without a thread ID or stream modes
+// have those of the first.
+func (s *server[T]) session(stream grpc.ServerStream) error {
+	var first *dynamicpb.Message
+	for {
+		req := dynamicpb.NewMessage(invokeRequest)
+		err := stream.RecvMsg(req)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = req
+		}
+		if get(req, "thread_id").String() == "" {
+			set(req, "thread_id", get(first, "thread_id"))
+		}
+		if modes := req.Mutable(req.Descriptor().Fields().ByName("stream_mode")).List(); modes.Len() == 0 {
+			firstModes := get(first, "stream_mode").List()
+			for i := range firstModes.Len() {
+				modes.Append(firstModes.Get(i))
+			}
+		}
+
+		handler, err := newStreamHandler[T](req, stream.SendMsg)
+		if err != nil {
+			return err
+		}
+		state, err := s.run(stream.Context(), req, graph.WithRunCallbacks[T](handler))
+		var gi *graph.GraphInterrupt
+		if err != nil && !errors.As(err, &gi) {
+			return toStatus(err)
+		}
+		event, err := finalEvent(state, gi)
+		if err != nil {
+			return err
+		}
+		set(event, "done", protoreflect.ValueOfBool(true))
+		if err := stream.SendMsg(event); err != nil {
+			return err
+		}
+	}
+}
+
+// finalEvent returns the event with the final state of a run and its
+// interrupt, if any.
+func finalEvent[T any](state T, gi *graph.GraphInterrupt) (*dynamicpb.Message, error) {
+	event := dynamicpb.NewMessage(streamEvent)
+	if err := setJSON(event, "state", state); err != nil {
+		return nil, toStatus(err)
+	}
+	if gi != nil {
+		if err := setInterrupt(event, gi); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return event, nil
+}
+
+// newStreamHandler returns the handler sending the events of the stream
+// modes of req, values by default, as StreamEvent messages.
+func newStreamHandler[T any](req *dynamicpb.Message, send func(m any) error) (*streammode.Handler[T], error) {
+	var modes []StreamMode
+	list := get(req, "stream_mode").List()
+	for i := range list.Len() {
+		mode := StreamMode(list.Get(i).String())
+		if !slices.Contains(streammode.Modes, mode) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown stream mode %q", mode)
+		}
+		modes = append(modes, mode)
+	}
+	if len(modes) == 0 {
+		modes = []StreamMode{StreamValues}
+	}
+	var mu sync.Mutex
+	return streammode.NewHandler[T](modes, func(e streammode.Event) {
+		event := dynamicpb.NewMessage(streamEvent)
+		set(event, "node", protoreflect.ValueOfString(e.Node))
+		set(event, "mode", protoreflect.ValueOfString(string(e.Mode)))
+		if err := setJSON(event, eventFields[e.Mode], e.Data); err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// A failed send means the client went away, which cancels the run.
+		_ = send(event)
+	}), nil
+}
+
+// eventFields are the fields of StreamEvent holding the data of the events
+// of each mode.
+var eventFields = map[StreamMode]string{
+	StreamValues:   "state",
+	StreamUpdates:  "update",
+	StreamMessages: "delta",
+}
+
+func (s *server[T]) getState(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error) {
+	cp, err := s.runnable.GetState(ctx, get(req, "thread_id").String())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return snapshot(cp)
+}
+
+func (s *server[T]) updateState(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error) {
+	threadID := get(req, "thread_id").String()
+	var update T
+	if err := json.Unmarshal(get(req, "values").Bytes(), &update); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "decode values: %v", err)
+	}
+	if err := s.runnable.UpdateState(ctx, threadID, update); err != nil {
+		return nil, toStatus(err)
+	}
+	return s.getState(ctx, req)
+}
+
+// snapshot returns the StateSnapshot of cp.
+func snapshot[T any](cp graph.Checkpoint[T]) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(stateSnapshot)
+	set(m, "checkpoint_id", protoreflect.ValueOfString(cp.ID))
+	set(m, "thread_id", protoreflect.ValueOfString(cp.ThreadID))
+	set(m, "step", protoreflect.ValueOfInt64(int64(cp.Step)))
+	set(m, "node", protoreflect.ValueOfString(cp.Node))
+	if err := setJSON(m, "state", cp.State); err != nil {
+		return nil, toStatus(err)
+	}
+	next := m.Mutable(m.Descriptor().Fields().ByName("next")).List()
+	for _, name := range cp.Next {
+		next.Append(protoreflect.ValueOfString(name))
+	}
+	if cp.Interrupt != nil {
+		if err := setInterrupt(m, cp.Interrupt); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	createdAt := m.Mutable(m.Descriptor().Fields().ByName("created_at")).Message()
+	set(createdAt, "seconds", protoreflect.ValueOfInt64(cp.CreatedAt.Unix()))
+	set(createdAt, "nanos", protoreflect.ValueOfInt32(int32(cp.CreatedAt.Nanosecond())))
+	return m, nil
+}
+
+// setJSON sets the named bytes field of m to v encoded as JSON.
+func setJSON(m protoreflect.Message, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	set(m, name, protoreflect.ValueOfBytes(data))
+	return nil
+}
+
+// setInterrupt sets the interrupt field of m to gi.
+func setInterrupt(m protoreflect.Message, gi *graph.GraphInterrupt) error {
+	im, err := interruptMessage(gi)
+	if err != nil {
+		return err
+	}
+	set(m, "interrupt", protoreflect.ValueOfMessage(im))
+	return nil
+}
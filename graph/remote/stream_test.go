@@ -0,0 +1,135 @@
+package remote_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/remote"
+	"github.com/tmc/langchaingo/llms"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// eventsOf returns the events of a run as mode:node strings, with the
+// updates of updates events.
+func eventsOf(events *[]string) func(remote.Event) error {
+	return func(e remote.Event) error {
+		event := string(e.Mode) + ":" + e.Node
+		if e.Update != nil {
+			event += " " + string(e.Update)
+		}
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newClient(t)
+	modes := remote.WithStreamMode(remote.StreamUpdates, remote.StreamValues)
+
+	var events []string
+	state := greetState{Messages: []string{"hi"}}
+	err := client.StreamEvents(ctx, &state, eventsOf(&events), remote.WithThreadID("t1"), modes)
+	var gi *graph.GraphInterrupt
+	if !errors.As(err, &gi) || gi.Value != "what is your name?" || len(events) != 0 {
+		t.Fatalf("expected an interrupt without events, but got %v and %q", err, events)
+	}
+
+	err = client.StreamEvents(ctx, &state, eventsOf(&events), remote.WithThreadID("t1"), remote.WithResume("ada"), modes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`updates:ask {"messages":["hi","name: ada"]}`,
+		"values:ask",
+		`updates:greet {"messages":["hi","name: ada","hello"]}`,
+		"values:greet",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("expected events %q, but got %q", want, events)
+	}
+	if want := []string{"hi", "name: ada", "hello"}; !slices.Equal(state.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, state.Messages)
+	}
+
+	err = client.StreamEvents(ctx, &state, eventsOf(&events), remote.WithStreamMode("tokens"))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected code %v for an unknown mode, but got %v", codes.InvalidArgument, err)
+	}
+}
+
+func TestStreamMessages(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("echo", func(_ context.Context, s *graph.MessageState) error {
+		s.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "hello"))
+		return nil
+	})
+	g.AddEdge("echo", graph.END)
+	g.SetEntryPoint("echo")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	client := connect(t, runnable)
+
+	var state graph.MessageState
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	var deltas []graph.MessageDelta
+	err = client.StreamEvents(context.Background(), &state, func(e remote.Event) error {
+		if e.Mode != remote.StreamMessages || e.Node != "echo" {
+			t.Errorf("expected a messages event of echo, but got %+v", e)
+		}
+		deltas = append(deltas, *e.Delta)
+		return nil
+	}, remote.WithStreamMode(remote.StreamMessages))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deltas) != 1 || deltas[0].Role != llms.ChatMessageTypeAI || deltas[0].Parts[0] != (llms.TextContent{Text: "hello"}) {
+		t.Errorf("expected the delta of the reply, but got %+v", deltas)
+	}
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	client := newClient(t)
+	session, err := client.Session(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var events []string
+	state := greetState{Messages: []string{"hi"}}
+	err = session.Run(&state, eventsOf(&events), remote.WithThreadID("t1"), remote.WithStreamMode(remote.StreamUpdates))
+	var gi *graph.GraphInterrupt
+	if !errors.As(err, &gi) || gi.Node != "ask" {
+		t.Fatalf("expected an interrupt in ask, but got %v", err)
+	}
+
+	// The resume has the thread and stream modes of the first run.
+	if err := session.Run(&state, eventsOf(&events), remote.WithResume("ada")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`updates:ask {"messages":["hi","name: ada"]}`,
+		`updates:greet {"messages":["hi","name: ada","hello"]}`,
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("expected events %q, but got %q", want, events)
+	}
+	if want := []string{"hi", "name: ada", "hello"}; !slices.Equal(state.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, state.Messages)
+	}
+	if err := session.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ErrRender is returned when a rendering service fails to draw a graph.
+var ErrRender = errors.New("render graph")
+
+// Dimensions of the SVG drawings, in pixels.
+const (
+	svgCharWidth  = 8
+	svgNodeHeight = 36
+	svgNodePad    = 12
+	svgLayerGap   = 48
+	svgNodeGap    = 32
+	svgMargin     = 24
+)
+
+// svgBox is the position of a node in an SVG drawing.
+type svgBox struct {
+	x, y, width int
+}
+
+func (b svgBox) centerX() int { return b.x + b.width/2 }
+
+// layout places the nodes of the graph in layers, by distance from START,
+// and returns their boxes and the size of the drawing.
+func (g *StateGraph[T]) layout() ([]string, []drawEdge, map[string]svgBox, int, int) {
+	nodes, edges := g.topology()
+	if slices.ContainsFunc(edges, func(e drawEdge) bool { return e.to == anyNode }) {
+		nodes = append(nodes[:len(nodes)-1], anyNode, END)
+	}
+
+	layer := map[string]int{START: 0}
+	for _, name := range nodes[1:] {
+		if _, ok := layer[name]; !ok {
+			// Unreachable nodes start new chains below START.
+			layer[name] = 1
+		}
+		for _, e := range edges {
+			if e.from == name {
+				if _, ok := layer[e.to]; !ok {
+					layer[e.to] = layer[name] + 1
+				}
+			}
+		}
+	}
+	last := 0
+	for _, name := range nodes {
+		if name != END {
+			last = max(last, layer[name])
+		}
+	}
+	layer[END] = last + 1
+
+	rows := make([][]string, last+2)
+	for _, name := range nodes {
+		rows[layer[name]] = append(rows[layer[name]], name)
+	}
+	widths := make([]int, len(rows))
+	width := 0
+	for i, row := range rows {
+		for j, name := range row {
+			if j > 0 {
+				widths[i] += svgNodeGap
+			}
+			widths[i] += boxWidth(name)
+		}
+		width = max(width, widths[i])
+	}
+
+	boxes := make(map[string]svgBox, len(nodes))
+	for i, row := range rows {
+		x := svgMargin + (width-widths[i])/2
+		y := svgMargin + i*(svgNodeHeight+svgLayerGap)
+		for _, name := range row {
+			boxes[name] = svgBox{x: x, y: y, width: boxWidth(name)}
+			x += boxWidth(name) + svgNodeGap
+		}
+	}
+	// Edges going back up are drawn on the right, hence the extra margin.
+	return nodes, edges, boxes, width + 2*svgMargin + svgLayerGap, len(rows)*(svgNodeHeight+svgLayerGap) - svgLayerGap + 2*svgMargin
+}
+
+func boxWidth(name string) int {
+	return len(nodeLabel(name))*svgCharWidth + 2*svgNodePad
+}
+
+func nodeLabel(name string) string {
+	if name == anyNode {
+		return "any node"
+	}
+	return name
+}
+
+// DrawSVG renders the graph as an SVG image to w, without any external tool
+// or service. Nodes are laid out top to bottom by distance from the start of
+// the graph; conditional edges are dashed and labeled edges show their
+// labels. The metadata of nodes shows in tooltips.
+func (g *StateGraph[T]) DrawSVG(w io.Writer) error {
+	nodes, edges, boxes, width, height := g.layout()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="monospace" font-size="13">`+"\n", width, height, width, height)
+	b.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#555"/></marker></defs>` + "\n")
+
+	for _, e := range edges {
+		from, to := boxes[e.from], boxes[e.to]
+		dash := ""
+		if e.conditional {
+			dash = ` stroke-dasharray="5,4"`
+		}
+		var path string
+		var labelX, labelY int
+		if to.y > from.y {
+			path = fmt.Sprintf("M%d,%d L%d,%d", from.centerX(), from.y+svgNodeHeight, to.centerX(), to.y)
+			labelX, labelY = (from.centerX()+to.centerX())/2, (from.y+svgNodeHeight+to.y)/2
+		} else {
+			// Back edges and self loops leave and enter boxes on the right.
+			bend := max(from.x+from.width, to.x+to.width) + svgLayerGap/2 + min((from.y-to.y)/8, svgLayerGap/2)
+			path = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d",
+				from.x+from.width, from.y+svgNodeHeight/2, bend, from.y+svgNodeHeight/2,
+				bend, to.y+svgNodeHeight/2, to.x+to.width, to.y+svgNodeHeight/2)
+			labelX, labelY = bend, (from.y+to.y)/2+svgNodeHeight/2
+		}
+		fmt.Fprintf(&b, `<path d="%s" fill="none" stroke="#555"%s marker-end="url(#arrow)"/>`+"\n", path, dash)
+		if e.label != "" {
+			fmt.Fprintf(&b, `<text class="label" x="%d" y="%d" text-anchor="middle" dominant-baseline="central" font-size="11" fill="#555">%s</text>`+"\n", labelX, labelY, html.EscapeString(e.label))
+		}
+	}
+
+	for _, name := range nodes {
+		box := boxes[name]
+		rx := 4
+		if name == START || name == END {
+			rx = svgNodeHeight / 2
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="#f2f0ff" stroke="#6a5acd"`, box.x, box.y, box.width, svgNodeHeight, rx)
+		if metadata := g.nodes[name].Metadata; !metadata.IsZero() {
+			// Viewers show the title of the box as a tooltip.
+			fmt.Fprintf(&b, "><title>%s</title></rect>\n", html.EscapeString(metadata.String()))
+		} else {
+			b.WriteString("/>\n")
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n", box.centerX(), box.y+svgNodeHeight/2, html.EscapeString(nodeLabel(name)))
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// DefaultMermaidServer is the service DrawPNG uses to render Mermaid diagrams.
+const DefaultMermaidServer = "https://mermaid.ink"
+
+// DrawPNGOption configures DrawPNG.
+type DrawPNGOption func(*drawPNGConfig)
+
+type drawPNGConfig struct {
+	server string
+	client *http.Client
+}
+
+// WithMermaidServer makes DrawPNG use a mermaid.ink compatible service at
+// baseURL, such as a self-hosted one, instead of DefaultMermaidServer.
+func WithMermaidServer(baseURL string) DrawPNGOption {
+	return func(c *drawPNGConfig) {
+		c.server = baseURL
+	}
+}
+
+// WithHTTPClient makes DrawPNG send its requests with client instead of
+// http.DefaultClient.
+func WithHTTPClient(client *http.Client) DrawPNGOption {
+	return func(c *drawPNGConfig) {
+		c.client = client
+	}
+}
+
+// DrawPNG renders the graph as a PNG image to the file at path. Since the
+// standard library cannot draw text, the image is rendered from DrawMermaid
+// by a mermaid.ink compatible service, see WithMermaidServer; use DrawSVG to
+// render locally.
+func (g *StateGraph[T]) DrawPNG(ctx context.Context, path string, opts ...DrawPNGOption) error {
+	cfg := drawPNGConfig{server: DefaultMermaidServer, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	diagram := base64.URLEncoding.EncodeToString([]byte(g.DrawMermaid()))
+	endpoint := strings.TrimSuffix(cfg.server, "/") + "/img/" + diagram + "?" + url.Values{"type": {"png"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRender, err)
+	}
+	resp, err := cfg.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRender, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%w: %s: %s", ErrRender, resp.Status, strings.TrimSpace(string(body)))
+	}
+	image, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRender, err)
+	}
+	return os.WriteFile(path, image, 0o644)
+}
@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrMissingRoute is returned when a route of a conditional edge has no node,
+// see AddTypedConditionalEdges and WithLabels.
+var ErrMissingRoute = errors.New("route without a node")
+
+// RouteEnum is implemented by route types enumerating their values, such as:
+//
+//	type Route int
+//
+//	const (
+//		CallTools Route = iota
+//		Finish
+//	)
+//
+//	func (Route) Values() []Route { return []Route{CallTools, Finish} }
+//
+// AddTypedConditionalEdges checks that every value of a RouteEnum has a node.
+type RouteEnum[R comparable] interface {
+	Values() []R
+}
+
+// AddTypedConditionalEdges adds a conditional edge from the node from to the
+// node that routes maps the route returned by path to. Unlike
+// AddConditionalEdges with WithMap, routes are typed values mapped to node
+// references when the edge is added:
+//
+//	graph.AddTypedConditionalEdges(g, agent, shouldContinue, map[Route]graph.NodeRef[State]{
+//		CallTools: tools,
+//		Finish:    graph.End[State](),
+//	})
+//
+// It returns ErrNodeNotFound if a reference does not refer to a node of the
+// graph, and ErrMissingRoute if R implements RouteEnum and one of its values
+// has no node, leaving the graph unchanged. Drawings label the edges with the
+// routes formatted with fmt.Sprint.
+//
+// A route without a node, which only a type that does not implement RouteEnum
+// allows, is an error of path: as with AddConditionalEdges, the edge then
+// routes to no node.
+func AddTypedConditionalEdges[T any, R comparable](
+	g *StateGraph[T],
+	from NodeRef[T],
+	path func(ctx context.Context, state *T) (R, error),
+	routes map[R]NodeRef[T],
+) error {
+	if from.name == END {
+		return fmt.Errorf("%w: conditional edge from %s", ErrNodeNotFound, END)
+	}
+	if err := g.checkRef(from); err != nil {
+		return err
+	}
+	for _, ref := range routes {
+		if err := g.checkRef(ref); err != nil {
+			return err
+		}
+	}
+	var zero R
+	if enum, ok := any(zero).(RouteEnum[R]); ok {
+		var errs []error
+		for _, route := range enum.Values() {
+			if _, ok := routes[route]; !ok {
+				errs = append(errs, fmt.Errorf("%w: %v", ErrMissingRoute, route))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+
+	labels := make(map[string]string, len(routes))
+	var targets []string
+	for route, ref := range routes {
+		labels[fmt.Sprint(route)] = ref.name
+		if !slices.Contains(targets, ref.name) {
+			targets = append(targets, ref.name)
+		}
+	}
+	slices.Sort(targets)
+
+	g.edges = append(g.edges, &Branch[T]{
+		Source: from.name,
+		Path: func(ctx context.Context, state *T) ([]string, error) {
+			route, err := path(ctx, state)
+			if err != nil {
+				return nil, err
+			}
+			ref, ok := routes[route]
+			if !ok {
+				return nil, fmt.Errorf("%w: %v", ErrMissingRoute, route)
+			}
+			return []string{ref.name}, nil
+		},
+		Mapping: func(x string) string {
+			return x
+		},
+		Targets: targets,
+		Routes:  labels,
+	})
+	return nil
+}
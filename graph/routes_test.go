@@ -0,0 +1,118 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+type route int
+
+const (
+	callTools route = iota
+	finish
+)
+
+func (route) Values() []route { return []route{callTools, finish} }
+
+func (r route) String() string {
+	return [...]string{"call_tools", "finish"}[r]
+}
+
+func TestAddTypedConditionalEdges(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[int]()
+	agent, _ := g.AddNode("agent", func(_ context.Context, n *int) error {
+		*n++
+		return nil
+	})
+	tools, _ := g.AddNode("tools", func(_ context.Context, n *int) error {
+		*n *= 2
+		return nil
+	})
+	err := graph.AddTypedConditionalEdges(g, agent, func(_ context.Context, n *int) (route, error) {
+		if *n >= 10 {
+			return finish, nil
+		}
+		return callTools, nil
+	}, map[route]graph.NodeRef[int]{
+		callTools: tools,
+		finish:    graph.End[int](),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g.AddEdgeRef(tools, agent)
+	g.SetEntryPointRef(agent)
+
+	runnable, err := g.Compile(graph.WithAllowedCycle("agent", "tools"))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 15 {
+		t.Errorf("expected 15, but got %d", n)
+	}
+
+	ascii := g.DrawASCII()
+	for _, want := range []string{"+..> tools (call_tools)", "+..> END (finish)"} {
+		if !strings.Contains(ascii, want) {
+			t.Errorf("expected drawing to contain %q, but got\n%s", want, ascii)
+		}
+	}
+}
+
+func TestAddTypedConditionalEdgesErrors(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	path := func(context.Context, *int) (route, error) { return finish, nil }
+
+	g := graph.NewStateGraph[int]()
+	agent, _ := g.AddNode("agent", noop)
+	other, _ := graph.NewStateGraph[int]().AddNode("other", noop)
+
+	testCases := []struct {
+		name    string
+		from    graph.NodeRef[int]
+		routes  map[route]graph.NodeRef[int]
+		wantErr error
+	}{
+		{
+			name:    "From END",
+			from:    graph.End[int](),
+			routes:  map[route]graph.NodeRef[int]{callTools: agent, finish: graph.End[int]()},
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Node of another graph",
+			from:    agent,
+			routes:  map[route]graph.NodeRef[int]{callTools: other, finish: graph.End[int]()},
+			wantErr: graph.ErrNodeNotFound,
+		},
+		{
+			name:    "Missing route",
+			from:    agent,
+			routes:  map[route]graph.NodeRef[int]{finish: graph.End[int]()},
+			wantErr: graph.ErrMissingRoute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := graph.AddTypedConditionalEdges(g, tc.from, path, tc.routes); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, but got %v", tc.wantErr, err)
+			}
+		})
+	}
+	if edges := g.Edges(); len(edges) != 0 {
+		t.Errorf("expected no edges, but got %v", edges)
+	}
+}
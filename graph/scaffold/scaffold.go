@@ -0,0 +1,107 @@
+// Package scaffold generates the skeleton of a new agent project: a state
+// with a reducer, a ReAct agent graph with a tool, a command chatting with
+// it on a checkpointed thread, and tests running it with a fake model,
+// so that new projects start from a working baseline:
+//
+//	err := scaffold.Generate("myagent", scaffold.Config{Module: "example.com/myagent"})
+//
+// The project builds once its dependencies are resolved with go mod tidy.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ErrNotEmpty is returned by Generate when the directory already has files.
+var ErrNotEmpty = errors.New("scaffold: directory is not empty")
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Config configures a generated project.
+type Config struct {
+	// Module is the module path of the project. It defaults to the base name
+	// of the directory.
+	Module string
+
+	// Replace, if set, replaces the langgraphgo module with this directory
+	// in go.mod, to build the project against a local checkout. A relative
+	// directory is relative to the project.
+	Replace string
+}
+
+// Files returns the files of a project, by name. The Go files are
+// formatted with gofmt.
+func Files(cfg Config) (map[string][]byte, error) {
+	if cfg.Module == "" {
+		return nil, errors.New("scaffold: missing module path")
+	}
+	t, err := template.ParseFS(templates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	data := struct {
+		Config
+		Name string
+	}{cfg, path.Base(cfg.Module)}
+
+	files := map[string][]byte{}
+	for _, tmpl := range t.Templates() {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("scaffold: %s: %w", tmpl.Name(), err)
+		}
+		name := strings.TrimSuffix(tmpl.Name(), ".tmpl")
+		src := b.Bytes()
+		if strings.HasSuffix(name, ".go") {
+			if src, err = format.Source(src); err != nil {
+				return nil, fmt.Errorf("scaffold: %s: %w", name, err)
+			}
+		}
+		files[name] = src
+	}
+	return files, nil
+}
+
+// Generate writes the files of a project to dir, creating it if needed. It
+// returns ErrNotEmpty rather than overwrite the files of an existing
+// directory.
+func Generate(dir string, cfg Config) error {
+	if cfg.Module == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		cfg.Module = filepath.Base(abs)
+	}
+	files, err := Files(cfg)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	switch {
+	case err == nil && len(entries) > 0:
+		return fmt.Errorf("%w: %s", ErrNotEmpty, dir)
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
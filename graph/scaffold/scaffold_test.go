@@ -0,0 +1,47 @@
+package scaffold_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph/scaffold"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "myagent")
+	if err := scaffold.Generate(dir, scaffold.Config{Replace: "../langgraphgo"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"agent.go", "agent_test.go", "go.mod", "main.go", "state.go"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected files %v, but got %v", want, names)
+	}
+
+	mod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"module myagent", "replace github.com/alberrttt/langgraphgo => ../langgraphgo"} {
+		if !strings.Contains(string(mod), line+"\n") {
+			t.Errorf("expected go.mod to contain %q, but got\n%s", line, mod)
+		}
+	}
+
+	if err := scaffold.Generate(dir, scaffold.Config{}); !errors.Is(err, scaffold.ErrNotEmpty) {
+		t.Errorf("expected error %v, but got %v", scaffold.ErrNotEmpty, err)
+	}
+}
@@ -0,0 +1,341 @@
+// Package schedule runs graphs on recurring schedules given as cron
+// expressions, such as a report agent running every morning:
+//
+//	run, err := schedule.GraphRun(runnable, `{"day": "{{.Time.Format "2006-01-02"}}"}`)
+//	...
+//	s := schedule.New()
+//	err = s.Add(schedule.Job{Name: "daily-report", Schedule: "0 9 * * *", Run: run})
+//	...
+//	err = s.Run(ctx)
+//
+// Schedules have five fields, minute to day of week, an optional leading
+// field of seconds, or are descriptors such as "@daily" or "@every 1h". They
+// are in the local time zone unless prefixed with "CRON_TZ=Europe/Paris ".
+// The scheduler records the runs of every job, see Scheduler.History.
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	// ErrDuplicateJob is returned when adding a job under the name of another.
+	ErrDuplicateJob = errors.New("duplicate job")
+
+	// ErrInvalidSchedule is returned when adding a job whose schedule cannot
+	// be parsed.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+var parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// OverlapPolicy tells what happens when a job is due while its previous run
+// is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run.
+	OverlapSkip OverlapPolicy = iota
+
+	// OverlapQueue runs the job once the previous run ended. Runs due while
+	// one is queued are skipped.
+	OverlapQueue
+
+	// OverlapAllow runs the job alongside the previous run.
+	OverlapAllow
+)
+
+// Tick is a run of a job.
+type Tick struct {
+	// Job is the name of the job.
+	Job string
+
+	// Time is the time the run was due.
+	Time time.Time
+
+	// ThreadID is the thread of the run, unique to the job and the time.
+	ThreadID string
+}
+
+// Job is a recurring run.
+type Job struct {
+	// Name identifies the job.
+	Name string
+
+	// Schedule is the cron expression telling when the job runs.
+	Schedule string
+
+	// Overlap tells what happens when the job is due while running,
+	// OverlapSkip by default.
+	Overlap OverlapPolicy
+
+	// Run runs the job, such as a graph with GraphRun.
+	Run func(ctx context.Context, tick Tick) error
+}
+
+// GraphRun returns a Job.Run invoking runnable on the thread of the tick. The
+// input state of the run is decoded from JSON rendered from the Go
+// text/template tmpl with the Tick, such as `{"day": "{{.Time.Format
+// "2006-01-02"}}"}`. Interrupted runs are successful.
+func GraphRun[T any](runnable *graph.Runnable[T], tmpl string, opts ...graph.InvokeOption) (func(context.Context, Tick) error, error) {
+	t, err := template.New("input").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, tick Tick) error {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, tick); err != nil {
+			return fmt.Errorf("render input: %w", err)
+		}
+		var state T
+		if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+			return fmt.Errorf("decode input: %w", err)
+		}
+		err := runnable.Invoke(ctx, &state, append(opts, graph.WithThreadID(tick.ThreadID))...)
+		var gi *graph.GraphInterrupt
+		if errors.As(err, &gi) {
+			return nil
+		}
+		return err
+	}, nil
+}
+
+// Clock tells the time to a Scheduler, such as a graphtest.FakeClock in
+// tests.
+type Clock = graph.Clock
+
+// RunStatus is the status of a run recorded by a Scheduler.
+type RunStatus string
+
+const (
+	RunRunning RunStatus = "running"
+	RunSuccess RunStatus = "success"
+	RunError   RunStatus = "error"
+	RunSkipped RunStatus = "skipped"
+)
+
+// Record is a run of a job, or a run that was skipped.
+type Record struct {
+	Tick
+	Status    RunStatus
+	Error     string
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithClock makes the scheduler tell the time with clock, such as in tests.
+func WithClock(clock Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// WithHistoryLimit keeps the last n records of every job, 100 by default.
+func WithHistoryLimit(n int) Option {
+	return func(s *Scheduler) {
+		s.historyLimit = n
+	}
+}
+
+// Scheduler runs jobs on their schedules.
+type Scheduler struct {
+	clock        Clock
+	historyLimit int
+
+	mu   sync.Mutex
+	jobs map[string]*job
+	// ctx is the context of Run, nil when the scheduler is not running.
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+// job is a job added to a scheduler.
+type job struct {
+	Job
+	schedule cron.Schedule
+	// stop stops the scheduling of the job.
+	stop    context.CancelFunc
+	running int
+	// queued is the tick queued by OverlapQueue, if any.
+	queued  *Tick
+	history []Record
+}
+
+// New returns a Scheduler without jobs.
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{clock: graph.SystemClock(), historyLimit: 100, jobs: make(map[string]*job)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add adds a job, which is scheduled right away if the scheduler is running.
+func (s *Scheduler) Add(j Job) error {
+	sched, err := parser.Parse(j.Schedule)
+	if err != nil {
+		return fmt.Errorf("%w: job %s: %v", ErrInvalidSchedule, j.Name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[j.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, j.Name)
+	}
+	jb := &job{Job: j, schedule: sched}
+	s.jobs[j.Name] = jb
+	if s.ctx != nil {
+		s.start(jb)
+	}
+	return nil
+}
+
+// Remove removes the job with the given name, its runs in progress going on.
+// It reports whether the scheduler had the job.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jb, ok := s.jobs[name]
+	if !ok {
+		return false
+	}
+	if jb.stop != nil {
+		jb.stop()
+	}
+	delete(s.jobs, name)
+	return true
+}
+
+// History returns the records of the last runs of a job, oldest first.
+func (s *Scheduler) History(name string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if jb, ok := s.jobs[name]; ok {
+		return append([]Record(nil), jb.history...)
+	}
+	return nil
+}
+
+// Run runs the jobs on their schedules until ctx is done, then waits for the
+// runs in progress, whose context is ctx, and returns the error of ctx.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.ctx != nil {
+		s.mu.Unlock()
+		return errors.New("scheduler is already running")
+	}
+	s.ctx = ctx
+	for _, jb := range s.jobs {
+		s.start(jb)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+	return ctx.Err()
+}
+
+// start schedules jb, s.mu being held.
+func (s *Scheduler) start(jb *job) {
+	runCtx := s.ctx
+	ctx, stop := context.WithCancel(runCtx)
+	jb.stop = stop
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			now := s.clock.Now()
+			next := jb.schedule.Next(now)
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.clock.After(next.Sub(now)):
+			}
+			s.fire(runCtx, jb, next)
+		}
+	}()
+}
+
+// fire runs jb for the time at, applying its overlap policy.
+func (s *Scheduler) fire(ctx context.Context, jb *job, at time.Time) {
+	tick := Tick{Job: jb.Name, Time: at, ThreadID: jb.Name + "/" + at.UTC().Format(time.RFC3339)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if jb.running > 0 {
+		switch {
+		case jb.Overlap == OverlapQueue && jb.queued == nil:
+			jb.queued = &tick
+			return
+		case jb.Overlap != OverlapAllow:
+			s.record(jb, Record{Tick: tick, Status: RunSkipped})
+			return
+		}
+	}
+	jb.running++
+	s.wg.Add(1)
+	go s.run(ctx, jb, tick)
+}
+
+// run runs jb for tick, then for the tick queued meanwhile, if any.
+func (s *Scheduler) run(ctx context.Context, jb *job, tick Tick) {
+	defer s.wg.Done()
+	for {
+		rec := Record{Tick: tick, Status: RunRunning, StartedAt: s.clock.Now()}
+		s.mu.Lock()
+		s.record(jb, rec)
+		s.mu.Unlock()
+
+		err := jb.Run(ctx, tick)
+		rec.EndedAt = s.clock.Now()
+		rec.Status = RunSuccess
+		if err != nil {
+			rec.Status = RunError
+			rec.Error = err.Error()
+		}
+
+		s.mu.Lock()
+		s.update(jb, rec)
+		if jb.queued == nil {
+			jb.running--
+			s.mu.Unlock()
+			return
+		}
+		tick = *jb.queued
+		jb.queued = nil
+		s.mu.Unlock()
+	}
+}
+
+// record adds rec to the history of jb, s.mu being held.
+func (s *Scheduler) record(jb *job, rec Record) {
+	jb.history = append(jb.history, rec)
+	if n := len(jb.history) - s.historyLimit; n > 0 && s.historyLimit > 0 {
+		jb.history = jb.history[n:]
+	}
+}
+
+// update replaces the record of the run of rec in the history of jb, unless
+// it was dropped, s.mu being held.
+func (s *Scheduler) update(jb *job, rec Record) {
+	for i := len(jb.history) - 1; i >= 0; i-- {
+		if jb.history[i].ThreadID == rec.ThreadID && jb.history[i].Status == RunRunning {
+			jb.history[i] = rec
+			return
+		}
+	}
+}
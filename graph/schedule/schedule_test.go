@@ -0,0 +1,235 @@
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/schedule"
+)
+
+// fakeClock is a Clock whose time is advanced by tests.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// advance waits for the scheduler to set a timer, moves the time to it and
+// fires it, then waits for the scheduler to set the next timer, which it does
+// once it handled the first.
+func (c *fakeClock) advance(t *testing.T) time.Time {
+	t.Helper()
+
+	c.waitTimer(t)
+	c.mu.Lock()
+	w := c.waiters[0]
+	c.waiters = c.waiters[1:]
+	c.now = w.at
+	c.mu.Unlock()
+	w.ch <- w.at
+	c.waitTimer(t)
+	return w.at
+}
+
+func (c *fakeClock) waitTimer(t *testing.T) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		n := len(c.waiters)
+		c.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a timer, but got none")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitHistory waits for the history of a job to have the given statuses.
+func waitHistory(t *testing.T, s *schedule.Scheduler, name string, want ...schedule.RunStatus) []schedule.Record {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		history := s.History(name)
+		var got []schedule.RunStatus
+		for _, rec := range history {
+			got = append(got, rec.Status)
+		}
+		if slices.Equal(got, want) {
+			return history
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected statuses %v, but got %v", want, got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func start(t *testing.T, s *schedule.Scheduler) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected error %v, but got %v", context.Canceled, err)
+		}
+	})
+}
+
+type reportState struct {
+	Day    string `json:"day"`
+	Report string `json:"report"`
+}
+
+func TestGraphRun(t *testing.T) {
+	t.Parallel()
+
+	reports := make(chan reportState, 1)
+	g := graph.NewStateGraph[reportState]()
+	g.AddNode("report", func(_ context.Context, s *reportState) error {
+		s.Report = "report of " + s.Day
+		reports <- *s
+		return nil
+	})
+	g.AddEdge("report", graph.END)
+	g.SetEntryPoint("report")
+	saver := graph.NewMemorySaver[reportState]()
+	runnable, err := g.Compile(graph.WithCheckpointer[reportState](saver))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	run, err := schedule.GraphRun(runnable, `{"day": "{{.Time.Format "2006-01-02"}}"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)}
+	s := schedule.New(schedule.WithClock(clock))
+	if err := s.Add(schedule.Job{Name: "daily-report", Schedule: "CRON_TZ=UTC 0 9 * * *", Run: run}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start(t, s)
+
+	if at, want := clock.advance(t), time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC); !at.Equal(want) {
+		t.Errorf("expected the job to run at %v, but got %v", want, at)
+	}
+	if got := <-reports; got.Report != "report of 2024-05-01" {
+		t.Errorf("expected the report of 2024-05-01, but got %q", got.Report)
+	}
+	history := waitHistory(t, s, "daily-report", schedule.RunSuccess)
+	if _, err := saver.Get(context.Background(), history[0].ThreadID); err != nil {
+		t.Errorf("expected a checkpoint on thread %s, but got %v", history[0].ThreadID, err)
+	}
+}
+
+func TestOverlapPolicies(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		overlap schedule.OverlapPolicy
+		want    []schedule.RunStatus
+	}{
+		{"Skip", schedule.OverlapSkip, []schedule.RunStatus{schedule.RunSuccess, schedule.RunSkipped, schedule.RunSkipped}},
+		{"Queue", schedule.OverlapQueue, []schedule.RunStatus{schedule.RunSuccess, schedule.RunSkipped, schedule.RunSuccess}},
+		{"Allow", schedule.OverlapAllow, []schedule.RunStatus{schedule.RunSuccess, schedule.RunSuccess, schedule.RunSuccess}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The first run blocks until the job was due twice more.
+			release := make(chan struct{})
+			var mu sync.Mutex
+			runs := 0
+			job := schedule.Job{
+				Name:     "job",
+				Schedule: "* * * * *",
+				Overlap:  tc.overlap,
+				Run: func(ctx context.Context, _ schedule.Tick) error {
+					mu.Lock()
+					runs++
+					first := runs == 1
+					mu.Unlock()
+					if first {
+						<-release
+					}
+					return nil
+				},
+			}
+			clock := &fakeClock{now: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)}
+			s := schedule.New(schedule.WithClock(clock))
+			if err := s.Add(job); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			start(t, s)
+
+			clock.advance(t)
+			clock.advance(t)
+			clock.advance(t)
+			close(release)
+
+			// Skipped runs are recorded when due, other runs when they start.
+			history := waitHistory(t, s, "job", tc.want...)
+			slices.SortFunc(history, func(a, b schedule.Record) int { return a.Time.Compare(b.Time) })
+			if tc.overlap == schedule.OverlapQueue && history[2].Status != schedule.RunSkipped {
+				t.Errorf("expected the run due at 08:32 to be queued and the next skipped, but got %+v", history)
+			}
+		})
+	}
+}
+
+func TestSchedulerErrors(t *testing.T) {
+	t.Parallel()
+
+	s := schedule.New()
+	run := func(context.Context, schedule.Tick) error { return nil }
+	if err := s.Add(schedule.Job{Name: "job", Schedule: "every day", Run: run}); !errors.Is(err, schedule.ErrInvalidSchedule) {
+		t.Errorf("expected error %v, but got %v", schedule.ErrInvalidSchedule, err)
+	}
+	if err := s.Add(schedule.Job{Name: "job", Schedule: "@daily", Run: run}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Add(schedule.Job{Name: "job", Schedule: "@hourly", Run: run}); !errors.Is(err, schedule.ErrDuplicateJob) {
+		t.Errorf("expected error %v, but got %v", schedule.ErrDuplicateJob, err)
+	}
+	if !s.Remove("job") || s.Remove("job") {
+		t.Error("expected the job to be removed once")
+	}
+	if _, err := schedule.GraphRun[reportState](nil, "{{.Missing"); err == nil {
+		t.Error("expected a template error, but got none")
+	}
+}
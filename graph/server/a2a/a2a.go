@@ -0,0 +1,262 @@
+// Package a2a serves compiled graphs with the Agent2Agent (A2A) protocol, so
+// that other agents and orchestrators discover them by their agent card and
+// send them tasks:
+//
+//	card := a2a.AgentCard{Name: "Support", Description: "Answers customers", URL: "https://support.example.com/a2a/"}
+//	h, err := a2a.New(card, runnable)
+//	if err != nil {
+//		return err
+//	}
+//	http.Handle("/a2a/", http.StripPrefix("/a2a", h))
+//
+// The handler serves the agent card at /.well-known/agent.json and the
+// JSON-RPC methods message/send, message/stream, tasks/get, tasks/cancel
+// and tasks/resubscribe at /. The contextId of messages is the thread of
+// the graph, so the tasks of a context continue its conversation, and a
+// task is a run of the graph. A run that is interrupted asks for input: the
+// next message of the task resumes it, see graph.WithResume.
+//
+// The messages of graphs whose state is a graph.MessageState are added to
+// their state, and their replies are the text of the AI messages of the
+// run. Other states are decoded from the data part of messages, or their
+// text as JSON, and sent whole as the data part of the reply, see WithInput
+// and WithOutput.
+package a2a
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProtocolVersion is the version of the A2A protocol served.
+const ProtocolVersion = "0.2.5"
+
+// AgentCard describes an agent to its clients.
+type AgentCard struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// URL is the URL of the JSON-RPC endpoint of the agent.
+	URL     string `json:"url"`
+	Version string `json:"version"`
+
+	ProtocolVersion    string            `json:"protocolVersion"`
+	Provider           *AgentProvider    `json:"provider,omitempty"`
+	DocumentationURL   string            `json:"documentationUrl,omitempty"`
+	Capabilities       AgentCapabilities `json:"capabilities"`
+	DefaultInputModes  []string          `json:"defaultInputModes"`
+	DefaultOutputModes []string          `json:"defaultOutputModes"`
+	Skills             []AgentSkill      `json:"skills"`
+}
+
+// AgentProvider is the organization providing an agent.
+type AgentProvider struct {
+	Organization string `json:"organization"`
+	URL          string `json:"url"`
+}
+
+// AgentCapabilities are the optional features of the protocol an agent
+// supports.
+type AgentCapabilities struct {
+	Streaming              bool `json:"streaming"`
+	PushNotifications      bool `json:"pushNotifications"`
+	StateTransitionHistory bool `json:"stateTransitionHistory"`
+}
+
+// AgentSkill is a task an agent performs.
+type AgentSkill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+// Role is the author of a message.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAgent Role = "agent"
+)
+
+// Message is a message of a user or an agent.
+type Message struct {
+	Kind      string         `json:"kind"`
+	MessageID string         `json:"messageId"`
+	Role      Role           `json:"role"`
+	Parts     []Part         `json:"parts"`
+	TaskID    string         `json:"taskId,omitempty"`
+	ContextID string         `json:"contextId,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// PartKind is the kind of a Part.
+type PartKind string
+
+const (
+	PartText PartKind = "text"
+	PartData PartKind = "data"
+	PartFile PartKind = "file"
+)
+
+// Part is a part of a message or an artifact: text, structured data or a
+// file.
+type Part struct {
+	Kind     PartKind       `json:"kind"`
+	Text     string         `json:"text,omitempty"`
+	Data     any            `json:"data,omitempty"`
+	File     *File          `json:"file,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// TextPart returns a part of kind text.
+func TextPart(text string) Part {
+	return Part{Kind: PartText, Text: text}
+}
+
+// DataPart returns a part of kind data.
+func DataPart(data any) Part {
+	return Part{Kind: PartData, Data: data}
+}
+
+// File is the content of a file part, inline as base64 bytes or at a URI.
+type File struct {
+	Name     string `json:"name,omitempty"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"`
+	URI      string `json:"uri,omitempty"`
+}
+
+// TaskState is the state of a task.
+type TaskState string
+
+const (
+	TaskSubmitted     TaskState = "submitted"
+	TaskWorking       TaskState = "working"
+	TaskInputRequired TaskState = "input-required"
+	TaskCompleted     TaskState = "completed"
+	TaskCanceled      TaskState = "canceled"
+	TaskFailed        TaskState = "failed"
+)
+
+// terminal reports whether a task in state s is over.
+func (s TaskState) terminal() bool {
+	return s == TaskCompleted || s == TaskCanceled || s == TaskFailed
+}
+
+// TaskStatus is the state of a task, with the message of the agent about
+// it, if any.
+type TaskStatus struct {
+	State     TaskState `json:"state"`
+	Message   *Message  `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Artifact is an output of a task.
+type Artifact struct {
+	ArtifactID string `json:"artifactId"`
+	Name       string `json:"name,omitempty"`
+	Parts      []Part `json:"parts"`
+}
+
+// Task is a unit of work of an agent: a run of the graph.
+type Task struct {
+	Kind      string     `json:"kind"`
+	ID        string     `json:"id"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	History   []Message  `json:"history,omitempty"`
+}
+
+// TaskStatusUpdateEvent is a change of the status of a task, sent to
+// streaming clients.
+type TaskStatusUpdateEvent struct {
+	Kind      string     `json:"kind"`
+	TaskID    string     `json:"taskId"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+
+	// Final is true for the last event of a stream.
+	Final bool `json:"final"`
+}
+
+// TaskArtifactUpdateEvent is a new or updated artifact of a task, sent to
+// streaming clients.
+type TaskArtifactUpdateEvent struct {
+	Kind      string   `json:"kind"`
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Artifact  Artifact `json:"artifact"`
+	Append    bool     `json:"append"`
+	LastChunk bool     `json:"lastChunk"`
+}
+
+// MessageSendParams are the params of message/send and message/stream.
+type MessageSendParams struct {
+	Message       Message                   `json:"message"`
+	Configuration *MessageSendConfiguration `json:"configuration,omitempty"`
+}
+
+// MessageSendConfiguration configures message/send.
+type MessageSendConfiguration struct {
+	// HistoryLength is the number of messages of the history of the task
+	// returned, all of them if nil.
+	HistoryLength *int `json:"historyLength,omitempty"`
+
+	// Blocking waits for the task to end or ask for input, which
+	// message/send does unless it is false.
+	Blocking *bool `json:"blocking,omitempty"`
+}
+
+// TaskQueryParams are the params of tasks/get.
+type TaskQueryParams struct {
+	ID            string `json:"id"`
+	HistoryLength *int   `json:"historyLength,omitempty"`
+}
+
+// TaskIDParams are the params of tasks/cancel and tasks/resubscribe.
+type TaskIDParams struct {
+	ID string `json:"id"`
+}
+
+// JSON-RPC error codes of the protocol.
+const (
+	CodeParseError                   = -32700
+	CodeInvalidRequest               = -32600
+	CodeMethodNotFound               = -32601
+	CodeInvalidParams                = -32602
+	CodeInternalError                = -32603
+	CodeTaskNotFound                 = -32001
+	CodeTaskNotCancelable            = -32002
+	CodePushNotificationNotSupported = -32003
+	CodeUnsupportedOperation         = -32004
+)
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// request is a JSON-RPC request.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// response is a JSON-RPC response.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
@@ -0,0 +1,274 @@
+package a2a_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server/a2a"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// agent replies to the last message: it asks for a name on "hello", waits
+// for its run to be canceled on "wait", and echoes other messages.
+func agent(ctx context.Context, s *graph.MessageState) error {
+	last := s.Messages[len(s.Messages)-1]
+	text := last.Parts[0].(llms.TextContent).Text
+	switch text {
+	case "hello":
+		name, err := graph.Interrupt(ctx, "what is your name?")
+		if err != nil {
+			return err
+		}
+		text = fmt.Sprintf("Hello, %v", name)
+	case "wait":
+		<-ctx.Done()
+		return ctx.Err()
+	default:
+		text = "You said: " + text
+	}
+	s.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, text))
+	return nil
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("agent", agent)
+	g.AddEdge("agent", graph.END)
+	g.SetEntryPoint("agent")
+	runnable, err := g.Compile(graph.WithCheckpointer[graph.MessageState](graph.NewMemorySaver[graph.MessageState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	h, err := a2a.New(a2a.AgentCard{Name: "Echo", Description: "Echoes messages"}, runnable)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// call calls method with params, decoding its result into result, and
+// returns its error.
+func call(t *testing.T, ts *httptest.Server, method string, params, result any) *a2a.Error {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Result json.RawMessage `json:"result"`
+		Error  *a2a.Error      `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body.Error == nil && result != nil {
+		if err := json.Unmarshal(body.Result, result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return body.Error
+}
+
+func message(text, taskID, contextID string) a2a.MessageSendParams {
+	return a2a.MessageSendParams{Message: a2a.Message{
+		Kind:      "message",
+		Role:      a2a.RoleUser,
+		Parts:     []a2a.Part{a2a.TextPart(text)},
+		TaskID:    taskID,
+		ContextID: contextID,
+	}}
+}
+
+// reply returns the text of the artifact of task.
+func reply(task a2a.Task) string {
+	if len(task.Artifacts) == 0 || len(task.Artifacts[0].Parts) == 0 {
+		return ""
+	}
+	return task.Artifacts[0].Parts[0].Text
+}
+
+func TestAgentCard(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	resp, err := http.Get(ts.URL + "/.well-known/agent.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var card a2a.AgentCard
+	if err := json.NewDecoder(resp.Body).Decode(&card); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if card.Name != "Echo" || card.ProtocolVersion != a2a.ProtocolVersion || !card.Capabilities.Streaming || card.DefaultInputModes[0] != "text/plain" {
+		t.Errorf("expected the card of Echo with defaults, but got %+v", card)
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	var task a2a.Task
+	if err := call(t, ts, "message/send", message("hi", "", ""), &task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status.State != a2a.TaskCompleted || reply(task) != "You said: hi" {
+		t.Errorf("expected a completed task replying %q, but got %+v", "You said: hi", task)
+	}
+
+	// The tasks of a context continue its conversation.
+	var next a2a.Task
+	if err := call(t, ts, "message/send", message("hello", "", task.ContextID), &next); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next.Status.State != a2a.TaskInputRequired || next.ContextID != task.ContextID || next.ID == task.ID {
+		t.Fatalf("expected a new task of context %s asking for input, but got %+v", task.ContextID, next)
+	}
+	if text := next.Status.Message.Parts[0].Text; text != "what is your name?" {
+		t.Errorf("expected question %q, but got %q", "what is your name?", text)
+	}
+	if err := call(t, ts, "message/send", message("Ada", next.ID, ""), &next); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next.Status.State != a2a.TaskCompleted || reply(next) != "Hello, Ada" {
+		t.Errorf("expected a completed task replying %q, but got %+v", "Hello, Ada", next)
+	}
+
+	var got a2a.Task
+	if err := call(t, ts, "tasks/get", map[string]any{"id": next.ID, "historyLength": 2}, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.History) != 2 || got.History[0].Role != a2a.RoleAgent || got.History[1].Parts[0].Text != "Ada" {
+		t.Errorf("expected the question and the answer, but got %+v", got.History)
+	}
+}
+
+func TestStreamMessage(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "message/stream", "params": message("hi", "", "")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var kinds []string
+	var final bool
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event struct {
+			Result struct {
+				Kind     string         `json:"kind"`
+				Status   a2a.TaskStatus `json:"status"`
+				Artifact a2a.Artifact   `json:"artifact"`
+				Final    bool           `json:"final"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		kinds = append(kinds, event.Result.Kind)
+		final = event.Result.Final
+		if event.Result.Kind == "artifact-update" && event.Result.Artifact.Parts[0].Text != "You said: hi" {
+			t.Errorf("expected artifact %q, but got %+v", "You said: hi", event.Result.Artifact)
+		}
+	}
+	if len(kinds) < 4 || kinds[0] != "task" || kinds[1] != "status-update" || kinds[len(kinds)-2] != "artifact-update" || !final {
+		t.Errorf("expected the task, its updates and a final status, but got %v (final: %v)", kinds, final)
+	}
+}
+
+func TestCancelTask(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	params := message("wait", "", "")
+	blocking := false
+	params.Configuration = &a2a.MessageSendConfiguration{Blocking: &blocking}
+	var task a2a.Task
+	if err := call(t, ts, "message/send", params, &task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status.State != a2a.TaskSubmitted && task.Status.State != a2a.TaskWorking {
+		t.Fatalf("expected a running task, but got %+v", task)
+	}
+	if err := call(t, ts, "message/send", message("hi", "", task.ContextID), nil); err == nil || err.Code != a2a.CodeInvalidParams {
+		t.Errorf("expected error code %d for a busy context, but got %v", a2a.CodeInvalidParams, err)
+	}
+
+	if err := call(t, ts, "tasks/cancel", a2a.TaskIDParams{ID: task.ID}, &task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status.State != a2a.TaskCanceled {
+		t.Errorf("expected a canceled task, but got %+v", task)
+	}
+	if err := call(t, ts, "tasks/cancel", a2a.TaskIDParams{ID: task.ID}, nil); err == nil || err.Code != a2a.CodeTaskNotCancelable {
+		t.Errorf("expected error code %d, but got %v", a2a.CodeTaskNotCancelable, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	testCases := []struct {
+		name   string
+		method string
+		params any
+		want   int
+	}{
+		{name: "Unknown method", method: "tasks/list", params: map[string]any{}, want: a2a.CodeMethodNotFound},
+		{name: "Unknown task", method: "tasks/get", params: a2a.TaskQueryParams{ID: "missing"}, want: a2a.CodeTaskNotFound},
+		{name: "Message of an unknown task", method: "message/send", params: message("hi", "missing", ""), want: a2a.CodeTaskNotFound},
+		{name: "Message without parts", method: "message/send", params: a2a.MessageSendParams{Message: a2a.Message{Role: a2a.RoleUser}}, want: a2a.CodeInvalidParams},
+		{name: "Invalid params", method: "message/send", params: "hi", want: a2a.CodeInvalidParams},
+		{name: "Push notifications", method: "tasks/pushNotificationConfig/set", params: map[string]any{}, want: a2a.CodePushNotificationNotSupported},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := call(t, ts, tc.method, tc.params, nil)
+			if err == nil || err.Code != tc.want {
+				t.Errorf("expected error code %d, but got %v", tc.want, err)
+			}
+		})
+	}
+
+	var task a2a.Task
+	if err := call(t, ts, "message/send", message("hi", "", ""), &task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := call(t, ts, "message/send", message("again", task.ID, ""), nil)
+	if err == nil || err.Code != a2a.CodeInvalidParams {
+		t.Errorf("expected error code %d for a completed task, but got %v", a2a.CodeInvalidParams, err)
+	}
+}
@@ -0,0 +1,656 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// maxBodySize is the size of the largest request the handler accepts.
+const maxBodySize = 10 << 20
+
+// Option configures a Handler.
+type Option[T any] func(*Handler[T])
+
+// WithInput adds the message of a user to the state of its run, replacing
+// the default, see the package documentation. Errors are answered as
+// invalid params.
+func WithInput[T any](input func(state *T, msg Message) error) Option[T] {
+	return func(h *Handler[T]) {
+		h.input = input
+	}
+}
+
+// WithOutput computes the parts of the artifact of a task from the state it
+// started with and its state, replacing the default, see the package
+// documentation.
+func WithOutput[T any](output func(start, state T) []Part) Option[T] {
+	return func(h *Handler[T]) {
+		h.output = output
+	}
+}
+
+// WithInvokeOptions invokes the runs with opts, such as graph.WithEntryPoint.
+func WithInvokeOptions[T any](opts ...graph.InvokeOption) Option[T] {
+	return func(h *Handler[T]) {
+		h.invokeOpts = append(h.invokeOpts, opts...)
+	}
+}
+
+// Handler serves a graph as an A2A agent. It is an http.Handler.
+type Handler[T any] struct {
+	card       AgentCard
+	runnable   *graph.Runnable[T]
+	input      func(state *T, msg Message) error
+	output     func(start, state T) []Part
+	invokeOpts []graph.InvokeOption
+	mux        *http.ServeMux
+
+	// mu guards tasks, busy and the fields of the tasks.
+	mu    sync.Mutex
+	tasks map[string]*task[T]
+	// busy are the contexts with a task running.
+	busy map[string]bool
+}
+
+// task is a task of a Handler.
+type task[T any] struct {
+	Task
+
+	// start is the state the task started with.
+	start T
+	// cancel cancels the run of the task, nil when it is not running.
+	cancel   context.CancelFunc
+	canceled bool
+	// done is closed when the current run of the task ends.
+	done chan struct{}
+	subs map[chan any]bool
+}
+
+// New returns a Handler serving runnable, which must have been compiled
+// with a checkpointer, as the agent of card. The protocol version, the
+// streaming capability, and the input and output modes of the card are
+// filled in when not set.
+func New[T any](card AgentCard, runnable *graph.Runnable[T], opts ...Option[T]) (*Handler[T], error) {
+	if runnable.Checkpointer() == nil {
+		return nil, fmt.Errorf("a2a: %w", graph.ErrNoCheckpointer)
+	}
+	modes := []string{"application/json"}
+	if _, ok := any(new(T)).(*graph.MessageState); ok {
+		modes = []string{"text/plain"}
+	}
+	if card.ProtocolVersion == "" {
+		card.ProtocolVersion = ProtocolVersion
+	}
+	if card.DefaultInputModes == nil {
+		card.DefaultInputModes = modes
+	}
+	if card.DefaultOutputModes == nil {
+		card.DefaultOutputModes = modes
+	}
+	if card.Skills == nil {
+		card.Skills = []AgentSkill{}
+	}
+	card.Capabilities.Streaming = true
+	h := &Handler[T]{
+		card:     card,
+		runnable: runnable,
+		input:    defaultInput[T],
+		output:   defaultOutput[T],
+		mux:      http.NewServeMux(),
+		tasks:    make(map[string]*task[T]),
+		busy:     make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("GET /.well-known/agent.json", h.agentCard)
+	h.mux.HandleFunc("GET /.well-known/agent-card.json", h.agentCard)
+	h.mux.HandleFunc("POST /{$}", h.rpc)
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler[T]) agentCard(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, h.card)
+}
+
+// rpc serves the JSON-RPC requests.
+func (h *Handler[T]) rpc(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeJSON(w, response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeJSON(w, response{JSONRPC: "2.0", ID: req.ID, Error: &Error{Code: CodeInvalidRequest, Message: "invalid JSON-RPC request"}})
+		return
+	}
+
+	var result any
+	var err error
+	switch req.Method {
+	case "message/send":
+		result, err = h.sendMessage(r.Context(), req.Params)
+	case "message/stream":
+		h.streamMessage(w, req)
+		return
+	case "tasks/get":
+		result, err = h.getTask(req.Params)
+	case "tasks/cancel":
+		result, err = h.cancelTask(req.Params)
+	case "tasks/resubscribe":
+		h.resubscribe(w, req)
+		return
+	case "tasks/pushNotificationConfig/set", "tasks/pushNotificationConfig/get":
+		err = &Error{Code: CodePushNotificationNotSupported, Message: "push notifications are not supported"}
+	default:
+		err = &Error{Code: CodeMethodNotFound, Message: "method " + req.Method + " not found"}
+	}
+	writeJSON(w, resultOf(req.ID, result, err))
+}
+
+// resultOf returns the response to the request with the given ID.
+func resultOf(id json.RawMessage, result any, err error) response {
+	if err == nil {
+		return response{JSONRPC: "2.0", ID: id, Result: result}
+	}
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	return response{JSONRPC: "2.0", ID: id, Error: rpcErr}
+}
+
+func invalidParams(format string, args ...any) error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+func decodeParams(data json.RawMessage, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalidParams("invalid params: %v", err)
+	}
+	return nil
+}
+
+// sendMessage serves message/send, waiting for the task to end or ask for
+// input unless the request is not blocking.
+func (h *Handler[T]) sendMessage(ctx context.Context, data json.RawMessage) (any, error) {
+	var params MessageSendParams
+	if err := decodeParams(data, &params); err != nil {
+		return nil, err
+	}
+	t, done, err := h.start(params.Message, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cfg := params.Configuration; cfg == nil || cfg.Blocking == nil || *cfg.Blocking {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	var historyLength *int
+	if params.Configuration != nil {
+		historyLength = params.Configuration.HistoryLength
+	}
+	return h.snapshot(t, historyLength), nil
+}
+
+// streamMessage serves message/stream, sending the task and then its
+// events as server-sent events until it ends or asks for input.
+func (h *Handler[T]) streamMessage(w http.ResponseWriter, req request) {
+	var params MessageSendParams
+	err := decodeParams(req.Params, &params)
+	sub := make(chan any, 64)
+	var t *task[T]
+	if err == nil {
+		t, _, err = h.start(params.Message, sub)
+	}
+	if err != nil {
+		writeJSON(w, resultOf(req.ID, nil, err))
+		return
+	}
+	h.stream(w, req.ID, h.snapshot(t, nil), sub)
+}
+
+// resubscribe serves tasks/resubscribe, streaming the task and then its
+// events as streamMessage does. The stream of a task that is not running
+// ends after the task.
+func (h *Handler[T]) resubscribe(w http.ResponseWriter, req request) {
+	var params TaskIDParams
+	if err := decodeParams(req.Params, &params); err != nil {
+		writeJSON(w, resultOf(req.ID, nil, err))
+		return
+	}
+	sub := make(chan any, 64)
+	h.mu.Lock()
+	t, ok := h.tasks[params.ID]
+	if ok && t.cancel != nil {
+		t.subs[sub] = true
+	} else {
+		close(sub)
+	}
+	h.mu.Unlock()
+	if !ok {
+		writeJSON(w, resultOf(req.ID, nil, taskNotFound(params.ID)))
+		return
+	}
+	h.stream(w, req.ID, h.snapshot(t, nil), sub)
+}
+
+// stream writes first and the events of sub as server-sent events of
+// JSON-RPC responses.
+func (h *Handler[T]) stream(w http.ResponseWriter, id json.RawMessage, first Task, sub chan any) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	send := func(event any) {
+		data, err := json.Marshal(response{JSONRPC: "2.0", ID: id, Result: event})
+		if err != nil {
+			return
+		}
+		// Errors are dropped: the events of a client that went away are
+		// dropped once its subscription is full.
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	send(first)
+	for event := range sub {
+		send(event)
+	}
+}
+
+func taskNotFound(id string) error {
+	return &Error{Code: CodeTaskNotFound, Message: "task " + id + " not found"}
+}
+
+func (h *Handler[T]) getTask(data json.RawMessage) (any, error) {
+	var params TaskQueryParams
+	if err := decodeParams(data, &params); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	t, ok := h.tasks[params.ID]
+	h.mu.Unlock()
+	if !ok {
+		return nil, taskNotFound(params.ID)
+	}
+	return h.snapshot(t, params.HistoryLength), nil
+}
+
+// cancelTask serves tasks/cancel, canceling the run of a working task and
+// waiting for it to end, or canceling a task asking for input.
+func (h *Handler[T]) cancelTask(data json.RawMessage) (any, error) {
+	var params TaskIDParams
+	if err := decodeParams(data, &params); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	t, ok := h.tasks[params.ID]
+	if !ok {
+		h.mu.Unlock()
+		return nil, taskNotFound(params.ID)
+	}
+	switch {
+	case t.Status.State.terminal():
+		h.mu.Unlock()
+		return nil, &Error{Code: CodeTaskNotCancelable, Message: fmt.Sprintf("task %s is %s", t.ID, t.Status.State)}
+	case t.cancel == nil:
+		t.Status = TaskStatus{State: TaskCanceled, Timestamp: time.Now().UTC()}
+		h.mu.Unlock()
+	default:
+		t.canceled = true
+		t.cancel()
+		done := t.done
+		h.mu.Unlock()
+		<-done
+	}
+	return h.snapshot(t, nil), nil
+}
+
+// start starts or resumes the task of msg, subscribing sub to its events if
+// not nil, and returns it with a channel closed when its run ends.
+func (h *Handler[T]) start(msg Message, sub chan any) (*task[T], chan struct{}, error) {
+	if msg.Role != RoleUser || len(msg.Parts) == 0 {
+		return nil, nil, invalidParams("message must be a user message with parts")
+	}
+	if msg.MessageID == "" {
+		msg.MessageID = uuid.NewString()
+	}
+	msg.Kind = "message"
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t, resumed := h.tasks[msg.TaskID]
+	switch {
+	case msg.TaskID != "" && !resumed:
+		return nil, nil, taskNotFound(msg.TaskID)
+	case resumed && t.Status.State != TaskInputRequired:
+		return nil, nil, invalidParams("task %s is %s", t.ID, t.Status.State)
+	case resumed:
+		msg.ContextID = t.ContextID
+	default:
+		if msg.ContextID == "" {
+			msg.ContextID = uuid.NewString()
+		}
+		if h.busy[msg.ContextID] {
+			return nil, nil, invalidParams("context %s has a task running", msg.ContextID)
+		}
+		t = &task[T]{Task: Task{Kind: "task", ID: uuid.NewString(), ContextID: msg.ContextID}, subs: make(map[chan any]bool)}
+		h.tasks[t.ID] = t
+	}
+	msg.TaskID = t.ID
+	h.busy[t.ContextID] = true
+	t.History = append(t.History, msg)
+	t.Status = TaskStatus{State: TaskSubmitted, Timestamp: time.Now().UTC()}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.done = make(chan struct{})
+	if sub != nil {
+		t.subs[sub] = true
+	}
+	go h.run(ctx, t, msg, resumed)
+	return t, t.done, nil
+}
+
+// run runs the graph for msg, resuming its interrupted run if resumed.
+func (h *Handler[T]) run(ctx context.Context, t *task[T], msg Message, resumed bool) {
+	h.setStatus(t, TaskStatus{State: TaskWorking, Timestamp: time.Now().UTC()}, false)
+
+	var state T
+	opts := slices.Concat(h.invokeOpts, []graph.InvokeOption{
+		graph.WithThreadID(t.ContextID),
+		graph.WithRunCallbacks[T](&artifactHandler[T]{h: h, t: t}),
+	})
+	var err error
+	if resumed {
+		opts = append(opts, graph.WithResume(resumeValue(msg)))
+	} else if err = h.initialState(ctx, t, &state, msg); err != nil {
+		err = &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	if err == nil {
+		err = h.runnable.Invoke(ctx, &state, opts...)
+	}
+
+	h.mu.Lock()
+	canceled := t.canceled
+	h.mu.Unlock()
+	status := TaskStatus{State: TaskCompleted, Timestamp: time.Now().UTC()}
+	var gi *graph.GraphInterrupt
+	switch {
+	case err == nil:
+		h.setArtifact(t, h.output(t.start, state), true)
+	case errors.As(err, &gi):
+		status.State = TaskInputRequired
+		status.Message = agentMessage(t, interruptText(gi))
+	case canceled:
+		status.State = TaskCanceled
+	default:
+		status.State = TaskFailed
+		status.Message = agentMessage(t, err.Error())
+	}
+	h.setStatus(t, status, true)
+}
+
+// initialState sets state to the latest state of the context of t, or the
+// zero state of new contexts, with the input of msg.
+func (h *Handler[T]) initialState(ctx context.Context, t *task[T], state *T, msg Message) error {
+	cp, err := h.runnable.GetState(ctx, t.ContextID)
+	switch {
+	case err == nil:
+		*state = cp.State
+	case !errors.Is(err, graph.ErrCheckpointNotFound):
+		return err
+	}
+	if err := h.input(state, msg); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	t.start = *state
+	h.mu.Unlock()
+	return nil
+}
+
+// setStatus sets the status of t and sends it to the subscribers of t. The
+// final status ends the run of t and the streams of its subscribers.
+func (h *Handler[T]) setStatus(t *task[T], status TaskStatus, final bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if status.Message != nil {
+		t.History = append(t.History, *status.Message)
+	}
+	t.Status = status
+	h.publish(t, TaskStatusUpdateEvent{Kind: "status-update", TaskID: t.ID, ContextID: t.ContextID, Status: status, Final: final})
+	if !final {
+		return
+	}
+	for sub := range t.subs {
+		close(sub)
+	}
+	clear(t.subs)
+	t.cancel()
+	t.cancel = nil
+	delete(h.busy, t.ContextID)
+	close(t.done)
+}
+
+// setArtifact sets the artifact of t to parts, sending it to the subscribers
+// of t if it changed or is the last.
+func (h *Handler[T]) setArtifact(t *task[T], parts []Part, last bool) {
+	if parts == nil {
+		parts = []Part{}
+	}
+	artifact := Artifact{ArtifactID: t.ID, Name: "result", Parts: parts}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(t.Artifacts) > 0 && !last && equalJSON(t.Artifacts[0], artifact) {
+		return
+	}
+	t.Artifacts = []Artifact{artifact}
+	h.publish(t, TaskArtifactUpdateEvent{Kind: "artifact-update", TaskID: t.ID, ContextID: t.ContextID, Artifact: artifact, LastChunk: last})
+}
+
+// publish sends event to the subscribers of t, dropping those that do not
+// keep up, h.mu being held.
+func (h *Handler[T]) publish(t *task[T], event any) {
+	for sub := range t.subs {
+		select {
+		case sub <- event:
+		default:
+			close(sub)
+			delete(t.subs, sub)
+		}
+	}
+}
+
+// snapshot returns a copy of t with its last historyLength messages, all of
+// them if nil.
+func (h *Handler[T]) snapshot(t *task[T], historyLength *int) Task {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snap := t.Task
+	snap.Artifacts = slices.Clone(t.Artifacts)
+	history := t.History
+	if historyLength != nil && *historyLength < len(history) {
+		history = history[len(history)-max(*historyLength, 0):]
+	}
+	snap.History = slices.Clone(history)
+	return snap
+}
+
+// artifactHandler sends the artifact of a task after every node.
+type artifactHandler[T any] struct {
+	h *Handler[T]
+	t *task[T]
+}
+
+func (a *artifactHandler[T]) NodeStart(context.Context, string, *T) {}
+
+func (a *artifactHandler[T]) NodeEnd(_ context.Context, _ string, state *T, err error) {
+	if err != nil {
+		return
+	}
+	a.h.mu.Lock()
+	start := a.t.start
+	a.h.mu.Unlock()
+	a.h.setArtifact(a.t, a.h.output(start, *state), false)
+}
+
+// agentMessage returns a message of the agent about t.
+func agentMessage[T any](t *task[T], text string) *Message {
+	return &Message{Kind: "message", MessageID: uuid.NewString(), Role: RoleAgent, Parts: []Part{TextPart(text)}, TaskID: t.ID, ContextID: t.ContextID}
+}
+
+// interruptText returns the value of gi as the text of a message.
+func interruptText(gi *graph.GraphInterrupt) string {
+	if s, ok := gi.Value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(gi.Value)
+	if err != nil {
+		return fmt.Sprint(gi.Value)
+	}
+	return string(data)
+}
+
+// resumeValue returns the value resuming an interrupted run with msg: the
+// data of its first data part, or its text.
+func resumeValue(msg Message) any {
+	for _, part := range msg.Parts {
+		if part.Kind == PartData {
+			return part.Data
+		}
+	}
+	return messageText(msg)
+}
+
+// messageText returns the text parts of msg.
+func messageText(msg Message) string {
+	var text strings.Builder
+	for _, part := range msg.Parts {
+		if part.Kind == PartText {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+// defaultInput adds msg to a graph.MessageState as a human message, with
+// the ID of msg. Other states are updated with the data of the first data
+// part of msg, or its text decoded as JSON, see graph.Reducer.
+func defaultInput[T any](state *T, msg Message) error {
+	if ms, ok := any(state).(*graph.MessageState); ok {
+		content := llms.MessageContent{Role: llms.ChatMessageTypeHuman}
+		for _, part := range msg.Parts {
+			p, err := contentPart(part)
+			if err != nil {
+				return err
+			}
+			content.Parts = append(content.Parts, p)
+		}
+		m := graph.NewMessage(content)
+		m.ID = msg.MessageID
+		return ms.AddMessages(m)
+	}
+
+	data := []byte(messageText(msg))
+	for _, part := range msg.Parts {
+		if part.Kind == PartData {
+			var err error
+			if data, err = json.Marshal(part.Data); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	var update T
+	if err := json.Unmarshal(data, &update); err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+	if r, ok := any(state).(graph.Reducer[T]); ok {
+		return r.Reduce(update)
+	}
+	*state = update
+	return nil
+}
+
+// contentPart converts part to the part of a message of a model.
+func contentPart(part Part) (llms.ContentPart, error) {
+	switch {
+	case part.Kind == PartText:
+		return llms.TextContent{Text: part.Text}, nil
+	case part.Kind == PartData:
+		data, err := json.Marshal(part.Data)
+		return llms.TextContent{Text: string(data)}, err
+	case part.Kind == PartFile && part.File != nil && part.File.URI != "":
+		return llms.ImageURLContent{URL: part.File.URI}, nil
+	case part.Kind == PartFile && part.File != nil:
+		data, err := base64.StdEncoding.DecodeString(part.File.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file bytes: %w", err)
+		}
+		return llms.BinaryContent{MIMEType: part.File.MIMEType, Data: data}, nil
+	}
+	return nil, fmt.Errorf("unsupported part %q", part.Kind)
+}
+
+// defaultOutput returns the text of the AI messages a graph.MessageState
+// gained since start. Other states are returned whole as a data part.
+func defaultOutput[T any](start, state T) []Part {
+	ms, ok := any(&state).(*graph.MessageState)
+	if !ok {
+		return []Part{DataPart(state)}
+	}
+	known := make(map[string]bool)
+	for _, m := range any(&start).(*graph.MessageState).Messages {
+		known[m.ID] = true
+	}
+	var parts []Part
+	for _, m := range ms.Messages {
+		if known[m.ID] || m.Role != llms.ChatMessageTypeAI {
+			continue
+		}
+		var text strings.Builder
+		for _, p := range m.Parts {
+			if tc, ok := p.(llms.TextContent); ok {
+				text.WriteString(tc.Text)
+			}
+		}
+		if text.Len() > 0 {
+			parts = append(parts, TextPart(text.String()))
+		}
+	}
+	return parts
+}
+
+// equalJSON reports whether a and b encode to the same JSON.
+func equalJSON(a, b any) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// User is the authenticated caller of a request.
+type User struct {
+	// Identity identifies the caller, such as the subject of its token.
+	// Threads created by the caller are owned by its identity.
+	Identity string
+
+	// Claims are the claims of the token of the caller, if any.
+	Claims map[string]any
+}
+
+// Authenticator authenticates the caller of a request. Requests it returns
+// an error for are answered with 401 Unauthorized and the error. Custom
+// authentication is a function of this type.
+type Authenticator func(r *http.Request) (User, error)
+
+// Action is an operation on a thread, see Authorizer.
+type Action string
+
+const (
+	// ActionCreate creates a thread.
+	ActionCreate Action = "create"
+	// ActionRead reads a thread, its state, history and runs. Searches
+	// only find the threads the caller may read.
+	ActionRead Action = "read"
+	// ActionUpdate updates the state or metadata of a thread or runs the
+	// graph on it.
+	ActionUpdate Action = "update"
+	// ActionDelete deletes a thread with its runs and checkpoints.
+	ActionDelete Action = "delete"
+)
+
+// Authorizer decides whether the user may perform action on thread, returning
+// an error if not. Errors are answered with 403 Forbidden, unless they come
+// from errorf.
+type Authorizer func(ctx context.Context, user User, action Action, thread Thread) error
+
+// Auth authenticates the requests of a Server or Mux and authorizes their
+// access to threads, see WithAuth and WithMuxAuth. Threads created by an
+// authenticated caller are owned by its identity, recorded as the owner of
+// the metadata of the thread.
+type Auth struct {
+	Authenticate Authenticator
+
+	// Authorize authorizes access to threads, OwnerOnly by default.
+	Authorize Authorizer
+}
+
+// OwnerOnly is the default Authorizer, letting callers only access the
+// threads they own. The threads of others are not found.
+func OwnerOnly(_ context.Context, user User, _ Action, thread Thread) error {
+	if owner, _ := thread.Metadata["owner"].(string); owner != user.Identity {
+		return errorf(http.StatusNotFound, "thread %s not found", thread.ThreadID)
+	}
+	return nil
+}
+
+type userKey struct{}
+
+// UserFrom returns the user authenticated for the request of ctx, if any.
+func UserFrom(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey{}).(User)
+	return user, ok
+}
+
+// Authenticate returns a handler authenticating the requests of h with
+// authn, such as to guard the handlers of graph/server/openai or
+// graph/server/a2a. h finds the caller with UserFrom.
+func Authenticate(h http.Handler, authn Authenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := UserFrom(r.Context()); ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		user, err := authn(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, errorf(http.StatusUnauthorized, "%v", err))
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+// authorize returns an error unless the user of ctx may perform action on
+// thread. Without auth, all threads are accessible.
+func (a *Auth) authorize(ctx context.Context, action Action, thread Thread) error {
+	if a == nil {
+		return nil
+	}
+	user, ok := UserFrom(ctx)
+	if !ok {
+		return errorf(http.StatusUnauthorized, "unauthenticated")
+	}
+	authorize := a.Authorize
+	if authorize == nil {
+		authorize = OwnerOnly
+	}
+	err := authorize(ctx, user, action, thread)
+	var he *httpError
+	if err != nil && !errors.As(err, &he) {
+		err = errorf(http.StatusForbidden, "%v", err)
+	}
+	return err
+}
+
+// credentials returns the API key of the X-Api-Key header of r, which
+// LangGraph SDK clients send, or else its bearer token.
+func credentials(r *http.Request) string {
+	if key := r.Header.Get("X-Api-Key"); key != "" {
+		return key
+	}
+	return bearer(r)
+}
+
+func bearer(r *http.Request) string {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// APIKeys authenticates callers by the API keys of their X-Api-Key header or
+// bearer token, keys mapping them to the identities of the callers.
+func APIKeys(keys map[string]string) Authenticator {
+	return func(r *http.Request) (User, error) {
+		key := credentials(r)
+		if key == "" {
+			return User{}, errors.New("missing API key")
+		}
+		for k, identity := range keys {
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				return User{Identity: identity}, nil
+			}
+		}
+		return User{}, errors.New("invalid API key")
+	}
+}
+
+// JWT authenticates callers by the JSON Web Tokens of their bearer tokens,
+// verified with key: a []byte secret for HS256, HS384 and HS512 tokens, an
+// *rsa.PublicKey for RS256 tokens or an *ecdsa.PublicKey for ES256 tokens.
+// Tokens must not be expired (exp) nor used before their time (nbf). The
+// subject (sub) of the token is the identity of the caller and its claims
+// are the claims of the User.
+func JWT(key any) Authenticator {
+	return func(r *http.Request) (User, error) {
+		token := bearer(r)
+		if token == "" {
+			return User{}, errors.New("missing bearer token")
+		}
+		claims, err := verifyJWT(token, key, time.Now())
+		if err != nil {
+			return User{}, fmt.Errorf("invalid token: %w", err)
+		}
+		sub, _ := claims["sub"].(string)
+		if sub == "" {
+			return User{}, errors.New("invalid token: missing subject")
+		}
+		return User{Identity: sub, Claims: claims}, nil
+	}
+}
+
+// verifyJWT verifies the signature and times of a compact JWT and returns
+// its claims.
+func verifyJWT(token string, key any, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	if err := verifySignature(header.Alg, parts[0]+"."+parts[1], sig, key); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if exp, ok := claims["exp"].(float64); ok && !now.Before(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("not valid yet")
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+	return nil
+}
+
+// verifySignature verifies the signature of signed with the algorithm alg,
+// which must be one of the algorithms of the type of key.
+func verifySignature(alg, signed string, sig []byte, key any) error {
+	switch key := key.(type) {
+	case []byte:
+		var h func() hash.Hash
+		switch alg {
+		case "HS256":
+			h = sha256.New
+		case "HS384":
+			h = sha512.New384
+		case "HS512":
+			h = sha512.New
+		default:
+			return fmt.Errorf("unexpected algorithm %q", alg)
+		}
+		mac := hmac.New(h, key)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			return fmt.Errorf("unexpected algorithm %q", alg)
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("invalid signature")
+		}
+	case *ecdsa.PublicKey:
+		if alg != "ES256" {
+			return fmt.Errorf("unexpected algorithm %q", alg)
+		}
+		if len(sig) != 64 {
+			return errors.New("invalid signature")
+		}
+		digest := sha256.Sum256([]byte(signed))
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
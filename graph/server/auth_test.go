@@ -0,0 +1,340 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+// callWith is call with the given request headers.
+func callWith(t *testing.T, ts *httptest.Server, header http.Header, method, path string, body, out any) int {
+	t.Helper()
+
+	var r bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&r).Encode(body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, ts.URL+path, &r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header = header
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("unexpected error decoding %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func apiKey(key string) http.Header {
+	return http.Header{"X-Api-Key": {key}}
+}
+
+// serveAuth serves a planner with auth.
+func serveAuth(t *testing.T, auth server.Auth) *httptest.Server {
+	t.Helper()
+
+	srv, err := server.New("planner", plannerRunnable(t, 1), server.WithAuth(auth))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestAuthOwnerOnly(t *testing.T) {
+	t.Parallel()
+
+	ts := serveAuth(t, server.Auth{Authenticate: server.APIKeys(map[string]string{"ada-key": "ada", "bob-key": "bob"})})
+	ada, bob := apiKey("ada-key"), http.Header{"Authorization": {"Bearer bob-key"}}
+
+	for _, header := range []http.Header{{}, apiKey("wrong")} {
+		if status := callWith(t, ts, header, "POST", "/threads/search", map[string]any{}, nil); status != http.StatusUnauthorized {
+			t.Errorf("expected status %d for header %v, but got %d", http.StatusUnauthorized, header, status)
+		}
+	}
+
+	// The owner of threads is the caller, whatever their metadata.
+	var thread server.Thread
+	if status := callWith(t, ts, ada, "POST", "/threads", map[string]any{"thread_id": "t1", "metadata": map[string]any{"owner": "bob"}}, &thread); status != http.StatusOK || thread.Metadata["owner"] != "ada" {
+		t.Fatalf("expected a thread owned by ada, but got %d %v", status, thread)
+	}
+	run := map[string]any{"assistant_id": "planner", "input": plannerState{Goal: "ship"}}
+	if status := callWith(t, ts, ada, "POST", "/threads/t1/runs/wait", run, nil); status != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+	}{
+		{name: "Thread", method: "GET", path: "/threads/t1"},
+		{name: "State", method: "GET", path: "/threads/t1/state"},
+		{name: "Update state", method: "POST", path: "/threads/t1/state", body: map[string]any{"values": plannerState{}}},
+		{name: "History", method: "POST", path: "/threads/t1/history", body: map[string]any{}},
+		{name: "Runs", method: "GET", path: "/threads/t1/runs"},
+		{name: "Run", method: "POST", path: "/threads/t1/runs/wait", body: run},
+		{name: "Patch", method: "PATCH", path: "/threads/t1", body: map[string]any{"metadata": map[string]any{}}},
+		{name: "Copy", method: "POST", path: "/threads/t1/copy", body: map[string]any{}},
+		{name: "Delete", method: "DELETE", path: "/threads/t1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := callWith(t, ts, bob, tt.method, tt.path, tt.body, nil); status != http.StatusNotFound {
+				t.Errorf("expected status %d for bob, but got %d", http.StatusNotFound, status)
+			}
+		})
+	}
+
+	var threads []server.Thread
+	callWith(t, ts, bob, "POST", "/threads/search", map[string]any{}, &threads)
+	if len(threads) != 0 {
+		t.Errorf("expected no threads for bob, but got %v", threads)
+	}
+	callWith(t, ts, ada, "POST", "/threads/search", map[string]any{}, &threads)
+	if len(threads) != 1 || threads[0].ThreadID != "t1" {
+		t.Errorf("expected thread t1 for ada, but got %v", threads)
+	}
+	var state server.ThreadState[plannerState]
+	if status := callWith(t, ts, ada, "GET", "/threads/t1/state", nil, &state); status != http.StatusOK || state.Values.Steps != 1 {
+		t.Errorf("expected one step, but got %d %+v", status, state.Values)
+	}
+	callWith(t, ts, ada, "PATCH", "/threads/t1", map[string]any{"metadata": map[string]any{"owner": "bob"}}, &thread)
+	if thread.Metadata["owner"] != "ada" {
+		t.Errorf("expected the owner to be kept, but got %v", thread.Metadata)
+	}
+}
+
+// failingDeleter is a checkpointer failing to delete threads.
+type failingDeleter struct {
+	graph.Checkpointer[plannerState]
+}
+
+func (failingDeleter) Delete(context.Context, string) error {
+	return errors.New("disk full")
+}
+
+func TestAuthThreadTakeover(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[plannerState]()
+	g.AddNode("plan", func(_ context.Context, s *plannerState) error {
+		s.Steps++
+		return nil
+	})
+	g.AddEdge("plan", graph.END)
+	g.SetEntryPoint("plan")
+	runnable, err := g.Compile(graph.WithCheckpointer[plannerState](failingDeleter{graph.NewMemorySaver[plannerState]()}))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	auth := server.WithAuth(server.Auth{Authenticate: server.APIKeys(map[string]string{"ada-key": "ada", "bob-key": "bob"})})
+	serve := func() *httptest.Server {
+		srv, err := server.New("planner", runnable, auth)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ts := httptest.NewServer(srv)
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	ada, bob := apiKey("ada-key"), apiKey("bob-key")
+
+	ts := serve()
+	callWith(t, ts, ada, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	callWith(t, ts, ada, "POST", "/threads/t1/runs/wait", map[string]any{"assistant_id": "planner"}, nil)
+
+	// The thread is kept when its checkpoints are not deleted.
+	if status := callWith(t, ts, ada, "DELETE", "/threads/t1", nil, nil); status != http.StatusInternalServerError {
+		t.Errorf("expected status %d deleting the thread, but got %d", http.StatusInternalServerError, status)
+	}
+	if status := callWith(t, ts, ada, "GET", "/threads/t1", nil, nil); status != http.StatusOK {
+		t.Errorf("expected status %d for ada, but got %d", http.StatusOK, status)
+	}
+	if status := callWith(t, ts, bob, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil); status != http.StatusConflict {
+		t.Errorf("expected status %d for bob, but got %d", http.StatusConflict, status)
+	}
+
+	// Nor do the checkpoints of a previous server change owner.
+	next := serve()
+	if status := callWith(t, next, bob, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil); status != http.StatusConflict {
+		t.Errorf("expected status %d for bob on the next server, but got %d", http.StatusConflict, status)
+	}
+	if status := callWith(t, next, bob, "GET", "/threads/t1/state", nil, nil); status != http.StatusNotFound {
+		t.Errorf("expected status %d for the state, but got %d", http.StatusNotFound, status)
+	}
+}
+
+// token returns a JWT with the given header and claims, signed by sign.
+func token(t *testing.T, header, claims map[string]any, sign func(signed []byte) []byte) string {
+	t.Helper()
+
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func TestAuthJWT(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	hs256 := func(key []byte) func([]byte) []byte {
+		return func(signed []byte) []byte {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(signed)
+			return mac.Sum(nil)
+		}
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	es256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	hour := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		key    any
+		header map[string]any
+		claims map[string]any
+		sign   func([]byte) []byte
+		want   int
+	}{
+		{name: "HS256", key: secret, header: map[string]any{"alg": "HS256"}, claims: map[string]any{"sub": "ada", "exp": hour}, sign: hs256(secret), want: http.StatusOK},
+		{name: "ES256", key: &ecKey.PublicKey, header: map[string]any{"alg": "ES256"}, claims: map[string]any{"sub": "ada"}, sign: es256, want: http.StatusOK},
+		{name: "Wrong secret", key: secret, header: map[string]any{"alg": "HS256"}, claims: map[string]any{"sub": "ada"}, sign: hs256([]byte("other")), want: http.StatusUnauthorized},
+		{name: "Unsigned", key: secret, header: map[string]any{"alg": "none"}, claims: map[string]any{"sub": "ada"}, sign: func([]byte) []byte { return nil }, want: http.StatusUnauthorized},
+		{name: "Algorithm of another key", key: &ecKey.PublicKey, header: map[string]any{"alg": "HS256"}, claims: map[string]any{"sub": "ada"}, sign: hs256(secret), want: http.StatusUnauthorized},
+		{name: "Expired", key: secret, header: map[string]any{"alg": "HS256"}, claims: map[string]any{"sub": "ada", "exp": time.Now().Add(-time.Minute).Unix()}, sign: hs256(secret), want: http.StatusUnauthorized},
+		{name: "Not valid yet", key: secret, header: map[string]any{"alg": "HS256"}, claims: map[string]any{"sub": "ada", "nbf": hour}, sign: hs256(secret), want: http.StatusUnauthorized},
+		{name: "No subject", key: secret, header: map[string]any{"alg": "HS256"}, claims: map[string]any{}, sign: hs256(secret), want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := serveAuth(t, server.Auth{Authenticate: server.JWT(tt.key)})
+			header := http.Header{"Authorization": {"Bearer " + token(t, tt.header, tt.claims, tt.sign)}}
+			var thread server.Thread
+			status := callWith(t, ts, header, "POST", "/threads", map[string]any{}, &thread)
+			if status != tt.want {
+				t.Fatalf("expected status %d, but got %d", tt.want, status)
+			}
+			if status == http.StatusOK && thread.Metadata["owner"] != "ada" {
+				t.Errorf("expected a thread owned by ada, but got %v", thread.Metadata)
+			}
+		})
+	}
+}
+
+func TestAuthAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	// Anyone may read the threads of the team, only their owners update
+	// them.
+	errReadOnly := errors.New("read only")
+	auth := server.Auth{
+		Authenticate: func(r *http.Request) (server.User, error) {
+			if name := r.Header.Get("X-User"); name != "" {
+				return server.User{Identity: name}, nil
+			}
+			return server.User{}, errors.New("who are you?")
+		},
+		Authorize: func(_ context.Context, user server.User, action server.Action, thread server.Thread) error {
+			if action == server.ActionRead || thread.Metadata["owner"] == user.Identity {
+				return nil
+			}
+			return errReadOnly
+		},
+	}
+	mux := server.NewMux(server.WithMuxAuth(auth))
+	if _, err := server.Handle(mux, "planner", plannerRunnable(t, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := server.Handle(mux, "planner", plannerRunnable(t, 1), server.WithVersion(2), server.WithAuth(auth)); err == nil {
+		t.Error("expected an error for WithAuth on a Mux, but got nil")
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	ada, bob := http.Header{"X-User": {"ada"}}, http.Header{"X-User": {"bob"}}
+
+	if status := callWith(t, ts, http.Header{}, "POST", "/assistants/search", map[string]any{}, nil); status != http.StatusUnauthorized {
+		t.Errorf("expected status %d, but got %d", http.StatusUnauthorized, status)
+	}
+	callWith(t, ts, ada, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	run := map[string]any{"assistant_id": "planner"}
+	if status := callWith(t, ts, ada, "POST", "/threads/t1/runs/wait", run, nil); status != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+	}
+
+	var state server.ThreadState[plannerState]
+	if status := callWith(t, ts, bob, "GET", "/threads/t1/state", nil, &state); status != http.StatusOK || state.Values.Steps != 1 {
+		t.Errorf("expected bob to read one step, but got %d %+v", status, state.Values)
+	}
+	var detail struct {
+		Detail string `json:"detail"`
+	}
+	if status := callWith(t, ts, bob, "POST", "/threads/t1/runs/wait", run, &detail); status != http.StatusForbidden || detail.Detail != errReadOnly.Error() {
+		t.Errorf("expected status %d with detail %q, but got %d %q", http.StatusForbidden, errReadOnly, status, detail.Detail)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	h := server.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := server.UserFrom(r.Context())
+		w.Write([]byte(user.Identity))
+	}), server.APIKeys(map[string]string{"key": "ada"}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected status %d with a challenge, but got %d %v", http.StatusUnauthorized, rec.Code, rec.Header())
+	}
+	rec = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Api-Key", "key")
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK || rec.Body.String() != "ada" {
+		t.Errorf("expected ada, but got %d %q", rec.Code, rec.Body.String())
+	}
+}
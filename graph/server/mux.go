@@ -0,0 +1,322 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// graphServer is a Server of a Mux, whatever the type of its state.
+type graphServer interface {
+	http.Handler
+	Assistant() Assistant
+	isAssistant(id string) bool
+}
+
+// Mux serves several graphs over HTTP as the assistants of one server, such
+// as different agents or the versions of an agent, each with its own config:
+//
+//	mux := server.NewMux()
+//	_, err := server.Handle(mux, "support", support)
+//	...
+//	_, err = server.Handle(mux, "research", research, server.WithVersion(2))
+//	...
+//	http.ListenAndServe(":2024", mux)
+//
+// The graphs share the threads and runs of the Mux. A thread is bound to
+// the assistant of its first run, recorded in its metadata as assistant_id
+// and graph_id, and runs of other assistants on the thread are rejected.
+// Runs referring to an assistant by graph ID run the latest version of the
+// graph on new threads, and the version the thread is bound to otherwise.
+// WebSocket clients of unbound threads give the assistant in the
+// assistant_id query parameter.
+type Mux struct {
+	store *store
+	mux   *http.ServeMux
+
+	mu     sync.RWMutex
+	graphs []graphServer
+}
+
+// MuxOption configures a Mux.
+type MuxOption func(*Mux)
+
+// WithMuxAuth authenticates the requests of the Mux with auth and authorizes
+// their access to threads, whichever graph they run, see Auth.
+func WithMuxAuth(auth Auth) MuxOption {
+	return func(m *Mux) {
+		m.store.auth = &auth
+	}
+}
+
+// WithMuxQuota limits the requests to the Mux and the tokens of its runs per
+// tenant, whichever graph they run, see Quota.
+func WithMuxQuota(quota Quota) MuxOption {
+	return func(m *Mux) {
+		m.store.quota = newQuota(quota)
+	}
+}
+
+// NewMux returns a Mux serving no graphs.
+func NewMux(opts ...MuxOption) *Mux {
+	m := &Mux{store: newStore(), mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.mux.HandleFunc("POST /assistants/search", m.searchAssistants)
+	m.mux.HandleFunc("GET /assistants/{assistant_id}", m.getAssistant)
+	m.mux.HandleFunc("POST /threads", m.store.createThread)
+	m.mux.HandleFunc("GET /threads", m.store.listThreads)
+	m.mux.HandleFunc("POST /threads/search", m.store.searchThreads)
+	m.mux.HandleFunc("GET /threads/{thread_id}", m.store.getThread)
+	m.mux.HandleFunc("PATCH /threads/{thread_id}", m.store.patchThread)
+	m.mux.HandleFunc("DELETE /threads/{thread_id}", m.serveThread(m.store.deleteThread))
+	m.mux.HandleFunc("POST /threads/{thread_id}/copy", m.serveThread(m.store.copyThread))
+	m.mux.HandleFunc("GET /threads/{thread_id}/state", m.getState)
+	m.mux.HandleFunc("POST /threads/{thread_id}/state", m.serveBound)
+	m.mux.HandleFunc("POST /threads/{thread_id}/history", m.getHistory)
+	m.mux.HandleFunc("POST /threads/{thread_id}/runs", m.serveRun)
+	m.mux.HandleFunc("GET /threads/{thread_id}/runs", m.store.listRuns)
+	m.mux.HandleFunc("GET /threads/{thread_id}/runs/{run_id}", m.store.getRun)
+	m.mux.HandleFunc("POST /threads/{thread_id}/runs/wait", m.serveRun)
+	m.mux.HandleFunc("POST /threads/{thread_id}/runs/stream", m.serveRun)
+	m.mux.HandleFunc("GET /threads/{thread_id}/runs/ws", m.serveRun)
+	m.mux.HandleFunc("POST /threads/{thread_id}/runs/{run_id}/cancel", m.store.cancelRun)
+	m.mux.HandleFunc("POST /runs", m.serveRun)
+	m.mux.HandleFunc("GET /runs/{run_id}", m.store.getRunResult)
+	m.mux.HandleFunc("POST /runs/{run_id}/cancel", m.store.cancelRun)
+	return m
+}
+
+// Handle serves runnable on m as the assistant of the graph with the given
+// ID, see New, and returns its server. It returns an error if m serves the
+// same version of the graph already.
+func Handle[T any](m *Mux, graphID string, runnable *graph.Runnable[T], opts ...Option) (*Server[T], error) {
+	s, err := newServer(graphID, runnable, m.store, opts)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, g := range m.graphs {
+		if g.Assistant().AssistantID == s.assistant.AssistantID {
+			return nil, fmt.Errorf("server: version %d of graph %s is served already", s.assistant.Version, graphID)
+		}
+	}
+	m.graphs = append(m.graphs, s)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.store.handler(m.mux).ServeHTTP(w, r)
+}
+
+// Assistants returns the assistants of m, in the order their graphs were
+// added.
+func (m *Mux) Assistants() []Assistant {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	assistants := make([]Assistant, 0, len(m.graphs))
+	for _, g := range m.graphs {
+		assistants = append(assistants, g.Assistant())
+	}
+	return assistants
+}
+
+// searchAssistants answers the assistants of the graph_id of the request,
+// or all of them, with its limit, 10 by default, and offset.
+func (m *Mux) searchAssistants(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		GraphID string `json:"graph_id"`
+		Limit   int    `json:"limit"`
+		Offset  int    `json:"offset"`
+	}{Limit: 10}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	assistants := []Assistant{}
+	for _, a := range m.Assistants() {
+		if req.GraphID == "" || a.GraphID == req.GraphID {
+			assistants = append(assistants, a)
+		}
+	}
+	assistants = assistants[min(max(req.Offset, 0), len(assistants)):]
+	assistants = assistants[:min(max(req.Limit, 0), len(assistants))]
+	writeJSON(w, http.StatusOK, assistants)
+}
+
+func (m *Mux) getAssistant(w http.ResponseWriter, r *http.Request) {
+	g, err := m.graph(r.PathValue("assistant_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g.Assistant())
+}
+
+// graph returns the graph of the assistant with the given ID, or the latest
+// version of the graph with the given graph ID.
+func (m *Mux) graph(id string) (graphServer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var latest graphServer
+	for _, g := range m.graphs {
+		a := g.Assistant()
+		switch {
+		case a.AssistantID == id:
+			return g, nil
+		case a.GraphID == id && (latest == nil || a.Version > latest.Assistant().Version):
+			latest = g
+		}
+	}
+	if latest == nil {
+		return nil, errorf(http.StatusNotFound, "assistant %s not found", id)
+	}
+	return latest, nil
+}
+
+// bound returns the graph the thread with the given ID is bound to, nil if
+// it is not bound. The caller of ctx must be allowed to read the thread.
+func (m *Mux) bound(ctx context.Context, threadID string) (graphServer, error) {
+	thread, err := m.store.thread(ctx, threadID, ActionRead)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := thread.Metadata["assistant_id"].(string)
+	if id == "" {
+		return nil, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, g := range m.graphs {
+		if g.Assistant().AssistantID == id {
+			return g, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "assistant %s of thread %s not found", id, threadID)
+}
+
+// getState answers the state of a thread from its graph. Unbound threads
+// have an empty state.
+func (m *Mux) getState(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("thread_id")
+	g, err := m.bound(r.Context(), threadID)
+	switch {
+	case err != nil:
+		writeError(w, err)
+	case g == nil:
+		writeJSON(w, http.StatusOK, ThreadState[map[string]any]{
+			Values:     map[string]any{},
+			Next:       []string{},
+			Checkpoint: CheckpointConfig{ThreadID: threadID},
+			Metadata:   map[string]any{},
+			Tasks:      []Task{},
+		})
+	default:
+		g.ServeHTTP(w, r)
+	}
+}
+
+// getHistory answers the checkpoints of a thread from its graph. Unbound
+// threads have none.
+func (m *Mux) getHistory(w http.ResponseWriter, r *http.Request) {
+	g, err := m.bound(r.Context(), r.PathValue("thread_id"))
+	switch {
+	case err != nil:
+		writeError(w, err)
+	case g == nil:
+		writeJSON(w, http.StatusOK, []ThreadState[map[string]any]{})
+	default:
+		g.ServeHTTP(w, r)
+	}
+}
+
+// serveBound serves a request on a thread with its graph.
+func (m *Mux) serveBound(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("thread_id")
+	g, err := m.bound(r.Context(), threadID)
+	switch {
+	case err != nil:
+		writeError(w, err)
+	case g == nil:
+		writeError(w, errorf(http.StatusNotFound, "thread %s has no state", threadID))
+	default:
+		g.ServeHTTP(w, r)
+	}
+}
+
+// serveThread returns a handler serving requests on a thread with its graph,
+// or with unbound if the thread is not bound to a graph.
+func (m *Mux) serveThread(unbound http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g, err := m.bound(r.Context(), r.PathValue("thread_id"))
+		switch {
+		case err != nil:
+			writeError(w, err)
+		case g == nil:
+			unbound(w, r)
+		default:
+			g.ServeHTTP(w, r)
+		}
+	}
+}
+
+// serveRun serves a request running a graph on a thread with the graph the
+// thread is bound to, or the graph of the assistant of the request, which
+// background runs on new threads require.
+func (m *Mux) serveRun(w http.ResponseWriter, r *http.Request) {
+	// Background runs without a thread run on a new one.
+	threadID := r.PathValue("thread_id")
+	var bound graphServer
+	if threadID != "" {
+		var err error
+		if bound, err = m.bound(r.Context(), threadID); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	// The assistant of WebSocket runs is in their messages, the query
+	// parameter telling the graph of unbound threads.
+	assistantID := r.URL.Query().Get("assistant_id")
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, errorf(http.StatusBadRequest, "invalid request body: %v", err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		var req RunRequest
+		if err := json.Unmarshal(body, &req); err != nil && len(bytes.TrimSpace(body)) > 0 {
+			writeError(w, errorf(http.StatusBadRequest, "invalid request body: %v", err))
+			return
+		}
+		assistantID = req.AssistantID
+	}
+
+	switch {
+	case bound != nil && assistantID != "" && !bound.isAssistant(assistantID):
+		writeError(w, errorf(http.StatusConflict, "thread %s is bound to assistant %s", threadID, bound.Assistant().AssistantID))
+	case bound != nil:
+		bound.ServeHTTP(w, r)
+	case assistantID == "" && threadID == "":
+		writeError(w, errorf(http.StatusUnprocessableEntity, "missing assistant_id"))
+	case assistantID == "":
+		writeError(w, errorf(http.StatusNotFound, "thread %s has no assistant", threadID))
+	default:
+		g, err := m.graph(assistantID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		g.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,136 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+// plannerRunnable compiles a planner adding steps to the steps of its state.
+func plannerRunnable(t *testing.T, steps int) *graph.Runnable[plannerState] {
+	t.Helper()
+
+	g := graph.NewStateGraph[plannerState]()
+	g.AddNode("plan", func(_ context.Context, s *plannerState) error {
+		s.Steps += steps
+		return nil
+	})
+	g.AddEdge("plan", graph.END)
+	g.SetEntryPoint("plan")
+	runnable, err := g.Compile(graph.WithCheckpointer[plannerState](graph.NewMemorySaver[plannerState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return runnable
+}
+
+func TestMux(t *testing.T) {
+	t.Parallel()
+
+	chat := graph.NewStateGraph[chatState]()
+	chat.AddNode("greet", func(_ context.Context, s *chatState) error {
+		s.Messages = append(s.Messages, "hello")
+		return nil
+	})
+	chat.AddEdge("greet", graph.END)
+	chat.SetEntryPoint("greet")
+	chatRunnable, err := chat.Compile(graph.WithCheckpointer[chatState](graph.NewMemorySaver[chatState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	mux := server.NewMux()
+	if _, err := server.Handle(mux, "chat", chatRunnable, server.WithDescription("Greets")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v1, err := server.Handle(mux, "planner", plannerRunnable(t, 1), server.WithConfig(map[string]any{"model": "small"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v2, err := server.Handle(mux, "planner", plannerRunnable(t, 10), server.WithVersion(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := server.Handle(mux, "planner", plannerRunnable(t, 1)); err == nil {
+		t.Error("expected an error serving version 1 of planner twice, but got none")
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	var assistants []server.Assistant
+	call(t, ts, "POST", "/assistants/search", map[string]any{}, &assistants)
+	if len(assistants) != 3 {
+		t.Fatalf("expected 3 assistants, but got %v", assistants)
+	}
+	call(t, ts, "POST", "/assistants/search", map[string]any{"graph_id": "planner"}, &assistants)
+	if len(assistants) != 2 || assistants[0].Config["model"] != "small" {
+		t.Errorf("expected the 2 versions of planner, but got %v", assistants)
+	}
+	var assistant server.Assistant
+	call(t, ts, "GET", "/assistants/planner", nil, &assistant)
+	if assistant.AssistantID != v2.Assistant().AssistantID {
+		t.Errorf("expected the latest version of planner, but got %+v", assistant)
+	}
+	if v1.Assistant().AssistantID == v2.Assistant().AssistantID {
+		t.Error("expected the versions of planner to be different assistants")
+	}
+
+	// Runs by graph ID run the latest version on new threads.
+	for _, id := range []string{"t1", "t2"} {
+		call(t, ts, "POST", "/threads", map[string]any{"thread_id": id}, nil)
+	}
+	var state server.ThreadState[plannerState]
+	call(t, ts, "GET", "/threads/t1/state", nil, &state)
+	if state.Values != (plannerState{}) {
+		t.Errorf("expected an empty state, but got %+v", state.Values)
+	}
+	var planned plannerState
+	if status := call(t, ts, "POST", "/threads/t1/runs/wait", map[string]any{"assistant_id": "planner", "input": plannerState{Goal: "ship"}}, &planned); status != http.StatusOK || planned.Steps != 10 {
+		t.Errorf("expected 10 steps, but got %d %+v", status, planned)
+	}
+	var thread server.Thread
+	call(t, ts, "GET", "/threads/t1", nil, &thread)
+	if thread.Metadata["assistant_id"] != v2.Assistant().AssistantID || thread.Metadata["graph_id"] != "planner" {
+		t.Errorf("expected the thread to be bound to version 2 of planner, but got %v", thread.Metadata)
+	}
+	call(t, ts, "GET", "/threads/t1/state", nil, &state)
+	if state.Values.Goal != "ship" || state.Values.Steps != 10 {
+		t.Errorf("expected the state of planner, but got %+v", state.Values)
+	}
+
+	// Runs by assistant ID run their version.
+	if status := call(t, ts, "POST", "/threads/t2/runs/wait", map[string]any{"assistant_id": v1.Assistant().AssistantID}, &planned); status != http.StatusOK || planned.Steps != 1 {
+		t.Errorf("expected 1 step, but got %d %+v", status, planned)
+	}
+	// Bound threads keep their version.
+	if status := call(t, ts, "POST", "/threads/t2/runs/wait", map[string]any{"assistant_id": "planner"}, &planned); status != http.StatusOK || planned.Steps != 2 {
+		t.Errorf("expected 2 steps, but got %d %+v", status, planned)
+	}
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{name: "Other assistant", method: "POST", path: "/threads/t1/runs/wait", body: map[string]any{"assistant_id": "chat"}, want: http.StatusConflict},
+		{name: "Other version", method: "POST", path: "/threads/t1/runs", body: map[string]any{"assistant_id": v1.Assistant().AssistantID}, want: http.StatusConflict},
+		{name: "Unknown assistant", method: "POST", path: "/threads/t3/runs/wait", body: map[string]any{"assistant_id": "missing"}, want: http.StatusNotFound},
+		{name: "Unknown thread", method: "GET", path: "/threads/missing/state", want: http.StatusNotFound},
+		{name: "State of unbound thread", method: "POST", path: "/threads/t3/state", body: map[string]any{"values": map[string]any{}}, want: http.StatusNotFound},
+		{name: "No assistant", method: "POST", path: "/threads/t3/runs/wait", body: map[string]any{}, want: http.StatusNotFound},
+	}
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t3"}, nil)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := call(t, ts, tc.method, tc.path, tc.body, nil); got != tc.want {
+				t.Errorf("expected status %d, but got %d", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+// Package openai serves graphs whose state is a graph.MessageState with the
+// chat completions API of OpenAI, so that OpenAI SDKs and chat UIs talk to
+// Go graphs as to a model:
+//
+//	http.Handle("/v1/", openai.New("support-agent", runnable, openai.WithAPIKey(key)))
+//
+// It serves POST /v1/chat/completions and GET /v1/models. The messages of a
+// request are the initial state of a run of the graph, and the reply is the
+// text of the AI messages the run added, separated by blank lines. Streamed
+// replies are sent as chat completion chunks as the nodes add messages, and
+// token by token for the nodes that emit the deltas of their replies with
+// graph.EmitMessageDelta.
+//
+// Requests carry the whole conversation, so runs are on no thread. A run
+// that is interrupted replies with the value of its interrupt, such as a
+// question to the user, whose answer starts a new run.
+package openai
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// maxBodySize is the size of the largest request the handler accepts.
+const maxBodySize = 10 << 20
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithModel serves runnable as the model with the given name too.
+func WithModel(model string, runnable *graph.Runnable[graph.MessageState]) Option {
+	return func(h *Handler) {
+		h.add(model, runnable)
+	}
+}
+
+// WithAPIKey rejects the requests without key as bearer token, as OpenAI
+// SDKs send their API key.
+func WithAPIKey(key string) Option {
+	return func(h *Handler) {
+		h.apiKey = key
+	}
+}
+
+// WithInvokeOptions invokes the runs with opts, such as graph.WithEntryPoint.
+func WithInvokeOptions(opts ...graph.InvokeOption) Option {
+	return func(h *Handler) {
+		h.invokeOpts = append(h.invokeOpts, opts...)
+	}
+}
+
+// Handler serves graphs as OpenAI models. It is an http.Handler.
+type Handler struct {
+	models     map[string]*graph.Runnable[graph.MessageState]
+	order      []string
+	apiKey     string
+	invokeOpts []graph.InvokeOption
+	created    int64
+	mux        *http.ServeMux
+}
+
+// New returns a Handler serving runnable as the model with the given name.
+func New(model string, runnable *graph.Runnable[graph.MessageState], opts ...Option) *Handler {
+	h := &Handler{
+		models:  make(map[string]*graph.Runnable[graph.MessageState]),
+		created: time.Now().Unix(),
+		mux:     http.NewServeMux(),
+	}
+	h.add(model, runnable)
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("POST /v1/chat/completions", h.chatCompletions)
+	h.mux.HandleFunc("GET /v1/models", h.listModels)
+	h.mux.HandleFunc("GET /v1/models/{model}", h.getModel)
+	return h
+}
+
+func (h *Handler) add(model string, runnable *graph.Runnable[graph.MessageState]) {
+	if _, ok := h.models[model]; !ok {
+		h.order = append(h.order, model)
+	}
+	h.models[model] = runnable
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.apiKey != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.apiKey)) != 1 {
+			writeError(w, errorf(http.StatusUnauthorized, "invalid_api_key", "invalid API key"))
+			return
+		}
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// Model is a model of the models endpoints.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+func (h *Handler) model(id string) Model {
+	return Model{ID: id, Object: "model", Created: h.created, OwnedBy: "langgraphgo"}
+}
+
+func (h *Handler) listModels(w http.ResponseWriter, r *http.Request) {
+	models := make([]Model, 0, len(h.order))
+	for _, id := range h.order {
+		models = append(models, h.model(id))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": models})
+}
+
+func (h *Handler) getModel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("model")
+	if _, ok := h.models[id]; !ok {
+		writeError(w, errorf(http.StatusNotFound, "model_not_found", "model %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, h.model(id))
+}
+
+// ChatRequest is the body of chat completion requests. Sampling parameters,
+// such as temperature, are ignored.
+type ChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+// ChatMessage is a message of a chat completion request or reply.
+type ChatMessage struct {
+	Role string `json:"role"`
+
+	// Content is a string or a list of parts of type text or image_url.
+	Content    json.RawMessage `json:"content,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	ToolCalls  []llms.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+// contentPart is a part of the content of a ChatMessage.
+type contentPart struct {
+	Type     string                `json:"type"`
+	Text     string                `json:"text,omitempty"`
+	ImageURL *llms.ImageURLContent `json:"image_url,omitempty"`
+}
+
+// messageState returns the state of the run of the messages of a request.
+func messageState(msgs []ChatMessage) (graph.MessageState, error) {
+	state := graph.NewMessageState()
+	for i, msg := range msgs {
+		parts, err := contentParts(msg.Content)
+		if err != nil {
+			return state, errorf(http.StatusBadRequest, "invalid_request_error", "messages[%d]: %v", i, err)
+		}
+		var content llms.MessageContent
+		switch msg.Role {
+		case "system", "developer":
+			content = llms.MessageContent{Role: llms.ChatMessageTypeSystem, Parts: parts}
+		case "user":
+			content = llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: parts}
+		case "assistant":
+			for _, call := range msg.ToolCalls {
+				parts = append(parts, call)
+			}
+			content = llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: parts}
+		case "tool":
+			var text strings.Builder
+			for _, part := range parts {
+				if tc, ok := part.(llms.TextContent); ok {
+					text.WriteString(tc.Text)
+				}
+			}
+			content = llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+				llms.ToolCallResponse{ToolCallID: msg.ToolCallID, Name: msg.Name, Content: text.String()},
+			}}
+		default:
+			return state, errorf(http.StatusBadRequest, "invalid_request_error", "messages[%d]: unknown role %q", i, msg.Role)
+		}
+		m := graph.NewMessage(content)
+		if msg.Name != "" && msg.Role != "tool" {
+			m = m.WithMetadata(graph.MetadataName, msg.Name)
+		}
+		state.Messages = append(state.Messages, m)
+	}
+	return state, nil
+}
+
+// contentParts decodes the content of a ChatMessage.
+func contentParts(data json.RawMessage) ([]llms.ContentPart, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return []llms.ContentPart{llms.TextContent{Text: text}}, nil
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return nil, errors.New("content must be a string or a list of parts")
+	}
+	var content []llms.ContentPart
+	for _, part := range parts {
+		switch {
+		case part.Type == "text":
+			content = append(content, llms.TextContent{Text: part.Text})
+		case part.Type == "image_url" && part.ImageURL != nil:
+			content = append(content, *part.ImageURL)
+		default:
+			return nil, fmt.Errorf("unsupported content part %q", part.Type)
+		}
+	}
+	return content, nil
+}
+
+// ChatCompletion is the reply of a chat completion request.
+type ChatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+}
+
+// ChatChoice is the choice of a ChatCompletion, its Message, or the Delta
+// of a chunk of a streamed reply.
+type ChatChoice struct {
+	Index        int          `json:"index"`
+	Message      *ChatMessage `json:"message,omitempty"`
+	Delta        *ChatDelta   `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+// ChatDelta is the change of a chunk of a streamed reply.
+type ChatDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+func (h *Handler) chatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req ChatRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeError(w, errorf(http.StatusBadRequest, "invalid_request_error", "invalid request body: %v", err))
+		return
+	}
+	runnable, ok := h.models[req.Model]
+	if !ok {
+		writeError(w, errorf(http.StatusNotFound, "model_not_found", "model %s not found", req.Model))
+		return
+	}
+	state, err := messageState(req.Messages)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	completion := ChatCompletion{
+		ID:      "chatcmpl-" + uuid.NewString(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	if req.Stream {
+		h.stream(r.Context(), w, runnable, state, completion)
+		return
+	}
+
+	known := messageIDs(state.Messages)
+	err = runnable.Invoke(r.Context(), &state, h.invokeOpts...)
+	var gi *graph.GraphInterrupt
+	if err != nil && !errors.As(err, &gi) {
+		writeError(w, errorf(http.StatusInternalServerError, "server_error", "run failed: %v", err))
+		return
+	}
+	reply := replyTexts(state.Messages, known)
+	if gi != nil {
+		reply = append(reply, interruptText(gi))
+	}
+	content, _ := json.Marshal(strings.Join(reply, separator))
+	stop := "stop"
+	completion.Choices = []ChatChoice{{Message: &ChatMessage{Role: "assistant", Content: content}, FinishReason: &stop}}
+	writeJSON(w, http.StatusOK, completion)
+}
+
+// separator separates the texts of the messages of a reply.
+const separator = "\n\n"
+
+// messageIDs returns the set of the IDs of msgs.
+func messageIDs(msgs []graph.Message) map[string]bool {
+	ids := make(map[string]bool, len(msgs))
+	for _, m := range msgs {
+		ids[m.ID] = true
+	}
+	return ids
+}
+
+// replyTexts returns the texts of the AI messages of msgs that are not
+// known, by ID, and have text.
+func replyTexts(msgs []graph.Message, known map[string]bool) []string {
+	var texts []string
+	for _, m := range msgs {
+		if known[m.ID] || m.Role != llms.ChatMessageTypeAI {
+			continue
+		}
+		if text := messageText(m.MessageContent); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// messageText returns the text parts of msg.
+func messageText(msg llms.MessageContent) string {
+	var text strings.Builder
+	for _, part := range msg.Parts {
+		if tc, ok := part.(llms.TextContent); ok {
+			text.WriteString(tc.Text)
+		}
+	}
+	return text.String()
+}
+
+// interruptText returns the value of gi as the text of a reply.
+func interruptText(gi *graph.GraphInterrupt) string {
+	if s, ok := gi.Value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(gi.Value)
+	if err != nil {
+		return fmt.Sprint(gi.Value)
+	}
+	return string(data)
+}
+
+// apiError is an error answered in the format of the OpenAI API.
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func errorf(status int, code, format string, args ...any) error {
+	return &apiError{status: status, code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// errorBody returns the body answering err.
+func errorBody(err error) map[string]any {
+	code := "server_error"
+	var ae *apiError
+	if errors.As(err, &ae) {
+		code = ae.code
+	}
+	typ := "invalid_request_error"
+	if code == "server_error" {
+		typ = "server_error"
+	}
+	return map[string]any{"error": map[string]any{"message": err.Error(), "type": typ, "param": nil, "code": code}}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers err with the status code of apiErrors and 500
+// otherwise.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var ae *apiError
+	if errors.As(err, &ae) {
+		status = ae.status
+	}
+	writeJSON(w, status, errorBody(err))
+}
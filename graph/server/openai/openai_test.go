@@ -0,0 +1,237 @@
+package openai_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server/openai"
+	"github.com/tmc/langchaingo/llms"
+	lcopenai "github.com/tmc/langchaingo/llms/openai"
+)
+
+// compile compiles a graph running node.
+func compile(t *testing.T, node func(context.Context, *graph.MessageState) error) *graph.Runnable[graph.MessageState] {
+	t.Helper()
+
+	g := graph.NewStateGraph[graph.MessageState]()
+	g.AddNode("agent", node)
+	g.AddEdge("agent", graph.END)
+	g.SetEntryPoint("agent")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return runnable
+}
+
+// echo streams the reply "You said: " followed by the text of the last
+// message, token by token.
+func echo(ctx context.Context, s *graph.MessageState) error {
+	last := s.Messages[len(s.Messages)-1]
+	text := last.Parts[0].(llms.TextContent).Text
+	stream := graph.StreamDeltas("reply", graph.EmitMessageDelta)
+	for _, chunk := range []string{"You ", "said: ", text} {
+		if err := stream(ctx, []byte(chunk)); err != nil {
+			return err
+		}
+	}
+	return s.AddMessages(graph.Message{ID: "reply", MessageContent: llms.TextParts(llms.ChatMessageTypeAI, "You said: "+text)})
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	twice := compile(t, func(_ context.Context, s *graph.MessageState) error {
+		s.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "one"))
+		s.AddMessage(llms.TextParts(llms.ChatMessageTypeTool, "ignored"))
+		s.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "two"))
+		return nil
+	})
+	ask := compile(t, func(ctx context.Context, s *graph.MessageState) error {
+		_, err := graph.Interrupt(ctx, "what is your name?")
+		return err
+	})
+	h := openai.New("echo", compile(t, echo),
+		openai.WithModel("twice", twice),
+		openai.WithModel("ask", ask),
+		openai.WithAPIKey("secret"))
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestOpenAIClient(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	llm, err := lcopenai.New(lcopenai.WithBaseURL(ts.URL+"/v1"), lcopenai.WithToken("secret"), lcopenai.WithModel("echo"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Be nice."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+	}
+
+	resp, err := llm.GenerateContent(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resp.Choices[0].Content; got != "You said: hi" {
+		t.Errorf("expected reply %q, but got %q", "You said: hi", got)
+	}
+
+	var chunks []string
+	resp, err = llm.GenerateContent(context.Background(), messages, llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		// The role and finish reason chunks have no content.
+		if len(chunk) > 0 {
+			chunks = append(chunks, string(chunk))
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"You ", "said: ", "hi"}; !slices.Equal(chunks, want) {
+		t.Errorf("expected chunks %q, but got %q", want, chunks)
+	}
+	if got := resp.Choices[0].Content; got != "You said: hi" {
+		t.Errorf("expected streamed reply %q, but got %q", "You said: hi", got)
+	}
+}
+
+// post posts body to the chat completions endpoint with the API key.
+func post(t *testing.T, ts *httptest.Server, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/chat/completions", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletions(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	testCases := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "Several messages", model: "twice", want: "one\n\ntwo"},
+		{name: "Interrupt", model: "ask", want: "what is your name?"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			body := `{"model": "` + tc.model + `", "messages": [{"role": "user", "content": [{"type": "text", "text": "hi"}]}]}`
+			var completion openai.ChatCompletion
+			if err := json.NewDecoder(post(t, ts, body).Body).Decode(&completion); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var content string
+			if err := json.Unmarshal(completion.Choices[0].Message.Content, &content); err != nil || content != tc.want {
+				t.Errorf("expected reply %q, but got %s", tc.want, completion.Choices[0].Message.Content)
+			}
+
+			// Streamed replies have the same content.
+			stream := strings.Replace(body, "{", `{"stream": true, `, 1)
+			scanner := bufio.NewScanner(post(t, ts, stream).Body)
+			var streamed strings.Builder
+			done := false
+			for scanner.Scan() {
+				data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+				if !ok {
+					continue
+				}
+				if data == "[DONE]" {
+					done = true
+					continue
+				}
+				var chunk openai.ChatCompletion
+				if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				streamed.WriteString(chunk.Choices[0].Delta.Content)
+			}
+			if !done || streamed.String() != tc.want {
+				t.Errorf("expected streamed reply %q, but got %q (done: %v)", tc.want, streamed.String(), done)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := newServer(t)
+	testCases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "Unknown model", body: `{"model": "missing", "messages": []}`, want: http.StatusNotFound},
+		{name: "Unknown role", body: `{"model": "echo", "messages": [{"role": "robot", "content": "hi"}]}`, want: http.StatusBadRequest},
+		{name: "Invalid content", body: `{"model": "echo", "messages": [{"role": "user", "content": 1}]}`, want: http.StatusBadRequest},
+		{name: "Invalid body", body: `{`, want: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			resp := post(t, ts, tc.body)
+			var body struct {
+				Error struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.StatusCode != tc.want || body.Error.Message == "" {
+				t.Errorf("expected status %d with an error, but got %d %+v", tc.want, resp.StatusCode, body)
+			}
+		})
+	}
+
+	resp, err := http.Get(ts.URL + "/v1/models")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status %d without API key, but got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var models struct {
+		Data []openai.Model `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models.Data) != 3 || models.Data[0].ID != "echo" {
+		t.Errorf("expected models echo, twice and ask, but got %+v", models.Data)
+	}
+}
@@ -0,0 +1,146 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// chunkStream writes the chunks of a streamed reply as server-sent events.
+type chunkStream struct {
+	w          http.ResponseWriter
+	completion ChatCompletion
+	known      map[string]bool
+
+	// mu guards the writes and the text sent of every message, the deltas
+	// of nodes running concurrently with the ends of others.
+	mu sync.Mutex
+	// sent is the text sent of the messages of the reply, by ID.
+	sent map[string]string
+	// last is the ID of the message whose text was sent last.
+	last string
+}
+
+// stream runs the graph on state, streaming the reply to w.
+func (h *Handler) stream(ctx context.Context, w http.ResponseWriter, runnable *graph.Runnable[graph.MessageState], state graph.MessageState, completion ChatCompletion) {
+	completion.Object = "chat.completion.chunk"
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	s := &chunkStream{w: w, completion: completion, known: messageIDs(state.Messages), sent: make(map[string]string)}
+	s.send(ChatDelta{Role: "assistant"}, nil)
+
+	opts := slices.Concat(h.invokeOpts, []graph.InvokeOption{
+		graph.WithMessageStream(s.delta),
+		graph.WithRunCallbacks[graph.MessageState](s),
+	})
+	err := runnable.Invoke(ctx, &state, opts...)
+	var gi *graph.GraphInterrupt
+	switch {
+	case errors.As(err, &gi):
+		s.mu.Lock()
+		s.text("interrupt", interruptText(gi))
+		s.mu.Unlock()
+	case err != nil:
+		s.write(errorBody(errorf(http.StatusInternalServerError, "server_error", "run failed: %v", err)))
+		return
+	}
+	stop := "stop"
+	s.send(ChatDelta{}, &stop)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.w, "data: [DONE]\n\n")
+	s.flush()
+}
+
+// delta sends the text of d, a delta emitted by a node, if it adds to an AI
+// message of the reply.
+func (s *chunkStream) delta(_ context.Context, d graph.MessageDelta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, started := s.sent[d.ID]
+	if s.known[d.ID] || d.Remove || d.Replace || !started && d.Role != llms.ChatMessageTypeAI {
+		return nil
+	}
+	s.text(d.ID, s.sent[d.ID]+d.Text)
+	return nil
+}
+
+// NodeStart implements graph.CallbackHandler.
+func (s *chunkStream) NodeStart(context.Context, string, *graph.MessageState) {}
+
+// NodeEnd sends the text the node added to the AI messages of the reply
+// which its deltas did not.
+func (s *chunkStream) NodeEnd(_ context.Context, _ string, state *graph.MessageState, err error) {
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range state.Messages {
+		if !s.known[m.ID] && m.Role == llms.ChatMessageTypeAI {
+			s.text(m.ID, messageText(m.MessageContent))
+		}
+	}
+}
+
+// text sends what text, the text of the message with the given ID, adds to
+// the text sent of the message, s.mu being held. Text that does not extend
+// the text sent, which was replaced, is not sent.
+func (s *chunkStream) text(id, text string) {
+	sent := s.sent[id]
+	if !strings.HasPrefix(text, sent) || len(text) == len(sent) {
+		return
+	}
+	chunk := text[len(sent):]
+	if s.last != id && s.last != "" {
+		chunk = separator + chunk
+	}
+	s.sent[id] = text
+	s.last = id
+	s.sendLocked(ChatDelta{Content: chunk}, nil)
+}
+
+func (s *chunkStream) send(delta ChatDelta, finishReason *string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendLocked(delta, finishReason)
+}
+
+func (s *chunkStream) sendLocked(delta ChatDelta, finishReason *string) {
+	chunk := s.completion
+	chunk.Choices = []ChatChoice{{Delta: &delta, FinishReason: finishReason}}
+	s.writeLocked(chunk)
+}
+
+func (s *chunkStream) write(v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeLocked(v)
+}
+
+// writeLocked writes v as an event. Errors are dropped: a client that went
+// away cancels the request context, which ends the run.
+func (s *chunkStream) writeLocked(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.w, "data: %s\n\n", data)
+	s.flush()
+}
+
+func (s *chunkStream) flush() {
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
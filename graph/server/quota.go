@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// ErrTokenQuota is returned by SpendTokens when the tenant of the run has
+// exceeded its token budget.
+var ErrTokenQuota = errors.New("server: token quota exceeded")
+
+// Limit is the number of requests and tokens a tenant may spend per window
+// of a Quota. Zero is unlimited.
+type Limit struct {
+	Requests int
+	Tokens   int
+}
+
+// Quota limits the requests to a Server or Mux and the tokens their runs
+// spend, per tenant and per window, see WithQuota and WithMuxQuota.
+// Requests over the limit of their tenant, and runs of tenants out of
+// tokens, are answered with 429 Too Many Requests and a Retry-After header
+// in seconds. All responses report the usage of the tenant in the headers
+//
+//	X-RateLimit-Limit-Requests      X-RateLimit-Limit-Tokens
+//	X-RateLimit-Remaining-Requests  X-RateLimit-Remaining-Tokens
+//	X-RateLimit-Reset-Requests      X-RateLimit-Reset-Tokens
+//
+// of its limited resources, the reset being in seconds. Nodes report the
+// tokens they spend, such as the usage of their model calls, with
+// SpendTokens. A WebSocket connection is one request, each of its runs
+// needing tokens left.
+type Quota struct {
+	// Limit is the limit of the tenants without one of their own.
+	Limit
+
+	// Tenants are the limits of tenants, such as the identities of users
+	// on a paid plan.
+	Tenants map[string]Limit
+
+	// Window is the period after which the usage of a tenant is reset, a
+	// minute by default.
+	Window time.Duration
+
+	// Tenant returns the tenant of a request. By default, it is the
+	// identity of the authenticated user (see Auth), or else the remote
+	// host of the request: credentials that were not authenticated could
+	// be made up to get a fresh quota.
+	Tenant func(r *http.Request) string
+
+	// Clock tells the time of the windows, the system clock by default,
+	// such as a graphtest.FakeClock in tests.
+	Clock graph.Clock
+}
+
+// quota keeps the usage of the tenants of a Quota.
+type quota struct {
+	Quota
+
+	// mu guards usage, lastSweep and the fields of meters.
+	mu        sync.Mutex
+	usage     map[string]*meter
+	lastSweep time.Time
+}
+
+// meter is the usage of a tenant in the current window.
+type meter struct {
+	q        *quota
+	tenant   string
+	limit    Limit
+	start    time.Time
+	requests int
+	tokens   int
+}
+
+func newQuota(q Quota) *quota {
+	if q.Window <= 0 {
+		q.Window = time.Minute
+	}
+	if q.Tenant == nil {
+		q.Tenant = tenantOf
+	}
+	if q.Clock == nil {
+		q.Clock = graph.SystemClock()
+	}
+	return &quota{Quota: q, usage: make(map[string]*meter)}
+}
+
+// tenantOf is the default tenant of a request.
+func tenantOf(r *http.Request) string {
+	if user, ok := UserFrom(r.Context()); ok {
+		return user.Identity
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// meter returns the meter of tenant, starting a new window if the last one
+// is over, q.mu being held. Meters of windows over are dropped once per
+// window.
+func (q *quota) meter(tenant string, now time.Time) *meter {
+	if now.Sub(q.lastSweep) >= q.Window {
+		for t, m := range q.usage {
+			if now.Sub(m.start) >= q.Window {
+				delete(q.usage, t)
+			}
+		}
+		q.lastSweep = now
+	}
+	m, ok := q.usage[tenant]
+	if !ok || now.Sub(m.start) >= q.Window {
+		limit, ok := q.Tenants[tenant]
+		if !ok {
+			limit = q.Limit
+		}
+		m = &meter{q: q, tenant: tenant, limit: limit, start: now}
+		q.usage[tenant] = m
+	}
+	return m
+}
+
+type meterKey struct{}
+
+// meterFrom returns the meter of the tenant of the request or run of ctx.
+func meterFrom(ctx context.Context) (*meter, bool) {
+	m, ok := ctx.Value(meterKey{}).(*meter)
+	return m, ok
+}
+
+// limit returns a handler counting the requests of h against their tenant's
+// limit and rejecting those over it.
+func (q *quota) limit(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The graphs of a Mux serve requests it counted already.
+		if _, ok := meterFrom(r.Context()); ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		now := q.Clock.Now()
+		q.mu.Lock()
+		m := q.meter(q.Tenant(r), now)
+		m.requests++
+		exceeded := m.limit.Requests > 0 && m.requests > m.limit.Requests
+		if exceeded {
+			// Rejected requests do not count.
+			m.requests--
+		}
+		m.header(w.Header(), now)
+		reset := m.reset(now)
+		q.mu.Unlock()
+		if exceeded {
+			writeError(w, &httpError{status: http.StatusTooManyRequests, detail: "request quota exceeded", retryAfter: reset})
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), meterKey{}, m)))
+	})
+}
+
+// reset returns the seconds until the window of m is over, as of now.
+func (m *meter) reset(now time.Time) string {
+	return strconv.Itoa(int((m.start.Add(m.q.Window).Sub(now) + time.Second - 1) / time.Second))
+}
+
+// header sets the usage headers of m, m.q.mu being held.
+func (m *meter) header(h http.Header, now time.Time) {
+	reset := m.reset(now)
+	set := func(name string, limit, used int) {
+		if limit <= 0 {
+			return
+		}
+		h.Set("X-RateLimit-Limit-"+name, strconv.Itoa(limit))
+		h.Set("X-RateLimit-Remaining-"+name, strconv.Itoa(max(limit-used, 0)))
+		h.Set("X-RateLimit-Reset-"+name, reset)
+	}
+	set("Requests", m.limit.Requests, m.requests)
+	set("Tokens", m.limit.Tokens, m.tokens)
+}
+
+// checkTokens returns an error if the tenant of ctx has no tokens left. The
+// usage of a tenant whose window is over is reset.
+func checkTokens(ctx context.Context) error {
+	m, ok := meterFrom(ctx)
+	if !ok {
+		return nil
+	}
+	q := m.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.Clock.Now()
+	current := q.meter(m.tenant, now)
+	if current.limit.Tokens > 0 && current.tokens >= current.limit.Tokens {
+		return &httpError{status: http.StatusTooManyRequests, detail: "token quota exceeded", retryAfter: current.reset(now)}
+	}
+	return nil
+}
+
+// SpendTokens charges n tokens to the tenant of the run of ctx, such as the
+// tokens of a model call of a node. It returns ErrTokenQuota if the tenant
+// has exceeded its token budget, which nodes may return to stop the run; the
+// next runs of the tenant are rejected until its usage is reset. Outside of
+// the runs of a server with a Quota, it does nothing.
+func SpendTokens(ctx context.Context, n int) error {
+	m, ok := meterFrom(ctx)
+	if !ok {
+		return nil
+	}
+	q := m.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	current := q.meter(m.tenant, q.Clock.Now())
+	current.tokens += n
+	if current.limit.Tokens > 0 && current.tokens > current.limit.Tokens {
+		return ErrTokenQuota
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+// callHeader sends body as JSON with the given request headers and returns
+// the status code and headers of the response.
+func callHeader(t *testing.T, ts *httptest.Server, header http.Header, method, path string, body any) (int, http.Header) {
+	t.Helper()
+
+	var r bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&r).Encode(body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, ts.URL+path, &r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header = header
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header
+}
+
+func TestQuotaRequests(t *testing.T) {
+	t.Parallel()
+
+	srv, err := server.New("planner", plannerRunnable(t, 1), server.WithQuota(server.Quota{
+		Limit:   server.Limit{Requests: 2},
+		Tenants: map[string]server.Limit{"vip": {Requests: 3}},
+	}), server.WithAuth(server.Auth{Authenticate: server.APIKeys(map[string]string{"ada": "ada", "vip": "vip"})}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		key  string
+		want []int
+	}{
+		{key: "ada", want: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{key: "vip", want: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+	}
+	for _, tt := range tests {
+		for i, want := range tt.want {
+			status, header := callHeader(t, ts, apiKey(tt.key), "POST", "/assistants/search", map[string]any{})
+			if status != want {
+				t.Fatalf("expected status %d for request %d of %s, but got %d", want, i+1, tt.key, status)
+			}
+			limit := len(tt.want) - 1
+			if got := header.Get("X-RateLimit-Limit-Requests"); got != strconv.Itoa(limit) {
+				t.Errorf("expected limit %d, but got %q", limit, got)
+			}
+			remaining := max(limit-i-1, 0)
+			if got := header.Get("X-RateLimit-Remaining-Requests"); got != strconv.Itoa(remaining) {
+				t.Errorf("expected %d requests remaining after request %d of %s, but got %q", remaining, i+1, tt.key, got)
+			}
+			if header.Get("X-RateLimit-Limit-Tokens") != "" {
+				t.Errorf("expected no token headers without a token limit, but got %v", header)
+			}
+			if retry := header.Get("Retry-After"); (status == http.StatusTooManyRequests) != (retry != "") {
+				t.Errorf("expected Retry-After only when rejected, but got %q with status %d", retry, status)
+			}
+		}
+	}
+}
+
+func TestQuotaUnauthenticated(t *testing.T) {
+	t.Parallel()
+
+	srv, err := server.New("planner", plannerRunnable(t, 1), server.WithQuota(server.Quota{Limit: server.Limit{Requests: 1}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	// Without Auth, API keys are not checked and do not tell tenants apart.
+	if status, _ := callHeader(t, ts, apiKey("ada"), "POST", "/assistants/search", map[string]any{}); status != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+	}
+	if status, _ := callHeader(t, ts, apiKey("bob"), "POST", "/assistants/search", map[string]any{}); status != http.StatusTooManyRequests {
+		t.Errorf("expected status %d for another API key from the same host, but got %d", http.StatusTooManyRequests, status)
+	}
+}
+
+func TestQuotaTokens(t *testing.T) {
+	t.Parallel()
+
+	spent := make(chan error, 3)
+	g := graph.NewStateGraph[plannerState]()
+	g.AddNode("plan", func(ctx context.Context, s *plannerState) error {
+		spent <- server.SpendTokens(ctx, 10)
+		s.Steps++
+		return nil
+	})
+	g.AddEdge("plan", graph.END)
+	g.SetEntryPoint("plan")
+	runnable, err := g.Compile(graph.WithCheckpointer[plannerState](graph.NewMemorySaver[plannerState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	mux := server.NewMux(
+		server.WithMuxQuota(server.Quota{Limit: server.Limit{Requests: 10, Tokens: 15}, Window: 200 * time.Millisecond}),
+		server.WithMuxAuth(server.Auth{
+			Authenticate: server.APIKeys(map[string]string{"ada": "ada", "bob": "bob"}),
+			Authorize:    func(context.Context, server.User, server.Action, server.Thread) error { return nil },
+		}),
+	)
+	if _, err := server.Handle(mux, "planner", runnable); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := server.Handle(mux, "planner", plannerRunnable(t, 1), server.WithVersion(2), server.WithQuota(server.Quota{})); err == nil {
+		t.Error("expected an error for WithQuota on a Mux, but got nil")
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	ada := apiKey("ada")
+
+	callHeader(t, ts, ada, "POST", "/threads", map[string]any{"thread_id": "t1"})
+	run := map[string]any{"assistant_id": "planner"}
+	for i, want := range []error{nil, server.ErrTokenQuota} {
+		if status, _ := callHeader(t, ts, ada, "POST", "/threads/t1/runs/wait", run); status != http.StatusOK {
+			t.Fatalf("expected status %d for run %d, but got %d", http.StatusOK, i+1, status)
+		}
+		if err := <-spent; !errors.Is(err, want) {
+			t.Errorf("expected error %v spending the tokens of run %d, but got %v", want, i+1, err)
+		}
+	}
+
+	// The requests of the Mux count once, though served by its graph.
+	status, header := callHeader(t, ts, ada, "POST", "/threads/t1/runs/wait", run)
+	if status != http.StatusTooManyRequests {
+		t.Errorf("expected status %d out of tokens, but got %d", http.StatusTooManyRequests, status)
+	}
+	if got := header.Get("X-RateLimit-Remaining-Requests"); got != "6" {
+		t.Errorf("expected 6 requests remaining, but got %q", got)
+	}
+	if got := header.Get("X-RateLimit-Remaining-Tokens"); got != "0" || header.Get("Retry-After") == "" {
+		t.Errorf("expected no tokens remaining and Retry-After, but got %v", header)
+	}
+	if status, _ := callHeader(t, ts, apiKey("bob"), "GET", "/threads/t1", nil); status != http.StatusOK {
+		t.Errorf("expected status %d for another tenant, but got %d", http.StatusOK, status)
+	}
+
+	// The usage is reset after the window.
+	time.Sleep(250 * time.Millisecond)
+	if status, _ := callHeader(t, ts, ada, "POST", "/threads/t1/runs/wait", run); status != http.StatusOK {
+		t.Errorf("expected status %d after the window, but got %d", http.StatusOK, status)
+	}
+	if err := <-spent; err != nil {
+		t.Errorf("unexpected error spending tokens after the window: %v", err)
+	}
+}
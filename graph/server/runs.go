@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// RunResult is a run with its outcome, answered by GET /runs/{run_id}.
+type RunResult struct {
+	Run
+
+	// Values is the final state of finished and interrupted runs.
+	Values json.RawMessage `json:"values,omitempty"`
+
+	// Error is the error of failed runs.
+	Error string `json:"error,omitempty"`
+}
+
+// job is the cancellation and the outcome of a run.
+type job struct {
+	// ctx is canceled by cancel when the run is canceled.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	values json.RawMessage
+	err    string
+}
+
+// createBackgroundRun starts a run of the assistant of the request on a new
+// thread in the background, answering the run. Clients poll it at its
+// Content-Location, GET /runs/{run_id}, and may cancel it with POST
+// /runs/{run_id}/cancel. The thread is kept, so that interrupted runs can
+// be resumed on it.
+func (s *Server[T]) createBackgroundRun(w http.ResponseWriter, r *http.Request) {
+	var req RunRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if !s.isAssistant(req.AssistantID) {
+		writeError(w, errorf(http.StatusNotFound, "assistant %s not found", req.AssistantID))
+		return
+	}
+	thread, err := s.newThread(r.Context(), "", nil, ThreadIdle)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	run, exec, err := s.startRun(r.Context(), thread.ThreadID, req)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.threads, thread.ThreadID)
+		s.mu.Unlock()
+		writeError(w, err)
+		return
+	}
+	s.enqueue(exec)
+	w.Header().Set("Content-Location", "/runs/"+run.RunID)
+	writeJSON(w, http.StatusOK, run)
+}
+
+// backgroundRun executes a background run.
+type backgroundRun[T any] func(ctx context.Context, opts ...graph.InvokeOption) (T, error)
+
+// enqueue queues a background run, pending until a worker executes it in the
+// order of the queue, see WithWorkers. Workers are started as runs are
+// queued and stop when the queue is empty.
+func (s *Server[T]) enqueue(exec backgroundRun[T]) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	s.queue = append(s.queue, exec)
+	if s.busy < s.workers {
+		s.busy++
+		go s.work()
+	}
+}
+
+// work executes the queued runs until the queue is empty.
+func (s *Server[T]) work() {
+	for {
+		s.queueMu.Lock()
+		if len(s.queue) == 0 {
+			s.busy--
+			s.queueMu.Unlock()
+			return
+		}
+		exec := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.queueMu.Unlock()
+		_, _ = exec(context.Background())
+	}
+}
+
+// getRunResult answers a run with its outcome.
+func (s *store) getRunResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("run_id")
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	var result RunResult
+	if ok {
+		result.Run = *run
+		if j := s.jobs[id]; j != nil {
+			result.Values, result.Error = j.values, j.err
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, errorf(http.StatusNotFound, "run %s not found", id))
+		return
+	}
+	if _, err := s.thread(r.Context(), result.ThreadID, ActionRead); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// cancelRun cancels a pending or running run, answering 202 Accepted with
+// the run, whose status becomes canceled once it stopped. Runs of another
+// thread than the thread_id of the path, if any, are not found.
+func (s *store) cancelRun(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("run_id")
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	var found Run
+	if ok {
+		found = *run
+	}
+	j := s.jobs[id]
+	s.mu.Unlock()
+	if threadID := r.PathValue("thread_id"); !ok || threadID != "" && found.ThreadID != threadID {
+		writeError(w, errorf(http.StatusNotFound, "run %s not found", id))
+		return
+	}
+	if _, err := s.thread(r.Context(), found.ThreadID, ActionUpdate); err != nil {
+		writeError(w, err)
+		return
+	}
+	if (found.Status != RunPending && found.Status != RunRunning) || j == nil {
+		writeError(w, errorf(http.StatusConflict, "run %s is %s", id, found.Status))
+		return
+	}
+	j.cancel()
+	writeJSON(w, http.StatusAccepted, found)
+}
+
+// finish records the final state and error of run, then sets the status of
+// run and of its thread, so that runs are polled with their outcome.
+func (s *store) finish(run *Run, status RunStatus, threadStatus ThreadStatus, values any, err error) {
+	data, _ := json.Marshal(values)
+	s.mu.Lock()
+	j := s.jobs[run.RunID]
+	j.values = data
+	if status == RunError || status == RunCanceled {
+		j.err = err.Error()
+	}
+	j.cancel()
+	s.mu.Unlock()
+	s.setStatus(run, status, threadStatus)
+}
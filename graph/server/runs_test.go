@@ -0,0 +1,152 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+// poll gets the result of a run until it is neither pending nor running.
+func poll(t *testing.T, ts *httptest.Server, runID string) server.RunResult {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var result server.RunResult
+		if status := call(t, ts, "GET", "/runs/"+runID, nil, &result); status != http.StatusOK {
+			t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+		}
+		if result.Status != server.RunPending && result.Status != server.RunRunning {
+			return result
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected run %s to finish, but it is %s", runID, result.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBackgroundRuns(t *testing.T) {
+	t.Parallel()
+
+	// Runs of plan block until gate is closed or they are canceled.
+	gate := make(chan struct{})
+	started := make(chan struct{}, 3)
+	g := graph.NewStateGraph[plannerState]()
+	g.AddNode("plan", func(ctx context.Context, s *plannerState) error {
+		started <- struct{}{}
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.Steps++
+		return nil
+	})
+	g.AddEdge("plan", graph.END)
+	g.SetEntryPoint("plan")
+	runnable, err := g.Compile(graph.WithCheckpointer[plannerState](graph.NewMemorySaver[plannerState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	mux := server.NewMux()
+	if _, err := server.Handle(mux, "planner", runnable, server.WithWorkers(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	// With one worker, the second and third runs wait for the first.
+	var runs [3]server.Run
+	for i := range runs {
+		input := map[string]any{"assistant_id": "planner", "input": plannerState{Goal: "ship"}}
+		if status := call(t, ts, "POST", "/runs", input, &runs[i]); status != http.StatusOK || runs[i].Status != server.RunPending {
+			t.Fatalf("expected a pending run, but got %d %+v", status, runs[i])
+		}
+		if i == 0 {
+			<-started
+		}
+	}
+	var result server.RunResult
+	call(t, ts, "GET", "/runs/"+runs[1].RunID, nil, &result)
+	if result.Status != server.RunPending || result.ThreadID != runs[1].ThreadID {
+		t.Errorf("expected the second run to be pending, but got %+v", result)
+	}
+
+	// The second run is canceled while pending, the first while running.
+	for _, run := range []server.Run{runs[1], runs[0]} {
+		if status := call(t, ts, "POST", "/runs/"+run.RunID+"/cancel", nil, nil); status != http.StatusAccepted {
+			t.Fatalf("expected status %d, but got %d", http.StatusAccepted, status)
+		}
+	}
+	for _, run := range []server.Run{runs[0], runs[1]} {
+		if result := poll(t, ts, run.RunID); result.Status != server.RunCanceled || result.Error == "" {
+			t.Errorf("expected canceled run %s with an error, but got %+v", run.RunID, result)
+		}
+	}
+	if status := call(t, ts, "POST", "/threads/"+runs[0].ThreadID+"/runs/"+runs[0].RunID+"/cancel", nil, nil); status != http.StatusConflict {
+		t.Errorf("expected status %d canceling a finished run, but got %d", http.StatusConflict, status)
+	}
+	if status := call(t, ts, "POST", "/threads/"+runs[1].ThreadID+"/runs/"+runs[0].RunID+"/cancel", nil, nil); status != http.StatusNotFound {
+		t.Errorf("expected status %d canceling the run of another thread, but got %d", http.StatusNotFound, status)
+	}
+
+	// The third run gets the worker.
+	<-started
+	close(gate)
+	result = poll(t, ts, runs[2].RunID)
+	var values plannerState
+	if err := json.Unmarshal(result.Values, &values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != server.RunSuccess || values != (plannerState{Goal: "ship", Steps: 1}) {
+		t.Errorf("expected a successful run with one step, but got %+v %+v", result, values)
+	}
+	var thread server.Thread
+	call(t, ts, "GET", "/threads/"+runs[2].ThreadID, nil, &thread)
+	if thread.Status != server.ThreadIdle {
+		t.Errorf("expected thread status %s, but got %s", server.ThreadIdle, thread.Status)
+	}
+}
+
+func TestBackgroundRunErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := newChatServer(t, nil)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{name: "Unknown assistant", method: "POST", path: "/runs", body: map[string]any{"assistant_id": "missing"}, want: http.StatusNotFound},
+		{name: "Invalid input", method: "POST", path: "/runs", body: map[string]any{"assistant_id": "agent", "input": 1}, want: http.StatusUnprocessableEntity},
+		{name: "Unknown run", method: "GET", path: "/runs/missing", want: http.StatusNotFound},
+		{name: "Cancel unknown run", method: "POST", path: "/runs/missing/cancel", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, ts, tt.method, tt.path, tt.body, &struct{}{}); status != tt.want {
+				t.Errorf("expected status %d, but got %d", tt.want, status)
+			}
+		})
+	}
+
+	// Failed runs leave no thread behind.
+	var threads []server.Thread
+	call(t, ts, "POST", "/threads/search", map[string]any{}, &threads)
+	if len(threads) != 0 {
+		t.Errorf("expected no threads, but got %v", threads)
+	}
+
+	if _, err := server.New("agent", plannerRunnable(t, 1), server.WithWorkers(0)); err == nil {
+		t.Error("expected an error for no workers, but got nil")
+	}
+}
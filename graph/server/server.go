@@ -0,0 +1,764 @@
+// Package server serves compiled graphs over HTTP with the assistants,
+// threads and runs endpoints of the LangGraph Platform API, so that LangGraph
+// SDK clients and UIs can talk to Go graphs:
+//
+//	runnable, err := g.Compile(graph.WithCheckpointer[State](graph.NewMemorySaver[State]()))
+//	...
+//	srv, err := server.New("agent", runnable)
+//	...
+//	http.ListenAndServe(":2024", srv)
+//
+// The server keeps threads and runs in memory; the states of threads are
+// kept by the checkpointer of the graph. WithAuth restricts the server to
+// authenticated callers, each accessing its own threads:
+//
+//	srv, err := server.New("agent", runnable, server.WithAuth(server.Auth{
+//		Authenticate: server.APIKeys(map[string]string{key: "ada"}),
+//	}))
+//
+// WithQuota limits the requests and the tokens of the runs of each caller.
+//
+// Frontends manage conversations with the thread endpoints: threads are
+// listed with GET /threads, searched by status and metadata with POST
+// /threads/search, their metadata changed with PATCH /threads/{thread_id},
+// copied or forked from a checkpoint with POST /threads/{thread_id}/copy and
+// deleted with their checkpoints with DELETE /threads/{thread_id}.
+//
+// Clients that cannot hold a connection during runs start them in the
+// background with POST /runs, poll them with GET /runs/{run_id} and cancel
+// them with POST /runs/{run_id}/cancel. Background runs are executed by a
+// pool of workers, see WithWorkers. Shutdown stops a server and parks its
+// runs, so that they continue on the next server.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+)
+
+// ThreadStatus is the status of a thread.
+type ThreadStatus string
+
+const (
+	ThreadIdle        ThreadStatus = "idle"
+	ThreadBusy        ThreadStatus = "busy"
+	ThreadInterrupted ThreadStatus = "interrupted"
+	ThreadError       ThreadStatus = "error"
+)
+
+// RunStatus is the status of a run.
+type RunStatus string
+
+const (
+	RunPending     RunStatus = "pending"
+	RunRunning     RunStatus = "running"
+	RunSuccess     RunStatus = "success"
+	RunError       RunStatus = "error"
+	RunInterrupted RunStatus = "interrupted"
+	RunCanceled    RunStatus = "canceled"
+	RunPaused      RunStatus = "paused"
+)
+
+// Assistant is a graph served by the server.
+type Assistant struct {
+	AssistantID string         `json:"assistant_id"`
+	GraphID     string         `json:"graph_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Config      map[string]any `json:"config"`
+	Metadata    map[string]any `json:"metadata"`
+	Version     int            `json:"version"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Thread is a conversation with the graph, whose state is checkpointed
+// between runs.
+type Thread struct {
+	ThreadID  string         `json:"thread_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Metadata  map[string]any `json:"metadata"`
+	Status    ThreadStatus   `json:"status"`
+}
+
+// Run is an invocation of the graph on a thread.
+type Run struct {
+	RunID             string         `json:"run_id"`
+	ThreadID          string         `json:"thread_id"`
+	AssistantID       string         `json:"assistant_id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	Status            RunStatus      `json:"status"`
+	Metadata          map[string]any `json:"metadata"`
+	MultitaskStrategy string         `json:"multitask_strategy"`
+}
+
+// ThreadState is the state of a thread as of its latest checkpoint.
+type ThreadState[T any] struct {
+	Values     T                `json:"values"`
+	Next       []string         `json:"next"`
+	Checkpoint CheckpointConfig `json:"checkpoint"`
+	Metadata   map[string]any   `json:"metadata"`
+	CreatedAt  *time.Time       `json:"created_at"`
+	Tasks      []Task           `json:"tasks"`
+}
+
+// CheckpointConfig identifies a checkpoint of a thread.
+type CheckpointConfig struct {
+	ThreadID     string `json:"thread_id"`
+	CheckpointNS string `json:"checkpoint_ns"`
+	CheckpointID string `json:"checkpoint_id"`
+}
+
+// Task is a node scheduled to run on a thread, with the interrupts that
+// paused it.
+type Task struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Interrupts []Interrupt `json:"interrupts"`
+}
+
+// Interrupt is an interrupt of a run, see graph.GraphInterrupt.
+type Interrupt struct {
+	Value any    `json:"value"`
+	When  string `json:"when"`
+}
+
+// RunRequest is the body of the requests creating runs.
+type RunRequest struct {
+	AssistantID string `json:"assistant_id"`
+
+	// Input is merged into the state of the thread, see graph.Reducer, or
+	// is the initial state of new threads.
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// Command resumes the interrupted run of the thread.
+	Command *Command `json:"command,omitempty"`
+
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// StreamMode selects the events of stream runs, values by default. It
+	// is a mode or a list of modes.
+	StreamMode StreamModes `json:"stream_mode,omitempty"`
+}
+
+// Command controls a run.
+type Command struct {
+	// Resume is the value Interrupt returns to the interrupted node, see
+	// graph.WithResume.
+	Resume json.RawMessage `json:"resume,omitempty"`
+}
+
+// httpError is an error answered with its status code.
+type httpError struct {
+	status int
+	detail string
+
+	// retryAfter, if set, is answered as the Retry-After header.
+	retryAfter string
+}
+
+func (e *httpError) Error() string {
+	return e.detail
+}
+
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status: status, detail: fmt.Sprintf(format, args...)}
+}
+
+// Option configures a Server.
+type Option func(*options)
+
+type options struct {
+	version     int
+	description string
+	config      map[string]any
+	invokeOpts  []graph.InvokeOption
+	auth        *Auth
+	quota       *Quota
+	workers     int
+}
+
+// WithVersion serves the graph as the given version of its assistant, 1 by
+// default. The assistant_id of version 1 is derived from the graph ID, the
+// assistant_id of other versions from both, so that a Mux can serve
+// several versions of a graph.
+func WithVersion(version int) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithDescription describes the assistant to clients.
+func WithDescription(description string) Option {
+	return func(o *options) {
+		o.description = description
+	}
+}
+
+// WithConfig sets the config of the assistant reported to clients.
+func WithConfig(config map[string]any) Option {
+	return func(o *options) {
+		o.config = config
+	}
+}
+
+// WithInvokeOptions invokes the runs of the graph with opts, such as
+// graph.WithEntryPoint.
+func WithInvokeOptions(opts ...graph.InvokeOption) Option {
+	return func(o *options) {
+		o.invokeOpts = append(o.invokeOpts, opts...)
+	}
+}
+
+// WithAuth authenticates the requests of the server with auth and authorizes
+// their access to threads, see Auth. The graphs of a Mux are guarded by the
+// Mux instead, see WithMuxAuth.
+func WithAuth(auth Auth) Option {
+	return func(o *options) {
+		o.auth = &auth
+	}
+}
+
+// WithQuota limits the requests to the server and the tokens of its runs per
+// tenant, see Quota. The graphs of a Mux are limited by the Mux instead, see
+// WithMuxQuota.
+func WithQuota(quota Quota) Option {
+	return func(o *options) {
+		o.quota = &quota
+	}
+}
+
+// WithWorkers executes the background runs of the graph on n workers, 10 by
+// default. Background runs wait for a worker in the order they were
+// created, pending until then.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
+// Server serves a compiled graph over HTTP. It is an http.Handler.
+type Server[T any] struct {
+	assistant  Assistant
+	runnable   *graph.Runnable[T]
+	invokeOpts []graph.InvokeOption
+	mux        *http.ServeMux
+	*store
+
+	// queueMu guards queue and busy, the number of workers executing the
+	// queued background runs, see enqueue.
+	queueMu sync.Mutex
+	queue   []backgroundRun[T]
+	busy    int
+	workers int
+}
+
+// New returns a server serving runnable as the assistant of the graph with
+// the given ID. Clients may refer to the assistant by its assistant_id or
+// by graphID. runnable must have been compiled with a checkpointer, see
+// graph.WithCheckpointer.
+func New[T any](graphID string, runnable *graph.Runnable[T], opts ...Option) (*Server[T], error) {
+	return newServer(graphID, runnable, nil, opts)
+}
+
+// newServer returns a server keeping its threads and runs in st, the store of
+// a Mux, or in a store of its own if st is nil.
+func newServer[T any](graphID string, runnable *graph.Runnable[T], st *store, opts []Option) (*Server[T], error) {
+	if graphID == "" {
+		return nil, errors.New("server: empty graph ID")
+	}
+	if runnable.Checkpointer() == nil {
+		return nil, fmt.Errorf("server: %w", graph.ErrNoCheckpointer)
+	}
+	o := options{version: 1, workers: 10}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.version < 1 {
+		return nil, fmt.Errorf("server: invalid version %d of graph %s", o.version, graphID)
+	}
+	if o.workers < 1 {
+		return nil, fmt.Errorf("server: invalid number of workers %d", o.workers)
+	}
+	switch {
+	case st == nil:
+		st = newStore()
+		st.auth = o.auth
+		if o.quota != nil {
+			st.quota = newQuota(*o.quota)
+		}
+	case o.auth != nil:
+		return nil, errors.New("server: the graphs of a Mux are authenticated by the Mux, see WithMuxAuth")
+	case o.quota != nil:
+		return nil, errors.New("server: the graphs of a Mux are limited by the Mux, see WithMuxQuota")
+	}
+	if o.config == nil {
+		o.config = map[string]any{}
+	}
+	now := time.Now().UTC()
+	s := &Server[T]{
+		assistant: Assistant{
+			AssistantID: assistantID(graphID, o.version),
+			GraphID:     graphID,
+			Name:        graphID,
+			Description: o.description,
+			Config:      o.config,
+			Metadata:    map[string]any{},
+			Version:     o.version,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+		runnable:   runnable,
+		invokeOpts: o.invokeOpts,
+		mux:        http.NewServeMux(),
+		store:      st,
+		workers:    o.workers,
+	}
+	st.mu.Lock()
+	st.checkpointed = append(st.checkpointed, func(ctx context.Context, id string) (bool, error) {
+		_, err := runnable.Checkpointer().Get(ctx, id)
+		if errors.Is(err, graph.ErrCheckpointNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	st.mu.Unlock()
+	s.mux.HandleFunc("POST /assistants/search", s.searchAssistants)
+	s.mux.HandleFunc("GET /assistants/{assistant_id}", s.getAssistant)
+	s.mux.HandleFunc("POST /threads", s.createThread)
+	s.mux.HandleFunc("GET /threads", s.listThreads)
+	s.mux.HandleFunc("POST /threads/search", s.searchThreads)
+	s.mux.HandleFunc("GET /threads/{thread_id}", s.getThread)
+	s.mux.HandleFunc("PATCH /threads/{thread_id}", s.patchThread)
+	s.mux.HandleFunc("DELETE /threads/{thread_id}", s.deleteThread)
+	s.mux.HandleFunc("POST /threads/{thread_id}/copy", s.copyThread)
+	s.mux.HandleFunc("GET /threads/{thread_id}/state", s.getState)
+	s.mux.HandleFunc("POST /threads/{thread_id}/state", s.updateState)
+	s.mux.HandleFunc("POST /threads/{thread_id}/history", s.getHistory)
+	s.mux.HandleFunc("POST /threads/{thread_id}/runs", s.createRun)
+	s.mux.HandleFunc("GET /threads/{thread_id}/runs", s.listRuns)
+	s.mux.HandleFunc("GET /threads/{thread_id}/runs/{run_id}", s.getRun)
+	s.mux.HandleFunc("POST /threads/{thread_id}/runs/wait", s.waitRun)
+	s.mux.HandleFunc("POST /threads/{thread_id}/runs/stream", s.streamRun)
+	s.mux.HandleFunc("GET /threads/{thread_id}/runs/ws", s.runsWebSocket)
+	s.mux.HandleFunc("POST /threads/{thread_id}/runs/{run_id}/cancel", s.cancelRun)
+	s.mux.HandleFunc("POST /runs", s.createBackgroundRun)
+	s.mux.HandleFunc("GET /runs/{run_id}", s.getRunResult)
+	s.mux.HandleFunc("POST /runs/{run_id}/cancel", s.cancelRun)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler(s.mux).ServeHTTP(w, r)
+}
+
+// Assistant returns the assistant of the graph.
+func (s *Server[T]) Assistant() Assistant {
+	return s.assistant
+}
+
+func (s *Server[T]) searchAssistants(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []Assistant{s.assistant})
+}
+
+func (s *Server[T]) getAssistant(w http.ResponseWriter, r *http.Request) {
+	if !s.isAssistant(r.PathValue("assistant_id")) {
+		writeError(w, errorf(http.StatusNotFound, "assistant %s not found", r.PathValue("assistant_id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.assistant)
+}
+
+// assistantID returns the assistant_id of a version of a graph.
+func assistantID(graphID string, version int) string {
+	name := graphID
+	if version != 1 {
+		name = fmt.Sprintf("%s@%d", graphID, version)
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+// isAssistant reports whether id refers to the assistant.
+func (s *Server[T]) isAssistant(id string) bool {
+	return id == s.assistant.AssistantID || id == s.assistant.GraphID
+}
+
+func (s *Server[T]) getState(w http.ResponseWriter, r *http.Request) {
+	thread, err := s.thread(r.Context(), r.PathValue("thread_id"), ActionRead)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	cp, err := s.runnable.GetState(r.Context(), thread.ThreadID)
+	switch {
+	case errors.Is(err, graph.ErrCheckpointNotFound):
+		writeJSON(w, http.StatusOK, ThreadState[T]{
+			Next:       []string{},
+			Checkpoint: CheckpointConfig{ThreadID: thread.ThreadID},
+			Metadata:   map[string]any{},
+			Tasks:      []Task{},
+		})
+		return
+	case err != nil:
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, threadState(cp))
+}
+
+// getHistory answers the checkpoints of a thread, latest first, up to the
+// limit of the request, 10 by default.
+func (s *Server[T]) getHistory(w http.ResponseWriter, r *http.Request) {
+	thread, err := s.thread(r.Context(), r.PathValue("thread_id"), ActionRead)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	req := struct {
+		Limit int `json:"limit"`
+	}{Limit: 10}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	cps, err := s.runnable.Checkpointer().List(r.Context(), thread.ThreadID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	history := []ThreadState[T]{}
+	for i := len(cps) - 1; i >= 0 && len(history) < req.Limit; i-- {
+		history = append(history, threadState(cps[i]))
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// threadState returns the ThreadState of cp.
+func threadState[T any](cp graph.Checkpoint[T]) ThreadState[T] {
+	state := ThreadState[T]{
+		Values:     cp.State,
+		Next:       append([]string{}, cp.Next...),
+		Checkpoint: CheckpointConfig{ThreadID: cp.ThreadID, CheckpointID: cp.ID},
+		Metadata:   map[string]any{"step": cp.Step, "source": "loop"},
+		CreatedAt:  &cp.CreatedAt,
+		Tasks:      []Task{},
+	}
+	for i, name := range cp.Next {
+		task := Task{ID: name, Name: name, Interrupts: []Interrupt{}}
+		// Interrupted runs resume at the first node of Next.
+		if cp.Interrupt != nil && i == 0 {
+			task.Interrupts = append(task.Interrupts, interruptOf(cp.Interrupt))
+		}
+		state.Tasks = append(state.Tasks, task)
+	}
+	return state
+}
+
+// interruptOf returns the Interrupt of gi.
+func interruptOf(gi *graph.GraphInterrupt) Interrupt {
+	switch {
+	case gi.Before:
+		return Interrupt{When: "before"}
+	case gi.After:
+		return Interrupt{When: "after"}
+	}
+	return Interrupt{Value: gi.Value, When: "during"}
+}
+
+func (s *Server[T]) updateState(w http.ResponseWriter, r *http.Request) {
+	thread, err := s.thread(r.Context(), r.PathValue("thread_id"), ActionUpdate)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req struct {
+		Values T `json:"values"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	err = s.runnable.UpdateState(r.Context(), thread.ThreadID, req.Values)
+	if errors.Is(err, graph.ErrCheckpointNotFound) {
+		err = errorf(http.StatusNotFound, "thread %s has no state", thread.ThreadID)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	cp, err := s.runnable.GetState(r.Context(), thread.ThreadID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]CheckpointConfig{
+		"checkpoint": {ThreadID: thread.ThreadID, CheckpointID: cp.ID},
+	})
+}
+
+func (s *Server[T]) createRun(w http.ResponseWriter, r *http.Request) {
+	var req RunRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	run, exec, err := s.startRun(r.Context(), r.PathValue("thread_id"), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.enqueue(exec)
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (s *Server[T]) waitRun(w http.ResponseWriter, r *http.Request) {
+	var req RunRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	_, exec, err := s.startRun(r.Context(), r.PathValue("thread_id"), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	state, err := exec(r.Context())
+	var gi *graph.GraphInterrupt
+	if err != nil && !errors.As(err, &gi) {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// streamRun streams the events of a run in the stream modes of the request
+// as server-sent events, see streamHandler. The location of the run is sent
+// in the Content-Location header and in the first event, metadata.
+func (s *Server[T]) streamRun(w http.ResponseWriter, r *http.Request) {
+	var req RunRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkStreamModes(&req); err != nil {
+		writeError(w, err)
+		return
+	}
+	run, exec, err := s.startRun(r.Context(), r.PathValue("thread_id"), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	location := "/threads/" + run.ThreadID + "/runs/" + run.RunID
+	w.Header().Set("Location", location+"/stream")
+	w.Header().Set("Content-Location", location)
+	stream := newEventStream(w)
+	stream.send("metadata", map[string]any{"run_id": run.RunID, "thread_id": run.ThreadID, "attempt": 1})
+	_, err = exec(r.Context(), graph.WithRunCallbacks[T](newStreamHandler[T](stream.send, req.StreamMode)))
+	var gi *graph.GraphInterrupt
+	if err != nil && !errors.As(err, &gi) {
+		stream.send("error", map[string]string{"error": "RunError", "message": err.Error()})
+	}
+}
+
+// startRun registers a run of req on the thread, marking the thread busy,
+// and returns it with the function executing it, which returns the final
+// state. The caller of ctx must be allowed to update the thread and have
+// tokens left, which the run spends, see SpendTokens.
+func (s *Server[T]) startRun(ctx context.Context, threadID string, req RunRequest) (Run, func(ctx context.Context, opts ...graph.InvokeOption) (T, error), error) {
+	if !s.isAssistant(req.AssistantID) {
+		return Run{}, nil, errorf(http.StatusNotFound, "assistant %s not found", req.AssistantID)
+	}
+	if _, err := s.thread(ctx, threadID, ActionUpdate); err != nil {
+		return Run{}, nil, err
+	}
+	if err := checkTokens(ctx); err != nil {
+		return Run{}, nil, err
+	}
+	m, metered := meterFrom(ctx)
+	var input *T
+	if len(req.Input) > 0 && string(req.Input) != "null" {
+		input = new(T)
+		if err := json.Unmarshal(req.Input, input); err != nil {
+			return Run{}, nil, errorf(http.StatusUnprocessableEntity, "invalid input: %v", err)
+		}
+	}
+	var resume []graph.InvokeOption
+	if req.Command != nil && len(req.Command.Resume) > 0 {
+		var value any
+		if err := json.Unmarshal(req.Command.Resume, &value); err != nil {
+			return Run{}, nil, errorf(http.StatusUnprocessableEntity, "invalid resume value: %v", err)
+		}
+		resume = append(resume, graph.WithResume(value))
+	}
+	if req.Metadata == nil {
+		req.Metadata = map[string]any{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return Run{}, nil, errorf(http.StatusServiceUnavailable, "server is shutting down")
+	}
+	thread, ok := s.threads[threadID]
+	if !ok {
+		return Run{}, nil, errorf(http.StatusNotFound, "thread %s not found", threadID)
+	}
+	if thread.Status == ThreadBusy {
+		return Run{}, nil, errorf(http.StatusConflict, "thread %s is busy", threadID)
+	}
+	if err := s.bind(thread, s.assistant); err != nil {
+		return Run{}, nil, err
+	}
+	now := time.Now().UTC()
+	thread.Status = ThreadBusy
+	thread.UpdatedAt = now
+	run := &Run{
+		RunID:             uuid.NewString(),
+		ThreadID:          threadID,
+		AssistantID:       s.assistant.AssistantID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Status:            RunPending,
+		Metadata:          req.Metadata,
+		MultitaskStrategy: "reject",
+	}
+	s.runs[run.RunID] = run
+	j := &job{}
+	j.ctx, j.cancel = context.WithCancel(context.Background())
+	s.jobs[run.RunID] = j
+	s.active.Add(1)
+
+	exec := func(ctx context.Context, extra ...graph.InvokeOption) (T, error) {
+		defer s.active.Done()
+		if metered {
+			ctx = context.WithValue(ctx, meterKey{}, m)
+		}
+		ctx, stop := context.WithCancel(ctx)
+		defer stop()
+		defer context.AfterFunc(j.ctx, stop)()
+		defer context.AfterFunc(s.stop, stop)()
+
+		// Runs canceled while pending, or pending when the server parks its
+		// runs, do not start.
+		var state T
+		err := j.ctx.Err()
+		if err == nil && isClosed(s.park) {
+			err = errNotStarted
+		}
+		started := err == nil
+		if started {
+			s.setStatus(run, RunRunning, ThreadBusy)
+			opts := slices.Concat(resume, extra, []graph.InvokeOption{graph.WithPause(s.park)})
+			state, err = s.execute(ctx, threadID, input, opts, len(resume) > 0)
+		}
+		var gi *graph.GraphInterrupt
+		switch {
+		case err == nil:
+			s.finish(run, RunSuccess, ThreadIdle, state, nil)
+		case errors.As(err, &gi):
+			s.finish(run, RunInterrupted, ThreadInterrupted, state, err)
+		case errors.Is(err, graph.ErrPaused) || s.stop.Err() != nil && j.ctx.Err() == nil && started:
+			s.finish(run, RunPaused, ThreadIdle, state, nil)
+			err = errorf(http.StatusServiceUnavailable, "run %s paused: server is shutting down", run.RunID)
+		case j.ctx.Err() != nil || !started:
+			if started {
+				s.fail(threadID, err)
+			}
+			s.finish(run, RunCanceled, ThreadIdle, state, err)
+		default:
+			s.fail(threadID, err)
+			s.finish(run, RunError, ThreadError, state, err)
+		}
+		return state, err
+	}
+	return *run, exec, nil
+}
+
+// execute invokes the graph on the thread. Unless the run is resumed, input
+// is merged into the state of the thread, or is the initial state of new
+// threads. Threads whose last run paused with nodes left to run, such as
+// runs parked by Shutdown, are continued.
+func (s *Server[T]) execute(ctx context.Context, threadID string, input *T, opts []graph.InvokeOption, resumed bool) (T, error) {
+	var state T
+	if !resumed {
+		cp, err := s.runnable.GetState(ctx, threadID)
+		switch {
+		case err == nil && paused(cp):
+			if input != nil {
+				if err := s.runnable.UpdateState(ctx, threadID, *input); err != nil {
+					return state, err
+				}
+			}
+			opts = append(slices.Clip(opts), graph.WithContinue())
+		case err == nil:
+			state = cp.State
+			if input != nil {
+				if err := merge(&state, *input); err != nil {
+					return state, err
+				}
+			}
+		case errors.Is(err, graph.ErrCheckpointNotFound):
+			if input != nil {
+				state = *input
+			}
+		default:
+			return state, err
+		}
+	}
+	err := s.runnable.Invoke(ctx, &state, slices.Concat(s.invokeOpts, opts, []graph.InvokeOption{graph.WithThreadID(threadID)})...)
+	return state, err
+}
+
+// merge merges update into state with its Reduce method if it has one, and
+// replaces it otherwise, as graph.Runnable.UpdateState does.
+func merge[T any](state *T, update T) error {
+	if r, ok := any(state).(graph.Reducer[T]); ok {
+		return r.Reduce(update)
+	}
+	*state = update
+	return nil
+}
+
+// decodeBody decodes the JSON body of r into v. An empty body leaves v
+// unchanged.
+func decodeBody(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers err as {"detail": message}, with the status code of
+// httpErrors and 500 otherwise.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	if errors.As(err, &he) {
+		status = he.status
+		if he.retryAfter != "" {
+			w.Header().Set("Retry-After", he.retryAfter)
+		}
+	}
+	writeJSON(w, status, map[string]string{"detail": err.Error()})
+}
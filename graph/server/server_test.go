@@ -0,0 +1,387 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type chatState struct {
+	Messages []string `json:"messages"`
+}
+
+func (s *chatState) Reduce(update chatState) error {
+	s.Messages = append(s.Messages, update.Messages...)
+	return nil
+}
+
+// newChatServer serves a graph asking for a name with Interrupt, then
+// greeting it. gate, if not nil, is received from before the greeting.
+func newChatServer(t *testing.T, gate chan struct{}) *httptest.Server {
+	t.Helper()
+
+	g := graph.NewStateGraph[chatState]()
+	g.AddNode("ask", func(ctx context.Context, s *chatState) error {
+		name, err := graph.Interrupt(ctx, "what is your name?")
+		if err != nil {
+			return err
+		}
+		s.Messages = append(s.Messages, fmt.Sprintf("name: %v", name))
+		return nil
+	})
+	g.AddNode("greet", func(ctx context.Context, s *chatState) error {
+		if gate != nil {
+			<-gate
+		}
+		if slices.Contains(s.Messages, "fail") {
+			return errors.New("greeting failed")
+		}
+		s.Messages = append(s.Messages, "hello")
+		return nil
+	})
+	g.AddEdge("ask", "greet")
+	g.AddEdge("greet", graph.END)
+	g.SetEntryPoint("ask")
+	return serve(t, g)
+}
+
+// call sends body as JSON and decodes the response into out, if not nil,
+// returning the status code.
+func call(t *testing.T, ts *httptest.Server, method, path string, body, out any) int {
+	t.Helper()
+
+	var r bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&r).Encode(body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, ts.URL+path, &r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("unexpected error decoding %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	ts := newChatServer(t, nil)
+
+	var assistants []server.Assistant
+	if status := call(t, ts, "POST", "/assistants/search", map[string]any{}, &assistants); status != http.StatusOK || len(assistants) != 1 {
+		t.Fatalf("expected one assistant, but got %d %v", status, assistants)
+	}
+	assistantID := assistants[0].AssistantID
+	var assistant server.Assistant
+	if status := call(t, ts, "GET", "/assistants/agent", nil, &assistant); status != http.StatusOK || assistant.AssistantID != assistantID {
+		t.Errorf("expected assistant %s by graph ID, but got %d %s", assistantID, status, assistant.AssistantID)
+	}
+
+	var thread server.Thread
+	if status := call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, &thread); status != http.StatusOK || thread.Status != server.ThreadIdle {
+		t.Fatalf("expected idle thread, but got %d %v", status, thread)
+	}
+	if status := call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil); status != http.StatusConflict {
+		t.Errorf("expected status %d for a duplicate thread, but got %d", http.StatusConflict, status)
+	}
+
+	// The run is interrupted by ask.
+	var values chatState
+	input := map[string]any{"assistant_id": assistantID, "input": chatState{Messages: []string{"hi"}}}
+	if status := call(t, ts, "POST", "/threads/t1/runs/wait", input, &values); status != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+	}
+	if want := []string{"hi"}; !slices.Equal(values.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, values.Messages)
+	}
+	var state server.ThreadState[chatState]
+	call(t, ts, "GET", "/threads/t1/state", nil, &state)
+	if !slices.Equal(state.Next, []string{"ask"}) || len(state.Tasks) != 1 || len(state.Tasks[0].Interrupts) != 1 {
+		t.Fatalf("expected an interrupted ask task, but got %+v", state)
+	}
+	if v := state.Tasks[0].Interrupts[0].Value; v != "what is your name?" {
+		t.Errorf("expected interrupt value %q, but got %v", "what is your name?", v)
+	}
+	call(t, ts, "GET", "/threads/t1", nil, &thread)
+	if thread.Status != server.ThreadInterrupted {
+		t.Errorf("expected thread status %s, but got %s", server.ThreadInterrupted, thread.Status)
+	}
+
+	resume := map[string]any{"assistant_id": "agent", "command": map[string]any{"resume": "ada"}}
+	call(t, ts, "POST", "/threads/t1/runs/wait", resume, &values)
+	if want := []string{"hi", "name: ada", "hello"}; !slices.Equal(values.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, values.Messages)
+	}
+	call(t, ts, "GET", "/threads/t1/state", nil, &state)
+	if len(state.Next) != 0 || state.Checkpoint.CheckpointID == "" {
+		t.Errorf("expected a final checkpoint, but got %+v", state)
+	}
+
+	// State updates are reduced into the thread.
+	if status := call(t, ts, "POST", "/threads/t1/state", map[string]any{"values": chatState{Messages: []string{"edited"}}}, nil); status != http.StatusOK {
+		t.Errorf("expected status %d, but got %d", http.StatusOK, status)
+	}
+	call(t, ts, "GET", "/threads/t1/state", nil, &state)
+	if want := []string{"hi", "name: ada", "hello", "edited"}; !slices.Equal(state.Values.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, state.Values.Messages)
+	}
+
+	var history []server.ThreadState[chatState]
+	call(t, ts, "POST", "/threads/t1/history", map[string]any{"limit": 2}, &history)
+	if len(history) != 2 || history[0].Checkpoint.CheckpointID != state.Checkpoint.CheckpointID {
+		t.Errorf("expected the last 2 checkpoints, latest first, but got %+v", history)
+	}
+	var threads []server.Thread
+	call(t, ts, "POST", "/threads/search", map[string]any{"status": "idle"}, &threads)
+	if len(threads) != 1 || threads[0].ThreadID != "t1" {
+		t.Errorf("expected thread t1, but got %+v", threads)
+	}
+	call(t, ts, "POST", "/threads/search", map[string]any{"status": "busy"}, &threads)
+	if len(threads) != 0 {
+		t.Errorf("expected no busy threads, but got %+v", threads)
+	}
+
+	var runs []server.Run
+	call(t, ts, "GET", "/threads/t1/runs", nil, &runs)
+	if len(runs) != 2 || runs[0].Status != server.RunSuccess || runs[1].Status != server.RunInterrupted {
+		t.Errorf("expected a successful and an interrupted run, but got %+v", runs)
+	}
+}
+
+func TestServerStream(t *testing.T) {
+	t.Parallel()
+
+	ts := newChatServer(t, nil)
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	call(t, ts, "POST", "/threads/t1/runs/wait", map[string]any{"assistant_id": "agent"}, nil)
+
+	resp, events := stream(t, ts, "/threads/t1/runs/stream", `{"assistant_id": "agent", "command": {"resume": "ada"}}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected content type text/event-stream, but got %s", ct)
+	}
+	if loc := resp.Header.Get("Content-Location"); !strings.HasPrefix(loc, "/threads/t1/runs/") {
+		t.Errorf("expected the run location, but got %q", loc)
+	}
+	want := []string{
+		"metadata",
+		`values {"messages":["name: ada"]}`,
+		`values {"messages":["name: ada","hello"]}`,
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("expected events %q, but got %q", want, events)
+	}
+}
+
+// stream posts body to the stream endpoint at path and returns the events
+// received, as "event data", leaving out the data of metadata events.
+func stream(t *testing.T, ts *httptest.Server, path, body string) (*http.Response, []string) {
+	t.Helper()
+
+	resp, err := ts.Client().Post(ts.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if event, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			events = append(events, event)
+		}
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok && events[len(events)-1] != "metadata" {
+			events[len(events)-1] += " " + data
+		}
+	}
+	return resp, events
+}
+
+// serve serves the graph with a memory checkpointer as the assistant of
+// graph "agent".
+func serve[T any](t *testing.T, g *graph.StateGraph[T]) *httptest.Server {
+	t.Helper()
+
+	runnable, err := g.Compile(graph.WithCheckpointer[T](graph.NewMemorySaver[T]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	srv, err := server.New("agent", runnable)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+type plannerState struct {
+	Goal  string `json:"goal"`
+	Steps int    `json:"steps"`
+}
+
+func TestServerStreamModes(t *testing.T) {
+	t.Parallel()
+
+	planner := graph.NewStateGraph[plannerState]()
+	planner.AddNode("plan", func(_ context.Context, s *plannerState) error {
+		s.Steps++
+		return nil
+	})
+	planner.AddEdge("plan", graph.END)
+	planner.SetEntryPoint("plan")
+
+	chat := graph.NewStateGraph[graph.MessageState]()
+	chat.AddNode("agent", func(_ context.Context, s *graph.MessageState) error {
+		return s.AddMessages(graph.Message{ID: "m1", MessageContent: llms.TextParts(llms.ChatMessageTypeAI, "hello")})
+	})
+	chat.AddEdge("agent", graph.END)
+	chat.SetEntryPoint("agent")
+
+	tests := []struct {
+		name string
+		ts   *httptest.Server
+		body string
+		want []string
+	}{
+		{
+			name: "Updates",
+			ts:   serve(t, planner),
+			body: `{"assistant_id": "agent", "input": {"goal": "ship"}, "stream_mode": "updates"}`,
+			want: []string{"metadata", `updates {"plan":{"steps":1}}`},
+		},
+		{
+			name: "Values and updates",
+			ts:   serve(t, planner),
+			body: `{"assistant_id": "agent", "input": {"goal": "ship"}, "stream_mode": ["values", "updates"]}`,
+			want: []string{"metadata", `values {"goal":"ship","steps":1}`, `updates {"plan":{"steps":1}}`},
+		},
+		{
+			name: "Messages",
+			ts:   serve(t, chat),
+			body: `{"assistant_id": "agent", "stream_mode": ["messages"]}`,
+			want: []string{
+				"metadata",
+				`messages [{"id":"m1","role":"ai","parts":[{"type":"text","text":"hello"}]},{"langgraph_node":"agent"}]`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			call(t, tt.ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+			_, events := stream(t, tt.ts, "/threads/t1/runs/stream", tt.body)
+			if !slices.Equal(events, tt.want) {
+				t.Errorf("expected events %q, but got %q", tt.want, events)
+			}
+		})
+	}
+}
+
+func TestServerBackgroundRun(t *testing.T) {
+	t.Parallel()
+
+	gate := make(chan struct{})
+	ts := newChatServer(t, gate)
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	call(t, ts, "POST", "/threads/t1/runs/wait", map[string]any{"assistant_id": "agent", "input": chatState{Messages: []string{"fail"}}}, nil)
+
+	var run server.Run
+	resume := map[string]any{"assistant_id": "agent", "command": map[string]any{"resume": "ada"}}
+	if status := call(t, ts, "POST", "/threads/t1/runs", resume, &run); status != http.StatusOK || run.RunID == "" {
+		t.Fatalf("expected a run, but got %d %+v", status, run)
+	}
+	// Runs on a busy thread are rejected.
+	if status := call(t, ts, "POST", "/threads/t1/runs/wait", resume, nil); status != http.StatusConflict {
+		t.Errorf("expected status %d, but got %d", http.StatusConflict, status)
+	}
+	close(gate)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for run.Status == server.RunPending || run.Status == server.RunRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the run to finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+		call(t, ts, "GET", "/threads/t1/runs/"+run.RunID, nil, &run)
+	}
+	if run.Status != server.RunError {
+		t.Errorf("expected run status %s, but got %s", server.RunError, run.Status)
+	}
+	var thread server.Thread
+	call(t, ts, "GET", "/threads/t1", nil, &thread)
+	if thread.Status != server.ThreadError {
+		t.Errorf("expected thread status %s, but got %s", server.ThreadError, thread.Status)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := newChatServer(t, nil)
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{name: "Unknown assistant", method: "GET", path: "/assistants/missing", want: http.StatusNotFound},
+		{name: "Unknown thread", method: "GET", path: "/threads/missing/state", want: http.StatusNotFound},
+		{name: "Unknown run", method: "GET", path: "/threads/t1/runs/missing", want: http.StatusNotFound},
+		{name: "Run on unknown thread", method: "POST", path: "/threads/missing/runs/wait", body: map[string]any{"assistant_id": "agent"}, want: http.StatusNotFound},
+		{name: "Run of unknown assistant", method: "POST", path: "/threads/t1/runs/wait", body: map[string]any{"assistant_id": "missing"}, want: http.StatusNotFound},
+		{name: "Invalid input", method: "POST", path: "/threads/t1/runs/wait", body: map[string]any{"assistant_id": "agent", "input": 1}, want: http.StatusUnprocessableEntity},
+		{name: "Unknown stream mode", method: "POST", path: "/threads/t1/runs/stream", body: map[string]any{"assistant_id": "agent", "stream_mode": "debug"}, want: http.StatusUnprocessableEntity},
+		{name: "Update without state", method: "POST", path: "/threads/t1/state", body: map[string]any{"values": chatState{}}, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var detail struct {
+				Detail string `json:"detail"`
+			}
+			if status := call(t, ts, tt.method, tt.path, tt.body, &detail); status != tt.want || detail.Detail == "" {
+				t.Errorf("expected status %d with a detail, but got %d %q", tt.want, status, detail.Detail)
+			}
+		})
+	}
+}
+
+func TestNewWithoutCheckpointer(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[chatState]()
+	g.AddNode("a", func(context.Context, *chatState) error { return nil })
+	g.AddEdge("a", graph.END)
+	g.SetEntryPoint("a")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	if _, err := server.New("agent", runnable); !errors.Is(err, graph.ErrNoCheckpointer) {
+		t.Errorf("expected error %v, but got %v", graph.ErrNoCheckpointer, err)
+	}
+}
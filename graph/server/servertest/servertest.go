@@ -0,0 +1,94 @@
+// Package servertest serves graphs in tests, over HTTP with package
+// graph/server and over gRPC with package graph/remote, on local ports:
+//
+//	func TestAgentServer(t *testing.T) {
+//		srv := servertest.Start(t, runnable)
+//		state := State{Question: "why?"}
+//		if err := srv.Client.Invoke(ctx, &state, remote.WithThreadID("t1")); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+//
+// The servers stop when the test ends.
+package servertest
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/remote"
+	"github.com/alberrttt/langgraphgo/graph/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// GraphID is the graph ID the graphs are served under.
+const GraphID = "graph"
+
+// Server is a graph served by Start.
+type Server[T any] struct {
+	// Runnable is the graph served.
+	Runnable *graph.Runnable[T]
+
+	// Server is the HTTP server of the graph.
+	Server *server.Server[T]
+
+	// URL is the base URL of the HTTP server, such as
+	// "http://127.0.0.1:50123".
+	URL string
+
+	// Client runs the graph over HTTP.
+	Client *remote.HTTPClient[T]
+
+	// GRPCAddr is the address of the gRPC server.
+	GRPCAddr string
+
+	// GRPC runs the graph over gRPC.
+	GRPC *remote.Client[T]
+}
+
+// Start serves runnable over HTTP and over gRPC on random local ports until
+// the test ends, and returns clients of both. A graph compiled without a
+// checkpointer is compiled again with a graph.MemorySaver, without its
+// other compile options. opts configure the HTTP server. Start fails the
+// test if the graph cannot be served.
+func Start[T any](t testing.TB, runnable *graph.Runnable[T], opts ...server.Option) *Server[T] {
+	t.Helper()
+	if runnable.Checkpointer() == nil {
+		var err error
+		if runnable, err = runnable.Graph.Compile(graph.WithCheckpointer[T](graph.NewMemorySaver[T]())); err != nil {
+			t.Fatalf("servertest: compile: %v", err)
+		}
+	}
+	srv, err := server.New(GraphID, runnable, opts...)
+	if err != nil {
+		t.Fatalf("servertest: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("servertest: %v", err)
+	}
+	gs := grpc.NewServer()
+	remote.Register(gs, runnable)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("servertest: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &Server[T]{
+		Runnable: runnable,
+		Server:   srv,
+		URL:      ts.URL,
+		Client:   remote.NewHTTPClient[T](ts.URL, GraphID, remote.WithHTTPClient(ts.Client())),
+		GRPCAddr: lis.Addr().String(),
+		GRPC:     remote.NewClient[T](conn),
+	}
+}
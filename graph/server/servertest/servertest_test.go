@@ -0,0 +1,56 @@
+package servertest_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/remote"
+	"github.com/alberrttt/langgraphgo/graph/server/servertest"
+)
+
+type greetState struct {
+	Messages []string `json:"messages"`
+}
+
+func TestStart(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[greetState]()
+	g.AddNode("greet", func(_ context.Context, s *greetState) error {
+		s.Messages = append(s.Messages, "hello")
+		return nil
+	})
+	g.AddEdge("greet", graph.END)
+	g.SetEntryPoint("greet")
+	// The graph is compiled without a checkpointer.
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	srv := servertest.Start(t, runnable)
+
+	// client is implemented by the clients of both servers.
+	type client interface {
+		Invoke(ctx context.Context, state *greetState, opts ...remote.InvokeOption) error
+		GetState(ctx context.Context, threadID string) (graph.Checkpoint[greetState], error)
+	}
+	ctx := context.Background()
+	for name, client := range map[string]client{"HTTP": srv.Client, "gRPC": srv.GRPC} {
+		state := greetState{Messages: []string{"hi"}}
+		if err := client.Invoke(ctx, &state, remote.WithThreadID("t-"+name)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if want := []string{"hi", "hello"}; !slices.Equal(state.Messages, want) {
+			t.Errorf("%s: expected messages %v, but got %v", name, want, state.Messages)
+		}
+		cp, err := client.GetState(ctx, "t-"+name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if want := []string{"hi", "hello"}; !slices.Equal(cp.State.Messages, want) {
+			t.Errorf("%s: expected checkpointed messages %v, but got %v", name, want, cp.State.Messages)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+)
+
+// errNotStarted is the error of the background runs still pending when the
+// server parks its runs.
+var errNotStarted = errors.New("server: shut down before the run started")
+
+// Shutdown stops the server gracefully, such as on SIGTERM during a rolling
+// deploy. Runs are no longer started, being answered with 503 Service
+// Unavailable, and the runs in flight are given grace to finish. The runs
+// still in flight then pause once their running node ends, checkpointed
+// with the nodes left to run, and the background runs not started yet are
+// canceled. The next run of a paused thread continues it, such as on the
+// server replacing this one, with its input merged into the state of the
+// thread. The server replacing this one knows the thread once it is created
+// again with its ID, unless the server has Auth: threads are owned in memory
+// only, so their checkpoints are not taken over by others. If ctx is done
+// before the runs paused, they are canceled and
+// Shutdown returns the error of ctx; their threads continue from the nodes
+// that were running.
+//
+// Shutdown does not close connections; it is called before
+// http.Server.Shutdown, which then waits for the responses of the runs:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+//	defer stop()
+//	go httpServer.ListenAndServe()
+//	<-ctx.Done()
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	srv.Shutdown(ctx, 30*time.Second)
+//	httpServer.Shutdown(ctx)
+//
+// The server of a graph of a Mux shuts down the Mux.
+func (s *Server[T]) Shutdown(ctx context.Context, grace time.Duration) error {
+	return s.shutdown(ctx, grace)
+}
+
+// Shutdown stops the Mux gracefully, pausing the runs of its graphs still in
+// flight after grace, see Server.Shutdown.
+func (m *Mux) Shutdown(ctx context.Context, grace time.Duration) error {
+	return m.store.shutdown(ctx, grace)
+}
+
+// shutdown stops the runs of s from starting, then waits for the runs in
+// flight to finish for grace, to pause until ctx is done, and cancels them.
+func (s *store) shutdown(ctx context.Context, grace time.Duration) error {
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	s.parkOnce.Do(func() { close(s.park) })
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// isClosed reports whether ch is closed, without blocking.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// paused reports whether the run checkpointed by cp paused with nodes left
+// to run, rather than ended, was interrupted or failed.
+func paused[T any](cp graph.Checkpoint[T]) bool {
+	return len(cp.Next) > 0 && cp.Interrupt == nil && cp.Error == ""
+}
+
+// fail checkpoints the failure of the run of a thread, as the distributed
+// executor does, so that the next run of the thread starts over rather than
+// continuing it, see execute. The failure is not recorded if the thread has
+// no nodes left to run or its checkpointer fails, the run failing anyway.
+func (s *Server[T]) fail(threadID string, err error) {
+	ctx := context.Background()
+	cp, cerr := s.runnable.GetState(ctx, threadID)
+	if cerr != nil || !paused(cp) {
+		return
+	}
+	cp.ID = uuid.NewString()
+	cp.Error = err.Error()
+	cp.CreatedAt = time.Now()
+	_ = s.runnable.Checkpointer().Put(ctx, cp)
+}
@@ -0,0 +1,211 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+// gatedRunnable returns a runnable whose plan node, adding a step, blocks
+// until gate is closed or its run is canceled, and whose act node adds ten
+// steps, or fails if act returns an error.
+func gatedRunnable(t *testing.T, gate <-chan struct{}, started chan<- struct{}, act func() error) *graph.Runnable[plannerState] {
+	t.Helper()
+
+	g := graph.NewStateGraph[plannerState]()
+	g.AddNode("plan", func(ctx context.Context, s *plannerState) error {
+		started <- struct{}{}
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.Steps++
+		return nil
+	})
+	g.AddNode("act", func(_ context.Context, s *plannerState) error {
+		if err := act(); err != nil {
+			return err
+		}
+		s.Steps += 10
+		return nil
+	})
+	g.AddEdge("plan", "act")
+	g.AddEdge("act", graph.END)
+	g.SetEntryPoint("plan")
+	runnable, err := g.Compile(graph.WithCheckpointer[plannerState](graph.NewMemorySaver[plannerState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return runnable
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	gate := make(chan struct{})
+	started := make(chan struct{}, 2)
+	runnable := gatedRunnable(t, gate, started, func() error { return nil })
+	mux := server.NewMux()
+	if _, err := server.Handle(mux, "planner", runnable); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	for _, id := range []string{"t1", "t2"} {
+		call(t, ts, "POST", "/threads", map[string]any{"thread_id": id}, nil)
+	}
+	var run server.Run
+	call(t, ts, "POST", "/threads/t1/runs", map[string]any{"assistant_id": "planner"}, &run)
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- mux.Shutdown(context.Background(), 0)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for call(t, ts, "POST", "/threads/t2/runs", map[string]any{"assistant_id": "planner"}, nil) != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("expected runs to be rejected while shutting down")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The run pauses once plan ends.
+	close(gate)
+	if err := <-shutdown; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := poll(t, ts, run.RunID); result.Status != server.RunPaused {
+		t.Errorf("expected run status %s, but got %+v", server.RunPaused, result)
+	}
+	var thread server.Thread
+	call(t, ts, "GET", "/threads/t1", nil, &thread)
+	if thread.Status != server.ThreadIdle {
+		t.Errorf("expected thread status %s, but got %s", server.ThreadIdle, thread.Status)
+	}
+	cp, err := runnable.GetState(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cp.Next, []string{"act"}) || cp.Error != "" {
+		t.Errorf("expected a checkpoint paused before act, but got %+v", cp)
+	}
+
+	// The next server continues the thread with act.
+	srv, err := server.New("planner", runnable)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next := httptest.NewServer(srv)
+	t.Cleanup(next.Close)
+	call(t, next, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	var state plannerState
+	if status := call(t, next, "POST", "/threads/t1/runs/wait", map[string]any{"assistant_id": "planner"}, &state); status != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+	}
+	if state.Steps != 11 {
+		t.Errorf("expected 11 steps, but got %d", state.Steps)
+	}
+}
+
+func TestShutdownGrace(t *testing.T) {
+	t.Parallel()
+
+	gate := make(chan struct{})
+	started := make(chan struct{}, 1)
+	srv, err := server.New("planner", gatedRunnable(t, gate, started, func() error { return nil }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	var run server.Run
+	call(t, ts, "POST", "/runs", map[string]any{"assistant_id": "planner"}, &run)
+	<-started
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(context.Background(), 5*time.Second)
+	}()
+
+	// Runs finishing within the grace period are not paused.
+	close(gate)
+	if err := <-shutdown; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := poll(t, ts, run.RunID); result.Status != server.RunSuccess {
+		t.Errorf("expected run status %s, but got %+v", server.RunSuccess, result)
+	}
+}
+
+func TestShutdownCancel(t *testing.T) {
+	t.Parallel()
+
+	// plan never ends unless canceled.
+	started := make(chan struct{}, 1)
+	srv, err := server.New("planner", gatedRunnable(t, nil, started, func() error { return nil }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	var run server.Run
+	call(t, ts, "POST", "/runs", map[string]any{"assistant_id": "planner"}, &run)
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx, 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, but got %v", context.DeadlineExceeded, err)
+	}
+	if result := poll(t, ts, run.RunID); result.Status != server.RunPaused {
+		t.Errorf("expected run status %s, but got %+v", server.RunPaused, result)
+	}
+}
+
+func TestFailedRunStartsOver(t *testing.T) {
+	t.Parallel()
+
+	gate := make(chan struct{})
+	close(gate)
+	started := make(chan struct{}, 2)
+	failed := false
+	runnable := gatedRunnable(t, gate, started, func() error {
+		if !failed {
+			failed = true
+			return errors.New("act failed")
+		}
+		return nil
+	})
+	srv, err := server.New("planner", runnable)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	run := map[string]any{"assistant_id": "planner"}
+	if status := call(t, ts, "POST", "/threads/t1/runs/wait", run, nil); status != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, but got %d", http.StatusInternalServerError, status)
+	}
+	if cp, err := runnable.GetState(context.Background(), "t1"); err != nil || cp.Error == "" {
+		t.Errorf("expected a checkpoint of the failure, but got %+v, %v", cp, err)
+	}
+
+	// Unlike paused runs, failed runs are not continued.
+	var state plannerState
+	call(t, ts, "POST", "/threads/t1/runs/wait", run, &state)
+	if state.Steps != 12 {
+		t.Errorf("expected 12 steps, but got %d", state.Steps)
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph/streammode"
+)
+
+// eventStream writes server-sent events.
+type eventStream struct {
+	mu sync.Mutex
+	w  http.ResponseWriter
+}
+
+// newEventStream writes the headers of an event stream to w.
+func newEventStream(w http.ResponseWriter) *eventStream {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	s := &eventStream{w: w}
+	s.flush()
+	return s
+}
+
+// send writes an event with data encoded as JSON. Errors are dropped: a
+// client that went away cancels the request context, which ends the run.
+func (s *eventStream) send(event string, data any) {
+	b := eventBuffers.Get().(*bytes.Buffer)
+	defer putEventBuffer(b)
+	fmt.Fprintf(b, "event: %s\ndata: ", event)
+	if err := json.NewEncoder(b).Encode(data); err != nil {
+		b.Reset()
+		b.WriteString("event: error\ndata: ")
+		_ = json.NewEncoder(b).Encode(map[string]string{"error": "EncodingError", "message": err.Error()})
+	}
+	// The encoder ended the data with a newline, the blank line ends the
+	// event.
+	b.WriteByte('\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(b.Bytes())
+	s.flush()
+}
+
+// eventBuffers are the buffers events are encoded in, shared by the streams
+// so that busy servers do not allocate one per event.
+var eventBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxEventBuffer is the capacity above which a buffer is dropped rather
+// than kept for later events, so that a large state does not pin memory.
+const maxEventBuffer = 64 << 10
+
+func putEventBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxEventBuffer {
+		return
+	}
+	b.Reset()
+	eventBuffers.Put(b)
+}
+
+func (s *eventStream) flush() {
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// StreamMode selects events of stream runs.
+type StreamMode = streammode.Mode
+
+const (
+	// StreamValues sends the state after each node as a values event.
+	StreamValues = streammode.Values
+
+	// StreamUpdates sends the fields of the state each node changed as an
+	// updates event, {"node": {"field": value}}. States that are not JSON
+	// objects are sent whole.
+	StreamUpdates = streammode.Updates
+
+	// StreamMessages sends the changes each node made to the messages of a
+	// graph.MessageState state as messages events of
+	// [delta, {"langgraph_node": node}] pairs, see graph.MessageDeltas. When
+	// the changes cannot be sent as deltas, the state is sent as a values
+	// event instead.
+	StreamMessages = streammode.Messages
+)
+
+// checkStreamModes defaults the stream modes of req to values and checks
+// that they are known.
+func checkStreamModes(req *RunRequest) error {
+	if len(req.StreamMode) == 0 {
+		req.StreamMode = StreamModes{StreamValues}
+	}
+	for _, mode := range req.StreamMode {
+		if !slices.Contains(streammode.Modes, mode) {
+			return errorf(http.StatusUnprocessableEntity, "unknown stream mode %q", mode)
+		}
+	}
+	return nil
+}
+
+// StreamModes are the stream modes of a run. They are decoded from a mode or
+// a list of modes.
+type StreamModes []StreamMode
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *StreamModes) UnmarshalJSON(data []byte) error {
+	var mode StreamMode
+	if err := json.Unmarshal(data, &mode); err == nil {
+		*m = StreamModes{mode}
+		return nil
+	}
+	var modes []StreamMode
+	if err := json.Unmarshal(data, &modes); err != nil {
+		return fmt.Errorf("stream mode must be a string or a list of strings: %w", err)
+	}
+	*m = modes
+	return nil
+}
+
+// newStreamHandler returns the handler sending the events of the stream
+// modes of a run as server-sent events.
+func newStreamHandler[T any](send func(event string, data any), modes StreamModes) *streammode.Handler[T] {
+	// metadata is the metadata of the messages events of the last node,
+	// encoded by send before the next event.
+	var metadata map[string]string
+	return streammode.NewHandler[T](modes, func(e streammode.Event) {
+		switch e.Mode {
+		case StreamValues:
+			send("values", e.Data)
+		case StreamUpdates:
+			send("updates", map[string]any{e.Node: e.Data})
+		case StreamMessages:
+			if metadata["langgraph_node"] != e.Node {
+				metadata = map[string]string{"langgraph_node": e.Node}
+			}
+			send("messages", []any{e.Data, metadata})
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// store keeps the threads and runs of a server, or of the graphs of a Mux.
+type store struct {
+	// mu guards threads, runs and jobs, and the fields of their values.
+	mu      sync.Mutex
+	threads map[string]*Thread
+	runs    map[string]*Run
+	jobs    map[string]*job
+
+	// auth, if not nil, authorizes the access of requests to threads.
+	auth *Auth
+
+	// quota, if not nil, limits the requests and tokens of tenants.
+	quota *quota
+
+	// checkpointed, guarded by mu, report whether the checkpointers of the
+	// graphs served have checkpoints of a thread, such as of threads of a
+	// previous server, whose owners are not known.
+	checkpointed []func(ctx context.Context, id string) (bool, error)
+
+	// stopping, guarded by mu, is set by shutdown, after which no runs
+	// start. active counts the runs started and not finished. park is
+	// closed to pause the runs, and stop is canceled to cancel them.
+	stopping bool
+	active   sync.WaitGroup
+	park     chan struct{}
+	parkOnce sync.Once
+	stop     context.Context
+	cancel   context.CancelFunc
+}
+
+func newStore() *store {
+	s := &store{threads: make(map[string]*Thread), runs: make(map[string]*Run), jobs: make(map[string]*job), park: make(chan struct{})}
+	s.stop, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// handler returns h authenticating and limiting its requests as configured.
+func (s *store) handler(h http.Handler) http.Handler {
+	if s.quota != nil {
+		h = s.quota.limit(h)
+	}
+	if s.auth != nil {
+		h = Authenticate(h, s.auth.Authenticate)
+	}
+	return h
+}
+
+func (s *store) createThread(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ThreadID string         `json:"thread_id"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	thread, err := s.newThread(r.Context(), req.ThreadID, req.Metadata, ThreadIdle)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+// newThread creates a thread of the given status for the caller of ctx,
+// with a random ID if id is empty. With Auth, id must not have checkpoints: threads are owned in
+// memory only, and callers could otherwise take over the threads of a
+// previous server.
+func (s *store) newThread(ctx context.Context, id string, metadata map[string]any, status ThreadStatus) (Thread, error) {
+	if id == "" {
+		id = uuid.NewString()
+	} else if s.auth != nil {
+		s.mu.Lock()
+		checkpointed := s.checkpointed
+		s.mu.Unlock()
+		for _, has := range checkpointed {
+			ok, err := has(ctx, id)
+			if err != nil {
+				return Thread{}, err
+			}
+			if ok {
+				return Thread{}, errorf(http.StatusConflict, "thread %s already exists", id)
+			}
+		}
+	}
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	if user, ok := UserFrom(ctx); ok && s.auth != nil {
+		metadata["owner"] = user.Identity
+	}
+	now := time.Now().UTC()
+	thread := &Thread{ThreadID: id, CreatedAt: now, UpdatedAt: now, Metadata: metadata, Status: status}
+	if err := s.auth.authorize(ctx, ActionCreate, *thread); err != nil {
+		return Thread{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.threads[id]; ok {
+		return Thread{}, errorf(http.StatusConflict, "thread %s already exists", id)
+	}
+	s.threads[id] = thread
+	return *thread, nil
+}
+
+// searchThreads answers the threads the caller may read, most recently
+// updated first, with the status and metadata of the request, if any,
+// paginated by its limit, 10 by default, and offset.
+func (s *store) searchThreads(w http.ResponseWriter, r *http.Request) {
+	filter := threadFilter{Limit: 10}
+	if err := decodeBody(r, &filter); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.findThreads(r.Context(), filter))
+}
+
+func (s *store) getThread(w http.ResponseWriter, r *http.Request) {
+	thread, err := s.thread(r.Context(), r.PathValue("thread_id"), ActionRead)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+// thread returns a copy of the thread with the given ID, if the caller of
+// ctx may perform action on it.
+func (s *store) thread(ctx context.Context, id string, action Action) (Thread, error) {
+	s.mu.Lock()
+	thread, ok := s.threads[id]
+	var found Thread
+	if ok {
+		found = *thread
+	}
+	s.mu.Unlock()
+	if !ok {
+		return Thread{}, errorf(http.StatusNotFound, "thread %s not found", id)
+	}
+	if err := s.auth.authorize(ctx, action, found); err != nil {
+		return Thread{}, err
+	}
+	return found, nil
+}
+
+func (s *store) listRuns(w http.ResponseWriter, r *http.Request) {
+	thread, err := s.thread(r.Context(), r.PathValue("thread_id"), ActionRead)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	runs := []Run{}
+	for _, run := range s.runs {
+		if run.ThreadID == thread.ThreadID {
+			runs = append(runs, *run)
+		}
+	}
+	s.mu.Unlock()
+	// Latest first.
+	slices.SortFunc(runs, func(a, b Run) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *store) getRun(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.thread(r.Context(), r.PathValue("thread_id"), ActionRead); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	run, ok := s.runs[r.PathValue("run_id")]
+	var found Run
+	if ok && run.ThreadID == r.PathValue("thread_id") {
+		found = *run
+	}
+	s.mu.Unlock()
+	if found.RunID == "" {
+		writeError(w, errorf(http.StatusNotFound, "run %s not found", r.PathValue("run_id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// setStatus sets the status of run and of its thread.
+func (s *store) setStatus(run *Run, status RunStatus, threadStatus ThreadStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	run.Status = status
+	run.UpdatedAt = now
+	if thread, ok := s.threads[run.ThreadID]; ok {
+		thread.Status = threadStatus
+		thread.UpdatedAt = now
+	}
+}
+
+// bind binds thread to assistant, recording it in the metadata of the thread
+// as the LangGraph Platform does, unless it is bound already. It returns an
+// error if the thread is bound to another assistant, s.mu being held.
+func (s *store) bind(thread *Thread, assistant Assistant) error {
+	switch id, _ := thread.Metadata["assistant_id"].(string); id {
+	case assistant.AssistantID:
+		return nil
+	case "":
+	default:
+		return errorf(http.StatusConflict, "thread %s is bound to assistant %s", thread.ThreadID, id)
+	}
+	// The metadata of copies of the thread may be read without s.mu.
+	metadata := maps.Clone(thread.Metadata)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata["assistant_id"] = assistant.AssistantID
+	metadata["graph_id"] = assistant.GraphID
+	thread.Metadata = metadata
+	return nil
+}
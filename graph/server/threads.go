@@ -0,0 +1,288 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+)
+
+// threadFilter selects the threads answered by searches.
+type threadFilter struct {
+	// Status, if set, is the status of the threads.
+	Status ThreadStatus `json:"status"`
+
+	// Metadata holds values the metadata of the threads have, compared as
+	// JSON.
+	Metadata map[string]any `json:"metadata"`
+
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// findThreads returns the threads of filter the caller of ctx may read, most
+// recently updated first.
+func (s *store) findThreads(ctx context.Context, filter threadFilter) []Thread {
+	s.mu.Lock()
+	threads := []Thread{}
+	for _, thread := range s.threads {
+		if (filter.Status == "" || thread.Status == filter.Status) && hasMetadata(thread.Metadata, filter.Metadata) {
+			threads = append(threads, *thread)
+		}
+	}
+	s.mu.Unlock()
+	threads = slices.DeleteFunc(threads, func(thread Thread) bool {
+		return s.auth.authorize(ctx, ActionRead, thread) != nil
+	})
+	slices.SortFunc(threads, func(a, b Thread) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	threads = threads[min(max(filter.Offset, 0), len(threads)):]
+	return threads[:min(max(filter.Limit, 0), len(threads))]
+}
+
+// hasMetadata reports whether metadata has the values of want.
+func hasMetadata(metadata, want map[string]any) bool {
+	for key, value := range want {
+		v, ok := metadata[key]
+		if !ok {
+			return false
+		}
+		a, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		b, err := json.Marshal(value)
+		if err != nil || !bytes.Equal(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+// listThreads answers the threads the caller may read as searchThreads
+// does, with the status, limit and offset query parameters.
+func (s *store) listThreads(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := threadFilter{Status: ThreadStatus(query.Get("status")), Limit: 10}
+	for name, n := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if v := query.Get(name); v != "" {
+			var err error
+			if *n, err = strconv.Atoi(v); err != nil {
+				writeError(w, errorf(http.StatusUnprocessableEntity, "invalid %s %q", name, v))
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, s.findThreads(r.Context(), filter))
+}
+
+// patchThread merges the metadata of the request into the metadata of a
+// thread, removing the keys set to null, and answers the thread. The
+// assistant the thread is bound to and, with Auth, its owner are kept.
+func (s *store) patchThread(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	id := r.PathValue("thread_id")
+	if _, err := s.thread(r.Context(), id, ActionUpdate); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.mu.Lock()
+	thread, ok := s.threads[id]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, errorf(http.StatusNotFound, "thread %s not found", id))
+		return
+	}
+	// The metadata is replaced rather than changed, copies of the thread
+	// sharing it.
+	metadata := maps.Clone(thread.Metadata)
+	for key, value := range req.Metadata {
+		switch {
+		case key == "assistant_id" || key == "graph_id" || key == "owner" && s.auth != nil:
+		case value == nil:
+			delete(metadata, key)
+		default:
+			metadata[key] = value
+		}
+	}
+	thread.Metadata = metadata
+	thread.UpdatedAt = time.Now().UTC()
+	found := *thread
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, found)
+}
+
+// deleteThread deletes a thread that has no checkpoints, answering 204 No
+// Content.
+func (s *store) deleteThread(w http.ResponseWriter, r *http.Request) {
+	if err := s.removeThread(r.Context(), r.PathValue("thread_id"), nil); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeThread removes a thread that is not busy with its runs, if the
+// caller of ctx may delete it, once deleteCheckpoints, if not nil, deleted
+// its checkpoints. The thread is busy meanwhile, and kept if they are not
+// deleted so that its checkpoints keep their owner.
+func (s *store) removeThread(ctx context.Context, id string, deleteCheckpoints func() error) error {
+	if _, err := s.thread(ctx, id, ActionDelete); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	thread, ok := s.threads[id]
+	if !ok {
+		s.mu.Unlock()
+		return errorf(http.StatusNotFound, "thread %s not found", id)
+	}
+	if thread.Status == ThreadBusy {
+		s.mu.Unlock()
+		return errorf(http.StatusConflict, "thread %s is busy", id)
+	}
+	status := thread.Status
+	thread.Status = ThreadBusy
+	s.mu.Unlock()
+
+	if deleteCheckpoints != nil {
+		if err := deleteCheckpoints(); err != nil {
+			s.mu.Lock()
+			thread.Status = status
+			s.mu.Unlock()
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.threads, id)
+	for runID, run := range s.runs {
+		if run.ThreadID == id {
+			delete(s.runs, runID)
+			delete(s.jobs, runID)
+		}
+	}
+	return nil
+}
+
+// copyRequest is the request of POST /threads/{thread_id}/copy.
+type copyRequest struct {
+	// ThreadID is the ID of the copy, random by default.
+	ThreadID string `json:"thread_id"`
+
+	// CheckpointID, if set, forks the thread as of this checkpoint of its
+	// history.
+	CheckpointID string `json:"checkpoint_id"`
+}
+
+// copyThread copies a thread that has no checkpoints, see Server.copyThread.
+func (s *store) copyThread(w http.ResponseWriter, r *http.Request) {
+	var req copyRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	id := r.PathValue("thread_id")
+	src, err := s.thread(r.Context(), id, ActionRead)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.CheckpointID != "" {
+		writeError(w, errorf(http.StatusNotFound, "checkpoint %s of thread %s not found", req.CheckpointID, id))
+		return
+	}
+	thread, err := s.newThread(r.Context(), req.ThreadID, maps.Clone(src.Metadata), ThreadIdle)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+// copyThread copies a thread with its metadata and checkpoints to a new
+// thread, such as to explore another path of a conversation, and answers
+// it. The copy is owned by the caller.
+func (s *Server[T]) copyThread(w http.ResponseWriter, r *http.Request) {
+	var req copyRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	ctx := r.Context()
+	id := r.PathValue("thread_id")
+	src, err := s.thread(ctx, id, ActionRead)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	checkpointer := s.runnable.Checkpointer()
+	cps, err := checkpointer.List(ctx, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.CheckpointID != "" {
+		i := slices.IndexFunc(cps, func(cp graph.Checkpoint[T]) bool { return cp.ID == req.CheckpointID })
+		if i < 0 {
+			writeError(w, errorf(http.StatusNotFound, "checkpoint %s of thread %s not found", req.CheckpointID, id))
+			return
+		}
+		cps = cps[:i+1]
+	}
+	status := ThreadIdle
+	if len(cps) > 0 && cps[len(cps)-1].Interrupt != nil {
+		status = ThreadInterrupted
+	}
+	thread, err := s.newThread(ctx, req.ThreadID, maps.Clone(src.Metadata), status)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, cp := range cps {
+		cp.ID = uuid.NewString()
+		cp.ThreadID = thread.ThreadID
+		if err := checkpointer.Put(ctx, cp); err != nil {
+			s.mu.Lock()
+			delete(s.threads, thread.ThreadID)
+			s.mu.Unlock()
+			writeError(w, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+// deleteThread deletes a thread with its runs and checkpoints, answering
+// 204 No Content. Threads of graphs whose checkpointer cannot delete
+// checkpoints, see graph.ThreadDeleter, are answered with 501 Not
+// Implemented.
+func (s *Server[T]) deleteThread(w http.ResponseWriter, r *http.Request) {
+	deleter, ok := s.runnable.Checkpointer().(graph.ThreadDeleter)
+	if !ok {
+		writeError(w, errorf(http.StatusNotImplemented, "the checkpointer of graph %s cannot delete threads", s.assistant.GraphID))
+		return
+	}
+	ctx := r.Context()
+	id := r.PathValue("thread_id")
+	if err := s.removeThread(ctx, id, func() error { return deleter.Delete(ctx, id) }); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
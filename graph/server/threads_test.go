@@ -0,0 +1,160 @@
+package server_test
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/server"
+)
+
+func TestThreads(t *testing.T) {
+	t.Parallel()
+
+	ts := newChatServer(t, nil)
+	for id, user := range map[string]string{"t1": "ada", "t2": "bob", "t3": "ada"} {
+		call(t, ts, "POST", "/threads", map[string]any{"thread_id": id, "metadata": map[string]any{"user": user, "topic": "billing"}}, nil)
+	}
+
+	ids := func(threads []server.Thread) []string {
+		var ids []string
+		for _, thread := range threads {
+			ids = append(ids, thread.ThreadID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		count  int
+		want   []string
+	}{
+		{name: "Search by metadata", method: "POST", path: "/threads/search", body: map[string]any{"metadata": map[string]any{"user": "ada"}}, count: 2, want: []string{"t1", "t3"}},
+		{name: "Search by unknown metadata", method: "POST", path: "/threads/search", body: map[string]any{"metadata": map[string]any{"user": "eve"}}},
+		{name: "Search page", method: "POST", path: "/threads/search", body: map[string]any{"limit": 2, "offset": 2}, count: 1},
+		{name: "List", method: "GET", path: "/threads", count: 3, want: []string{"t1", "t2", "t3"}},
+		{name: "List page", method: "GET", path: "/threads?limit=1&offset=1&status=idle", count: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var threads []server.Thread
+			if status := call(t, ts, tt.method, tt.path, tt.body, &threads); status != http.StatusOK {
+				t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+			}
+			if len(threads) != tt.count || tt.want != nil && !slices.Equal(ids(threads), tt.want) {
+				t.Errorf("expected %d threads %v, but got %v", tt.count, tt.want, ids(threads))
+			}
+		})
+	}
+	if status := call(t, ts, "GET", "/threads?limit=all", nil, nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d for an invalid limit, but got %d", http.StatusUnprocessableEntity, status)
+	}
+
+	// Metadata is merged, null removing keys.
+	var thread server.Thread
+	call(t, ts, "PATCH", "/threads/t1", map[string]any{"metadata": map[string]any{"topic": nil, "tier": "pro"}}, &thread)
+	if want := map[string]any{"user": "ada", "tier": "pro"}; !maps.Equal(thread.Metadata, want) {
+		t.Errorf("expected metadata %v, but got %v", want, thread.Metadata)
+	}
+
+	// A copy of the interrupted thread is resumed apart from it.
+	call(t, ts, "POST", "/threads/t1/runs/wait", map[string]any{"assistant_id": "agent", "input": chatState{Messages: []string{"hi"}}}, nil)
+	var fork server.Thread
+	if status := call(t, ts, "POST", "/threads/t1/copy", map[string]any{"thread_id": "fork"}, &fork); status != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, status)
+	}
+	if fork.Status != server.ThreadInterrupted || fork.Metadata["tier"] != "pro" {
+		t.Errorf("expected an interrupted copy of t1, but got %+v", fork)
+	}
+	call(t, ts, "GET", "/threads/fork", nil, &fork)
+	if fork.Status != server.ThreadInterrupted {
+		t.Errorf("expected thread fork %s, but got %s", server.ThreadInterrupted, fork.Status)
+	}
+	var values chatState
+	call(t, ts, "POST", "/threads/fork/runs/wait", map[string]any{"assistant_id": "agent", "command": map[string]any{"resume": "bob"}}, &values)
+	call(t, ts, "POST", "/threads/t1/runs/wait", map[string]any{"assistant_id": "agent", "command": map[string]any{"resume": "ada"}}, nil)
+	if want := []string{"hi", "name: bob", "hello"}; !slices.Equal(values.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, values.Messages)
+	}
+
+	// A fork from the first checkpoint of t1 is interrupted again.
+	var history []server.ThreadState[chatState]
+	call(t, ts, "POST", "/threads/t1/history", map[string]any{}, &history)
+	first := history[len(history)-1].Checkpoint.CheckpointID
+	call(t, ts, "POST", "/threads/t1/copy", map[string]any{"thread_id": "retry", "checkpoint_id": first}, &fork)
+	var state server.ThreadState[chatState]
+	call(t, ts, "GET", "/threads/retry/state", nil, &state)
+	if fork.Status != server.ThreadInterrupted || !slices.Equal(state.Values.Messages, []string{"hi"}) {
+		t.Errorf("expected a fork interrupted before ask, but got %+v %+v", fork, state)
+	}
+	if status := call(t, ts, "POST", "/threads/t1/copy", map[string]any{"checkpoint_id": "missing"}, nil); status != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown checkpoint, but got %d", http.StatusNotFound, status)
+	}
+
+	// Deleted threads are gone with their runs and checkpoints.
+	if status := call(t, ts, "DELETE", "/threads/t1", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("expected status %d, but got %d", http.StatusNoContent, status)
+	}
+	for _, path := range []string{"/threads/t1", "/threads/t1/runs"} {
+		if status := call(t, ts, "GET", path, nil, nil); status != http.StatusNotFound {
+			t.Errorf("expected status %d for %s, but got %d", http.StatusNotFound, path, status)
+		}
+	}
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	call(t, ts, "POST", "/threads/t1/history", map[string]any{}, &history)
+	if len(history) != 0 {
+		t.Errorf("expected no checkpoints, but got %+v", history)
+	}
+}
+
+// listOnly is a checkpointer that cannot delete threads.
+type listOnly struct {
+	graph.Checkpointer[plannerState]
+}
+
+func TestThreadsDelete(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[plannerState]()
+	g.AddNode("plan", func(_ context.Context, s *plannerState) error {
+		s.Steps++
+		return nil
+	})
+	g.AddEdge("plan", graph.END)
+	g.SetEntryPoint("plan")
+	runnable, err := g.Compile(graph.WithCheckpointer[plannerState](listOnly{graph.NewMemorySaver[plannerState]()}))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	mux := server.NewMux()
+	if _, err := server.Handle(mux, "planner", runnable); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	for _, id := range []string{"unbound", "bound"} {
+		call(t, ts, "POST", "/threads", map[string]any{"thread_id": id}, nil)
+	}
+	call(t, ts, "POST", "/threads/bound/runs/wait", map[string]any{"assistant_id": "planner"}, nil)
+	tests := []struct {
+		thread string
+		want   int
+	}{
+		{thread: "unbound", want: http.StatusNoContent},
+		{thread: "bound", want: http.StatusNotImplemented},
+		{thread: "missing", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if status := call(t, ts, "DELETE", "/threads/"+tt.thread, nil, nil); status != tt.want {
+			t.Errorf("expected status %d deleting thread %s, but got %d", tt.want, tt.thread, status)
+		}
+	}
+}
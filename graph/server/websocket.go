@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{}
+
+// wsMessage is a message of a WebSocket client.
+type wsMessage struct {
+	Type string `json:"type"`
+	RunRequest
+	Resume json.RawMessage `json:"resume,omitempty"`
+}
+
+// wsStream sends events over a WebSocket connection.
+type wsStream struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// send writes an event with data. Errors are dropped: a client that went
+// away cancels the run.
+func (s *wsStream) send(event string, data any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.conn.WriteJSON(map[string]any{"event": event, "data": data})
+}
+
+// runsWebSocket serves GET /threads/{thread_id}/runs/ws, which runs the
+// graph interactively over one connection. The client sends JSON messages:
+//
+//	{"type": "run", ...}             starts a run, the other fields being a RunRequest
+//	{"type": "resume", "resume": v}  resumes the interrupted run with v
+//	{"type": "cancel"}               cancels the current run
+//
+// and receives the events of the runs as {"event": name, "data": data}: a
+// metadata event, the events of the stream modes (see StreamMode), and one
+// of end, interrupt or error. Resumed runs keep the assistant and stream
+// modes of the previous run. Runs are rejected while one is in progress.
+// Closing the connection cancels the current run.
+func (s *Server[T]) runsWebSocket(w http.ResponseWriter, r *http.Request) {
+	thread, err := s.thread(r.Context(), r.PathValue("thread_id"), ActionUpdate)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade answered the request.
+		return
+	}
+	defer conn.Close()
+	stream := &wsStream{conn: conn}
+
+	// Messages are read concurrently with the runs, so that runs can be
+	// canceled; stop cancels the current run and is nil between runs. The
+	// runs are authorized for the caller of the request.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+	var (
+		mu   sync.Mutex
+		stop context.CancelFunc
+	)
+	requests := make(chan wsRequest)
+	go func() {
+		defer cancel()
+		defer close(requests)
+		for {
+			var msg wsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				var syntaxErr *json.SyntaxError
+				var typeErr *json.UnmarshalTypeError
+				if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+					stream.send("error", map[string]string{"error": "InvalidMessage", "message": err.Error()})
+					continue
+				}
+				return
+			}
+			mu.Lock()
+			switch {
+			case msg.Type == "cancel":
+				if stop != nil {
+					stop()
+				}
+				mu.Unlock()
+				continue
+			case stop != nil:
+				mu.Unlock()
+				stream.send("error", map[string]string{"error": "InvalidMessage", "message": "a run is in progress"})
+				continue
+			}
+			var runCtx context.Context
+			runCtx, stop = context.WithCancel(ctx)
+			mu.Unlock()
+			requests <- wsRequest{ctx: runCtx, msg: msg}
+		}
+	}()
+
+	var last RunRequest
+	for req := range requests {
+		if run, ok := s.wsRun(req.ctx, stream, thread.ThreadID, req.msg, last); ok {
+			last = run
+		}
+		mu.Lock()
+		stop()
+		stop = nil
+		mu.Unlock()
+	}
+}
+
+// wsRequest is a message starting a run, with the context of the run.
+type wsRequest struct {
+	ctx context.Context
+	msg wsMessage
+}
+
+// wsRun runs the graph as requested by msg, last being the request of the
+// previous run, and returns the request of the run, reporting whether it
+// started.
+func (s *Server[T]) wsRun(ctx context.Context, stream *wsStream, threadID string, msg wsMessage, last RunRequest) (RunRequest, bool) {
+	var req RunRequest
+	switch msg.Type {
+	case "run":
+		req = msg.RunRequest
+	case "resume":
+		req = RunRequest{AssistantID: last.AssistantID, StreamMode: last.StreamMode, Command: &Command{Resume: msg.Resume}}
+	default:
+		stream.send("error", map[string]string{"error": "InvalidMessage", "message": "unknown message type " + msg.Type})
+		return req, false
+	}
+	if err := checkStreamModes(&req); err != nil {
+		stream.send("error", map[string]string{"error": "InvalidRequest", "message": err.Error()})
+		return req, false
+	}
+	run, exec, err := s.startRun(ctx, threadID, req)
+	if err != nil {
+		stream.send("error", map[string]string{"error": "InvalidRequest", "message": err.Error()})
+		return req, false
+	}
+
+	stream.send("metadata", map[string]any{"run_id": run.RunID, "thread_id": run.ThreadID, "attempt": 1})
+	_, err = exec(ctx, graph.WithRunCallbacks[T](newStreamHandler[T](stream.send, req.StreamMode)))
+	var gi *graph.GraphInterrupt
+	switch {
+	case err == nil:
+		stream.send("end", map[string]string{"run_id": run.RunID, "status": string(RunSuccess)})
+	case errors.As(err, &gi):
+		in := interruptOf(gi)
+		stream.send("interrupt", map[string]any{"run_id": run.RunID, "node": gi.Node, "value": in.Value, "when": in.When})
+	default:
+		stream.send("error", map[string]string{"run_id": run.RunID, "error": "RunError", "message": err.Error()})
+	}
+	return req, true
+}
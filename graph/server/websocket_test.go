@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/gorilla/websocket"
+)
+
+// dial opens the WebSocket of the thread.
+func dial(t *testing.T, ts *httptest.Server, threadID string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/threads/" + threadID + "/runs/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+type wsEvent struct {
+	Event string         `json:"event"`
+	Data  map[string]any `json:"data"`
+}
+
+// expectEvents reads events and checks their names.
+func expectEvents(t *testing.T, conn *websocket.Conn, names ...string) []wsEvent {
+	t.Helper()
+
+	var events []wsEvent
+	for _, name := range names {
+		var event wsEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event.Event != name {
+			t.Fatalf("expected event %s, but got %s %v", name, event.Event, event.Data)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestServerWebSocket(t *testing.T) {
+	t.Parallel()
+
+	ts := newChatServer(t, nil)
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	conn := dial(t, ts, "t1")
+
+	err := conn.WriteJSON(map[string]any{"type": "run", "assistant_id": "agent", "input": chatState{Messages: []string{"hi"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events := expectEvents(t, conn, "metadata", "interrupt")
+	if v := events[1].Data["value"]; v != "what is your name?" {
+		t.Errorf("expected interrupt value %q, but got %v", "what is your name?", v)
+	}
+
+	if err := conn.WriteJSON(map[string]any{"type": "resume", "resume": "ada"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events = expectEvents(t, conn, "metadata", "values", "values", "end")
+	if got, want := fmt.Sprint(events[2].Data["messages"]), "[hi name: ada hello]"; got != want {
+		t.Errorf("expected messages %s, but got %s", want, got)
+	}
+}
+
+func TestServerWebSocketCancel(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[plannerState]()
+	g.AddNode("plan", func(ctx context.Context, _ *plannerState) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.AddEdge("plan", graph.END)
+	g.SetEntryPoint("plan")
+	ts := serve(t, g)
+	call(t, ts, "POST", "/threads", map[string]any{"thread_id": "t1"}, nil)
+	conn := dial(t, ts, "t1")
+
+	run := map[string]any{"type": "run", "assistant_id": "agent"}
+	if err := conn.WriteJSON(run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectEvents(t, conn, "metadata")
+
+	// Runs are rejected while one is in progress.
+	if err := conn.WriteJSON(run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events := expectEvents(t, conn, "error")
+	if msg := events[0].Data["message"]; msg != "a run is in progress" {
+		t.Errorf("expected a run in progress, but got %v", msg)
+	}
+
+	if err := conn.WriteJSON(map[string]any{"type": "cancel"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events = expectEvents(t, conn, "error")
+	if msg, _ := events[0].Data["message"].(string); !strings.Contains(msg, context.Canceled.Error()) {
+		t.Errorf("expected a canceled run, but got %q", msg)
+	}
+}
+
+func TestServerWebSocketUnknownThread(t *testing.T) {
+	t.Parallel()
+
+	ts := newChatServer(t, nil)
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/threads/missing/runs/ws"
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %d, but got %v", http.StatusNotFound, err)
+	}
+}
@@ -0,0 +1,152 @@
+// Package streammode computes the events of the stream modes of runs — the
+// states, updates and message deltas clients of graph servers subscribe to —
+// so that the servers of packages graph/server and graph/remote stream the
+// same events:
+//
+//	h := streammode.NewHandler[State]([]streammode.Mode{streammode.Updates}, send)
+//	err := runnable.Invoke(ctx, &state, graph.WithRunCallbacks[State](h))
+package streammode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+// Mode selects the events of a run.
+type Mode string
+
+const (
+	// Values sends the state after each node.
+	Values Mode = "values"
+
+	// Updates sends the fields of the state each node changed. States that
+	// are not JSON objects are sent whole.
+	Updates Mode = "updates"
+
+	// Messages sends the changes each node made to the messages of a
+	// graph.MessageState state as deltas, see graph.MessageDeltas. When the
+	// changes cannot be sent as deltas, the state is sent as a Values event
+	// instead.
+	Messages Mode = "messages"
+)
+
+// Modes are the known stream modes.
+var Modes = []Mode{Values, Updates, Messages}
+
+// Event is an event of a stream mode, sent after a node succeeded.
+type Event struct {
+	Node string
+	Mode Mode
+
+	// Data is the state for Values events; the fields of the state the node
+	// changed, a map[string]json.RawMessage, or the state if it is not a JSON
+	// object, for Updates events; and a graph.MessageDelta for Messages
+	// events.
+	Data any
+}
+
+// Handler is a graph.CallbackHandler sending the events of its stream modes
+// after each node that succeeded.
+type Handler[T any] struct {
+	send  func(Event)
+	modes []Mode
+
+	// before holds the fields and messages of the state when each running
+	// node started.
+	mu     sync.Mutex
+	before map[string]nodeStart
+}
+
+var _ graph.CallbackHandler[struct{}] = (*Handler[struct{}])(nil)
+
+type nodeStart struct {
+	fields   map[string]json.RawMessage
+	messages []graph.Message
+}
+
+// NewHandler returns a Handler sending the events of modes to send. Unknown
+// modes are ignored.
+func NewHandler[T any](modes []Mode, send func(Event)) *Handler[T] {
+	return &Handler[T]{send: send, modes: modes, before: make(map[string]nodeStart)}
+}
+
+// NodeStart implements graph.CallbackHandler, keeping what the events of the
+// node are computed from.
+func (h *Handler[T]) NodeStart(_ context.Context, node string, state *T) {
+	var start nodeStart
+	if slices.Contains(h.modes, Updates) {
+		start.fields, _ = fieldsOf(state)
+	}
+	if ms, ok := any(state).(*graph.MessageState); ok && slices.Contains(h.modes, Messages) {
+		start.messages = ms.Clone().Messages
+	}
+	h.mu.Lock()
+	h.before[node] = start
+	h.mu.Unlock()
+}
+
+// NodeEnd implements graph.CallbackHandler, sending the events of the node
+// unless it failed.
+func (h *Handler[T]) NodeEnd(_ context.Context, node string, state *T, err error) {
+	h.mu.Lock()
+	start := h.before[node]
+	delete(h.before, node)
+	h.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	for _, mode := range h.modes {
+		switch mode {
+		case Values:
+			h.send(Event{Node: node, Mode: Values, Data: state})
+		case Updates:
+			fields, ok := fieldsOf(state)
+			if !ok || start.fields == nil {
+				h.send(Event{Node: node, Mode: Updates, Data: state})
+				continue
+			}
+			update := make(map[string]json.RawMessage)
+			for name, value := range fields {
+				if !bytes.Equal(start.fields[name], value) {
+					update[name] = value
+				}
+			}
+			h.send(Event{Node: node, Mode: Updates, Data: update})
+		case Messages:
+			ms, ok := any(state).(*graph.MessageState)
+			if !ok {
+				continue
+			}
+			deltas, ok := graph.MessageDeltas(start.messages, ms.Messages)
+			if !ok {
+				if !slices.Contains(h.modes, Values) {
+					h.send(Event{Node: node, Mode: Values, Data: state})
+				}
+				continue
+			}
+			for _, d := range deltas {
+				h.send(Event{Node: node, Mode: Messages, Data: d})
+			}
+		}
+	}
+}
+
+// fieldsOf returns the encoded fields of state, and false if it does not
+// encode to a JSON object.
+func fieldsOf(state any) (map[string]json.RawMessage, bool) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, false
+	}
+	return fields, true
+}
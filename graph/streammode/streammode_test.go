@@ -0,0 +1,72 @@
+package streammode_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/streammode"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type counterState struct {
+	Count int    `json:"count"`
+	Label string `json:"label"`
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	var events []streammode.Event
+	h := streammode.NewHandler[counterState]([]streammode.Mode{streammode.Updates, streammode.Values}, func(e streammode.Event) {
+		events = append(events, e)
+	})
+	state := counterState{Label: "a"}
+	h.NodeStart(context.Background(), "inc", &state)
+	state.Count++
+	h.NodeEnd(context.Background(), "inc", &state, nil)
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, but got %d", len(events))
+	}
+	update, err := json.Marshal(events[0].Data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if events[0].Node != "inc" || events[0].Mode != streammode.Updates || string(update) != `{"count":1}` {
+		t.Errorf("expected the update of inc, but got %+v encoded as %s", events[0], update)
+	}
+	if events[1].Mode != streammode.Values || events[1].Data != &state {
+		t.Errorf("expected the state, but got %+v", events[1])
+	}
+
+	events = nil
+	h.NodeStart(context.Background(), "fail", &state)
+	h.NodeEnd(context.Background(), "fail", &state, context.Canceled)
+	if len(events) != 0 {
+		t.Errorf("expected no events for a failed node, but got %+v", events)
+	}
+}
+
+func TestHandlerMessages(t *testing.T) {
+	t.Parallel()
+
+	var events []streammode.Event
+	h := streammode.NewHandler[graph.MessageState]([]streammode.Mode{streammode.Messages}, func(e streammode.Event) {
+		events = append(events, e)
+	})
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	h.NodeStart(context.Background(), "reply", &state)
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "hello"))
+	h.NodeEnd(context.Background(), "reply", &state, nil)
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, but got %d", len(events))
+	}
+	d, ok := events[0].Data.(graph.MessageDelta)
+	if !ok || events[0].Mode != streammode.Messages || d.ID != state.LastMessage().ID || d.Role != llms.ChatMessageTypeAI || len(d.Parts) != 1 {
+		t.Errorf("expected the delta of the reply, but got %+v", events[0])
+	}
+}
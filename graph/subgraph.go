@@ -0,0 +1,24 @@
+package graph
+
+import "context"
+
+// AddSubgraph adds a node running the compiled graph sub on the state, such
+// as to reuse a graph as a step of larger graphs while keeping it a unit,
+// unlike Merge. Every run of the node invokes sub from its entry point. The
+// node is drawn as a single node unless drawings expand subgraphs, see
+// WithXray. Options are those of AddNode.
+//
+// It returns a reference to the node, or ErrEmptyName or ErrDuplicateNode,
+// leaving the graph unchanged.
+func (g *StateGraph[T]) AddSubgraph(name string, sub *Runnable[T], opts ...NodeOption) (NodeRef[T], error) {
+	ref, err := g.AddNode(name, func(ctx context.Context, state *T) error {
+		return sub.Invoke(ctx, state)
+	}, opts...)
+	if err != nil {
+		return NodeRef[T]{}, err
+	}
+	node := g.nodes[name]
+	node.Subgraph = sub.Graph
+	g.nodes[name] = node
+	return ref, nil
+}
@@ -0,0 +1,83 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestAddSubgraph(t *testing.T) {
+	t.Parallel()
+
+	research := graph.NewStateGraph[int]()
+	research.AddNode("search", func(_ context.Context, n *int) error {
+		*n *= 10
+		return nil
+	})
+	research.AddEdge("search", graph.END)
+	research.SetEntryPoint("search")
+	sub, err := research.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	g := graph.NewStateGraph[int]()
+	g.AddNode("plan", func(_ context.Context, n *int) error {
+		*n++
+		return nil
+	})
+	if _, err := g.AddSubgraph("research", sub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := g.AddSubgraph("research", sub); err == nil {
+		t.Error("expected an error for a duplicate node")
+	}
+	g.AddEdge("plan", "research")
+	g.AddEdge("research", graph.END)
+	g.SetEntryPoint("plan")
+
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	n := 0
+	if err := runnable.Invoke(context.Background(), &n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 10 {
+		t.Errorf("expected 10, but got %d", n)
+	}
+
+	want := `graph TD;
+	n0(["START"]);
+	n1["plan"];
+	n2["research"];
+	n3(["END"]);
+	n0 --> n1;
+	n1 --> n2;
+	n2 --> n3;
+`
+	if got := g.DrawMermaid(); got != want {
+		t.Errorf("expected diagram\n%s\nbut got\n%s", want, got)
+	}
+
+	want = `graph TD;
+	n0(["START"]);
+	n1["plan"];
+	subgraph n2["research"]
+		n3(["START"]);
+		n4["search"];
+		n5(["END"]);
+		n3 --> n4;
+		n4 --> n5;
+	end
+	n6(["END"]);
+	n0 --> n1;
+	n1 --> n2;
+	n2 --> n6;
+`
+	if got := g.DrawMermaid(graph.WithXray()); got != want {
+		t.Errorf("expected diagram\n%s\nbut got\n%s", want, got)
+	}
+}
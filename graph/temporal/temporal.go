@@ -0,0 +1,209 @@
+// Package temporal runs compiled graphs as Temporal workflows for durable
+// execution: every run is a workflow and every node an activity, so that
+// nodes are retried by Temporal and runs outlive the failure of workers,
+// including runs waiting long for a human to resume them.
+//
+// Workers register the workflow and activity of a graph, which needs no
+// checkpointer, its state being kept in the workflow history:
+//
+//	g := temporal.New("agent", runnable)
+//	w := worker.New(c, "agents", worker.Options{})
+//	g.Register(w)
+//	...
+//	run, err := g.Start(ctx, c, client.StartWorkflowOptions{ID: "thread", TaskQueue: "agents"}, state)
+//
+// A run interrupted by a node waits for the ResumeSignal, sent with Resume,
+// and the latest checkpoint of a run is queried with GetState.
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// ResumeSignal is the signal resuming an interrupted run with its value,
+	// see graph.WithResume.
+	ResumeSignal = "resume"
+
+	// StateQuery is the query returning the latest checkpoint of a run.
+	StateQuery = "state"
+)
+
+// ErrInterruptTimeout is returned by runs left interrupted for longer than
+// the interrupt timeout, see WithInterruptTimeout.
+var ErrInterruptTimeout = errors.New("interrupted run was not resumed in time")
+
+// Option configures a Graph.
+type Option func(*options)
+
+type options struct {
+	activity         workflow.ActivityOptions
+	interruptTimeout time.Duration
+}
+
+// WithActivityOptions sets the options of the activities running the nodes,
+// such as their timeouts and retry policy. The nodes time out after ten
+// minutes by default and are retried as by default in Temporal.
+func WithActivityOptions(opts workflow.ActivityOptions) Option {
+	return func(o *options) {
+		o.activity = opts
+	}
+}
+
+// WithInterruptTimeout fails the runs left interrupted for longer than d
+// with ErrInterruptTimeout. Interrupted runs wait for ever by default.
+func WithInterruptTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.interruptTimeout = d
+	}
+}
+
+// Graph is a graph run by Temporal workers.
+type Graph[T any] struct {
+	name     string
+	runnable *graph.Runnable[T]
+	opts     options
+}
+
+// New returns the Graph running runnable under the workflow type name, its
+// activity being named name + ".step".
+func New[T any](name string, runnable *graph.Runnable[T], opts ...Option) *Graph[T] {
+	g := &Graph[T]{
+		name:     name,
+		runnable: runnable,
+		opts:     options{activity: workflow.ActivityOptions{StartToCloseTimeout: 10 * time.Minute}},
+	}
+	for _, opt := range opts {
+		opt(&g.opts)
+	}
+	return g
+}
+
+// Registry registers workflows and activities, such as a worker.Worker.
+type Registry interface {
+	RegisterWorkflowWithOptions(w any, options workflow.RegisterOptions)
+	RegisterActivityWithOptions(a any, options activity.RegisterOptions)
+}
+
+// Register registers the workflow and activity of the graph with r.
+func (g *Graph[T]) Register(r Registry) {
+	r.RegisterWorkflowWithOptions(g.Workflow, workflow.RegisterOptions{Name: g.name})
+	r.RegisterActivityWithOptions(g.Step, activity.RegisterOptions{Name: g.stepName()})
+}
+
+func (g *Graph[T]) stepName() string {
+	return g.name + ".step"
+}
+
+// StepInput is the input of the activity running a node.
+type StepInput[T any] struct {
+	// Checkpoint is the latest checkpoint of the run, with a zero ID when the
+	// run starts.
+	Checkpoint graph.Checkpoint[T]
+
+	// Resumed is set when the interrupted run is resumed with Resume.
+	Resumed bool
+	Resume  any
+}
+
+// Workflow is the workflow running the graph from state and returning the
+// final state. The ID of the workflow is the thread of its checkpoints.
+func (g *Graph[T]) Workflow(ctx workflow.Context, state T) (T, error) {
+	cp := graph.Checkpoint[T]{ThreadID: workflow.GetInfo(ctx).WorkflowExecution.ID, State: state}
+	err := workflow.SetQueryHandler(ctx, StateQuery, func() (graph.Checkpoint[T], error) {
+		return cp, nil
+	})
+	if err != nil {
+		return state, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, g.opts.activity)
+	resume := workflow.GetSignalChannel(ctx, ResumeSignal)
+
+	in := StepInput[T]{Checkpoint: cp}
+	for {
+		var out graph.Checkpoint[T]
+		if err := workflow.ExecuteActivity(ctx, g.stepName(), in).Get(ctx, &out); err != nil {
+			return cp.State, err
+		}
+		cp = out
+		in = StepInput[T]{Checkpoint: cp}
+		switch {
+		case cp.Interrupt != nil:
+			value, err := g.waitResume(ctx, resume)
+			if err != nil {
+				return cp.State, err
+			}
+			in.Resumed, in.Resume = true, value
+		case len(cp.Next) == 0:
+			return cp.State, nil
+		}
+	}
+}
+
+// waitResume waits for the ResumeSignal and returns its value.
+func (g *Graph[T]) waitResume(ctx workflow.Context, resume workflow.ReceiveChannel) (any, error) {
+	var value any
+	timedOut := false
+	sel := workflow.NewSelector(ctx)
+	sel.AddReceive(resume, func(c workflow.ReceiveChannel, _ bool) {
+		c.Receive(ctx, &value)
+	})
+	if g.opts.interruptTimeout > 0 {
+		timerCtx, cancel := workflow.WithCancel(ctx)
+		defer cancel()
+		sel.AddFuture(workflow.NewTimer(timerCtx, g.opts.interruptTimeout), func(workflow.Future) {
+			timedOut = true
+		})
+	}
+	sel.Select(ctx)
+	if timedOut {
+		return nil, fmt.Errorf("%w: %s", ErrInterruptTimeout, g.opts.interruptTimeout)
+	}
+	return value, nil
+}
+
+// Step is the activity running the next node of a run, see
+// graph.Runnable.Step. Interrupts are returned in the checkpoint.
+func (g *Graph[T]) Step(ctx context.Context, in StepInput[T]) (graph.Checkpoint[T], error) {
+	var opts []graph.InvokeOption
+	if in.Resumed {
+		opts = append(opts, graph.WithResume(in.Resume))
+	}
+	cp, err := g.runnable.Step(ctx, in.Checkpoint, opts...)
+	var gi *graph.GraphInterrupt
+	if err != nil && !errors.As(err, &gi) {
+		return graph.Checkpoint[T]{}, err
+	}
+	return cp, nil
+}
+
+// Start starts a run of the graph from state with c.
+func (g *Graph[T]) Start(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, state T) (client.WorkflowRun, error) {
+	return c.ExecuteWorkflow(ctx, opts, g.name, state)
+}
+
+// Resume resumes the interrupted run of the workflow with value.
+func (g *Graph[T]) Resume(ctx context.Context, c client.Client, workflowID string, value any) error {
+	return c.SignalWorkflow(ctx, workflowID, "", ResumeSignal, value)
+}
+
+// GetState returns the latest checkpoint of the run of the workflow.
+func (g *Graph[T]) GetState(ctx context.Context, c client.Client, workflowID string) (graph.Checkpoint[T], error) {
+	v, err := c.QueryWorkflow(ctx, workflowID, "", StateQuery)
+	if err != nil {
+		return graph.Checkpoint[T]{}, err
+	}
+	var cp graph.Checkpoint[T]
+	if err := v.Get(&cp); err != nil {
+		return graph.Checkpoint[T]{}, err
+	}
+	return cp, nil
+}
@@ -0,0 +1,111 @@
+package temporal_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/temporal"
+	sdktemporal "go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+type greetState struct {
+	Messages []string `json:"messages"`
+}
+
+// newGraph builds a graph asking for a name with Interrupt, then greeting it
+// with a node failing the first time it runs.
+func newGraph(t *testing.T, opts ...temporal.Option) *temporal.Graph[greetState] {
+	t.Helper()
+
+	var greets atomic.Int32
+	g := graph.NewStateGraph[greetState]()
+	g.AddNode("ask", func(ctx context.Context, s *greetState) error {
+		name, err := graph.Interrupt(ctx, "what is your name?")
+		if err != nil {
+			return err
+		}
+		s.Messages = append(s.Messages, "name: "+name.(string))
+		return nil
+	})
+	g.AddNode("greet", func(_ context.Context, s *greetState) error {
+		if greets.Add(1) == 1 {
+			return errors.New("unavailable")
+		}
+		s.Messages = append(s.Messages, "hello")
+		return nil
+	})
+	g.AddEdge("ask", "greet")
+	g.AddEdge("greet", graph.END)
+	g.SetEntryPoint("ask")
+	runnable, err := g.Compile()
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	opts = append([]temporal.Option{temporal.WithActivityOptions(workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         &sdktemporal.RetryPolicy{InitialInterval: time.Millisecond},
+	})}, opts...)
+	return temporal.New("greeter", runnable, opts...)
+}
+
+func TestWorkflow(t *testing.T) {
+	t.Parallel()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	g := newGraph(t)
+	g.Register(env)
+
+	env.RegisterDelayedCallback(func() {
+		v, err := env.QueryWorkflow(temporal.StateQuery)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+		var cp graph.Checkpoint[greetState]
+		if err := v.Get(&cp); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if cp.Interrupt == nil || cp.Interrupt.Value != "what is your name?" || !slices.Equal(cp.Next, []string{"ask"}) {
+			t.Errorf("expected an interrupt in ask, but got %+v", cp)
+		}
+		env.SignalWorkflow(temporal.ResumeSignal, "ada")
+	}, time.Hour)
+	env.ExecuteWorkflow("greeter", greetState{Messages: []string{"hi"}})
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("expected the workflow to complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var state greetState
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"hi", "name: ada", "hello"}; !slices.Equal(state.Messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, state.Messages)
+	}
+}
+
+func TestWorkflowInterruptTimeout(t *testing.T) {
+	t.Parallel()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	newGraph(t, temporal.WithInterruptTimeout(24*time.Hour)).Register(env)
+
+	env.ExecuteWorkflow("greeter", greetState{})
+	err := env.GetWorkflowError()
+	if err == nil || !strings.Contains(err.Error(), temporal.ErrInterruptTimeout.Error()) {
+		t.Errorf("expected error %v, but got %v", temporal.ErrInterruptTimeout, err)
+	}
+}
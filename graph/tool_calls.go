@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+var (
+	// ErrToolCallNotFound is returned when adding the result of a tool call
+	// that is not pending.
+	ErrToolCallNotFound = errors.New("no pending tool call")
+
+	// ErrMissingToolResults is returned by ValidateToolCalls when tool calls
+	// have no result.
+	ErrMissingToolResults = errors.New("tool calls without results")
+
+	// ErrUnexpectedToolResult is returned by ValidateToolCalls when a tool
+	// result does not answer a preceding tool call.
+	ErrUnexpectedToolResult = errors.New("tool result without a tool call")
+)
+
+// PendingToolCalls returns the tool calls of AI messages that have no result
+// yet, in order.
+func (s *MessageState) PendingToolCalls() []llms.ToolCall {
+	pending, _ := s.scanToolCalls()
+	return pending
+}
+
+// AddToolResult answers the pending tool call with the given ID, appending a
+// tool message with content. It returns ErrToolCallNotFound if no such call
+// is pending.
+func (s *MessageState) AddToolResult(callID, content string) error {
+	for _, call := range s.PendingToolCalls() {
+		if call.ID != callID {
+			continue
+		}
+		var name string
+		if call.FunctionCall != nil {
+			name = call.FunctionCall.Name
+		}
+		msg := NewMessage(llms.MessageContent{
+			Role:  llms.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: callID, Name: name, Content: content}},
+		})
+		s.setMessages(append(s.Messages, s.redact(msg.WithMetadata(MetadataToolCallID, callID))))
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrToolCallNotFound, callID)
+}
+
+// ValidateToolCalls checks that every tool call has a result and every tool
+// result answers a preceding call, which providers require of the history
+// sent to a model.
+func (s *MessageState) ValidateToolCalls() error {
+	pending, unexpected := s.scanToolCalls()
+	if unexpected != "" {
+		return fmt.Errorf("%w: %s", ErrUnexpectedToolResult, unexpected)
+	}
+	if len(pending) > 0 {
+		ids := make([]string, len(pending))
+		for i, call := range pending {
+			ids[i] = call.ID
+		}
+		return fmt.Errorf("%w: %s", ErrMissingToolResults, strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+// scanToolCalls returns the tool calls without results, and the ID of the
+// first tool result that answers no preceding call, if any.
+func (s *MessageState) scanToolCalls() (pending []llms.ToolCall, unexpected string) {
+	for _, msg := range s.Messages {
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case llms.ToolCall:
+				pending = append(pending, p)
+			case llms.ToolCallResponse:
+				i := indexOfToolCall(pending, p.ToolCallID)
+				if i < 0 {
+					if unexpected == "" {
+						unexpected = p.ToolCallID
+					}
+					continue
+				}
+				pending = append(pending[:i], pending[i+1:]...)
+			}
+		}
+	}
+	return pending, unexpected
+}
+
+func indexOfToolCall(calls []llms.ToolCall, id string) int {
+	for i, call := range calls {
+		if call.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package graph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func aiToolCalls(ids ...string) llms.MessageContent {
+	msg := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+	for _, id := range ids {
+		msg.Parts = append(msg.Parts, llms.ToolCall{ID: id, Type: "function", FunctionCall: &llms.FunctionCall{Name: "tool-" + id}})
+	}
+	return msg
+}
+
+func TestToolCallLifecycle(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+	state.AddMessage(aiToolCalls("a", "b"))
+
+	if err := state.ValidateToolCalls(); !errors.Is(err, graph.ErrMissingToolResults) || err.Error() != "tool calls without results: a, b" {
+		t.Errorf("expected missing results for a and b, but got %v", err)
+	}
+	if err := state.AddToolResult("c", "nope"); !errors.Is(err, graph.ErrToolCallNotFound) {
+		t.Errorf("expected error %v, but got %v", graph.ErrToolCallNotFound, err)
+	}
+
+	if err := state.AddToolResult("b", "result b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pending := state.PendingToolCalls(); len(pending) != 1 || pending[0].ID != "a" {
+		t.Errorf("expected call a to be pending, but got %v", pending)
+	}
+	last := state.LastMessage()
+	if resp, ok := last.Parts[0].(llms.ToolCallResponse); !ok || resp.Name != "tool-b" || resp.Content != "result b" || last.ToolCallID() != "b" {
+		t.Errorf("unexpected tool message %+v", last)
+	}
+	if err := state.AddToolResult("b", "again"); !errors.Is(err, graph.ErrToolCallNotFound) {
+		t.Errorf("expected error %v, but got %v", graph.ErrToolCallNotFound, err)
+	}
+
+	if err := state.AddToolResult("a", "result a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := state.ValidateToolCalls(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateToolCallsUnexpectedResult(t *testing.T) {
+	t.Parallel()
+
+	state := graph.NewMessageState()
+	state.AddMessage(llms.MessageContent{
+		Role:  llms.ChatMessageTypeTool,
+		Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "x", Content: "orphan"}},
+	})
+	state.AddMessage(aiToolCalls("x"))
+	if err := state.ValidateToolCalls(); !errors.Is(err, graph.ErrUnexpectedToolResult) {
+		t.Errorf("expected error %v, but got %v", graph.ErrUnexpectedToolResult, err)
+	}
+}
@@ -0,0 +1,43 @@
+package trigger
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSource is a Source delivering the messages of NATS subjects. The key of
+// a message is its subject, so that subscribing to "orders.*" keys messages
+// by order.
+//
+// Core NATS does not acknowledge messages: those for which handle fails are
+// not delivered again.
+type NATSSource struct {
+	conn    *nats.Conn
+	subject string
+	queue   string
+}
+
+// NewNATSSource returns a NATSSource subscribing to subject, which may have
+// wildcards. Subscribers in the same queue group, if queue is not empty,
+// share the messages, each message being delivered to one of them.
+func NewNATSSource(conn *nats.Conn, subject, queue string) *NATSSource {
+	return &NATSSource{conn: conn, subject: subject, queue: queue}
+}
+
+// Subscribe calls handle with the messages of the subject until ctx is done
+// or the connection is closed.
+func (s *NATSSource) Subscribe(ctx context.Context, handle func(context.Context, Message) error) error {
+	sub, err := s.conn.QueueSubscribeSync(s.subject, s.queue)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		msg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		_ = handle(ctx, Message{Topic: msg.Subject, Key: msg.Subject, Data: msg.Data})
+	}
+}
@@ -0,0 +1,57 @@
+package trigger_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph/trigger"
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+)
+
+func TestNATSSource(t *testing.T) {
+	t.Parallel()
+
+	opts := natstest.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	srv := natstest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	runs := make(chan orderState, 100)
+	tr := trigger.New(newRunnable(t, runs), trigger.NewNATSSource(conn, "orders.*", "agents"), trigger.WithThreadPerKey[orderState]())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- tr.Run(ctx) }()
+
+	// Messages published before the subscription are not delivered.
+	deadline := time.After(5 * time.Second)
+	var got orderState
+	for got.Events == nil {
+		if err := conn.Publish("orders.1", []byte(`{"events": ["created"]}`)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		select {
+		case got = <-runs:
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("expected a run, but got none")
+		}
+	}
+	if want := []string{"created", "recorded"}; !slices.Equal(got.Events, want) {
+		t.Errorf("expected events %q, but got %q", want, got.Events)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, but got %v", context.Canceled, err)
+	}
+}
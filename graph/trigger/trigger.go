@@ -0,0 +1,184 @@
+// Package trigger starts runs of compiled graphs from the messages of topics,
+// such as NATS subjects, turning graphs into stream processors:
+//
+//	source := trigger.NewNATSSource(conn, "orders.*", "agents")
+//	t := trigger.New(runnable, source, trigger.WithThreadPerKey[State]())
+//	err := t.Run(ctx)
+//
+// Every message is decoded into the input state of a run, from JSON by
+// default. Runs get a thread of their own, or the thread of the key of their
+// message with WithThreadPerKey, so that the runs of a key share their state.
+// Messages are handled one at a time, in the order of their source.
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+)
+
+// Message is a message of a topic.
+type Message struct {
+	// Topic is the topic the message was published to.
+	Topic string
+
+	// Key identifies the entity the message is about, such as an order, if
+	// the source has keys.
+	Key string
+
+	// Data is the payload of the message.
+	Data []byte
+}
+
+// Source delivers the messages of topics.
+type Source interface {
+	// Subscribe calls handle with the messages of the source until ctx is
+	// done, returning its error, or the source fails. Sources that
+	// acknowledge messages deliver again those for which handle returns an
+	// error.
+	Subscribe(ctx context.Context, handle func(context.Context, Message) error) error
+}
+
+// Option configures a Trigger.
+type Option[T any] func(*Trigger[T])
+
+// WithDecoder decodes messages into the input states of runs with decode,
+// instead of decoding their data from JSON.
+func WithDecoder[T any](decode func(Message) (T, error)) Option[T] {
+	return func(t *Trigger[T]) {
+		t.decode = decode
+	}
+}
+
+// WithThreadPerKey runs the messages of a key on the thread named by the key,
+// their input being merged into the latest state of the thread as by
+// graph.Runnable.UpdateState. Messages without a key get a thread of their
+// own.
+func WithThreadPerKey[T any]() Option[T] {
+	return func(t *Trigger[T]) {
+		t.threadPerKey = true
+	}
+}
+
+// WithErrorHandler calls handle with the messages that could not be decoded
+// or whose run failed, which are otherwise dropped.
+func WithErrorHandler[T any](handle func(Message, error)) Option[T] {
+	return func(t *Trigger[T]) {
+		t.onError = handle
+	}
+}
+
+// WithInvokeOptions invokes the runs with opts, such as graph.WithEntryPoint.
+func WithInvokeOptions[T any](opts ...graph.InvokeOption) Option[T] {
+	return func(t *Trigger[T]) {
+		t.invokeOpts = append(t.invokeOpts, opts...)
+	}
+}
+
+// Trigger starts a run of a graph for every message of a source.
+type Trigger[T any] struct {
+	runnable     *graph.Runnable[T]
+	source       Source
+	decode       func(Message) (T, error)
+	threadPerKey bool
+	onError      func(Message, error)
+	invokeOpts   []graph.InvokeOption
+}
+
+// New returns a Trigger running runnable for the messages of source.
+func New[T any](runnable *graph.Runnable[T], source Source, opts ...Option[T]) *Trigger[T] {
+	t := &Trigger[T]{runnable: runnable, source: source, decode: decodeJSON[T]}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func decodeJSON[T any](msg Message) (T, error) {
+	var state T
+	err := json.Unmarshal(msg.Data, &state)
+	return state, err
+}
+
+// Run runs the graph for the messages of the source until ctx is done,
+// returning its error, or the source fails.
+func (t *Trigger[T]) Run(ctx context.Context) error {
+	return t.source.Subscribe(ctx, t.handle)
+}
+
+// handle runs the graph for msg. Messages that cannot be decoded are
+// dropped, as delivering them again does not help; runs that fail are
+// reported to the source.
+func (t *Trigger[T]) handle(ctx context.Context, msg Message) error {
+	input, err := t.decode(msg)
+	if err != nil {
+		t.reportError(msg, fmt.Errorf("decode message: %w", err))
+		return nil
+	}
+	threadID := msg.Key
+	if !t.threadPerKey || threadID == "" {
+		threadID = uuid.NewString()
+	}
+	state, err := t.initialState(ctx, threadID, input)
+	if err == nil {
+		err = t.runnable.Invoke(ctx, &state, append(t.invokeOpts, graph.WithThreadID(threadID))...)
+	}
+	var gi *graph.GraphInterrupt
+	if err != nil && !errors.As(err, &gi) {
+		err = fmt.Errorf("run on thread %s: %w", threadID, err)
+		t.reportError(msg, err)
+		return err
+	}
+	return nil
+}
+
+// initialState returns the state a run on a thread starts with: input,
+// merged into the latest state of the thread if it has one.
+func (t *Trigger[T]) initialState(ctx context.Context, threadID string, input T) (T, error) {
+	if !t.threadPerKey || t.runnable.Checkpointer() == nil {
+		return input, nil
+	}
+	cp, err := t.runnable.GetState(ctx, threadID)
+	if errors.Is(err, graph.ErrCheckpointNotFound) {
+		return input, nil
+	}
+	if err != nil {
+		return input, err
+	}
+	state := cp.State
+	if r, ok := any(&state).(graph.Reducer[T]); ok {
+		err = r.Reduce(input)
+		return state, err
+	}
+	return input, nil
+}
+
+func (t *Trigger[T]) reportError(msg Message, err error) {
+	if t.onError != nil {
+		t.onError(msg, err)
+	}
+}
+
+// ChannelSource is a Source delivering the messages sent on a channel, such
+// as by another part of the program, until the channel is closed.
+type ChannelSource <-chan Message
+
+// Subscribe calls handle with the messages of the channel until ctx is done
+// or the channel is closed, then returning nil.
+func (c ChannelSource) Subscribe(ctx context.Context, handle func(context.Context, Message) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-c:
+			if !ok {
+				return nil
+			}
+			_ = handle(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package trigger_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/trigger"
+)
+
+// orderState collects the events of an order, merging the events of the
+// messages of the order.
+type orderState struct {
+	Events []string `json:"events"`
+}
+
+func (s *orderState) Reduce(update orderState) error {
+	s.Events = append(s.Events, update.Events...)
+	return nil
+}
+
+// newRunnable compiles a graph recording every run in its state, and failing
+// on the event "fail". The states of the runs are sent on runs.
+func newRunnable(t *testing.T, runs chan<- orderState) *graph.Runnable[orderState] {
+	t.Helper()
+
+	g := graph.NewStateGraph[orderState]()
+	g.AddNode("record", func(_ context.Context, s *orderState) error {
+		if slices.Contains(s.Events, "fail") {
+			return errors.New("boom")
+		}
+		s.Events = append(s.Events, "recorded")
+		runs <- *s
+		return nil
+	})
+	g.AddEdge("record", graph.END)
+	g.SetEntryPoint("record")
+	runnable, err := g.Compile(graph.WithCheckpointer[orderState](graph.NewMemorySaver[orderState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	return runnable
+}
+
+func TestTrigger(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		opts []trigger.Option[orderState]
+		want [][]string
+	}{
+		{
+			name: "Thread per message",
+			want: [][]string{{"created", "recorded"}, {"paid", "recorded"}, {"other", "recorded"}},
+		},
+		{
+			name: "Thread per key",
+			opts: []trigger.Option[orderState]{trigger.WithThreadPerKey[orderState]()},
+			want: [][]string{{"created", "recorded"}, {"created", "recorded", "paid", "recorded"}, {"other", "recorded"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			msgs := make(chan trigger.Message, 5)
+			msgs <- trigger.Message{Topic: "orders", Key: "1", Data: []byte(`{"events": ["created"]}`)}
+			msgs <- trigger.Message{Topic: "orders", Key: "1", Data: []byte(`{"events": ["paid"]}`)}
+			msgs <- trigger.Message{Topic: "orders", Key: "2", Data: []byte(`not json`)}
+			msgs <- trigger.Message{Topic: "orders", Key: "2", Data: []byte(`{"events": ["fail"]}`)}
+			msgs <- trigger.Message{Topic: "orders", Key: "2", Data: []byte(`{"events": ["other"]}`)}
+			close(msgs)
+
+			runs := make(chan orderState, 5)
+			var mu sync.Mutex
+			var errs []string
+			opts := append(tc.opts, trigger.WithErrorHandler[orderState](func(msg trigger.Message, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err.Error())
+			}))
+			tr := trigger.New(newRunnable(t, runs), trigger.ChannelSource(msgs), opts...)
+			if err := tr.Run(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			close(runs)
+
+			var got [][]string
+			for run := range runs {
+				got = append(got, run.Events)
+			}
+			if !slices.EqualFunc(got, tc.want, slices.Equal[[]string]) {
+				t.Errorf("expected runs %q, but got %q", tc.want, got)
+			}
+			if len(errs) != 2 || !strings.HasPrefix(errs[0], "decode message") || !strings.Contains(errs[1], "boom") {
+				t.Errorf("expected a decode error and a run error, but got %q", errs)
+			}
+		})
+	}
+}
+
+func TestTriggerDecoder(t *testing.T) {
+	t.Parallel()
+
+	msgs := make(chan trigger.Message, 1)
+	msgs <- trigger.Message{Topic: "orders", Data: []byte("created")}
+	close(msgs)
+
+	runs := make(chan orderState, 1)
+	decode := func(msg trigger.Message) (orderState, error) {
+		return orderState{Events: []string{msg.Topic + ": " + string(msg.Data)}}, nil
+	}
+	tr := trigger.New(newRunnable(t, runs), trigger.ChannelSource(msgs), trigger.WithDecoder(decode))
+	if err := tr.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := (<-runs).Events, []string{"orders: created", "recorded"}; !slices.Equal(got, want) {
+		t.Errorf("expected events %q, but got %q", want, got)
+	}
+}
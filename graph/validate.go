@@ -0,0 +1,253 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrUnreachableNode is returned when a node cannot be reached from the
+	// entry point.
+	ErrUnreachableNode = errors.New("node unreachable from entry point")
+
+	// ErrNoPathToEnd is returned when no path leads from the entry point to END.
+	ErrNoPathToEnd = errors.New("no path from entry point to END")
+
+	// ErrCycle is returned when the edges of a graph form a cycle that is not
+	// allowed with WithAllowedCycle.
+	ErrCycle = errors.New("graph contains a cycle")
+)
+
+// Validate checks the structure of the graph: the entry point is set, every
+// edge connects registered nodes or END, the routes listed with WithLabels
+// are mapped, every node is reachable from the entry points, see
+// SetNamedEntryPoint, and END can be reached. It returns all the problems found,
+// joined with errors.Join.
+//
+// Conditional edges are followed to the nodes given to WithMap; without a map
+// they are assumed to lead to any node.
+func (g *StateGraph[T]) Validate() error {
+	return g.validate(false)
+}
+
+// validate implements Validate. With implicitEnd, nodes without outgoing
+// edges lead to END, see WithImplicitEnd.
+func (g *StateGraph[T]) validate(implicitEnd bool) error {
+	if g.entryPoint == "" {
+		return ErrEntryPointNotSet
+	}
+
+	var errs []error
+	known := func(name string) bool {
+		_, ok := g.nodes[name]
+		return ok
+	}
+	if !known(g.entryPoint) {
+		errs = append(errs, fmt.Errorf("%w: entry point %s", ErrNodeNotFound, g.entryPoint))
+	}
+	// entries are the nodes runs may start from.
+	entries := []string{g.entryPoint}
+	for _, name := range slices.Sorted(maps.Keys(g.entryPoints)) {
+		node := g.entryPoints[name]
+		if !known(node) {
+			errs = append(errs, fmt.Errorf("%w: entry point %s at %s", ErrNodeNotFound, name, node))
+			continue
+		}
+		entries = append(entries, node)
+	}
+
+	// successors maps nodes to the nodes their edges lead to; dynamic holds
+	// the nodes with edges that may lead anywhere.
+	successors := make(map[string][]string)
+	dynamic := make(map[string]bool)
+	for _, edge := range g.edges {
+		from := edge.From()
+		if !known(from) {
+			errs = append(errs, fmt.Errorf("%w: edge from %s", ErrNodeNotFound, from))
+		}
+		targets, ok := edgeTargets(edge)
+		if !ok {
+			dynamic[from] = true
+		}
+		for _, to := range targets {
+			if to != END && !known(to) {
+				errs = append(errs, fmt.Errorf("%w: edge from %s to %s", ErrNodeNotFound, from, to))
+			}
+		}
+		if branch, ok := edge.(*Branch[T]); ok && branch.Routes != nil && branch.Default == "" {
+			for _, label := range branch.Labels {
+				if _, ok := branch.Routes[label]; !ok {
+					errs = append(errs, fmt.Errorf("%w: %s from %s", ErrMissingRoute, label, from))
+				}
+			}
+		}
+		successors[from] = append(successors[from], targets...)
+	}
+	if implicitEnd {
+		for name := range g.nodes {
+			if _, ok := successors[name]; !ok && !dynamic[name] {
+				successors[name] = []string{END}
+			}
+		}
+	}
+
+	if known(g.entryPoint) {
+		reached := make(map[string]bool, len(entries))
+		for _, node := range entries {
+			reached[node] = true
+		}
+		queue := entries
+		anywhere := false
+		for len(queue) > 0 && !anywhere {
+			node := queue[0]
+			queue = queue[1:]
+			anywhere = dynamic[node]
+			for _, to := range successors[node] {
+				if !reached[to] {
+					reached[to] = true
+					queue = append(queue, to)
+				}
+			}
+		}
+		if !anywhere {
+			var unreachable []string
+			for name := range g.nodes {
+				if !reached[name] {
+					unreachable = append(unreachable, name)
+				}
+			}
+			slices.Sort(unreachable)
+			for _, name := range unreachable {
+				errs = append(errs, fmt.Errorf("%w: %s", ErrUnreachableNode, name))
+			}
+			if !reached[END] {
+				errs = append(errs, ErrNoPathToEnd)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// edgeTargets returns the nodes edge may lead to. It reports false if it may
+// also lead to nodes that cannot be known before running the graph.
+func edgeTargets[T any](edge Edge[T]) ([]string, bool) {
+	switch e := edge.(type) {
+	case *SimpleEdge[T]:
+		return []string{e.to}, true
+	case *Branch[T]:
+		var targets []string
+		for _, to := range append(slices.Clone(e.Targets), e.Then, e.Default) {
+			if to != "" {
+				targets = append(targets, to)
+			}
+		}
+		return targets, e.Targets != nil
+	default:
+		return nil, false
+	}
+}
+
+// checkCycles returns an ErrCycle for every group of nodes whose edges form a
+// cycle that is not allowed. An edge within a cycle is allowed when both its
+// nodes belong to one of the allowed cycles.
+//
+// Conditional edges without a map of their targets cannot be checked and
+// are ignored.
+func (g *StateGraph[T]) checkCycles(allowed [][]string) error {
+	successors := make(map[string][]string)
+	for _, edge := range g.edges {
+		targets, _ := edgeTargets(edge)
+		for _, to := range targets {
+			if _, ok := g.nodes[to]; ok {
+				successors[edge.From()] = append(successors[edge.From()], to)
+			}
+		}
+	}
+	isAllowed := func(from, to string) bool {
+		for _, cycle := range allowed {
+			if slices.Contains(cycle, from) && slices.Contains(cycle, to) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var errs []error
+	for _, component := range stronglyConnected(g.nodes, successors) {
+		inComponent := make(map[string]bool, len(component))
+		for _, name := range component {
+			inComponent[name] = true
+		}
+		for _, from := range component {
+			disallowed := slices.ContainsFunc(successors[from], func(to string) bool {
+				return inComponent[to] && !isAllowed(from, to)
+			})
+			if disallowed {
+				slices.Sort(component)
+				errs = append(errs, fmt.Errorf("%w: %s (see WithAllowedCycle)", ErrCycle, strings.Join(component, ", ")))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stronglyConnected returns the strongly connected components of the graph
+// made of nodes and successors that hold a cycle, with Tarjan's algorithm.
+func stronglyConnected[T any](nodes map[string]Node[T], successors map[string][]string) [][]string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		low[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfLoop := false
+		for _, to := range successors[name] {
+			if to == name {
+				selfLoop = true
+			}
+			if _, seen := index[to]; !seen {
+				visit(to)
+				low[name] = min(low[name], low[to])
+			} else if onStack[to] {
+				low[name] = min(low[name], index[to])
+			}
+		}
+
+		if low[name] != index[name] {
+			return
+		}
+		i := slices.Index(stack, name)
+		component := slices.Clone(stack[i:])
+		stack = stack[:i]
+		for _, n := range component {
+			onStack[n] = false
+		}
+		if len(component) > 1 || selfLoop {
+			components = append(components, component)
+		}
+	}
+	for _, name := range names {
+		if _, seen := index[name]; !seen {
+			visit(name)
+		}
+	}
+	return components
+}
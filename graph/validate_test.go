@@ -0,0 +1,234 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alberrttt/langgraphgo/graph"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	route := func(context.Context, *int) ([]string, error) { return []string{"done"}, nil }
+
+	testCases := []struct {
+		name  string
+		build func(b *graph.Builder[int])
+		want  []string
+	}{
+		{
+			name: "Valid loop",
+			build: func(b *graph.Builder[int]) {
+				b.AddNode("agent", noop)
+				b.AddNode("tools", noop)
+				b.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"tools": "tools", "done": graph.END}))
+				b.AddEdge("tools", "agent")
+				b.SetEntryPoint("agent")
+			},
+		},
+		{
+			name: "Unmapped branch leads anywhere",
+			build: func(b *graph.Builder[int]) {
+				b.AddNode("agent", noop)
+				b.AddNode("tools", noop)
+				b.AddConditionalEdges("agent", route)
+				b.SetEntryPoint("agent")
+			},
+		},
+		{
+			name: "Unknown entry point",
+			build: func(b *graph.Builder[int]) {
+				b.AddNode("agent", noop)
+				b.AddEdge("agent", graph.END)
+				b.SetEntryPoint("start")
+			},
+			want: []string{"node not found: entry point start"},
+		},
+		{
+			name: "Dangling edges",
+			build: func(b *graph.Builder[int]) {
+				b.AddNode("agent", noop)
+				b.AddEdge("agent", graph.END)
+				b.AddEdge("ghost", "agent")
+				b.AddConditionalEdges("agent", route, graph.WithMap[int](map[string]string{"done": "finish"}))
+				b.SetEntryPoint("agent")
+			},
+			want: []string{
+				"node not found: edge from ghost",
+				"node not found: edge from agent to finish",
+			},
+		},
+		{
+			name: "Unmapped labels",
+			build: func(b *graph.Builder[int]) {
+				b.AddNode("agent", noop)
+				b.AddNode("tools", noop)
+				b.AddConditionalEdges("agent", route,
+					graph.WithMap[int](map[string]string{"tools": "tools", "done": graph.END}),
+					graph.WithLabels[int]("tools", "review", "done", "retry"))
+				b.AddEdge("tools", "agent")
+				b.SetEntryPoint("agent")
+			},
+			want: []string{
+				"route without a node: review from agent",
+				"route without a node: retry from agent",
+			},
+		},
+		{
+			name: "Unmapped labels with default",
+			build: func(b *graph.Builder[int]) {
+				b.AddNode("agent", noop)
+				b.AddNode("tools", noop)
+				b.AddConditionalEdges("agent", route,
+					graph.WithMap[int](map[string]string{"tools": "tools"}),
+					graph.WithLabels[int]("tools", "done"),
+					graph.WithDefault[int](graph.END))
+				b.AddEdge("tools", "agent")
+				b.SetEntryPoint("agent")
+			},
+		},
+		{
+			name: "Unreachable nodes and no END",
+			build: func(b *graph.Builder[int]) {
+				b.AddNode("agent", noop)
+				b.AddNode("tools", noop)
+				b.AddNode("orphan", noop)
+				b.AddNode("island", noop)
+				b.AddEdge("agent", "tools")
+				b.AddEdge("tools", "agent")
+				b.AddEdge("orphan", graph.END)
+				b.SetEntryPoint("agent")
+			},
+			want: []string{
+				"node unreachable from entry point: island",
+				"node unreachable from entry point: orphan",
+				"no path from entry point to END",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// The builder allows references to missing nodes, which Build
+			// reports from Validate.
+			b := graph.NewBuilder[int]()
+			tc.build(b)
+			g, err := b.Build()
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected errors %q, but got %q", tc.want, got)
+			}
+			if g == nil {
+				return
+			}
+			if err := g.Validate(); err != nil {
+				t.Errorf("expected valid graph, but got %v", err)
+			}
+			if _, err := g.Compile(graph.WithAllowedCycle("agent", "tools")); err != nil {
+				t.Errorf("unexpected compile error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateErrorsMatch(t *testing.T) {
+	t.Parallel()
+
+	registry := graph.NewNodeRegistry[int]()
+	registry.RegisterFunction("noop", func(context.Context, *int) error { return nil })
+	g, err := graph.BuildGraph(graph.GraphDefinition{
+		EntryPoint: "agent",
+		Nodes:      []graph.NodeDefinition{{Name: "agent", Function: "noop"}, {Name: "orphan", Function: "noop"}},
+		Edges:      []graph.EdgeDefinition{{From: "agent", To: "tools"}},
+	}, registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = g.Compile()
+	for _, target := range []error{graph.ErrNodeNotFound, graph.ErrUnreachableNode, graph.ErrNoPathToEnd} {
+		if !errors.Is(err, target) {
+			t.Errorf("expected error %v, but got %v", target, err)
+		}
+	}
+}
+
+func TestCycles(t *testing.T) {
+	t.Parallel()
+
+	noop := func(context.Context, *int) error { return nil }
+	done := func(context.Context, *int) ([]string, error) { return []string{"done"}, nil }
+
+	// The graph has an agent calling tools or asking a human until done, and
+	// a reviewer looping on itself.
+	build := func() *graph.StateGraph[int] {
+		g := graph.NewStateGraph[int]()
+		for _, name := range []string{"agent", "tools", "human", "review"} {
+			g.AddNode(name, noop)
+		}
+		g.AddConditionalEdges("agent", done, graph.WithMap[int](map[string]string{
+			"tools": "tools",
+			"human": "human",
+			"done":  "review",
+		}))
+		g.AddEdge("tools", "agent")
+		g.AddEdge("human", "agent")
+		g.AddConditionalEdges("review", done, graph.WithMap[int](map[string]string{"again": "review", "done": graph.END}))
+		g.SetEntryPoint("agent")
+		return g
+	}
+
+	testCases := []struct {
+		name    string
+		allowed [][]string
+		want    []string
+	}{
+		{
+			name: "No cycle allowed",
+			want: []string{
+				"graph contains a cycle: agent, human, tools (see WithAllowedCycle)",
+				"graph contains a cycle: review (see WithAllowedCycle)",
+			},
+		},
+		{
+			name:    "Some cycles allowed",
+			allowed: [][]string{{"agent", "tools"}, {"review"}},
+			want:    []string{"graph contains a cycle: agent, human, tools (see WithAllowedCycle)"},
+		},
+		{
+			name:    "All cycles allowed",
+			allowed: [][]string{{"agent", "tools"}, {"agent", "human"}, {"review"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var opts []graph.CompileOption
+			for _, cycle := range tc.allowed {
+				opts = append(opts, graph.WithAllowedCycle(cycle...))
+			}
+			_, err := build().Compile(opts...)
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected errors %q, but got %q", tc.want, got)
+			}
+			if tc.want != nil && !errors.Is(err, graph.ErrCycle) {
+				t.Errorf("expected error %v, but got %v", graph.ErrCycle, err)
+			}
+		})
+	}
+}
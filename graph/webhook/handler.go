@@ -0,0 +1,259 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/google/uuid"
+)
+
+// maxBodySize is the size of the largest payload a Handler accepts.
+const maxBodySize = 1 << 20
+
+// Defaults of the options of a Handler.
+const (
+	DefaultTolerance = 5 * time.Minute
+	DefaultMaxRuns   = 64
+)
+
+// Option configures a Handler.
+type Option[T any] func(*Handler[T])
+
+// WithSecret rejects the requests that are not signed with secret, stale or
+// already delivered, see SignRequest.
+func WithSecret[T any](secret []byte) Option[T] {
+	return func(h *Handler[T]) {
+		h.secret = secret
+	}
+}
+
+// WithTolerance sets how far the timestamp of a signed request may be from
+// the time it is received, DefaultTolerance by default. Signatures are
+// remembered for that long to reject repeated deliveries.
+func WithTolerance[T any](d time.Duration) Option[T] {
+	return func(h *Handler[T]) {
+		h.tolerance = d
+	}
+}
+
+// WithMaxRuns caps the runs in flight at n, DefaultMaxRuns by default.
+// Requests beyond it are rejected with 503 Service Unavailable, to be
+// delivered again later.
+func WithMaxRuns[T any](n int) Option[T] {
+	return func(h *Handler[T]) {
+		h.maxRuns = n
+	}
+}
+
+// WithCompletionWebhook sends an Event to sender when a run ends.
+func WithCompletionWebhook[T any](sender *Sender) Option[T] {
+	return func(h *Handler[T]) {
+		h.senders = append(h.senders, sender)
+	}
+}
+
+// WithProjection sends the value project returns for the final state of runs
+// in events, such as a few of its fields, instead of the whole state.
+func WithProjection[T any](project func(T) any) Option[T] {
+	return func(h *Handler[T]) {
+		h.project = project
+	}
+}
+
+// WithErrorHandler calls handle with the events that could not be sent,
+// which are otherwise dropped.
+func WithErrorHandler[T any](handle func(Event, error)) Option[T] {
+	return func(h *Handler[T]) {
+		h.onError = handle
+	}
+}
+
+// WithInvokeOptions invokes the runs with opts, such as graph.WithEntryPoint.
+func WithInvokeOptions[T any](opts ...graph.InvokeOption) Option[T] {
+	return func(h *Handler[T]) {
+		h.invokeOpts = append(h.invokeOpts, opts...)
+	}
+}
+
+// Handler is an inbound webhook starting a run of a graph for every POST
+// request, its JSON payload being the input state of the run.
+//
+// The run is started on the thread given by the thread_id query parameter,
+// or a new thread, and the handler responds with 202 Accepted and the IDs of
+// the run and its thread as JSON: {"run_id": ..., "thread_id": ...}.
+//
+// With WithSecret, requests must be signed with SignRequest, which covers
+// their timestamp and thread as well as their payload, so that a captured
+// request cannot be delivered again nor aimed at another thread.
+type Handler[T any] struct {
+	runnable   *graph.Runnable[T]
+	secret     []byte
+	tolerance  time.Duration
+	maxRuns    int
+	senders    []*Sender
+	project    func(T) any
+	invokeOpts []graph.InvokeOption
+	onError    func(Event, error)
+	runs       sync.WaitGroup
+
+	// slots holds a value per run in flight.
+	slots chan struct{}
+
+	mu sync.Mutex
+	// seen holds the signatures of the requests delivered within the
+	// tolerance, with the time they can be forgotten.
+	seen map[string]time.Time
+}
+
+// NewHandler returns a Handler running runnable.
+func NewHandler[T any](runnable *graph.Runnable[T], opts ...Option[T]) *Handler[T] {
+	h := &Handler[T]{runnable: runnable, tolerance: DefaultTolerance, maxRuns: DefaultMaxRuns, seen: map[string]time.Time{}}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.slots = make(chan struct{}, max(h.maxRuns, 1))
+	return h
+}
+
+func (h *Handler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	threadID := r.URL.Query().Get("thread_id")
+	if h.secret != nil {
+		if status, err := h.verify(r, threadID, body); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+	var state T
+	if err := json.Unmarshal(body, &state); err != nil {
+		http.Error(w, "invalid payload: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	select {
+	case h.slots <- struct{}{}:
+	default:
+		if h.secret != nil {
+			// The request may be delivered again.
+			h.mu.Lock()
+			delete(h.seen, r.Header.Get(SignatureHeader))
+			h.mu.Unlock()
+		}
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many runs in flight", http.StatusServiceUnavailable)
+		return
+	}
+	runID := uuid.NewString()
+	if threadID == "" {
+		threadID = uuid.NewString()
+	}
+	h.runs.Add(1)
+	go func() {
+		defer h.runs.Done()
+		defer func() { <-h.slots }()
+		h.run(context.WithoutCancel(r.Context()), runID, threadID, state)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"run_id": runID, "thread_id": threadID})
+}
+
+// verify checks the signature and timestamp of r, whose thread is threadID
+// and payload body, and that it was not delivered before, returning the
+// status to reject it with otherwise.
+func (h *Handler[T]) verify(r *http.Request, threadID string, body []byte) (int, error) {
+	signature := r.Header.Get(SignatureHeader)
+	unix, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return http.StatusUnauthorized, errors.New("invalid timestamp")
+	}
+	timestamp := time.Unix(unix, 0)
+	if !VerifyRequest(h.secret, timestamp, threadID, body, signature) {
+		return http.StatusUnauthorized, errors.New("invalid signature")
+	}
+	now := time.Now()
+	if timestamp.Before(now.Add(-h.tolerance)) || timestamp.After(now.Add(h.tolerance)) {
+		return http.StatusUnauthorized, errors.New("stale timestamp")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sig, forget := range h.seen {
+		if now.After(forget) {
+			delete(h.seen, sig)
+		}
+	}
+	if _, ok := h.seen[signature]; ok {
+		return http.StatusConflict, errors.New("already delivered")
+	}
+	h.seen[signature] = timestamp.Add(h.tolerance)
+	return 0, nil
+}
+
+// Wait waits for the runs started by the handler to end and their events to
+// be sent.
+func (h *Handler[T]) Wait() {
+	h.runs.Wait()
+}
+
+// run runs the graph and sends the event of its end.
+func (h *Handler[T]) run(ctx context.Context, runID, threadID string, state T) {
+	err := h.runnable.Invoke(ctx, &state, append(h.invokeOpts, graph.WithThreadID(threadID))...)
+	if len(h.senders) == 0 {
+		return
+	}
+
+	event := NewEvent(runID, threadID, state, err, h.project)
+	for _, sender := range h.senders {
+		if err := sender.Send(ctx, event); err != nil && h.onError != nil {
+			h.onError(event, err)
+		}
+	}
+}
+
+// NewEvent returns the event of the end of a run on a thread, whose final
+// state is state, or its projection by project if not nil, and Invoke
+// returned err. It lets runs started otherwise than by a Handler, such as by
+// a trigger or a schedule, be reported with Sender.Send.
+func NewEvent[T any](runID, threadID string, state T, err error, project func(T) any) Event {
+	event := Event{Type: RunCompleted, RunID: runID, ThreadID: threadID, Time: time.Now().UTC()}
+	var gi *graph.GraphInterrupt
+	switch {
+	case errors.As(err, &gi):
+		event.Type = RunInterrupted
+		event.Interrupt = gi.Value
+	case err != nil:
+		event.Type = RunFailed
+		event.Error = err.Error()
+		return event
+	}
+	var value any = state
+	if project != nil {
+		value = project(state)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		event.Type = RunFailed
+		event.Error = "encode state: " + err.Error()
+		return event
+	}
+	event.State = data
+	return event
+}
@@ -0,0 +1,211 @@
+// Package webhook starts runs of compiled graphs from inbound webhooks and
+// reports the end of runs to outbound webhooks:
+//
+//	notify := webhook.NewSender("https://example.com/hooks/runs", webhook.WithSigningSecret(secret))
+//	http.Handle("/hooks/orders", webhook.NewHandler(runnable,
+//		webhook.WithSecret[State](inboundSecret),
+//		webhook.WithCompletionWebhook[State](notify)))
+//
+// Payloads are signed with HMAC-SHA256, the signature being sent in the
+// SignatureHeader as "sha256=" followed by the hex digest. Outbound events
+// are signed with Sign; inbound requests with SignRequest, which also covers
+// their timestamp and thread so that they cannot be replayed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header carrying the signature of a payload.
+const SignatureHeader = "X-Webhook-Signature"
+
+// TimestampHeader is the header carrying the time an inbound request was
+// signed at, in seconds since the Unix epoch, see SignRequest.
+const TimestampHeader = "X-Webhook-Timestamp"
+
+// Sign returns the signature of body with secret, as sent in the
+// SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body with secret.
+func Verify(secret, body []byte, signature string) bool {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// SignRequest returns the signature with secret of an inbound request
+// signed at timestamp, which starts a run on the thread threadID, empty for
+// a new thread, with the payload body. It is sent in the SignatureHeader,
+// with the timestamp in the TimestampHeader. The signed message is the
+// timestamp in seconds since the Unix epoch, a newline, the query escaped
+// thread ID, a newline and the body.
+func SignRequest(secret []byte, timestamp time.Time, threadID string, body []byte) string {
+	return Sign(secret, requestMessage(timestamp, threadID, body))
+}
+
+// VerifyRequest reports whether signature is the signature of an inbound
+// request, see SignRequest.
+func VerifyRequest(secret []byte, timestamp time.Time, threadID string, body []byte, signature string) bool {
+	return Verify(secret, requestMessage(timestamp, threadID, body), signature)
+}
+
+// requestMessage returns the message signed for an inbound request.
+func requestMessage(timestamp time.Time, threadID string, body []byte) []byte {
+	msg := strconv.AppendInt(nil, timestamp.Unix(), 10)
+	msg = append(msg, '\n')
+	msg = append(msg, url.QueryEscape(threadID)...)
+	msg = append(msg, '\n')
+	return append(msg, body...)
+}
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	RunCompleted   EventType = "run.completed"
+	RunFailed      EventType = "run.failed"
+	RunInterrupted EventType = "run.interrupted"
+)
+
+// Event is the payload of outbound webhooks, sent when a run ends.
+type Event struct {
+	Type     EventType `json:"event"`
+	RunID    string    `json:"run_id"`
+	ThreadID string    `json:"thread_id"`
+
+	// State is the final state of the run, or its projection, see
+	// WithProjection. Failed runs have none.
+	State json.RawMessage `json:"state,omitempty"`
+
+	// Interrupt is the value the run was interrupted with.
+	Interrupt any `json:"interrupt,omitempty"`
+
+	// Error is the error the run failed with.
+	Error string `json:"error,omitempty"`
+
+	Time time.Time `json:"time"`
+}
+
+// SenderOption configures a Sender.
+type SenderOption func(*Sender)
+
+// WithSigningSecret signs the payloads with secret.
+func WithSigningSecret(secret []byte) SenderOption {
+	return func(s *Sender) {
+		s.secret = secret
+	}
+}
+
+// WithRetries retries failed deliveries n times, 3 by default. Deliveries
+// fail on network errors and on 429 and 5xx responses.
+func WithRetries(n int) SenderOption {
+	return func(s *Sender) {
+		s.retries = n
+	}
+}
+
+// WithBackoff waits d before the first retry, twice as long before every
+// other, one second by default.
+func WithBackoff(d time.Duration) SenderOption {
+	return func(s *Sender) {
+		s.backoff = d
+	}
+}
+
+// WithHTTPClient sends the payloads with client, http.DefaultClient by
+// default.
+func WithHTTPClient(client *http.Client) SenderOption {
+	return func(s *Sender) {
+		s.client = client
+	}
+}
+
+// Sender posts events to an outbound webhook.
+type Sender struct {
+	url     string
+	secret  []byte
+	retries int
+	backoff time.Duration
+	client  *http.Client
+}
+
+// NewSender returns a Sender posting events to url.
+func NewSender(url string, opts ...SenderOption) *Sender {
+	s := &Sender{url: url, retries: 3, backoff: time.Second, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Send posts event as JSON, retrying failed deliveries.
+func (s *Sender) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.retries {
+			return fmt.Errorf("send %s webhook: %w", event.Type, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post posts body once, reporting whether a failed delivery may be retried.
+func (s *Sender) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != nil {
+		req.Header.Set(SignatureHeader, Sign(s.secret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return false, fmt.Errorf("unexpected status %s", resp.Status)
+}
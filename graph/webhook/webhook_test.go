@@ -0,0 +1,365 @@
+package webhook_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alberrttt/langgraphgo/graph"
+	"github.com/alberrttt/langgraphgo/graph/webhook"
+)
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	secret, body := []byte("secret"), []byte(`{"a": 1}`)
+	signature := webhook.Sign(secret, body)
+	testCases := []struct {
+		name      string
+		secret    []byte
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "Valid", secret: secret, body: body, signature: signature, want: true},
+		{name: "Other body", secret: secret, body: []byte(`{"a": 2}`), signature: signature},
+		{name: "Other secret", secret: []byte("other"), body: body, signature: signature},
+		{name: "No prefix", secret: secret, body: body, signature: strings.TrimPrefix(signature, "sha256=")},
+		{name: "Not hex", secret: secret, body: body, signature: "sha256=zz"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := webhook.Verify(tc.secret, tc.body, tc.signature); got != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// receiver is an outbound webhook failing the first failures deliveries with
+// status 503.
+type receiver struct {
+	t        *testing.T
+	secret   []byte
+	failures int
+
+	mu       sync.Mutex
+	attempts int
+	events   []webhook.Event
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.attempts++
+	if rc.attempts <= rc.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if !webhook.Verify(rc.secret, body, r.Header.Get(webhook.SignatureHeader)) {
+		rc.t.Errorf("expected a signed payload, but got signature %q", r.Header.Get(webhook.SignatureHeader))
+	}
+	var event webhook.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		rc.t.Errorf("unexpected error: %v", err)
+	}
+	rc.events = append(rc.events, event)
+}
+
+func TestSenderRetries(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		failures int
+		wantErr  bool
+	}{
+		{name: "Retried", failures: 2},
+		{name: "Out of retries", failures: 3, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rc := &receiver{t: t, secret: []byte("secret"), failures: tc.failures}
+			ts := httptest.NewServer(rc)
+			t.Cleanup(ts.Close)
+
+			sender := webhook.NewSender(ts.URL, webhook.WithSigningSecret(rc.secret), webhook.WithRetries(2), webhook.WithBackoff(time.Millisecond))
+			err := sender.Send(context.Background(), webhook.Event{Type: webhook.RunCompleted, RunID: "r1"})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, but got %v", tc.wantErr, err)
+			}
+			if rc.attempts != 3 {
+				t.Errorf("expected 3 attempts, but got %d", rc.attempts)
+			}
+		})
+	}
+}
+
+func TestSenderClientError(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(ts.Close)
+
+	err := webhook.NewSender(ts.URL, webhook.WithBackoff(time.Millisecond)).Send(context.Background(), webhook.Event{})
+	if err == nil || attempts != 1 {
+		t.Errorf("expected one failed attempt, but got %d and %v", attempts, err)
+	}
+}
+
+type orderState struct {
+	Order  string `json:"order"`
+	Status string `json:"status"`
+	Notes  string `json:"notes"`
+}
+
+func newHandler(t *testing.T, rc *receiver, opts ...webhook.Option[orderState]) *webhook.Handler[orderState] {
+	t.Helper()
+
+	g := graph.NewStateGraph[orderState]()
+	g.AddNode("process", func(ctx context.Context, s *orderState) error {
+		switch s.Order {
+		case "slow":
+			time.Sleep(50 * time.Millisecond)
+		case "broken":
+			return errors.New("boom")
+		case "review":
+			if _, err := graph.Interrupt(ctx, "approve?"); err != nil {
+				return err
+			}
+		}
+		s.Status = "processed"
+		s.Notes = "internal"
+		return nil
+	})
+	g.AddEdge("process", graph.END)
+	g.SetEntryPoint("process")
+	runnable, err := g.Compile(graph.WithCheckpointer[orderState](graph.NewMemorySaver[orderState]()))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	ts := httptest.NewServer(rc)
+	t.Cleanup(ts.Close)
+	sender := webhook.NewSender(ts.URL, webhook.WithSigningSecret(rc.secret), webhook.WithBackoff(time.Millisecond))
+	return webhook.NewHandler(runnable, append([]webhook.Option[orderState]{
+		webhook.WithSecret[orderState]([]byte("inbound")),
+		webhook.WithCompletionWebhook[orderState](sender),
+		webhook.WithProjection(func(s orderState) any {
+			return map[string]string{"order": s.Order, "status": s.Status}
+		}),
+	}, opts...)...)
+}
+
+// post posts body to the handler served by ts on the thread, signed at
+// timestamp with signature.
+func post(t *testing.T, ts *httptest.Server, body, thread string, timestamp time.Time, signature string) *http.Response {
+	t.Helper()
+	target := ts.URL
+	if thread != "" {
+		target += "?thread_id=" + url.QueryEscape(thread)
+	}
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header.Set(webhook.SignatureHeader, signature)
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// signRequest signs a request to a handler returned by newHandler.
+func signRequest(timestamp time.Time, thread, body string) string {
+	return webhook.SignRequest([]byte("inbound"), timestamp, thread, []byte(body))
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	rc := &receiver{t: t, secret: []byte("outbound"), failures: 1}
+	h := newHandler(t, rc)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	now := time.Now()
+	if resp := post(t, ts, `[]`, "", now, signRequest(now, "", `[]`)); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, but got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+
+	var ids []map[string]string
+	for i, order := range []string{"a", "broken", "review"} {
+		body := `{"order": "` + order + `"}`
+		thread := "t" + string(rune('1'+i))
+		resp := post(t, ts, body, thread, now, signRequest(now, thread, body))
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected status %d, but got %d", http.StatusAccepted, resp.StatusCode)
+		}
+		var got map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, got)
+		h.Wait()
+	}
+
+	if len(rc.events) != 3 {
+		t.Fatalf("expected 3 events, but got %d", len(rc.events))
+	}
+	want := []struct {
+		typ   webhook.EventType
+		state string
+	}{
+		{webhook.RunCompleted, `{"order":"a","status":"processed"}`},
+		{webhook.RunFailed, ``},
+		{webhook.RunInterrupted, `{"order":"review","status":""}`},
+	}
+	for i, event := range rc.events {
+		if event.Type != want[i].typ || string(event.State) != want[i].state {
+			t.Errorf("event %d: expected %s with state %s, but got %s with state %s", i, want[i].typ, want[i].state, event.Type, event.State)
+		}
+		if event.RunID != ids[i]["run_id"] || event.ThreadID != ids[i]["thread_id"] || event.ThreadID != "t"+string(rune('1'+i)) {
+			t.Errorf("event %d: expected run %v, but got %s on %s", i, ids[i], event.RunID, event.ThreadID)
+		}
+	}
+	if !strings.Contains(rc.events[1].Error, "boom") || rc.events[2].Interrupt != "approve?" {
+		t.Errorf("expected the error and interrupt of the runs, but got %+v", rc.events)
+	}
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, but got %d", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+	big := bytes.Repeat([]byte("a"), 2<<20)
+	if resp := post(t, ts, string(big), "", now, ""); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, but got %d", http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	t.Parallel()
+
+	h := newHandler(t, &receiver{t: t, secret: []byte("outbound")})
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	now := time.Now()
+	body := `{"order": "a"}`
+	testCases := []struct {
+		name       string
+		body       string
+		thread     string
+		timestamp  time.Time
+		signature  string
+		wantStatus int
+	}{
+		{
+			name:       "Other body",
+			body:       `{"order": "b"}`,
+			thread:     "t1",
+			timestamp:  now,
+			signature:  signRequest(now, "t1", body),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Other thread",
+			body:       body,
+			thread:     "t2",
+			timestamp:  now,
+			signature:  signRequest(now, "t1", body),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Other timestamp",
+			body:       body,
+			thread:     "t1",
+			timestamp:  now.Add(time.Second),
+			signature:  signRequest(now, "t1", body),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Body signature only",
+			body:       body,
+			thread:     "t1",
+			timestamp:  now,
+			signature:  webhook.Sign([]byte("inbound"), []byte(body)),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Stale",
+			body:       body,
+			thread:     "t1",
+			timestamp:  now.Add(-time.Hour),
+			signature:  signRequest(now.Add(-time.Hour), "t1", body),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if resp := post(t, ts, tc.body, tc.thread, tc.timestamp, tc.signature); resp.StatusCode != tc.wantStatus {
+				t.Errorf("expected status %d, but got %d", tc.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("Repeated", func(t *testing.T) {
+		signature := signRequest(now, "t3", body)
+		if resp := post(t, ts, body, "t3", now, signature); resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected status %d, but got %d", http.StatusAccepted, resp.StatusCode)
+		}
+		if resp := post(t, ts, body, "t3", now, signature); resp.StatusCode != http.StatusConflict {
+			t.Errorf("expected status %d, but got %d", http.StatusConflict, resp.StatusCode)
+		}
+	})
+	h.Wait()
+}
+
+func TestHandlerMaxRuns(t *testing.T) {
+	t.Parallel()
+
+	h := newHandler(t, &receiver{t: t, secret: []byte("outbound")}, webhook.WithMaxRuns[orderState](1))
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	now := time.Now()
+	slow, fast := `{"order": "slow"}`, `{"order": "a"}`
+	if resp := post(t, ts, slow, "t1", now, signRequest(now, "t1", slow)); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected status %d, but got %d", http.StatusAccepted, resp.StatusCode)
+	}
+	resp := post(t, ts, fast, "t2", now, signRequest(now, "t2", fast))
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("expected status %d with Retry-After, but got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	h.Wait()
+	if resp := post(t, ts, fast, "t2", now, signRequest(now, "t2", fast)); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("expected status %d once the run ended, but got %d", http.StatusAccepted, resp.StatusCode)
+	}
+	h.Wait()
+}